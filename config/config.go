@@ -10,14 +10,18 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Logging  LoggingConfig  `json:"logging"`
-	FHIR     FHIRConfig     `json:"fhir"`
-	Redis    RedisConfig    `json:"redis"`
-	Consul   ConsulConfig   `json:"consul"`
-	Vault    VaultConfig    `json:"vault"`
-	Jaeger   JaegerConfig   `json:"jaeger"`
+	Server     ServerConfig     `json:"server"`
+	Database   DatabaseConfig   `json:"database"`
+	Logging    LoggingConfig    `json:"logging"`
+	FHIR       FHIRConfig       `json:"fhir"`
+	Redis      RedisConfig      `json:"redis"`
+	Consul     ConsulConfig     `json:"consul"`
+	Vault      VaultConfig      `json:"vault"`
+	Jaeger     JaegerConfig     `json:"jaeger"`
+	Validation ValidationConfig `json:"validation"`
+	Identifier IdentifierConfig `json:"identifier"`
+	Merge      MergeConfig      `json:"merge"`
+	Bulk       BulkConfig       `json:"bulk"`
 }
 
 type ServerConfig struct {
@@ -47,9 +51,12 @@ type LoggingConfig struct {
 	File   string `json:"file"`
 }
 
+// FHIRConfig describes the FHIR API. IDs selects how logical ids are assigned to new
+// resources: "sequential" numbers or random "uuid"s.
 type FHIRConfig struct {
 	BaseURL string `json:"base_url"`
 	Version string `json:"version"`
+	IDs     string `json:"ids"`
 }
 
 type RedisConfig struct {
@@ -70,6 +77,35 @@ type VaultConfig struct {
 	SecretPath string `json:"secret_path"`
 }
 
+// ValidationConfig selects the StructureDefinition profiles loaded from disk and the
+// canonical URLs of those enforced on every write
+type ValidationConfig struct {
+	Directory string   `json:"directory"`
+	Profiles  []string `json:"profiles"`
+}
+
+// IdentifierConfig lists the identifier systems, e.g. a hospital MRN system, whose values
+// may each be assigned to a single patient only
+type IdentifierConfig struct {
+	UniqueSystems []string `json:"unique_systems" mapstructure:"unique_systems"`
+}
+
+// MergeConfig selects how Patient $merge combines each element of the source patient with
+// the target's: "union", "target" or "source"
+type MergeConfig struct {
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+	Telecom    string `json:"telecom"`
+	Address    string `json:"address"`
+}
+
+// BulkConfig selects the directory bulk data jobs write their NDJSON files to, and the
+// directory of the files an import may name
+type BulkConfig struct {
+	Directory       string `json:"directory"`
+	ImportDirectory string `json:"import_directory" mapstructure:"import_directory"`
+}
+
 type JaegerConfig struct {
 	Endpoint    string `json:"endpoint"`
 	ServiceName string `json:"service_name"`
@@ -101,6 +137,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("logging.file", "logs/app.log")
 	viper.SetDefault("fhir.base_url", "/api/v1")
 	viper.SetDefault("fhir.version", "R4")
+	viper.SetDefault("fhir.ids", "sequential")
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", "6379")
 	viper.SetDefault("redis.password", "")
@@ -114,6 +151,15 @@ func Load() (*Config, error) {
 	viper.SetDefault("jaeger.service_name", "go-fhir-demo")
 	viper.SetDefault("jaeger.environment", "development")
 	viper.SetDefault("jaeger.enabled", true)
+	viper.SetDefault("validation.directory", "profiles")
+	viper.SetDefault("validation.profiles", []string{})
+	viper.SetDefault("identifier.unique_systems", []string{})
+	viper.SetDefault("merge.identifier", "union")
+	viper.SetDefault("merge.name", "union")
+	viper.SetDefault("merge.telecom", "union")
+	viper.SetDefault("merge.address", "union")
+	viper.SetDefault("bulk.directory", "bulk")
+	viper.SetDefault("bulk.import_directory", "import")
 
 	// Bind environment variables
 	_ = viper.BindEnv("server.port", "SERVER_PORT")
@@ -125,6 +171,7 @@ func Load() (*Config, error) {
 	_ = viper.BindEnv("database.name", "DB_NAME")
 	_ = viper.BindEnv("database.sslmode", "DB_SSLMODE")
 	_ = viper.BindEnv("logging.level", "LOG_LEVEL")
+	_ = viper.BindEnv("fhir.ids", "FHIR_IDS")
 	_ = viper.BindEnv("redis.host", "REDIS_HOST")
 	_ = viper.BindEnv("redis.port", "REDIS_PORT")
 	_ = viper.BindEnv("redis.password", "REDIS_PASSWORD")
@@ -138,6 +185,9 @@ func Load() (*Config, error) {
 	_ = viper.BindEnv("jaeger.service_name", "JAEGER_SERVICE_NAME")
 	_ = viper.BindEnv("jaeger.environment", "JAEGER_ENVIRONMENT")
 	_ = viper.BindEnv("jaeger.enabled", "JAEGER_ENABLED")
+	_ = viper.BindEnv("validation.directory", "VALIDATION_DIRECTORY")
+	_ = viper.BindEnv("bulk.directory", "BULK_DIRECTORY")
+	_ = viper.BindEnv("bulk.import_directory", "BULK_IMPORT_DIRECTORY")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
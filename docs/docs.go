@@ -15,214 +15,294 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/consul/secret": {
-            "get": {
-                "description": "Fetches a secret from Consul Key Vault and returns it as JSON",
+        "/": {
+            "post": {
+                "description": "Execute the Patient interactions of a batch or transaction Bundle. Batch entries succeed or fail independently. Transaction entries run in a single database transaction that is rolled back when any entry fails, and urn:uuid fullUrls of created patients are resolved in references between entries.",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Consul"
+                    "Bundle"
+                ],
+                "summary": "Process a batch or transaction Bundle",
+                "parameters": [
+                    {
+                        "description": "FHIR Bundle of type batch or transaction",
+                        "name": "bundle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    }
                 ],
-                "summary": "Get secret from Consul KV",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "A batch-response or transaction-response Bundle",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/api/v1/vault/secret": {
+        "/$bulk-files/{id}/{name}": {
             "get": {
-                "description": "Fetches a secret from Vault Key Vault and returns it as JSON",
+                "description": "Download an NDJSON file listed in the manifest of a completed export.",
                 "produces": [
-                    "application/json"
+                    "application/fhir+ndjson"
                 ],
                 "tags": [
-                    "Vault"
+                    "Bulk Data"
+                ],
+                "summary": "Download a bulk data file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get secret from Vault KV",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/cron/cleanup": {
-            "post": {
-                "description": "Triggers a background cleanup job.",
+        "/$bulk-status/{id}": {
+            "get": {
+                "description": "Poll a bulk data job. A running job answers 202 with its progress in X-Progress; a completed export answers 200 with the manifest of its files; a failed job answers 500 with an OperationOutcome.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Cron"
+                    "Bulk Data"
                 ],
-                "summary": "Trigger a cleanup job",
-                "responses": {
-                    "202": {
-                        "description": "Accepted",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                "summary": "Get the status of a bulk data job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
-                }
-            }
-        },
-        "/cron/sync": {
-            "post": {
-                "description": "Triggers a background data synchronization job.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Cron"
                 ],
-                "summary": "Trigger a data sync job",
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkManifest"
+                        }
+                    },
                     "202": {
-                        "description": "Accepted",
+                        "description": "The job is still running",
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "Seconds to wait before polling again"
+                            },
+                            "X-Progress": {
+                                "type": "string",
+                                "description": "Progress of the job"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
-            }
-        },
-        "/external-patients": {
-            "get": {
-                "description": "Searches for patient resources on an external FHIR server based on query parameters",
+            },
+            "delete": {
+                "description": "Cancel a running bulk data job, or remove a finished one, along with the files it wrote.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "ExternalPatients"
+                    "Bulk Data"
                 ],
-                "summary": "Search for external patients",
+                "summary": "Cancel a bulk data job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "FHIR search parameters (e.g., name=John,birthdate=1990-01-01)",
-                        "name": "_query",
-                        "in": "query"
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Successfully retrieved search results",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/fhir.Bundle"
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Creates a new patient resource on an external FHIR server",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/$export": {
+            "get": {
+                "description": "Start an asynchronous FHIR Bulk Data export of every resource on the server to NDJSON files, one per resource type. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "ExternalPatients"
+                    "Bulk Data"
                 ],
-                "summary": "Create an external patient",
+                "summary": "Export all resources",
                 "parameters": [
                     {
-                        "description": "Patient resource to create (FHIR-compliant JSON)",
-                        "name": "patient",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
+                        "type": "string",
+                        "description": "respond-async",
+                        "name": "Prefer",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/fhir+ndjson, the only format supported",
+                        "name": "_outputFormat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only resources updated after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated resource types to export, e.g. Patient,Observation",
+                        "name": "_type",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successfully created patient",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        },
+                        "headers": {
+                            "Content-Location": {
+                                "type": "string",
+                                "description": "Status URL of the export job"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/external-patients/{id}": {
+        "/OperationDefinition/{id}": {
             "get": {
-                "description": "Retrieves a patient resource from an external FHIR server by its ID",
+                "description": "Get the OperationDefinition of an operation the capability statement describes with a local definition: Patient-merge, Patient-unmerge, Patient-expunge or Patient-undelete.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "ExternalPatients"
+                    "Capability"
                 ],
-                "summary": "Get an external patient by ID",
+                "summary": "Get an operation definition",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Patient ID",
+                        "description": "OperationDefinition id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -230,55 +310,35 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved patient",
-                        "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationDefinition"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/external-patients/{id}/cached": {
-            "get": {
-                "description": "Retrieves a patient resource from an external FHIR server by its ID with Redis caching",
+        "/admin/patients/{id}/$undelete": {
+            "post": {
+                "description": "Restore a deleted FHIR Patient resource as a new version. Its identifiers are indexed again, so an identifier of a unique system that another patient took since the deletion is a conflict.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "ExternalPatients"
+                    "Admin"
                 ],
-                "summary": "Get an external patient by ID with caching",
+                "summary": "Undelete a Patient",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Patient ID",
+                        "description": "Patient logical ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -286,215 +346,247 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved patient",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/fhir.Patient"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "The patient is not deleted, or an identifier of a unique system belongs to another patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/external-patients/{id}/delayed": {
+        "/api/v1/consul/secret": {
             "get": {
-                "description": "Retrieves a patient resource from an external FHIR server by its ID with configurable timeout",
+                "description": "Fetches a secret from Consul Key Vault and returns it as JSON",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "ExternalPatients"
-                ],
-                "summary": "Get an external patient by ID with timeout",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Patient ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Timeout in seconds (default: 10)",
-                        "name": "timeout",
-                        "in": "query"
-                    }
+                    "Consul"
                 ],
+                "summary": "Get secret from Consul KV",
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved patient",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
-                    },
-                    "404": {
-                        "description": "Patient not found",
+                    }
+                }
+            }
+        },
+        "/api/v1/vault/secret": {
+            "get": {
+                "description": "Fetches a secret from Vault Key Vault and returns it as JSON",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get secret from Vault KV",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "408": {
-                        "description": "Request timeout",
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/cron/cleanup": {
+            "post": {
+                "description": "Triggers a background cleanup job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Trigger a cleanup job",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                    }
+                }
+            }
+        },
+        "/cron/sync": {
+            "post": {
+                "description": "Triggers a background data synchronization job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Trigger a data sync job",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/patients": {
+        "/external-patients": {
             "get": {
-                "description": "Get all FHIR Patient resources with pagination",
+                "description": "Searches for patient resources on an external FHIR server based on query parameters",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Get all Patients",
+                "summary": "Search for external patients",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Offset",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "FHIR search parameters (e.g., name=John,birthdate=1990-01-01)",
+                        "name": "_query",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Successfully retrieved search results",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new FHIR Patient resource",
+                "description": "Creates a new patient resource on an external FHIR server",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Create a new Patient",
+                "summary": "Create an external patient",
                 "parameters": [
                     {
-                        "description": "FHIR Patient resource",
+                        "description": "Patient resource to create (FHIR-compliant JSON)",
                         "name": "patient",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
+                            "type": "object"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created",
+                        "description": "Successfully created patient",
                         "schema": {
                             "$ref": "#/definitions/fhir.Patient"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/patients/{id}": {
+        "/external-patients/{id}": {
             "get": {
-                "description": "Get a FHIR Patient resource by its ID",
+                "description": "Retrieves a patient resource from an external FHIR server by its ID",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Get a Patient by ID",
+                "summary": "Get an external patient by ID",
                 "parameters": [
                     {
-                        "type": "integer",
+                        "type": "string",
                         "description": "Patient ID",
                         "name": "id",
                         "in": "path",
@@ -503,106 +595,124 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Successfully retrieved patient",
                         "schema": {
                             "$ref": "#/definitions/fhir.Patient"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Patient not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing FHIR Patient resource",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/external-patients/{id}/$everything": {
+            "get": {
+                "description": "Proxies the Patient/$everything operation of an external FHIR server, returning the patient and every resource in its compartment",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Update a Patient",
+                "summary": "Get everything for an external patient",
                 "parameters": [
                     {
-                        "type": "integer",
+                        "type": "string",
                         "description": "Patient ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "FHIR Patient resource",
-                        "name": "patient",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
-                        }
+                        "type": "string",
+                        "description": "Only resources updated at or after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated resource types to include",
+                        "name": "_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page",
+                        "name": "_count",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Successfully retrieved the patient compartment",
                         "schema": {
-                            "$ref": "#/definitions/fhir.Patient"
+                            "$ref": "#/definitions/fhir.Bundle"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Patient not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing FHIR Patient resource",
+            }
+        },
+        "/external-patients/{id}/cached": {
+            "get": {
+                "description": "Retrieves a patient resource from an external FHIR server by its ID with Redis caching",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Delete a Patient",
+                "summary": "Get an external patient by ID with caching",
                 "parameters": [
                     {
-                        "type": "integer",
+                        "type": "string",
                         "description": "Patient ID",
                         "name": "id",
                         "in": "path",
@@ -610,54 +720,336 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "Successfully retrieved patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Patient not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
-                    }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
                 }
-            },
-            "patch": {
-                "description": "Partially update an existing FHIR Patient resource",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/external-patients/{id}/delayed": {
+            "get": {
+                "description": "Retrieves a patient resource from an external FHIR server by its ID with configurable timeout",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Patient"
+                    "ExternalPatients"
                 ],
-                "summary": "Partially update a Patient",
+                "summary": "Get an external patient by ID with timeout",
                 "parameters": [
                     {
-                        "type": "integer",
+                        "type": "string",
                         "description": "Patient ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Partial updates",
-                        "name": "patches",
+                        "type": "integer",
+                        "description": "Timeout in seconds (default: 10)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "502": {
+                        "description": "External server error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "504": {
+                        "description": "External server timeout",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/metadata": {
+            "get": {
+                "description": "Get the CapabilityStatement of the server: the resource types, interactions, search parameters, operations and formats it supports. It is built from the registered routes when the server starts.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Capability"
+                ],
+                "summary": "Get the capability statement",
+                "parameters": [
+                    {
+                        "enum": [
+                            "full"
+                        ],
+                        "type": "string",
+                        "default": "full",
+                        "description": "Kind of statement to return",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.CapabilityStatement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients": {
+            "get": {
+                "description": "Search FHIR Patient resources using standard FHIR search parameters, returning a searchset Bundle",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Search Patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Logical id of the patient",
+                        "name": "_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "A portion of either family or given name (supports :exact and :contains)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "A portion of the family name",
+                        "name": "family",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "A portion of the given name",
+                        "name": "given",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Gender (male, female, other, unknown)",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date of birth with optional prefix (eq, ne, lt, gt, le, ge, sa, eb, ap)",
+                        "name": "birthdate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whether the patient record is active (true, false)",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Referenced resources to add: Patient:organization or Patient:general-practitioner",
+                        "name": "_include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Referring resources to add, e.g. Observation:subject or Encounter:patient",
+                        "name": "_revinclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return subsets of the patients (true, text, data, false), or only the total (count)",
+                        "name": "_summary",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated top-level elements to return",
+                        "name": "_elements",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "When the patient last changed, with optional prefix",
+                        "name": "_lastUpdated",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words, or starts of words, anywhere in the patient's names, telecom, addresses, contacts, notes or narrative; matches are ranked by relevance",
+                        "name": "_content",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words, or starts of words, in the patient's narrative",
+                        "name": "_text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort keys (_id, _lastUpdated, family, given, birthdate), - for descending; without it full-text matches are sorted by relevance",
+                        "name": "_sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Whether to count the matches (none, estimate, accurate); only accurate counts on pages after the first",
+                        "name": "_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of results per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque page token taken from a Bundle paging link",
+                        "name": "_page_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit (deprecated, use _count); capped at 1000 like _count",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset (deprecated, use paging links)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the single Patient matching the search criteria, or create it when nothing matches. Multiple matches are rejected with 412.",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Conditionally update a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient identifier as system|value",
+                        "name": "identifier",
+                        "in": "query"
+                    },
+                    {
+                        "description": "FHIR Patient resource",
+                        "name": "patient",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.Patient"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation",
+                        "name": "Prefer",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -665,43 +1057,1574 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "An identifier of a unique system belongs to another patient",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fhir.OperationOutcome"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "fhir.Address": {
-            "type": "object",
-            "properties": {
-                "city": {
-                    "type": "string"
-                },
-                "country": {
-                    "type": "string"
-                },
+            },
+            "post": {
+                "description": "Create a new FHIR Patient resource. With If-None-Exist the patient is only created when no existing patient matches the given search criteria.",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Create a new Patient",
+                "parameters": [
+                    {
+                        "description": "FHIR Patient resource",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search criteria for a conditional create, e.g. identifier=http://hospital.org|123",
+                        "name": "If-None-Exist",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "An existing patient matched the If-None-Exist criteria",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created; a Warning header names each probable duplicate found by the patient matcher",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "An identifier of a unique system belongs to another patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the single Patient matching the search criteria. No match is not an error; multiple matches are rejected with 412.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Conditionally delete a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient identifier as system|value",
+                        "name": "identifier",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/$export": {
+            "get": {
+                "description": "Start an asynchronous FHIR Bulk Data export of every patient and the resources in their compartments to NDJSON files, one per resource type. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Bulk Data"
+                ],
+                "summary": "Export all patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "respond-async",
+                        "name": "Prefer",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/fhir+ndjson, the only format supported",
+                        "name": "_outputFormat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only resources updated after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated resource types to export, e.g. Patient,Observation",
+                        "name": "_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        },
+                        "headers": {
+                            "Content-Location": {
+                                "type": "string",
+                                "description": "Status URL of the export job"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/$import": {
+            "post": {
+                "description": "Start an asynchronous import of patients from NDJSON, one Patient per line. The body is either the NDJSON itself, sent as application/fhir+ndjson, or a Parameters resource whose input parameters name NDJSON files by a url part relative to the server's import directory. Every line is validated and gets a server-assigned logical id. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll; the manifest of the completed import counts the patients imported from each input and lists an OperationOutcome file for the lines that failed.",
+                "consumes": [
+                    "application/fhir+ndjson",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Bulk Data"
+                ],
+                "summary": "Import patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "respond-async",
+                        "name": "Prefer",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "NDJSON of Patient resources, or a Parameters resource with input parameters",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        },
+                        "headers": {
+                            "Content-Location": {
+                                "type": "string",
+                                "description": "Status URL of the import job"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/$match": {
+            "post": {
+                "description": "Find stored patients that may be the patient in the request. Candidates are scored on name, birth date, gender, telecom, address and identifiers and returned best first, with the score in search.score and the grade in the match-grade extension. The body is a Parameters resource with a resource parameter and optional onlyCertainMatches and count parameters, or a bare Patient resource.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/fhir+json",
+                    "application/fhir+xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Find matching Patients",
+                "parameters": [
+                    {
+                        "description": "Parameters resource or Patient resource",
+                        "name": "parameters",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/$merge": {
+            "post": {
+                "description": "Merge a duplicate source patient into a target patient. The target takes the source's identifiers, names, telecom and addresses by the configured merge rules and a replaces link; the source becomes inactive with a replaced-by link; references to the source in other resources are repointed to the target. The body is a Parameters resource with source-patient and target-patient references and an optional preview flag. The response is a Parameters resource with the outcome, the merged target as result, and the source.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/fhir+json",
+                    "application/fhir+xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Merge two Patients",
+                "parameters": [
+                    {
+                        "description": "Parameters resource",
+                        "name": "parameters",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Parameters"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "A patient was already merged, or an identifier of a unique system belongs to another patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "A patient changed while it was being merged",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/$validate": {
+            "post": {
+                "description": "Validate a FHIR Patient resource without storing it. The resource is checked against the base specification, the enforced profiles and any requested profiles. The body is a Patient resource, or a Parameters resource with resource and profile parameters.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/fhir+json",
+                    "application/fhir+xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Validate a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Canonical URL of a profile to validate against",
+                        "name": "profile",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Patient resource or Parameters resource",
+                        "name": "resource",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Validation result; error issues mean the resource is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/_history": {
+            "get": {
+                "description": "Get the versions of all FHIR Patient resources as a history Bundle, newest first",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Get the history of all Patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include versions created at or after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of entries per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque page token taken from a Bundle paging link",
+                        "name": "_page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}": {
+            "get": {
+                "description": "Get a FHIR Patient resource by its ID. A deleted patient is reported with 410 Gone.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Get a Patient by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return a subset of the patient (true, text, data, false)",
+                        "name": "_summary",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated top-level elements to return",
+                        "name": "_elements",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "410": {
+                        "description": "The patient has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing FHIR Patient resource",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Update a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "FHIR Patient resource",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weak ETag of the version being updated, e.g. W/\\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "An identifier of a unique system belongs to another patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing FHIR Patient resource",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Delete a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weak ETag of the version being deleted, e.g. W/\\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update an existing FHIR Patient resource. The body is an RFC 6902 JSON Patch (application/json-patch+json), a FHIRPath Patch Parameters resource (application/fhir+json or application/fhir+xml), or a flat map of active, family, given, gender and birthDate (application/merge-patch+json). Plain application/json may carry any of the three. Unknown keys and invalid values in the flat map are rejected.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/json-patch+json",
+                    "application/merge-patch+json",
+                    "application/fhir+json",
+                    "application/fhir+xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Partially update a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Patch operations, FHIRPath Patch Parameters or partial updates",
+                        "name": "patches",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weak ETag of the version being patched, e.g. W/\\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak ETag of the patient's version, e.g. W/\\\"1\\"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time the patient was last updated"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "An identifier of a unique system belongs to another patient",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/$everything": {
+            "get": {
+                "description": "Return the patient and every resource in its compartment, i.e. every Observation and Encounter that refers to it, as a searchset Bundle",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Get everything for a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only resources updated at or after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated resource types to include, e.g. Patient,Observation",
+                        "name": "_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of results per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque page token taken from a Bundle paging link",
+                        "name": "_page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/$expunge": {
+            "post": {
+                "description": "Permanently remove a FHIR Patient resource, deleted or not, with its identifiers and merge records. The optional body is a Parameters resource; with expungePreviousVersions set to true the patient's history is removed too. The response is a Parameters resource with the count of rows removed.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/fhir+json",
+                    "application/fhir+xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Expunge a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Parameters resource with expungePreviousVersions",
+                        "name": "parameters",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Parameters"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/$unmerge": {
+            "post": {
+                "description": "Reverse the merge of a source patient. The source is restored as it was before the merge; the target gets back its identifiers, names, telecom and addresses from before the merge and loses its replaces link; repointed references that still point to the target are pointed back to the source. The response is a Parameters resource with the outcome and both patients.",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Reverse a Patient merge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Logical ID of the merged source patient",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Parameters"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "409": {
+                        "description": "The patient has not been merged",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "A patient changed while it was being unmerged",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/_history": {
+            "get": {
+                "description": "Get all versions of a FHIR Patient resource as a history Bundle, newest first",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Get the history of a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include versions created at or after this instant",
+                        "name": "_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of entries per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque page token taken from a Bundle paging link",
+                        "name": "_page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/_history/{vid}": {
+            "get": {
+                "description": "Get a FHIR Patient resource as it was at the given version (vread)",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Patient"
+                ],
+                "summary": "Get a specific version of a Patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version ID",
+                        "name": "vid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/{collection}": {
+            "get": {
+                "description": "Search Observation, Encounter, Practitioner or Organization resources with the search parameters listed for the type in the CapabilityStatement, returning a searchset Bundle",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Resource"
+                ],
+                "summary": "Search resources",
+                "parameters": [
+                    {
+                        "enum": [
+                            "observations",
+                            "encounters",
+                            "practitioners",
+                            "organizations"
+                        ],
+                        "type": "string",
+                        "description": "Resource collection",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Logical id of the resource",
+                        "name": "_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of results per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque page token taken from a Bundle paging link",
+                        "name": "_page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an Observation, Encounter, Practitioner or Organization. Any id in the resource is replaced by a server-assigned logical id.",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Resource"
+                ],
+                "summary": "Create a resource",
+                "parameters": [
+                    {
+                        "enum": [
+                            "observations",
+                            "encounters",
+                            "practitioners",
+                            "organizations"
+                        ],
+                        "type": "string",
+                        "description": "Resource collection",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "FHIR resource of the collection's type",
+                        "name": "resource",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        },
+        "/{collection}/{id}": {
+            "get": {
+                "description": "Get an Observation, Encounter, Practitioner or Organization by its logical id",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Resource"
+                ],
+                "summary": "Get a resource by ID",
+                "parameters": [
+                    {
+                        "enum": [
+                            "observations",
+                            "encounters",
+                            "practitioners",
+                            "organizations"
+                        ],
+                        "type": "string",
+                        "description": "Resource collection",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing Observation, Encounter, Practitioner or Organization",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Resource"
+                ],
+                "summary": "Update a resource",
+                "parameters": [
+                    {
+                        "enum": [
+                            "observations",
+                            "encounters",
+                            "practitioners",
+                            "organizations"
+                        ],
+                        "type": "string",
+                        "description": "Resource collection",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "FHIR resource of the collection's type",
+                        "name": "resource",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weak ETag of the version being updated, e.g. W/\\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an existing Observation, Encounter, Practitioner or Organization",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Resource"
+                ],
+                "summary": "Delete a resource",
+                "parameters": [
+                    {
+                        "enum": [
+                            "observations",
+                            "encounters",
+                            "practitioners",
+                            "organizations"
+                        ],
+                        "type": "string",
+                        "description": "Resource collection",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Logical ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weak ETag of the version being deleted, e.g. W/\\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/fhir.OperationOutcome"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "fhir.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
                 "district": {
                     "type": "string"
                 },
@@ -714,115 +2637,1234 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "line": {
+                "line": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "period": {
+                    "$ref": "#/definitions/fhir.Period"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/fhir.AddressType"
+                },
+                "use": {
+                    "$ref": "#/definitions/fhir.AddressUse"
+                }
+            }
+        },
+        "fhir.AddressType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "AddressTypePostal",
+                "AddressTypePhysical",
+                "AddressTypeBoth"
+            ]
+        },
+        "fhir.AddressUse": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4
+            ],
+            "x-enum-varnames": [
+                "AddressUseHome",
+                "AddressUseWork",
+                "AddressUseTemp",
+                "AddressUseOld",
+                "AddressUseBilling"
+            ]
+        },
+        "fhir.AdministrativeGender": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "AdministrativeGenderMale",
+                "AdministrativeGenderFemale",
+                "AdministrativeGenderOther",
+                "AdministrativeGenderUnknown"
+            ]
+        },
+        "fhir.Age": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "comparator": {
+                    "$ref": "#/definitions/fhir.QuantityComparator"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "system": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.Annotation": {
+            "type": "object",
+            "properties": {
+                "authorReference": {
+                    "$ref": "#/definitions/fhir.Reference"
+                },
+                "authorString": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.Attachment": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "creation": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.BindingStrength": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "BindingStrengthRequired",
+                "BindingStrengthExtensible",
+                "BindingStrengthPreferred",
+                "BindingStrengthExample"
+            ]
+        },
+        "fhir.Bundle": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.BundleEntry"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "$ref": "#/definitions/fhir.Identifier"
+                },
+                "implicitRules": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.BundleLink"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/fhir.Meta"
+                },
+                "signature": {
+                    "$ref": "#/definitions/fhir.Signature"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/fhir.BundleType"
+                }
+            }
+        },
+        "fhir.BundleEntry": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "fullUrl": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.BundleLink"
+                    }
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "request": {
+                    "$ref": "#/definitions/fhir.BundleEntryRequest"
+                },
+                "resource": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "response": {
+                    "$ref": "#/definitions/fhir.BundleEntryResponse"
+                },
+                "search": {
+                    "$ref": "#/definitions/fhir.BundleEntrySearch"
+                }
+            }
+        },
+        "fhir.BundleEntryRequest": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ifMatch": {
+                    "type": "string"
+                },
+                "ifModifiedSince": {
+                    "type": "string"
+                },
+                "ifNoneExist": {
+                    "type": "string"
+                },
+                "ifNoneMatch": {
+                    "type": "string"
+                },
+                "method": {
+                    "$ref": "#/definitions/fhir.HTTPVerb"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.BundleEntryResponse": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "outcome": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.BundleEntrySearch": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/fhir.SearchEntryMode"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "score": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.BundleLink": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "relation": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.BundleType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8
+            ],
+            "x-enum-varnames": [
+                "BundleTypeDocument",
+                "BundleTypeMessage",
+                "BundleTypeTransaction",
+                "BundleTypeTransactionResponse",
+                "BundleTypeBatch",
+                "BundleTypeBatchResponse",
+                "BundleTypeHistory",
+                "BundleTypeSearchset",
+                "BundleTypeCollection"
+            ]
+        },
+        "fhir.CapabilityStatement": {
+            "type": "object",
+            "properties": {
+                "contact": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.ContactDetail"
+                    }
+                },
+                "copyright": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "document": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementDocument"
+                    }
+                },
+                "experimental": {
+                    "type": "boolean"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "fhirVersion": {
+                    "$ref": "#/definitions/fhir.FHIRVersion"
+                },
+                "format": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "implementation": {
+                    "$ref": "#/definitions/fhir.CapabilityStatementImplementation"
+                },
+                "implementationGuide": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "implicitRules": {
+                    "type": "string"
+                },
+                "imports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "instantiates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jurisdiction": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CodeableConcept"
+                    }
+                },
+                "kind": {
+                    "$ref": "#/definitions/fhir.CapabilityStatementKind"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "messaging": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementMessaging"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/fhir.Meta"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patchFormat": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "publisher": {
+                    "type": "string"
+                },
+                "purpose": {
+                    "type": "string"
+                },
+                "rest": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRest"
+                    }
+                },
+                "software": {
+                    "$ref": "#/definitions/fhir.CapabilityStatementSoftware"
+                },
+                "status": {
+                    "$ref": "#/definitions/fhir.PublicationStatus"
+                },
+                "text": {
+                    "$ref": "#/definitions/fhir.Narrative"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "useContext": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.UsageContext"
+                    }
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.CapabilityStatementDocument": {
+            "type": "object",
+            "properties": {
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/fhir.DocumentMode"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "profile": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.CapabilityStatementImplementation": {
+            "type": "object",
+            "properties": {
+                "custodian": {
+                    "$ref": "#/definitions/fhir.Reference"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.CapabilityStatementKind": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "CapabilityStatementKindInstance",
+                "CapabilityStatementKindCapability",
+                "CapabilityStatementKindRequirements"
+            ]
+        },
+        "fhir.CapabilityStatementMessaging": {
+            "type": "object",
+            "properties": {
+                "documentation": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementMessagingEndpoint"
+                    }
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "reliableCache": {
+                    "type": "integer"
+                },
+                "supportedMessage": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementMessagingSupportedMessage"
+                    }
+                }
+            }
+        },
+        "fhir.CapabilityStatementMessagingEndpoint": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "protocol": {
+                    "$ref": "#/definitions/fhir.Coding"
+                }
+            }
+        },
+        "fhir.CapabilityStatementMessagingSupportedMessage": {
+            "type": "object",
+            "properties": {
+                "definition": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/fhir.EventCapabilityMode"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                }
+            }
+        },
+        "fhir.CapabilityStatementRest": {
+            "type": "object",
+            "properties": {
+                "compartment": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interaction": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestInteraction"
+                    }
+                },
+                "mode": {
+                    "$ref": "#/definitions/fhir.RestfulCapabilityMode"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "operation": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResourceOperation"
+                    }
+                },
+                "resource": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResource"
+                    }
+                },
+                "searchParam": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResourceSearchParam"
+                    }
+                },
+                "security": {
+                    "$ref": "#/definitions/fhir.CapabilityStatementRestSecurity"
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestInteraction": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/fhir.SystemRestfulInteraction"
+                },
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestResource": {
+            "type": "object",
+            "properties": {
+                "conditionalCreate": {
+                    "type": "boolean"
+                },
+                "conditionalDelete": {
+                    "$ref": "#/definitions/fhir.ConditionalDeleteStatus"
+                },
+                "conditionalRead": {
+                    "$ref": "#/definitions/fhir.ConditionalReadStatus"
+                },
+                "conditionalUpdate": {
+                    "type": "boolean"
+                },
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interaction": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResourceInteraction"
+                    }
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "operation": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResourceOperation"
+                    }
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "readHistory": {
+                    "type": "boolean"
+                },
+                "referencePolicy": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.ReferenceHandlingPolicy"
+                    }
+                },
+                "searchInclude": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "searchParam": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CapabilityStatementRestResourceSearchParam"
+                    }
+                },
+                "searchRevInclude": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "supportedProfile": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/fhir.ResourceType"
+                },
+                "updateCreate": {
+                    "type": "boolean"
+                },
+                "versioning": {
+                    "$ref": "#/definitions/fhir.ResourceVersionPolicy"
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestResourceInteraction": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/fhir.TypeRestfulInteraction"
+                },
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/fhir.Extension"
                     }
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestResourceOperation": {
+            "type": "object",
+            "properties": {
+                "definition": {
+                    "type": "string"
                 },
-                "period": {
-                    "$ref": "#/definitions/fhir.Period"
+                "documentation": {
+                    "type": "string"
                 },
-                "postalCode": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
-                "state": {
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestResourceSearchParam": {
+            "type": "object",
+            "properties": {
+                "definition": {
                     "type": "string"
                 },
-                "text": {
+                "documentation": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "name": {
                     "type": "string"
                 },
                 "type": {
-                    "$ref": "#/definitions/fhir.AddressType"
+                    "$ref": "#/definitions/fhir.SearchParamType"
+                }
+            }
+        },
+        "fhir.CapabilityStatementRestSecurity": {
+            "type": "object",
+            "properties": {
+                "cors": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "service": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CodeableConcept"
+                    }
+                }
+            }
+        },
+        "fhir.CapabilityStatementSoftware": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.CodeableConcept": {
+            "type": "object",
+            "properties": {
+                "coding": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Coding"
+                    }
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.Coding": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "display": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "system": {
+                    "type": "string"
+                },
+                "userSelected": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.ConditionalDeleteStatus": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "ConditionalDeleteStatusNotSupported",
+                "ConditionalDeleteStatusSingle",
+                "ConditionalDeleteStatusMultiple"
+            ]
+        },
+        "fhir.ConditionalReadStatus": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "ConditionalReadStatusNotSupported",
+                "ConditionalReadStatusModifiedSince",
+                "ConditionalReadStatusNotMatch",
+                "ConditionalReadStatusFullSupport"
+            ]
+        },
+        "fhir.ContactDetail": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "telecom": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.ContactPoint"
+                    }
+                }
+            }
+        },
+        "fhir.ContactPoint": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "period": {
+                    "$ref": "#/definitions/fhir.Period"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "system": {
+                    "$ref": "#/definitions/fhir.ContactPointSystem"
                 },
                 "use": {
-                    "$ref": "#/definitions/fhir.AddressUse"
+                    "$ref": "#/definitions/fhir.ContactPointUse"
+                },
+                "value": {
+                    "type": "string"
                 }
             }
         },
-        "fhir.AddressType": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2
-            ],
-            "x-enum-varnames": [
-                "AddressTypePostal",
-                "AddressTypePhysical",
-                "AddressTypeBoth"
-            ]
-        },
-        "fhir.AddressUse": {
+        "fhir.ContactPointSystem": {
             "type": "integer",
             "enum": [
                 0,
                 1,
                 2,
                 3,
-                4
+                4,
+                5,
+                6
             ],
             "x-enum-varnames": [
-                "AddressUseHome",
-                "AddressUseWork",
-                "AddressUseTemp",
-                "AddressUseOld",
-                "AddressUseBilling"
+                "ContactPointSystemPhone",
+                "ContactPointSystemFax",
+                "ContactPointSystemEmail",
+                "ContactPointSystemPager",
+                "ContactPointSystemUrl",
+                "ContactPointSystemSms",
+                "ContactPointSystemOther"
             ]
         },
-        "fhir.AdministrativeGender": {
+        "fhir.ContactPointUse": {
             "type": "integer",
             "enum": [
                 0,
                 1,
                 2,
-                3
+                3,
+                4
             ],
             "x-enum-varnames": [
-                "AdministrativeGenderMale",
-                "AdministrativeGenderFemale",
-                "AdministrativeGenderOther",
-                "AdministrativeGenderUnknown"
+                "ContactPointUseHome",
+                "ContactPointUseWork",
+                "ContactPointUseTemp",
+                "ContactPointUseOld",
+                "ContactPointUseMobile"
             ]
         },
-        "fhir.Age": {
+        "fhir.Contributor": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "comparator": {
-                    "$ref": "#/definitions/fhir.QuantityComparator"
-                },
-                "extension": {
+                "contact": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.ContactDetail"
                     }
                 },
-                "id": {
-                    "type": "string"
-                },
-                "system": {
-                    "type": "string"
-                },
-                "unit": {
-                    "type": "string"
-                },
-                "value": {
-                    "type": "string"
-                }
-            }
-        },
-        "fhir.Annotation": {
-            "type": "object",
-            "properties": {
-                "authorReference": {
-                    "$ref": "#/definitions/fhir.Reference"
-                },
-                "authorString": {
-                    "type": "string"
-                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -832,25 +3874,37 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "text": {
+                "name": {
                     "type": "string"
                 },
-                "time": {
-                    "type": "string"
+                "type": {
+                    "$ref": "#/definitions/fhir.ContributorType"
                 }
             }
         },
-        "fhir.Attachment": {
+        "fhir.ContributorType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "ContributorTypeAuthor",
+                "ContributorTypeEditor",
+                "ContributorTypeReviewer",
+                "ContributorTypeEndorser"
+            ]
+        },
+        "fhir.Count": {
             "type": "object",
             "properties": {
-                "contentType": {
-                    "type": "string"
-                },
-                "creation": {
+                "code": {
                     "type": "string"
                 },
-                "data": {
-                    "type": "string"
+                "comparator": {
+                    "$ref": "#/definitions/fhir.QuantityComparator"
                 },
                 "extension": {
                     "type": "array",
@@ -858,191 +3912,106 @@ const docTemplate = `{
                         "$ref": "#/definitions/fhir.Extension"
                     }
                 },
-                "hash": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "string"
                 },
-                "language": {
+                "system": {
                     "type": "string"
                 },
-                "size": {
-                    "type": "integer"
-                },
-                "title": {
+                "unit": {
                     "type": "string"
                 },
-                "url": {
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "fhir.Bundle": {
+        "fhir.DataRequirement": {
             "type": "object",
             "properties": {
-                "entry": {
+                "codeFilter": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.BundleEntry"
+                        "$ref": "#/definitions/fhir.DataRequirementCodeFilter"
                     }
                 },
-                "id": {
-                    "type": "string"
-                },
-                "identifier": {
-                    "$ref": "#/definitions/fhir.Identifier"
-                },
-                "implicitRules": {
-                    "type": "string"
-                },
-                "language": {
-                    "type": "string"
-                },
-                "link": {
+                "dateFilter": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.BundleLink"
+                        "$ref": "#/definitions/fhir.DataRequirementDateFilter"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/fhir.Meta"
-                },
-                "signature": {
-                    "$ref": "#/definitions/fhir.Signature"
-                },
-                "timestamp": {
-                    "type": "string"
-                },
-                "total": {
-                    "type": "integer"
-                },
-                "type": {
-                    "$ref": "#/definitions/fhir.BundleType"
-                }
-            }
-        },
-        "fhir.BundleEntry": {
-            "type": "object",
-            "properties": {
                 "extension": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/fhir.Extension"
                     }
                 },
-                "fullUrl": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "string"
                 },
-                "link": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.BundleLink"
-                    }
+                "limit": {
+                    "type": "integer"
                 },
-                "modifierExtension": {
+                "mustSupport": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "type": "string"
                     }
                 },
-                "request": {
-                    "$ref": "#/definitions/fhir.BundleEntryRequest"
-                },
-                "resource": {
+                "profile": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "type": "string"
                     }
                 },
-                "response": {
-                    "$ref": "#/definitions/fhir.BundleEntryResponse"
-                },
-                "search": {
-                    "$ref": "#/definitions/fhir.BundleEntrySearch"
-                }
-            }
-        },
-        "fhir.BundleEntryRequest": {
-            "type": "object",
-            "properties": {
-                "extension": {
+                "sort": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.DataRequirementSort"
                     }
                 },
-                "id": {
-                    "type": "string"
-                },
-                "ifMatch": {
-                    "type": "string"
-                },
-                "ifModifiedSince": {
-                    "type": "string"
-                },
-                "ifNoneExist": {
-                    "type": "string"
-                },
-                "ifNoneMatch": {
-                    "type": "string"
-                },
-                "method": {
-                    "$ref": "#/definitions/fhir.HTTPVerb"
+                "subjectCodeableConcept": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
                 },
-                "modifierExtension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
+                "subjectReference": {
+                    "$ref": "#/definitions/fhir.Reference"
                 },
-                "url": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "fhir.BundleEntryResponse": {
+        "fhir.DataRequirementCodeFilter": {
             "type": "object",
             "properties": {
-                "etag": {
-                    "type": "string"
-                },
-                "extension": {
+                "code": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.Coding"
                     }
                 },
-                "id": {
-                    "type": "string"
-                },
-                "lastModified": {
-                    "type": "string"
-                },
-                "location": {
-                    "type": "string"
-                },
-                "modifierExtension": {
+                "extension": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/fhir.Extension"
                     }
                 },
-                "outcome": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
-                },
-                "status": {
+                "id": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "searchParam": {
+                    "type": "string"
+                },
+                "valueSet": {
                     "type": "string"
                 }
             }
         },
-        "fhir.BundleEntrySearch": {
+        "fhir.DataRequirementDateFilter": {
             "type": "object",
             "properties": {
                 "extension": {
@@ -1054,23 +4023,29 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "mode": {
-                    "$ref": "#/definitions/fhir.SearchEntryMode"
+                "path": {
+                    "type": "string"
                 },
-                "modifierExtension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
+                "searchParam": {
+                    "type": "string"
                 },
-                "score": {
+                "valueDateTime": {
                     "type": "string"
+                },
+                "valueDuration": {
+                    "$ref": "#/definitions/fhir.Duration"
+                },
+                "valuePeriod": {
+                    "$ref": "#/definitions/fhir.Period"
                 }
             }
         },
-        "fhir.BundleLink": {
+        "fhir.DataRequirementSort": {
             "type": "object",
             "properties": {
+                "direction": {
+                    "$ref": "#/definitions/fhir.SortDirection"
+                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1080,21 +4055,12 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "modifierExtension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
-                },
-                "relation": {
-                    "type": "string"
-                },
-                "url": {
+                "path": {
                     "type": "string"
                 }
             }
         },
-        "fhir.BundleType": {
+        "fhir.DaysOfWeek": {
             "type": "integer",
             "enum": [
                 0,
@@ -1103,53 +4069,26 @@ const docTemplate = `{
                 3,
                 4,
                 5,
-                6,
-                7,
-                8
+                6
             ],
             "x-enum-varnames": [
-                "BundleTypeDocument",
-                "BundleTypeMessage",
-                "BundleTypeTransaction",
-                "BundleTypeTransactionResponse",
-                "BundleTypeBatch",
-                "BundleTypeBatchResponse",
-                "BundleTypeHistory",
-                "BundleTypeSearchset",
-                "BundleTypeCollection"
+                "DaysOfWeekMon",
+                "DaysOfWeekTue",
+                "DaysOfWeekWed",
+                "DaysOfWeekThu",
+                "DaysOfWeekFri",
+                "DaysOfWeekSat",
+                "DaysOfWeekSun"
             ]
         },
-        "fhir.CodeableConcept": {
-            "type": "object",
-            "properties": {
-                "coding": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Coding"
-                    }
-                },
-                "extension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
-                },
-                "id": {
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
-                }
-            }
-        },
-        "fhir.Coding": {
+        "fhir.Distance": {
             "type": "object",
             "properties": {
                 "code": {
                     "type": "string"
                 },
-                "display": {
-                    "type": "string"
+                "comparator": {
+                    "$ref": "#/definitions/fhir.QuantityComparator"
                 },
                 "extension": {
                     "type": "array",
@@ -1163,40 +4102,46 @@ const docTemplate = `{
                 "system": {
                     "type": "string"
                 },
-                "userSelected": {
-                    "type": "boolean"
+                "unit": {
+                    "type": "string"
                 },
-                "version": {
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "fhir.ContactDetail": {
+        "fhir.DocumentMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "DocumentModeProducer",
+                "DocumentModeConsumer"
+            ]
+        },
+        "fhir.Dosage": {
             "type": "object",
             "properties": {
-                "extension": {
+                "additionalInstruction": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.CodeableConcept"
                     }
                 },
-                "id": {
-                    "type": "string"
+                "asNeededBoolean": {
+                    "type": "boolean"
                 },
-                "name": {
-                    "type": "string"
+                "asNeededCodeableConcept": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
                 },
-                "telecom": {
+                "doseAndRate": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.ContactPoint"
+                        "$ref": "#/definitions/fhir.DosageDoseAndRate"
                     }
-                }
-            }
-        },
-        "fhir.ContactPoint": {
-            "type": "object",
-            "properties": {
+                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1206,69 +4151,52 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "period": {
-                    "$ref": "#/definitions/fhir.Period"
+                "maxDosePerAdministration": {
+                    "$ref": "#/definitions/fhir.Quantity"
                 },
-                "rank": {
-                    "type": "integer"
+                "maxDosePerLifetime": {
+                    "$ref": "#/definitions/fhir.Quantity"
                 },
-                "system": {
-                    "$ref": "#/definitions/fhir.ContactPointSystem"
+                "maxDosePerPeriod": {
+                    "$ref": "#/definitions/fhir.Ratio"
                 },
-                "use": {
-                    "$ref": "#/definitions/fhir.ContactPointUse"
+                "method": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
                 },
-                "value": {
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "patientInstruction": {
+                    "type": "string"
+                },
+                "route": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "sequence": {
+                    "type": "integer"
+                },
+                "site": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "text": {
                     "type": "string"
+                },
+                "timing": {
+                    "$ref": "#/definitions/fhir.Timing"
                 }
             }
         },
-        "fhir.ContactPointSystem": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4,
-                5,
-                6
-            ],
-            "x-enum-varnames": [
-                "ContactPointSystemPhone",
-                "ContactPointSystemFax",
-                "ContactPointSystemEmail",
-                "ContactPointSystemPager",
-                "ContactPointSystemUrl",
-                "ContactPointSystemSms",
-                "ContactPointSystemOther"
-            ]
-        },
-        "fhir.ContactPointUse": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4
-            ],
-            "x-enum-varnames": [
-                "ContactPointUseHome",
-                "ContactPointUseWork",
-                "ContactPointUseTemp",
-                "ContactPointUseOld",
-                "ContactPointUseMobile"
-            ]
-        },
-        "fhir.Contributor": {
+        "fhir.DosageDoseAndRate": {
             "type": "object",
             "properties": {
-                "contact": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.ContactDetail"
-                    }
+                "doseQuantity": {
+                    "$ref": "#/definitions/fhir.Quantity"
+                },
+                "doseRange": {
+                    "$ref": "#/definitions/fhir.Range"
                 },
                 "extension": {
                     "type": "array",
@@ -1279,30 +4207,21 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "rateQuantity": {
+                    "$ref": "#/definitions/fhir.Quantity"
+                },
+                "rateRange": {
+                    "$ref": "#/definitions/fhir.Range"
+                },
+                "rateRatio": {
+                    "$ref": "#/definitions/fhir.Ratio"
                 },
                 "type": {
-                    "$ref": "#/definitions/fhir.ContributorType"
+                    "$ref": "#/definitions/fhir.CodeableConcept"
                 }
             }
         },
-        "fhir.ContributorType": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3
-            ],
-            "x-enum-varnames": [
-                "ContributorTypeAuthor",
-                "ContributorTypeEditor",
-                "ContributorTypeReviewer",
-                "ContributorTypeEndorser"
-            ]
-        },
-        "fhir.Count": {
+        "fhir.Duration": {
             "type": "object",
             "properties": {
                 "code": {
@@ -1331,20 +4250,25 @@ const docTemplate = `{
                 }
             }
         },
-        "fhir.DataRequirement": {
+        "fhir.EventCapabilityMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "EventCapabilityModeSender",
+                "EventCapabilityModeReceiver"
+            ]
+        },
+        "fhir.Expression": {
             "type": "object",
             "properties": {
-                "codeFilter": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.DataRequirementCodeFilter"
-                    }
+                "description": {
+                    "type": "string"
                 },
-                "dateFilter": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.DataRequirementDateFilter"
-                    }
+                "expression": {
+                    "type": "string"
                 },
                 "extension": {
                     "type": "array",
@@ -1355,47 +4279,20 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "limit": {
-                    "type": "integer"
-                },
-                "mustSupport": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "profile": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "sort": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.DataRequirementSort"
-                    }
-                },
-                "subjectCodeableConcept": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
+                "language": {
+                    "type": "string"
                 },
-                "subjectReference": {
-                    "$ref": "#/definitions/fhir.Reference"
+                "name": {
+                    "type": "string"
                 },
-                "type": {
+                "reference": {
                     "type": "string"
                 }
             }
         },
-        "fhir.DataRequirementCodeFilter": {
+        "fhir.Extension": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Coding"
-                    }
-                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1405,18 +4302,232 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "path": {
+                "url": {
                     "type": "string"
                 },
-                "searchParam": {
+                "valueAddress": {
+                    "$ref": "#/definitions/fhir.Address"
+                },
+                "valueAge": {
+                    "$ref": "#/definitions/fhir.Age"
+                },
+                "valueAnnotation": {
+                    "$ref": "#/definitions/fhir.Annotation"
+                },
+                "valueAttachment": {
+                    "$ref": "#/definitions/fhir.Attachment"
+                },
+                "valueBase64Binary": {
                     "type": "string"
                 },
-                "valueSet": {
+                "valueBoolean": {
+                    "type": "boolean"
+                },
+                "valueCanonical": {
+                    "type": "string"
+                },
+                "valueCode": {
+                    "type": "string"
+                },
+                "valueCodeableConcept": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "valueCoding": {
+                    "$ref": "#/definitions/fhir.Coding"
+                },
+                "valueContactDetail": {
+                    "$ref": "#/definitions/fhir.ContactDetail"
+                },
+                "valueContactPoint": {
+                    "$ref": "#/definitions/fhir.ContactPoint"
+                },
+                "valueContributor": {
+                    "$ref": "#/definitions/fhir.Contributor"
+                },
+                "valueCount": {
+                    "$ref": "#/definitions/fhir.Count"
+                },
+                "valueDataRequirement": {
+                    "$ref": "#/definitions/fhir.DataRequirement"
+                },
+                "valueDate": {
+                    "type": "string"
+                },
+                "valueDateTime": {
+                    "type": "string"
+                },
+                "valueDecimal": {
+                    "type": "string"
+                },
+                "valueDistance": {
+                    "$ref": "#/definitions/fhir.Distance"
+                },
+                "valueDosage": {
+                    "$ref": "#/definitions/fhir.Dosage"
+                },
+                "valueDuration": {
+                    "$ref": "#/definitions/fhir.Duration"
+                },
+                "valueExpression": {
+                    "$ref": "#/definitions/fhir.Expression"
+                },
+                "valueHumanName": {
+                    "$ref": "#/definitions/fhir.HumanName"
+                },
+                "valueId": {
+                    "type": "string"
+                },
+                "valueIdentifier": {
+                    "$ref": "#/definitions/fhir.Identifier"
+                },
+                "valueInstant": {
+                    "type": "string"
+                },
+                "valueInteger": {
+                    "type": "integer"
+                },
+                "valueMarkdown": {
+                    "type": "string"
+                },
+                "valueMeta": {
+                    "$ref": "#/definitions/fhir.Meta"
+                },
+                "valueMoney": {
+                    "$ref": "#/definitions/fhir.Money"
+                },
+                "valueOid": {
+                    "type": "string"
+                },
+                "valueParameterDefinition": {
+                    "$ref": "#/definitions/fhir.ParameterDefinition"
+                },
+                "valuePeriod": {
+                    "$ref": "#/definitions/fhir.Period"
+                },
+                "valuePositiveInt": {
+                    "type": "integer"
+                },
+                "valueQuantity": {
+                    "$ref": "#/definitions/fhir.Quantity"
+                },
+                "valueRange": {
+                    "$ref": "#/definitions/fhir.Range"
+                },
+                "valueRatio": {
+                    "$ref": "#/definitions/fhir.Ratio"
+                },
+                "valueReference": {
+                    "$ref": "#/definitions/fhir.Reference"
+                },
+                "valueRelatedArtifact": {
+                    "$ref": "#/definitions/fhir.RelatedArtifact"
+                },
+                "valueSampledData": {
+                    "$ref": "#/definitions/fhir.SampledData"
+                },
+                "valueSignature": {
+                    "$ref": "#/definitions/fhir.Signature"
+                },
+                "valueString": {
+                    "type": "string"
+                },
+                "valueTime": {
+                    "type": "string"
+                },
+                "valueTiming": {
+                    "$ref": "#/definitions/fhir.Timing"
+                },
+                "valueTriggerDefinition": {
+                    "$ref": "#/definitions/fhir.TriggerDefinition"
+                },
+                "valueUnsignedInt": {
+                    "type": "integer"
+                },
+                "valueUri": {
+                    "type": "string"
+                },
+                "valueUrl": {
+                    "type": "string"
+                },
+                "valueUsageContext": {
+                    "$ref": "#/definitions/fhir.UsageContext"
+                },
+                "valueUuid": {
                     "type": "string"
                 }
             }
         },
-        "fhir.DataRequirementDateFilter": {
+        "fhir.FHIRVersion": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8,
+                9,
+                10,
+                11,
+                12,
+                13,
+                14,
+                15,
+                16,
+                17,
+                18,
+                19,
+                20,
+                21
+            ],
+            "x-enum-varnames": [
+                "FHIRVersion0_01",
+                "FHIRVersion0_05",
+                "FHIRVersion0_06",
+                "FHIRVersion0_11",
+                "FHIRVersion0_0_80",
+                "FHIRVersion0_0_81",
+                "FHIRVersion0_0_82",
+                "FHIRVersion0_4_0",
+                "FHIRVersion0_5_0",
+                "FHIRVersion1_0_0",
+                "FHIRVersion1_0_1",
+                "FHIRVersion1_0_2",
+                "FHIRVersion1_1_0",
+                "FHIRVersion1_4_0",
+                "FHIRVersion1_6_0",
+                "FHIRVersion1_8_0",
+                "FHIRVersion3_0_0",
+                "FHIRVersion3_0_1",
+                "FHIRVersion3_3_0",
+                "FHIRVersion3_5_0",
+                "FHIRVersion4_0_0",
+                "FHIRVersion4_0_1"
+            ]
+        },
+        "fhir.HTTPVerb": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5
+            ],
+            "x-enum-varnames": [
+                "HTTPVerbGET",
+                "HTTPVerbHEAD",
+                "HTTPVerbPOST",
+                "HTTPVerbPUT",
+                "HTTPVerbDELETE",
+                "HTTPVerbPATCH"
+            ]
+        },
+        "fhir.HumanName": {
             "type": "object",
             "properties": {
                 "extension": {
@@ -1425,31 +4536,46 @@ const docTemplate = `{
                         "$ref": "#/definitions/fhir.Extension"
                     }
                 },
+                "family": {
+                    "type": "string"
+                },
+                "given": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "string"
                 },
-                "path": {
-                    "type": "string"
+                "period": {
+                    "$ref": "#/definitions/fhir.Period"
                 },
-                "searchParam": {
-                    "type": "string"
+                "prefix": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "valueDateTime": {
-                    "type": "string"
+                "suffix": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "valueDuration": {
-                    "$ref": "#/definitions/fhir.Duration"
+                "text": {
+                    "type": "string"
                 },
-                "valuePeriod": {
-                    "$ref": "#/definitions/fhir.Period"
+                "use": {
+                    "$ref": "#/definitions/fhir.NameUse"
                 }
             }
         },
-        "fhir.DataRequirementSort": {
+        "fhir.Identifier": {
             "type": "object",
             "properties": {
-                "direction": {
-                    "$ref": "#/definitions/fhir.SortDirection"
+                "assigner": {
+                    "$ref": "#/definitions/fhir.Reference"
                 },
                 "extension": {
                     "type": "array",
@@ -1460,12 +4586,56 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "path": {
+                "period": {
+                    "$ref": "#/definitions/fhir.Period"
+                },
+                "system": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "use": {
+                    "$ref": "#/definitions/fhir.IdentifierUse"
+                },
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "fhir.DaysOfWeek": {
+        "fhir.IdentifierUse": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4
+            ],
+            "x-enum-varnames": [
+                "IdentifierUseUsual",
+                "IdentifierUseOfficial",
+                "IdentifierUseTemp",
+                "IdentifierUseSecondary",
+                "IdentifierUseOld"
+            ]
+        },
+        "fhir.IssueSeverity": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "IssueSeverityFatal",
+                "IssueSeverityError",
+                "IssueSeverityWarning",
+                "IssueSeverityInformation"
+            ]
+        },
+        "fhir.IssueType": {
             "type": "integer",
             "enum": [
                 0,
@@ -1474,27 +4644,84 @@ const docTemplate = `{
                 3,
                 4,
                 5,
-                6
+                6,
+                7,
+                8,
+                9,
+                10,
+                11,
+                12,
+                13,
+                14,
+                15,
+                16,
+                17,
+                18,
+                19,
+                20,
+                21,
+                22,
+                23,
+                24,
+                25,
+                26,
+                27,
+                28,
+                29,
+                30
             ],
             "x-enum-varnames": [
-                "DaysOfWeekMon",
-                "DaysOfWeekTue",
-                "DaysOfWeekWed",
-                "DaysOfWeekThu",
-                "DaysOfWeekFri",
-                "DaysOfWeekSat",
-                "DaysOfWeekSun"
+                "IssueTypeInvalid",
+                "IssueTypeStructure",
+                "IssueTypeRequired",
+                "IssueTypeValue",
+                "IssueTypeInvariant",
+                "IssueTypeSecurity",
+                "IssueTypeLogin",
+                "IssueTypeUnknown",
+                "IssueTypeExpired",
+                "IssueTypeForbidden",
+                "IssueTypeSuppressed",
+                "IssueTypeProcessing",
+                "IssueTypeNotSupported",
+                "IssueTypeDuplicate",
+                "IssueTypeMultipleMatches",
+                "IssueTypeNotFound",
+                "IssueTypeDeleted",
+                "IssueTypeTooLong",
+                "IssueTypeCodeInvalid",
+                "IssueTypeExtension",
+                "IssueTypeTooCostly",
+                "IssueTypeBusinessRule",
+                "IssueTypeConflict",
+                "IssueTypeTransient",
+                "IssueTypeLockError",
+                "IssueTypeNoStore",
+                "IssueTypeException",
+                "IssueTypeTimeout",
+                "IssueTypeIncomplete",
+                "IssueTypeThrottled",
+                "IssueTypeInformational"
             ]
         },
-        "fhir.Distance": {
+        "fhir.LinkType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "LinkTypeReplacedBy",
+                "LinkTypeReplaces",
+                "LinkTypeRefer",
+                "LinkTypeSeealso"
+            ]
+        },
+        "fhir.Meta": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "comparator": {
-                    "$ref": "#/definitions/fhir.QuantityComparator"
-                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1504,93 +4731,40 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "system": {
-                    "type": "string"
-                },
-                "unit": {
-                    "type": "string"
-                },
-                "value": {
+                "lastUpdated": {
                     "type": "string"
-                }
-            }
-        },
-        "fhir.Dosage": {
-            "type": "object",
-            "properties": {
-                "additionalInstruction": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.CodeableConcept"
-                    }
-                },
-                "asNeededBoolean": {
-                    "type": "boolean"
-                },
-                "asNeededCodeableConcept": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
                 },
-                "doseAndRate": {
+                "profile": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.DosageDoseAndRate"
+                        "type": "string"
                     }
                 },
-                "extension": {
+                "security": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.Coding"
                     }
                 },
-                "id": {
+                "source": {
                     "type": "string"
                 },
-                "maxDosePerAdministration": {
-                    "$ref": "#/definitions/fhir.Quantity"
-                },
-                "maxDosePerLifetime": {
-                    "$ref": "#/definitions/fhir.Quantity"
-                },
-                "maxDosePerPeriod": {
-                    "$ref": "#/definitions/fhir.Ratio"
-                },
-                "method": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
-                },
-                "modifierExtension": {
+                "tag": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.Coding"
                     }
                 },
-                "patientInstruction": {
-                    "type": "string"
-                },
-                "route": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
-                },
-                "sequence": {
-                    "type": "integer"
-                },
-                "site": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
-                },
-                "text": {
+                "versionId": {
                     "type": "string"
-                },
-                "timing": {
-                    "$ref": "#/definitions/fhir.Timing"
                 }
             }
         },
-        "fhir.DosageDoseAndRate": {
+        "fhir.Money": {
             "type": "object",
             "properties": {
-                "doseQuantity": {
-                    "$ref": "#/definitions/fhir.Quantity"
-                },
-                "doseRange": {
-                    "$ref": "#/definitions/fhir.Range"
+                "currency": {
+                    "type": "string"
                 },
                 "extension": {
                     "type": "array",
@@ -1601,29 +4775,38 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "rateQuantity": {
-                    "$ref": "#/definitions/fhir.Quantity"
-                },
-                "rateRange": {
-                    "$ref": "#/definitions/fhir.Range"
-                },
-                "rateRatio": {
-                    "$ref": "#/definitions/fhir.Ratio"
-                },
-                "type": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
+                "value": {
+                    "type": "string"
                 }
             }
         },
-        "fhir.Duration": {
+        "fhir.NameUse": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6
+            ],
+            "x-enum-varnames": [
+                "NameUseUsual",
+                "NameUseOfficial",
+                "NameUseTemp",
+                "NameUseNickname",
+                "NameUseAnonymous",
+                "NameUseOld",
+                "NameUseMaiden"
+            ]
+        },
+        "fhir.Narrative": {
             "type": "object",
             "properties": {
-                "code": {
+                "div": {
                     "type": "string"
                 },
-                "comparator": {
-                    "$ref": "#/definitions/fhir.QuantityComparator"
-                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1633,49 +4816,56 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "system": {
-                    "type": "string"
-                },
-                "unit": {
-                    "type": "string"
-                },
-                "value": {
-                    "type": "string"
+                "status": {
+                    "$ref": "#/definitions/fhir.NarrativeStatus"
                 }
             }
         },
-        "fhir.Expression": {
+        "fhir.NarrativeStatus": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "NarrativeStatusGenerated",
+                "NarrativeStatusExtensions",
+                "NarrativeStatusAdditional",
+                "NarrativeStatusEmpty"
+            ]
+        },
+        "fhir.OperationDefinition": {
             "type": "object",
             "properties": {
-                "description": {
+                "affectsState": {
+                    "type": "boolean"
+                },
+                "base": {
+                    "type": "string"
+                },
+                "code": {
                     "type": "string"
                 },
-                "expression": {
+                "comment": {
                     "type": "string"
                 },
-                "extension": {
+                "contact": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Extension"
+                        "$ref": "#/definitions/fhir.ContactDetail"
                     }
                 },
-                "id": {
+                "date": {
                     "type": "string"
                 },
-                "language": {
+                "description": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "experimental": {
+                    "type": "boolean"
                 },
-                "reference": {
-                    "type": "string"
-                }
-            }
-        },
-        "fhir.Extension": {
-            "type": "object",
-            "properties": {
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1685,181 +4875,250 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "url": {
+                "implicitRules": {
                     "type": "string"
                 },
-                "valueAddress": {
-                    "$ref": "#/definitions/fhir.Address"
-                },
-                "valueAge": {
-                    "$ref": "#/definitions/fhir.Age"
-                },
-                "valueAnnotation": {
-                    "$ref": "#/definitions/fhir.Annotation"
-                },
-                "valueAttachment": {
-                    "$ref": "#/definitions/fhir.Attachment"
-                },
-                "valueBase64Binary": {
+                "inputProfile": {
                     "type": "string"
                 },
-                "valueBoolean": {
+                "instance": {
                     "type": "boolean"
                 },
-                "valueCanonical": {
-                    "type": "string"
+                "jurisdiction": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.CodeableConcept"
+                    }
                 },
-                "valueCode": {
-                    "type": "string"
+                "kind": {
+                    "$ref": "#/definitions/fhir.OperationKind"
                 },
-                "valueCodeableConcept": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
+                "language": {
+                    "type": "string"
                 },
-                "valueCoding": {
-                    "$ref": "#/definitions/fhir.Coding"
+                "meta": {
+                    "$ref": "#/definitions/fhir.Meta"
                 },
-                "valueContactDetail": {
-                    "$ref": "#/definitions/fhir.ContactDetail"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueContactPoint": {
-                    "$ref": "#/definitions/fhir.ContactPoint"
+                "name": {
+                    "type": "string"
                 },
-                "valueContributor": {
-                    "$ref": "#/definitions/fhir.Contributor"
+                "outputProfile": {
+                    "type": "string"
                 },
-                "valueCount": {
-                    "$ref": "#/definitions/fhir.Count"
+                "overload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.OperationDefinitionOverload"
+                    }
                 },
-                "valueDataRequirement": {
-                    "$ref": "#/definitions/fhir.DataRequirement"
+                "parameter": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.OperationDefinitionParameter"
+                    }
                 },
-                "valueDate": {
+                "publisher": {
                     "type": "string"
                 },
-                "valueDateTime": {
+                "purpose": {
                     "type": "string"
                 },
-                "valueDecimal": {
-                    "type": "string"
+                "resource": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.ResourceType"
+                    }
                 },
-                "valueDistance": {
-                    "$ref": "#/definitions/fhir.Distance"
+                "status": {
+                    "$ref": "#/definitions/fhir.PublicationStatus"
                 },
-                "valueDosage": {
-                    "$ref": "#/definitions/fhir.Dosage"
+                "system": {
+                    "type": "boolean"
                 },
-                "valueDuration": {
-                    "$ref": "#/definitions/fhir.Duration"
+                "text": {
+                    "$ref": "#/definitions/fhir.Narrative"
                 },
-                "valueExpression": {
-                    "$ref": "#/definitions/fhir.Expression"
+                "title": {
+                    "type": "string"
                 },
-                "valueHumanName": {
-                    "$ref": "#/definitions/fhir.HumanName"
+                "type": {
+                    "type": "boolean"
                 },
-                "valueId": {
+                "url": {
                     "type": "string"
                 },
-                "valueIdentifier": {
-                    "$ref": "#/definitions/fhir.Identifier"
+                "useContext": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.UsageContext"
+                    }
                 },
-                "valueInstant": {
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "fhir.OperationDefinitionOverload": {
+            "type": "object",
+            "properties": {
+                "comment": {
                     "type": "string"
                 },
-                "valueInteger": {
-                    "type": "integer"
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueMarkdown": {
+                "id": {
                     "type": "string"
                 },
-                "valueMeta": {
-                    "$ref": "#/definitions/fhir.Meta"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueMoney": {
-                    "$ref": "#/definitions/fhir.Money"
+                "parameterName": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "fhir.OperationDefinitionParameter": {
+            "type": "object",
+            "properties": {
+                "binding": {
+                    "$ref": "#/definitions/fhir.OperationDefinitionParameterBinding"
                 },
-                "valueOid": {
+                "documentation": {
                     "type": "string"
                 },
-                "valueParameterDefinition": {
-                    "$ref": "#/definitions/fhir.ParameterDefinition"
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valuePeriod": {
-                    "$ref": "#/definitions/fhir.Period"
+                "id": {
+                    "type": "string"
                 },
-                "valuePositiveInt": {
-                    "type": "integer"
+                "max": {
+                    "type": "string"
                 },
-                "valueQuantity": {
-                    "$ref": "#/definitions/fhir.Quantity"
+                "min": {
+                    "type": "integer"
                 },
-                "valueRange": {
-                    "$ref": "#/definitions/fhir.Range"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueRatio": {
-                    "$ref": "#/definitions/fhir.Ratio"
+                "name": {
+                    "type": "string"
                 },
-                "valueReference": {
-                    "$ref": "#/definitions/fhir.Reference"
+                "part": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.OperationDefinitionParameter"
+                    }
                 },
-                "valueRelatedArtifact": {
-                    "$ref": "#/definitions/fhir.RelatedArtifact"
+                "referencedFrom": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.OperationDefinitionParameterReferencedFrom"
+                    }
                 },
-                "valueSampledData": {
-                    "$ref": "#/definitions/fhir.SampledData"
+                "searchType": {
+                    "$ref": "#/definitions/fhir.SearchParamType"
                 },
-                "valueSignature": {
-                    "$ref": "#/definitions/fhir.Signature"
+                "targetProfile": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "valueString": {
+                "type": {
                     "type": "string"
                 },
-                "valueTime": {
-                    "type": "string"
+                "use": {
+                    "$ref": "#/definitions/fhir.OperationParameterUse"
+                }
+            }
+        },
+        "fhir.OperationDefinitionParameterBinding": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueTiming": {
-                    "$ref": "#/definitions/fhir.Timing"
+                "id": {
+                    "type": "string"
                 },
-                "valueTriggerDefinition": {
-                    "$ref": "#/definitions/fhir.TriggerDefinition"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueUnsignedInt": {
-                    "type": "integer"
+                "strength": {
+                    "$ref": "#/definitions/fhir.BindingStrength"
                 },
-                "valueUri": {
+                "valueSet": {
                     "type": "string"
+                }
+            }
+        },
+        "fhir.OperationDefinitionParameterReferencedFrom": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueUrl": {
+                "id": {
                     "type": "string"
                 },
-                "valueUsageContext": {
-                    "$ref": "#/definitions/fhir.UsageContext"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "valueUuid": {
+                "source": {
+                    "type": "string"
+                },
+                "sourceId": {
                     "type": "string"
                 }
             }
         },
-        "fhir.HTTPVerb": {
+        "fhir.OperationKind": {
             "type": "integer",
             "enum": [
                 0,
-                1,
-                2,
-                3,
-                4,
-                5
+                1
             ],
             "x-enum-varnames": [
-                "HTTPVerbGET",
-                "HTTPVerbHEAD",
-                "HTTPVerbPOST",
-                "HTTPVerbPUT",
-                "HTTPVerbDELETE",
-                "HTTPVerbPATCH"
+                "OperationKindOperation",
+                "OperationKindQuery"
             ]
         },
-        "fhir.HumanName": {
+        "fhir.OperationOutcome": {
             "type": "object",
             "properties": {
                 "extension": {
@@ -1868,46 +5127,52 @@ const docTemplate = `{
                         "$ref": "#/definitions/fhir.Extension"
                     }
                 },
-                "family": {
+                "id": {
                     "type": "string"
                 },
-                "given": {
+                "implicitRules": {
+                    "type": "string"
+                },
+                "issue": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/fhir.OperationOutcomeIssue"
                     }
                 },
-                "id": {
+                "language": {
                     "type": "string"
                 },
-                "period": {
-                    "$ref": "#/definitions/fhir.Period"
-                },
-                "prefix": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "meta": {
+                    "$ref": "#/definitions/fhir.Meta"
                 },
-                "suffix": {
+                "modifierExtension": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/fhir.Extension"
                     }
                 },
                 "text": {
-                    "type": "string"
-                },
-                "use": {
-                    "$ref": "#/definitions/fhir.NameUse"
+                    "$ref": "#/definitions/fhir.Narrative"
                 }
             }
         },
-        "fhir.Identifier": {
+        "fhir.OperationOutcomeIssue": {
             "type": "object",
             "properties": {
-                "assigner": {
-                    "$ref": "#/definitions/fhir.Reference"
+                "code": {
+                    "$ref": "#/definitions/fhir.IssueType"
+                },
+                "details": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "expression": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "extension": {
                     "type": "array",
@@ -1918,58 +5183,40 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "period": {
-                    "$ref": "#/definitions/fhir.Period"
-                },
-                "system": {
-                    "type": "string"
-                },
-                "type": {
-                    "$ref": "#/definitions/fhir.CodeableConcept"
+                "location": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "use": {
-                    "$ref": "#/definitions/fhir.IdentifierUse"
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
                 },
-                "value": {
-                    "type": "string"
+                "severity": {
+                    "$ref": "#/definitions/fhir.IssueSeverity"
                 }
             }
         },
-        "fhir.IdentifierUse": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4
-            ],
-            "x-enum-varnames": [
-                "IdentifierUseUsual",
-                "IdentifierUseOfficial",
-                "IdentifierUseTemp",
-                "IdentifierUseSecondary",
-                "IdentifierUseOld"
-            ]
-        },
-        "fhir.LinkType": {
+        "fhir.OperationParameterUse": {
             "type": "integer",
             "enum": [
                 0,
-                1,
-                2,
-                3
+                1
             ],
             "x-enum-varnames": [
-                "LinkTypeReplacedBy",
-                "LinkTypeReplaces",
-                "LinkTypeRefer",
-                "LinkTypeSeealso"
+                "OperationParameterUseIn",
+                "OperationParameterUseOut"
             ]
         },
-        "fhir.Meta": {
+        "fhir.ParameterDefinition": {
             "type": "object",
             "properties": {
+                "documentation": {
+                    "type": "string"
+                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -1979,41 +5226,52 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "lastUpdated": {
+                "max": {
+                    "type": "string"
+                },
+                "min": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
                 "profile": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                    "type": "string"
                 },
-                "security": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Coding"
-                    }
+                "type": {
+                    "type": "string"
                 },
-                "source": {
+                "use": {
+                    "$ref": "#/definitions/fhir.OperationParameterUse"
+                }
+            }
+        },
+        "fhir.Parameters": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "tag": {
+                "implicitRules": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/fhir.Meta"
+                },
+                "parameter": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/fhir.Coding"
+                        "$ref": "#/definitions/fhir.ParametersParameter"
                     }
-                },
-                "versionId": {
-                    "type": "string"
                 }
             }
         },
-        "fhir.Money": {
+        "fhir.ParametersParameter": {
             "type": "object",
             "properties": {
-                "currency": {
-                    "type": "string"
-                },
                 "extension": {
                     "type": "array",
                     "items": {
@@ -2023,110 +5281,176 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "value": {
+                "modifierExtension": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.Extension"
+                    }
+                },
+                "name": {
                     "type": "string"
-                }
-            }
-        },
-        "fhir.NameUse": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4,
-                5,
-                6
-            ],
-            "x-enum-varnames": [
-                "NameUseUsual",
-                "NameUseOfficial",
-                "NameUseTemp",
-                "NameUseNickname",
-                "NameUseAnonymous",
-                "NameUseOld",
-                "NameUseMaiden"
-            ]
-        },
-        "fhir.Narrative": {
-            "type": "object",
-            "properties": {
-                "div": {
+                },
+                "part": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fhir.ParametersParameter"
+                    }
+                },
+                "resource": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "valueAddress": {
+                    "$ref": "#/definitions/fhir.Address"
+                },
+                "valueAge": {
+                    "$ref": "#/definitions/fhir.Age"
+                },
+                "valueAnnotation": {
+                    "$ref": "#/definitions/fhir.Annotation"
+                },
+                "valueAttachment": {
+                    "$ref": "#/definitions/fhir.Attachment"
+                },
+                "valueBase64Binary": {
+                    "type": "string"
+                },
+                "valueBoolean": {
+                    "type": "boolean"
+                },
+                "valueCanonical": {
+                    "type": "string"
+                },
+                "valueCode": {
+                    "type": "string"
+                },
+                "valueCodeableConcept": {
+                    "$ref": "#/definitions/fhir.CodeableConcept"
+                },
+                "valueCoding": {
+                    "$ref": "#/definitions/fhir.Coding"
+                },
+                "valueContactDetail": {
+                    "$ref": "#/definitions/fhir.ContactDetail"
+                },
+                "valueContactPoint": {
+                    "$ref": "#/definitions/fhir.ContactPoint"
+                },
+                "valueContributor": {
+                    "$ref": "#/definitions/fhir.Contributor"
+                },
+                "valueCount": {
+                    "$ref": "#/definitions/fhir.Count"
+                },
+                "valueDataRequirement": {
+                    "$ref": "#/definitions/fhir.DataRequirement"
+                },
+                "valueDate": {
+                    "type": "string"
+                },
+                "valueDateTime": {
+                    "type": "string"
+                },
+                "valueDecimal": {
                     "type": "string"
                 },
-                "extension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
+                "valueDistance": {
+                    "$ref": "#/definitions/fhir.Distance"
+                },
+                "valueDosage": {
+                    "$ref": "#/definitions/fhir.Dosage"
+                },
+                "valueDuration": {
+                    "$ref": "#/definitions/fhir.Duration"
                 },
-                "id": {
+                "valueExpression": {
+                    "$ref": "#/definitions/fhir.Expression"
+                },
+                "valueHumanName": {
+                    "$ref": "#/definitions/fhir.HumanName"
+                },
+                "valueId": {
                     "type": "string"
                 },
-                "status": {
-                    "$ref": "#/definitions/fhir.NarrativeStatus"
-                }
-            }
-        },
-        "fhir.NarrativeStatus": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3
-            ],
-            "x-enum-varnames": [
-                "NarrativeStatusGenerated",
-                "NarrativeStatusExtensions",
-                "NarrativeStatusAdditional",
-                "NarrativeStatusEmpty"
-            ]
-        },
-        "fhir.OperationParameterUse": {
-            "type": "integer",
-            "enum": [
-                0,
-                1
-            ],
-            "x-enum-varnames": [
-                "OperationParameterUseIn",
-                "OperationParameterUseOut"
-            ]
-        },
-        "fhir.ParameterDefinition": {
-            "type": "object",
-            "properties": {
-                "documentation": {
+                "valueIdentifier": {
+                    "$ref": "#/definitions/fhir.Identifier"
+                },
+                "valueInstant": {
                     "type": "string"
                 },
-                "extension": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fhir.Extension"
-                    }
+                "valueInteger": {
+                    "type": "integer"
                 },
-                "id": {
+                "valueMarkdown": {
                     "type": "string"
                 },
-                "max": {
+                "valueMeta": {
+                    "$ref": "#/definitions/fhir.Meta"
+                },
+                "valueMoney": {
+                    "$ref": "#/definitions/fhir.Money"
+                },
+                "valueOid": {
                     "type": "string"
                 },
-                "min": {
+                "valueParameterDefinition": {
+                    "$ref": "#/definitions/fhir.ParameterDefinition"
+                },
+                "valuePeriod": {
+                    "$ref": "#/definitions/fhir.Period"
+                },
+                "valuePositiveInt": {
                     "type": "integer"
                 },
-                "name": {
+                "valueQuantity": {
+                    "$ref": "#/definitions/fhir.Quantity"
+                },
+                "valueRange": {
+                    "$ref": "#/definitions/fhir.Range"
+                },
+                "valueRatio": {
+                    "$ref": "#/definitions/fhir.Ratio"
+                },
+                "valueReference": {
+                    "$ref": "#/definitions/fhir.Reference"
+                },
+                "valueRelatedArtifact": {
+                    "$ref": "#/definitions/fhir.RelatedArtifact"
+                },
+                "valueSampledData": {
+                    "$ref": "#/definitions/fhir.SampledData"
+                },
+                "valueSignature": {
+                    "$ref": "#/definitions/fhir.Signature"
+                },
+                "valueString": {
                     "type": "string"
                 },
-                "profile": {
+                "valueTime": {
                     "type": "string"
                 },
-                "type": {
+                "valueTiming": {
+                    "$ref": "#/definitions/fhir.Timing"
+                },
+                "valueTriggerDefinition": {
+                    "$ref": "#/definitions/fhir.TriggerDefinition"
+                },
+                "valueUnsignedInt": {
+                    "type": "integer"
+                },
+                "valueUri": {
                     "type": "string"
                 },
-                "use": {
-                    "$ref": "#/definitions/fhir.OperationParameterUse"
+                "valueUrl": {
+                    "type": "string"
+                },
+                "valueUsageContext": {
+                    "$ref": "#/definitions/fhir.UsageContext"
+                },
+                "valueUuid": {
+                    "type": "string"
                 }
             }
         },
@@ -2362,6 +5686,21 @@ const docTemplate = `{
                 }
             }
         },
+        "fhir.PublicationStatus": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "PublicationStatusDraft",
+                "PublicationStatusActive",
+                "PublicationStatusRetired",
+                "PublicationStatusUnknown"
+            ]
+        },
         "fhir.Quantity": {
             "type": "object",
             "properties": {
@@ -2472,6 +5811,23 @@ const docTemplate = `{
                 }
             }
         },
+        "fhir.ReferenceHandlingPolicy": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4
+            ],
+            "x-enum-varnames": [
+                "ReferenceHandlingPolicyLiteral",
+                "ReferenceHandlingPolicyLogical",
+                "ReferenceHandlingPolicyResolves",
+                "ReferenceHandlingPolicyEnforced",
+                "ReferenceHandlingPolicyLocal"
+            ]
+        },
         "fhir.RelatedArtifact": {
             "type": "object",
             "properties": {
@@ -2530,6 +5886,333 @@ const docTemplate = `{
                 "RelatedArtifactTypeComposedOf"
             ]
         },
+        "fhir.ResourceType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8,
+                9,
+                10,
+                11,
+                12,
+                13,
+                14,
+                15,
+                16,
+                17,
+                18,
+                19,
+                20,
+                21,
+                22,
+                23,
+                24,
+                25,
+                26,
+                27,
+                28,
+                29,
+                30,
+                31,
+                32,
+                33,
+                34,
+                35,
+                36,
+                37,
+                38,
+                39,
+                40,
+                41,
+                42,
+                43,
+                44,
+                45,
+                46,
+                47,
+                48,
+                49,
+                50,
+                51,
+                52,
+                53,
+                54,
+                55,
+                56,
+                57,
+                58,
+                59,
+                60,
+                61,
+                62,
+                63,
+                64,
+                65,
+                66,
+                67,
+                68,
+                69,
+                70,
+                71,
+                72,
+                73,
+                74,
+                75,
+                76,
+                77,
+                78,
+                79,
+                80,
+                81,
+                82,
+                83,
+                84,
+                85,
+                86,
+                87,
+                88,
+                89,
+                90,
+                91,
+                92,
+                93,
+                94,
+                95,
+                96,
+                97,
+                98,
+                99,
+                100,
+                101,
+                102,
+                103,
+                104,
+                105,
+                106,
+                107,
+                108,
+                109,
+                110,
+                111,
+                112,
+                113,
+                114,
+                115,
+                116,
+                117,
+                118,
+                119,
+                120,
+                121,
+                122,
+                123,
+                124,
+                125,
+                126,
+                127,
+                128,
+                129,
+                130,
+                131,
+                132,
+                133,
+                134,
+                135,
+                136,
+                137,
+                138,
+                139,
+                140,
+                141,
+                142,
+                143,
+                144,
+                145,
+                146,
+                147
+            ],
+            "x-enum-varnames": [
+                "ResourceTypeAccount",
+                "ResourceTypeActivityDefinition",
+                "ResourceTypeAdverseEvent",
+                "ResourceTypeAllergyIntolerance",
+                "ResourceTypeAppointment",
+                "ResourceTypeAppointmentResponse",
+                "ResourceTypeAuditEvent",
+                "ResourceTypeBasic",
+                "ResourceTypeBinary",
+                "ResourceTypeBiologicallyDerivedProduct",
+                "ResourceTypeBodyStructure",
+                "ResourceTypeBundle",
+                "ResourceTypeCapabilityStatement",
+                "ResourceTypeCarePlan",
+                "ResourceTypeCareTeam",
+                "ResourceTypeCatalogEntry",
+                "ResourceTypeChargeItem",
+                "ResourceTypeChargeItemDefinition",
+                "ResourceTypeClaim",
+                "ResourceTypeClaimResponse",
+                "ResourceTypeClinicalImpression",
+                "ResourceTypeCodeSystem",
+                "ResourceTypeCommunication",
+                "ResourceTypeCommunicationRequest",
+                "ResourceTypeCompartmentDefinition",
+                "ResourceTypeComposition",
+                "ResourceTypeConceptMap",
+                "ResourceTypeCondition",
+                "ResourceTypeConsent",
+                "ResourceTypeContract",
+                "ResourceTypeCoverage",
+                "ResourceTypeCoverageEligibilityRequest",
+                "ResourceTypeCoverageEligibilityResponse",
+                "ResourceTypeDetectedIssue",
+                "ResourceTypeDevice",
+                "ResourceTypeDeviceDefinition",
+                "ResourceTypeDeviceMetric",
+                "ResourceTypeDeviceRequest",
+                "ResourceTypeDeviceUseStatement",
+                "ResourceTypeDiagnosticReport",
+                "ResourceTypeDocumentManifest",
+                "ResourceTypeDocumentReference",
+                "ResourceTypeDomainResource",
+                "ResourceTypeEffectEvidenceSynthesis",
+                "ResourceTypeEncounter",
+                "ResourceTypeEndpoint",
+                "ResourceTypeEnrollmentRequest",
+                "ResourceTypeEnrollmentResponse",
+                "ResourceTypeEpisodeOfCare",
+                "ResourceTypeEventDefinition",
+                "ResourceTypeEvidence",
+                "ResourceTypeEvidenceVariable",
+                "ResourceTypeExampleScenario",
+                "ResourceTypeExplanationOfBenefit",
+                "ResourceTypeFamilyMemberHistory",
+                "ResourceTypeFlag",
+                "ResourceTypeGoal",
+                "ResourceTypeGraphDefinition",
+                "ResourceTypeGroup",
+                "ResourceTypeGuidanceResponse",
+                "ResourceTypeHealthcareService",
+                "ResourceTypeImagingStudy",
+                "ResourceTypeImmunization",
+                "ResourceTypeImmunizationEvaluation",
+                "ResourceTypeImmunizationRecommendation",
+                "ResourceTypeImplementationGuide",
+                "ResourceTypeInsurancePlan",
+                "ResourceTypeInvoice",
+                "ResourceTypeLibrary",
+                "ResourceTypeLinkage",
+                "ResourceTypeList",
+                "ResourceTypeLocation",
+                "ResourceTypeMeasure",
+                "ResourceTypeMeasureReport",
+                "ResourceTypeMedia",
+                "ResourceTypeMedication",
+                "ResourceTypeMedicationAdministration",
+                "ResourceTypeMedicationDispense",
+                "ResourceTypeMedicationKnowledge",
+                "ResourceTypeMedicationRequest",
+                "ResourceTypeMedicationStatement",
+                "ResourceTypeMedicinalProduct",
+                "ResourceTypeMedicinalProductAuthorization",
+                "ResourceTypeMedicinalProductContraindication",
+                "ResourceTypeMedicinalProductIndication",
+                "ResourceTypeMedicinalProductIngredient",
+                "ResourceTypeMedicinalProductInteraction",
+                "ResourceTypeMedicinalProductManufactured",
+                "ResourceTypeMedicinalProductPackaged",
+                "ResourceTypeMedicinalProductPharmaceutical",
+                "ResourceTypeMedicinalProductUndesirableEffect",
+                "ResourceTypeMessageDefinition",
+                "ResourceTypeMessageHeader",
+                "ResourceTypeMolecularSequence",
+                "ResourceTypeNamingSystem",
+                "ResourceTypeNutritionOrder",
+                "ResourceTypeObservation",
+                "ResourceTypeObservationDefinition",
+                "ResourceTypeOperationDefinition",
+                "ResourceTypeOperationOutcome",
+                "ResourceTypeOrganization",
+                "ResourceTypeOrganizationAffiliation",
+                "ResourceTypeParameters",
+                "ResourceTypePatient",
+                "ResourceTypePaymentNotice",
+                "ResourceTypePaymentReconciliation",
+                "ResourceTypePerson",
+                "ResourceTypePlanDefinition",
+                "ResourceTypePractitioner",
+                "ResourceTypePractitionerRole",
+                "ResourceTypeProcedure",
+                "ResourceTypeProvenance",
+                "ResourceTypeQuestionnaire",
+                "ResourceTypeQuestionnaireResponse",
+                "ResourceTypeRelatedPerson",
+                "ResourceTypeRequestGroup",
+                "ResourceTypeResearchDefinition",
+                "ResourceTypeResearchElementDefinition",
+                "ResourceTypeResearchStudy",
+                "ResourceTypeResearchSubject",
+                "ResourceTypeResource",
+                "ResourceTypeRiskAssessment",
+                "ResourceTypeRiskEvidenceSynthesis",
+                "ResourceTypeSchedule",
+                "ResourceTypeSearchParameter",
+                "ResourceTypeServiceRequest",
+                "ResourceTypeSlot",
+                "ResourceTypeSpecimen",
+                "ResourceTypeSpecimenDefinition",
+                "ResourceTypeStructureDefinition",
+                "ResourceTypeStructureMap",
+                "ResourceTypeSubscription",
+                "ResourceTypeSubstance",
+                "ResourceTypeSubstanceNucleicAcid",
+                "ResourceTypeSubstancePolymer",
+                "ResourceTypeSubstanceProtein",
+                "ResourceTypeSubstanceReferenceInformation",
+                "ResourceTypeSubstanceSourceMaterial",
+                "ResourceTypeSubstanceSpecification",
+                "ResourceTypeSupplyDelivery",
+                "ResourceTypeSupplyRequest",
+                "ResourceTypeTask",
+                "ResourceTypeTerminologyCapabilities",
+                "ResourceTypeTestReport",
+                "ResourceTypeTestScript",
+                "ResourceTypeValueSet",
+                "ResourceTypeVerificationResult",
+                "ResourceTypeVisionPrescription"
+            ]
+        },
+        "fhir.ResourceVersionPolicy": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "ResourceVersionPolicyNoVersion",
+                "ResourceVersionPolicyVersioned",
+                "ResourceVersionPolicyVersionedUpdate"
+            ]
+        },
+        "fhir.RestfulCapabilityMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "RestfulCapabilityModeClient",
+                "RestfulCapabilityModeServer"
+            ]
+        },
         "fhir.SampledData": {
             "type": "object",
             "properties": {
@@ -2578,6 +6261,31 @@ const docTemplate = `{
                 "SearchEntryModeOutcome"
             ]
         },
+        "fhir.SearchParamType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8
+            ],
+            "x-enum-varnames": [
+                "SearchParamTypeNumber",
+                "SearchParamTypeDate",
+                "SearchParamTypeString",
+                "SearchParamTypeToken",
+                "SearchParamTypeReference",
+                "SearchParamTypeComposite",
+                "SearchParamTypeQuantity",
+                "SearchParamTypeUri",
+                "SearchParamTypeSpecial"
+            ]
+        },
         "fhir.Signature": {
             "type": "object",
             "properties": {
@@ -2627,6 +6335,47 @@ const docTemplate = `{
                 "SortDirectionDescending"
             ]
         },
+        "fhir.SystemRestfulInteraction": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8,
+                9,
+                10,
+                11,
+                12,
+                13,
+                14,
+                15,
+                16
+            ],
+            "x-enum-varnames": [
+                "SystemRestfulInteractionRead",
+                "SystemRestfulInteractionVread",
+                "SystemRestfulInteractionUpdate",
+                "SystemRestfulInteractionPatch",
+                "SystemRestfulInteractionDelete",
+                "SystemRestfulInteractionHistory",
+                "SystemRestfulInteractionHistoryInstance",
+                "SystemRestfulInteractionHistoryType",
+                "SystemRestfulInteractionHistorySystem",
+                "SystemRestfulInteractionCreate",
+                "SystemRestfulInteractionSearch",
+                "SystemRestfulInteractionSearchType",
+                "SystemRestfulInteractionSearchSystem",
+                "SystemRestfulInteractionCapabilities",
+                "SystemRestfulInteractionTransaction",
+                "SystemRestfulInteractionBatch",
+                "SystemRestfulInteractionOperation"
+            ]
+        },
         "fhir.Timing": {
             "type": "object",
             "properties": {
@@ -2797,6 +6546,47 @@ const docTemplate = `{
                 "TriggerTypeDataAccessEnded"
             ]
         },
+        "fhir.TypeRestfulInteraction": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8,
+                9,
+                10,
+                11,
+                12,
+                13,
+                14,
+                15,
+                16
+            ],
+            "x-enum-varnames": [
+                "TypeRestfulInteractionRead",
+                "TypeRestfulInteractionVread",
+                "TypeRestfulInteractionUpdate",
+                "TypeRestfulInteractionPatch",
+                "TypeRestfulInteractionDelete",
+                "TypeRestfulInteractionHistory",
+                "TypeRestfulInteractionHistoryInstance",
+                "TypeRestfulInteractionHistoryType",
+                "TypeRestfulInteractionHistorySystem",
+                "TypeRestfulInteractionCreate",
+                "TypeRestfulInteractionSearch",
+                "TypeRestfulInteractionSearchType",
+                "TypeRestfulInteractionSearchSystem",
+                "TypeRestfulInteractionCapabilities",
+                "TypeRestfulInteractionTransaction",
+                "TypeRestfulInteractionBatch",
+                "TypeRestfulInteractionOperation"
+            ]
+        },
         "fhir.UsageContext": {
             "type": "object",
             "properties": {
@@ -2825,6 +6615,49 @@ const docTemplate = `{
                     "$ref": "#/definitions/fhir.Reference"
                 }
             }
+        },
+        "handlers.BulkManifest": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BulkManifestOutput"
+                    }
+                },
+                "output": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BulkManifestOutput"
+                    }
+                },
+                "request": {
+                    "type": "string"
+                },
+                "requiresAccessToken": {
+                    "type": "boolean"
+                },
+                "transactionTime": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BulkManifestOutput": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "inputUrl": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// BulkHandlerInterface defines the contract for bulk data handlers
+type BulkHandlerInterface interface {
+	ExportSystem(c *gin.Context)
+	ExportPatients(c *gin.Context)
+	ImportPatients(c *gin.Context)
+	GetJobStatus(c *gin.Context)
+	DeleteJob(c *gin.Context)
+	GetJobFile(c *gin.Context)
+}
+
+// BulkHandler serves the FHIR Bulk Data export and import operations and the status and files
+// of their jobs
+type BulkHandler struct {
+	service domain.BulkService
+}
+
+// NewBulkHandler creates a new bulk data handler
+func NewBulkHandler(service domain.BulkService) BulkHandlerInterface {
+	return &BulkHandler{service: service}
+}
+
+// ndjsonContentType is the media type of the files bulk data jobs write
+const ndjsonContentType = "application/fhir+ndjson"
+
+// bulkRetryAfter is the number of seconds clients are asked to wait between status requests
+const bulkRetryAfter = "5"
+
+// ndjsonFormats are the _outputFormat and inputFormat values accepted, all meaning NDJSON
+var ndjsonFormats = []string{ndjsonContentType, "application/ndjson", "ndjson"}
+
+// ndjsonUploadTypes are the request content types of an NDJSON upload
+var ndjsonUploadTypes = []string{ndjsonContentType, "application/ndjson", "application/x-ndjson"}
+
+// BulkManifest is the response to a status request for a completed job. The errors of an
+// import are OperationOutcome files.
+type BulkManifest struct {
+	TransactionTime     string               `json:"transactionTime"`
+	Request             string               `json:"request"`
+	RequiresAccessToken bool                 `json:"requiresAccessToken"`
+	Output              []BulkManifestOutput `json:"output"`
+	Error               []BulkManifestOutput `json:"error"`
+}
+
+// BulkManifestOutput is a file listed in a BulkManifest, or the resources an import read from
+// one of its inputs
+type BulkManifestOutput struct {
+	Type     string `json:"type"`
+	URL      string `json:"url,omitempty"`
+	InputURL string `json:"inputUrl,omitempty"`
+	Count    int64  `json:"count"`
+}
+
+// ExportSystem handles GET /$export
+// @Summary Export all resources
+// @Description Start an asynchronous FHIR Bulk Data export of every resource on the server to NDJSON files, one per resource type. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll.
+// @Tags Bulk Data
+// @Produce json,xml
+// @Param Prefer header string true "respond-async"
+// @Param _outputFormat query string false "application/fhir+ndjson, the only format supported"
+// @Param _since query string false "Only resources updated after this instant"
+// @Param _type query string false "Comma-separated resource types to export, e.g. Patient,Observation"
+// @Success 202 {object} fhir.OperationOutcome
+// @Header 202 {string} Content-Location "Status URL of the export job"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /$export [get]
+func (h *BulkHandler) ExportSystem(c *gin.Context) {
+	h.export(c, false)
+}
+
+// ExportPatients handles GET /patients/$export
+// @Summary Export all patients
+// @Description Start an asynchronous FHIR Bulk Data export of every patient and the resources in their compartments to NDJSON files, one per resource type. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll.
+// @Tags Bulk Data
+// @Produce json,xml
+// @Param Prefer header string true "respond-async"
+// @Param _outputFormat query string false "application/fhir+ndjson, the only format supported"
+// @Param _since query string false "Only resources updated after this instant"
+// @Param _type query string false "Comma-separated resource types to export, e.g. Patient,Observation"
+// @Success 202 {object} fhir.OperationOutcome
+// @Header 202 {string} Content-Location "Status URL of the export job"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/$export [get]
+func (h *BulkHandler) ExportPatients(c *gin.Context) {
+	h.export(c, true)
+}
+
+// ImportPatients handles POST /patients/$import
+// @Summary Import patients
+// @Description Start an asynchronous import of patients from NDJSON, one Patient per line. The body is either the NDJSON itself, sent as application/fhir+ndjson, or a Parameters resource whose input parameters name NDJSON files by a url part relative to the server's import directory. Every line is validated and gets a server-assigned logical id. The request must carry Prefer: respond-async. The 202 response's Content-Location is the status URL to poll; the manifest of the completed import counts the patients imported from each input and lists an OperationOutcome file for the lines that failed.
+// @Tags Bulk Data
+// @Accept application/fhir+ndjson
+// @Accept json
+// @Produce json,xml
+// @Param Prefer header string true "respond-async"
+// @Param body body object true "NDJSON of Patient resources, or a Parameters resource with input parameters"
+// @Success 202 {object} fhir.OperationOutcome
+// @Header 202 {string} Content-Location "Status URL of the import job"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/$import [post]
+func (h *BulkHandler) ImportPatients(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ImportPatients")
+	defer span.End()
+
+	if _, ok := preference(c, "respond-async"); !ok {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Bulk data import requires the Prefer: respond-async header")
+		return
+	}
+	request := domain.ImportRequest{Request: requestBaseURL(c) + c.Request.URL.RequestURI()}
+	if mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); containsFold(ndjsonUploadTypes, mediaType) {
+		request.Upload = c.Request.Body
+	} else {
+		body, err := c.GetRawData()
+		if err != nil || !fhirpatch.IsParameters(body) {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be NDJSON or a Parameters resource")
+			return
+		}
+		if request.Paths, err = parseImportParameters(body); err != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid parameters: "+err.Error())
+			return
+		}
+	}
+
+	job, err := h.service.StartImport(ctx, request)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to start bulk import: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Location", bulkBaseURL(c)+"/$bulk-status/"+job.ID)
+	c.JSON(http.StatusAccepted, outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational,
+		fmt.Sprintf("Started bulk import %s", job.ID)))
+}
+
+// export starts an export job for a kick-off request
+func (h *BulkHandler) export(c *gin.Context, patient bool) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Export")
+	defer span.End()
+
+	if _, ok := preference(c, "respond-async"); !ok {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Bulk data export requires the Prefer: respond-async header")
+		return
+	}
+	request, err := parseExportRequest(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid parameters: "+err.Error())
+		return
+	}
+	request.Patient = patient
+
+	job, err := h.service.StartExport(ctx, request)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to start bulk export: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Location", bulkBaseURL(c)+"/$bulk-status/"+job.ID)
+	c.JSON(http.StatusAccepted, outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational,
+		fmt.Sprintf("Started bulk export %s", job.ID)))
+}
+
+// GetJobStatus handles GET /$bulk-status/{id}
+// @Summary Get the status of a bulk data job
+// @Description Poll a bulk data job. A running job answers 202 with its progress in X-Progress; a completed export answers 200 with the manifest of its files; a failed job answers 500 with an OperationOutcome.
+// @Tags Bulk Data
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} BulkManifest
+// @Success 202 "The job is still running"
+// @Header 202 {string} X-Progress "Progress of the job"
+// @Header 202 {string} Retry-After "Seconds to wait before polling again"
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /$bulk-status/{id} [get]
+func (h *BulkHandler) GetJobStatus(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetJobStatus")
+	defer span.End()
+
+	id := c.Param("id")
+	job, err := h.service.GetJob(ctx, id)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get bulk data job %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	switch job.Status {
+	case domain.BulkJobInProgress:
+		if job.Progress != "" {
+			c.Header("X-Progress", job.Progress)
+		}
+		c.Header("Retry-After", bulkRetryAfter)
+		c.Status(http.StatusAccepted)
+	case domain.BulkJobFailed:
+		outcome.Write(c, http.StatusInternalServerError, fhir.IssueTypeException, job.Error)
+	default:
+		manifest, err := bulkManifest(c, job)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to read the output of bulk data job %s: %v", id, err)
+			outcome.Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, manifest)
+	}
+}
+
+// DeleteJob handles DELETE /$bulk-status/{id}
+// @Summary Cancel a bulk data job
+// @Description Cancel a running bulk data job, or remove a finished one, along with the files it wrote.
+// @Tags Bulk Data
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 202 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /$bulk-status/{id} [delete]
+func (h *BulkHandler) DeleteJob(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "DeleteJob")
+	defer span.End()
+
+	id := c.Param("id")
+	if err := h.service.DeleteJob(ctx, id); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete bulk data job %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational,
+		fmt.Sprintf("Deleted bulk data job %s", id)))
+}
+
+// GetJobFile handles GET /$bulk-files/{id}/{name}
+// @Summary Download a bulk data file
+// @Description Download an NDJSON file listed in the manifest of a completed export.
+// @Tags Bulk Data
+// @Produce application/fhir+ndjson
+// @Param id path string true "Job ID"
+// @Param name path string true "File name"
+// @Success 200 {file} file
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /$bulk-files/{id}/{name} [get]
+func (h *BulkHandler) GetJobFile(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetJobFile")
+	defer span.End()
+
+	id, name := c.Param("id"), c.Param("name")
+	path, err := h.service.JobFile(ctx, id, name)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get file %s of bulk data job %s: %v", name, id, err)
+		outcome.Error(c, err)
+		return
+	}
+	c.Header("Content-Type", ndjsonContentType)
+	c.File(path)
+}
+
+// parseExportRequest reads the parameters of an export kick-off request
+func parseExportRequest(c *gin.Context) (domain.ExportRequest, error) {
+	request := domain.ExportRequest{Request: requestBaseURL(c) + c.Request.URL.RequestURI()}
+
+	if outputFormat := c.Query("_outputFormat"); outputFormat != "" && !containsFold(ndjsonFormats, outputFormat) {
+		return request, fmt.Errorf("_outputFormat %q is not supported", outputFormat)
+	}
+	if since := c.Query("_since"); since != "" {
+		start, _, err := fhirsearch.ParseDateRange(since)
+		if err != nil {
+			return request, fmt.Errorf("invalid _since value: %w", err)
+		}
+		request.Since = &start
+	}
+	if types := c.Query("_type"); types != "" {
+		request.Types = fhirsearch.SplitValues(types)
+	}
+	return request, nil
+}
+
+// parseImportParameters reads the files named by the input parameters of an import request.
+// Each input has a url part and an optional type part, which must be Patient.
+func parseImportParameters(body []byte) ([]string, error) {
+	var parameters fhir.Parameters
+	if err := json.Unmarshal(body, &parameters); err != nil {
+		return nil, fmt.Errorf("invalid Parameters resource: %w", err)
+	}
+
+	var paths []string
+	for _, parameter := range parameters.Parameter {
+		switch parameter.Name {
+		case "inputFormat":
+			if format := parameterString(parameter); !containsFold(ndjsonFormats, format) {
+				return nil, fmt.Errorf("inputFormat %q is not supported", format)
+			}
+		case "input":
+			var path string
+			for _, part := range parameter.Part {
+				switch part.Name {
+				case "type":
+					if resourceType := parameterString(part); resourceType != "Patient" {
+						return nil, fmt.Errorf("input type %q is not supported; only Patient can be imported", resourceType)
+					}
+				case "url":
+					path = parameterString(part)
+				}
+			}
+			if path == "" {
+				return nil, errors.New("every input must have a url part")
+			}
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("Parameters must contain at least one input parameter")
+	}
+	return paths, nil
+}
+
+// parameterString returns the value of a parameter given as a string, code, uri or url
+func parameterString(parameter fhir.ParametersParameter) string {
+	for _, value := range []*string{parameter.ValueString, parameter.ValueCode, parameter.ValueUri, parameter.ValueUrl} {
+		if value != nil {
+			return *value
+		}
+	}
+	return ""
+}
+
+// bulkManifest lists the files of a completed job with their download URLs
+func bulkManifest(c *gin.Context, job *domain.BulkJob) (BulkManifest, error) {
+	var files []domain.BulkFile
+	if err := json.Unmarshal(job.Output, &files); err != nil {
+		return BulkManifest{}, err
+	}
+	manifest := BulkManifest{
+		TransactionTime: job.TransactionTime.UTC().Format(time.RFC3339Nano),
+		Request:         job.Request,
+		Output:          make([]BulkManifestOutput, 0, len(files)),
+		Error:           []BulkManifestOutput{},
+	}
+	base := bulkBaseURL(c)
+	for _, file := range files {
+		output := BulkManifestOutput{Type: file.Type, InputURL: file.Input, Count: file.Count}
+		if file.Name != "" {
+			output.URL = base + "/$bulk-files/" + job.ID + "/" + file.Name
+		}
+		if job.Kind == domain.BulkJobImport && file.Type == "OperationOutcome" {
+			manifest.Error = append(manifest.Error, output)
+		} else {
+			manifest.Output = append(manifest.Output, output)
+		}
+	}
+	return manifest, nil
+}
+
+// bulkBaseURL returns the absolute URL of the API version the current bulk data route belongs to
+func bulkBaseURL(c *gin.Context) string {
+	path := c.FullPath()
+	if idx := strings.Index(path, "/$"); idx >= 0 {
+		path = path[:idx]
+	}
+	return requestBaseURL(c) + strings.TrimSuffix(path, "/patients")
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
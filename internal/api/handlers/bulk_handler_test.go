@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type BulkHandlerTestSuite struct {
+	suite.Suite
+	mockCtrl    *gomock.Controller
+	mockService *mocks.MockBulkService
+	router      *gin.Engine
+}
+
+func (suite *BulkHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.mockCtrl = gomock.NewController(suite.T())
+	suite.mockService = mocks.NewMockBulkService(suite.mockCtrl)
+	handler := NewBulkHandler(suite.mockService)
+	router := gin.New()
+	router.GET("/api/v1/$export", handler.ExportSystem)
+	router.GET("/api/v1/patients/$export", handler.ExportPatients)
+	router.POST("/api/v1/patients/$import", handler.ImportPatients)
+	router.GET("/api/v1/$bulk-status/:id", handler.GetJobStatus)
+	router.DELETE("/api/v1/$bulk-status/:id", handler.DeleteJob)
+	router.GET("/api/v1/$bulk-files/:id/:name", handler.GetJobFile)
+	suite.router = router
+}
+
+func (suite *BulkHandlerTestSuite) TearDownTest() {
+	suite.mockCtrl.Finish()
+}
+
+func TestBulkHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkHandlerTestSuite))
+}
+
+func (suite *BulkHandlerTestSuite) request(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	return suite.requestBody(method, path, headers, "")
+}
+
+func (suite *BulkHandlerTestSuite) requestBody(method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "example.com"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BulkHandlerTestSuite) TestExport_RequiresRespondAsync() {
+	w := suite.request("GET", "/api/v1/$export", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "respond-async")
+}
+
+func (suite *BulkHandlerTestSuite) TestExport_UnsupportedOutputFormat() {
+	w := suite.request("GET", "/api/v1/$export?_outputFormat=text/csv", map[string]string{"Prefer": "respond-async"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "_outputFormat")
+}
+
+func (suite *BulkHandlerTestSuite) TestExportPatients_Accepted() {
+	since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	suite.mockService.EXPECT().
+		StartExport(gomock.Any(), domain.ExportRequest{
+			Request: "http://example.com/api/v1/patients/$export?_type=Patient,Observation&_since=2025-01-02T03:04:05Z",
+			Patient: true,
+			Types:   []string{"Patient", "Observation"},
+			Since:   &since,
+		}).
+		Return(&domain.BulkJob{ID: "job-1", Status: domain.BulkJobInProgress}, nil)
+
+	w := suite.request("GET", "/api/v1/patients/$export?_type=Patient,Observation&_since=2025-01-02T03:04:05Z",
+		map[string]string{"Prefer": "respond-async"})
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Equal(suite.T(), "http://example.com/api/v1/$bulk-status/job-1", w.Header().Get("Content-Location"))
+}
+
+func (suite *BulkHandlerTestSuite) TestExport_InvalidType() {
+	suite.mockService.EXPECT().
+		StartExport(gomock.Any(), gomock.Any()).
+		Return(nil, domain.ErrValidation)
+
+	w := suite.request("GET", "/api/v1/$export?_type=Unknown", map[string]string{"Prefer": "respond-async"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BulkHandlerTestSuite) TestImport_RequiresRespondAsync() {
+	w := suite.requestBody("POST", "/api/v1/patients/$import", map[string]string{"Content-Type": "application/fhir+ndjson"}, "{}\n")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "respond-async")
+}
+
+func (suite *BulkHandlerTestSuite) TestImport_Upload() {
+	suite.mockService.EXPECT().
+		StartImport(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, request domain.ImportRequest) (*domain.BulkJob, error) {
+			assert.Empty(suite.T(), request.Paths)
+			data, err := io.ReadAll(request.Upload)
+			suite.Require().NoError(err)
+			assert.Equal(suite.T(), `{"resourceType":"Patient"}`+"\n", string(data))
+			return &domain.BulkJob{ID: "job-1", Kind: domain.BulkJobImport, Status: domain.BulkJobInProgress}, nil
+		})
+
+	w := suite.requestBody("POST", "/api/v1/patients/$import",
+		map[string]string{"Prefer": "respond-async", "Content-Type": "application/fhir+ndjson"},
+		`{"resourceType":"Patient"}`+"\n")
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Equal(suite.T(), "http://example.com/api/v1/$bulk-status/job-1", w.Header().Get("Content-Location"))
+}
+
+func (suite *BulkHandlerTestSuite) TestImport_Parameters() {
+	suite.mockService.EXPECT().
+		StartImport(gomock.Any(), domain.ImportRequest{
+			Request: "http://example.com/api/v1/patients/$import",
+			Paths:   []string{"file://patients.ndjson", "more/patients.ndjson"},
+		}).
+		Return(&domain.BulkJob{ID: "job-1", Kind: domain.BulkJobImport, Status: domain.BulkJobInProgress}, nil)
+
+	w := suite.requestBody("POST", "/api/v1/patients/$import",
+		map[string]string{"Prefer": "respond-async", "Content-Type": "application/fhir+json"},
+		`{"resourceType":"Parameters","parameter":[
+			{"name":"inputFormat","valueCode":"application/fhir+ndjson"},
+			{"name":"input","part":[{"name":"type","valueCode":"Patient"},{"name":"url","valueUrl":"file://patients.ndjson"}]},
+			{"name":"input","part":[{"name":"url","valueUrl":"more/patients.ndjson"}]}
+		]}`)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+}
+
+func (suite *BulkHandlerTestSuite) TestImport_UnsupportedInputType() {
+	w := suite.requestBody("POST", "/api/v1/patients/$import",
+		map[string]string{"Prefer": "respond-async", "Content-Type": "application/fhir+json"},
+		`{"resourceType":"Parameters","parameter":[
+			{"name":"input","part":[{"name":"type","valueCode":"Observation"},{"name":"url","valueUrl":"observations.ndjson"}]}
+		]}`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "only Patient can be imported")
+}
+
+func (suite *BulkHandlerTestSuite) TestImport_RequiresInput() {
+	w := suite.requestBody("POST", "/api/v1/patients/$import",
+		map[string]string{"Prefer": "respond-async", "Content-Type": "application/fhir+json"},
+		`{"resourceType":"Parameters","parameter":[]}`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobStatus_InProgress() {
+	suite.mockService.EXPECT().
+		GetJob(gomock.Any(), "job-1").
+		Return(&domain.BulkJob{ID: "job-1", Status: domain.BulkJobInProgress, Progress: "Exported 1 of 3 resource types"}, nil)
+
+	w := suite.request("GET", "/api/v1/$bulk-status/job-1", nil)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Equal(suite.T(), "Exported 1 of 3 resource types", w.Header().Get("X-Progress"))
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobStatus_Completed() {
+	suite.mockService.EXPECT().
+		GetJob(gomock.Any(), "job-1").
+		Return(&domain.BulkJob{
+			ID:              "job-1",
+			Status:          domain.BulkJobCompleted,
+			Request:         "http://example.com/api/v1/$export",
+			Output:          []byte(`[{"type":"Patient","name":"Patient.ndjson","count":2}]`),
+			TransactionTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}, nil)
+
+	w := suite.request("GET", "/api/v1/$bulk-status/job-1", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{
+		"transactionTime": "2025-01-02T03:04:05Z",
+		"request": "http://example.com/api/v1/$export",
+		"requiresAccessToken": false,
+		"output": [{"type": "Patient", "url": "http://example.com/api/v1/$bulk-files/job-1/Patient.ndjson", "count": 2}],
+		"error": []
+	}`, w.Body.String())
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobStatus_ImportCompleted() {
+	suite.mockService.EXPECT().
+		GetJob(gomock.Any(), "job-1").
+		Return(&domain.BulkJob{
+			ID:      "job-1",
+			Kind:    domain.BulkJobImport,
+			Status:  domain.BulkJobCompleted,
+			Request: "http://example.com/api/v1/patients/$import",
+			Output: []byte(`[{"type":"Patient","input":"patients.ndjson","count":9},
+				{"type":"OperationOutcome","name":"errors.ndjson","count":1}]`),
+			TransactionTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}, nil)
+
+	w := suite.request("GET", "/api/v1/$bulk-status/job-1", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{
+		"transactionTime": "2025-01-02T03:04:05Z",
+		"request": "http://example.com/api/v1/patients/$import",
+		"requiresAccessToken": false,
+		"output": [{"type": "Patient", "inputUrl": "patients.ndjson", "count": 9}],
+		"error": [{"type": "OperationOutcome", "url": "http://example.com/api/v1/$bulk-files/job-1/errors.ndjson", "count": 1}]
+	}`, w.Body.String())
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobStatus_Failed() {
+	suite.mockService.EXPECT().
+		GetJob(gomock.Any(), "job-1").
+		Return(&domain.BulkJob{ID: "job-1", Status: domain.BulkJobFailed, Error: "disk full"}, nil)
+
+	w := suite.request("GET", "/api/v1/$bulk-status/job-1", nil)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "disk full")
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobStatus_NotFound() {
+	suite.mockService.EXPECT().
+		GetJob(gomock.Any(), "missing").
+		Return(nil, domain.ErrNotFound)
+
+	w := suite.request("GET", "/api/v1/$bulk-status/missing", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *BulkHandlerTestSuite) TestDeleteJob() {
+	suite.mockService.EXPECT().DeleteJob(gomock.Any(), "job-1").Return(nil)
+
+	w := suite.request("DELETE", "/api/v1/$bulk-status/job-1", nil)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+}
+
+func (suite *BulkHandlerTestSuite) TestGetJobFile() {
+	path := filepath.Join(suite.T().TempDir(), "Patient.ndjson")
+	suite.Require().NoError(os.WriteFile(path, []byte(`{"resourceType":"Patient","id":"1"}`+"\n"), 0o644))
+	suite.mockService.EXPECT().JobFile(gomock.Any(), "job-1", "Patient.ndjson").Return(path, nil)
+
+	w := suite.request("GET", "/api/v1/$bulk-files/job-1/Patient.ndjson", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/fhir+ndjson", w.Header().Get("Content-Type"))
+	var patient map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &patient))
+	assert.Equal(suite.T(), "1", patient["id"])
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirbundle"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// BundleHandlerInterface defines the contract for bundle handlers
+type BundleHandlerInterface interface {
+	ProcessBundle(c *gin.Context)
+}
+
+// BundleHandler struct
+type BundleHandler struct {
+	service  domain.BundleService
+	patients domain.PatientService
+}
+
+// NewBundleHandler creates a new bundle handler. Patients in the response bundle are rendered
+// by the patient service.
+func NewBundleHandler(service domain.BundleService, patients domain.PatientService) BundleHandlerInterface {
+	return &BundleHandler{
+		service:  service,
+		patients: patients,
+	}
+}
+
+// ProcessBundle handles POST /
+// @Summary Process a batch or transaction Bundle
+// @Description Execute the Patient interactions of a batch or transaction Bundle. Batch entries succeed or fail independently. Transaction entries run in a single database transaction that is rolled back when any entry fails, and urn:uuid fullUrls of created patients are resolved in references between entries.
+// @Tags Bundle
+// @Accept json,xml
+// @Produce json,xml
+// @Param bundle body fhir.Bundle true "FHIR Bundle of type batch or transaction"
+// @Success 200 {object} fhir.Bundle "A batch-response or transaction-response Bundle"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 422 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router / [post]
+func (h *BundleHandler) ProcessBundle(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ProcessBundle")
+	defer span.End()
+
+	body, err := c.GetRawData()
+	if err != nil || resourceType(body) != "Bundle" {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be a Bundle resource")
+		return
+	}
+	var bundle fhir.Bundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to parse bundle: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid Bundle: "+err.Error())
+		return
+	}
+	transaction := bundle.Type == fhir.BundleTypeTransaction
+	if !transaction && bundle.Type != fhir.BundleTypeBatch {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Bundle type must be batch or transaction")
+		return
+	}
+
+	// A malformed transaction entry rejects the whole bundle, a malformed batch entry only itself
+	requests := make([]domain.BundleEntryRequest, 0, len(bundle.Entry))
+	positions := make([]int, 0, len(bundle.Entry))
+	results := make([]domain.BundleEntryResult, len(bundle.Entry))
+	for i, entry := range bundle.Entry {
+		request, err := parseBundleEntry(entry)
+		if err != nil {
+			if transaction {
+				outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, fmt.Sprintf("Invalid entry %d: %v", i, err))
+				return
+			}
+			results[i] = domain.BundleEntryResult{Err: fmt.Errorf("%w: %v", domain.ErrValidation, err)}
+			continue
+		}
+		requests = append(requests, request)
+		positions = append(positions, i)
+	}
+
+	responseType := fhir.BundleTypeBatchResponse
+	var processed []domain.BundleEntryResult
+	if transaction {
+		responseType = fhir.BundleTypeTransactionResponse
+		processed, err = h.service.ProcessTransaction(ctx, requests)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to process transaction: %v", err)
+			outcome.Error(c, err)
+			return
+		}
+	} else {
+		processed = h.service.ProcessBatch(ctx, requests)
+	}
+	for j, result := range processed {
+		results[positions[j]] = result
+	}
+
+	patientsURL := requestBaseURL(c) + c.FullPath() + "/patients"
+	entries := make([]fhir.BundleEntry, len(results))
+	for i, result := range results {
+		entries[i] = h.responseEntry(ctx, patientsURL, result)
+	}
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	c.JSON(http.StatusOK, fhir.Bundle{
+		Type:      responseType,
+		Timestamp: &timestamp,
+		Entry:     entries,
+	})
+}
+
+// responseEntry renders the result of one entry as a response bundle entry
+func (h *BundleHandler) responseEntry(ctx context.Context, patientsURL string, result domain.BundleEntryResult) fhir.BundleEntry {
+	if result.Err != nil {
+		return errorEntry(ctx, result.Err)
+	}
+
+	entry := fhir.BundleEntry{Response: &fhir.BundleEntryResponse{Status: statusLine(result.Status)}}
+	var (
+		resource interface{}
+		err      error
+	)
+	switch {
+	case result.Patient != nil:
+		resource, err = h.patients.ConvertToFHIR(ctx, result.Patient)
+		setEntryVersion(&entry, patientsURL, result.Patient.LogicalID, result.Patient.VersionID, result.Patient.UpdatedAt)
+		if result.Status == http.StatusCreated {
+			location := fmt.Sprintf("patients/%s/_history/%d", result.Patient.LogicalID, result.Patient.VersionID)
+			entry.Response.Location = &location
+		}
+	case result.Version != nil:
+		resource, err = h.patients.ConvertHistoryToFHIR(ctx, result.Version)
+		setEntryVersion(&entry, patientsURL, result.Version.LogicalID, result.Version.VersionID, result.Version.CreatedAt)
+	case result.Status == http.StatusNoContent:
+		// Deletes have no resource
+		return entry
+	default:
+		resource, err = h.searchSet(ctx, patientsURL, result)
+	}
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to render bundle entry: %v", err)
+		return errorEntry(ctx, err)
+	}
+
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return errorEntry(ctx, fmt.Errorf("failed to marshal bundle entry resource: %w", err))
+	}
+	entry.Resource = raw
+	return entry
+}
+
+// searchSet renders the patients found by a search entry as a searchset Bundle
+func (h *BundleHandler) searchSet(ctx context.Context, patientsURL string, result domain.BundleEntryResult) (*fhir.Bundle, error) {
+	entries := make([]fhir.BundleEntry, 0, len(result.Matches))
+	for _, patient := range result.Matches {
+		fhirPatient, err := h.patients.ConvertToFHIR(ctx, patient)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := fhirbundle.NewEntry(patientsURL+"/"+patient.LogicalID, fhirPatient, fhir.SearchEntryModeMatch)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return fhirbundle.NewSearchSet(result.Total, entries, nil), nil
+}
+
+// setEntryVersion sets the fullUrl, ETag and last modified time of a response entry
+func setEntryVersion(entry *fhir.BundleEntry, patientsURL, logicalID string, versionID int, modified time.Time) {
+	fullURL := patientsURL + "/" + logicalID
+	tag := etag(versionID)
+	lastModified := modified.UTC().Format(time.RFC3339)
+	entry.FullUrl = &fullURL
+	entry.Response.Etag = &tag
+	entry.Response.LastModified = &lastModified
+}
+
+// errorEntry reports a failed entry with its status and OperationOutcome
+func errorEntry(ctx context.Context, err error) fhir.BundleEntry {
+	status, result := outcome.FromError(ctx, err)
+	raw, _ := json.Marshal(result)
+	return fhir.BundleEntry{
+		Response: &fhir.BundleEntryResponse{Status: statusLine(status), Outcome: raw},
+	}
+}
+
+// statusLine formats an HTTP status as a bundle response status, e.g. "201 Created"
+func statusLine(status int) string {
+	return fmt.Sprintf("%d %s", status, http.StatusText(status))
+}
+
+// parseBundleEntry reads the Patient interaction of a batch or transaction entry
+func parseBundleEntry(entry fhir.BundleEntry) (domain.BundleEntryRequest, error) {
+	var request domain.BundleEntryRequest
+	if entry.Request == nil {
+		return request, errors.New("entry.request is required")
+	}
+	request.Method = entry.Request.Method.Code()
+	if entry.FullUrl != nil {
+		request.FullURL = *entry.FullUrl
+	}
+	if entry.Request.IfMatch != nil {
+		version, err := parseIfMatchValue(*entry.Request.IfMatch)
+		if err != nil {
+			return request, err
+		}
+		request.ExpectedVersion = version
+	}
+
+	target, err := url.Parse(entry.Request.Url)
+	if err != nil {
+		return request, fmt.Errorf("invalid request url %q", entry.Request.Url)
+	}
+	segments, ok := patientPath(target.Path)
+	if !ok {
+		return request, fmt.Errorf("request url %q does not address Patient resources", entry.Request.Url)
+	}
+	if len(segments) > 0 {
+		if !logicalIDPattern.MatchString(segments[0]) {
+			return request, fmt.Errorf("invalid Patient ID %q", segments[0])
+		}
+		request.ID = segments[0]
+	}
+
+	switch {
+	case request.Method == http.MethodGet && len(segments) == 0:
+		request.Criteria, err = fhirsearch.Parse(target.Query(), domain.PatientSearchParameters)
+	case request.Method == http.MethodGet && len(segments) == 1:
+	case request.Method == http.MethodGet && len(segments) == 3 && segments[1] == "_history":
+		request.VersionID, err = strconv.Atoi(segments[2])
+		if err != nil || request.VersionID < 1 {
+			err = fmt.Errorf("invalid version %q", segments[2])
+		}
+	case request.Method == http.MethodPost && len(segments) == 0:
+		if entry.Request.IfNoneExist != nil {
+			request.Criteria, err = parseIfNoneExist(*entry.Request.IfNoneExist)
+		}
+	case (request.Method == http.MethodPut || request.Method == http.MethodDelete) && len(segments) == 0:
+		request.Criteria, err = parseConditionalCriteria(target.Query())
+	case (request.Method == http.MethodPut || request.Method == http.MethodDelete) && len(segments) == 1:
+	case request.Method == http.MethodPatch && len(segments) == 1:
+		request.Resource, request.PatchFormat, err = parsePatchResource(entry.Resource)
+		return request, err
+	default:
+		return request, fmt.Errorf("%s %s is not supported", request.Method, entry.Request.Url)
+	}
+	if err != nil {
+		return request, err
+	}
+
+	if request.Method == http.MethodPost || request.Method == http.MethodPut {
+		if len(entry.Resource) == 0 {
+			return request, fmt.Errorf("%s entries require a resource", request.Method)
+		}
+		request.Resource = entry.Resource
+	}
+	return request, nil
+}
+
+// patientPath returns the path segments following the resource type of an entry request url.
+// Both the Patient type and this server's patients collection are accepted, relative or absolute.
+func patientPath(path string) ([]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "Patient" || segment == "patients" {
+			return segments[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// parsePatchResource reads the patch document of a PATCH entry: a FHIRPath Patch Parameters
+// resource, or a Binary holding a base64 encoded JSON Patch
+func parsePatchResource(resource json.RawMessage) ([]byte, string, error) {
+	if fhirpatch.IsParameters(resource) {
+		return resource, domain.PatchFormatFHIRPathPatch, nil
+	}
+	if resourceType(resource) != "Binary" {
+		return nil, "", errors.New("PATCH entries require a Parameters or Binary resource")
+	}
+
+	var binary fhir.Binary
+	if err := json.Unmarshal(resource, &binary); err != nil {
+		return nil, "", fmt.Errorf("invalid Binary resource: %w", err)
+	}
+	if binary.ContentType != "application/json-patch+json" || binary.Data == nil {
+		return nil, "", errors.New("Binary patches must contain application/json-patch+json data")
+	}
+	patch, err := base64.StdEncoding.DecodeString(*binary.Data)
+	if err != nil || !json.Valid(patch) {
+		return nil, "", errors.New("Binary patch data must be base64 encoded JSON")
+	}
+	return patch, domain.PatchFormatJSONPatch, nil
+}
+
+// resourceType returns the resourceType of a JSON resource, or an empty string
+func resourceType(resource []byte) string {
+	var probe struct {
+		ResourceType string `json:"resourceType"`
+	}
+	if json.Unmarshal(resource, &probe) != nil {
+		return ""
+	}
+	return probe.ResourceType
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type BundleHandlerTestSuite struct {
+	suite.Suite
+	mockCtrl     *gomock.Controller
+	mockService  *mocks.MockBundleService
+	mockPatients *mocks.MockPatientService
+	router       *gin.Engine
+}
+
+func (suite *BundleHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.mockCtrl = gomock.NewController(suite.T())
+	suite.mockService = mocks.NewMockBundleService(suite.mockCtrl)
+	suite.mockPatients = mocks.NewMockPatientService(suite.mockCtrl)
+	handler := NewBundleHandler(suite.mockService, suite.mockPatients)
+	router := gin.New()
+	router.POST("/api/v1", handler.ProcessBundle)
+	suite.router = router
+
+	suite.mockPatients.EXPECT().
+		ConvertToFHIR(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, patient *domain.Patient) (*fhir.Patient, error) {
+			return &fhir.Patient{Id: utils.CreateStringPtr(patient.LogicalID)}, nil
+		})
+}
+
+func (suite *BundleHandlerTestSuite) TearDownTest() {
+	suite.mockCtrl.Finish()
+}
+
+func TestBundleHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(BundleHandlerTestSuite))
+}
+
+func (suite *BundleHandlerTestSuite) post(body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/api/v1", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_Transaction() {
+	body := `{"resourceType":"Bundle","type":"transaction","entry":[
+		{"fullUrl":"urn:uuid:61ebe359-bfdc-4613-8bf2-c5e300945f0a","resource":{"resourceType":"Patient"},
+		 "request":{"method":"POST","url":"Patient","ifNoneExist":"identifier=urn:mrn|1"}},
+		{"request":{"method":"GET","url":"Patient?family=Doe"}},
+		{"request":{"method":"DELETE","url":"Patient/9","ifMatch":"W/\"2\""}}]}`
+
+	suite.mockService.EXPECT().
+		ProcessTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, requests []domain.BundleEntryRequest) ([]domain.BundleEntryResult, error) {
+			suite.Require().Len(requests, 3)
+			assert.Equal(suite.T(), http.MethodPost, requests[0].Method)
+			assert.Equal(suite.T(), "urn:uuid:61ebe359-bfdc-4613-8bf2-c5e300945f0a", requests[0].FullURL)
+			assert.NotNil(suite.T(), requests[0].Criteria)
+			assert.JSONEq(suite.T(), `{"resourceType":"Patient"}`, string(requests[0].Resource))
+			assert.Equal(suite.T(), http.MethodGet, requests[1].Method)
+			assert.Len(suite.T(), requests[1].Criteria.Params, 1)
+			assert.Equal(suite.T(), "9", requests[2].ID)
+			assert.Equal(suite.T(), 2, requests[2].ExpectedVersion)
+			return []domain.BundleEntryResult{
+				{Status: http.StatusCreated, Patient: &domain.Patient{LogicalID: "12", VersionID: 1, UpdatedAt: time.Now()}},
+				{Status: http.StatusOK, Matches: []*domain.Patient{{LogicalID: "3"}}, Total: 1},
+				{Status: http.StatusNoContent},
+			}, nil
+		})
+
+	w := suite.post(body)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.BundleTypeTransactionResponse, resp.Type)
+	suite.Require().Len(resp.Entry, 3)
+	assert.Equal(suite.T(), "201 Created", resp.Entry[0].Response.Status)
+	assert.Equal(suite.T(), "patients/12/_history/1", *resp.Entry[0].Response.Location)
+	assert.Equal(suite.T(), `W/"1"`, *resp.Entry[0].Response.Etag)
+	assert.True(suite.T(), strings.HasSuffix(*resp.Entry[0].FullUrl, "/api/v1/patients/12"))
+	var searchSet fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(resp.Entry[1].Resource, &searchSet))
+	assert.Equal(suite.T(), fhir.BundleTypeSearchset, searchSet.Type)
+	assert.Len(suite.T(), searchSet.Entry, 1)
+	assert.Equal(suite.T(), "204 No Content", resp.Entry[2].Response.Status)
+	assert.Empty(suite.T(), resp.Entry[2].Resource)
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_TransactionFailure() {
+	body := `{"resourceType":"Bundle","type":"transaction","entry":[{"request":{"method":"GET","url":"Patient/404"}}]}`
+	suite.mockService.EXPECT().
+		ProcessTransaction(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("entry 0: %w", domain.ErrNotFound))
+
+	w := suite.post(body)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	var resp fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(suite.T(), *resp.Issue[0].Diagnostics, "entry 0")
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_TransactionInvalidEntry() {
+	body := `{"resourceType":"Bundle","type":"transaction","entry":[{"request":{"method":"GET","url":"Observation/1"}}]}`
+
+	w := suite.post(body)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Invalid entry 0")
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_BatchInvalidEntry() {
+	body := `{"resourceType":"Bundle","type":"batch","entry":[
+		{"request":{"method":"PUT","url":"Patient/1"}},
+		{"request":{"method":"GET","url":"Patient/1"}}]}`
+	suite.mockService.EXPECT().
+		ProcessBatch(gomock.Any(), gomock.Len(1)).
+		Return([]domain.BundleEntryResult{{Status: http.StatusOK, Patient: &domain.Patient{LogicalID: "1", VersionID: 3}}})
+
+	w := suite.post(body)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.BundleTypeBatchResponse, resp.Type)
+	suite.Require().Len(resp.Entry, 2)
+	assert.Equal(suite.T(), "400 Bad Request", resp.Entry[0].Response.Status)
+	var entryOutcome fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(resp.Entry[0].Response.Outcome, &entryOutcome))
+	assert.Equal(suite.T(), fhir.IssueTypeInvalid, entryOutcome.Issue[0].Code)
+	assert.Equal(suite.T(), "200 OK", resp.Entry[1].Response.Status)
+	assert.Equal(suite.T(), `W/"3"`, *resp.Entry[1].Response.Etag)
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_BatchDuplicateIdentifier() {
+	body := `{"resourceType":"Bundle","type":"batch","entry":[
+		{"resource":{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"123"}]},"request":{"method":"POST","url":"Patient"}}]}`
+	suite.mockService.EXPECT().
+		ProcessBatch(gomock.Any(), gomock.Len(1)).
+		Return([]domain.BundleEntryResult{{Err: fmt.Errorf("%w: identifier urn:mrn|123 is already assigned to Patient/abc", domain.ErrConflict)}})
+
+	w := suite.post(body)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Entry, 1)
+	assert.Equal(suite.T(), "409 Conflict", resp.Entry[0].Response.Status)
+	var entryOutcome fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(resp.Entry[0].Response.Outcome, &entryOutcome))
+	assert.Equal(suite.T(), fhir.IssueTypeConflict, entryOutcome.Issue[0].Code)
+	assert.Contains(suite.T(), *entryOutcome.Issue[0].Diagnostics, "urn:mrn|123 is already assigned to Patient/abc")
+}
+
+func (suite *BundleHandlerTestSuite) TestProcessBundle_RejectsOtherBundles() {
+	w := suite.post(`{"resourceType":"Bundle","type":"collection"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.post(`{"resourceType":"Patient"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestParseBundleEntry_JSONPatchBinary(t *testing.T) {
+	data := "W3sib3AiOiJyZXBsYWNlIiwicGF0aCI6Ii9hY3RpdmUiLCJ2YWx1ZSI6ZmFsc2V9XQ==" // [{"op":"replace","path":"/active","value":false}]
+	entry := fhir.BundleEntry{
+		Resource: json.RawMessage(`{"resourceType":"Binary","contentType":"application/json-patch+json","data":"` + data + `"}`),
+		Request:  &fhir.BundleEntryRequest{Method: fhir.HTTPVerbPATCH, Url: "http://example.org/api/v1/patients/7"},
+	}
+
+	request, err := parseBundleEntry(entry)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "7", request.ID)
+	assert.Equal(t, domain.PatchFormatJSONPatch, request.PatchFormat)
+	assert.JSONEq(t, `[{"op":"replace","path":"/active","value":false}]`, string(request.Resource))
+}
@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// CapabilityHandlerInterface defines the contract for the capability statement handler
+type CapabilityHandlerInterface interface {
+	GetCapabilityStatement(c *gin.Context)
+	GetOperationDefinition(c *gin.Context)
+}
+
+// CapabilityResource describes a resource type served under the FHIR base, for the
+// capability statement. Its interactions and operations are read from the routes.
+type CapabilityResource struct {
+	Type       string
+	Collection string // Path segment of the type's endpoints under the FHIR base
+	// SearchParameters lists the parameters a search of the type supports
+	SearchParameters []fhirsearch.Definition
+	// SearchInclude and SearchRevInclude list the supported _include and _revinclude values
+	SearchInclude    []string
+	SearchRevInclude []string
+	// ConditionalCreate marks types whose create honors If-None-Exist
+	ConditionalCreate bool
+}
+
+// CapabilityConfig describes the server a capability statement is built for
+type CapabilityConfig struct {
+	BasePath  string               // Path of the FHIR base, e.g. /api/v1
+	Routes    gin.RoutesInfo       // The registered routes, read for interactions and operations
+	Resources []CapabilityResource // The resource types served under the base
+	Formats   []string             // Media types of the supported FHIR formats
+	CORS      bool                 // Whether cross-origin requests are allowed
+}
+
+// CapabilityHandler serves the capability statement of the server
+type CapabilityHandler struct {
+	statement fhir.CapabilityStatement
+	basePath  string
+	// modes builds the statement for each supported mode parameter; the first is the default
+	modes map[string]func(c *gin.Context) interface{}
+}
+
+// capabilityModes lists the supported mode parameter values, the default first
+var capabilityModes = []string{"full"}
+
+// operationDefinitions maps the routed operations to their definitions, by name for system
+// operations and by type and name for type and instance operations. Operations without a
+// published R4 definition have a local one, relative to the FHIR base, which the statement
+// resolves against the base URL and GetOperationDefinition serves. Routes not listed here, such as the bulk status and file
+// endpoints, are not operations and are left out of the statement.
+var operationDefinitions = map[string]string{
+	"export":             "http://hl7.org/fhir/uv/bulkdata/OperationDefinition/export",
+	"Patient/export":     "http://hl7.org/fhir/uv/bulkdata/OperationDefinition/patient-export",
+	"Patient/import":     "http://hl7.org/fhir/uv/bulkdata/OperationDefinition/import",
+	"Patient/everything": "http://hl7.org/fhir/OperationDefinition/Patient-everything",
+	"Patient/match":      "http://hl7.org/fhir/OperationDefinition/Patient-match",
+	"Patient/validate":   "http://hl7.org/fhir/OperationDefinition/Resource-validate",
+	"Patient/merge":      "OperationDefinition/Patient-merge",
+	"Patient/unmerge":    "OperationDefinition/Patient-unmerge",
+	"Patient/expunge":    "OperationDefinition/Patient-expunge",
+	"Patient/undelete":   "OperationDefinition/Patient-undelete",
+}
+
+// adminSegment is the path segment administrative routes are grouped under. Their operations
+// are listed on their resource type, documented with the path they are served at.
+const adminSegment = "admin"
+
+// NewCapabilityHandler creates a capability statement handler. The statement is built once,
+// from the routes registered when the handler is created.
+func NewCapabilityHandler(config CapabilityConfig) CapabilityHandlerInterface {
+	h := &CapabilityHandler{
+		statement: buildCapabilityStatement(config, time.Now()),
+		basePath:  config.BasePath,
+	}
+	h.modes = map[string]func(c *gin.Context) interface{}{
+		"full": h.fullStatement,
+	}
+	return h
+}
+
+// GetCapabilityStatement handles GET /metadata
+// @Summary Get the capability statement
+// @Description Get the CapabilityStatement of the server: the resource types, interactions, search parameters, operations and formats it supports. It is built from the registered routes when the server starts.
+// @Tags Capability
+// @Produce json,xml
+// @Param mode query string false "Kind of statement to return" Enums(full) default(full)
+// @Success 200 {object} fhir.CapabilityStatement
+// @Failure 400 {object} fhir.OperationOutcome
+// @Router /metadata [get]
+func (h *CapabilityHandler) GetCapabilityStatement(c *gin.Context) {
+	_, span := tracer.StartSpan(c.Request.Context(), "GetCapabilityStatement")
+	defer span.End()
+
+	mode := c.DefaultQuery("mode", capabilityModes[0])
+	build, ok := h.modes[mode]
+	if !ok {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeNotSupported,
+			fmt.Sprintf("mode %q is not supported; supported modes are %s", mode, strings.Join(capabilityModes, ", ")))
+		return
+	}
+	c.JSON(http.StatusOK, build(c))
+}
+
+// fullStatement returns the statement of the server, with the base URL the request was made to
+func (h *CapabilityHandler) fullStatement(c *gin.Context) interface{} {
+	statement := h.statement
+	url := requestBaseURL(c) + h.basePath
+	statement.Implementation = &fhir.CapabilityStatementImplementation{Description: "FHIR Patient API", Url: &url}
+	statement.Rest = resolveDefinitions(statement.Rest, url)
+	return statement
+}
+
+// resolveDefinitions returns a copy of rest with the local operation definitions made
+// absolute against the base URL of the server
+func resolveDefinitions(rest []fhir.CapabilityStatementRest, baseURL string) []fhir.CapabilityStatementRest {
+	resolve := func(operations []fhir.CapabilityStatementRestResourceOperation) []fhir.CapabilityStatementRestResourceOperation {
+		operations = slices.Clone(operations)
+		for i := range operations {
+			if !strings.Contains(operations[i].Definition, "://") {
+				operations[i].Definition = baseURL + "/" + operations[i].Definition
+			}
+		}
+		return operations
+	}
+	rest = slices.Clone(rest)
+	for i := range rest {
+		rest[i].Operation = resolve(rest[i].Operation)
+		rest[i].Resource = slices.Clone(rest[i].Resource)
+		for j := range rest[i].Resource {
+			rest[i].Resource[j].Operation = resolve(rest[i].Resource[j].Operation)
+		}
+	}
+	return rest
+}
+
+// buildCapabilityStatement describes the server from its configuration and routes
+func buildCapabilityStatement(config CapabilityConfig, date time.Time) fhir.CapabilityStatement {
+	publisher := "FHIR Demo"
+	rest := fhir.CapabilityStatementRest{
+		Mode:     fhir.RestfulCapabilityModeServer,
+		Security: capabilitySecurity(config.CORS),
+	}
+	collections := make(map[string]int, len(config.Resources))
+	for _, resource := range config.Resources {
+		capability, err := newResourceCapability(resource)
+		if err != nil {
+			continue
+		}
+		collections[resource.Collection] = len(rest.Resource)
+		rest.Resource = append(rest.Resource, capability)
+	}
+
+	patch := false
+	for _, route := range config.Routes {
+		segments, ok := routeSegments(config.BasePath, route.Path)
+		if !ok {
+			continue
+		}
+		if len(segments) == 0 {
+			if route.Method == http.MethodPost {
+				rest.Interaction = append(rest.Interaction,
+					fhir.CapabilityStatementRestInteraction{Code: fhir.SystemRestfulInteractionBatch},
+					fhir.CapabilityStatementRestInteraction{Code: fhir.SystemRestfulInteractionTransaction})
+			}
+			continue
+		}
+		if name, ok := operationName(segments[0]); ok && len(segments) == 1 {
+			rest.Operation = appendOperation(rest.Operation, name, name, nil)
+			continue
+		}
+		admin := segments[0] == adminSegment && len(segments) > 1
+		if admin {
+			segments = segments[1:]
+		}
+		index, ok := collections[segments[0]]
+		if !ok {
+			continue
+		}
+		resource := &rest.Resource[index]
+		if admin {
+			if name, ok := routeOperation(segments[1:]); ok {
+				path := append([]string{"[base]", adminSegment}, segments...)
+				for i, segment := range path {
+					if param, ok := strings.CutPrefix(segment, ":"); ok {
+						path[i] = "[" + param + "]"
+					}
+				}
+				documentation := "Served at " + strings.Join(path, "/")
+				resource.Operation = appendOperation(resource.Operation, resource.Type.Code()+"/"+name, name, &documentation)
+			}
+			continue
+		}
+		addRouteCapability(resource, route.Method, segments[1:])
+		patch = patch || route.Method == http.MethodPatch
+	}
+
+	sortOperations(rest.Operation)
+	for i := range rest.Resource {
+		resource := &rest.Resource[i]
+		sort.Slice(resource.Interaction, func(a, b int) bool { return resource.Interaction[a].Code < resource.Interaction[b].Code })
+		sortOperations(resource.Operation)
+	}
+
+	statement := fhir.CapabilityStatement{
+		Status:      fhir.PublicationStatusActive,
+		Date:        date.UTC().Format(time.RFC3339),
+		Publisher:   &publisher,
+		Kind:        fhir.CapabilityStatementKindInstance,
+		Software:    softwareCapability(),
+		FhirVersion: fhir.FHIRVersion4_0_1,
+		Format:      config.Formats,
+		Rest:        []fhir.CapabilityStatementRest{rest},
+	}
+	if patch {
+		statement.PatchFormat = PatchFormats
+	}
+	return statement
+}
+
+// newResourceCapability describes a resource type without its interactions and operations.
+// Every type keeps versions and checks If-Match on update, and update never creates.
+func newResourceCapability(resource CapabilityResource) (fhir.CapabilityStatementRestResource, error) {
+	var resourceType fhir.ResourceType
+	if err := json.Unmarshal([]byte(`"`+resource.Type+`"`), &resourceType); err != nil {
+		return fhir.CapabilityStatementRestResource{}, err
+	}
+	versioning := fhir.ResourceVersionPolicyVersionedUpdate
+	readHistory, updateCreate, conditionalCreate := false, false, resource.ConditionalCreate
+	capability := fhir.CapabilityStatementRestResource{
+		Type:              resourceType,
+		Versioning:        &versioning,
+		ReadHistory:       &readHistory,
+		UpdateCreate:      &updateCreate,
+		ConditionalCreate: &conditionalCreate,
+		SearchInclude:     resource.SearchInclude,
+		SearchRevInclude:  resource.SearchRevInclude,
+	}
+	for _, def := range resource.SearchParameters {
+		var paramType fhir.SearchParamType
+		if err := json.Unmarshal([]byte(`"`+string(def.Type)+`"`), &paramType); err != nil {
+			continue
+		}
+		documentation := def.Description
+		capability.SearchParam = append(capability.SearchParam, fhir.CapabilityStatementRestResourceSearchParam{
+			Name:          def.Name,
+			Type:          paramType,
+			Documentation: &documentation,
+		})
+	}
+	return capability, nil
+}
+
+// addRouteCapability adds the interaction or operation a route serves to its resource type.
+// The segments are the route path after the type's collection.
+func addRouteCapability(resource *fhir.CapabilityStatementRestResource, method string, segments []string) {
+	interaction := func(code fhir.TypeRestfulInteraction) {
+		for _, existing := range resource.Interaction {
+			if existing.Code == code {
+				return
+			}
+		}
+		resource.Interaction = append(resource.Interaction, fhir.CapabilityStatementRestResourceInteraction{Code: code})
+	}
+	path := make([]string, len(segments))
+	for i, segment := range segments {
+		path[i] = segment
+		if strings.HasPrefix(segment, ":") {
+			path[i] = ":"
+		}
+	}
+
+	switch strings.Join(append([]string{method}, path...), " ") {
+	case "GET":
+		interaction(fhir.TypeRestfulInteractionSearchType)
+	case "POST":
+		interaction(fhir.TypeRestfulInteractionCreate)
+	case "PUT":
+		conditionalUpdate := true
+		resource.ConditionalUpdate = &conditionalUpdate
+	case "DELETE":
+		conditionalDelete := fhir.ConditionalDeleteStatusSingle
+		resource.ConditionalDelete = &conditionalDelete
+	case "GET _history":
+		interaction(fhir.TypeRestfulInteractionHistoryType)
+	case "GET :":
+		interaction(fhir.TypeRestfulInteractionRead)
+	case "PUT :":
+		interaction(fhir.TypeRestfulInteractionUpdate)
+	case "PATCH :":
+		interaction(fhir.TypeRestfulInteractionPatch)
+	case "DELETE :":
+		interaction(fhir.TypeRestfulInteractionDelete)
+	case "GET : _history":
+		interaction(fhir.TypeRestfulInteractionHistoryInstance)
+	case "GET : _history :":
+		interaction(fhir.TypeRestfulInteractionVread)
+		*resource.ReadHistory = true
+	default:
+		if name, ok := routeOperation(segments); ok {
+			resource.Operation = appendOperation(resource.Operation, resource.Type.Code()+"/"+name, name, nil)
+		}
+	}
+}
+
+// routeOperation returns the operation a route serves, given its segments after the type's
+// collection. Type operations are /{collection}/$name, instance operations /{collection}/:id/$name.
+func routeOperation(segments []string) (string, bool) {
+	switch {
+	case len(segments) == 1:
+		return operationName(segments[0])
+	case len(segments) == 2 && strings.HasPrefix(segments[0], ":"):
+		return operationName(segments[1])
+	default:
+		return "", false
+	}
+}
+
+// routeSegments splits a route path under the FHIR base into its segments
+func routeSegments(basePath, path string) ([]string, bool) {
+	if path == basePath {
+		return nil, true
+	}
+	rest, ok := strings.CutPrefix(path, basePath+"/")
+	if !ok {
+		return nil, false
+	}
+	return strings.Split(rest, "/"), true
+}
+
+// operationName returns the name of an operation path segment such as $export
+func operationName(segment string) (string, bool) {
+	name, ok := strings.CutPrefix(segment, "$")
+	return name, ok && name != ""
+}
+
+// appendOperation adds an operation with a definition, once
+func appendOperation(operations []fhir.CapabilityStatementRestResourceOperation, key, name string, documentation *string) []fhir.CapabilityStatementRestResourceOperation {
+	definition, ok := operationDefinitions[key]
+	if !ok {
+		return operations
+	}
+	for _, operation := range operations {
+		if operation.Name == name {
+			return operations
+		}
+	}
+	return append(operations, fhir.CapabilityStatementRestResourceOperation{Name: name, Definition: definition, Documentation: documentation})
+}
+
+// sortOperations orders operations by name, as route order is not registration order
+func sortOperations(operations []fhir.CapabilityStatementRestResourceOperation) {
+	slices.SortFunc(operations, func(a, b fhir.CapabilityStatementRestResourceOperation) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// capabilitySecurity describes the security of the API, which does not authenticate requests
+func capabilitySecurity(cors bool) *fhir.CapabilityStatementRestSecurity {
+	description := "Requests are not authenticated or authorized"
+	return &fhir.CapabilityStatementRestSecurity{Cors: &cors, Description: &description}
+}
+
+// softwareCapability names the server software, with the version and time of the build
+func softwareCapability() *fhir.CapabilityStatementSoftware {
+	software := &fhir.CapabilityStatementSoftware{Name: "FHIR Patient API"}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		software.Version, software.ReleaseDate = buildVersion(info)
+	}
+	return software
+}
+
+// buildVersion returns the module version of a build, or else its VCS revision, and the time
+// of the revision. Either is nil when the build does not record it.
+func buildVersion(info *debug.BuildInfo) (*string, *string) {
+	var version, releaseDate *string
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = &info.Main.Version
+	}
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision := settings["vcs.revision"]; version == nil && revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if settings["vcs.modified"] == "true" {
+			revision += "-dirty"
+		}
+		version = &revision
+	}
+	if revisionTime := settings["vcs.time"]; revisionTime != "" {
+		releaseDate = &revisionTime
+	}
+	return version, releaseDate
+}
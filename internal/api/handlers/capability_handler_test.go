@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CapabilityHandlerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func (suite *CapabilityHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	routes := gin.RoutesInfo{
+		{Method: "POST", Path: "/api/v1"},
+		{Method: "GET", Path: "/api/v1/$export"},
+		{Method: "GET", Path: "/api/v1/$bulk-status/:id"},
+		{Method: "GET", Path: "/api/v1/patients"},
+		{Method: "POST", Path: "/api/v1/patients"},
+		{Method: "DELETE", Path: "/api/v1/patients"},
+		{Method: "POST", Path: "/api/v1/patients/$match"},
+		{Method: "POST", Path: "/api/v1/patients/$merge"},
+		{Method: "GET", Path: "/api/v1/patients/:id"},
+		{Method: "PATCH", Path: "/api/v1/patients/:id"},
+		{Method: "GET", Path: "/api/v1/patients/:id/_history/:vid"},
+		{Method: "GET", Path: "/api/v1/patients/:id/$everything"},
+		{Method: "POST", Path: "/api/v1/patients/:id/$unmerge"},
+		{Method: "POST", Path: "/api/v1/patients/:id/$expunge"},
+		{Method: "POST", Path: "/api/v1/admin/patients/:id/$undelete"},
+		{Method: "DELETE", Path: "/api/v1/admin/patients/:id"},
+		{Method: "GET", Path: "/api/v1/observations/:id"},
+		{Method: "GET", Path: "/api/v1/external-patients/:id"},
+		{Method: "GET", Path: "/health"},
+	}
+	handler := NewCapabilityHandler(CapabilityConfig{
+		BasePath: "/api/v1",
+		Routes:   routes,
+		Resources: []CapabilityResource{
+			{
+				Type:              "Patient",
+				Collection:        "patients",
+				SearchParameters:  []fhirsearch.Definition{{Name: "family", Type: fhirsearch.TypeString, Description: "Family name"}},
+				SearchInclude:     []string{"Patient:organization"},
+				ConditionalCreate: true,
+			},
+			{Type: "Observation", Collection: "observations"},
+		},
+		Formats: []string{"application/fhir+json"},
+		CORS:    true,
+	})
+	suite.router = gin.New()
+	suite.router.GET("/metadata", handler.GetCapabilityStatement)
+	suite.router.GET("/api/v1/OperationDefinition/:id", handler.GetOperationDefinition)
+}
+
+func TestCapabilityHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CapabilityHandlerTestSuite))
+}
+
+func (suite *CapabilityHandlerTestSuite) request(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Host = "example.com"
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *CapabilityHandlerTestSuite) TestGetCapabilityStatement() {
+	w := suite.request("/metadata")
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var statement map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &statement))
+	assert.Equal(suite.T(), "CapabilityStatement", statement["resourceType"])
+	assert.Equal(suite.T(), "4.0.1", statement["fhirVersion"])
+	assert.Equal(suite.T(), "http://example.com/api/v1", statement["implementation"].(map[string]interface{})["url"])
+	assert.Equal(suite.T(), []interface{}{"application/fhir+json"}, statement["format"])
+	assert.JSONEq(suite.T(), suite.marshal(PatchFormats), suite.marshal(statement["patchFormat"]))
+
+	rest := statement["rest"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(suite.T(), true, rest["security"].(map[string]interface{})["cors"])
+	assert.JSONEq(suite.T(), `[{"code":"batch"},{"code":"transaction"}]`, suite.marshal(rest["interaction"]))
+	assert.JSONEq(suite.T(), `[{"name":"export","definition":"http://hl7.org/fhir/uv/bulkdata/OperationDefinition/export"}]`,
+		suite.marshal(rest["operation"]))
+
+	resources := rest["resource"].([]interface{})
+	suite.Require().Len(resources, 2)
+	patient := resources[0].(map[string]interface{})
+	assert.Equal(suite.T(), "Patient", patient["type"])
+	assert.JSONEq(suite.T(), `[{"code":"read"},{"code":"vread"},{"code":"patch"},{"code":"create"},{"code":"search-type"}]`,
+		suite.marshal(patient["interaction"]))
+	assert.Equal(suite.T(), true, patient["readHistory"])
+	assert.Equal(suite.T(), true, patient["conditionalCreate"])
+	assert.Equal(suite.T(), "single", patient["conditionalDelete"])
+	assert.Nil(suite.T(), patient["conditionalUpdate"])
+	assert.JSONEq(suite.T(), `[{"name":"family","type":"string","documentation":"Family name"}]`, suite.marshal(patient["searchParam"]))
+	assert.JSONEq(suite.T(), `[
+		{"name":"everything","definition":"http://hl7.org/fhir/OperationDefinition/Patient-everything"},
+		{"name":"expunge","definition":"http://example.com/api/v1/OperationDefinition/Patient-expunge"},
+		{"name":"match","definition":"http://hl7.org/fhir/OperationDefinition/Patient-match"},
+		{"name":"merge","definition":"http://example.com/api/v1/OperationDefinition/Patient-merge"},
+		{"name":"undelete","definition":"http://example.com/api/v1/OperationDefinition/Patient-undelete",
+			"documentation":"Served at [base]/admin/patients/[id]/$undelete"},
+		{"name":"unmerge","definition":"http://example.com/api/v1/OperationDefinition/Patient-unmerge"}
+	]`, suite.marshal(patient["operation"]))
+
+	observation := resources[1].(map[string]interface{})
+	assert.JSONEq(suite.T(), `[{"code":"read"}]`, suite.marshal(observation["interaction"]))
+	assert.Equal(suite.T(), false, observation["readHistory"])
+}
+
+func (suite *CapabilityHandlerTestSuite) TestGetCapabilityStatement_LocalDefinitionsFollowTheBaseURL() {
+	suite.request("/metadata")
+	req, _ := http.NewRequest("GET", "/metadata", nil)
+	req.Host = "fhir.example.org"
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"definition":"http://fhir.example.org/api/v1/OperationDefinition/Patient-merge"`)
+	assert.NotContains(suite.T(), w.Body.String(), "example.com")
+}
+
+// TestGetCapabilityStatement_DefinitionsResolve tests that every local definition the statement
+// points to is served, with the url it is advertised under
+func (suite *CapabilityHandlerTestSuite) TestGetCapabilityStatement_DefinitionsResolve() {
+	w := suite.request("/metadata")
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var statement fhir.CapabilityStatement
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &statement))
+
+	followed := 0
+	for _, operation := range statement.Rest[0].Resource[0].Operation {
+		path, local := strings.CutPrefix(operation.Definition, "http://example.com")
+		if !local {
+			continue
+		}
+		followed++
+
+		w := suite.request(path)
+
+		suite.Require().Equal(http.StatusOK, w.Code, operation.Definition)
+		var definition fhir.OperationDefinition
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &definition))
+		suite.Require().NotNil(definition.Url)
+		assert.Equal(suite.T(), operation.Definition, *definition.Url)
+		assert.Equal(suite.T(), operation.Name, definition.Code)
+		assert.Equal(suite.T(), []fhir.ResourceType{fhir.ResourceTypePatient}, definition.Resource)
+	}
+	assert.Equal(suite.T(), 4, followed)
+}
+
+func (suite *CapabilityHandlerTestSuite) TestGetOperationDefinition() {
+	w := suite.request("/api/v1/OperationDefinition/Patient-merge")
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var definition map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &definition))
+	assert.Equal(suite.T(), "OperationDefinition", definition["resourceType"])
+	assert.Equal(suite.T(), "Patient-merge", definition["id"])
+	assert.Equal(suite.T(), true, definition["type"])
+	assert.Equal(suite.T(), false, definition["instance"])
+	names := []string{}
+	for _, parameter := range definition["parameter"].([]interface{}) {
+		names = append(names, parameter.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(suite.T(), []string{"source-patient", "target-patient", "preview", "outcome", "result", "source"}, names)
+}
+
+func (suite *CapabilityHandlerTestSuite) TestGetOperationDefinition_NotFound() {
+	w := suite.request("/api/v1/OperationDefinition/Patient-everything")
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"code":"not-found"`)
+}
+
+func (suite *CapabilityHandlerTestSuite) TestGetCapabilityStatement_UnsupportedMode() {
+	w := suite.request("/metadata?mode=terminology")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "not-supported")
+}
+
+func (suite *CapabilityHandlerTestSuite) TestBuildVersion() {
+	version, releaseDate := buildVersion(&debug.BuildInfo{Main: debug.Module{Version: "v1.4.0"}})
+	suite.Require().NotNil(version)
+	assert.Equal(suite.T(), "v1.4.0", *version)
+	assert.Nil(suite.T(), releaseDate)
+
+	version, releaseDate = buildVersion(&debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "e180d2f0c1a2b3c4d5e6f708192a3b4c5d6e7f80"},
+			{Key: "vcs.time", Value: "2025-06-05T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+	suite.Require().NotNil(version)
+	assert.Equal(suite.T(), "e180d2f0c1a2-dirty", *version)
+	suite.Require().NotNil(releaseDate)
+	assert.Equal(suite.T(), "2025-06-05T10:00:00Z", *releaseDate)
+
+	version, _ = buildVersion(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	assert.Nil(suite.T(), version)
+}
+
+// marshal returns the JSON of part of a decoded response, for comparison with JSONEq
+func (suite *CapabilityHandlerTestSuite) marshal(value interface{}) string {
+	data, err := json.Marshal(value)
+	suite.Require().NoError(err)
+	return string(data)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirbundle"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// CompartmentHandlerInterface defines the contract for compartment handlers
+type CompartmentHandlerInterface interface {
+	GetPatientEverything(c *gin.Context)
+}
+
+// CompartmentHandler serves operations over a patient's compartment
+type CompartmentHandler struct {
+	service domain.CompartmentService
+}
+
+// NewCompartmentHandler creates a new compartment handler
+func NewCompartmentHandler(service domain.CompartmentService) CompartmentHandlerInterface {
+	return &CompartmentHandler{
+		service: service,
+	}
+}
+
+// GetPatientEverything handles GET /patients/:id/$everything
+// @Summary Get everything for a Patient
+// @Description Return the patient and every resource in its compartment, i.e. every Observation and Encounter that refers to it, as a searchset Bundle
+// @Tags Patient
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param _since query string false "Only resources updated at or after this instant"
+// @Param _type query string false "Comma-separated resource types to include, e.g. Patient,Observation"
+// @Param _count query int false "Number of results per page" default(10)
+// @Param _page_token query string false "Opaque page token taken from a Bundle paging link"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/{id}/$everything [get]
+func (h *CompartmentHandler) GetPatientEverything(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatientEverything")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+	// $everything pages like history: _since, _count and _page_token
+	paging, err := parseHistoryQuery(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid parameters: "+err.Error())
+		return
+	}
+	query := domain.CompartmentQuery{
+		PatientID: id,
+		Since:     paging.Since,
+		Count:     paging.Count,
+		Offset:    paging.Offset,
+	}
+	if types := c.Query("_type"); types != "" {
+		query.Types = fhirsearch.SplitValues(types)
+	}
+
+	logger.WithContext(ctx).Infof("Fetching everything for patient %s", id)
+	entries, total, err := h.service.PatientEverything(ctx, query)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get everything for patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	baseURL := strings.TrimSuffix(collectionURL(c), "/patients")
+	bundleEntries := make([]fhir.BundleEntry, 0, len(entries))
+	for _, entry := range entries {
+		fullURL := baseURL + "/" + compartmentCollection(entry.ResourceType) + "/" + entry.LogicalID
+		bundleEntry, err := fhirbundle.NewEntry(fullURL, entry.Resource, fhir.SearchEntryModeMatch)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to build bundle entry for %s %s: %v", entry.ResourceType, entry.LogicalID, err)
+			continue
+		}
+		bundleEntries = append(bundleEntries, bundleEntry)
+	}
+
+	pageURL := requestBaseURL(c) + c.Request.URL.Path
+	links := fhirbundle.PageLinks(pageURL, c.Request.URL.Query(), total, query.Count, query.Offset)
+	c.JSON(http.StatusOK, fhirbundle.NewSearchSet(total, bundleEntries, links))
+}
+
+// compartmentCollection returns the path segment under /api/v1 of a resource type
+func compartmentCollection(resourceType string) string {
+	if definition, ok := domain.ResourceDefinitions[resourceType]; ok {
+		return definition.Collection
+	}
+	return "patients"
+}
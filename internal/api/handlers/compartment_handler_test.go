@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type CompartmentHandlerTestSuite struct {
+	suite.Suite
+	mockCtrl    *gomock.Controller
+	mockService *mocks.MockCompartmentService
+	router      *gin.Engine
+}
+
+func (suite *CompartmentHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.mockCtrl = gomock.NewController(suite.T())
+	suite.mockService = mocks.NewMockCompartmentService(suite.mockCtrl)
+	handler := NewCompartmentHandler(suite.mockService)
+	router := gin.New()
+	router.GET("/api/v1/patients/:id/$everything", handler.GetPatientEverything)
+	suite.router = router
+}
+
+func (suite *CompartmentHandlerTestSuite) TearDownTest() {
+	suite.mockCtrl.Finish()
+}
+
+func TestCompartmentHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CompartmentHandlerTestSuite))
+}
+
+func (suite *CompartmentHandlerTestSuite) get(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *CompartmentHandlerTestSuite) TestGetPatientEverything_Success() {
+	suite.mockService.EXPECT().
+		PatientEverything(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query domain.CompartmentQuery) ([]domain.CompartmentEntry, int64, error) {
+			assert.Equal(suite.T(), "1", query.PatientID)
+			assert.Equal(suite.T(), []string{"Patient", "Observation"}, query.Types)
+			assert.Equal(suite.T(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), query.Since.UTC())
+			assert.Equal(suite.T(), 2, query.Count)
+			return []domain.CompartmentEntry{
+				{ResourceType: "Patient", LogicalID: "1", Resource: []byte(`{"resourceType":"Patient","id":"1"}`)},
+				{ResourceType: "Observation", LogicalID: "7", Resource: []byte(`{"resourceType":"Observation","id":"7"}`)},
+			}, 3, nil
+		})
+
+	w := suite.get("/api/v1/patients/1/$everything?_type=Patient,Observation&_since=2024-01-01&_count=2")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.BundleTypeSearchset, resp.Type)
+	assert.Equal(suite.T(), 3, *resp.Total)
+	suite.Require().Len(resp.Entry, 2)
+	assert.True(suite.T(), strings.HasSuffix(*resp.Entry[0].FullUrl, "/api/v1/patients/1"))
+	assert.True(suite.T(), strings.HasSuffix(*resp.Entry[1].FullUrl, "/api/v1/observations/7"))
+	var relations []string
+	for _, link := range resp.Link {
+		relations = append(relations, link.Relation)
+	}
+	assert.Contains(suite.T(), relations, "next")
+}
+
+func (suite *CompartmentHandlerTestSuite) TestGetPatientEverything_Errors() {
+	w := suite.get("/api/v1/patients/1/$everything?_since=yesterday")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.mockService.EXPECT().PatientEverything(gomock.Any(), gomock.Any()).Return(nil, int64(0), domain.ErrNotFound)
+	w = suite.get("/api/v1/patients/2/$everything")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"go-fhir-demo/config"
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils"
 	"go-fhir-demo/pkg/utils/tracer"
@@ -30,7 +33,7 @@ func NewConsulHandler(cfg *config.ConsulConfig) ConsulHandlerInterface {
 // @Tags Consul
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 502 {object} fhir.OperationOutcome
 // @Router /api/v1/consul/secret [get]
 func (h *ConsulHandler) GetConsulSecret(c *gin.Context) {
 	// Start a child span for the background job
@@ -40,10 +43,7 @@ func (h *ConsulHandler) GetConsulSecret(c *gin.Context) {
 	logger.WithContext(ctx).Infof("Fetching secret from Consul KV at %s with key %s", h.cfg.Address, h.cfg.Key)
 	data, err := utils.GetConsulKV(h.cfg.Address, h.cfg.Key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch from Consul",
-			"message": err.Error(),
-		})
+		outcome.Error(c, fmt.Errorf("%w: failed to fetch from Consul: %v", domain.ErrUpstream, err))
 		return
 	}
 	c.JSON(http.StatusOK, data)
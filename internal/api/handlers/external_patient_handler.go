@@ -1,17 +1,19 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
+	"go-fhir-demo/internal/api/outcome"
 	"go-fhir-demo/internal/service"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils"
 	"go-fhir-demo/pkg/utils/tracer"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
 )
 
 // ExternalPatientHandlerInterface defines the contract for external patient handlers
@@ -21,6 +23,7 @@ type ExternalPatientHandlerInterface interface {
 	GetExternalPatientByIDDelayed(c *gin.Context)
 	SearchExternalPatients(c *gin.Context)
 	CreateExternalPatient(c *gin.Context)
+	GetExternalPatientEverything(c *gin.Context)
 }
 
 // ExternalPatientHandler handles requests for external patient data.
@@ -39,12 +42,13 @@ func NewExternalPatientHandler(service service.ExternalPatientServiceInterface)
 // @Summary Get an external patient by ID
 // @Description Retrieves a patient resource from an external FHIR server by its ID
 // @Tags ExternalPatients
-// @Produce json
+// @Produce json,xml
 // @Param id path string true "Patient ID"
 // @Success 200 {object} fhir.Patient "Successfully retrieved patient"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 404 {object} map[string]string "Patient not found"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 404 {object} fhir.OperationOutcome "Patient not found"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
 // @Router /external-patients/{id} [get]
 func (h *ExternalPatientHandler) GetExternalPatientByID(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "GetExternalPatientByID")
@@ -52,15 +56,14 @@ func (h *ExternalPatientHandler) GetExternalPatientByID(c *gin.Context) {
 	logger.WithContext(ctx).Infof("Fetching external patient by ID: %s", c.Param("id"))
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Patient ID is required"})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeRequired, "Patient ID is required")
 		return
 	}
 
 	patient, err := h.service.GetExternalPatientByID(ctx, id)
 	if err != nil {
-		// Basic error handling, can be improved to differentiate 404 from 500
 		logger.WithContext(ctx).Errorf("Failed to get external patient by ID %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve patient from external server", "details": err.Error()})
+		outcome.Error(c, err)
 		return
 	}
 
@@ -71,12 +74,13 @@ func (h *ExternalPatientHandler) GetExternalPatientByID(c *gin.Context) {
 // @Summary Get an external patient by ID with caching
 // @Description Retrieves a patient resource from an external FHIR server by its ID with Redis caching
 // @Tags ExternalPatients
-// @Produce json
+// @Produce json,xml
 // @Param id path string true "Patient ID"
 // @Success 200 {object} fhir.Patient "Successfully retrieved patient"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 404 {object} map[string]string "Patient not found"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 404 {object} fhir.OperationOutcome "Patient not found"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
 // @Router /external-patients/{id}/cached [get]
 func (h *ExternalPatientHandler) GetExternalPatientByIDCached(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "GetExternalPatientByIDCached")
@@ -86,7 +90,7 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDCached(c *gin.Context) {
 	logger.WithContext(ctx).Infof("Fetching cached external patient by ID: %s", id)
 
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Patient ID is required"})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeRequired, "Patient ID is required")
 		return
 	}
 
@@ -96,10 +100,7 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDCached(c *gin.Context) {
 	patient, err := h.service.GetExternalPatientByIDCached(ctx, id)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to get cached external patient by ID %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve patient from external server or cache",
-			"details": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
 
@@ -110,14 +111,15 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDCached(c *gin.Context) {
 // @Summary Get an external patient by ID with timeout
 // @Description Retrieves a patient resource from an external FHIR server by its ID with configurable timeout
 // @Tags ExternalPatients
-// @Produce json
+// @Produce json,xml
 // @Param id path string true "Patient ID"
 // @Param timeout query int false "Timeout in seconds (default: 10)"
 // @Success 200 {object} fhir.Patient "Successfully retrieved patient"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 404 {object} map[string]string "Patient not found"
-// @Failure 408 {object} map[string]string "Request timeout"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 404 {object} fhir.OperationOutcome "Patient not found"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
+// @Failure 504 {object} fhir.OperationOutcome "External server timeout"
 // @Router /external-patients/{id}/delayed [get]
 func (h *ExternalPatientHandler) GetExternalPatientByIDDelayed(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "GetExternalPatientByIDDelayed")
@@ -126,7 +128,7 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDDelayed(c *gin.Context) {
 	id := c.Param("id")
 	logger.WithContext(ctx).Infof("Fetching delayed external patient by ID: %s", id)
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Patient ID is required"})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeRequired, "Patient ID is required")
 		return
 	}
 
@@ -134,7 +136,7 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDDelayed(c *gin.Context) {
 	timeoutStr := c.DefaultQuery("timeout", "10")
 	timeoutSeconds, err := strconv.Atoi(timeoutStr)
 	if err != nil || timeoutSeconds <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timeout parameter"})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid timeout parameter")
 		return
 	}
 
@@ -144,34 +146,67 @@ func (h *ExternalPatientHandler) GetExternalPatientByIDDelayed(c *gin.Context) {
 
 	patient, err := h.service.GetExternalPatientByIDDelayed(ctx, id, timeout)
 	if err != nil {
-		if err == context.DeadlineExceeded {
-			logger.WithContext(ctx).Errorf("Timeout occurred for patient %s: %v", id, err)
-			c.JSON(http.StatusRequestTimeout, gin.H{
-				"error":   "Request timeout",
-				"details": "The external FHIR server did not respond within the specified timeout",
-			})
-			return
-		}
 		logger.WithContext(ctx).Errorf("Failed to get delayed external patient by ID %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve patient from external server",
-			"details": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, patient)
 }
 
+// GetExternalPatientEverything godoc
+// @Summary Get everything for an external patient
+// @Description Proxies the Patient/$everything operation of an external FHIR server, returning the patient and every resource in its compartment
+// @Tags ExternalPatients
+// @Produce json,xml
+// @Param id path string true "Patient ID"
+// @Param _since query string false "Only resources updated at or after this instant"
+// @Param _type query string false "Comma-separated resource types to include"
+// @Param _count query int false "Number of results per page"
+// @Success 200 {object} fhir.Bundle "Successfully retrieved the patient compartment"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 404 {object} fhir.OperationOutcome "Patient not found"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
+// @Router /external-patients/{id}/$everything [get]
+func (h *ExternalPatientHandler) GetExternalPatientEverything(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetExternalPatientEverything")
+	defer span.End()
+
+	id := c.Param("id")
+	logger.WithContext(ctx).Infof("Fetching everything for external patient: %s", id)
+	if id == "" {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeRequired, "Patient ID is required")
+		return
+	}
+
+	params := url.Values{}
+	for _, name := range []string{"_since", "_type", "_count", "start", "end"} {
+		if values, ok := c.Request.URL.Query()[name]; ok {
+			params[name] = values
+		}
+	}
+
+	bundle, err := h.service.GetExternalPatientEverything(ctx, id, params)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get everything for external patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
 // SearchExternalPatients godoc
 // @Summary Search for external patients
 // @Description Searches for patient resources on an external FHIR server based on query parameters
 // @Tags ExternalPatients
-// @Produce json
+// @Produce json,xml
 // @Param _query query string false "FHIR search parameters (e.g., name=John,birthdate=1990-01-01)"
 // @Success 200 {object} fhir.Bundle "Successfully retrieved search results"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
 // @Router /external-patients [get]
 func (h *ExternalPatientHandler) SearchExternalPatients(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "SearchExternalPatients")
@@ -188,7 +223,7 @@ func (h *ExternalPatientHandler) SearchExternalPatients(c *gin.Context) {
 	bundle, err := h.service.SearchExternalPatients(ctx, queryParams)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to search external patients: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search patients on external server", "details": err.Error()})
+		outcome.Error(c, err)
 		return
 	}
 
@@ -199,12 +234,13 @@ func (h *ExternalPatientHandler) SearchExternalPatients(c *gin.Context) {
 // @Summary Create an external patient
 // @Description Creates a new patient resource on an external FHIR server
 // @Tags ExternalPatients
-// @Accept json
-// @Produce json
+// @Accept json,xml
+// @Produce json,xml
 // @Param patient body object true "Patient resource to create (FHIR-compliant JSON)"
 // @Success 201 {object} fhir.Patient "Successfully created patient"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} fhir.OperationOutcome "Invalid request"
+// @Failure 500 {object} fhir.OperationOutcome "Internal server error"
+// @Failure 502 {object} fhir.OperationOutcome "External server error"
 // @Router /external-patients [post]
 func (h *ExternalPatientHandler) CreateExternalPatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "CreateExternalPatient")
@@ -212,7 +248,7 @@ func (h *ExternalPatientHandler) CreateExternalPatient(c *gin.Context) {
 
 	var jsonData map[string]interface{}
 	if err := c.ShouldBindJSON(&jsonData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient data", "details": err.Error()})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid patient data: "+err.Error())
 		return
 	}
 
@@ -220,14 +256,14 @@ func (h *ExternalPatientHandler) CreateExternalPatient(c *gin.Context) {
 
 	patient, err := utils.ConvertJsonToFHIRPatient(jsonData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert to FHIR Patient", "details": err.Error()})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Failed to convert to FHIR Patient: "+err.Error())
 		return
 	}
 
 	createdPatient, err := h.service.CreateExternalPatient(ctx, patient)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to create external patient: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create patient on external server", "details": err.Error()})
+		outcome.Error(c, err)
 		return
 	}
 
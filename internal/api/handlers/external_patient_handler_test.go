@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/internal/service/mocks"
 	"go-fhir-demo/pkg/utils"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -34,6 +37,7 @@ func (suite *ExternalPatientHandlerTestSuite) SetupTest() {
 	router.RedirectTrailingSlash = false
 	router.GET("/external-patients/:id", suite.handler.GetExternalPatientByID)
 	router.GET("/external-patients", suite.handler.SearchExternalPatients)
+	router.GET("/external-patients/:id/$everything", suite.handler.GetExternalPatientEverything)
 	suite.router = router
 }
 
@@ -76,6 +80,38 @@ func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByID_Error()
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
 }
 
+func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByID_NotFound() {
+	testID := "missing"
+	suite.mockService.EXPECT().
+		GetExternalPatientByID(gomock.Any(), testID).
+		Return(nil, fmt.Errorf("%w: fhir server returned status 404", domain.ErrNotFound))
+
+	req, _ := http.NewRequest("GET", "/external-patients/"+testID, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	var resp fhir.OperationOutcome
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueTypeNotFound, resp.Issue[0].Code)
+	assert.Equal(suite.T(), fhir.IssueSeverityError, resp.Issue[0].Severity)
+}
+
+func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByID_UpstreamError() {
+	testID := "broken"
+	suite.mockService.EXPECT().
+		GetExternalPatientByID(gomock.Any(), testID).
+		Return(nil, fmt.Errorf("%w: fhir server returned status 503", domain.ErrUpstream))
+
+	req, _ := http.NewRequest("GET", "/external-patients/"+testID, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadGateway, w.Code)
+}
+
 func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByID_BadRequest() {
 	req, _ := http.NewRequest("GET", "/external-patients/", nil)
 	w := httptest.NewRecorder()
@@ -113,6 +149,30 @@ func (suite *ExternalPatientHandlerTestSuite) TestSearchExternalPatients_Error()
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
 }
 
+func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientEverything_ForwardsParameters() {
+	suite.mockService.EXPECT().
+		GetExternalPatientEverything(gomock.Any(), "123", url.Values{"_type": {"Observation"}, "_count": {"5"}}).
+		Return(&fhir.Bundle{Type: fhir.BundleTypeSearchset}, nil)
+
+	req, _ := http.NewRequest("GET", "/external-patients/123/$everything?_type=Observation&_count=5&foo=bar", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientEverything_NotFound() {
+	suite.mockService.EXPECT().
+		GetExternalPatientEverything(gomock.Any(), "404", gomock.Any()).
+		Return(nil, fmt.Errorf("%w: upstream", domain.ErrNotFound))
+
+	req, _ := http.NewRequest("GET", "/external-patients/404/$everything", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
 func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByIDCached_Success() {
 	testID := "cached-id-456"
 	mockPatient := &fhir.Patient{Id: utils.CreateStringPtr(testID)}
@@ -189,7 +249,7 @@ func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByIDDelayed_
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(suite.T(), http.StatusRequestTimeout, w.Code)
+	assert.Equal(suite.T(), http.StatusGatewayTimeout, w.Code)
 }
 
 func (suite *ExternalPatientHandlerTestSuite) TestGetExternalPatientByIDDelayed_Error() {
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// MergeHandlerInterface defines the contract for merge handlers
+type MergeHandlerInterface interface {
+	MergePatients(c *gin.Context)
+	UnmergePatient(c *gin.Context)
+}
+
+// MergeHandler serves the patient merge and unmerge operations
+type MergeHandler struct {
+	service  domain.MergeService
+	patients domain.PatientService
+}
+
+// NewMergeHandler creates a new merge handler. The patient service converts the merged
+// patients to FHIR for the response.
+func NewMergeHandler(service domain.MergeService, patients domain.PatientService) MergeHandlerInterface {
+	return &MergeHandler{
+		service:  service,
+		patients: patients,
+	}
+}
+
+// MergePatients handles POST /patients/$merge
+// @Summary Merge two Patients
+// @Description Merge a duplicate source patient into a target patient. The target takes the source's identifiers, names, telecom and addresses by the configured merge rules and a replaces link; the source becomes inactive with a replaced-by link; references to the source in other resources are repointed to the target. The body is a Parameters resource with source-patient and target-patient references and an optional preview flag. The response is a Parameters resource with the outcome, the merged target as result, and the source.
+// @Tags Patient
+// @Accept json,xml
+// @Accept application/fhir+json
+// @Accept application/fhir+xml
+// @Produce json,xml
+// @Param parameters body object true "Parameters resource"
+// @Success 200 {object} fhir.Parameters
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "A patient was already merged, or an identifier of a unique system belongs to another patient"
+// @Failure 412 {object} fhir.OperationOutcome "A patient changed while it was being merged"
+// @Failure 422 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/$merge [post]
+func (h *MergeHandler) MergePatients(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "MergePatients")
+	defer span.End()
+
+	body, err := c.GetRawData()
+	if err != nil || !fhirpatch.IsParameters(body) {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be a Parameters resource")
+		return
+	}
+	request, err := parseMergeParameters(body)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, err.Error())
+		return
+	}
+
+	result, err := h.service.MergePatients(ctx, request)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to merge patient %s into %s: %v", request.SourceID, request.TargetID, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	diagnostics := fmt.Sprintf("Merged Patient/%s into Patient/%s and repointed %d references", request.SourceID, request.TargetID, result.References)
+	if request.Preview {
+		diagnostics = fmt.Sprintf("Preview of merging Patient/%s into Patient/%s; nothing was stored", request.SourceID, request.TargetID)
+	}
+	h.writeResult(c, diagnostics, patientParameter{"result", result.Target}, patientParameter{"source", result.Source})
+}
+
+// UnmergePatient handles POST /patients/:id/$unmerge
+// @Summary Reverse a Patient merge
+// @Description Reverse the merge of a source patient. The source is restored as it was before the merge; the target gets back its identifiers, names, telecom and addresses from before the merge and loses its replaces link; repointed references that still point to the target are pointed back to the source. The response is a Parameters resource with the outcome and both patients.
+// @Tags Patient
+// @Produce json,xml
+// @Param id path string true "Logical ID of the merged source patient"
+// @Success 200 {object} fhir.Parameters
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "The patient has not been merged"
+// @Failure 412 {object} fhir.OperationOutcome "A patient changed while it was being unmerged"
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/{id}/$unmerge [post]
+func (h *MergeHandler) UnmergePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "UnmergePatient")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UnmergePatient(ctx, id)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to unmerge patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	diagnostics := fmt.Sprintf("Unmerged Patient/%s from Patient/%s and restored %d references", id, result.Target.LogicalID, result.References)
+	h.writeResult(c, diagnostics, patientParameter{"source", result.Source}, patientParameter{"target", result.Target})
+}
+
+// patientParameter is a patient returned as a named output parameter
+type patientParameter struct {
+	name    string
+	patient *domain.Patient
+}
+
+// writeResult writes a Parameters resource with an informational outcome followed by the patients
+func (h *MergeHandler) writeResult(c *gin.Context, diagnostics string, patients ...patientParameter) {
+	ctx := c.Request.Context()
+	raw, err := json.Marshal(outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational, diagnostics))
+	if err != nil {
+		outcome.Error(c, err)
+		return
+	}
+	parameters := fhir.Parameters{Parameter: []fhir.ParametersParameter{{Name: "outcome", Resource: raw}}}
+	for _, output := range patients {
+		fhirPatient, err := h.patients.ConvertToFHIR(ctx, output.patient)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
+			outcome.Error(c, err)
+			return
+		}
+		if raw, err = json.Marshal(fhirPatient); err != nil {
+			outcome.Error(c, err)
+			return
+		}
+		parameters.Parameter = append(parameters.Parameter, fhir.ParametersParameter{Name: output.name, Resource: raw})
+	}
+	c.JSON(http.StatusOK, parameters)
+}
+
+// parseMergeParameters reads the source-patient, target-patient and preview parameters of a $merge Parameters body
+func parseMergeParameters(body []byte) (domain.MergeRequest, error) {
+	var parameters fhir.Parameters
+	if err := json.Unmarshal(body, &parameters); err != nil {
+		return domain.MergeRequest{}, fmt.Errorf("invalid Parameters resource: %w", err)
+	}
+
+	var request domain.MergeRequest
+	for _, parameter := range parameters.Parameter {
+		var err error
+		switch parameter.Name {
+		case "source-patient":
+			request.SourceID, err = patientReferenceID(parameter)
+		case "target-patient":
+			request.TargetID, err = patientReferenceID(parameter)
+		case "preview":
+			if parameter.ValueBoolean == nil {
+				err = errors.New("preview must be a valueBoolean")
+			} else {
+				request.Preview = *parameter.ValueBoolean
+			}
+		}
+		if err != nil {
+			return domain.MergeRequest{}, err
+		}
+	}
+	if request.SourceID == "" || request.TargetID == "" {
+		return domain.MergeRequest{}, errors.New("Parameters must contain source-patient and target-patient parameters")
+	}
+	return request, nil
+}
+
+// patientReferenceID returns the logical id of the Patient a reference parameter refers to
+func patientReferenceID(parameter fhir.ParametersParameter) (string, error) {
+	if parameter.ValueReference == nil || parameter.ValueReference.Reference == nil {
+		return "", fmt.Errorf("%s must be a valueReference", parameter.Name)
+	}
+	reference := *parameter.ValueReference.Reference
+	index := strings.LastIndex(reference, "Patient/")
+	if index < 0 || (index > 0 && reference[index-1] != '/') || !logicalIDPattern.MatchString(reference[index+len("Patient/"):]) {
+		return "", fmt.Errorf("%s must refer to a Patient, e.g. Patient/123", parameter.Name)
+	}
+	return reference[index+len("Patient/"):], nil
+}
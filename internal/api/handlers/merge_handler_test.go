@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type MergeHandlerTestSuite struct {
+	suite.Suite
+	mockCtrl     *gomock.Controller
+	mockService  *mocks.MockMergeService
+	mockPatients *mocks.MockPatientService
+	router       *gin.Engine
+}
+
+func (suite *MergeHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.mockCtrl = gomock.NewController(suite.T())
+	suite.mockService = mocks.NewMockMergeService(suite.mockCtrl)
+	suite.mockPatients = mocks.NewMockPatientService(suite.mockCtrl)
+	handler := NewMergeHandler(suite.mockService, suite.mockPatients)
+	router := gin.New()
+	router.POST("/api/v1/patients/$merge", handler.MergePatients)
+	router.POST("/api/v1/patients/:id/$unmerge", handler.UnmergePatient)
+	suite.router = router
+}
+
+func (suite *MergeHandlerTestSuite) TearDownTest() {
+	suite.mockCtrl.Finish()
+}
+
+func TestMergeHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(MergeHandlerTestSuite))
+}
+
+func (suite *MergeHandlerTestSuite) post(path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// expectConversions converts each patient to a FHIR patient with its logical id
+func (suite *MergeHandlerTestSuite) expectConversions() {
+	suite.mockPatients.EXPECT().
+		ConvertToFHIR(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, patient *domain.Patient) (*fhir.Patient, error) {
+			return &fhir.Patient{Id: &patient.LogicalID}, nil
+		}).
+		Times(2)
+}
+
+func (suite *MergeHandlerTestSuite) TestMergePatients_Success() {
+	suite.mockService.EXPECT().
+		MergePatients(gomock.Any(), domain.MergeRequest{SourceID: "1", TargetID: "2"}).
+		Return(&domain.MergeResult{Source: &domain.Patient{LogicalID: "1"}, Target: &domain.Patient{LogicalID: "2"}, References: 3}, nil)
+	suite.expectConversions()
+
+	w := suite.post("/api/v1/patients/$merge", `{"resourceType":"Parameters","parameter":[
+		{"name":"source-patient","valueReference":{"reference":"Patient/1"}},
+		{"name":"target-patient","valueReference":{"reference":"http://example.org/fhir/Patient/2"}}]}`)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Parameters
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Parameter, 3)
+	assert.Equal(suite.T(), "outcome", resp.Parameter[0].Name)
+	assert.Contains(suite.T(), string(resp.Parameter[0].Resource), "repointed 3 references")
+	assert.Equal(suite.T(), "result", resp.Parameter[1].Name)
+	assert.JSONEq(suite.T(), `{"id":"2","resourceType":"Patient"}`, string(resp.Parameter[1].Resource))
+	assert.Equal(suite.T(), "source", resp.Parameter[2].Name)
+}
+
+func (suite *MergeHandlerTestSuite) TestMergePatients_Preview() {
+	suite.mockService.EXPECT().
+		MergePatients(gomock.Any(), domain.MergeRequest{SourceID: "1", TargetID: "2", Preview: true}).
+		Return(&domain.MergeResult{Source: &domain.Patient{LogicalID: "1"}, Target: &domain.Patient{LogicalID: "2"}}, nil)
+	suite.expectConversions()
+
+	w := suite.post("/api/v1/patients/$merge", `{"resourceType":"Parameters","parameter":[
+		{"name":"source-patient","valueReference":{"reference":"Patient/1"}},
+		{"name":"target-patient","valueReference":{"reference":"Patient/2"}},
+		{"name":"preview","valueBoolean":true}]}`)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "nothing was stored")
+}
+
+func (suite *MergeHandlerTestSuite) TestMergePatients_InvalidParameters() {
+	for _, body := range []string{
+		`{"resourceType":"Patient"}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"source-patient","valueReference":{"reference":"Patient/1"}}]}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"source-patient","valueReference":{"reference":"Observation/1"}},{"name":"target-patient","valueReference":{"reference":"Patient/2"}}]}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"source-patient","valueString":"1"},{"name":"target-patient","valueReference":{"reference":"Patient/2"}}]}`,
+	} {
+		w := suite.post("/api/v1/patients/$merge", body)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *MergeHandlerTestSuite) TestMergePatients_AlreadyMerged() {
+	suite.mockService.EXPECT().
+		MergePatients(gomock.Any(), gomock.Any()).
+		Return(nil, domain.ErrConflict)
+
+	w := suite.post("/api/v1/patients/$merge", `{"resourceType":"Parameters","parameter":[
+		{"name":"source-patient","valueReference":{"reference":"Patient/1"}},
+		{"name":"target-patient","valueReference":{"reference":"Patient/2"}}]}`)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *MergeHandlerTestSuite) TestUnmergePatient_Success() {
+	suite.mockService.EXPECT().
+		UnmergePatient(gomock.Any(), "1").
+		Return(&domain.MergeResult{Source: &domain.Patient{LogicalID: "1"}, Target: &domain.Patient{LogicalID: "2"}, References: 1}, nil)
+	suite.expectConversions()
+
+	w := suite.post("/api/v1/patients/1/$unmerge", "")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Parameters
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Parameter, 3)
+	assert.Contains(suite.T(), string(resp.Parameter[0].Resource), "Unmerged Patient/1 from Patient/2")
+	assert.Equal(suite.T(), "source", resp.Parameter[1].Name)
+	assert.Equal(suite.T(), "target", resp.Parameter[2].Name)
+}
+
+func (suite *MergeHandlerTestSuite) TestUnmergePatient_NotMerged() {
+	suite.mockService.EXPECT().
+		UnmergePatient(gomock.Any(), "1").
+		Return(nil, domain.ErrConflict)
+
+	w := suite.post("/api/v1/patients/1/$unmerge", "")
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
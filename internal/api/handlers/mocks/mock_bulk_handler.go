@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\bulk_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\bulk_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_bulk_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBulkHandlerInterface is a mock of BulkHandlerInterface interface.
+type MockBulkHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockBulkHandlerInterfaceMockRecorder is the mock recorder for MockBulkHandlerInterface.
+type MockBulkHandlerInterfaceMockRecorder struct {
+	mock *MockBulkHandlerInterface
+}
+
+// NewMockBulkHandlerInterface creates a new mock instance.
+func NewMockBulkHandlerInterface(ctrl *gomock.Controller) *MockBulkHandlerInterface {
+	mock := &MockBulkHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockBulkHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkHandlerInterface) EXPECT() *MockBulkHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteJob mocks base method.
+func (m *MockBulkHandlerInterface) DeleteJob(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteJob", c)
+}
+
+// DeleteJob indicates an expected call of DeleteJob.
+func (mr *MockBulkHandlerInterfaceMockRecorder) DeleteJob(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJob", reflect.TypeOf((*MockBulkHandlerInterface)(nil).DeleteJob), c)
+}
+
+// ExportPatients mocks base method.
+func (m *MockBulkHandlerInterface) ExportPatients(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExportPatients", c)
+}
+
+// ExportPatients indicates an expected call of ExportPatients.
+func (mr *MockBulkHandlerInterfaceMockRecorder) ExportPatients(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportPatients", reflect.TypeOf((*MockBulkHandlerInterface)(nil).ExportPatients), c)
+}
+
+// ExportSystem mocks base method.
+func (m *MockBulkHandlerInterface) ExportSystem(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExportSystem", c)
+}
+
+// ExportSystem indicates an expected call of ExportSystem.
+func (mr *MockBulkHandlerInterfaceMockRecorder) ExportSystem(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportSystem", reflect.TypeOf((*MockBulkHandlerInterface)(nil).ExportSystem), c)
+}
+
+// GetJobFile mocks base method.
+func (m *MockBulkHandlerInterface) GetJobFile(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetJobFile", c)
+}
+
+// GetJobFile indicates an expected call of GetJobFile.
+func (mr *MockBulkHandlerInterfaceMockRecorder) GetJobFile(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobFile", reflect.TypeOf((*MockBulkHandlerInterface)(nil).GetJobFile), c)
+}
+
+// GetJobStatus mocks base method.
+func (m *MockBulkHandlerInterface) GetJobStatus(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetJobStatus", c)
+}
+
+// GetJobStatus indicates an expected call of GetJobStatus.
+func (mr *MockBulkHandlerInterfaceMockRecorder) GetJobStatus(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobStatus", reflect.TypeOf((*MockBulkHandlerInterface)(nil).GetJobStatus), c)
+}
+
+// ImportPatients mocks base method.
+func (m *MockBulkHandlerInterface) ImportPatients(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ImportPatients", c)
+}
+
+// ImportPatients indicates an expected call of ImportPatients.
+func (mr *MockBulkHandlerInterfaceMockRecorder) ImportPatients(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPatients", reflect.TypeOf((*MockBulkHandlerInterface)(nil).ImportPatients), c)
+}
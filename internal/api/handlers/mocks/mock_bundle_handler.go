@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\bundle_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\bundle_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_bundle_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBundleHandlerInterface is a mock of BundleHandlerInterface interface.
+type MockBundleHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBundleHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockBundleHandlerInterfaceMockRecorder is the mock recorder for MockBundleHandlerInterface.
+type MockBundleHandlerInterfaceMockRecorder struct {
+	mock *MockBundleHandlerInterface
+}
+
+// NewMockBundleHandlerInterface creates a new mock instance.
+func NewMockBundleHandlerInterface(ctrl *gomock.Controller) *MockBundleHandlerInterface {
+	mock := &MockBundleHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockBundleHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBundleHandlerInterface) EXPECT() *MockBundleHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ProcessBundle mocks base method.
+func (m *MockBundleHandlerInterface) ProcessBundle(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProcessBundle", c)
+}
+
+// ProcessBundle indicates an expected call of ProcessBundle.
+func (mr *MockBundleHandlerInterfaceMockRecorder) ProcessBundle(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBundle", reflect.TypeOf((*MockBundleHandlerInterface)(nil).ProcessBundle), c)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\capability_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\capability_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_capability_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCapabilityHandlerInterface is a mock of CapabilityHandlerInterface interface.
+type MockCapabilityHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCapabilityHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCapabilityHandlerInterfaceMockRecorder is the mock recorder for MockCapabilityHandlerInterface.
+type MockCapabilityHandlerInterfaceMockRecorder struct {
+	mock *MockCapabilityHandlerInterface
+}
+
+// NewMockCapabilityHandlerInterface creates a new mock instance.
+func NewMockCapabilityHandlerInterface(ctrl *gomock.Controller) *MockCapabilityHandlerInterface {
+	mock := &MockCapabilityHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockCapabilityHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCapabilityHandlerInterface) EXPECT() *MockCapabilityHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetCapabilityStatement mocks base method.
+func (m *MockCapabilityHandlerInterface) GetCapabilityStatement(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetCapabilityStatement", c)
+}
+
+// GetCapabilityStatement indicates an expected call of GetCapabilityStatement.
+func (mr *MockCapabilityHandlerInterfaceMockRecorder) GetCapabilityStatement(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCapabilityStatement", reflect.TypeOf((*MockCapabilityHandlerInterface)(nil).GetCapabilityStatement), c)
+}
+
+// GetOperationDefinition mocks base method.
+func (m *MockCapabilityHandlerInterface) GetOperationDefinition(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetOperationDefinition", c)
+}
+
+// GetOperationDefinition indicates an expected call of GetOperationDefinition.
+func (mr *MockCapabilityHandlerInterfaceMockRecorder) GetOperationDefinition(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperationDefinition", reflect.TypeOf((*MockCapabilityHandlerInterface)(nil).GetOperationDefinition), c)
+}
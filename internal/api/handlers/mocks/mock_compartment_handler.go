@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\compartment_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\compartment_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_compartment_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCompartmentHandlerInterface is a mock of CompartmentHandlerInterface interface.
+type MockCompartmentHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompartmentHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCompartmentHandlerInterfaceMockRecorder is the mock recorder for MockCompartmentHandlerInterface.
+type MockCompartmentHandlerInterfaceMockRecorder struct {
+	mock *MockCompartmentHandlerInterface
+}
+
+// NewMockCompartmentHandlerInterface creates a new mock instance.
+func NewMockCompartmentHandlerInterface(ctrl *gomock.Controller) *MockCompartmentHandlerInterface {
+	mock := &MockCompartmentHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockCompartmentHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompartmentHandlerInterface) EXPECT() *MockCompartmentHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetPatientEverything mocks base method.
+func (m *MockCompartmentHandlerInterface) GetPatientEverything(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetPatientEverything", c)
+}
+
+// GetPatientEverything indicates an expected call of GetPatientEverything.
+func (mr *MockCompartmentHandlerInterfaceMockRecorder) GetPatientEverything(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientEverything", reflect.TypeOf((*MockCompartmentHandlerInterface)(nil).GetPatientEverything), c)
+}
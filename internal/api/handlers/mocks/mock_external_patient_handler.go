@@ -88,6 +88,18 @@ func (mr *MockExternalPatientHandlerInterfaceMockRecorder) GetExternalPatientByI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalPatientByIDDelayed", reflect.TypeOf((*MockExternalPatientHandlerInterface)(nil).GetExternalPatientByIDDelayed), c)
 }
 
+// GetExternalPatientEverything mocks base method.
+func (m *MockExternalPatientHandlerInterface) GetExternalPatientEverything(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetExternalPatientEverything", c)
+}
+
+// GetExternalPatientEverything indicates an expected call of GetExternalPatientEverything.
+func (mr *MockExternalPatientHandlerInterfaceMockRecorder) GetExternalPatientEverything(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalPatientEverything", reflect.TypeOf((*MockExternalPatientHandlerInterface)(nil).GetExternalPatientEverything), c)
+}
+
 // SearchExternalPatients mocks base method.
 func (m *MockExternalPatientHandlerInterface) SearchExternalPatients(c *gin.Context) {
 	m.ctrl.T.Helper()
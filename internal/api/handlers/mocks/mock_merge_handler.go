@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\merge_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\merge_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_merge_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMergeHandlerInterface is a mock of MergeHandlerInterface interface.
+type MockMergeHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMergeHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMergeHandlerInterfaceMockRecorder is the mock recorder for MockMergeHandlerInterface.
+type MockMergeHandlerInterfaceMockRecorder struct {
+	mock *MockMergeHandlerInterface
+}
+
+// NewMockMergeHandlerInterface creates a new mock instance.
+func NewMockMergeHandlerInterface(ctrl *gomock.Controller) *MockMergeHandlerInterface {
+	mock := &MockMergeHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockMergeHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMergeHandlerInterface) EXPECT() *MockMergeHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// MergePatients mocks base method.
+func (m *MockMergeHandlerInterface) MergePatients(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MergePatients", c)
+}
+
+// MergePatients indicates an expected call of MergePatients.
+func (mr *MockMergeHandlerInterfaceMockRecorder) MergePatients(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePatients", reflect.TypeOf((*MockMergeHandlerInterface)(nil).MergePatients), c)
+}
+
+// UnmergePatient mocks base method.
+func (m *MockMergeHandlerInterface) UnmergePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnmergePatient", c)
+}
+
+// UnmergePatient indicates an expected call of UnmergePatient.
+func (mr *MockMergeHandlerInterfaceMockRecorder) UnmergePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmergePatient", reflect.TypeOf((*MockMergeHandlerInterface)(nil).UnmergePatient), c)
+}
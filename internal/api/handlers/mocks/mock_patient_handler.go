@@ -40,6 +40,30 @@ func (m *MockPatientHandlerInterface) EXPECT() *MockPatientHandlerInterfaceMockR
 	return m.recorder
 }
 
+// ConditionalDeletePatient mocks base method.
+func (m *MockPatientHandlerInterface) ConditionalDeletePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConditionalDeletePatient", c)
+}
+
+// ConditionalDeletePatient indicates an expected call of ConditionalDeletePatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) ConditionalDeletePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalDeletePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).ConditionalDeletePatient), c)
+}
+
+// ConditionalUpdatePatient mocks base method.
+func (m *MockPatientHandlerInterface) ConditionalUpdatePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConditionalUpdatePatient", c)
+}
+
+// ConditionalUpdatePatient indicates an expected call of ConditionalUpdatePatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) ConditionalUpdatePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalUpdatePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).ConditionalUpdatePatient), c)
+}
+
 // CreatePatient mocks base method.
 func (m *MockPatientHandlerInterface) CreatePatient(c *gin.Context) {
 	m.ctrl.T.Helper()
@@ -64,6 +88,18 @@ func (mr *MockPatientHandlerInterfaceMockRecorder) DeletePatient(c any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).DeletePatient), c)
 }
 
+// ExpungePatient mocks base method.
+func (m *MockPatientHandlerInterface) ExpungePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExpungePatient", c)
+}
+
+// ExpungePatient indicates an expected call of ExpungePatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) ExpungePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpungePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).ExpungePatient), c)
+}
+
 // GetPatient mocks base method.
 func (m *MockPatientHandlerInterface) GetPatient(c *gin.Context) {
 	m.ctrl.T.Helper()
@@ -76,6 +112,30 @@ func (mr *MockPatientHandlerInterfaceMockRecorder) GetPatient(c any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).GetPatient), c)
 }
 
+// GetPatientHistory mocks base method.
+func (m *MockPatientHandlerInterface) GetPatientHistory(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetPatientHistory", c)
+}
+
+// GetPatientHistory indicates an expected call of GetPatientHistory.
+func (mr *MockPatientHandlerInterfaceMockRecorder) GetPatientHistory(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientHistory", reflect.TypeOf((*MockPatientHandlerInterface)(nil).GetPatientHistory), c)
+}
+
+// GetPatientVersion mocks base method.
+func (m *MockPatientHandlerInterface) GetPatientVersion(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetPatientVersion", c)
+}
+
+// GetPatientVersion indicates an expected call of GetPatientVersion.
+func (mr *MockPatientHandlerInterfaceMockRecorder) GetPatientVersion(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientVersion", reflect.TypeOf((*MockPatientHandlerInterface)(nil).GetPatientVersion), c)
+}
+
 // GetPatients mocks base method.
 func (m *MockPatientHandlerInterface) GetPatients(c *gin.Context) {
 	m.ctrl.T.Helper()
@@ -88,6 +148,30 @@ func (mr *MockPatientHandlerInterfaceMockRecorder) GetPatients(c any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatients", reflect.TypeOf((*MockPatientHandlerInterface)(nil).GetPatients), c)
 }
 
+// GetPatientsHistory mocks base method.
+func (m *MockPatientHandlerInterface) GetPatientsHistory(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetPatientsHistory", c)
+}
+
+// GetPatientsHistory indicates an expected call of GetPatientsHistory.
+func (mr *MockPatientHandlerInterfaceMockRecorder) GetPatientsHistory(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientsHistory", reflect.TypeOf((*MockPatientHandlerInterface)(nil).GetPatientsHistory), c)
+}
+
+// MatchPatient mocks base method.
+func (m *MockPatientHandlerInterface) MatchPatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MatchPatient", c)
+}
+
+// MatchPatient indicates an expected call of MatchPatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) MatchPatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchPatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).MatchPatient), c)
+}
+
 // PatchPatient mocks base method.
 func (m *MockPatientHandlerInterface) PatchPatient(c *gin.Context) {
 	m.ctrl.T.Helper()
@@ -100,6 +184,18 @@ func (mr *MockPatientHandlerInterfaceMockRecorder) PatchPatient(c any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).PatchPatient), c)
 }
 
+// UndeletePatient mocks base method.
+func (m *MockPatientHandlerInterface) UndeletePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UndeletePatient", c)
+}
+
+// UndeletePatient indicates an expected call of UndeletePatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) UndeletePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndeletePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).UndeletePatient), c)
+}
+
 // UpdatePatient mocks base method.
 func (m *MockPatientHandlerInterface) UpdatePatient(c *gin.Context) {
 	m.ctrl.T.Helper()
@@ -111,3 +207,15 @@ func (mr *MockPatientHandlerInterfaceMockRecorder) UpdatePatient(c any) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).UpdatePatient), c)
 }
+
+// ValidatePatient mocks base method.
+func (m *MockPatientHandlerInterface) ValidatePatient(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ValidatePatient", c)
+}
+
+// ValidatePatient indicates an expected call of ValidatePatient.
+func (mr *MockPatientHandlerInterfaceMockRecorder) ValidatePatient(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePatient", reflect.TypeOf((*MockPatientHandlerInterface)(nil).ValidatePatient), c)
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\resource_handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\resource_handler.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\api\handlers\mocks\mock_resource_handler.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockResourceHandlerInterface is a mock of ResourceHandlerInterface interface.
+type MockResourceHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceHandlerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockResourceHandlerInterfaceMockRecorder is the mock recorder for MockResourceHandlerInterface.
+type MockResourceHandlerInterfaceMockRecorder struct {
+	mock *MockResourceHandlerInterface
+}
+
+// NewMockResourceHandlerInterface creates a new mock instance.
+func NewMockResourceHandlerInterface(ctrl *gomock.Controller) *MockResourceHandlerInterface {
+	mock := &MockResourceHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockResourceHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceHandlerInterface) EXPECT() *MockResourceHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Collection mocks base method.
+func (m *MockResourceHandlerInterface) Collection() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Collection")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Collection indicates an expected call of Collection.
+func (mr *MockResourceHandlerInterfaceMockRecorder) Collection() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Collection", reflect.TypeOf((*MockResourceHandlerInterface)(nil).Collection))
+}
+
+// CreateResource mocks base method.
+func (m *MockResourceHandlerInterface) CreateResource(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateResource", c)
+}
+
+// CreateResource indicates an expected call of CreateResource.
+func (mr *MockResourceHandlerInterfaceMockRecorder) CreateResource(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockResourceHandlerInterface)(nil).CreateResource), c)
+}
+
+// DeleteResource mocks base method.
+func (m *MockResourceHandlerInterface) DeleteResource(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteResource", c)
+}
+
+// DeleteResource indicates an expected call of DeleteResource.
+func (mr *MockResourceHandlerInterfaceMockRecorder) DeleteResource(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResource", reflect.TypeOf((*MockResourceHandlerInterface)(nil).DeleteResource), c)
+}
+
+// GetResource mocks base method.
+func (m *MockResourceHandlerInterface) GetResource(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetResource", c)
+}
+
+// GetResource indicates an expected call of GetResource.
+func (mr *MockResourceHandlerInterfaceMockRecorder) GetResource(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResource", reflect.TypeOf((*MockResourceHandlerInterface)(nil).GetResource), c)
+}
+
+// ResourceType mocks base method.
+func (m *MockResourceHandlerInterface) ResourceType() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResourceType")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ResourceType indicates an expected call of ResourceType.
+func (mr *MockResourceHandlerInterfaceMockRecorder) ResourceType() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceType", reflect.TypeOf((*MockResourceHandlerInterface)(nil).ResourceType))
+}
+
+// SearchResources mocks base method.
+func (m *MockResourceHandlerInterface) SearchResources(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SearchResources", c)
+}
+
+// SearchResources indicates an expected call of SearchResources.
+func (mr *MockResourceHandlerInterfaceMockRecorder) SearchResources(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchResources", reflect.TypeOf((*MockResourceHandlerInterface)(nil).SearchResources), c)
+}
+
+// UpdateResource mocks base method.
+func (m *MockResourceHandlerInterface) UpdateResource(c *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateResource", c)
+}
+
+// UpdateResource indicates an expected call of UpdateResource.
+func (mr *MockResourceHandlerInterfaceMockRecorder) UpdateResource(c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockResourceHandlerInterface)(nil).UpdateResource), c)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// localOperationDefinitions are the definitions of the operations without a published R4
+// definition, by id. They are served under the FHIR base, where the capability statement
+// points to them; the url is set to the address each is served at.
+var localOperationDefinitions = map[string]fhir.OperationDefinition{
+	"Patient-merge": localOperationDefinition("Patient-merge", "merge", true, false,
+		"Merge a duplicate source patient into a target patient. The target takes the source's identifiers, names, telecom and "+
+			"addresses by the configured merge rules; the source becomes inactive and references to it are repointed to the target.",
+		operationParameter("source-patient", fhir.OperationParameterUseIn, 1, "Reference", "The duplicate patient to merge"),
+		operationParameter("target-patient", fhir.OperationParameterUseIn, 1, "Reference", "The patient that remains"),
+		operationParameter("preview", fhir.OperationParameterUseIn, 0, "boolean", "Return the result without storing anything"),
+		operationParameter("outcome", fhir.OperationParameterUseOut, 1, "OperationOutcome", "The outcome of the merge"),
+		operationParameter("result", fhir.OperationParameterUseOut, 1, "Patient", "The merged target patient"),
+		operationParameter("source", fhir.OperationParameterUseOut, 1, "Patient", "The source patient after the merge"),
+	),
+	"Patient-unmerge": localOperationDefinition("Patient-unmerge", "unmerge", false, true,
+		"Reverse the merge of a source patient, restoring it and the target and pointing repointed references back to the source.",
+		operationParameter("outcome", fhir.OperationParameterUseOut, 1, "OperationOutcome", "The outcome of the unmerge"),
+		operationParameter("source", fhir.OperationParameterUseOut, 1, "Patient", "The restored source patient"),
+		operationParameter("target", fhir.OperationParameterUseOut, 1, "Patient", "The target patient without the merged data"),
+	),
+	"Patient-expunge": localOperationDefinition("Patient-expunge", "expunge", false, true,
+		"Permanently remove a patient, deleted or not, with its identifiers and merge records.",
+		operationParameter("expungePreviousVersions", fhir.OperationParameterUseIn, 0, "boolean", "Remove the patient's history too"),
+		operationParameter("count", fhir.OperationParameterUseOut, 1, "integer", "The number of rows removed"),
+	),
+	"Patient-undelete": localOperationDefinition("Patient-undelete", "undelete", false, true,
+		"Restore a deleted patient as a new version. Served at [base]/admin/patients/[id]/$undelete.",
+		operationParameter("return", fhir.OperationParameterUseOut, 1, "Patient", "The restored patient"),
+	),
+}
+
+// localOperationDefinition describes a Patient operation that changes what the server stores
+func localOperationDefinition(id, code string, typeLevel, instance bool, description string, parameters ...fhir.OperationDefinitionParameter) fhir.OperationDefinition {
+	affectsState := true
+	return fhir.OperationDefinition{
+		Id:           &id,
+		Name:         id,
+		Status:       fhir.PublicationStatusActive,
+		Kind:         fhir.OperationKindOperation,
+		Description:  &description,
+		AffectsState: &affectsState,
+		Code:         code,
+		Resource:     []fhir.ResourceType{fhir.ResourceTypePatient},
+		Type:         typeLevel,
+		Instance:     instance,
+		Parameter:    parameters,
+	}
+}
+
+// operationParameter describes a parameter that occurs at most once
+func operationParameter(name string, use fhir.OperationParameterUse, min int, dataType, documentation string) fhir.OperationDefinitionParameter {
+	return fhir.OperationDefinitionParameter{
+		Name:          name,
+		Use:           use,
+		Min:           min,
+		Max:           "1",
+		Type:          &dataType,
+		Documentation: &documentation,
+	}
+}
+
+// GetOperationDefinition handles GET /OperationDefinition/:id
+// @Summary Get an operation definition
+// @Description Get the OperationDefinition of an operation the capability statement describes with a local definition: Patient-merge, Patient-unmerge, Patient-expunge or Patient-undelete.
+// @Tags Capability
+// @Produce json,xml
+// @Param id path string true "OperationDefinition id"
+// @Success 200 {object} fhir.OperationDefinition
+// @Failure 404 {object} fhir.OperationOutcome
+// @Router /OperationDefinition/{id} [get]
+func (h *CapabilityHandler) GetOperationDefinition(c *gin.Context) {
+	_, span := tracer.StartSpan(c.Request.Context(), "GetOperationDefinition")
+	defer span.End()
+
+	id := c.Param("id")
+	definition, ok := localOperationDefinitions[id]
+	if !ok {
+		outcome.Write(c, http.StatusNotFound, fhir.IssueTypeNotFound, fmt.Sprintf("OperationDefinition/%s is not known to this server", id))
+		return
+	}
+	url := requestBaseURL(c) + h.basePath + "/OperationDefinition/" + id
+	definition.Url = &url
+	c.JSON(http.StatusOK, definition)
+}
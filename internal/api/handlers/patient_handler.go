@@ -1,10 +1,24 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 
+	"go-fhir-demo/internal/api/outcome"
 	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirbundle"
+	"go-fhir-demo/pkg/fhirmatch"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirsubset"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils/tracer"
 
@@ -20,30 +34,59 @@ type PatientHandlerInterface interface {
 	UpdatePatient(c *gin.Context)
 	PatchPatient(c *gin.Context)
 	DeletePatient(c *gin.Context)
+	ConditionalUpdatePatient(c *gin.Context)
+	ConditionalDeletePatient(c *gin.Context)
+	GetPatientHistory(c *gin.Context)
+	GetPatientVersion(c *gin.Context)
+	GetPatientsHistory(c *gin.Context)
+	ValidatePatient(c *gin.Context)
+	MatchPatient(c *gin.Context)
+	UndeletePatient(c *gin.Context)
+	ExpungePatient(c *gin.Context)
 }
 
+// Values of the return preference of the Prefer header
+const (
+	returnMinimal          = "minimal"
+	returnOperationOutcome = "OperationOutcome"
+)
+
+// logicalIDPattern matches the FHIR id data type
+var logicalIDPattern = regexp.MustCompile(`^[A-Za-z0-9\-.]{1,64}$`)
+
 // PatientHandler struct
 type PatientHandler struct {
-	service domain.PatientService
+	service      domain.PatientService
+	compartments domain.CompartmentService
 }
 
-// NewPatientHandler creates a new patient handler
-func NewPatientHandler(service domain.PatientService) PatientHandlerInterface {
+// NewPatientHandler creates a new patient handler. The compartment service resolves the
+// resources _include and _revinclude add to patient searches.
+func NewPatientHandler(service domain.PatientService, compartments domain.CompartmentService) PatientHandlerInterface {
 	return &PatientHandler{
-		service: service,
+		service:      service,
+		compartments: compartments,
 	}
 }
 
 // CreatePatient handles POST /patients
 // @Summary Create a new Patient
-// @Description Create a new FHIR Patient resource
+// @Description Create a new FHIR Patient resource. With If-None-Exist the patient is only created when no existing patient matches the given search criteria.
 // @Tags Patient
-// @Accept json
-// @Produce json
+// @Accept json,xml
+// @Produce json,xml
 // @Param patient body fhir.Patient true "FHIR Patient resource"
-// @Success 201 {object} fhir.Patient
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Param If-None-Exist header string false "Search criteria for a conditional create, e.g. identifier=http://hospital.org|123"
+// @Param Prefer header string false "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation"
+// @Success 200 {object} fhir.Patient "An existing patient matched the If-None-Exist criteria"
+// @Success 201 {object} fhir.Patient "Created; a Warning header names each probable duplicate found by the patient matcher"
+// @Header 200,201 {string} Location "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+// @Header 200,201 {string} ETag "Weak ETag of the patient's version, e.g. W/\"1\""
+// @Header 200,201 {string} Last-Modified "Time the patient was last updated"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "An identifier of a unique system belongs to another patient"
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients [post]
 func (h *PatientHandler) CreatePatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "CreatePatient")
@@ -54,321 +97,1138 @@ func (h *PatientHandler) CreatePatient(c *gin.Context) {
 	if err := c.ShouldBindJSON(&fhirPatient); err != nil {
 		logger.WithContext(ctx).Errorf("Failed to bind JSON: %v", err)
 		// Return a 400 Bad Request if JSON binding fails
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid JSON",
-			"message": err.Error(),
-		})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	// Create patient
-	patient, err := h.service.CreatePatient(ctx, &fhirPatient)
+	// Create patient, or find the existing one for a conditional create
+	var (
+		patient *domain.Patient
+		err     error
+	)
+	created := true
+	if ifNoneExist := c.GetHeader("If-None-Exist"); ifNoneExist != "" {
+		criteria, parseErr := parseIfNoneExist(ifNoneExist)
+		if parseErr != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-None-Exist header: "+parseErr.Error())
+			return
+		}
+		patient, created, err = h.service.ConditionalCreatePatient(ctx, &fhirPatient, criteria)
+	} else {
+		patient, err = h.service.CreatePatient(ctx, &fhirPatient)
+	}
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to create patient: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create patient",
-			"message": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
 
 	// Convert back to FHIR for response
-	fhirResponse, err := h.service.ConvertToFHIR(ctx, patient)
-	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to convert response",
-			"message": err.Error(),
-		})
-		return
+	status, action := http.StatusCreated, "Created"
+	if !created {
+		status, action = http.StatusOK, "Found existing"
 	}
-
-	c.JSON(http.StatusCreated, fhirResponse)
+	for _, duplicate := range patient.Duplicates {
+		c.Writer.Header().Add("Warning", fmt.Sprintf(`199 - "Possible duplicate of Patient/%s (%s match, score %.2f)"`,
+			duplicate.Patient.LogicalID, duplicate.Grade, duplicate.Score))
+	}
+	h.writePatient(ctx, c, status, action, patient)
 }
 
 // GetPatient handles GET /patients/:id
 // @Summary Get a Patient by ID
-// @Description Get a FHIR Patient resource by its ID
+// @Description Get a FHIR Patient resource by its ID. A deleted patient is reported with 410 Gone.
 // @Tags Patient
-// @Produce json
-// @Param id path int true "Patient ID"
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param _summary query string false "Return a subset of the patient (true, text, data, false)"
+// @Param _elements query string false "Comma-separated top-level elements to return"
 // @Success 200 {object} fhir.Patient
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 410 {object} fhir.OperationOutcome "The patient has been deleted"
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients/{id} [get]
 func (h *PatientHandler) GetPatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatient")
 	defer span.End()
 
-	idStr := c.Param("id")
-	if idStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID is required",
-		})
+	id, ok := parseLogicalID(c)
+	if !ok {
 		return
 	}
-	// Parse the ID from the URL parameter
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	// If parsing fails, return a 400 Bad Request
+	projection, err := fhirsubset.Parse(c.Request.URL.Query())
+	if err == nil && projection.Summary == fhirsubset.SummaryCount {
+		err = errors.New("_summary=count is only supported on search")
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID must be a valid number",
-		})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, err.Error())
 		return
 	}
 	// Fetch the patient from the service
-	logger.WithContext(ctx).Infof("Fetching patient with ID: %d", id)
-	patient, err := h.service.GetPatient(ctx, uint(id))
+	logger.WithContext(ctx).Infof("Fetching patient with ID: %s", id)
+	patient, err := h.service.GetPatient(ctx, id)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to get patient: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Patient not found",
-			"message": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
 
 	fhirPatient, err := h.service.ConvertToFHIR(ctx, patient)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to convert patient data",
-			"message": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, fhirPatient)
+	resource, err := projectPatient(projection, fhirPatient)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to project patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(patient.VersionID))
+	c.JSON(http.StatusOK, resource)
 }
 
 // GetPatients handles GET /patients
-// @Summary Get all Patients
-// @Description Get all FHIR Patient resources with pagination
+// @Summary Search Patients
+// @Description Search FHIR Patient resources using standard FHIR search parameters, returning a searchset Bundle
 // @Tags Patient
-// @Produce json
-// @Param limit query int false "Limit" default(10)
-// @Param offset query int false "Offset" default(0)
-// @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Produce json,xml
+// @Param _id query string false "Logical id of the patient"
+// @Param name query string false "A portion of either family or given name (supports :exact and :contains)"
+// @Param family query string false "A portion of the family name"
+// @Param given query string false "A portion of the given name"
+// @Param gender query string false "Gender (male, female, other, unknown)"
+// @Param birthdate query string false "Date of birth with optional prefix (eq, ne, lt, gt, le, ge, sa, eb, ap)"
+// @Param active query string false "Whether the patient record is active (true, false)"
+// @Param _include query string false "Referenced resources to add: Patient:organization or Patient:general-practitioner"
+// @Param _revinclude query string false "Referring resources to add, e.g. Observation:subject or Encounter:patient"
+// @Param _summary query string false "Return subsets of the patients (true, text, data, false), or only the total (count)"
+// @Param _elements query string false "Comma-separated top-level elements to return"
+// @Param _lastUpdated query string false "When the patient last changed, with optional prefix"
+// @Param _content query string false "Words, or starts of words, anywhere in the patient's names, telecom, addresses, contacts, notes or narrative; matches are ranked by relevance"
+// @Param _text query string false "Words, or starts of words, in the patient's narrative"
+// @Param _sort query string false "Comma-separated sort keys (_id, _lastUpdated, family, given, birthdate), - for descending; without it full-text matches are sorted by relevance"
+// @Param _total query string false "Whether to count the matches (none, estimate, accurate); only accurate counts on pages after the first"
+// @Param _count query int false "Number of results per page" default(10)
+// @Param _page_token query string false "Opaque page token taken from a Bundle paging link"
+// @Param limit query int false "Limit (deprecated, use _count); capped at 1000 like _count" default(10)
+// @Param offset query int false "Offset (deprecated, use paging links)" default(0)
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients [get]
 func (h *PatientHandler) GetPatients(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatients")
 	defer span.End()
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+	query, err := fhirsearch.Parse(c.Request.URL.Query(), domain.PatientSearchParameters)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid search parameters: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid search parameters: "+err.Error())
+		return
+	}
+	projection, err := fhirsubset.Parse(c.Request.URL.Query())
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid search parameters: "+err.Error())
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 10
+	// _summary=count only needs the number of matches
+	if projection.Summary == fhirsubset.SummaryCount {
+		total, err := h.service.CountPatients(ctx, query)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to count patients: %v", err)
+			outcome.Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, fhirbundle.NewSearchSet(total, nil, nil))
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	// Support the legacy limit/offset parameters when no FHIR paging parameters are given
+	values := c.Request.URL.Query()
+	if _, ok := values["_count"]; !ok {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+		query.Count = min(limit, fhirsearch.MaxCount)
+	}
+	if !fhirsearch.HasPageToken(values) {
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+		query.Offset = offset
 	}
 
-	logger.WithContext(ctx).Infof("Fetching patients with limit %d and offset %d", limit, offset)
+	logger.WithContext(ctx).Infof("Searching patients with %d parameters, count %d and offset %d",
+		len(query.Params), query.Count, query.Offset)
 
-	patients, total, err := h.service.GetPatients(ctx, limit, offset)
+	page, err := h.service.SearchPatients(ctx, query)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to get patients: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get patients",
-			"message": err.Error(),
-		})
+		outcome.Error(c, err)
 		return
 	}
+	patients := page.Patients
 
-	// Convert patients to FHIR format
-	fhirPatients := make([]*fhir.Patient, 0, len(patients))
+	// Convert patients to FHIR searchset entries
+	pageURL := collectionURL(c)
+	entries := make([]fhir.BundleEntry, 0, len(patients))
 	for _, patient := range patients {
 		fhirPatient, err := h.service.ConvertToFHIR(ctx, patient)
 		if err != nil {
-			logger.WithContext(ctx).Warnf("Failed to convert patient %d to FHIR: %v", patient.ID, err)
+			logger.WithContext(ctx).Warnf("Failed to convert patient %s to FHIR: %v", patient.LogicalID, err)
+			continue
+		}
+		resource, err := projectPatient(projection, fhirPatient)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to project patient %s: %v", patient.LogicalID, err)
 			continue
 		}
-		fhirPatients = append(fhirPatients, fhirPatient)
+		entry, err := fhirbundle.NewEntry(pageURL+"/"+patient.LogicalID, resource, fhir.SearchEntryModeMatch)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to build bundle entry for patient %s: %v", patient.LogicalID, err)
+			continue
+		}
+		if patient.Score > 0 {
+			score := json.Number(strconv.FormatFloat(patient.Score, 'f', 4, 64))
+			entry.Search.Score = &score
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(query.Include) > 0 || len(query.RevInclude) > 0 {
+		included, truncated, err := h.compartments.PatientIncludes(ctx, patients, query)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to resolve included resources: %v", err)
+			outcome.Error(c, err)
+			return
+		}
+		baseURL := strings.TrimSuffix(pageURL, "/patients")
+		for _, resource := range included {
+			fullURL := baseURL + "/" + compartmentCollection(resource.ResourceType) + "/" + resource.LogicalID
+			entry, err := fhirbundle.NewEntry(fullURL, resource.Resource, fhir.SearchEntryModeInclude)
+			if err != nil {
+				logger.WithContext(ctx).Warnf("Failed to build bundle entry for %s %s: %v", resource.ResourceType, resource.LogicalID, err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if truncated {
+			entry, err := fhirbundle.NewEntry("", outcome.New(fhir.IssueSeverityWarning, fhir.IssueTypeTooCostly,
+				fmt.Sprintf("Included resources were limited to %d", fhirsearch.MaxIncludeCount)), fhir.SearchEntryModeOutcome)
+			if err == nil {
+				entry.FullUrl = nil
+				entries = append(entries, entry)
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"patients": fhirPatients,
-		"total":    total,
-		"limit":    limit,
-		"offset":   offset,
-	})
+	// Paging links carry keyset cursors; the last page is found backwards from the end
+	self := values.Get(fhirsearch.PageTokenParam)
+	if self == "" && query.Offset > 0 {
+		self = fhirsearch.EncodePageToken(query.Offset)
+	}
+	var last *fhirsearch.Cursor
+	if page.Total != 0 {
+		last = &fhirsearch.Cursor{Sort: fhirsearch.FormatSort(query.Sort), Backward: true}
+	}
+	links := fhirbundle.CursorLinks(pageURL, values, query.Count, self, page.Previous, page.Next, last)
+	c.JSON(http.StatusOK, fhirbundle.NewSearchSet(page.Total, entries, links))
 }
 
 // UpdatePatient handles PUT /patients/:id
 // @Summary Update a Patient
 // @Description Update an existing FHIR Patient resource
 // @Tags Patient
-// @Accept json
-// @Produce json
-// @Param id path int true "Patient ID"
+// @Accept json,xml
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
 // @Param patient body fhir.Patient true "FHIR Patient resource"
+// @Param If-Match header string false "Weak ETag of the version being updated, e.g. W/\"3\""
+// @Param Prefer header string false "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation"
 // @Success 200 {object} fhir.Patient
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Header 200 {string} Location "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+// @Header 200 {string} ETag "Weak ETag of the patient's version, e.g. W/\"1\""
+// @Header 200 {string} Last-Modified "Time the patient was last updated"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "An identifier of a unique system belongs to another patient"
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients/{id} [put]
 func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "UpdatePatient")
 	defer span.End()
-	idStr := c.Param("id")
-	if idStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID is required",
-		})
+	id, ok := parseLogicalID(c)
+	if !ok {
 		return
 	}
-	// Parse the ID from the URL parameter
-	id, err := strconv.ParseUint(idStr, 10, 64)
 
-	// If parsing fails, return a 400 Bad Request
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID must be a valid number",
-		})
-		return
-	}
-
-	logger.WithContext(ctx).Infof("Updating patient with ID: %d", id)
+	logger.WithContext(ctx).Infof("Updating patient with ID: %s", id)
 
 	var fhirPatient fhir.Patient
 	if err := c.ShouldBindJSON(&fhirPatient); err != nil {
 		logger.WithContext(ctx).Errorf("Failed to bind JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid JSON",
-			"message": err.Error(),
-		})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	logger.WithContext(ctx).Infof("Updating patient with ID: %d using FHIR data", id)
+	logger.WithContext(ctx).Infof("Updating patient with ID: %s using FHIR data", id)
 
-	patient, err := h.service.UpdatePatient(ctx, uint(id), &fhirPatient)
+	expectedVersion, err := parseIfMatch(c)
 	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to update patient %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update patient",
-			"message": err.Error(),
-		})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-Match header: "+err.Error())
 		return
 	}
 
-	fhirResponse, err := h.service.ConvertToFHIR(ctx, patient)
+	patient, err := h.service.UpdatePatient(ctx, id, &fhirPatient, expectedVersion)
 	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to convert response",
-			"message": err.Error(),
-		})
+		logger.WithContext(ctx).Errorf("Failed to update patient %s: %v", id, err)
+		outcome.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, fhirResponse)
+	h.writePatient(ctx, c, http.StatusOK, "Updated", patient)
 }
 
+// PatchFormats are the media types of the FHIR patch formats PatchPatient accepts: JSON
+// Patch, and FHIRPath Patch Parameters in either format. The flat map of partial updates
+// is not a FHIR patch format.
+var PatchFormats = []string{"application/json-patch+json", "application/fhir+json", "application/fhir+xml"}
+
 // PatchPatient handles PATCH /patients/:id
 // @Summary Partially update a Patient
-// @Description Partially update an existing FHIR Patient resource
+// @Description Partially update an existing FHIR Patient resource. The body is an RFC 6902 JSON Patch (application/json-patch+json), a FHIRPath Patch Parameters resource (application/fhir+json or application/fhir+xml), or a flat map of active, family, given, gender and birthDate (application/merge-patch+json). Plain application/json may carry any of the three. Unknown keys and invalid values in the flat map are rejected.
 // @Tags Patient
-// @Accept json
-// @Produce json
-// @Param id path int true "Patient ID"
-// @Param patches body map[string]interface{} true "Partial updates"
+// @Accept json,xml
+// @Accept application/json-patch+json
+// @Accept application/merge-patch+json
+// @Accept application/fhir+json
+// @Accept application/fhir+xml
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param patches body object true "JSON Patch operations, FHIRPath Patch Parameters or partial updates"
+// @Param If-Match header string false "Weak ETag of the version being patched, e.g. W/\"3\""
+// @Param Prefer header string false "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation"
 // @Success 200 {object} fhir.Patient
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Header 200 {string} Location "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+// @Header 200 {string} ETag "Weak ETag of the patient's version, e.g. W/\"1\""
+// @Header 200 {string} Last-Modified "Time the patient was last updated"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "An identifier of a unique system belongs to another patient"
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 415 {object} fhir.OperationOutcome
+// @Failure 422 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients/{id} [patch]
 func (h *PatientHandler) PatchPatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "PatchPatient")
 	defer span.End()
 
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID must be a valid number",
-		})
+	id, ok := parseLogicalID(c)
+	if !ok {
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		logger.WithContext(ctx).Errorf("Failed to bind JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid JSON",
-			"message": err.Error(),
-		})
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-Match header: "+err.Error())
 		return
 	}
 
-	patient, err := h.service.PatchPatient(ctx, uint(id), updates)
-	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to patch patient %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to patch patient",
-			"message": err.Error(),
-		})
+	body, err := c.GetRawData()
+	if err != nil || !json.Valid(body) {
+		logger.WithContext(ctx).Errorf("Failed to read patch body: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Patch body must be valid JSON")
 		return
 	}
 
-	fhirResponse, err := h.service.ConvertToFHIR(ctx, patient)
+	// The patch format is chosen by content type. Plain application/json is told apart by
+	// the shape of the body; only it and merge patches may carry the flat map of updates.
+	isJSONPatch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+	var patient *domain.Patient
+	switch contentType := c.ContentType(); {
+	case contentType == "application/json-patch+json", contentType == "application/json" && isJSONPatch:
+		patient, err = h.service.JSONPatchPatient(ctx, id, body, expectedVersion)
+	case contentType == "application/fhir+json", contentType == "application/json" && fhirpatch.IsParameters(body):
+		if !fhirpatch.IsParameters(body) {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "A FHIRPath Patch body must be a Parameters resource")
+			return
+		}
+		patient, err = h.service.FHIRPathPatchPatient(ctx, id, body, expectedVersion)
+	case contentType == "application/merge-patch+json", contentType == "application/json":
+		var updates map[string]interface{}
+		if err := json.Unmarshal(body, &updates); err != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Partial updates must be a JSON object: "+err.Error())
+			return
+		}
+		patient, err = h.service.PatchPatient(ctx, id, updates, expectedVersion)
+	default:
+		outcome.Write(c, http.StatusUnsupportedMediaType, fhir.IssueTypeNotSupported,
+			"Unsupported patch Content-Type "+contentType+"; use application/json-patch+json, application/fhir+json or application/merge-patch+json")
+		return
+	}
 	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to convert response",
-			"message": err.Error(),
-		})
+		logger.WithContext(ctx).Errorf("Failed to patch patient %s: %v", id, err)
+		outcome.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, fhirResponse)
+	h.writePatient(ctx, c, http.StatusOK, "Updated", patient)
 }
 
 // DeletePatient handles DELETE /patients/:id
 // @Summary Delete a Patient
 // @Description Delete an existing FHIR Patient resource
 // @Tags Patient
-// @Produce json
-// @Param id path int true "Patient ID"
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param If-Match header string false "Weak ETag of the version being deleted, e.g. W/\"3\""
 // @Success 204 "No Content"
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
 // @Router /patients/{id} [delete]
 func (h *PatientHandler) DeletePatient(c *gin.Context) {
 	ctx, span := tracer.StartSpan(c.Request.Context(), "DeletePatient")
 	defer span.End()
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+	logger.WithContext(ctx).Infof("Deleting patient with ID: %s", id)
 
+	expectedVersion, err := parseIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid patient ID",
-			"message": "Patient ID must be a valid number",
-		})
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-Match header: "+err.Error())
 		return
 	}
-	logger.WithContext(ctx).Infof("Deleting patient with ID: %d", id)
 
-	err = h.service.DeletePatient(ctx, uint(id))
+	err = h.service.DeletePatient(ctx, id, expectedVersion)
 	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to delete patient %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete patient",
-			"message": err.Error(),
-		})
+		logger.WithContext(ctx).Errorf("Failed to delete patient %s: %v", id, err)
+		outcome.Error(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// UndeletePatient handles POST /admin/patients/:id/$undelete
+// @Summary Undelete a Patient
+// @Description Restore a deleted FHIR Patient resource as a new version. Its identifiers are indexed again, so an identifier of a unique system that another patient took since the deletion is a conflict.
+// @Tags Admin
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Success 200 {object} fhir.Patient
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "The patient is not deleted, or an identifier of a unique system belongs to another patient"
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /admin/patients/{id}/$undelete [post]
+func (h *PatientHandler) UndeletePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "UndeletePatient")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+	logger.WithContext(ctx).Infof("Undeleting patient with ID: %s", id)
+
+	patient, err := h.service.UndeletePatient(ctx, id)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to undelete patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	fhirPatient, err := h.service.ConvertToFHIR(ctx, patient)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(patient.VersionID))
+	c.JSON(http.StatusOK, fhirPatient)
+}
+
+// ExpungePatient handles POST /patients/:id/$expunge
+// @Summary Expunge a Patient
+// @Description Permanently remove a FHIR Patient resource, deleted or not, with its identifiers and merge records. The optional body is a Parameters resource; with expungePreviousVersions set to true the patient's history is removed too. The response is a Parameters resource with the count of rows removed.
+// @Tags Patient
+// @Accept json,xml
+// @Accept application/fhir+json
+// @Accept application/fhir+xml
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param parameters body object false "Parameters resource with expungePreviousVersions"
+// @Success 200 {object} fhir.Parameters
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/{id}/$expunge [post]
+func (h *PatientHandler) ExpungePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ExpungePatient")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Failed to read request body")
+		return
+	}
+	history := false
+	if len(body) > 0 {
+		if !fhirpatch.IsParameters(body) {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be a Parameters resource")
+			return
+		}
+		if history, err = parseExpungeParameters(body); err != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, err.Error())
+			return
+		}
+	}
+	logger.WithContext(ctx).Infof("Expunging patient with ID: %s (history: %t)", id, history)
+
+	removed, err := h.service.ExpungePatient(ctx, id, history)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to expunge patient %s: %v", id, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	count := int(removed)
+	c.JSON(http.StatusOK, fhir.Parameters{Parameter: []fhir.ParametersParameter{{Name: "count", ValueInteger: &count}}})
+}
+
+// ConditionalUpdatePatient handles PUT /patients?criteria
+// @Summary Conditionally update a Patient
+// @Description Update the single Patient matching the search criteria, or create it when nothing matches. Multiple matches are rejected with 412.
+// @Tags Patient
+// @Accept json,xml
+// @Produce json,xml
+// @Param identifier query string false "Patient identifier as system|value"
+// @Param patient body fhir.Patient true "FHIR Patient resource"
+// @Param Prefer header string false "return=minimal for no body, return=OperationOutcome for an outcome instead of the patient; default return=representation"
+// @Success 200 {object} fhir.Patient
+// @Success 201 {object} fhir.Patient
+// @Header 200,201 {string} Location "Versioned URL of the patient, e.g. .../patients/abc/_history/1"
+// @Header 200,201 {string} ETag "Weak ETag of the patient's version, e.g. W/\"1\""
+// @Header 200,201 {string} Last-Modified "Time the patient was last updated"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 409 {object} fhir.OperationOutcome "An identifier of a unique system belongs to another patient"
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients [put]
+func (h *PatientHandler) ConditionalUpdatePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ConditionalUpdatePatient")
+	defer span.End()
+
+	criteria, err := parseConditionalCriteria(c.Request.URL.Query())
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid search criteria: "+err.Error())
+		return
+	}
+
+	var fhirPatient fhir.Patient
+	if err := c.ShouldBindJSON(&fhirPatient); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to bind JSON: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	patient, created, err := h.service.ConditionalUpdatePatient(ctx, criteria, &fhirPatient)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to conditionally update patient: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	status, action := http.StatusOK, "Updated"
+	if created {
+		status, action = http.StatusCreated, "Created"
+	}
+	h.writePatient(ctx, c, status, action, patient)
+}
+
+// ConditionalDeletePatient handles DELETE /patients?criteria
+// @Summary Conditionally delete a Patient
+// @Description Delete the single Patient matching the search criteria. No match is not an error; multiple matches are rejected with 412.
+// @Tags Patient
+// @Produce json,xml
+// @Param identifier query string false "Patient identifier as system|value"
+// @Success 204 "No Content"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients [delete]
+func (h *PatientHandler) ConditionalDeletePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ConditionalDeletePatient")
+	defer span.End()
+
+	criteria, err := parseConditionalCriteria(c.Request.URL.Query())
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid search criteria: "+err.Error())
+		return
+	}
+
+	if err := h.service.ConditionalDeletePatient(ctx, criteria); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to conditionally delete patient: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ValidatePatient handles POST /patients/$validate
+// @Summary Validate a Patient
+// @Description Validate a FHIR Patient resource without storing it. The resource is checked against the base specification, the enforced profiles and any requested profiles. The body is a Patient resource, or a Parameters resource with resource and profile parameters.
+// @Tags Patient
+// @Accept json,xml
+// @Accept application/fhir+json
+// @Accept application/fhir+xml
+// @Produce json,xml
+// @Param profile query string false "Canonical URL of a profile to validate against"
+// @Param resource body object true "Patient resource or Parameters resource"
+// @Success 200 {object} fhir.OperationOutcome "Validation result; error issues mean the resource is invalid"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Router /patients/$validate [post]
+func (h *PatientHandler) ValidatePatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "ValidatePatient")
+	defer span.End()
+
+	body, err := c.GetRawData()
+	if err != nil || !json.Valid(body) {
+		logger.WithContext(ctx).Errorf("Failed to read validation body: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be valid JSON")
+		return
+	}
+
+	resource, profiles := body, c.QueryArray("profile")
+	if fhirpatch.IsParameters(body) {
+		resource, profiles, err = parseValidateParameters(body, profiles)
+		if err != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, err.Error())
+			return
+		}
+	}
+
+	issues, err := h.service.ValidatePatient(ctx, resource, profiles)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to validate patient: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	if len(issues) == 0 {
+		c.JSON(http.StatusOK, outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational, "Validation successful, no issues found"))
+		return
+	}
+	c.JSON(http.StatusOK, fhir.OperationOutcome{Issue: issues})
+}
+
+// MatchPatient handles POST /patients/$match
+// @Summary Find matching Patients
+// @Description Find stored patients that may be the patient in the request. Candidates are scored on name, birth date, gender, telecom, address and identifiers and returned best first, with the score in search.score and the grade in the match-grade extension. The body is a Parameters resource with a resource parameter and optional onlyCertainMatches and count parameters, or a bare Patient resource.
+// @Tags Patient
+// @Accept json,xml
+// @Accept application/fhir+json
+// @Accept application/fhir+xml
+// @Produce json,xml
+// @Param parameters body object true "Parameters resource or Patient resource"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/$match [post]
+func (h *PatientHandler) MatchPatient(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "MatchPatient")
+	defer span.End()
+
+	body, err := c.GetRawData()
+	if err != nil || !json.Valid(body) {
+		logger.WithContext(ctx).Errorf("Failed to read match body: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Request body must be valid JSON")
+		return
+	}
+
+	resource, onlyCertainMatches, count := body, false, 0
+	if fhirpatch.IsParameters(body) {
+		resource, onlyCertainMatches, count, err = parseMatchParameters(body)
+		if err != nil {
+			outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, err.Error())
+			return
+		}
+	}
+	var document struct {
+		ResourceType string `json:"resourceType"`
+	}
+	var fhirPatient fhir.Patient
+	if json.Unmarshal(resource, &document) != nil || document.ResourceType != "Patient" || json.Unmarshal(resource, &fhirPatient) != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "The resource to match must be a Patient")
+		return
+	}
+
+	matches, err := h.service.MatchPatient(ctx, &fhirPatient, onlyCertainMatches, count)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to match patient: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	pageURL := collectionURL(c)
+	entries := make([]fhir.BundleEntry, 0, len(matches))
+	for _, match := range matches {
+		fhirMatch, err := h.service.ConvertToFHIR(ctx, match.Patient)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to convert patient %s to FHIR: %v", match.Patient.LogicalID, err)
+			continue
+		}
+		entry, err := fhirbundle.NewEntry(pageURL+"/"+match.Patient.LogicalID, fhirMatch, fhir.SearchEntryModeMatch)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to build bundle entry for patient %s: %v", match.Patient.LogicalID, err)
+			continue
+		}
+		score := json.Number(strconv.FormatFloat(match.Score, 'f', -1, 64))
+		grade := string(match.Grade)
+		entry.Search.Score = &score
+		entry.Search.Extension = []fhir.Extension{{Url: fhirmatch.GradeExtensionURL, ValueCode: &grade}}
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, fhirbundle.NewSearchSet(int64(len(entries)), entries, nil))
+}
+
+// GetPatientHistory handles GET /patients/:id/_history
+// @Summary Get the history of a Patient
+// @Description Get all versions of a FHIR Patient resource as a history Bundle, newest first
+// @Tags Patient
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param _since query string false "Only include versions created at or after this instant"
+// @Param _count query int false "Number of entries per page" default(10)
+// @Param _page_token query string false "Opaque page token taken from a Bundle paging link"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/{id}/_history [get]
+func (h *PatientHandler) GetPatientHistory(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatientHistory")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid history parameters: "+err.Error())
+		return
+	}
+	query.PatientID = id
+
+	logger.WithContext(ctx).Infof("Fetching history for patient with ID: %s", id)
+	h.writeHistory(c, query)
+}
+
+// GetPatientsHistory handles GET /patients/_history
+// @Summary Get the history of all Patients
+// @Description Get the versions of all FHIR Patient resources as a history Bundle, newest first
+// @Tags Patient
+// @Produce json,xml
+// @Param _since query string false "Only include versions created at or after this instant"
+// @Param _count query int false "Number of entries per page" default(10)
+// @Param _page_token query string false "Opaque page token taken from a Bundle paging link"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/_history [get]
+func (h *PatientHandler) GetPatientsHistory(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatientsHistory")
+	defer span.End()
+
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid history parameters: "+err.Error())
+		return
+	}
+
+	logger.WithContext(ctx).Infof("Fetching history for all patients")
+	h.writeHistory(c, query)
+}
+
+// GetPatientVersion handles GET /patients/:id/_history/:vid
+// @Summary Get a specific version of a Patient
+// @Description Get a FHIR Patient resource as it was at the given version (vread)
+// @Tags Patient
+// @Produce json,xml
+// @Param id path string true "Patient logical ID"
+// @Param vid path int true "Version ID"
+// @Success 200 {object} fhir.Patient
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 410 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /patients/{id}/_history/{vid} [get]
+func (h *PatientHandler) GetPatientVersion(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "GetPatientVersion")
+	defer span.End()
+
+	id, ok := parseLogicalID(c)
+	if !ok {
+		return
+	}
+	versionID, err := strconv.Atoi(c.Param("vid"))
+	if err != nil || versionID < 1 {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Version ID must be a positive number")
+		return
+	}
+
+	logger.WithContext(ctx).Infof("Fetching version %d of patient with ID: %s", versionID, id)
+	entry, err := h.service.GetPatientVersion(ctx, id, versionID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get patient version: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	fhirPatient, err := h.service.ConvertHistoryToFHIR(ctx, entry)
+	if err != nil {
+		if errors.Is(err, domain.ErrGone) {
+			outcome.Error(c, fmt.Errorf("%w: version %d of patient %s is a deletion", err, versionID, id))
+			return
+		}
+		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(entry.VersionID))
+	c.JSON(http.StatusOK, fhirPatient)
+}
+
+// writeHistory loads history entries and writes them as a history Bundle
+func (h *PatientHandler) writeHistory(c *gin.Context, query domain.HistoryQuery) {
+	ctx := c.Request.Context()
+
+	entries, total, err := h.service.GetPatientHistory(ctx, query)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get patient history: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+
+	baseURL := collectionURL(c)
+	bundleEntries := make([]fhir.BundleEntry, 0, len(entries))
+	for _, entry := range entries {
+		var resource interface{}
+		if entry.Method != http.MethodDelete {
+			fhirPatient, err := h.service.ConvertHistoryToFHIR(ctx, entry)
+			if err != nil {
+				logger.WithContext(ctx).Warnf("Failed to convert version %d of patient %s: %v", entry.VersionID, entry.LogicalID, err)
+				continue
+			}
+			resource = fhirPatient
+		}
+		bundleEntry, err := fhirbundle.NewHistoryEntry(
+			baseURL+"/"+entry.LogicalID,
+			resource,
+			entry.Method,
+			"patients/"+entry.LogicalID,
+			historyStatus(entry.Method),
+			etag(entry.VersionID),
+			entry.CreatedAt,
+		)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to build history entry: %v", err)
+			continue
+		}
+		bundleEntries = append(bundleEntries, bundleEntry)
+	}
+
+	pageURL := requestBaseURL(c) + c.Request.URL.Path
+	links := fhirbundle.PageLinks(pageURL, c.Request.URL.Query(), total, query.Count, query.Offset)
+	c.JSON(http.StatusOK, fhirbundle.NewHistory(total, bundleEntries, links))
+}
+
+// parseHistoryQuery reads the _since, _count and _page_token history parameters
+func parseHistoryQuery(c *gin.Context) (domain.HistoryQuery, error) {
+	query := domain.HistoryQuery{Count: fhirsearch.DefaultCount}
+
+	if since := c.Query("_since"); since != "" {
+		start, _, err := fhirsearch.ParseDateRange(since)
+		if err != nil {
+			return query, fmt.Errorf("invalid _since value: %w", err)
+		}
+		query.Since = &start
+	}
+	if countStr := c.Query("_count"); countStr != "" {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 0 {
+			return query, fmt.Errorf("invalid _count value %q", countStr)
+		}
+		if count > fhirsearch.MaxCount {
+			count = fhirsearch.MaxCount
+		}
+		query.Count = count
+	}
+	if token := c.Query(fhirsearch.PageTokenParam); token != "" {
+		offset, err := fhirsearch.DecodePageToken(token)
+		if err != nil {
+			return query, err
+		}
+		query.Offset = offset
+	}
+	return query, nil
+}
+
+// historyStatus returns the response status recorded for a history entry
+func historyStatus(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "201 Created"
+	case http.MethodDelete:
+		return "204 No Content"
+	default:
+		return "200 OK"
+	}
+}
+
+// writePatient responds to a create or update of a patient. Location, ETag and Last-Modified
+// are always set; the body is the patient, nothing or an OperationOutcome depending on the
+// return preference of the Prefer header.
+func (h *PatientHandler) writePatient(ctx context.Context, c *gin.Context, status int, action string, patient *domain.Patient) {
+	c.Header("Location", fmt.Sprintf("%s/%s/_history/%d", collectionURL(c), patient.LogicalID, patient.VersionID))
+	c.Header("ETag", etag(patient.VersionID))
+	if !patient.UpdatedAt.IsZero() {
+		c.Header("Last-Modified", patient.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	returnPreference, _ := preference(c, "return")
+	switch returnPreference {
+	case returnMinimal:
+		c.Status(status)
+		return
+	case returnOperationOutcome:
+		c.JSON(status, outcome.New(fhir.IssueSeverityInformation, fhir.IssueTypeInformational,
+			fmt.Sprintf("%s Patient/%s version %d", action, patient.LogicalID, patient.VersionID)))
+		return
+	}
+
+	fhirPatient, err := h.service.ConvertToFHIR(ctx, patient)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to convert to FHIR: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+	c.JSON(status, fhirPatient)
+}
+
+// preference returns the value of a preference of the Prefer header, such as minimal for
+// return=minimal, and whether the client stated it. Preferences such as respond-async have no value.
+func preference(c *gin.Context, name string) (string, bool) {
+	for _, header := range c.Request.Header.Values("Prefer") {
+		for _, part := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if strings.EqualFold(strings.TrimSpace(key), name) {
+				return strings.Trim(strings.TrimSpace(value), `"`), true
+			}
+		}
+	}
+	return "", false
+}
+
+// collectionURL returns the absolute URL of the patients collection the current route belongs to
+func collectionURL(c *gin.Context) string {
+	path := c.FullPath()
+	if idx := strings.Index(path, "/patients"); idx >= 0 {
+		path = path[:idx+len("/patients")]
+	}
+	return requestBaseURL(c) + path
+}
+
+// requestBaseURL returns the scheme and host the client used to reach the server
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// parseLogicalID reads the logical id from the :id path parameter, writing a 400 response
+// when it is not a valid FHIR id
+func parseLogicalID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if !logicalIDPattern.MatchString(id) {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid,
+			"Patient ID must be 1 to 64 letters, digits, '-' or '.'")
+		return "", false
+	}
+	return id, true
+}
+
+// projectPatient returns the part of a patient selected by _summary or _elements, or the
+// patient itself when the projection keeps every element
+func projectPatient(projection fhirsubset.Projection, fhirPatient *fhir.Patient) (interface{}, error) {
+	if !projection.Subsets() {
+		return fhirPatient, nil
+	}
+	data, err := json.Marshal(fhirPatient)
+	if err != nil {
+		return nil, err
+	}
+	projected, err := projection.Apply(data, domain.PatientSummaryElements)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(projected), nil
+}
+
+// etag formats a resource version as a weak entity tag
+func etag(versionID int) string {
+	return fmt.Sprintf(`W/"%d"`, versionID)
+}
+
+// parseIfMatch reads the expected version from the If-Match header.
+// Zero means the request carries no version precondition.
+func parseIfMatch(c *gin.Context) (int, error) {
+	return parseIfMatchValue(c.GetHeader("If-Match"))
+}
+
+// parseIfMatchValue reads the expected version from an If-Match value
+func parseIfMatchValue(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("If-Match must be an ETag such as W/\"1\", got %q", header)
+	}
+	return version, nil
+}
+
+// parseIfNoneExist parses the search criteria of an If-None-Exist header.
+// Both a bare query string and the Patient?query form are accepted.
+func parseIfNoneExist(header string) (*fhirsearch.Query, error) {
+	if _, query, found := strings.Cut(header, "?"); found {
+		header = query
+	}
+	values, err := url.ParseQuery(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search criteria: %w", err)
+	}
+	return parseConditionalCriteria(values)
+}
+
+// parseValidateParameters reads the resource and profile parameters of a $validate Parameters body
+func parseValidateParameters(body []byte, profiles []string) ([]byte, []string, error) {
+	var parameters fhir.Parameters
+	if err := json.Unmarshal(body, &parameters); err != nil {
+		return nil, nil, fmt.Errorf("invalid Parameters resource: %w", err)
+	}
+
+	var resource []byte
+	for _, parameter := range parameters.Parameter {
+		switch parameter.Name {
+		case "resource":
+			resource = parameter.Resource
+		case "profile":
+			if parameter.ValueUri != nil {
+				profiles = append(profiles, *parameter.ValueUri)
+			} else if parameter.ValueCanonical != nil {
+				profiles = append(profiles, *parameter.ValueCanonical)
+			}
+		}
+	}
+	if len(resource) == 0 {
+		return nil, nil, errors.New("Parameters must contain a resource parameter")
+	}
+	return resource, profiles, nil
+}
+
+// parseMatchParameters reads the resource, onlyCertainMatches and count parameters of a $match Parameters body
+func parseMatchParameters(body []byte) ([]byte, bool, int, error) {
+	var parameters fhir.Parameters
+	if err := json.Unmarshal(body, &parameters); err != nil {
+		return nil, false, 0, fmt.Errorf("invalid Parameters resource: %w", err)
+	}
+
+	var (
+		resource           []byte
+		onlyCertainMatches bool
+		count              int
+	)
+	for _, parameter := range parameters.Parameter {
+		switch parameter.Name {
+		case "resource":
+			resource = parameter.Resource
+		case "onlyCertainMatches":
+			if parameter.ValueBoolean == nil {
+				return nil, false, 0, errors.New("onlyCertainMatches must be a valueBoolean")
+			}
+			onlyCertainMatches = *parameter.ValueBoolean
+		case "count":
+			if parameter.ValueInteger == nil || *parameter.ValueInteger < 1 {
+				return nil, false, 0, errors.New("count must be a positive valueInteger")
+			}
+			count = *parameter.ValueInteger
+		}
+	}
+	if len(resource) == 0 {
+		return nil, false, 0, errors.New("Parameters must contain a resource parameter")
+	}
+	return resource, onlyCertainMatches, count, nil
+}
+
+// parseExpungeParameters reads the expungePreviousVersions parameter of an $expunge Parameters body
+func parseExpungeParameters(body []byte) (bool, error) {
+	var parameters fhir.Parameters
+	if err := json.Unmarshal(body, &parameters); err != nil {
+		return false, fmt.Errorf("invalid Parameters resource: %w", err)
+	}
+	history := false
+	for _, parameter := range parameters.Parameter {
+		if parameter.Name != "expungePreviousVersions" {
+			continue
+		}
+		if parameter.ValueBoolean == nil {
+			return false, errors.New("expungePreviousVersions must be a valueBoolean")
+		}
+		history = *parameter.ValueBoolean
+	}
+	return history, nil
+}
+
+// parseConditionalCriteria parses the search criteria of a conditional interaction,
+// which must contain at least one supported parameter
+func parseConditionalCriteria(values url.Values) (*fhirsearch.Query, error) {
+	criteria, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+	if err != nil {
+		return nil, err
+	}
+	if len(criteria.Params) == 0 {
+		return nil, errors.New("conditional interactions require at least one supported search parameter")
+	}
+	return criteria, nil
+}
@@ -2,14 +2,21 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/internal/middleware"
+	"go-fhir-demo/pkg/fhirmatch"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
 	"go-fhir-demo/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -21,17 +28,19 @@ import (
 
 type PatientHandlerTestSuite struct {
 	suite.Suite
-	mockCtrl    *gomock.Controller
-	mockService *mocks.MockPatientService
-	handler     PatientHandlerInterface
-	router      *gin.Engine
+	mockCtrl         *gomock.Controller
+	mockService      *mocks.MockPatientService
+	mockCompartments *mocks.MockCompartmentService
+	handler          PatientHandlerInterface
+	router           *gin.Engine
 }
 
 func (suite *PatientHandlerTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	suite.mockCtrl = gomock.NewController(suite.T())
 	suite.mockService = mocks.NewMockPatientService(suite.mockCtrl)
-	suite.handler = NewPatientHandler(suite.mockService)
+	suite.mockCompartments = mocks.NewMockCompartmentService(suite.mockCtrl)
+	suite.handler = NewPatientHandler(suite.mockService, suite.mockCompartments)
 	router := gin.New()
 	router.POST("/patients", suite.handler.CreatePatient)
 	router.GET("/patients/:id", suite.handler.GetPatient)
@@ -39,6 +48,15 @@ func (suite *PatientHandlerTestSuite) SetupTest() {
 	router.PUT("/patients/:id", suite.handler.UpdatePatient)
 	router.PATCH("/patients/:id", suite.handler.PatchPatient)
 	router.DELETE("/patients/:id", suite.handler.DeletePatient)
+	router.PUT("/patients", suite.handler.ConditionalUpdatePatient)
+	router.DELETE("/patients", suite.handler.ConditionalDeletePatient)
+	router.POST("/patients/$validate", suite.handler.ValidatePatient)
+	router.POST("/patients/$match", suite.handler.MatchPatient)
+	router.GET("/patients/_history", suite.handler.GetPatientsHistory)
+	router.GET("/patients/:id/_history", suite.handler.GetPatientHistory)
+	router.GET("/patients/:id/_history/:vid", suite.handler.GetPatientVersion)
+	router.POST("/patients/:id/$expunge", suite.handler.ExpungePatient)
+	router.POST("/admin/patients/:id/$undelete", suite.handler.UndeletePatient)
 	suite.router = router
 
 	// Globally mock ConvertToFHIR for any input
@@ -92,6 +110,182 @@ func (suite *PatientHandlerTestSuite) TestCreatePatient_Success() {
 	assert.Equal(suite.T(), "mocked", *resp.Id)
 }
 
+func (suite *PatientHandlerTestSuite) TestCreatePatient_ResponseHeaders() {
+	updatedAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	suite.mockService.EXPECT().
+		CreatePatient(gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 1, LogicalID: "abc", VersionID: 1, UpdatedAt: updatedAt}, nil)
+
+	req, _ := http.NewRequest("POST", "http://fhir.example.org/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), "http://fhir.example.org/patients/abc/_history/1", w.Header().Get("Location"))
+	assert.Equal(suite.T(), `W/"1"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), "Fri, 01 Mar 2024 10:30:00 GMT", w.Header().Get("Last-Modified"))
+	assert.Contains(suite.T(), w.Body.String(), "mocked")
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_PreferMinimal() {
+	suite.mockService.EXPECT().
+		CreatePatient(gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 1, LogicalID: "abc", VersionID: 1}, nil)
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "return=minimal")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Empty(suite.T(), w.Body.String())
+	assert.Contains(suite.T(), w.Header().Get("Location"), "/patients/abc/_history/1")
+	assert.Equal(suite.T(), `W/"1"`, w.Header().Get("ETag"))
+	assert.Empty(suite.T(), w.Header().Get("Last-Modified"))
+}
+
+func (suite *PatientHandlerTestSuite) TestUpdatePatient_PreferOperationOutcome() {
+	suite.mockService.EXPECT().
+		UpdatePatient(gomock.Any(), "abc", gomock.Any(), 0).
+		Return(&domain.Patient{ID: 1, LogicalID: "abc", VersionID: 3}, nil)
+
+	req, _ := http.NewRequest("PUT", "/patients/abc", bytes.NewBufferString(`{"resourceType":"Patient","id":"abc"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "handling=strict, return=OperationOutcome")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueSeverityInformation, resp.Issue[0].Severity)
+	assert.Equal(suite.T(), "Updated Patient/abc version 3", *resp.Issue[0].Diagnostics)
+	assert.Equal(suite.T(), `W/"3"`, w.Header().Get("ETag"))
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_IfNoneExistMatched() {
+	criteria := &fhirsearch.Query{
+		Params: []fhirsearch.Param{{Name: "identifier", Type: fhirsearch.TypeToken, Values: []string{"urn:mrn|42"}}},
+		Count:  10,
+	}
+	suite.mockService.EXPECT().
+		ConditionalCreatePatient(gomock.Any(), gomock.Any(), criteria).
+		Return(&domain.Patient{ID: 5, VersionID: 2}, false, nil)
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Exist", "Patient?identifier=urn:mrn|42")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `W/"2"`, w.Header().Get("ETag"))
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_IfNoneExistCreated() {
+	suite.mockService.EXPECT().
+		ConditionalCreatePatient(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 6, VersionID: 1}, true, nil)
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Exist", "identifier=urn:mrn|43")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_IfNoneExistMultipleMatches() {
+	suite.mockService.EXPECT().
+		ConditionalCreatePatient(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, false, domain.ErrMultipleMatches)
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Exist", "family=Doe")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_IfNoneExistWithoutCriteria() {
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Exist", "unknown=1")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestConditionalUpdatePatient_Created() {
+	suite.mockService.EXPECT().
+		ConditionalUpdatePatient(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 8, VersionID: 1}, true, nil)
+
+	req, _ := http.NewRequest("PUT", "/patients?identifier=urn:mrn|8", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestConditionalUpdatePatient_Updated() {
+	suite.mockService.EXPECT().
+		ConditionalUpdatePatient(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 8, VersionID: 3}, false, nil)
+
+	req, _ := http.NewRequest("PUT", "/patients?identifier=urn:mrn|8", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `W/"3"`, w.Header().Get("ETag"))
+}
+
+func (suite *PatientHandlerTestSuite) TestConditionalUpdatePatient_MissingCriteria() {
+	req, _ := http.NewRequest("PUT", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestConditionalDeletePatient_Success() {
+	suite.mockService.EXPECT().
+		ConditionalDeletePatient(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	req, _ := http.NewRequest("DELETE", "/patients?family=Doe", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestConditionalDeletePatient_MultipleMatches() {
+	suite.mockService.EXPECT().
+		ConditionalDeletePatient(gomock.Any(), gomock.Any()).
+		Return(domain.ErrMultipleMatches)
+
+	req, _ := http.NewRequest("DELETE", "/patients?family=Doe", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
 func (suite *PatientHandlerTestSuite) TestCreatePatient_BadRequest() {
 	req, _ := http.NewRequest("POST", "/patients", bytes.NewBuffer([]byte("invalid json")))
 	req.Header.Set("Content-Type", "application/json")
@@ -104,7 +298,7 @@ func (suite *PatientHandlerTestSuite) TestCreatePatient_Error() {
 	fhirPatient := &fhir.Patient{Id: utils.CreateStringPtr("123")}
 	suite.mockService.EXPECT().
 		CreatePatient(gomock.Any(), fhirPatient).
-		Return(&domain.Patient{}, errors.New("create error"))
+		Return(&domain.Patient{}, errors.New("pq: connection refused to db-internal:5432"))
 
 	body, _ := json.Marshal(fhirPatient)
 	req, _ := http.NewRequest("POST", "/patients", bytes.NewBuffer(body))
@@ -112,32 +306,178 @@ func (suite *PatientHandlerTestSuite) TestCreatePatient_Error() {
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	var resp fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueTypeException, resp.Issue[0].Code)
+	assert.Equal(suite.T(), "Internal server error", *resp.Issue[0].Diagnostics)
+	assert.NotContains(suite.T(), w.Body.String(), "db-internal")
 }
 
 func (suite *PatientHandlerTestSuite) TestGetPatient_Success() {
-	domainPatient := &domain.Patient{ID: 1}
+	domainPatient := &domain.Patient{ID: 1, LogicalID: "1", VersionID: 3}
 	suite.mockService.EXPECT().
-		GetPatient(gomock.Any(), uint(1)).
+		GetPatient(gomock.Any(), "1").
 		Return(domainPatient, nil)
 
 	req, _ := http.NewRequest("GET", "/patients/1", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `W/"3"`, w.Header().Get("ETag"))
+}
+
+// TestGetPatient_Pretty tests that _pretty=true indents the JSON and XML representations
+func (suite *PatientHandlerTestSuite) TestGetPatient_Pretty() {
+	suite.mockService.EXPECT().
+		GetPatient(gomock.Any(), "1").
+		Return(&domain.Patient{ID: 1, LogicalID: "1", VersionID: 3}, nil).
+		Times(2)
+	router := gin.New()
+	router.Use(middleware.FHIRFormat())
+	router.GET("/patients/:id", suite.handler.GetPatient)
+
+	req, _ := http.NewRequest("GET", "/patients/1?_pretty=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), middleware.FHIRJSONContentType, w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "{\n  \"id\": \"mocked\",\n  \"resourceType\": \"Patient\"\n}", w.Body.String())
+
+	req, _ = http.NewRequest("GET", "/patients/1?_pretty=true&_format=xml", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), middleware.FHIRXMLContentType, w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `W/"3"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), `<?xml version="1.0" encoding="UTF-8"?>
+<Patient xmlns="http://hl7.org/fhir">
+  <id value="mocked"/>
+</Patient>`, w.Body.String())
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatient_Projection() {
+	// The suite converts every patient to a bare one, so this test uses its own handler
+	service := mocks.NewMockPatientService(suite.mockCtrl)
+	handler := NewPatientHandler(service, suite.mockCompartments)
+	router := gin.New()
+	router.GET("/patients/:id", handler.GetPatient)
+	gender := fhir.AdministrativeGenderFemale
+	deceased := false
+	service.EXPECT().GetPatient(gomock.Any(), "1").Return(&domain.Patient{ID: 1, LogicalID: "1", VersionID: 2}, nil).AnyTimes()
+	service.EXPECT().ConvertToFHIR(gomock.Any(), gomock.Any()).Return(&fhir.Patient{
+		Id:                   utils.CreateStringPtr("1"),
+		Meta:                 &fhir.Meta{VersionId: utils.CreateStringPtr("2")},
+		Text:                 &fhir.Narrative{Status: fhir.NarrativeStatusGenerated, Div: "<div>Jane</div>"},
+		Name:                 []fhir.HumanName{{Family: utils.CreateStringPtr("Doe")}},
+		Gender:               &gender,
+		BirthDate:            utils.CreateStringPtr("1990-01-01"),
+		DeceasedBoolean:      &deceased,
+		MaritalStatus:        &fhir.CodeableConcept{Text: utils.CreateStringPtr("married")},
+		Contact:              []fhir.PatientContact{{Name: &fhir.HumanName{Family: utils.CreateStringPtr("Roe")}}},
+		MultipleBirthBoolean: &deceased,
+	}, nil).AnyTimes()
+
+	get := func(query string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/patients/1?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, query)
+		var resource map[string]interface{}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resource))
+		return resource
+	}
+	keys := func(resource map[string]interface{}) []string {
+		var names []string
+		for name := range resource {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	summary := get("_summary=true")
+	assert.ElementsMatch(suite.T(), []string{"resourceType", "id", "meta", "name", "gender", "birthDate", "deceasedBoolean"}, keys(summary))
+	assert.Equal(suite.T(), []interface{}{map[string]interface{}{"system": "http://terminology.hl7.org/CodeSystem/v3-ObservationValue", "code": "SUBSETTED"}},
+		summary["meta"].(map[string]interface{})["tag"])
+	assert.Equal(suite.T(), "2", summary["meta"].(map[string]interface{})["versionId"])
+
+	assert.ElementsMatch(suite.T(), []string{"resourceType", "id", "meta", "text"}, keys(get("_summary=text")))
+	assert.NotContains(suite.T(), keys(get("_summary=data")), "text")
+	assert.ElementsMatch(suite.T(), []string{"resourceType", "id", "meta", "name", "birthDate"}, keys(get("_elements=name,birthDate")))
+	full := get("_summary=false")
+	assert.Contains(suite.T(), keys(full), "contact")
+	assert.Nil(suite.T(), full["meta"].(map[string]interface{})["tag"])
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatient_InvalidProjection() {
+	for _, query := range []string{"_summary=brief", "_summary=count", "_summary=true&_elements=name"} {
+		req, _ := http.NewRequest("GET", "/patients/1?"+query, nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatient_LogicalID() {
+	logicalID := "b7e4c1d2-0a9f-4a44-9d8e-1f2a3b4c5d6e"
+	suite.mockService.EXPECT().
+		GetPatient(gomock.Any(), logicalID).
+		Return(&domain.Patient{ID: 12, LogicalID: logicalID, VersionID: 1}, nil)
+
+	req, _ := http.NewRequest("GET", "/patients/"+logicalID, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
 }
 
 func (suite *PatientHandlerTestSuite) TestGetPatient_NotFound() {
 	suite.mockService.EXPECT().
-		GetPatient(gomock.Any(), uint(2)).
-		Return(nil, errors.New("not found"))
+		GetPatient(gomock.Any(), "2").
+		Return(nil, domain.ErrNotFound)
 	req, _ := http.NewRequest("GET", "/patients/2", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueTypeNotFound, resp.Issue[0].Code)
+	assert.Equal(suite.T(), "resource not found", *resp.Issue[0].Diagnostics)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatient_Deleted() {
+	suite.mockService.EXPECT().
+		GetPatient(gomock.Any(), "2").
+		Return(nil, fmt.Errorf("%w: patient 2 has been deleted", domain.ErrGone))
+	req, _ := http.NewRequest("GET", "/patients/2", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.IssueTypeDeleted, resp.Issue[0].Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatient_DatabaseError() {
+	suite.mockService.EXPECT().
+		GetPatient(gomock.Any(), "2").
+		Return(nil, errors.New("connection refused"))
+	req, _ := http.NewRequest("GET", "/patients/2", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.IssueTypeException, resp.Issue[0].Code)
 }
 
 func (suite *PatientHandlerTestSuite) TestGetPatient_BadRequest() {
-	req, _ := http.NewRequest("GET", "/patients/abc", nil)
+	req, _ := http.NewRequest("GET", "/patients/not_an_id", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
@@ -147,19 +487,244 @@ func (suite *PatientHandlerTestSuite) TestGetPatients_Success() {
 	active := true
 
 	domainPatients := []*domain.Patient{
-		{ID: 1, Family: "Doe", Given: "John", Gender: "male", Active: &active},
-		{ID: 2, Family: "Smith", Given: "Jane", Gender: "female", Active: &active},
+		{ID: 1, LogicalID: "1", Family: "Doe", Given: "John", Gender: "male", Active: &active},
+		{ID: 2, LogicalID: "2", Family: "Smith", Given: "Jane", Gender: "female", Active: &active},
+	}
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), &fhirsearch.Query{Count: 10, Offset: 0}).
+		Return(&domain.PatientPage{Patients: domainPatients, Total: 2}, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients?limit=10&offset=0", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	err := json.Unmarshal(w.Body.Bytes(), &bundle)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), fhir.BundleTypeSearchset, bundle.Type)
+	assert.Equal(suite.T(), 2, *bundle.Total)
+	assert.Len(suite.T(), bundle.Entry, 2)
+	assert.Equal(suite.T(), "http://example.com/patients/1", *bundle.Entry[0].FullUrl)
+	assert.Equal(suite.T(), fhir.SearchEntryModeMatch, *bundle.Entry[0].Search.Mode)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_LimitCapped() {
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), &fhirsearch.Query{Count: fhirsearch.MaxCount, Offset: 0}).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{}, Total: 0}, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients?limit=1000000", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_SummaryCount() {
+	suite.mockService.EXPECT().
+		CountPatients(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+			suite.Require().Len(query.Params, 1)
+			assert.Equal(suite.T(), "gender", query.Params[0].Name)
+			return 42, nil
+		})
+
+	req, _ := http.NewRequest("GET", "/patients?gender=female&_summary=count", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), 42, *resp.Total)
+	assert.Empty(suite.T(), resp.Entry)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_Elements() {
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 1, LogicalID: "1"}}, Total: 1}, nil)
+
+	req, _ := http.NewRequest("GET", "/patients?_elements=name", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Entry, 1)
+	assert.JSONEq(suite.T(),
+		`{"resourceType":"Patient","id":"mocked","meta":{"tag":[{"system":"http://terminology.hl7.org/CodeSystem/v3-ObservationValue","code":"SUBSETTED"}]}}`,
+		string(resp.Entry[0].Resource))
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_Include() {
+	patients := []*domain.Patient{{ID: 1, LogicalID: "1"}}
+	suite.mockService.EXPECT().SearchPatients(gomock.Any(), gomock.Any()).Return(&domain.PatientPage{Patients: patients, Total: 1}, nil)
+	suite.mockCompartments.EXPECT().
+		PatientIncludes(gomock.Any(), patients, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, patients []*domain.Patient, query *fhirsearch.Query) ([]domain.CompartmentEntry, bool, error) {
+			assert.Equal(suite.T(), []fhirsearch.Include{{Source: "Patient", Param: "organization"}}, query.Include)
+			assert.Equal(suite.T(), []fhirsearch.Include{{Source: "Observation", Param: "subject"}}, query.RevInclude)
+			return []domain.CompartmentEntry{
+				{ResourceType: "Organization", LogicalID: "7", Resource: []byte(`{"resourceType":"Organization","id":"7"}`)},
+			}, true, nil
+		})
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients?_include=Patient:organization&_revinclude=Observation:subject", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &bundle))
+	assert.Equal(suite.T(), 1, *bundle.Total)
+	suite.Require().Len(bundle.Entry, 3)
+	assert.Equal(suite.T(), "http://example.com/organizations/7", *bundle.Entry[1].FullUrl)
+	assert.Equal(suite.T(), fhir.SearchEntryModeInclude, *bundle.Entry[1].Search.Mode)
+	assert.Nil(suite.T(), bundle.Entry[2].FullUrl)
+	assert.Equal(suite.T(), fhir.SearchEntryModeOutcome, *bundle.Entry[2].Search.Mode)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_UnsupportedInclude() {
+	suite.mockService.EXPECT().SearchPatients(gomock.Any(), gomock.Any()).Return(&domain.PatientPage{Patients: []*domain.Patient{}}, nil)
+	suite.mockCompartments.EXPECT().
+		PatientIncludes(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, false, fmt.Errorf("%w: _include Patient:link is not supported", domain.ErrValidation))
+
+	req, _ := http.NewRequest("GET", "/patients?_include=Patient:link", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/patients?_include=organization", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_PagingLinks() {
+	token := fhirsearch.EncodePageToken(2)
+	previous := &fhirsearch.Cursor{Values: []string{"3"}, Backward: true}
+	next := &fhirsearch.Cursor{Values: []string{"4"}}
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), &fhirsearch.Query{Count: 2, Offset: 2}).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 3}, {ID: 4}}, Total: 7, Previous: previous, Next: next}, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients?_count=2&_page_token="+token, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	err := json.Unmarshal(w.Body.Bytes(), &bundle)
+	assert.NoError(suite.T(), err)
+
+	links := map[string]string{}
+	for _, link := range bundle.Link {
+		links[link.Relation] = link.Url
+	}
+	assert.Equal(suite.T(), "http://example.com/patients?_count=2&_page_token="+token, links["self"])
+	assert.Equal(suite.T(), "http://example.com/patients?_count=2", links["first"])
+	assert.Equal(suite.T(), "http://example.com/patients?_count=2&_page_token="+fhirsearch.EncodeCursor(*previous), links["previous"])
+	assert.Equal(suite.T(), "http://example.com/patients?_count=2&_page_token="+fhirsearch.EncodeCursor(*next), links["next"])
+	assert.Equal(suite.T(), "http://example.com/patients?_count=2&_page_token="+fhirsearch.EncodeCursor(fhirsearch.Cursor{Backward: true}), links["last"])
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_SortAndCursor() {
+	cursor := fhirsearch.Cursor{Sort: "-birthdate,family", Values: []string{"1980-03-01T00:00:00Z", "Doe", "7"}}
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+			assert.Equal(suite.T(), []fhirsearch.SortKey{{Name: "birthdate", Descending: true}, {Name: "family"}}, query.Sort)
+			assert.Equal(suite.T(), &cursor, query.Cursor)
+			assert.Equal(suite.T(), fhirsearch.TotalNone, query.Total)
+			return &domain.PatientPage{Patients: []*domain.Patient{{ID: 8, LogicalID: "8"}}, Total: -1}, nil
+		})
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients?_sort=-birthdate,family&_total=none&_page_token="+fhirsearch.EncodeCursor(cursor), nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &bundle))
+	assert.Nil(suite.T(), bundle.Total)
+	assert.Len(suite.T(), bundle.Entry, 1)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_ContentScore() {
+	suite.mockService.EXPECT().
+		SearchPatients(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+			assert.Equal(suite.T(), []fhirsearch.Param{{Name: "_content", Type: fhirsearch.TypeString, Values: []string{"elm street"}}}, query.Params)
+			return &domain.PatientPage{Patients: []*domain.Patient{{ID: 1, LogicalID: "1", Score: 0.375}}, Total: 1}, nil
+		})
+
+	req, _ := http.NewRequest("GET", "/patients?_content=elm%20street", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &bundle))
+	suite.Require().Len(bundle.Entry, 1)
+	suite.Require().NotNil(bundle.Entry[0].Search.Score)
+	assert.Equal(suite.T(), "0.3750", bundle.Entry[0].Search.Score.String())
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_InvalidSort() {
+	for _, rawQuery := range []string{
+		"_sort=gender",
+		"_sort=family,-family",
+		"_total=sometimes",
+		"_sort=family&_page_token=" + fhirsearch.EncodeCursor(fhirsearch.Cursor{Sort: "given", Values: []string{"Ann", "1"}}),
+	} {
+		req, _ := http.NewRequest("GET", "/patients?"+rawQuery, nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, rawQuery)
+	}
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_InvalidPageToken() {
+	req, _ := http.NewRequest("GET", "/patients?_page_token=garbage", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatients_WithSearchParameters() {
+	expected := &fhirsearch.Query{
+		Params: []fhirsearch.Param{
+			{Name: "birthdate", Type: fhirsearch.TypeDate, Values: []string{"ge1980-01-01"}},
+			{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Doe", "Smith"}},
+			{Name: "gender", Type: fhirsearch.TypeToken, Values: []string{"male"}},
+		},
+		Count: 5,
 	}
 	suite.mockService.EXPECT().
-		GetPatients(gomock.Any(), 10, 0).
-		Return(domainPatients, int64(2), nil)
+		SearchPatients(gomock.Any(), expected).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 1}}, Total: 1}, nil)
 
-	req, _ := http.NewRequest("GET", "/patients?limit=10&offset=0", nil)
+	req, _ := http.NewRequest("GET", "/patients?family=Doe,Smith&gender=male&birthdate=ge1980-01-01&_count=5&unknown=x", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 }
 
+func (suite *PatientHandlerTestSuite) TestGetPatients_InvalidSearchParameter() {
+	req, _ := http.NewRequest("GET", "/patients?birthdate=notadate", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/patients?gender=robot", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
 func (suite *PatientHandlerTestSuite) TestUpdatePatient_Success() {
 	id := utils.CreateStringPtr("1")
 	active := true
@@ -179,7 +744,7 @@ func (suite *PatientHandlerTestSuite) TestUpdatePatient_Success() {
 	}
 	domainPatient := &domain.Patient{ID: 1}
 	suite.mockService.EXPECT().
-		UpdatePatient(gomock.Any(), uint(1), fhirPatient).
+		UpdatePatient(gomock.Any(), "1", fhirPatient, 0).
 		Return(domainPatient, nil)
 
 	body, _ := json.Marshal(fhirPatient)
@@ -194,7 +759,7 @@ func (suite *PatientHandlerTestSuite) TestPatchPatient_Success() {
 	patch := map[string]interface{}{"family": "Updated"}
 	domainPatient := &domain.Patient{ID: 1, Family: "Updated"}
 	suite.mockService.EXPECT().
-		PatchPatient(gomock.Any(), uint(1), patch).
+		PatchPatient(gomock.Any(), "1", patch, 0).
 		Return(domainPatient, nil)
 
 	body, _ := json.Marshal(patch)
@@ -207,7 +772,7 @@ func (suite *PatientHandlerTestSuite) TestPatchPatient_Success() {
 
 func (suite *PatientHandlerTestSuite) TestDeletePatient_Success() {
 	suite.mockService.EXPECT().
-		DeletePatient(gomock.Any(), uint(1)).
+		DeletePatient(gomock.Any(), "1", 0).
 		Return(nil)
 	req, _ := http.NewRequest("DELETE", "/patients/1", nil)
 	w := httptest.NewRecorder()
@@ -215,8 +780,180 @@ func (suite *PatientHandlerTestSuite) TestDeletePatient_Success() {
 	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
 }
 
+func (suite *PatientHandlerTestSuite) TestUpdatePatient_PreconditionFailed() {
+	fhirPatient := &fhir.Patient{Name: []fhir.HumanName{{Family: utils.CreateStringPtr("Gamma")}}}
+	suite.mockService.EXPECT().
+		UpdatePatient(gomock.Any(), "1", fhirPatient, 2).
+		Return(nil, domain.ErrVersionConflict)
+
+	body, _ := json.Marshal(fhirPatient)
+	req, _ := http.NewRequest("PUT", "/patients/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `W/"2"`)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
+// TestCreatePatient_DuplicateIdentifier tests that an identifier of a unique system that
+// belongs to another patient is a conflict outcome naming the owner
+func (suite *PatientHandlerTestSuite) TestCreatePatient_DuplicateIdentifier() {
+	fhirPatient := &fhir.Patient{Identifier: []fhir.Identifier{{System: utils.CreateStringPtr("urn:mrn"), Value: utils.CreateStringPtr("123")}}}
+	suite.mockService.EXPECT().
+		CreatePatient(gomock.Any(), fhirPatient).
+		Return(nil, fmt.Errorf("%w: identifier urn:mrn|123 is already assigned to Patient/abc", domain.ErrConflict))
+
+	body, _ := json.Marshal(fhirPatient)
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	var resp fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueSeverityError, resp.Issue[0].Severity)
+	assert.Equal(suite.T(), fhir.IssueTypeConflict, resp.Issue[0].Code)
+	assert.Equal(suite.T(), "resource conflict: identifier urn:mrn|123 is already assigned to Patient/abc", *resp.Issue[0].Diagnostics)
+}
+
+// TestUpdatePatient_DuplicateIdentifier tests that an update taking another patient's unique identifier is a conflict
+func (suite *PatientHandlerTestSuite) TestUpdatePatient_DuplicateIdentifier() {
+	fhirPatient := &fhir.Patient{Identifier: []fhir.Identifier{{System: utils.CreateStringPtr("urn:mrn"), Value: utils.CreateStringPtr("123")}}}
+	suite.mockService.EXPECT().
+		UpdatePatient(gomock.Any(), "1", fhirPatient, 0).
+		Return(nil, fmt.Errorf("%w: identifier urn:mrn|123 is already assigned to Patient/abc", domain.ErrConflict))
+
+	body, _ := json.Marshal(fhirPatient)
+	req, _ := http.NewRequest("PUT", "/patients/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"code":"conflict"`)
+	assert.Contains(suite.T(), w.Body.String(), "already assigned to Patient/abc")
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_WithIfMatch() {
+	patch := map[string]interface{}{"family": "Updated"}
+	suite.mockService.EXPECT().
+		PatchPatient(gomock.Any(), "1", patch, 3).
+		Return(&domain.Patient{ID: 1, VersionID: 4}, nil)
+
+	body, _ := json.Marshal(patch)
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `W/"3"`)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `W/"4"`, w.Header().Get("ETag"))
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_JSONPatch() {
+	patch := `[{"op":"replace","path":"/name/0/family","value":"Updated"}]`
+	suite.mockService.EXPECT().
+		JSONPatchPatient(gomock.Any(), "1", []byte(patch), 0).
+		Return(&domain.Patient{ID: 1, VersionID: 2}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_FHIRPathPatch() {
+	parameters := `{"resourceType":"Parameters","parameter":[]}`
+	suite.mockService.EXPECT().
+		FHIRPathPatchPatient(gomock.Any(), "1", []byte(parameters), 0).
+		Return(&domain.Patient{ID: 1, VersionID: 2}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(parameters))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_InvalidPath() {
+	suite.mockService.EXPECT().
+		JSONPatchPatient(gomock.Any(), "1", gomock.Any(), 0).
+		Return(nil, fmt.Errorf("operation 0: %w", fhirpatch.ErrInvalidPath))
+
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`[{"op":"remove","path":"/nothing"}]`))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_InvalidJSON() {
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`{"family":`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_MergePatch() {
+	patch := map[string]interface{}{"gender": "female"}
+	suite.mockService.EXPECT().
+		PatchPatient(gomock.Any(), "1", patch, 0).
+		Return(&domain.Patient{ID: 1, VersionID: 2}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`{"gender":"female"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_FHIRBodyMustBeParameters() {
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`{"gender":"female"}`))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Parameters")
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_UnsupportedContentType() {
+	for _, contentType := range []string{"", "text/plain"} {
+		req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`{"gender":"female"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code, contentType)
+	}
+}
+
+func (suite *PatientHandlerTestSuite) TestPatchPatient_InvalidIfMatch() {
+	req, _ := http.NewRequest("PATCH", "/patients/1", bytes.NewBufferString(`{"family":"Updated"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "not-an-etag")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestDeletePatient_PreconditionFailed() {
+	suite.mockService.EXPECT().
+		DeletePatient(gomock.Any(), "1", 7).
+		Return(domain.ErrVersionConflict)
+	req, _ := http.NewRequest("DELETE", "/patients/1", nil)
+	req.Header.Set("If-Match", `"7"`)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
 func (suite *PatientHandlerTestSuite) TestDeletePatient_BadRequest() {
-	req, _ := http.NewRequest("DELETE", "/patients/abc", nil)
+	req, _ := http.NewRequest("DELETE", "/patients/not_an_id", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
@@ -224,10 +961,309 @@ func (suite *PatientHandlerTestSuite) TestDeletePatient_BadRequest() {
 
 func (suite *PatientHandlerTestSuite) TestDeletePatient_Error() {
 	suite.mockService.EXPECT().
-		DeletePatient(gomock.Any(), uint(2)).
+		DeletePatient(gomock.Any(), "2", 0).
 		Return(errors.New("delete error"))
 	req, _ := http.NewRequest("DELETE", "/patients/2", nil)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
 }
+
+func (suite *PatientHandlerTestSuite) TestGetPatientHistory_Success() {
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	entries := []*domain.PatientHistory{
+		{PatientID: 1, LogicalID: "1", VersionID: 3, Method: "DELETE", CreatedAt: modified},
+		{PatientID: 1, LogicalID: "1", VersionID: 2, Method: "PUT", CreatedAt: modified},
+		{PatientID: 1, LogicalID: "1", VersionID: 1, Method: "POST", CreatedAt: modified},
+	}
+	suite.mockService.EXPECT().
+		GetPatientHistory(gomock.Any(), domain.HistoryQuery{PatientID: "1", Count: 10}).
+		Return(entries, int64(3), nil)
+	suite.mockService.EXPECT().
+		ConvertHistoryToFHIR(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(&fhir.Patient{Id: utils.CreateStringPtr("1")}, nil)
+
+	req, _ := http.NewRequest("GET", "http://example.com/patients/1/_history", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var bundle fhir.Bundle
+	err := json.Unmarshal(w.Body.Bytes(), &bundle)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), fhir.BundleTypeHistory, bundle.Type)
+	assert.Equal(suite.T(), 3, *bundle.Total)
+	assert.Len(suite.T(), bundle.Entry, 3)
+	assert.Equal(suite.T(), fhir.HTTPVerbDELETE, bundle.Entry[0].Request.Method)
+	assert.Nil(suite.T(), bundle.Entry[0].Resource)
+	assert.Equal(suite.T(), `W/"3"`, *bundle.Entry[0].Response.Etag)
+	assert.Equal(suite.T(), "http://example.com/patients/1", *bundle.Entry[1].FullUrl)
+	assert.Equal(suite.T(), "201 Created", bundle.Entry[2].Response.Status)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatientHistory_NotFound() {
+	suite.mockService.EXPECT().
+		GetPatientHistory(gomock.Any(), gomock.Any()).
+		Return(nil, int64(0), domain.ErrNotFound)
+
+	req, _ := http.NewRequest("GET", "/patients/9/_history", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatientsHistory_Since() {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockService.EXPECT().
+		GetPatientHistory(gomock.Any(), domain.HistoryQuery{Since: &since, Count: 5}).
+		Return([]*domain.PatientHistory{}, int64(0), nil)
+
+	req, _ := http.NewRequest("GET", "/patients/_history?_since=2024-01-01&_count=5", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatientVersion_Success() {
+	entry := &domain.PatientHistory{PatientID: 1, LogicalID: "1", VersionID: 2, Method: "PUT"}
+	suite.mockService.EXPECT().
+		GetPatientVersion(gomock.Any(), "1", 2).
+		Return(entry, nil)
+	suite.mockService.EXPECT().
+		ConvertHistoryToFHIR(gomock.Any(), entry).
+		Return(&fhir.Patient{Id: utils.CreateStringPtr("1")}, nil)
+
+	req, _ := http.NewRequest("GET", "/patients/1/_history/2", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatientVersion_Deleted() {
+	entry := &domain.PatientHistory{PatientID: 1, LogicalID: "1", VersionID: 3, Method: "DELETE"}
+	suite.mockService.EXPECT().
+		GetPatientVersion(gomock.Any(), "1", 3).
+		Return(entry, nil)
+	suite.mockService.EXPECT().
+		ConvertHistoryToFHIR(gomock.Any(), entry).
+		Return(nil, domain.ErrGone)
+
+	req, _ := http.NewRequest("GET", "/patients/1/_history/3", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestGetPatientVersion_NotFound() {
+	suite.mockService.EXPECT().
+		GetPatientVersion(gomock.Any(), "1", 7).
+		Return(nil, domain.ErrNotFound)
+
+	req, _ := http.NewRequest("GET", "/patients/1/_history/7", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_ValidationError() {
+	diagnostics := "Patient.birthDate: value 1990-13-45 must be a valid date"
+	suite.mockService.EXPECT().
+		CreatePatient(gomock.Any(), gomock.Any()).
+		Return(nil, &domain.ValidationError{Issues: []fhir.OperationOutcomeIssue{{
+			Severity:    fhir.IssueSeverityError,
+			Code:        fhir.IssueTypeValue,
+			Diagnostics: &diagnostics,
+			Expression:  []string{"Patient.birthDate"},
+		}}})
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient","birthDate":"1990-13-45"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueTypeValue, resp.Issue[0].Code)
+	assert.Equal(suite.T(), []string{"Patient.birthDate"}, resp.Issue[0].Expression)
+}
+
+func (suite *PatientHandlerTestSuite) TestValidatePatient_Valid() {
+	body := `{"resourceType":"Patient","gender":"male"}`
+	suite.mockService.EXPECT().
+		ValidatePatient(gomock.Any(), []byte(body), []string{"http://example.org/profile"}).
+		Return(nil, nil)
+
+	req, _ := http.NewRequest("POST", "/patients/$validate?profile=http://example.org/profile", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueSeverityInformation, resp.Issue[0].Severity)
+}
+
+func (suite *PatientHandlerTestSuite) TestValidatePatient_Parameters() {
+	diagnostics := "Patient.identifier: minimum required = 1, but only found 0"
+	suite.mockService.EXPECT().
+		ValidatePatient(gomock.Any(), []byte(`{"resourceType":"Patient"}`), []string{"http://example.org/profile"}).
+		Return([]fhir.OperationOutcomeIssue{{
+			Severity:    fhir.IssueSeverityError,
+			Code:        fhir.IssueTypeRequired,
+			Diagnostics: &diagnostics,
+		}}, nil)
+
+	body := `{"resourceType":"Parameters","parameter":[
+		{"name":"resource","resource":{"resourceType":"Patient"}},
+		{"name":"profile","valueUri":"http://example.org/profile"}]}`
+	req, _ := http.NewRequest("POST", "/patients/$validate", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.OperationOutcome
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Issue, 1)
+	assert.Equal(suite.T(), fhir.IssueTypeRequired, resp.Issue[0].Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestCreatePatient_WarnsOfDuplicates() {
+	suite.mockService.EXPECT().
+		CreatePatient(gomock.Any(), gomock.Any()).
+		Return(&domain.Patient{ID: 4, LogicalID: "4", VersionID: 1, Duplicates: []domain.PatientMatch{
+			{Patient: &domain.Patient{LogicalID: "1"}, Score: 1, Grade: fhirmatch.GradeCertain},
+			{Patient: &domain.Patient{LogicalID: "2"}, Score: 0.875, Grade: fhirmatch.GradeProbable},
+		}}, nil)
+
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(`{"resourceType":"Patient"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), []string{
+		`199 - "Possible duplicate of Patient/1 (certain match, score 1.00)"`,
+		`199 - "Possible duplicate of Patient/2 (probable match, score 0.88)"`,
+	}, w.Header().Values("Warning"))
+}
+
+func (suite *PatientHandlerTestSuite) TestMatchPatient_Parameters() {
+	family := "Doe"
+	suite.mockService.EXPECT().
+		MatchPatient(gomock.Any(), &fhir.Patient{Name: []fhir.HumanName{{Family: &family}}}, true, 5).
+		Return([]domain.PatientMatch{{Patient: &domain.Patient{LogicalID: "1"}, Score: 0.968, Grade: fhirmatch.GradeProbable}}, nil)
+
+	body := `{"resourceType":"Parameters","parameter":[
+		{"name":"resource","resource":{"resourceType":"Patient","name":[{"family":"Doe"}]}},
+		{"name":"onlyCertainMatches","valueBoolean":true},
+		{"name":"count","valueInteger":5}]}`
+	req, _ := http.NewRequest("POST", "/patients/$match", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.BundleTypeSearchset, resp.Type)
+	assert.Equal(suite.T(), 1, *resp.Total)
+	suite.Require().Len(resp.Entry, 1)
+	assert.Equal(suite.T(), "0.968", resp.Entry[0].Search.Score.String())
+	suite.Require().Len(resp.Entry[0].Search.Extension, 1)
+	assert.Equal(suite.T(), "http://hl7.org/fhir/StructureDefinition/match-grade", resp.Entry[0].Search.Extension[0].Url)
+	assert.Equal(suite.T(), "probable", *resp.Entry[0].Search.Extension[0].ValueCode)
+}
+
+func (suite *PatientHandlerTestSuite) TestMatchPatient_InvalidInput() {
+	for _, body := range []string{
+		`{"resourceType":"Observation"}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"count","valueInteger":5}]}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"resource","resource":{"resourceType":"Patient"}},{"name":"count","valueInteger":0}]}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"resource","resource":{"resourceType":"Patient"}},{"name":"onlyCertainMatches","valueString":"yes"}]}`,
+	} {
+		req, _ := http.NewRequest("POST", "/patients/$match", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *PatientHandlerTestSuite) TestValidatePatient_ParametersWithoutResource() {
+	body := `{"resourceType":"Parameters","parameter":[{"name":"profile","valueUri":"http://example.org/profile"}]}`
+	req, _ := http.NewRequest("POST", "/patients/$validate", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestUndeletePatient_Success() {
+	suite.mockService.EXPECT().
+		UndeletePatient(gomock.Any(), "1").
+		Return(&domain.Patient{ID: 1, LogicalID: "1", VersionID: 4}, nil)
+
+	req, _ := http.NewRequest("POST", "/admin/patients/1/$undelete", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `W/"4"`, w.Header().Get("ETag"))
+}
+
+func (suite *PatientHandlerTestSuite) TestUndeletePatient_NotDeleted() {
+	suite.mockService.EXPECT().
+		UndeletePatient(gomock.Any(), "1").
+		Return(nil, domain.ErrConflict)
+
+	req, _ := http.NewRequest("POST", "/admin/patients/1/$undelete", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestExpungePatient_WithHistory() {
+	suite.mockService.EXPECT().
+		ExpungePatient(gomock.Any(), "1", true).
+		Return(int64(4), nil)
+
+	body := `{"resourceType":"Parameters","parameter":[{"name":"expungePreviousVersions","valueBoolean":true}]}`
+	req, _ := http.NewRequest("POST", "/patients/1/$expunge", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp fhir.Parameters
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Parameter, 1)
+	assert.Equal(suite.T(), "count", resp.Parameter[0].Name)
+	assert.Equal(suite.T(), 4, *resp.Parameter[0].ValueInteger)
+}
+
+func (suite *PatientHandlerTestSuite) TestExpungePatient_WithoutBody() {
+	suite.mockService.EXPECT().
+		ExpungePatient(gomock.Any(), "1", false).
+		Return(int64(1), nil)
+
+	req, _ := http.NewRequest("POST", "/patients/1/$expunge", bytes.NewBufferString(""))
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PatientHandlerTestSuite) TestExpungePatient_InvalidParameters() {
+	for _, body := range []string{
+		`{"resourceType":"Patient"}`,
+		`{"resourceType":"Parameters","parameter":[{"name":"expungePreviousVersions","valueString":"yes"}]}`,
+	} {
+		req, _ := http.NewRequest("POST", "/patients/1/$expunge", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
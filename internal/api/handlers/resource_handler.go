@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirbundle"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// ResourceHandlerInterface defines the contract for the handlers of a generic resource type
+type ResourceHandlerInterface interface {
+	ResourceType() string
+	Collection() string
+	CreateResource(c *gin.Context)
+	GetResource(c *gin.Context)
+	SearchResources(c *gin.Context)
+	UpdateResource(c *gin.Context)
+	DeleteResource(c *gin.Context)
+}
+
+// ResourceHandler serves one resource type of the generic resource store
+type ResourceHandler struct {
+	definition domain.ResourceDefinition
+	service    domain.ResourceService
+}
+
+// NewResourceHandler creates a handler for a resource type listed in domain.ResourceDefinitions
+func NewResourceHandler(resourceType string, service domain.ResourceService) ResourceHandlerInterface {
+	return &ResourceHandler{
+		definition: domain.ResourceDefinitions[resourceType],
+		service:    service,
+	}
+}
+
+// ResourceType returns the FHIR type the handler serves
+func (h *ResourceHandler) ResourceType() string {
+	return h.definition.Type
+}
+
+// Collection returns the path segment of the type's endpoints
+func (h *ResourceHandler) Collection() string {
+	return h.definition.Collection
+}
+
+// CreateResource handles POST /{collection}
+// @Summary Create a resource
+// @Description Create an Observation, Encounter, Practitioner or Organization. Any id in the resource is replaced by a server-assigned logical id.
+// @Tags Resource
+// @Accept json,xml
+// @Produce json,xml
+// @Param collection path string true "Resource collection" Enums(observations, encounters, practitioners, organizations)
+// @Param resource body object true "FHIR resource of the collection's type"
+// @Success 201 {object} object
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 422 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /{collection} [post]
+func (h *ResourceHandler) CreateResource(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Create"+h.definition.Type)
+	defer span.End()
+
+	body, ok := h.readBody(c)
+	if !ok {
+		return
+	}
+
+	resource, err := h.service.CreateResource(ctx, h.definition.Type, body)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to create %s: %v", h.definition.Type, err)
+		outcome.Error(c, err)
+		return
+	}
+	h.writeResource(c, http.StatusCreated, resource)
+}
+
+// GetResource handles GET /{collection}/:id
+// @Summary Get a resource by ID
+// @Description Get an Observation, Encounter, Practitioner or Organization by its logical id
+// @Tags Resource
+// @Produce json,xml
+// @Param collection path string true "Resource collection" Enums(observations, encounters, practitioners, organizations)
+// @Param id path string true "Logical ID"
+// @Success 200 {object} object
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /{collection}/{id} [get]
+func (h *ResourceHandler) GetResource(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Get"+h.definition.Type)
+	defer span.End()
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	logger.WithContext(ctx).Infof("Fetching %s with ID: %s", h.definition.Type, id)
+
+	resource, err := h.service.GetResource(ctx, h.definition.Type, id)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get %s: %v", h.definition.Type, err)
+		outcome.Error(c, err)
+		return
+	}
+	h.writeResource(c, http.StatusOK, resource)
+}
+
+// SearchResources handles GET /{collection}
+// @Summary Search resources
+// @Description Search Observation, Encounter, Practitioner or Organization resources with the search parameters listed for the type in the CapabilityStatement, returning a searchset Bundle
+// @Tags Resource
+// @Produce json,xml
+// @Param collection path string true "Resource collection" Enums(observations, encounters, practitioners, organizations)
+// @Param _id query string false "Logical id of the resource"
+// @Param _count query int false "Number of results per page" default(10)
+// @Param _page_token query string false "Opaque page token taken from a Bundle paging link"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /{collection} [get]
+func (h *ResourceHandler) SearchResources(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Search"+h.definition.Type)
+	defer span.End()
+
+	values := c.Request.URL.Query()
+	query, err := fhirsearch.Parse(values, h.definition.SearchParameters)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid search parameters: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid search parameters: "+err.Error())
+		return
+	}
+
+	resources, total, err := h.service.SearchResources(ctx, h.definition.Type, query)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to search %s: %v", h.definition.Type, err)
+		outcome.Error(c, err)
+		return
+	}
+
+	pageURL := h.collectionURL(c)
+	entries := make([]fhir.BundleEntry, 0, len(resources))
+	for _, resource := range resources {
+		data, err := h.service.ConvertToFHIR(ctx, resource)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to convert %s %s to FHIR: %v", h.definition.Type, resource.LogicalID, err)
+			continue
+		}
+		entry, err := fhirbundle.NewEntry(pageURL+"/"+resource.LogicalID, json.RawMessage(data), fhir.SearchEntryModeMatch)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to build bundle entry for %s %s: %v", h.definition.Type, resource.LogicalID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	links := fhirbundle.PageLinks(pageURL, values, total, query.Count, query.Offset)
+	c.JSON(http.StatusOK, fhirbundle.NewSearchSet(total, entries, links))
+}
+
+// UpdateResource handles PUT /{collection}/:id
+// @Summary Update a resource
+// @Description Update an existing Observation, Encounter, Practitioner or Organization
+// @Tags Resource
+// @Accept json,xml
+// @Produce json,xml
+// @Param collection path string true "Resource collection" Enums(observations, encounters, practitioners, organizations)
+// @Param id path string true "Logical ID"
+// @Param resource body object true "FHIR resource of the collection's type"
+// @Param If-Match header string false "Weak ETag of the version being updated, e.g. W/\"3\""
+// @Success 200 {object} object
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 422 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /{collection}/{id} [put]
+func (h *ResourceHandler) UpdateResource(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Update"+h.definition.Type)
+	defer span.End()
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-Match header: "+err.Error())
+		return
+	}
+	body, ok := h.readBody(c)
+	if !ok {
+		return
+	}
+
+	logger.WithContext(ctx).Infof("Updating %s with ID: %s", h.definition.Type, id)
+	resource, err := h.service.UpdateResource(ctx, h.definition.Type, id, body, expectedVersion)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to update %s %s: %v", h.definition.Type, id, err)
+		outcome.Error(c, err)
+		return
+	}
+	h.writeResource(c, http.StatusOK, resource)
+}
+
+// DeleteResource handles DELETE /{collection}/:id
+// @Summary Delete a resource
+// @Description Delete an existing Observation, Encounter, Practitioner or Organization
+// @Tags Resource
+// @Produce json,xml
+// @Param collection path string true "Resource collection" Enums(observations, encounters, practitioners, organizations)
+// @Param id path string true "Logical ID"
+// @Param If-Match header string false "Weak ETag of the version being deleted, e.g. W/\"3\""
+// @Success 204 "No Content"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 500 {object} fhir.OperationOutcome
+// @Router /{collection}/{id} [delete]
+func (h *ResourceHandler) DeleteResource(c *gin.Context) {
+	ctx, span := tracer.StartSpan(c.Request.Context(), "Delete"+h.definition.Type)
+	defer span.End()
+
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Invalid If-Match header: "+err.Error())
+		return
+	}
+
+	logger.WithContext(ctx).Infof("Deleting %s with ID: %s", h.definition.Type, id)
+	if err := h.service.DeleteResource(ctx, h.definition.Type, id, expectedVersion); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete %s %s: %v", h.definition.Type, id, err)
+		outcome.Error(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writeResource writes the FHIR representation of a resource with its ETag
+func (h *ResourceHandler) writeResource(c *gin.Context, status int, resource *domain.Resource) {
+	data, err := h.service.ConvertToFHIR(c.Request.Context(), resource)
+	if err != nil {
+		logger.WithContext(c.Request.Context()).Errorf("Failed to convert to FHIR: %v", err)
+		outcome.Error(c, err)
+		return
+	}
+	c.Header("ETag", etag(resource.VersionID))
+	c.Data(status, "application/fhir+json; charset=utf-8", data)
+}
+
+// readBody reads a JSON request body, writing a 400 response when it is not valid JSON
+func (h *ResourceHandler) readBody(c *gin.Context) ([]byte, bool) {
+	body, err := c.GetRawData()
+	if err != nil || !json.Valid(body) {
+		logger.WithContext(c.Request.Context()).Errorf("Failed to read %s body: %v", h.definition.Type, err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid JSON: request body must be a FHIR "+h.definition.Type)
+		return nil, false
+	}
+	return body, true
+}
+
+// parseID reads the logical id from the :id path parameter, writing a 400 response
+// when it is not a valid FHIR id
+func (h *ResourceHandler) parseID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if !logicalIDPattern.MatchString(id) {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid,
+			h.definition.Type+" ID must be 1 to 64 letters, digits, '-' or '.'")
+		return "", false
+	}
+	return id, true
+}
+
+// collectionURL returns the absolute URL of the collection the current route belongs to
+func (h *ResourceHandler) collectionURL(c *gin.Context) string {
+	path := c.FullPath()
+	segment := "/" + h.definition.Collection
+	if idx := strings.Index(path, segment); idx >= 0 {
+		path = path[:idx+len(segment)]
+	}
+	return requestBaseURL(c) + path
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type ResourceHandlerTestSuite struct {
+	suite.Suite
+	mockCtrl    *gomock.Controller
+	mockService *mocks.MockResourceService
+	router      *gin.Engine
+}
+
+func (suite *ResourceHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.mockCtrl = gomock.NewController(suite.T())
+	suite.mockService = mocks.NewMockResourceService(suite.mockCtrl)
+	handler := NewResourceHandler("Observation", suite.mockService)
+	router := gin.New()
+	observations := router.Group("/api/v1/" + handler.Collection())
+	observations.GET("", handler.SearchResources)
+	observations.POST("", handler.CreateResource)
+	observations.GET("/:id", handler.GetResource)
+	observations.PUT("/:id", handler.UpdateResource)
+	observations.DELETE("/:id", handler.DeleteResource)
+	suite.router = router
+
+	suite.mockService.EXPECT().
+		ConvertToFHIR(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, resource *domain.Resource) ([]byte, error) {
+			return resource.FHIRData, nil
+		})
+}
+
+func (suite *ResourceHandlerTestSuite) TearDownTest() {
+	suite.mockCtrl.Finish()
+}
+
+func TestResourceHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ResourceHandlerTestSuite))
+}
+
+func (suite *ResourceHandlerTestSuite) request(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/fhir+json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *ResourceHandlerTestSuite) TestCreateResource_Success() {
+	body := `{"resourceType":"Observation","status":"final","code":{"text":"Heart rate"}}`
+	suite.mockService.EXPECT().
+		CreateResource(gomock.Any(), "Observation", []byte(body)).
+		Return(&domain.Resource{LogicalID: "5", VersionID: 1, FHIRData: []byte(`{"resourceType":"Observation","id":"5"}`)}, nil)
+
+	w := suite.request("POST", "/api/v1/observations", body, nil)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), `W/"1"`, w.Header().Get("ETag"))
+	assert.JSONEq(suite.T(), `{"resourceType":"Observation","id":"5"}`, w.Body.String())
+}
+
+func (suite *ResourceHandlerTestSuite) TestCreateResource_InvalidJSON() {
+	w := suite.request("POST", "/api/v1/observations", `{"resourceType":`, nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	var resp fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.IssueTypeStructure, resp.Issue[0].Code)
+}
+
+func (suite *ResourceHandlerTestSuite) TestGetResource() {
+	tests := []struct {
+		name           string
+		id             string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "found",
+			id:   "5",
+			setupMock: func() {
+				suite.mockService.EXPECT().
+					GetResource(gomock.Any(), "Observation", "5").
+					Return(&domain.Resource{LogicalID: "5", VersionID: 3, FHIRData: []byte(`{"resourceType":"Observation","id":"5"}`)}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "not found",
+			id:   "6",
+			setupMock: func() {
+				suite.mockService.EXPECT().GetResource(gomock.Any(), "Observation", "6").Return(nil, domain.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "not_valid",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			tt.setupMock()
+			w := suite.request("GET", "/api/v1/observations/"+tt.id, "", nil)
+			assert.Equal(suite.T(), tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func (suite *ResourceHandlerTestSuite) TestSearchResources() {
+	suite.mockService.EXPECT().
+		SearchResources(gomock.Any(), "Observation", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+			suite.Require().Len(query.Params, 1)
+			assert.Equal(suite.T(), "patient", query.Params[0].Name)
+			return []*domain.Resource{{LogicalID: "5", FHIRData: []byte(`{"resourceType":"Observation","id":"5"}`)}}, 1, nil
+		})
+
+	w := suite.request("GET", "/api/v1/observations?patient=1", "", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var resp fhir.Bundle
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), fhir.BundleTypeSearchset, resp.Type)
+	assert.Equal(suite.T(), 1, *resp.Total)
+	suite.Require().Len(resp.Entry, 1)
+	assert.Contains(suite.T(), *resp.Entry[0].FullUrl, "/api/v1/observations/5")
+}
+
+func (suite *ResourceHandlerTestSuite) TestSearchResources_InvalidCode() {
+	w := suite.request("GET", "/api/v1/observations?status=done", "", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ResourceHandlerTestSuite) TestUpdateResource_VersionConflict() {
+	body := `{"resourceType":"Observation","id":"5","status":"final","code":{"text":"Heart rate"}}`
+	suite.mockService.EXPECT().
+		UpdateResource(gomock.Any(), "Observation", "5", []byte(body), 2).
+		Return(nil, domain.ErrVersionConflict)
+
+	w := suite.request("PUT", "/api/v1/observations/5", body, map[string]string{"If-Match": `W/"2"`})
+
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
+func (suite *ResourceHandlerTestSuite) TestDeleteResource() {
+	suite.mockService.EXPECT().DeleteResource(gomock.Any(), "Observation", "5", 0).Return(nil)
+
+	w := suite.request("DELETE", "/api/v1/observations/5", "", nil)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"go-fhir-demo/config"
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -28,15 +31,12 @@ func NewVaultHandler(cfg *config.VaultConfig) VaultHandlerInterface {
 // @Tags Vault
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 502 {object} fhir.OperationOutcome
 // @Router /api/v1/vault/secret [get]
 func (h *VaultHandler) GetVaultSecret(c *gin.Context) {
 	data, err := utils.GetVaultKV(h.cfg.Address, h.cfg.Token, h.cfg.SecretPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch from Vault",
-			"message": err.Error(),
-		})
+		outcome.Error(c, fmt.Errorf("%w: failed to fetch from Vault: %v", domain.ErrUpstream, err))
 		return
 	}
 	c.JSON(http.StatusOK, data)
@@ -0,0 +1,88 @@
+// Package outcome is the shared error model of the API. Errors are returned to
+// clients as FHIR OperationOutcome resources with an HTTP status chosen from the
+// kind of error.
+package outcome
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// New builds an OperationOutcome with a single issue
+func New(severity fhir.IssueSeverity, code fhir.IssueType, diagnostics string) fhir.OperationOutcome {
+	return fhir.OperationOutcome{
+		Issue: []fhir.OperationOutcomeIssue{
+			{
+				Severity:    severity,
+				Code:        code,
+				Diagnostics: &diagnostics,
+			},
+		},
+	}
+}
+
+// Classify maps an error to the HTTP status and issue type it is reported with.
+// Errors that are not recognised are reported as 500 exceptions.
+func Classify(err error) (int, fhir.IssueType) {
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity, fhir.IssueTypeInvalid
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, fhir.IssueTypeNotFound
+	case errors.Is(err, domain.ErrGone):
+		return http.StatusGone, fhir.IssueTypeDeleted
+	case errors.Is(err, domain.ErrValidation), errors.Is(err, fhirpatch.ErrInvalidPatch):
+		return http.StatusBadRequest, fhir.IssueTypeInvalid
+	case errors.Is(err, fhirpatch.ErrInvalidPath), errors.Is(err, fhirpatch.ErrTestFailed):
+		return http.StatusUnprocessableEntity, fhir.IssueTypeProcessing
+	case errors.Is(err, domain.ErrMultipleMatches):
+		return http.StatusPreconditionFailed, fhir.IssueTypeMultipleMatches
+	case errors.Is(err, domain.ErrVersionConflict):
+		return http.StatusPreconditionFailed, fhir.IssueTypeConflict
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, fhir.IssueTypeConflict
+	case errors.Is(err, domain.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, fhir.IssueTypeTimeout
+	case errors.Is(err, domain.ErrUpstream):
+		return http.StatusBadGateway, fhir.IssueTypeTransient
+	default:
+		return http.StatusInternalServerError, fhir.IssueTypeException
+	}
+}
+
+// Error writes err as an OperationOutcome with the status it is classified as.
+// Validation errors are written with all of their issues.
+func Error(c *gin.Context, err error) {
+	status, result := FromError(c.Request.Context(), err)
+	c.JSON(status, result)
+}
+
+// FromError builds the OperationOutcome err is reported with, along with its status.
+// Only classified errors carry their message; the detail of any other error is logged
+// and the client is told no more than that the server failed.
+func FromError(ctx context.Context, err error) (int, fhir.OperationOutcome) {
+	status, code := Classify(err)
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		return status, fhir.OperationOutcome{Issue: validationErr.Issues}
+	}
+	if status == http.StatusInternalServerError {
+		logger.WithContext(ctx).Errorf("Internal server error: %v", err)
+		return status, New(fhir.IssueSeverityError, code, "Internal server error")
+	}
+	return status, New(fhir.IssueSeverityError, code, err.Error())
+}
+
+// Write writes an OperationOutcome with a single error issue and the given status
+func Write(c *gin.Context, status int, code fhir.IssueType, diagnostics string) {
+	c.JSON(status, New(fhir.IssueSeverityError, code, diagnostics))
+}
@@ -43,9 +43,9 @@ func (m *MockRouteSetupInterface) EXPECT() *MockRouteSetupInterfaceMockRecorder
 }
 
 // SetupRoutes mocks base method.
-func (m *MockRouteSetupInterface) SetupRoutes(patientHandler handlers.PatientHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine {
+func (m *MockRouteSetupInterface) SetupRoutes(patientHandler handlers.PatientHandlerInterface, bundleHandler handlers.BundleHandlerInterface, resourceHandlers []handlers.ResourceHandlerInterface, compartmentHandler handlers.CompartmentHandlerInterface, mergeHandler handlers.MergeHandlerInterface, bulkHandler handlers.BulkHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine {
 	m.ctrl.T.Helper()
-	varargs := []any{patientHandler, externalPatientHandler, cronJobHandler}
+	varargs := []any{patientHandler, bundleHandler, resourceHandlers, compartmentHandler, mergeHandler, bulkHandler, externalPatientHandler, cronJobHandler}
 	for _, a := range consulHandler {
 		varargs = append(varargs, a)
 	}
@@ -55,8 +55,8 @@ func (m *MockRouteSetupInterface) SetupRoutes(patientHandler handlers.PatientHan
 }
 
 // SetupRoutes indicates an expected call of SetupRoutes.
-func (mr *MockRouteSetupInterfaceMockRecorder) SetupRoutes(patientHandler, externalPatientHandler, cronJobHandler any, consulHandler ...any) *gomock.Call {
+func (mr *MockRouteSetupInterfaceMockRecorder) SetupRoutes(patientHandler, bundleHandler, resourceHandlers, compartmentHandler, mergeHandler, bulkHandler, externalPatientHandler, cronJobHandler any, consulHandler ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{patientHandler, externalPatientHandler, cronJobHandler}, consulHandler...)
+	varargs := append([]any{patientHandler, bundleHandler, resourceHandlers, compartmentHandler, mergeHandler, bulkHandler, externalPatientHandler, cronJobHandler}, consulHandler...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupRoutes", reflect.TypeOf((*MockRouteSetupInterface)(nil).SetupRoutes), varargs...)
 }
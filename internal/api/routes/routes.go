@@ -3,6 +3,7 @@ package routes
 import (
 	"go-fhir-demo/internal/api/handlers"
 	"go-fhir-demo/internal/api/handlers/cron"
+	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/internal/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -10,7 +11,7 @@ import (
 
 // RouteSetupInterface defines the contract for route setup
 type RouteSetupInterface interface {
-	SetupRoutes(patientHandler handlers.PatientHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine
+	SetupRoutes(patientHandler handlers.PatientHandlerInterface, bundleHandler handlers.BundleHandlerInterface, resourceHandlers []handlers.ResourceHandlerInterface, compartmentHandler handlers.CompartmentHandlerInterface, mergeHandler handlers.MergeHandlerInterface, bulkHandler handlers.BulkHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine
 }
 
 // RouteSetup implements RouteSetupInterface
@@ -22,14 +23,19 @@ func NewRouteSetup() RouteSetupInterface {
 }
 
 // Legacy function for backward compatibility
-func SetupRoutes(patientHandler handlers.PatientHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine {
+func SetupRoutes(patientHandler handlers.PatientHandlerInterface, bundleHandler handlers.BundleHandlerInterface, resourceHandlers []handlers.ResourceHandlerInterface, compartmentHandler handlers.CompartmentHandlerInterface, mergeHandler handlers.MergeHandlerInterface, bulkHandler handlers.BulkHandlerInterface, externalPatientHandler handlers.ExternalPatientHandlerInterface, cronJobHandler cron.CronJobHandlerInterface, consulHandler ...handlers.ConsulHandlerInterface) *gin.Engine {
 	routeSetup := NewRouteSetup()
-	return routeSetup.SetupRoutes(patientHandler, externalPatientHandler, cronJobHandler, consulHandler...)
+	return routeSetup.SetupRoutes(patientHandler, bundleHandler, resourceHandlers, compartmentHandler, mergeHandler, bulkHandler, externalPatientHandler, cronJobHandler, consulHandler...)
 }
 
 // SetupRoutes configures all the routes for the application
 func (r *RouteSetup) SetupRoutes(
 	patientHandler handlers.PatientHandlerInterface,
+	bundleHandler handlers.BundleHandlerInterface,
+	resourceHandlers []handlers.ResourceHandlerInterface,
+	compartmentHandler handlers.CompartmentHandlerInterface,
+	mergeHandler handlers.MergeHandlerInterface,
+	bulkHandler handlers.BulkHandlerInterface,
 	externalPatientHandler handlers.ExternalPatientHandlerInterface,
 	cronJobHandler cron.CronJobHandlerInterface,
 	// Add optional handlers
@@ -53,26 +59,70 @@ func (r *RouteSetup) SetupRoutes(
 		})
 	})
 
+	// FHIR endpoints negotiate JSON or XML
+	fhirFormat := middleware.FHIRFormat()
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Batch and transaction bundles
+		v1.POST("", fhirFormat, bundleHandler.ProcessBundle)
+
+		// Bulk data jobs; the status and file endpoints answer in their own formats
+		v1.GET("/$export", fhirFormat, bulkHandler.ExportSystem)
+		v1.GET("/$bulk-status/:id", bulkHandler.GetJobStatus)
+		v1.DELETE("/$bulk-status/:id", bulkHandler.DeleteJob)
+		v1.GET("/$bulk-files/:id/:name", bulkHandler.GetJobFile)
+
 		// Patient routes
-		patients := v1.Group("/patients")
+		patients := v1.Group("/patients", fhirFormat)
 		{
 			patients.GET("", patientHandler.GetPatients)
 			patients.POST("", patientHandler.CreatePatient)
+			patients.PUT("", patientHandler.ConditionalUpdatePatient)
+			patients.DELETE("", patientHandler.ConditionalDeletePatient)
+			patients.POST("/$validate", patientHandler.ValidatePatient)
+			patients.POST("/$match", patientHandler.MatchPatient)
+			patients.POST("/$merge", mergeHandler.MergePatients)
+			patients.GET("/$export", bulkHandler.ExportPatients)
+			patients.POST("/$import", bulkHandler.ImportPatients)
+			patients.GET("/_history", patientHandler.GetPatientsHistory)
 			patients.GET("/:id", patientHandler.GetPatient)
+			patients.GET("/:id/_history", patientHandler.GetPatientHistory)
+			patients.GET("/:id/_history/:vid", patientHandler.GetPatientVersion)
+			patients.GET("/:id/$everything", compartmentHandler.GetPatientEverything)
+			patients.POST("/:id/$unmerge", mergeHandler.UnmergePatient)
+			patients.POST("/:id/$expunge", patientHandler.ExpungePatient)
 			patients.PUT("/:id", patientHandler.UpdatePatient)
 			patients.PATCH("/:id", patientHandler.PatchPatient)
 			patients.DELETE("/:id", patientHandler.DeletePatient)
 		}
 
+		// Administrative routes
+		admin := v1.Group("/admin", fhirFormat)
+		{
+			admin.POST("/patients/:id/$undelete", patientHandler.UndeletePatient)
+		}
+
+		// Routes of the resource types kept in the generic resource store
+		for _, resourceHandler := range resourceHandlers {
+			resources := v1.Group("/"+resourceHandler.Collection(), fhirFormat)
+			{
+				resources.GET("", resourceHandler.SearchResources)
+				resources.POST("", resourceHandler.CreateResource)
+				resources.GET("/:id", resourceHandler.GetResource)
+				resources.PUT("/:id", resourceHandler.UpdateResource)
+				resources.DELETE("/:id", resourceHandler.DeleteResource)
+			}
+		}
+
 		// External Patient routes
-		externalPatients := v1.Group("/external-patients")
+		externalPatients := v1.Group("/external-patients", fhirFormat)
 		{
 			externalPatients.GET("/:id", externalPatientHandler.GetExternalPatientByID)
 			externalPatients.GET("/:id/cached", externalPatientHandler.GetExternalPatientByIDCached)
 			externalPatients.GET("/:id/delayed", externalPatientHandler.GetExternalPatientByIDDelayed)
+			externalPatients.GET("/:id/$everything", externalPatientHandler.GetExternalPatientEverything)
 			externalPatients.GET("", externalPatientHandler.SearchExternalPatients)
 			externalPatients.POST("", externalPatientHandler.CreateExternalPatient)
 		}
@@ -91,40 +141,60 @@ func (r *RouteSetup) SetupRoutes(
 		}
 	}
 
-	// FHIR metadata endpoint
-	router.GET("/metadata", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"resourceType": "CapabilityStatement",
-			"status":       "active",
-			"date":         "2025-06-05",
-			"publisher":    "FHIR Demo",
-			"kind":         "instance",
-			"software": gin.H{
-				"name":    "FHIR Patient API",
-				"version": "1.0.0",
-			},
-			"fhirVersion": "4.0.1",
-			"format":      []string{"json"},
-			"rest": []gin.H{
-				{
-					"mode": "server",
-					"resource": []gin.H{
-						{
-							"type": "Patient",
-							"interaction": []gin.H{
-								{"code": "read"},
-								{"code": "create"},
-								{"code": "update"},
-								{"code": "patch"},
-								{"code": "delete"},
-								{"code": "search-type"},
-							},
-						},
-					},
-				},
-			},
-		})
+	// The capability statement describes the routes registered above, and is served at the
+	// FHIR base as well as at the root. The local operation definitions it points to are
+	// served at the FHIR base.
+	capabilityHandler := handlers.NewCapabilityHandler(handlers.CapabilityConfig{
+		BasePath:  v1.BasePath(),
+		Routes:    router.Routes(),
+		Resources: capabilityResources(resourceHandlers),
+		Formats:   middleware.FHIRMediaTypes,
+		CORS:      true, // middleware.CORS runs on every route
 	})
+	v1.GET("/metadata", fhirFormat, capabilityHandler.GetCapabilityStatement)
+	router.GET("/metadata", fhirFormat, capabilityHandler.GetCapabilityStatement)
+	v1.GET("/OperationDefinition/:id", fhirFormat, capabilityHandler.GetOperationDefinition)
 
 	return router
 }
+
+// patientIncludes lists the _include values patient search supports
+func patientIncludes() []string {
+	includes := make([]string, 0, len(domain.PatientIncludeParameters))
+	for _, def := range domain.PatientIncludeParameters {
+		includes = append(includes, "Patient:"+def.Name)
+	}
+	return includes
+}
+
+// patientRevIncludes lists the _revinclude values patient search supports
+func patientRevIncludes() []string {
+	revIncludes := domain.PatientRevIncludes()
+	values := make([]string, 0, len(revIncludes))
+	for _, include := range revIncludes {
+		values = append(values, include.String())
+	}
+	return values
+}
+
+// capabilityResources lists the resource types served under the FHIR base with their search
+// parameters: Patient, then the types kept in the generic resource store
+func capabilityResources(resourceHandlers []handlers.ResourceHandlerInterface) []handlers.CapabilityResource {
+	resources := []handlers.CapabilityResource{{
+		Type:              "Patient",
+		Collection:        "patients",
+		SearchParameters:  domain.PatientSearchParameters,
+		SearchInclude:     patientIncludes(),
+		SearchRevInclude:  patientRevIncludes(),
+		ConditionalCreate: true,
+	}}
+	for _, resourceHandler := range resourceHandlers {
+		definition := domain.ResourceDefinitions[resourceHandler.ResourceType()]
+		resources = append(resources, handlers.CapabilityResource{
+			Type:             definition.Type,
+			Collection:       resourceHandler.Collection(),
+			SearchParameters: definition.SearchParameters,
+		})
+	}
+	return resources
+}
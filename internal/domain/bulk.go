@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BulkJobKind is the operation a bulk data job runs
+type BulkJobKind string
+
+const (
+	BulkJobExport BulkJobKind = "export"
+	BulkJobImport BulkJobKind = "import"
+)
+
+// BulkJobStatus is the state of a bulk data job
+type BulkJobStatus string
+
+const (
+	BulkJobInProgress BulkJobStatus = "in-progress"
+	BulkJobCompleted  BulkJobStatus = "completed"
+	BulkJobFailed     BulkJobStatus = "failed"
+)
+
+// BulkJob is an asynchronous bulk data operation. The files it writes are kept on local disk
+// until the job is deleted.
+type BulkJob struct {
+	ID              string        `json:"id" gorm:"type:varchar(64);primaryKey"`
+	Kind            BulkJobKind   `json:"kind" gorm:"type:varchar(16);not null"`
+	Status          BulkJobStatus `json:"status" gorm:"type:varchar(16);not null;index"`
+	Request         string        `json:"request" gorm:"not null"`  // URL of the kick-off request
+	Progress        string        `json:"progress"`                 // Reported to clients polling a running job
+	Output          []byte        `json:"output" gorm:"type:jsonb"` // BulkFile values of the files written
+	Error           string        `json:"error"`                    // Why the job failed
+	TransactionTime time.Time     `json:"transaction_time"`         // Resources changed after it are left out
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// BulkFile is an NDJSON file written by a bulk data job, or for an import, the resources
+// imported from one of its inputs
+type BulkFile struct {
+	Type  string `json:"type"`            // Resource type of every line
+	Name  string `json:"name,omitempty"`  // File name within the job's directory; empty when no file was written
+	Input string `json:"input,omitempty"` // Input an import read the resources from
+	Count int64  `json:"count"`           // Number of resources
+}
+
+// ExportRequest asks for an NDJSON export of the resources on the server
+type ExportRequest struct {
+	Request string     // URL of the kick-off request
+	Patient bool       // Export the patients and their compartments only, rather than every resource
+	Types   []string   // Resource types to export; empty exports every type
+	Since   *time.Time // Only resources last updated after Since
+}
+
+// ImportRequest asks for an NDJSON import of patients, from an upload or from files on the server
+type ImportRequest struct {
+	Request string    // URL of the kick-off request
+	Upload  io.Reader // NDJSON sent with the kick-off request; nil when the request names files
+	Paths   []string  // NDJSON files to read, relative to the import directory
+}
+
+// ExportQuery selects the resources of one type an export reads
+type ExportQuery struct {
+	Since   *time.Time
+	Until   time.Time
+	Patient bool // Only resources in the compartment of a patient
+	AfterID uint // Resources are read in id order, from after AfterID
+	Limit   int
+}
+
+// BulkRepository defines the interface for storing bulk data jobs and reading the resources
+// they export in batches
+type BulkRepository interface {
+	Create(ctx context.Context, job *BulkJob) error
+	Get(ctx context.Context, id string) (*BulkJob, error)
+	Update(ctx context.Context, job *BulkJob) error
+	Delete(ctx context.Context, id string) error
+	FailInterrupted(ctx context.Context) (int64, error)
+	ExportPatients(ctx context.Context, query ExportQuery) ([]*Patient, error)
+	ExportResources(ctx context.Context, resourceType string, query ExportQuery) ([]*Resource, error)
+}
+
+// BulkService defines the interface for asynchronous bulk data operations
+type BulkService interface {
+	StartExport(ctx context.Context, request ExportRequest) (*BulkJob, error)
+	StartImport(ctx context.Context, request ImportRequest) (*BulkJob, error)
+	GetJob(ctx context.Context, id string) (*BulkJob, error)
+	DeleteJob(ctx context.Context, id string) error
+	JobFile(ctx context.Context, id, name string) (string, error)
+	FailInterruptedJobs(ctx context.Context) error
+}
+
+// TableName specifies the table name for BulkJob model
+func (BulkJob) TableName() string {
+	return "bulk_jobs"
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+
+	"go-fhir-demo/pkg/fhirsearch"
+)
+
+// Patch formats of a bundle PATCH entry
+const (
+	PatchFormatJSONPatch     = "json-patch"
+	PatchFormatFHIRPathPatch = "fhirpath-patch"
+)
+
+// BundleEntryRequest is one Patient interaction of a batch or transaction Bundle
+type BundleEntryRequest struct {
+	Method          string            // HTTP verb of the interaction
+	FullURL         string            // entry.fullUrl; urn:uuid values are resolved to the created patient
+	ID              string            // Logical id of an instance interaction
+	VersionID       int               // Version of a vread
+	Criteria        *fhirsearch.Query // Search criteria of a search or conditional interaction
+	ExpectedVersion int               // Version required by If-Match; zero for none
+	Resource        []byte            // Patient of a create or update, or the patch document of a patch
+	PatchFormat     string            // Format of the patch document of a patch
+}
+
+// BundleEntryResult is the outcome of one entry of a batch or transaction Bundle
+type BundleEntryResult struct {
+	Status  int             // HTTP status of the interaction
+	Patient *Patient        // Patient read or written; nil for deletes, searches and vreads
+	Version *PatientHistory // Version read by a vread
+	Matches []*Patient      // Patients found by a search
+	Total   int64           // Total number of patients matching a search
+	Err     error           // Failure of a batch entry; a failing transaction entry fails the whole bundle
+}
+
+// BundleService defines the interface for processing batch and transaction Bundles
+type BundleService interface {
+	ProcessBatch(ctx context.Context, requests []BundleEntryRequest) []BundleEntryResult
+	ProcessTransaction(ctx context.Context, requests []BundleEntryRequest) ([]BundleEntryResult, error)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-fhir-demo/pkg/fhirsearch"
+)
+
+// CompartmentQuery selects a page of the resources in a patient's compartment
+type CompartmentQuery struct {
+	PatientID string
+	Types     []string   // Resource types to include; empty includes every type
+	Since     *time.Time // Only resources last updated at or after Since
+	Count     int
+	Offset    int
+}
+
+// CompartmentEntry is a resource of a patient compartment, or one related to a patient, in its FHIR JSON form
+type CompartmentEntry struct {
+	ResourceType string
+	LogicalID    string
+	Resource     json.RawMessage
+}
+
+// CompartmentService defines the interface for patient compartment operations
+type CompartmentService interface {
+	PatientEverything(ctx context.Context, query CompartmentQuery) ([]CompartmentEntry, int64, error)
+	PatientIncludes(ctx context.Context, patients []*Patient, query *fhirsearch.Query) ([]CompartmentEntry, bool, error)
+}
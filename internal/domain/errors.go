@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+var (
+	// ErrNotFound is returned when a requested resource or version does not exist
+	ErrNotFound = errors.New("resource not found")
+	// ErrGone is returned when a requested resource version has been deleted
+	ErrGone = errors.New("resource deleted")
+	// ErrValidation is returned when a request or resource is not acceptable as given
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict is returned when a write conflicts with the current state of the server
+	ErrConflict = errors.New("resource conflict")
+	// ErrVersionConflict is returned when a write expects a version that is no longer current
+	ErrVersionConflict = errors.New("resource version mismatch")
+	// ErrMultipleMatches is returned when conditional criteria match more than one resource
+	ErrMultipleMatches = errors.New("multiple resources match the criteria")
+	// ErrUpstream is returned when an upstream server fails or answers with an unexpected status
+	ErrUpstream = errors.New("upstream server error")
+	// ErrTimeout is returned when an upstream server does not answer in time
+	ErrTimeout = errors.New("upstream request timed out")
+)
+
+// ValidationError reports the issues that make a resource invalid. It matches ErrValidation.
+type ValidationError struct {
+	Issues []fhir.OperationOutcomeIssue
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Diagnostics != nil {
+			messages = append(messages, *issue.Diagnostics)
+		}
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Is lets errors.Is match a ValidationError against ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
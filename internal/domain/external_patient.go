@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"net/url"
 	"time"
 
 	"github.com/samply/golang-fhir-models/fhir-models/fhir"
@@ -13,4 +14,5 @@ type ExternalPatientService interface {
 	SearchExternalPatients(params map[string]string) (*fhir.Bundle, error)
 	CreateExternalPatient(patient *fhir.Patient) (*fhir.Patient, error)
 	GetExternalPatientByIDDelayed(ctx context.Context, id string, timeout time.Duration) (*fhir.Patient, error)
+	GetExternalPatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error)
 }
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MergeRule selects how an element of the source patient is combined with the target's in a merge
+type MergeRule string
+
+const (
+	MergeRuleUnion  MergeRule = "union"  // The target's values followed by the source's values the target lacks
+	MergeRuleTarget MergeRule = "target" // The target's values only
+	MergeRuleSource MergeRule = "source" // The source's values, or the target's when the source has none
+)
+
+// MergeRules holds the merge rule of each element combined by a merge
+type MergeRules struct {
+	Identifier MergeRule
+	Name       MergeRule
+	Telecom    MergeRule
+	Address    MergeRule
+}
+
+// Validate checks every element has a known merge rule
+func (r MergeRules) Validate() error {
+	for element, rule := range map[string]MergeRule{"identifier": r.Identifier, "name": r.Name, "telecom": r.Telecom, "address": r.Address} {
+		switch rule {
+		case MergeRuleUnion, MergeRuleTarget, MergeRuleSource:
+		default:
+			return fmt.Errorf("unknown merge rule %q for %s, expected union, target or source", rule, element)
+		}
+	}
+	return nil
+}
+
+// MergeRequest asks to merge the source patient into the target patient
+type MergeRequest struct {
+	SourceID string // Logical id of the patient merged away
+	TargetID string // Logical id of the patient that remains
+	Preview  bool   // Compute the result without storing anything
+}
+
+// MergeResult is the state of both patients after a merge or unmerge
+type MergeResult struct {
+	Source     *Patient
+	Target     *Patient
+	References int // Number of references in other resources repointed
+}
+
+// PatientMerge records the merge of a source patient into a target patient, with the state
+// needed to reverse it
+type PatientMerge struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	SourceID   uint       `json:"source_id" gorm:"not null;index"`
+	TargetID   uint       `json:"target_id" gorm:"not null;index"`
+	SourceData []byte     `json:"source_data" gorm:"type:jsonb;not null"` // Source resource before the merge
+	TargetData []byte     `json:"target_data" gorm:"type:jsonb;not null"` // Target resource before the merge
+	References []byte     `json:"references" gorm:"type:jsonb"`           // Repointed references, as RepointedReference values
+	CreatedAt  time.Time  `json:"created_at"`
+	UnmergedAt *time.Time `json:"unmerged_at" gorm:"index"`
+}
+
+// RepointedReference is a reference in another resource that a merge changed from the source to the target
+type RepointedReference struct {
+	ResourceID uint   `json:"resourceId"`
+	Path       string `json:"path"`      // JSON pointer to the reference element
+	Reference  string `json:"reference"` // Value before the merge
+}
+
+// MergeRepository defines the interface for storing merges. Both patients, the repointed
+// references and the merge record are written in a single transaction.
+type MergeRepository interface {
+	Merge(ctx context.Context, merge *PatientMerge, source, target *Patient, sourceVersion, targetVersion int) error
+	Unmerge(ctx context.Context, merge *PatientMerge, source, target *Patient, sourceVersion, targetVersion int) error
+	GetActiveMerge(ctx context.Context, sourceID uint) (*PatientMerge, error)
+}
+
+// MergeService defines the interface for merging duplicate patients
+type MergeService interface {
+	MergePatients(ctx context.Context, request MergeRequest) (*MergeResult, error)
+	UnmergePatient(ctx context.Context, sourceID string) (*MergeResult, error)
+}
+
+// TableName specifies the table name for PatientMerge model
+func (PatientMerge) TableName() string {
+	return "patient_merges"
+}
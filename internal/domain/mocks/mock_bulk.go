@@ -0,0 +1,256 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\bulk.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\bulk.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\mocks\mock_bulk.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBulkRepository is a mock of BulkRepository interface.
+type MockBulkRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockBulkRepositoryMockRecorder is the mock recorder for MockBulkRepository.
+type MockBulkRepositoryMockRecorder struct {
+	mock *MockBulkRepository
+}
+
+// NewMockBulkRepository creates a new mock instance.
+func NewMockBulkRepository(ctrl *gomock.Controller) *MockBulkRepository {
+	mock := &MockBulkRepository{ctrl: ctrl}
+	mock.recorder = &MockBulkRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkRepository) EXPECT() *MockBulkRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockBulkRepository) Create(ctx context.Context, job *domain.BulkJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBulkRepositoryMockRecorder) Create(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBulkRepository)(nil).Create), ctx, job)
+}
+
+// Delete mocks base method.
+func (m *MockBulkRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBulkRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBulkRepository)(nil).Delete), ctx, id)
+}
+
+// ExportPatients mocks base method.
+func (m *MockBulkRepository) ExportPatients(ctx context.Context, query domain.ExportQuery) ([]*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportPatients", ctx, query)
+	ret0, _ := ret[0].([]*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportPatients indicates an expected call of ExportPatients.
+func (mr *MockBulkRepositoryMockRecorder) ExportPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportPatients", reflect.TypeOf((*MockBulkRepository)(nil).ExportPatients), ctx, query)
+}
+
+// ExportResources mocks base method.
+func (m *MockBulkRepository) ExportResources(ctx context.Context, resourceType string, query domain.ExportQuery) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportResources", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportResources indicates an expected call of ExportResources.
+func (mr *MockBulkRepositoryMockRecorder) ExportResources(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportResources", reflect.TypeOf((*MockBulkRepository)(nil).ExportResources), ctx, resourceType, query)
+}
+
+// FailInterrupted mocks base method.
+func (m *MockBulkRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailInterrupted", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailInterrupted indicates an expected call of FailInterrupted.
+func (mr *MockBulkRepositoryMockRecorder) FailInterrupted(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailInterrupted", reflect.TypeOf((*MockBulkRepository)(nil).FailInterrupted), ctx)
+}
+
+// Get mocks base method.
+func (m *MockBulkRepository) Get(ctx context.Context, id string) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBulkRepositoryMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBulkRepository)(nil).Get), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockBulkRepository) Update(ctx context.Context, job *domain.BulkJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBulkRepositoryMockRecorder) Update(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBulkRepository)(nil).Update), ctx, job)
+}
+
+// MockBulkService is a mock of BulkService interface.
+type MockBulkService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockBulkServiceMockRecorder is the mock recorder for MockBulkService.
+type MockBulkServiceMockRecorder struct {
+	mock *MockBulkService
+}
+
+// NewMockBulkService creates a new mock instance.
+func NewMockBulkService(ctrl *gomock.Controller) *MockBulkService {
+	mock := &MockBulkService{ctrl: ctrl}
+	mock.recorder = &MockBulkServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkService) EXPECT() *MockBulkServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteJob mocks base method.
+func (m *MockBulkService) DeleteJob(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJob indicates an expected call of DeleteJob.
+func (mr *MockBulkServiceMockRecorder) DeleteJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJob", reflect.TypeOf((*MockBulkService)(nil).DeleteJob), ctx, id)
+}
+
+// FailInterruptedJobs mocks base method.
+func (m *MockBulkService) FailInterruptedJobs(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailInterruptedJobs", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailInterruptedJobs indicates an expected call of FailInterruptedJobs.
+func (mr *MockBulkServiceMockRecorder) FailInterruptedJobs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailInterruptedJobs", reflect.TypeOf((*MockBulkService)(nil).FailInterruptedJobs), ctx)
+}
+
+// GetJob mocks base method.
+func (m *MockBulkService) GetJob(ctx context.Context, id string) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockBulkServiceMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockBulkService)(nil).GetJob), ctx, id)
+}
+
+// JobFile mocks base method.
+func (m *MockBulkService) JobFile(ctx context.Context, id, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobFile", ctx, id, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JobFile indicates an expected call of JobFile.
+func (mr *MockBulkServiceMockRecorder) JobFile(ctx, id, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobFile", reflect.TypeOf((*MockBulkService)(nil).JobFile), ctx, id, name)
+}
+
+// StartExport mocks base method.
+func (m *MockBulkService) StartExport(ctx context.Context, request domain.ExportRequest) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartExport", ctx, request)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartExport indicates an expected call of StartExport.
+func (mr *MockBulkServiceMockRecorder) StartExport(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartExport", reflect.TypeOf((*MockBulkService)(nil).StartExport), ctx, request)
+}
+
+// StartImport mocks base method.
+func (m *MockBulkService) StartImport(ctx context.Context, request domain.ImportRequest) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImport", ctx, request)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartImport indicates an expected call of StartImport.
+func (mr *MockBulkServiceMockRecorder) StartImport(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImport", reflect.TypeOf((*MockBulkService)(nil).StartImport), ctx, request)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\bundle.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\bundle.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\mocks\mock_bundle.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBundleService is a mock of BundleService interface.
+type MockBundleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBundleServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockBundleServiceMockRecorder is the mock recorder for MockBundleService.
+type MockBundleServiceMockRecorder struct {
+	mock *MockBundleService
+}
+
+// NewMockBundleService creates a new mock instance.
+func NewMockBundleService(ctrl *gomock.Controller) *MockBundleService {
+	mock := &MockBundleService{ctrl: ctrl}
+	mock.recorder = &MockBundleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBundleService) EXPECT() *MockBundleServiceMockRecorder {
+	return m.recorder
+}
+
+// ProcessBatch mocks base method.
+func (m *MockBundleService) ProcessBatch(ctx context.Context, requests []domain.BundleEntryRequest) []domain.BundleEntryResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBatch", ctx, requests)
+	ret0, _ := ret[0].([]domain.BundleEntryResult)
+	return ret0
+}
+
+// ProcessBatch indicates an expected call of ProcessBatch.
+func (mr *MockBundleServiceMockRecorder) ProcessBatch(ctx, requests any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBatch", reflect.TypeOf((*MockBundleService)(nil).ProcessBatch), ctx, requests)
+}
+
+// ProcessTransaction mocks base method.
+func (m *MockBundleService) ProcessTransaction(ctx context.Context, requests []domain.BundleEntryRequest) ([]domain.BundleEntryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessTransaction", ctx, requests)
+	ret0, _ := ret[0].([]domain.BundleEntryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessTransaction indicates an expected call of ProcessTransaction.
+func (mr *MockBundleServiceMockRecorder) ProcessTransaction(ctx, requests any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessTransaction", reflect.TypeOf((*MockBundleService)(nil).ProcessTransaction), ctx, requests)
+}
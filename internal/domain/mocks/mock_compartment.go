@@ -0,0 +1,75 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\compartment.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\compartment.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\mocks\mock_compartment.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCompartmentService is a mock of CompartmentService interface.
+type MockCompartmentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompartmentServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCompartmentServiceMockRecorder is the mock recorder for MockCompartmentService.
+type MockCompartmentServiceMockRecorder struct {
+	mock *MockCompartmentService
+}
+
+// NewMockCompartmentService creates a new mock instance.
+func NewMockCompartmentService(ctrl *gomock.Controller) *MockCompartmentService {
+	mock := &MockCompartmentService{ctrl: ctrl}
+	mock.recorder = &MockCompartmentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompartmentService) EXPECT() *MockCompartmentServiceMockRecorder {
+	return m.recorder
+}
+
+// PatientEverything mocks base method.
+func (m *MockCompartmentService) PatientEverything(ctx context.Context, query domain.CompartmentQuery) ([]domain.CompartmentEntry, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatientEverything", ctx, query)
+	ret0, _ := ret[0].([]domain.CompartmentEntry)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PatientEverything indicates an expected call of PatientEverything.
+func (mr *MockCompartmentServiceMockRecorder) PatientEverything(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatientEverything", reflect.TypeOf((*MockCompartmentService)(nil).PatientEverything), ctx, query)
+}
+
+// PatientIncludes mocks base method.
+func (m *MockCompartmentService) PatientIncludes(ctx context.Context, patients []*domain.Patient, query *fhirsearch.Query) ([]domain.CompartmentEntry, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatientIncludes", ctx, patients, query)
+	ret0, _ := ret[0].([]domain.CompartmentEntry)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PatientIncludes indicates an expected call of PatientIncludes.
+func (mr *MockCompartmentServiceMockRecorder) PatientIncludes(ctx, patients, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatientIncludes", reflect.TypeOf((*MockCompartmentService)(nil).PatientIncludes), ctx, patients, query)
+}
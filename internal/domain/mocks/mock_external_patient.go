@@ -11,6 +11,7 @@ package mocks
 
 import (
 	context "context"
+	url "net/url"
 	reflect "reflect"
 	time "time"
 
@@ -87,6 +88,21 @@ func (mr *MockExternalPatientServiceMockRecorder) GetExternalPatientByIDDelayed(
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalPatientByIDDelayed", reflect.TypeOf((*MockExternalPatientService)(nil).GetExternalPatientByIDDelayed), ctx, id, timeout)
 }
 
+// GetExternalPatientEverything mocks base method.
+func (m *MockExternalPatientService) GetExternalPatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExternalPatientEverything", ctx, id, params)
+	ret0, _ := ret[0].(*fhir.Bundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExternalPatientEverything indicates an expected call of GetExternalPatientEverything.
+func (mr *MockExternalPatientServiceMockRecorder) GetExternalPatientEverything(ctx, id, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalPatientEverything", reflect.TypeOf((*MockExternalPatientService)(nil).GetExternalPatientEverything), ctx, id, params)
+}
+
 // SearchExternalPatients mocks base method.
 func (m *MockExternalPatientService) SearchExternalPatients(params map[string]string) (*fhir.Bundle, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\merge.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\merge.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\mocks\mock_merge.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMergeRepository is a mock of MergeRepository interface.
+type MockMergeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMergeRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMergeRepositoryMockRecorder is the mock recorder for MockMergeRepository.
+type MockMergeRepositoryMockRecorder struct {
+	mock *MockMergeRepository
+}
+
+// NewMockMergeRepository creates a new mock instance.
+func NewMockMergeRepository(ctrl *gomock.Controller) *MockMergeRepository {
+	mock := &MockMergeRepository{ctrl: ctrl}
+	mock.recorder = &MockMergeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMergeRepository) EXPECT() *MockMergeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetActiveMerge mocks base method.
+func (m *MockMergeRepository) GetActiveMerge(ctx context.Context, sourceID uint) (*domain.PatientMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveMerge", ctx, sourceID)
+	ret0, _ := ret[0].(*domain.PatientMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveMerge indicates an expected call of GetActiveMerge.
+func (mr *MockMergeRepositoryMockRecorder) GetActiveMerge(ctx, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveMerge", reflect.TypeOf((*MockMergeRepository)(nil).GetActiveMerge), ctx, sourceID)
+}
+
+// Merge mocks base method.
+func (m *MockMergeRepository) Merge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, merge, source, target, sourceVersion, targetVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockMergeRepositoryMockRecorder) Merge(ctx, merge, source, target, sourceVersion, targetVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockMergeRepository)(nil).Merge), ctx, merge, source, target, sourceVersion, targetVersion)
+}
+
+// Unmerge mocks base method.
+func (m *MockMergeRepository) Unmerge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unmerge", ctx, merge, source, target, sourceVersion, targetVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unmerge indicates an expected call of Unmerge.
+func (mr *MockMergeRepositoryMockRecorder) Unmerge(ctx, merge, source, target, sourceVersion, targetVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmerge", reflect.TypeOf((*MockMergeRepository)(nil).Unmerge), ctx, merge, source, target, sourceVersion, targetVersion)
+}
+
+// MockMergeService is a mock of MergeService interface.
+type MockMergeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMergeServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockMergeServiceMockRecorder is the mock recorder for MockMergeService.
+type MockMergeServiceMockRecorder struct {
+	mock *MockMergeService
+}
+
+// NewMockMergeService creates a new mock instance.
+func NewMockMergeService(ctrl *gomock.Controller) *MockMergeService {
+	mock := &MockMergeService{ctrl: ctrl}
+	mock.recorder = &MockMergeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMergeService) EXPECT() *MockMergeServiceMockRecorder {
+	return m.recorder
+}
+
+// MergePatients mocks base method.
+func (m *MockMergeService) MergePatients(ctx context.Context, request domain.MergeRequest) (*domain.MergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergePatients", ctx, request)
+	ret0, _ := ret[0].(*domain.MergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergePatients indicates an expected call of MergePatients.
+func (mr *MockMergeServiceMockRecorder) MergePatients(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePatients", reflect.TypeOf((*MockMergeService)(nil).MergePatients), ctx, request)
+}
+
+// UnmergePatient mocks base method.
+func (m *MockMergeService) UnmergePatient(ctx context.Context, sourceID string) (*domain.MergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnmergePatient", ctx, sourceID)
+	ret0, _ := ret[0].(*domain.MergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnmergePatient indicates an expected call of UnmergePatient.
+func (mr *MockMergeServiceMockRecorder) UnmergePatient(ctx, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmergePatient", reflect.TypeOf((*MockMergeService)(nil).UnmergePatient), ctx, sourceID)
+}
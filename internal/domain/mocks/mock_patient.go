@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
 	reflect "reflect"
 
 	fhir "github.com/samply/golang-fhir-models/fhir-models/fhir"
@@ -71,18 +72,47 @@ func (mr *MockPatientRepositoryMockRecorder) Create(ctx, patient any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPatientRepository)(nil).Create), ctx, patient)
 }
 
+// CreateBatch mocks base method.
+func (m *MockPatientRepository) CreateBatch(ctx context.Context, patients []*domain.Patient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, patients)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockPatientRepositoryMockRecorder) CreateBatch(ctx, patients any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockPatientRepository)(nil).CreateBatch), ctx, patients)
+}
+
 // Delete mocks base method.
-func (m *MockPatientRepository) Delete(ctx context.Context, id uint) error {
+func (m *MockPatientRepository) Delete(ctx context.Context, id uint, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockPatientRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+func (mr *MockPatientRepositoryMockRecorder) Delete(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPatientRepository)(nil).Delete), ctx, id, expectedVersion)
+}
+
+// Expunge mocks base method.
+func (m *MockPatientRepository) Expunge(ctx context.Context, logicalID string, history bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expunge", ctx, logicalID, history)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Expunge indicates an expected call of Expunge.
+func (mr *MockPatientRepositoryMockRecorder) Expunge(ctx, logicalID, history any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPatientRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expunge", reflect.TypeOf((*MockPatientRepository)(nil).Expunge), ctx, logicalID, history)
 }
 
 // GetAll mocks base method.
@@ -115,18 +145,177 @@ func (mr *MockPatientRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPatientRepository)(nil).GetByID), ctx, id)
 }
 
+// GetByLogicalID mocks base method.
+func (m *MockPatientRepository) GetByLogicalID(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalID", ctx, logicalID)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalID indicates an expected call of GetByLogicalID.
+func (mr *MockPatientRepositoryMockRecorder) GetByLogicalID(ctx, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalID", reflect.TypeOf((*MockPatientRepository)(nil).GetByLogicalID), ctx, logicalID)
+}
+
+// GetVersion mocks base method.
+func (m *MockPatientRepository) GetVersion(ctx context.Context, logicalID string, versionID int) (*domain.PatientHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx, logicalID, versionID)
+	ret0, _ := ret[0].(*domain.PatientHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockPatientRepositoryMockRecorder) GetVersion(ctx, logicalID, versionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockPatientRepository)(nil).GetVersion), ctx, logicalID, versionID)
+}
+
+// History mocks base method.
+func (m *MockPatientRepository) History(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, query)
+	ret0, _ := ret[0].([]*domain.PatientHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// History indicates an expected call of History.
+func (mr *MockPatientRepositoryMockRecorder) History(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockPatientRepository)(nil).History), ctx, query)
+}
+
+// MatchCandidates mocks base method.
+func (m *MockPatientRepository) MatchCandidates(ctx context.Context, criteria domain.MatchCriteria, limit int) ([]*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchCandidates", ctx, criteria, limit)
+	ret0, _ := ret[0].([]*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchCandidates indicates an expected call of MatchCandidates.
+func (mr *MockPatientRepositoryMockRecorder) MatchCandidates(ctx, criteria, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchCandidates", reflect.TypeOf((*MockPatientRepository)(nil).MatchCandidates), ctx, criteria, limit)
+}
+
+// Search mocks base method.
+func (m *MockPatientRepository) Search(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query)
+	ret0, _ := ret[0].(*domain.PatientPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPatientRepositoryMockRecorder) Search(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPatientRepository)(nil).Search), ctx, query)
+}
+
+// SearchCount mocks base method.
+func (m *MockPatientRepository) SearchCount(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCount", ctx, query)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount.
+func (mr *MockPatientRepositoryMockRecorder) SearchCount(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockPatientRepository)(nil).SearchCount), ctx, query)
+}
+
+// Transaction mocks base method.
+func (m *MockPatientRepository) Transaction(ctx context.Context, fn func(domain.PatientRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockPatientRepositoryMockRecorder) Transaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockPatientRepository)(nil).Transaction), ctx, fn)
+}
+
+// Undelete mocks base method.
+func (m *MockPatientRepository) Undelete(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Undelete", ctx, logicalID)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Undelete indicates an expected call of Undelete.
+func (mr *MockPatientRepositoryMockRecorder) Undelete(ctx, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undelete", reflect.TypeOf((*MockPatientRepository)(nil).Undelete), ctx, logicalID)
+}
+
 // Update mocks base method.
-func (m *MockPatientRepository) Update(ctx context.Context, patient *domain.Patient) error {
+func (m *MockPatientRepository) Update(ctx context.Context, patient *domain.Patient, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, patient)
+	ret := m.ctrl.Call(m, "Update", ctx, patient, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockPatientRepositoryMockRecorder) Update(ctx, patient any) *gomock.Call {
+func (mr *MockPatientRepositoryMockRecorder) Update(ctx, patient, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPatientRepository)(nil).Update), ctx, patient)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPatientRepository)(nil).Update), ctx, patient, expectedVersion)
+}
+
+// MockIDGenerator is a mock of IDGenerator interface.
+type MockIDGenerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDGeneratorMockRecorder
+	isgomock struct{}
+}
+
+// MockIDGeneratorMockRecorder is the mock recorder for MockIDGenerator.
+type MockIDGeneratorMockRecorder struct {
+	mock *MockIDGenerator
+}
+
+// NewMockIDGenerator creates a new mock instance.
+func NewMockIDGenerator(ctrl *gomock.Controller) *MockIDGenerator {
+	mock := &MockIDGenerator{ctrl: ctrl}
+	mock.recorder = &MockIDGeneratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDGenerator) EXPECT() *MockIDGeneratorMockRecorder {
+	return m.recorder
+}
+
+// NextID mocks base method.
+func (m *MockIDGenerator) NextID(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextID", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextID indicates an expected call of NextID.
+func (mr *MockIDGeneratorMockRecorder) NextID(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextID", reflect.TypeOf((*MockIDGenerator)(nil).NextID), ctx)
 }
 
 // MockPatientService is a mock of PatientService interface.
@@ -153,6 +342,52 @@ func (m *MockPatientService) EXPECT() *MockPatientServiceMockRecorder {
 	return m.recorder
 }
 
+// ConditionalCreatePatient mocks base method.
+func (m *MockPatientService) ConditionalCreatePatient(ctx context.Context, fhirPatient *fhir.Patient, criteria *fhirsearch.Query) (*domain.Patient, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalCreatePatient", ctx, fhirPatient, criteria)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConditionalCreatePatient indicates an expected call of ConditionalCreatePatient.
+func (mr *MockPatientServiceMockRecorder) ConditionalCreatePatient(ctx, fhirPatient, criteria any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalCreatePatient", reflect.TypeOf((*MockPatientService)(nil).ConditionalCreatePatient), ctx, fhirPatient, criteria)
+}
+
+// ConditionalDeletePatient mocks base method.
+func (m *MockPatientService) ConditionalDeletePatient(ctx context.Context, criteria *fhirsearch.Query) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalDeletePatient", ctx, criteria)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConditionalDeletePatient indicates an expected call of ConditionalDeletePatient.
+func (mr *MockPatientServiceMockRecorder) ConditionalDeletePatient(ctx, criteria any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalDeletePatient", reflect.TypeOf((*MockPatientService)(nil).ConditionalDeletePatient), ctx, criteria)
+}
+
+// ConditionalUpdatePatient mocks base method.
+func (m *MockPatientService) ConditionalUpdatePatient(ctx context.Context, criteria *fhirsearch.Query, fhirPatient *fhir.Patient) (*domain.Patient, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalUpdatePatient", ctx, criteria, fhirPatient)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConditionalUpdatePatient indicates an expected call of ConditionalUpdatePatient.
+func (mr *MockPatientServiceMockRecorder) ConditionalUpdatePatient(ctx, criteria, fhirPatient any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalUpdatePatient", reflect.TypeOf((*MockPatientService)(nil).ConditionalUpdatePatient), ctx, criteria, fhirPatient)
+}
+
 // ConvertFromFHIR mocks base method.
 func (m *MockPatientService) ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +403,21 @@ func (mr *MockPatientServiceMockRecorder) ConvertFromFHIR(ctx, fhirPatient any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertFromFHIR", reflect.TypeOf((*MockPatientService)(nil).ConvertFromFHIR), ctx, fhirPatient)
 }
 
+// ConvertHistoryToFHIR mocks base method.
+func (m *MockPatientService) ConvertHistoryToFHIR(ctx context.Context, entry *domain.PatientHistory) (*fhir.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertHistoryToFHIR", ctx, entry)
+	ret0, _ := ret[0].(*fhir.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertHistoryToFHIR indicates an expected call of ConvertHistoryToFHIR.
+func (mr *MockPatientServiceMockRecorder) ConvertHistoryToFHIR(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertHistoryToFHIR", reflect.TypeOf((*MockPatientService)(nil).ConvertHistoryToFHIR), ctx, entry)
+}
+
 // ConvertToFHIR mocks base method.
 func (m *MockPatientService) ConvertToFHIR(ctx context.Context, patient *domain.Patient) (*fhir.Patient, error) {
 	m.ctrl.T.Helper()
@@ -183,6 +433,21 @@ func (mr *MockPatientServiceMockRecorder) ConvertToFHIR(ctx, patient any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertToFHIR", reflect.TypeOf((*MockPatientService)(nil).ConvertToFHIR), ctx, patient)
 }
 
+// CountPatients mocks base method.
+func (m *MockPatientService) CountPatients(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPatients", ctx, query)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPatients indicates an expected call of CountPatients.
+func (mr *MockPatientServiceMockRecorder) CountPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPatients", reflect.TypeOf((*MockPatientService)(nil).CountPatients), ctx, query)
+}
+
 // CreatePatient mocks base method.
 func (m *MockPatientService) CreatePatient(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
@@ -199,21 +464,51 @@ func (mr *MockPatientServiceMockRecorder) CreatePatient(ctx, fhirPatient any) *g
 }
 
 // DeletePatient mocks base method.
-func (m *MockPatientService) DeletePatient(ctx context.Context, id uint) error {
+func (m *MockPatientService) DeletePatient(ctx context.Context, id string, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePatient", ctx, id)
+	ret := m.ctrl.Call(m, "DeletePatient", ctx, id, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePatient indicates an expected call of DeletePatient.
-func (mr *MockPatientServiceMockRecorder) DeletePatient(ctx, id any) *gomock.Call {
+func (mr *MockPatientServiceMockRecorder) DeletePatient(ctx, id, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientService)(nil).DeletePatient), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientService)(nil).DeletePatient), ctx, id, expectedVersion)
+}
+
+// ExpungePatient mocks base method.
+func (m *MockPatientService) ExpungePatient(ctx context.Context, id string, history bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpungePatient", ctx, id, history)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpungePatient indicates an expected call of ExpungePatient.
+func (mr *MockPatientServiceMockRecorder) ExpungePatient(ctx, id, history any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpungePatient", reflect.TypeOf((*MockPatientService)(nil).ExpungePatient), ctx, id, history)
+}
+
+// FHIRPathPatchPatient mocks base method.
+func (m *MockPatientService) FHIRPathPatchPatient(ctx context.Context, id string, parameters []byte, expectedVersion int) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FHIRPathPatchPatient", ctx, id, parameters, expectedVersion)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FHIRPathPatchPatient indicates an expected call of FHIRPathPatchPatient.
+func (mr *MockPatientServiceMockRecorder) FHIRPathPatchPatient(ctx, id, parameters, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FHIRPathPatchPatient", reflect.TypeOf((*MockPatientService)(nil).FHIRPathPatchPatient), ctx, id, parameters, expectedVersion)
 }
 
 // GetPatient mocks base method.
-func (m *MockPatientService) GetPatient(ctx context.Context, id uint) (*domain.Patient, error) {
+func (m *MockPatientService) GetPatient(ctx context.Context, id string) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPatient", ctx, id)
 	ret0, _ := ret[0].(*domain.Patient)
@@ -227,6 +522,37 @@ func (mr *MockPatientServiceMockRecorder) GetPatient(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatient", reflect.TypeOf((*MockPatientService)(nil).GetPatient), ctx, id)
 }
 
+// GetPatientHistory mocks base method.
+func (m *MockPatientService) GetPatientHistory(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatientHistory", ctx, query)
+	ret0, _ := ret[0].([]*domain.PatientHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPatientHistory indicates an expected call of GetPatientHistory.
+func (mr *MockPatientServiceMockRecorder) GetPatientHistory(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientHistory", reflect.TypeOf((*MockPatientService)(nil).GetPatientHistory), ctx, query)
+}
+
+// GetPatientVersion mocks base method.
+func (m *MockPatientService) GetPatientVersion(ctx context.Context, id string, versionID int) (*domain.PatientHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatientVersion", ctx, id, versionID)
+	ret0, _ := ret[0].(*domain.PatientHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatientVersion indicates an expected call of GetPatientVersion.
+func (mr *MockPatientServiceMockRecorder) GetPatientVersion(ctx, id, versionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientVersion", reflect.TypeOf((*MockPatientService)(nil).GetPatientVersion), ctx, id, versionID)
+}
+
 // GetPatients mocks base method.
 func (m *MockPatientService) GetPatients(ctx context.Context, limit, offset int) ([]*domain.Patient, int64, error) {
 	m.ctrl.T.Helper()
@@ -243,32 +569,122 @@ func (mr *MockPatientServiceMockRecorder) GetPatients(ctx, limit, offset any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatients", reflect.TypeOf((*MockPatientService)(nil).GetPatients), ctx, limit, offset)
 }
 
+// JSONPatchPatient mocks base method.
+func (m *MockPatientService) JSONPatchPatient(ctx context.Context, id string, patch []byte, expectedVersion int) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JSONPatchPatient", ctx, id, patch, expectedVersion)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JSONPatchPatient indicates an expected call of JSONPatchPatient.
+func (mr *MockPatientServiceMockRecorder) JSONPatchPatient(ctx, id, patch, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JSONPatchPatient", reflect.TypeOf((*MockPatientService)(nil).JSONPatchPatient), ctx, id, patch, expectedVersion)
+}
+
+// MatchPatient mocks base method.
+func (m *MockPatientService) MatchPatient(ctx context.Context, fhirPatient *fhir.Patient, onlyCertainMatches bool, count int) ([]domain.PatientMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchPatient", ctx, fhirPatient, onlyCertainMatches, count)
+	ret0, _ := ret[0].([]domain.PatientMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchPatient indicates an expected call of MatchPatient.
+func (mr *MockPatientServiceMockRecorder) MatchPatient(ctx, fhirPatient, onlyCertainMatches, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchPatient", reflect.TypeOf((*MockPatientService)(nil).MatchPatient), ctx, fhirPatient, onlyCertainMatches, count)
+}
+
 // PatchPatient mocks base method.
-func (m *MockPatientService) PatchPatient(ctx context.Context, id uint, updates map[string]any) (*domain.Patient, error) {
+func (m *MockPatientService) PatchPatient(ctx context.Context, id string, updates map[string]any, expectedVersion int) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchPatient", ctx, id, updates)
+	ret := m.ctrl.Call(m, "PatchPatient", ctx, id, updates, expectedVersion)
 	ret0, _ := ret[0].(*domain.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchPatient indicates an expected call of PatchPatient.
-func (mr *MockPatientServiceMockRecorder) PatchPatient(ctx, id, updates any) *gomock.Call {
+func (mr *MockPatientServiceMockRecorder) PatchPatient(ctx, id, updates, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPatient", reflect.TypeOf((*MockPatientService)(nil).PatchPatient), ctx, id, updates)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPatient", reflect.TypeOf((*MockPatientService)(nil).PatchPatient), ctx, id, updates, expectedVersion)
+}
+
+// PreparePatient mocks base method.
+func (m *MockPatientService) PreparePatient(ctx context.Context, resource []byte) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreparePatient", ctx, resource)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreparePatient indicates an expected call of PreparePatient.
+func (mr *MockPatientServiceMockRecorder) PreparePatient(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreparePatient", reflect.TypeOf((*MockPatientService)(nil).PreparePatient), ctx, resource)
+}
+
+// SearchPatients mocks base method.
+func (m *MockPatientService) SearchPatients(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPatients", ctx, query)
+	ret0, _ := ret[0].(*domain.PatientPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPatients indicates an expected call of SearchPatients.
+func (mr *MockPatientServiceMockRecorder) SearchPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatients", reflect.TypeOf((*MockPatientService)(nil).SearchPatients), ctx, query)
+}
+
+// UndeletePatient mocks base method.
+func (m *MockPatientService) UndeletePatient(ctx context.Context, id string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndeletePatient", ctx, id)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UndeletePatient indicates an expected call of UndeletePatient.
+func (mr *MockPatientServiceMockRecorder) UndeletePatient(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndeletePatient", reflect.TypeOf((*MockPatientService)(nil).UndeletePatient), ctx, id)
 }
 
 // UpdatePatient mocks base method.
-func (m *MockPatientService) UpdatePatient(ctx context.Context, id uint, fhirPatient *fhir.Patient) (*domain.Patient, error) {
+func (m *MockPatientService) UpdatePatient(ctx context.Context, id string, fhirPatient *fhir.Patient, expectedVersion int) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdatePatient", ctx, id, fhirPatient)
+	ret := m.ctrl.Call(m, "UpdatePatient", ctx, id, fhirPatient, expectedVersion)
 	ret0, _ := ret[0].(*domain.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdatePatient indicates an expected call of UpdatePatient.
-func (mr *MockPatientServiceMockRecorder) UpdatePatient(ctx, id, fhirPatient any) *gomock.Call {
+func (mr *MockPatientServiceMockRecorder) UpdatePatient(ctx, id, fhirPatient, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientService)(nil).UpdatePatient), ctx, id, fhirPatient, expectedVersion)
+}
+
+// ValidatePatient mocks base method.
+func (m *MockPatientService) ValidatePatient(ctx context.Context, resource []byte, profiles []string) ([]fhir.OperationOutcomeIssue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidatePatient", ctx, resource, profiles)
+	ret0, _ := ret[0].([]fhir.OperationOutcomeIssue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidatePatient indicates an expected call of ValidatePatient.
+func (mr *MockPatientServiceMockRecorder) ValidatePatient(ctx, resource, profiles any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientService)(nil).UpdatePatient), ctx, id, fhirPatient)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePatient", reflect.TypeOf((*MockPatientService)(nil).ValidatePatient), ctx, resource, profiles)
 }
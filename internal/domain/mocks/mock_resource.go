@@ -0,0 +1,276 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\resource.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\resource.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\domain\mocks\mock_resource.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockResourceRepository is a mock of ResourceRepository interface.
+type MockResourceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockResourceRepositoryMockRecorder is the mock recorder for MockResourceRepository.
+type MockResourceRepositoryMockRecorder struct {
+	mock *MockResourceRepository
+}
+
+// NewMockResourceRepository creates a new mock instance.
+func NewMockResourceRepository(ctrl *gomock.Controller) *MockResourceRepository {
+	mock := &MockResourceRepository{ctrl: ctrl}
+	mock.recorder = &MockResourceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceRepository) EXPECT() *MockResourceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Compartment mocks base method.
+func (m *MockResourceRepository) Compartment(ctx context.Context, query domain.CompartmentQuery) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compartment", ctx, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Compartment indicates an expected call of Compartment.
+func (mr *MockResourceRepositoryMockRecorder) Compartment(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compartment", reflect.TypeOf((*MockResourceRepository)(nil).Compartment), ctx, query)
+}
+
+// Create mocks base method.
+func (m *MockResourceRepository) Create(ctx context.Context, resource *domain.Resource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, resource)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockResourceRepositoryMockRecorder) Create(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockResourceRepository)(nil).Create), ctx, resource)
+}
+
+// Delete mocks base method.
+func (m *MockResourceRepository) Delete(ctx context.Context, id uint, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockResourceRepositoryMockRecorder) Delete(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockResourceRepository)(nil).Delete), ctx, id, expectedVersion)
+}
+
+// GetByLogicalID mocks base method.
+func (m *MockResourceRepository) GetByLogicalID(ctx context.Context, resourceType, logicalID string) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalID", ctx, resourceType, logicalID)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalID indicates an expected call of GetByLogicalID.
+func (mr *MockResourceRepositoryMockRecorder) GetByLogicalID(ctx, resourceType, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalID", reflect.TypeOf((*MockResourceRepository)(nil).GetByLogicalID), ctx, resourceType, logicalID)
+}
+
+// GetByLogicalIDs mocks base method.
+func (m *MockResourceRepository) GetByLogicalIDs(ctx context.Context, resourceType string, logicalIDs []string) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalIDs", ctx, resourceType, logicalIDs)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalIDs indicates an expected call of GetByLogicalIDs.
+func (mr *MockResourceRepositoryMockRecorder) GetByLogicalIDs(ctx, resourceType, logicalIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalIDs", reflect.TypeOf((*MockResourceRepository)(nil).GetByLogicalIDs), ctx, resourceType, logicalIDs)
+}
+
+// Referencing mocks base method.
+func (m *MockResourceRepository) Referencing(ctx context.Context, resourceType, param string, references []string, limit int) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Referencing", ctx, resourceType, param, references, limit)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Referencing indicates an expected call of Referencing.
+func (mr *MockResourceRepositoryMockRecorder) Referencing(ctx, resourceType, param, references, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Referencing", reflect.TypeOf((*MockResourceRepository)(nil).Referencing), ctx, resourceType, param, references, limit)
+}
+
+// Search mocks base method.
+func (m *MockResourceRepository) Search(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockResourceRepositoryMockRecorder) Search(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockResourceRepository)(nil).Search), ctx, resourceType, query)
+}
+
+// Update mocks base method.
+func (m *MockResourceRepository) Update(ctx context.Context, resource *domain.Resource, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, resource, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockResourceRepositoryMockRecorder) Update(ctx, resource, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockResourceRepository)(nil).Update), ctx, resource, expectedVersion)
+}
+
+// MockResourceService is a mock of ResourceService interface.
+type MockResourceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockResourceServiceMockRecorder is the mock recorder for MockResourceService.
+type MockResourceServiceMockRecorder struct {
+	mock *MockResourceService
+}
+
+// NewMockResourceService creates a new mock instance.
+func NewMockResourceService(ctrl *gomock.Controller) *MockResourceService {
+	mock := &MockResourceService{ctrl: ctrl}
+	mock.recorder = &MockResourceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceService) EXPECT() *MockResourceServiceMockRecorder {
+	return m.recorder
+}
+
+// ConvertToFHIR mocks base method.
+func (m *MockResourceService) ConvertToFHIR(ctx context.Context, resource *domain.Resource) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertToFHIR", ctx, resource)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertToFHIR indicates an expected call of ConvertToFHIR.
+func (mr *MockResourceServiceMockRecorder) ConvertToFHIR(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertToFHIR", reflect.TypeOf((*MockResourceService)(nil).ConvertToFHIR), ctx, resource)
+}
+
+// CreateResource mocks base method.
+func (m *MockResourceService) CreateResource(ctx context.Context, resourceType string, data []byte) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResource", ctx, resourceType, data)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateResource indicates an expected call of CreateResource.
+func (mr *MockResourceServiceMockRecorder) CreateResource(ctx, resourceType, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockResourceService)(nil).CreateResource), ctx, resourceType, data)
+}
+
+// DeleteResource mocks base method.
+func (m *MockResourceService) DeleteResource(ctx context.Context, resourceType, id string, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteResource", ctx, resourceType, id, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteResource indicates an expected call of DeleteResource.
+func (mr *MockResourceServiceMockRecorder) DeleteResource(ctx, resourceType, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResource", reflect.TypeOf((*MockResourceService)(nil).DeleteResource), ctx, resourceType, id, expectedVersion)
+}
+
+// GetResource mocks base method.
+func (m *MockResourceService) GetResource(ctx context.Context, resourceType, id string) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResource", ctx, resourceType, id)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResource indicates an expected call of GetResource.
+func (mr *MockResourceServiceMockRecorder) GetResource(ctx, resourceType, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResource", reflect.TypeOf((*MockResourceService)(nil).GetResource), ctx, resourceType, id)
+}
+
+// SearchResources mocks base method.
+func (m *MockResourceService) SearchResources(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchResources", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchResources indicates an expected call of SearchResources.
+func (mr *MockResourceServiceMockRecorder) SearchResources(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchResources", reflect.TypeOf((*MockResourceService)(nil).SearchResources), ctx, resourceType, query)
+}
+
+// UpdateResource mocks base method.
+func (m *MockResourceService) UpdateResource(ctx context.Context, resourceType, id string, data []byte, expectedVersion int) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateResource", ctx, resourceType, id, data, expectedVersion)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateResource indicates an expected call of UpdateResource.
+func (mr *MockResourceServiceMockRecorder) UpdateResource(ctx, resourceType, id, data, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockResourceService)(nil).UpdateResource), ctx, resourceType, id, data, expectedVersion)
+}
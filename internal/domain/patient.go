@@ -4,6 +4,9 @@ import (
 	"context"
 	"time"
 
+	"go-fhir-demo/pkg/fhirmatch"
+	"go-fhir-demo/pkg/fhirsearch"
+
 	"github.com/samply/golang-fhir-models/fhir-models/fhir"
 	"gorm.io/gorm"
 )
@@ -11,40 +14,185 @@ import (
 // Patient represents a FHIR Patient resource in the database
 type Patient struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	FHIRData  []byte         `json:"fhir_data" gorm:"type:jsonb;not null"` // Store FHIR JSON as bytes to avoid invalid UTF-8
+	LogicalID string         `json:"logical_id" gorm:"type:varchar(64);uniqueIndex"` // FHIR resource id, also stored in FHIRData
+	FHIRData  []byte         `json:"fhir_data" gorm:"type:jsonb;not null"`           // Store FHIR JSON as bytes to avoid invalid UTF-8
 	Active    *bool          `json:"active" gorm:"index"`
 	Family    string         `json:"family" gorm:"index"`
 	Given     string         `json:"given" gorm:"index"`
 	Gender    string         `json:"gender" gorm:"type:varchar(20);index"`
 	BirthDate *time.Time     `json:"birth_date" gorm:"index"`
+	VersionID int            `json:"version_id" gorm:"not null;default:1"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Duplicates []PatientMatch `json:"-" gorm:"-"`              // Probable duplicates found when the patient was created; not stored
+	Score      float64        `json:"-" gorm:"->;-:migration"` // Relevance of a _content or _text match; not stored
+}
+
+// PatientHistory stores a snapshot of a Patient resource for every version written
+type PatientHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PatientID uint      `json:"patient_id" gorm:"not null;uniqueIndex:idx_patient_history_version"`
+	VersionID int       `json:"version_id" gorm:"not null;uniqueIndex:idx_patient_history_version"`
+	LogicalID string    `json:"logical_id" gorm:"type:varchar(64);index"`
+	Method    string    `json:"method" gorm:"type:varchar(10);not null"` // HTTP verb of the interaction
+	FHIRData  []byte    `json:"fhir_data" gorm:"type:jsonb"`             // Empty for deletions
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// PatientIdentifier is one Patient.identifier of a stored patient, kept in a side table so
+// patients can be looked up by system and value
+type PatientIdentifier struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	PatientID uint   `json:"patient_id" gorm:"not null;index"`
+	System    string `json:"system" gorm:"not null;default:'';index:idx_patient_identifiers_lookup"` // Empty when the identifier has no system
+	Value     string `json:"value" gorm:"not null;index:idx_patient_identifiers_lookup"`
+}
+
+// HistoryQuery filters patient history entries
+type HistoryQuery struct {
+	PatientID string // Logical id of the patient; empty selects history across all patients
+	Since     *time.Time
+	Count     int
+	Offset    int
+}
+
+// PatientPage is one page of patient search results. Total is -1 when the matches were not
+// counted. Next and Previous are the cursors of the adjacent pages, nil when there is none.
+type PatientPage struct {
+	Patients []*Patient
+	Total    int64
+	Next     *fhirsearch.Cursor
+	Previous *fhirsearch.Cursor
+}
+
+// MatchCriteria selects the candidate patients a patient is compared with by $match. A
+// patient is a candidate when it agrees with any of the criteria.
+type MatchCriteria struct {
+	BirthDate      *time.Time
+	FamilyPrefixes []string // Prefixes of the family names
+	GivenPrefixes  []string // Prefixes of the given names, only matched within the year of BirthDate
+	Identifiers    []PatientIdentifier
+}
+
+// PatientMatch is a candidate patient scored against the patient being matched
+type PatientMatch struct {
+	Patient *Patient
+	Score   float64
+	Grade   fhirmatch.Grade
 }
 
 // PatientRepository defines the interface for patient data operations
 type PatientRepository interface {
 	Create(ctx context.Context, patient *Patient) error
+	CreateBatch(ctx context.Context, patients []*Patient) error
 	GetByID(ctx context.Context, id uint) (*Patient, error)
+	GetByLogicalID(ctx context.Context, logicalID string) (*Patient, error)
 	GetAll(ctx context.Context, limit, offset int) ([]*Patient, error)
-	Update(ctx context.Context, patient *Patient) error
-	Delete(ctx context.Context, id uint) error
+	Search(ctx context.Context, query *fhirsearch.Query) (*PatientPage, error)
+	Update(ctx context.Context, patient *Patient, expectedVersion int) error
+	Delete(ctx context.Context, id uint, expectedVersion int) error
+	Undelete(ctx context.Context, logicalID string) (*Patient, error)
+	Expunge(ctx context.Context, logicalID string, history bool) (int64, error)
 	Count(ctx context.Context) (int64, error)
+	SearchCount(ctx context.Context, query *fhirsearch.Query) (int64, error)
+	History(ctx context.Context, query HistoryQuery) ([]*PatientHistory, int64, error)
+	GetVersion(ctx context.Context, logicalID string, versionID int) (*PatientHistory, error)
+	MatchCandidates(ctx context.Context, criteria MatchCriteria, limit int) ([]*Patient, error)
+	Transaction(ctx context.Context, fn func(repo PatientRepository) error) error
+}
+
+// IDGenerator assigns logical ids to new resources
+type IDGenerator interface {
+	NextID(ctx context.Context) (string, error)
 }
 
 // PatientService defines the interface for patient business logic
 type PatientService interface {
 	CreatePatient(ctx context.Context, fhirPatient *fhir.Patient) (*Patient, error)
-	GetPatient(ctx context.Context, id uint) (*Patient, error)
+	GetPatient(ctx context.Context, id string) (*Patient, error)
 	GetPatients(ctx context.Context, limit, offset int) ([]*Patient, int64, error)
-	UpdatePatient(ctx context.Context, id uint, fhirPatient *fhir.Patient) (*Patient, error)
-	PatchPatient(ctx context.Context, id uint, updates map[string]interface{}) (*Patient, error)
-	DeletePatient(ctx context.Context, id uint) error
+	SearchPatients(ctx context.Context, query *fhirsearch.Query) (*PatientPage, error)
+	CountPatients(ctx context.Context, query *fhirsearch.Query) (int64, error)
+	UpdatePatient(ctx context.Context, id string, fhirPatient *fhir.Patient, expectedVersion int) (*Patient, error)
+	PatchPatient(ctx context.Context, id string, updates map[string]interface{}, expectedVersion int) (*Patient, error)
+	JSONPatchPatient(ctx context.Context, id string, patch []byte, expectedVersion int) (*Patient, error)
+	FHIRPathPatchPatient(ctx context.Context, id string, parameters []byte, expectedVersion int) (*Patient, error)
+	DeletePatient(ctx context.Context, id string, expectedVersion int) error
+	UndeletePatient(ctx context.Context, id string) (*Patient, error)
+	ExpungePatient(ctx context.Context, id string, history bool) (int64, error)
+	ConditionalCreatePatient(ctx context.Context, fhirPatient *fhir.Patient, criteria *fhirsearch.Query) (*Patient, bool, error)
+	ConditionalUpdatePatient(ctx context.Context, criteria *fhirsearch.Query, fhirPatient *fhir.Patient) (*Patient, bool, error)
+	ConditionalDeletePatient(ctx context.Context, criteria *fhirsearch.Query) error
+	GetPatientHistory(ctx context.Context, query HistoryQuery) ([]*PatientHistory, int64, error)
+	GetPatientVersion(ctx context.Context, id string, versionID int) (*PatientHistory, error)
+	ConvertHistoryToFHIR(ctx context.Context, entry *PatientHistory) (*fhir.Patient, error)
 	ConvertToFHIR(ctx context.Context, patient *Patient) (*fhir.Patient, error)
 	ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.Patient) (*Patient, error)
+	ValidatePatient(ctx context.Context, resource []byte, profiles []string) ([]fhir.OperationOutcomeIssue, error)
+	PreparePatient(ctx context.Context, resource []byte) (*Patient, error)
+	MatchPatient(ctx context.Context, fhirPatient *fhir.Patient, onlyCertainMatches bool, count int) ([]PatientMatch, error)
+}
+
+// PatientSummaryElements lists the summary elements of Patient returned for _summary=true
+var PatientSummaryElements = []string{
+	"identifier", "active", "name", "telecom", "gender", "birthDate", "deceased", "address", "managingOrganization", "link",
+}
+
+// PatientSearchParameters lists the FHIR search parameters supported for Patient
+var PatientSearchParameters = []fhirsearch.Definition{
+	{Name: "_id", Type: fhirsearch.TypeToken, Description: "Logical id of this artifact", Sortable: true},
+	{Name: "_lastUpdated", Type: fhirsearch.TypeDate, Description: "When the resource version last changed", Sortable: true},
+	{Name: "_content", Type: fhirsearch.TypeString, Description: "Words in the patient's names, telecom, addresses, contacts, notes or narrative"},
+	{Name: "_text", Type: fhirsearch.TypeString, Description: "Words in the patient's narrative"},
+	{Name: "identifier", Type: fhirsearch.TypeToken, Description: "A patient identifier, as system|value", Paths: []string{"identifier"}},
+	{Name: "name", Type: fhirsearch.TypeString, Description: "A portion of either family or given name of the patient"},
+	{Name: "family", Type: fhirsearch.TypeString, Description: "A portion of the family name of the patient", Sortable: true},
+	{Name: "given", Type: fhirsearch.TypeString, Description: "A portion of the given name of the patient", Sortable: true},
+	{
+		Name:        "gender",
+		Type:        fhirsearch.TypeToken,
+		Description: "Gender of the patient",
+		Codes:       []string{"male", "female", "other", "unknown"},
+	},
+	{Name: "birthdate", Type: fhirsearch.TypeDate, Description: "The patient's date of birth", Sortable: true},
+	{
+		Name:        "active",
+		Type:        fhirsearch.TypeToken,
+		Description: "Whether the patient record is active",
+		Codes:       []string{"true", "false"},
+	},
+}
+
+// PatientIncludeParameters lists the reference parameters of Patient that _include can follow
+var PatientIncludeParameters = []fhirsearch.Definition{
+	{
+		Name:        "organization",
+		Type:        fhirsearch.TypeReference,
+		Description: "The organization that is the custodian of the patient record",
+		Paths:       []string{"managingOrganization"},
+		Target:      "Organization",
+	},
+	{
+		Name:        "general-practitioner",
+		Type:        fhirsearch.TypeReference,
+		Description: "Patient's nominated general practitioner",
+		Paths:       []string{"generalPractitioner"},
+	},
 }
 
 // TableName specifies the table name for Patient model
 func (Patient) TableName() string {
 	return "patients"
 }
+
+// TableName specifies the table name for PatientIdentifier model
+func (PatientIdentifier) TableName() string {
+	return "patient_identifiers"
+}
+
+// TableName specifies the table name for PatientHistory model
+func (PatientHistory) TableName() string {
+	return "patient_history"
+}
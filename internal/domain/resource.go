@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"gorm.io/gorm"
+)
+
+// Resource is a FHIR resource of a type without a dedicated model, stored as JSONB
+type Resource struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ResourceType string         `json:"resource_type" gorm:"type:varchar(64);not null;uniqueIndex:idx_resources_type_logical_id"`
+	LogicalID    string         `json:"logical_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_resources_type_logical_id"`
+	FHIRData     []byte         `json:"fhir_data" gorm:"type:jsonb;not null"`
+	VersionID    int            `json:"version_id" gorm:"not null;default:1"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// ResourceSearchValue is one search parameter value extracted from a stored resource
+type ResourceSearchValue struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ResourceID uint       `json:"resource_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(64);not null;index:idx_resource_search_values_lookup"`
+	System     string     `json:"system"`                                               // Token system
+	Value      string     `json:"value" gorm:"index:idx_resource_search_values_lookup"` // String, token code or Type/id reference
+	DateStart  *time.Time `json:"date_start"`                                           // Null when the date range has no lower bound
+	DateEnd    *time.Time `json:"date_end"`                                             // Null when the date range has no upper bound
+}
+
+// ResourceRepository defines the interface for generic resource data operations
+type ResourceRepository interface {
+	Create(ctx context.Context, resource *Resource) error
+	GetByLogicalID(ctx context.Context, resourceType, logicalID string) (*Resource, error)
+	Search(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*Resource, int64, error)
+	Update(ctx context.Context, resource *Resource, expectedVersion int) error
+	Delete(ctx context.Context, id uint, expectedVersion int) error
+	Compartment(ctx context.Context, query CompartmentQuery) ([]*Resource, int64, error)
+	GetByLogicalIDs(ctx context.Context, resourceType string, logicalIDs []string) ([]*Resource, error)
+	Referencing(ctx context.Context, resourceType, param string, references []string, limit int) ([]*Resource, error)
+}
+
+// ResourceService defines the interface for generic resource business logic
+type ResourceService interface {
+	CreateResource(ctx context.Context, resourceType string, data []byte) (*Resource, error)
+	GetResource(ctx context.Context, resourceType, id string) (*Resource, error)
+	SearchResources(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*Resource, int64, error)
+	UpdateResource(ctx context.Context, resourceType, id string, data []byte, expectedVersion int) (*Resource, error)
+	DeleteResource(ctx context.Context, resourceType, id string, expectedVersion int) error
+	ConvertToFHIR(ctx context.Context, resource *Resource) ([]byte, error)
+}
+
+// ResourceDefinition describes a resource type served by the generic resource store
+type ResourceDefinition struct {
+	Type       string
+	Collection string // Path segment of the type's endpoints under /api/v1
+	// SearchParameters lists the supported search parameters and the elements their values are extracted from
+	SearchParameters []fhirsearch.Definition
+	// New returns an empty model of the type, used to check the structure of submitted resources
+	New func() interface{}
+	// Compartment lists the reference search parameters that place a resource in the
+	// compartment of the patient it refers to; empty when the type has no patient compartment
+	Compartment []string
+}
+
+// ResourceDefinitions lists the resource types served by the generic resource store, by type
+var ResourceDefinitions = map[string]ResourceDefinition{
+	"Observation": {
+		Type:        "Observation",
+		Collection:  "observations",
+		New:         func() interface{} { return &fhir.Observation{} },
+		Compartment: []string{"subject", "performer"},
+		SearchParameters: withCommonParameters(
+			fhirsearch.Definition{Name: "identifier", Type: fhirsearch.TypeToken, Description: "The unique id for a particular observation", Paths: []string{"identifier"}},
+			fhirsearch.Definition{
+				Name:        "status",
+				Type:        fhirsearch.TypeToken,
+				Description: "The status of the observation",
+				Codes:       []string{"registered", "preliminary", "final", "amended", "corrected", "cancelled", "entered-in-error", "unknown"},
+				Paths:       []string{"status"},
+			},
+			fhirsearch.Definition{Name: "code", Type: fhirsearch.TypeToken, Description: "The code of the observation type", Paths: []string{"code"}},
+			fhirsearch.Definition{Name: "category", Type: fhirsearch.TypeToken, Description: "The classification of the type of observation", Paths: []string{"category"}},
+			fhirsearch.Definition{Name: "subject", Type: fhirsearch.TypeReference, Description: "The subject that the observation is about", Paths: []string{"subject"}},
+			fhirsearch.Definition{Name: "patient", Type: fhirsearch.TypeReference, Description: "The subject that the observation is about, if a patient", Paths: []string{"subject"}, Target: "Patient"},
+			fhirsearch.Definition{Name: "encounter", Type: fhirsearch.TypeReference, Description: "Encounter related to the observation", Paths: []string{"encounter"}, Target: "Encounter"},
+			fhirsearch.Definition{Name: "performer", Type: fhirsearch.TypeReference, Description: "Who performed the observation", Paths: []string{"performer"}},
+			fhirsearch.Definition{
+				Name:        "date",
+				Type:        fhirsearch.TypeDate,
+				Description: "Obtained date/time, or the period it was obtained in",
+				Paths:       []string{"effectiveDateTime", "effectivePeriod", "effectiveInstant"},
+			},
+		),
+	},
+	"Encounter": {
+		Type:        "Encounter",
+		Collection:  "encounters",
+		New:         func() interface{} { return &fhir.Encounter{} },
+		Compartment: []string{"subject"},
+		SearchParameters: withCommonParameters(
+			fhirsearch.Definition{Name: "identifier", Type: fhirsearch.TypeToken, Description: "Identifier(s) by which this encounter is known", Paths: []string{"identifier"}},
+			fhirsearch.Definition{
+				Name:        "status",
+				Type:        fhirsearch.TypeToken,
+				Description: "The status of the encounter",
+				Codes:       []string{"planned", "arrived", "triaged", "in-progress", "onleave", "finished", "cancelled", "entered-in-error", "unknown"},
+				Paths:       []string{"status"},
+			},
+			fhirsearch.Definition{Name: "class", Type: fhirsearch.TypeToken, Description: "Classification of the encounter", Paths: []string{"class"}},
+			fhirsearch.Definition{Name: "type", Type: fhirsearch.TypeToken, Description: "Specific type of encounter", Paths: []string{"type"}},
+			fhirsearch.Definition{Name: "subject", Type: fhirsearch.TypeReference, Description: "The patient or group present at the encounter", Paths: []string{"subject"}},
+			fhirsearch.Definition{Name: "patient", Type: fhirsearch.TypeReference, Description: "The patient present at the encounter", Paths: []string{"subject"}, Target: "Patient"},
+			fhirsearch.Definition{Name: "participant", Type: fhirsearch.TypeReference, Description: "Persons involved in the encounter other than the patient", Paths: []string{"participant.individual"}},
+			fhirsearch.Definition{Name: "practitioner", Type: fhirsearch.TypeReference, Description: "Practitioners involved in the encounter", Paths: []string{"participant.individual"}, Target: "Practitioner"},
+			fhirsearch.Definition{Name: "service-provider", Type: fhirsearch.TypeReference, Description: "The organization responsible for the encounter", Paths: []string{"serviceProvider"}, Target: "Organization"},
+			fhirsearch.Definition{Name: "date", Type: fhirsearch.TypeDate, Description: "The period of the encounter", Paths: []string{"period"}},
+		),
+	},
+	"Practitioner": {
+		Type:       "Practitioner",
+		Collection: "practitioners",
+		New:        func() interface{} { return &fhir.Practitioner{} },
+		SearchParameters: withCommonParameters(
+			fhirsearch.Definition{Name: "identifier", Type: fhirsearch.TypeToken, Description: "A practitioner's identifier", Paths: []string{"identifier"}},
+			fhirsearch.Definition{Name: "name", Type: fhirsearch.TypeString, Description: "A portion of the practitioner's name", Paths: []string{"name"}},
+			fhirsearch.Definition{Name: "family", Type: fhirsearch.TypeString, Description: "A portion of the family name", Paths: []string{"name.family"}},
+			fhirsearch.Definition{Name: "given", Type: fhirsearch.TypeString, Description: "A portion of the given name", Paths: []string{"name.given"}},
+			fhirsearch.Definition{Name: "gender", Type: fhirsearch.TypeToken, Description: "Gender of the practitioner", Codes: []string{"male", "female", "other", "unknown"}, Paths: []string{"gender"}},
+			fhirsearch.Definition{Name: "active", Type: fhirsearch.TypeToken, Description: "Whether the practitioner record is active", Codes: []string{"true", "false"}, Paths: []string{"active"}},
+			fhirsearch.Definition{Name: "telecom", Type: fhirsearch.TypeToken, Description: "The value in any kind of contact", Paths: []string{"telecom"}},
+			fhirsearch.Definition{Name: "address", Type: fhirsearch.TypeString, Description: "A portion of any part of the address", Paths: []string{"address"}},
+		),
+	},
+	"Organization": {
+		Type:       "Organization",
+		Collection: "organizations",
+		New:        func() interface{} { return &fhir.Organization{} },
+		SearchParameters: withCommonParameters(
+			fhirsearch.Definition{Name: "identifier", Type: fhirsearch.TypeToken, Description: "Any identifier for the organization", Paths: []string{"identifier"}},
+			fhirsearch.Definition{Name: "name", Type: fhirsearch.TypeString, Description: "A portion of the organization's name or alias", Paths: []string{"name", "alias"}},
+			fhirsearch.Definition{Name: "active", Type: fhirsearch.TypeToken, Description: "Whether the organization record is active", Codes: []string{"true", "false"}, Paths: []string{"active"}},
+			fhirsearch.Definition{Name: "type", Type: fhirsearch.TypeToken, Description: "A code for the type of organization", Paths: []string{"type"}},
+			fhirsearch.Definition{Name: "partof", Type: fhirsearch.TypeReference, Description: "The organization this organization is part of", Paths: []string{"partOf"}, Target: "Organization"},
+			fhirsearch.Definition{Name: "address", Type: fhirsearch.TypeString, Description: "A portion of any part of the address", Paths: []string{"address"}},
+		),
+	},
+}
+
+// ResourceTypes returns the types served by the generic resource store in name order
+func ResourceTypes() []string {
+	types := make([]string, 0, len(ResourceDefinitions))
+	for resourceType := range ResourceDefinitions {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// PatientRevIncludes lists the _revinclude directives patient search supports: every reference
+// parameter of a served type that can refer to a Patient, in type order
+func PatientRevIncludes() []fhirsearch.Include {
+	var includes []fhirsearch.Include
+	for _, resourceType := range ResourceTypes() {
+		for _, def := range ResourceDefinitions[resourceType].SearchParameters {
+			if def.Type == fhirsearch.TypeReference && (def.Target == "" || def.Target == "Patient") {
+				includes = append(includes, fhirsearch.Include{Source: resourceType, Param: def.Name})
+			}
+		}
+	}
+	return includes
+}
+
+// withCommonParameters adds the parameters every resource type supports. _id is matched
+// against the logical id column rather than extracted values.
+func withCommonParameters(defs ...fhirsearch.Definition) []fhirsearch.Definition {
+	common := fhirsearch.Definition{Name: "_id", Type: fhirsearch.TypeToken, Description: "Logical id of this artifact"}
+	return append([]fhirsearch.Definition{common}, defs...)
+}
+
+// TableName specifies the table name for Resource model
+func (Resource) TableName() string {
+	return "resources"
+}
+
+// TableName specifies the table name for ResourceSearchValue model
+func (ResourceSearchValue) TableName() string {
+	return "resource_search_values"
+}
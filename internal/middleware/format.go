@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-fhir-demo/internal/api/outcome"
+	"go-fhir-demo/pkg/fhirxml"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+const (
+	// FHIRJSONContentType is the content type of JSON responses
+	FHIRJSONContentType = "application/fhir+json; fhirVersion=4.0"
+	// FHIRXMLContentType is the content type of XML responses
+	FHIRXMLContentType = "application/fhir+xml; fhirVersion=4.0"
+)
+
+// format is a representation of FHIR resources
+type format int
+
+const (
+	formatJSON format = iota
+	formatXML
+)
+
+// formatMediaTypes maps the media types and _format values of each format
+var formatMediaTypes = map[string]format{
+	"json":                  formatJSON,
+	"application/json":      formatJSON,
+	"application/fhir+json": formatJSON,
+	"xml":                   formatXML,
+	"text/xml":              formatXML,
+	"application/xml":       formatXML,
+	"application/fhir+xml":  formatXML,
+}
+
+// FHIRMediaTypes are the media types of the supported FHIR formats
+var FHIRMediaTypes = []string{"application/fhir+json", "application/fhir+xml"}
+
+// jsonPatchMediaTypes are the JSON request bodies accepted besides FHIR resources
+var jsonPatchMediaTypes = []string{"application/json-patch+json", "application/merge-patch+json"}
+
+// ndjsonMediaTypes are the NDJSON request bodies of bulk data uploads, passed on unchanged
+var ndjsonMediaTypes = []string{"application/fhir+ndjson", "application/ndjson", "application/x-ndjson"}
+
+// FHIRFormat negotiates the representation of FHIR requests and responses. The response
+// format is taken from the _format parameter, or else the Accept header, and is 406 Not
+// Acceptable when neither names a supported format. XML request bodies are converted to
+// JSON before the handler reads them, and other unsupported bodies are 415 Unsupported
+// Media Type. JSON responses are converted to XML when XML was asked for, and indented when
+// _pretty=true.
+func FHIRFormat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &formatWriter{ResponseWriter: c.Writer, format: formatJSON}
+		c.Writer = writer
+		defer func() {
+			writer.flush(c)
+			c.Writer = writer.ResponseWriter
+		}()
+
+		responseFormat, ok := negotiateFormat(c.Query("_format"), c.GetHeader("Accept"))
+		if !ok {
+			outcome.Write(c, http.StatusNotAcceptable, fhir.IssueTypeNotSupported,
+				"None of the requested formats is supported; use application/fhir+json or application/fhir+xml")
+			c.Abort()
+			return
+		}
+		writer.format = responseFormat
+		writer.pretty = c.Query("_pretty") == "true"
+
+		if !convertRequestBody(c) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// negotiateFormat picks the response format from _format, or else from the Accept header
+// by quality. An absent Accept header or a wildcard selects JSON.
+func negotiateFormat(formatParam, accept string) (format, bool) {
+	if formatParam != "" {
+		mediaType, _, err := mime.ParseMediaType(formatParam)
+		if err != nil {
+			mediaType = strings.TrimSpace(formatParam)
+		}
+		f, ok := formatMediaTypes[strings.ToLower(mediaType)]
+		return f, ok
+	}
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+
+	best, bestQuality := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if version, ok := params["fhirversion"]; ok && !strings.HasPrefix(version, "4.0") {
+			continue
+		}
+		f, ok := formatMediaTypes[mediaType]
+		if !ok && (mediaType == "*/*" || mediaType == "application/*") {
+			f, ok = formatJSON, true
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = f, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+// convertRequestBody converts an XML request body to JSON and rejects bodies of any other
+// unsupported type. A request without a Content-Type is read as JSON. It reports whether
+// the request can go on to the handler.
+func convertRequestBody(c *gin.Context) bool {
+	contentType := c.GetHeader("Content-Type")
+	if c.Request.Body == nil || c.Request.ContentLength == 0 || contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		outcome.Write(c, http.StatusUnsupportedMediaType, fhir.IssueTypeNotSupported, "Invalid Content-Type "+contentType)
+		return false
+	}
+	f, ok := formatMediaTypes[mediaType]
+	switch {
+	case ok && f == formatJSON, slices.Contains(jsonPatchMediaTypes, mediaType), slices.Contains(ndjsonMediaTypes, mediaType):
+		return true
+	case !ok:
+		outcome.Write(c, http.StatusUnsupportedMediaType, fhir.IssueTypeNotSupported,
+			"Unsupported Content-Type "+mediaType+"; use application/fhir+json or application/fhir+xml")
+		return false
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeInvalid, "Failed to read request body")
+		return false
+	}
+	converted, err := fhirxml.Unmarshal(body)
+	if err != nil {
+		logger.WithContext(c.Request.Context()).Warnf("Invalid XML request body: %v", err)
+		outcome.Write(c, http.StatusBadRequest, fhir.IssueTypeStructure, "Invalid XML resource: "+err.Error())
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(converted))
+	c.Request.ContentLength = int64(len(converted))
+	c.Request.Header.Set("Content-Type", "application/fhir+json")
+	return true
+}
+
+// formatWriter sets the FHIR content type on JSON responses and holds them back when they
+// are to be converted to XML or indented. Other responses pass through unchanged.
+type formatWriter struct {
+	gin.ResponseWriter
+	format  format
+	pretty  bool // Whether _pretty asked for indented output
+	decided bool // Whether the first write has decided how the response is written
+	buffer  *bytes.Buffer
+}
+
+// decide inspects the content type the handler set before the first byte is written
+func (w *formatWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if mediaType != "application/json" && mediaType != "application/fhir+json" {
+		return
+	}
+	if w.format == formatXML || w.pretty {
+		w.buffer = &bytes.Buffer{}
+		return
+	}
+	w.Header().Set("Content-Type", FHIRJSONContentType)
+}
+
+func (w *formatWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffer != nil {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *formatWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports a held back response as written so nothing else is written after it
+func (w *formatWriter) Written() bool {
+	return w.buffer != nil || w.ResponseWriter.Written()
+}
+
+// flush writes a held back response as XML or indented JSON. A response that cannot be
+// converted, such as one that is not a resource, is written as JSON.
+func (w *formatWriter) flush(c *gin.Context) {
+	if w.buffer == nil {
+		return
+	}
+	body := w.buffer.Bytes()
+	w.buffer = nil
+	indent := ""
+	if w.pretty {
+		indent = "  "
+	}
+
+	if w.format == formatXML {
+		converted, err := fhirxml.MarshalIndent(body, indent)
+		if err == nil {
+			w.Header().Set("Content-Type", FHIRXMLContentType)
+			_, _ = w.ResponseWriter.Write(converted)
+			return
+		}
+		logger.WithContext(c.Request.Context()).Warnf("Failed to convert response to XML: %v", err)
+	}
+	if w.pretty {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", indent); err == nil {
+			body = indented.Bytes()
+		}
+	}
+	w.Header().Set("Content-Type", FHIRJSONContentType)
+	_, _ = w.ResponseWriter.Write(body)
+}
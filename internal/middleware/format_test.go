@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FormatMiddlewareTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	// The body and Content-Type the create handler received, nil when it was not called
+	received    []byte
+	contentType string
+}
+
+func (suite *FormatMiddlewareTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.received, suite.contentType = nil, ""
+	router := gin.New()
+	router.Use(FHIRFormat())
+	router.GET("/patients/:id", func(c *gin.Context) {
+		id, family := c.Param("id"), "Baker"
+		c.JSON(http.StatusOK, fhir.Patient{Id: &id, Name: []fhir.HumanName{{Family: &family, Given: []string{"Ann"}}}})
+	})
+	router.GET("/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/file", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/fhir+ndjson", []byte(`{"resourceType":"Patient"}`+"\n"))
+	})
+	router.POST("/patients", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		suite.Require().NoError(err)
+		suite.received, suite.contentType = body, c.GetHeader("Content-Type")
+		c.Data(http.StatusCreated, "application/fhir+json", body)
+	})
+	suite.router = router
+}
+
+func TestFormatMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(FormatMiddlewareTestSuite))
+}
+
+func (suite *FormatMiddlewareTestSuite) request(method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestJSONByDefault tests that JSON responses carry the FHIR JSON content type with the version
+func (suite *FormatMiddlewareTestSuite) TestJSONByDefault() {
+	for _, accept := range []string{"", "*/*", "application/json", "application/fhir+json; fhirVersion=4.0"} {
+		w := suite.request("GET", "/patients/p1", map[string]string{"Accept": accept}, "")
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code, accept)
+		assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"), accept)
+		assert.JSONEq(suite.T(), `{"resourceType":"Patient","id":"p1","name":[{"family":"Baker","given":["Ann"]}]}`, w.Body.String(), accept)
+	}
+}
+
+// TestFormatParameterOverridesAccept tests that _format wins over the Accept header
+func (suite *FormatMiddlewareTestSuite) TestFormatParameterOverridesAccept() {
+	w := suite.request("GET", "/patients/p1?_format=xml", map[string]string{"Accept": "application/fhir+json"}, "")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/fhir+xml; fhirVersion=4.0", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<Patient xmlns="http://hl7.org/fhir"><id value="p1"/><name><family value="Baker"/><given value="Ann"/></name></Patient>`,
+		w.Body.String())
+
+	w = suite.request("GET", "/patients/p1?_format=application/fhir%2Bjson", map[string]string{"Accept": "application/fhir+xml"}, "")
+
+	assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"))
+	assert.True(suite.T(), json.Valid(w.Body.Bytes()))
+}
+
+// TestAcceptQuality tests that the supported format with the highest quality is chosen
+func (suite *FormatMiddlewareTestSuite) TestAcceptQuality() {
+	cases := map[string]string{
+		"application/fhir+json;q=0.5, application/fhir+xml;q=0.9":            "application/fhir+xml; fhirVersion=4.0",
+		"application/fhir+xml;q=0.4, application/json":                       "application/fhir+json; fhirVersion=4.0",
+		"text/html, application/xml;q=0.8":                                   "application/fhir+xml; fhirVersion=4.0",
+		"application/fhir+xml;q=0, */*;q=0.1":                                "application/fhir+json; fhirVersion=4.0",
+		"application/fhir+xml; fhirVersion=3.0, application/fhir+json;q=0.2": "application/fhir+json; fhirVersion=4.0",
+	}
+	for accept, contentType := range cases {
+		w := suite.request("GET", "/patients/p1", map[string]string{"Accept": accept}, "")
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code, accept)
+		assert.Equal(suite.T(), contentType, w.Header().Get("Content-Type"), accept)
+	}
+}
+
+// TestNotAcceptable tests that a request naming no supported format is 406
+func (suite *FormatMiddlewareTestSuite) TestNotAcceptable() {
+	for _, path := range []string{"/patients/p1", "/patients/p1?_format=turtle"} {
+		w := suite.request("GET", path, map[string]string{"Accept": "text/html"}, "")
+
+		assert.Equal(suite.T(), http.StatusNotAcceptable, w.Code, path)
+		assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"), path)
+		assert.Contains(suite.T(), w.Body.String(), `"code":"not-supported"`, path)
+	}
+}
+
+// TestUnsupportedContentType tests that a body of an unsupported type is 415 and never reaches the handler
+func (suite *FormatMiddlewareTestSuite) TestUnsupportedContentType() {
+	for _, contentType := range []string{"text/plain", "application/x-www-form-urlencoded", "not a media type;"} {
+		w := suite.request("POST", "/patients", map[string]string{"Content-Type": contentType}, `resourceType=Patient`)
+
+		assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code, contentType)
+		assert.Contains(suite.T(), w.Body.String(), `"code":"not-supported"`, contentType)
+		assert.Nil(suite.T(), suite.received, contentType)
+	}
+}
+
+// TestXMLRequestBody tests that an XML body reaches the handler as FHIR JSON
+func (suite *FormatMiddlewareTestSuite) TestXMLRequestBody() {
+	body := `<Patient xmlns="http://hl7.org/fhir"><active value="true"/><name><family value="Baker"/></name></Patient>`
+
+	w := suite.request("POST", "/patients", map[string]string{"Content-Type": "application/fhir+xml"}, body)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), "application/fhir+json", suite.contentType)
+	assert.JSONEq(suite.T(), `{"resourceType":"Patient","active":true,"name":[{"family":"Baker"}]}`, string(suite.received))
+	assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"))
+}
+
+// TestInvalidXMLRequestBody tests that XML that is not a FHIR resource is 400
+func (suite *FormatMiddlewareTestSuite) TestInvalidXMLRequestBody() {
+	w := suite.request("POST", "/patients", map[string]string{"Content-Type": "application/xml"}, `<Patient><active value="true"/></Patient>`)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Invalid XML resource")
+	assert.Nil(suite.T(), suite.received)
+}
+
+// TestJSONRequestBodies tests that JSON, patch and NDJSON bodies pass through unchanged
+func (suite *FormatMiddlewareTestSuite) TestJSONRequestBodies() {
+	for _, contentType := range []string{"application/fhir+json", "application/json", "application/json-patch+json", "application/fhir+ndjson"} {
+		w := suite.request("POST", "/patients", map[string]string{"Content-Type": contentType}, `{"resourceType":"Patient"}`)
+
+		assert.Equal(suite.T(), http.StatusCreated, w.Code, contentType)
+		assert.Equal(suite.T(), contentType, suite.contentType, contentType)
+		assert.Equal(suite.T(), `{"resourceType":"Patient"}`, string(suite.received), contentType)
+	}
+}
+
+// TestPrettyJSON tests that _pretty=true indents JSON responses
+func (suite *FormatMiddlewareTestSuite) TestPrettyJSON() {
+	w := suite.request("GET", "/patients/p1?_pretty=true", nil, "")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `{
+  "id": "p1",
+  "name": [
+    {
+      "family": "Baker",
+      "given": [
+        "Ann"
+      ]
+    }
+  ],
+  "resourceType": "Patient"
+}`, w.Body.String())
+}
+
+// TestPrettyXML tests that _pretty=true puts each XML element on its own indented line
+func (suite *FormatMiddlewareTestSuite) TestPrettyXML() {
+	w := suite.request("GET", "/patients/p1?_pretty=true", map[string]string{"Accept": "application/fhir+xml"}, "")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/fhir+xml; fhirVersion=4.0", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `<?xml version="1.0" encoding="UTF-8"?>
+<Patient xmlns="http://hl7.org/fhir">
+  <id value="p1"/>
+  <name>
+    <family value="Baker"/>
+    <given value="Ann"/>
+  </name>
+</Patient>`, w.Body.String())
+}
+
+// TestPrettyOtherResponses tests that responses that are not resources are still written
+func (suite *FormatMiddlewareTestSuite) TestPrettyOtherResponses() {
+	w := suite.request("GET", "/status?_pretty=true&_format=xml", nil, "")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/fhir+json; fhirVersion=4.0", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "{\n  \"status\": \"ok\"\n}", w.Body.String())
+
+	w = suite.request("GET", "/file?_pretty=true", nil, "")
+
+	assert.Equal(suite.T(), "application/fhir+ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `{"resourceType":"Patient"}`+"\n", w.Body.String())
+}
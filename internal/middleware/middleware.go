@@ -3,6 +3,7 @@ package middleware
 import (
 	"time"
 
+	"go-fhir-demo/internal/api/outcome"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils/tracer"
 
@@ -97,14 +98,11 @@ func ErrorHandler() gin.HandlerFunc {
 		// Handle any errors that occurred during request processing
 		if len(c.Errors) > 0 {
 			err := c.Errors.Last()
-			logger.Errorf("Request error: %v", err.Err)
-
-			// Don't override status if it's already set
-			if c.Writer.Status() == 200 {
-				c.JSON(500, gin.H{
-					"error":   "Internal server error",
-					"message": err.Error(),
-				})
+			logger.WithContext(c.Request.Context()).Errorf("Request error: %v", err.Err)
+
+			// Don't override a response the handler already wrote
+			if !c.Writer.Written() {
+				outcome.Error(c, err.Err)
 			}
 		}
 	}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"gorm.io/gorm"
+)
+
+// BulkRepositoryInterface defines the contract for bulk data repository
+type BulkRepositoryInterface interface {
+	Create(ctx context.Context, job *domain.BulkJob) error
+	Get(ctx context.Context, id string) (*domain.BulkJob, error)
+	Update(ctx context.Context, job *domain.BulkJob) error
+	Delete(ctx context.Context, id string) error
+	FailInterrupted(ctx context.Context) (int64, error)
+	ExportPatients(ctx context.Context, query domain.ExportQuery) ([]*domain.Patient, error)
+	ExportResources(ctx context.Context, resourceType string, query domain.ExportQuery) ([]*domain.Resource, error)
+}
+
+// patientCompartmentMember selects resources that refer to any patient through one of the
+// compartment parameters of their type
+const patientCompartmentMember = "EXISTS (SELECT 1 FROM resource_search_values v " +
+	"WHERE v.resource_id = resources.id AND v.name IN ? AND v.value LIKE 'Patient/%')"
+
+type bulkRepository struct {
+	db *gorm.DB
+}
+
+// NewBulkRepository creates a new repository for bulk data jobs
+func NewBulkRepository(db *gorm.DB) BulkRepositoryInterface {
+	return &bulkRepository{db: db}
+}
+
+// Create stores a new bulk data job
+func (r *bulkRepository) Create(ctx context.Context, job *domain.BulkJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to create bulk %s job: %v", job.Kind, err)
+		return err
+	}
+	return nil
+}
+
+// Get retrieves a bulk data job, returning domain.ErrNotFound when it does not exist
+func (r *bulkRepository) Get(ctx context.Context, id string) (*domain.BulkJob, error) {
+	var job domain.BulkJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: bulk data job %s", domain.ErrNotFound, id)
+		}
+		logger.WithContext(ctx).Errorf("Failed to get bulk data job %s: %v", id, err)
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update stores the status, progress, output and error of a job. A job deleted in the
+// meantime stays deleted.
+func (r *bulkRepository) Update(ctx context.Context, job *domain.BulkJob) error {
+	err := r.db.WithContext(ctx).Model(&domain.BulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":   job.Status,
+		"progress": job.Progress,
+		"output":   job.Output,
+		"error":    job.Error,
+	}).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to update bulk data job %s: %v", job.ID, err)
+		return err
+	}
+	return nil
+}
+
+// Delete removes a bulk data job; deleting a job that does not exist is not an error
+func (r *bulkRepository) Delete(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&domain.BulkJob{}).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete bulk data job %s: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// FailInterrupted marks the jobs still in progress as failed. It is run at startup, when no
+// job can still be running, and returns the number of jobs marked.
+func (r *bulkRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&domain.BulkJob{}).
+		Where("status = ?", domain.BulkJobInProgress).
+		Updates(map[string]interface{}{"status": domain.BulkJobFailed, "error": "The server stopped while the job was running"})
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("Failed to fail interrupted bulk data jobs: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// ExportPatients reads the next batch of patients changed within the query's time range, in id order
+func (r *bulkRepository) ExportPatients(ctx context.Context, query domain.ExportQuery) ([]*domain.Patient, error) {
+	ctx, span := tracer.StartSpan(ctx, "ExportPatients")
+	defer span.End()
+
+	var patients []*domain.Patient
+	if err := exportScope(r.db.WithContext(ctx), query).Find(&patients).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to read patients for export: %v", err)
+		return nil, err
+	}
+	return patients, nil
+}
+
+// ExportResources reads the next batch of resources of a type changed within the query's time
+// range, in id order. A patient query reads only resources in the compartment of a patient.
+func (r *bulkRepository) ExportResources(ctx context.Context, resourceType string, query domain.ExportQuery) ([]*domain.Resource, error) {
+	ctx, span := tracer.StartSpan(ctx, "ExportResources")
+	defer span.End()
+
+	definition, ok := domain.ResourceDefinitions[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("%w: resource type %s is not supported", domain.ErrValidation, resourceType)
+	}
+	db := r.db.WithContext(ctx).Where("resource_type = ?", resourceType)
+	if query.Patient {
+		if len(definition.Compartment) == 0 {
+			return []*domain.Resource{}, nil
+		}
+		db = db.Where(patientCompartmentMember, definition.Compartment)
+	}
+
+	var resources []*domain.Resource
+	if err := exportScope(db, query).Find(&resources).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to read %s resources for export: %v", resourceType, err)
+		return nil, err
+	}
+	return resources, nil
+}
+
+// exportScope limits a query to one batch of the rows an export reads
+func exportScope(db *gorm.DB, query domain.ExportQuery) *gorm.DB {
+	db = db.Where("id > ? AND updated_at <= ?", query.AfterID, query.Until)
+	if query.Since != nil {
+		db = db.Where("updated_at > ?", *query.Since)
+	}
+	return db.Order("id").Limit(query.Limit)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go-fhir-demo/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Logical id strategies
+const (
+	IDStrategyUUID       = "uuid"
+	IDStrategySequential = "sequential"
+)
+
+// logicalIDSequence is the database sequence sequential logical ids are drawn from
+const logicalIDSequence = "patient_logical_id_seq"
+
+// NewIDGenerator returns the logical id generator for a strategy: random UUIDs, or sequential
+// numbers drawn from a database sequence
+func NewIDGenerator(db *gorm.DB, strategy string) (domain.IDGenerator, error) {
+	switch strategy {
+	case IDStrategyUUID:
+		return uuidGenerator{}, nil
+	case IDStrategySequential:
+		return &sequenceGenerator{db: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown logical id strategy %q, expected %s or %s", strategy, IDStrategyUUID, IDStrategySequential)
+	}
+}
+
+type uuidGenerator struct{}
+
+// NextID returns a random version 4 UUID
+func (uuidGenerator) NextID(ctx context.Context) (string, error) {
+	return uuid.NewString(), nil
+}
+
+type sequenceGenerator struct {
+	db *gorm.DB
+}
+
+// NextID returns the next value of the logical id sequence
+func (g *sequenceGenerator) NextID(ctx context.Context) (string, error) {
+	var next int64
+	if err := g.db.WithContext(ctx).Raw("SELECT nextval('" + logicalIDSequence + "')").Scan(&next).Error; err != nil {
+		return "", fmt.Errorf("failed to allocate logical id: %w", err)
+	}
+	return strconv.FormatInt(next, 10), nil
+}
+
+// MigrateLogicalIDs gives patients stored before logical ids were introduced their numeric
+// primary key as logical id, embeds it in their stored resources and history, and creates
+// the sequence for sequential ids so that it starts after every existing primary key
+func MigrateLogicalIDs(db *gorm.DB) error {
+	statements := []string{
+		`UPDATE patients
+		SET logical_id = id::text, fhir_data = jsonb_set(fhir_data, '{id}', to_jsonb(id::text))
+		WHERE logical_id IS NULL`,
+		`UPDATE patient_history AS h
+		SET logical_id = p.logical_id,
+			fhir_data = CASE WHEN h.fhir_data IS NULL THEN NULL ELSE jsonb_set(h.fhir_data, '{id}', to_jsonb(p.logical_id)) END
+		FROM patients AS p
+		WHERE h.patient_id = p.id AND h.logical_id IS NULL`,
+		`DO $$
+		BEGIN
+			IF to_regclass('` + logicalIDSequence + `') IS NULL THEN
+				CREATE SEQUENCE ` + logicalIDSequence + `;
+				PERFORM setval('` + logicalIDSequence + `', COALESCE((SELECT MAX(id) FROM patients), 0) + 1, false);
+			END IF;
+		END $$`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate logical ids: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"gorm.io/gorm"
+)
+
+// MergeRepositoryInterface defines the contract for merge repository
+type MergeRepositoryInterface interface {
+	Merge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error
+	Unmerge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error
+	GetActiveMerge(ctx context.Context, sourceID uint) (*domain.PatientMerge, error)
+}
+
+type mergeRepository struct {
+	db            *gorm.DB
+	uniqueSystems []string
+}
+
+// NewMergeRepository creates a new merge repository. Patients are written like the patient
+// repository writes them, enforcing the same unique identifier systems.
+func NewMergeRepository(db *gorm.DB, uniqueSystems []string) MergeRepositoryInterface {
+	return &mergeRepository{
+		db:            db,
+		uniqueSystems: uniqueSystems,
+	}
+}
+
+// Merge stores the merged source and target patients, repoints references to the source in
+// other resources to the target and records the merge. The source is written first so the
+// identifiers it hands over are free when the target takes them.
+func (r *mergeRepository) Merge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	ctx, span := tracer.StartSpan(ctx, "Merge")
+	defer span.End()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		patients := &patientRepository{db: tx, uniqueSystems: r.uniqueSystems}
+		if err := patients.Update(ctx, source, sourceVersion); err != nil {
+			return err
+		}
+		if err := patients.Update(ctx, target, targetVersion); err != nil {
+			return err
+		}
+
+		references, err := repointReferences(ctx, tx, "Patient/"+source.LogicalID, "Patient/"+target.LogicalID)
+		if err != nil {
+			return err
+		}
+		if merge.References, err = json.Marshal(references); err != nil {
+			return err
+		}
+		merge.SourceID, merge.TargetID = source.ID, target.ID
+		return tx.Create(merge).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to merge patient %s into %s: %v", source.LogicalID, target.LogicalID, err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("Merged patient %s into %s", source.LogicalID, target.LogicalID)
+	return nil
+}
+
+// Unmerge stores the restored source and target patients, points the references the merge
+// repointed back to the source and marks the merge reversed. The target is written first so
+// the identifiers it gives back are free when the source takes them.
+func (r *mergeRepository) Unmerge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	ctx, span := tracer.StartSpan(ctx, "Unmerge")
+	defer span.End()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		patients := &patientRepository{db: tx, uniqueSystems: r.uniqueSystems}
+		if err := patients.Update(ctx, target, targetVersion); err != nil {
+			return err
+		}
+		if err := patients.Update(ctx, source, sourceVersion); err != nil {
+			return err
+		}
+
+		var references []domain.RepointedReference
+		if len(merge.References) > 0 {
+			if err := json.Unmarshal(merge.References, &references); err != nil {
+				return fmt.Errorf("failed to unmarshal repointed references: %w", err)
+			}
+		}
+		if err := restoreReferences(ctx, tx, references, "Patient/"+target.LogicalID); err != nil {
+			return err
+		}
+		now := time.Now()
+		merge.UnmergedAt = &now
+		return tx.Model(merge).Update("unmerged_at", now).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to unmerge patient %s from %s: %v", source.LogicalID, target.LogicalID, err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("Unmerged patient %s from %s", source.LogicalID, target.LogicalID)
+	return nil
+}
+
+// GetActiveMerge retrieves the merge of a source patient that has not been reversed,
+// returning domain.ErrNotFound when there is none
+func (r *mergeRepository) GetActiveMerge(ctx context.Context, sourceID uint) (*domain.PatientMerge, error) {
+	var merge domain.PatientMerge
+	err := r.db.WithContext(ctx).
+		Where("source_id = ? AND unmerged_at IS NULL", sourceID).
+		Order("id DESC").
+		First(&merge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		logger.WithContext(ctx).Errorf("Failed to get merge of patient %d: %v", sourceID, err)
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// repointReferences changes every reference to from in the generic resource store to to,
+// returning the references changed. Resources are found through the indexed values of the
+// reference parameters that can refer to a patient, the ones _revinclude supports.
+func repointReferences(ctx context.Context, tx *gorm.DB, from, to string) ([]domain.RepointedReference, error) {
+	params := make(map[string][]string)
+	var types []string
+	for _, include := range domain.PatientRevIncludes() {
+		if params[include.Source] == nil {
+			types = append(types, include.Source)
+		}
+		params[include.Source] = append(params[include.Source], include.Param)
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+	members := make([]string, len(types))
+	args := make([]interface{}, 0, 3*len(types))
+	for i, resourceType := range types {
+		members[i] = compartmentMember
+		args = append(args, resourceType, params[resourceType], from)
+	}
+
+	var resources []*domain.Resource
+	if err := tx.Where(strings.Join(members, " OR "), args...).Order("id").Find(&resources).Error; err != nil {
+		return nil, err
+	}
+
+	resourceRepo := &resourceRepository{db: tx}
+	var repointed []domain.RepointedReference
+	for _, resource := range resources {
+		var changed []domain.RepointedReference
+		err := rewriteReferences(resource, func(path, reference string) (string, bool) {
+			if !refersTo(reference, from) {
+				return "", false
+			}
+			changed = append(changed, domain.RepointedReference{ResourceID: resource.ID, Path: path, Reference: reference})
+			return to, true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		if err := resourceRepo.Update(ctx, resource, 0); err != nil {
+			return nil, err
+		}
+		sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+		repointed = append(repointed, changed...)
+	}
+	return repointed, nil
+}
+
+// restoreReferences sets repointed references that still point to to back to their value
+// before the merge. References changed since the merge are left alone.
+func restoreReferences(ctx context.Context, tx *gorm.DB, references []domain.RepointedReference, to string) error {
+	byResource := make(map[uint]map[string]string)
+	var ids []uint
+	for _, reference := range references {
+		if byResource[reference.ResourceID] == nil {
+			byResource[reference.ResourceID] = make(map[string]string)
+			ids = append(ids, reference.ResourceID)
+		}
+		byResource[reference.ResourceID][reference.Path] = reference.Reference
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var resources []*domain.Resource
+	if err := tx.Where("id IN ?", ids).Order("id").Find(&resources).Error; err != nil {
+		return err
+	}
+	resourceRepo := &resourceRepository{db: tx}
+	for _, resource := range resources {
+		original := byResource[resource.ID]
+		restored := false
+		err := rewriteReferences(resource, func(path, reference string) (string, bool) {
+			value, ok := original[path]
+			if !ok || reference != to {
+				return "", false
+			}
+			restored = true
+			return value, true
+		})
+		if err != nil {
+			return err
+		}
+		if !restored {
+			continue
+		}
+		if err := resourceRepo.Update(ctx, resource, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteReferences calls rewrite with the JSON pointer and value of every reference element
+// of a resource, storing the values rewrite replaces in the resource's FHIR data
+func rewriteReferences(resource *domain.Resource, rewrite func(path, reference string) (string, bool)) error {
+	var document interface{}
+	if err := json.Unmarshal(resource.FHIRData, &document); err != nil {
+		return fmt.Errorf("failed to unmarshal %s %s: %w", resource.ResourceType, resource.LogicalID, err)
+	}
+	changed := false
+	var visit func(node interface{}, path string)
+	visit = func(node interface{}, path string) {
+		switch value := node.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if reference, ok := child.(string); ok && key == "reference" {
+					if replaced, ok := rewrite(path+"/"+key, reference); ok {
+						value[key] = replaced
+						changed = true
+					}
+					continue
+				}
+				visit(child, path+"/"+key)
+			}
+		case []interface{}:
+			for i, child := range value {
+				visit(child, path+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+	visit(document, "")
+	if !changed {
+		return nil
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s %s: %w", resource.ResourceType, resource.LogicalID, err)
+	}
+	resource.FHIRData = data
+	return nil
+}
+
+// refersTo reports whether a reference points at the relative reference target, possibly
+// through an absolute URL or a specific version
+func refersTo(reference, target string) bool {
+	if index := strings.Index(reference, "/_history/"); index >= 0 {
+		reference = reference[:index]
+	}
+	return reference == target || strings.HasSuffix(reference, "/"+target)
+}
@@ -0,0 +1,144 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\bulk_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\bulk_repository.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\mocks\mock_bulk_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBulkRepositoryInterface is a mock of BulkRepositoryInterface interface.
+type MockBulkRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockBulkRepositoryInterfaceMockRecorder is the mock recorder for MockBulkRepositoryInterface.
+type MockBulkRepositoryInterfaceMockRecorder struct {
+	mock *MockBulkRepositoryInterface
+}
+
+// NewMockBulkRepositoryInterface creates a new mock instance.
+func NewMockBulkRepositoryInterface(ctrl *gomock.Controller) *MockBulkRepositoryInterface {
+	mock := &MockBulkRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockBulkRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkRepositoryInterface) EXPECT() *MockBulkRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockBulkRepositoryInterface) Create(ctx context.Context, job *domain.BulkJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) Create(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).Create), ctx, job)
+}
+
+// Delete mocks base method.
+func (m *MockBulkRepositoryInterface) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).Delete), ctx, id)
+}
+
+// ExportPatients mocks base method.
+func (m *MockBulkRepositoryInterface) ExportPatients(ctx context.Context, query domain.ExportQuery) ([]*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportPatients", ctx, query)
+	ret0, _ := ret[0].([]*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportPatients indicates an expected call of ExportPatients.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) ExportPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportPatients", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).ExportPatients), ctx, query)
+}
+
+// ExportResources mocks base method.
+func (m *MockBulkRepositoryInterface) ExportResources(ctx context.Context, resourceType string, query domain.ExportQuery) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportResources", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportResources indicates an expected call of ExportResources.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) ExportResources(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportResources", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).ExportResources), ctx, resourceType, query)
+}
+
+// FailInterrupted mocks base method.
+func (m *MockBulkRepositoryInterface) FailInterrupted(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailInterrupted", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailInterrupted indicates an expected call of FailInterrupted.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) FailInterrupted(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailInterrupted", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).FailInterrupted), ctx)
+}
+
+// Get mocks base method.
+func (m *MockBulkRepositoryInterface) Get(ctx context.Context, id string) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).Get), ctx, id)
+}
+
+// Update mocks base method.
+func (m *MockBulkRepositoryInterface) Update(ctx context.Context, job *domain.BulkJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBulkRepositoryInterfaceMockRecorder) Update(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBulkRepositoryInterface)(nil).Update), ctx, job)
+}
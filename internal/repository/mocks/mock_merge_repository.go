@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\merge_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\merge_repository.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\mocks\mock_merge_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMergeRepositoryInterface is a mock of MergeRepositoryInterface interface.
+type MockMergeRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMergeRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMergeRepositoryInterfaceMockRecorder is the mock recorder for MockMergeRepositoryInterface.
+type MockMergeRepositoryInterfaceMockRecorder struct {
+	mock *MockMergeRepositoryInterface
+}
+
+// NewMockMergeRepositoryInterface creates a new mock instance.
+func NewMockMergeRepositoryInterface(ctrl *gomock.Controller) *MockMergeRepositoryInterface {
+	mock := &MockMergeRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockMergeRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMergeRepositoryInterface) EXPECT() *MockMergeRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetActiveMerge mocks base method.
+func (m *MockMergeRepositoryInterface) GetActiveMerge(ctx context.Context, sourceID uint) (*domain.PatientMerge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveMerge", ctx, sourceID)
+	ret0, _ := ret[0].(*domain.PatientMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveMerge indicates an expected call of GetActiveMerge.
+func (mr *MockMergeRepositoryInterfaceMockRecorder) GetActiveMerge(ctx, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveMerge", reflect.TypeOf((*MockMergeRepositoryInterface)(nil).GetActiveMerge), ctx, sourceID)
+}
+
+// Merge mocks base method.
+func (m *MockMergeRepositoryInterface) Merge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, merge, source, target, sourceVersion, targetVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockMergeRepositoryInterfaceMockRecorder) Merge(ctx, merge, source, target, sourceVersion, targetVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockMergeRepositoryInterface)(nil).Merge), ctx, merge, source, target, sourceVersion, targetVersion)
+}
+
+// Unmerge mocks base method.
+func (m *MockMergeRepositoryInterface) Unmerge(ctx context.Context, merge *domain.PatientMerge, source, target *domain.Patient, sourceVersion, targetVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unmerge", ctx, merge, source, target, sourceVersion, targetVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unmerge indicates an expected call of Unmerge.
+func (mr *MockMergeRepositoryInterfaceMockRecorder) Unmerge(ctx, merge, source, target, sourceVersion, targetVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmerge", reflect.TypeOf((*MockMergeRepositoryInterface)(nil).Unmerge), ctx, merge, source, target, sourceVersion, targetVersion)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -70,18 +71,47 @@ func (mr *MockPatientRepositoryInterfaceMockRecorder) Create(ctx, patient any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Create), ctx, patient)
 }
 
+// CreateBatch mocks base method.
+func (m *MockPatientRepositoryInterface) CreateBatch(ctx context.Context, patients []*domain.Patient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, patients)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) CreateBatch(ctx, patients any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).CreateBatch), ctx, patients)
+}
+
 // Delete mocks base method.
-func (m *MockPatientRepositoryInterface) Delete(ctx context.Context, id uint) error {
+func (m *MockPatientRepositoryInterface) Delete(ctx context.Context, id uint, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockPatientRepositoryInterfaceMockRecorder) Delete(ctx, id any) *gomock.Call {
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Delete(ctx, id, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Delete), ctx, id, expectedVersion)
+}
+
+// Expunge mocks base method.
+func (m *MockPatientRepositoryInterface) Expunge(ctx context.Context, logicalID string, history bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expunge", ctx, logicalID, history)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Expunge indicates an expected call of Expunge.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Expunge(ctx, logicalID, history any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expunge", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Expunge), ctx, logicalID, history)
 }
 
 // GetAll mocks base method.
@@ -114,16 +144,136 @@ func (mr *MockPatientRepositoryInterfaceMockRecorder) GetByID(ctx, id any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).GetByID), ctx, id)
 }
 
+// GetByLogicalID mocks base method.
+func (m *MockPatientRepositoryInterface) GetByLogicalID(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalID", ctx, logicalID)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalID indicates an expected call of GetByLogicalID.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) GetByLogicalID(ctx, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalID", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).GetByLogicalID), ctx, logicalID)
+}
+
+// GetVersion mocks base method.
+func (m *MockPatientRepositoryInterface) GetVersion(ctx context.Context, logicalID string, versionID int) (*domain.PatientHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx, logicalID, versionID)
+	ret0, _ := ret[0].(*domain.PatientHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) GetVersion(ctx, logicalID, versionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).GetVersion), ctx, logicalID, versionID)
+}
+
+// History mocks base method.
+func (m *MockPatientRepositoryInterface) History(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, query)
+	ret0, _ := ret[0].([]*domain.PatientHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// History indicates an expected call of History.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) History(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).History), ctx, query)
+}
+
+// MatchCandidates mocks base method.
+func (m *MockPatientRepositoryInterface) MatchCandidates(ctx context.Context, criteria domain.MatchCriteria, limit int) ([]*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchCandidates", ctx, criteria, limit)
+	ret0, _ := ret[0].([]*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchCandidates indicates an expected call of MatchCandidates.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) MatchCandidates(ctx, criteria, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchCandidates", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).MatchCandidates), ctx, criteria, limit)
+}
+
+// Search mocks base method.
+func (m *MockPatientRepositoryInterface) Search(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query)
+	ret0, _ := ret[0].(*domain.PatientPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Search(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Search), ctx, query)
+}
+
+// SearchCount mocks base method.
+func (m *MockPatientRepositoryInterface) SearchCount(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCount", ctx, query)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) SearchCount(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).SearchCount), ctx, query)
+}
+
+// Transaction mocks base method.
+func (m *MockPatientRepositoryInterface) Transaction(ctx context.Context, fn func(domain.PatientRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Transaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Transaction), ctx, fn)
+}
+
+// Undelete mocks base method.
+func (m *MockPatientRepositoryInterface) Undelete(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Undelete", ctx, logicalID)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Undelete indicates an expected call of Undelete.
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Undelete(ctx, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undelete", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Undelete), ctx, logicalID)
+}
+
 // Update mocks base method.
-func (m *MockPatientRepositoryInterface) Update(ctx context.Context, patient *domain.Patient) error {
+func (m *MockPatientRepositoryInterface) Update(ctx context.Context, patient *domain.Patient, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, patient)
+	ret := m.ctrl.Call(m, "Update", ctx, patient, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockPatientRepositoryInterfaceMockRecorder) Update(ctx, patient any) *gomock.Call {
+func (mr *MockPatientRepositoryInterfaceMockRecorder) Update(ctx, patient, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Update), ctx, patient)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPatientRepositoryInterface)(nil).Update), ctx, patient, expectedVersion)
 }
@@ -0,0 +1,162 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\resource_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\resource_repository.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\repository\mocks\mock_resource_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockResourceRepositoryInterface is a mock of ResourceRepositoryInterface interface.
+type MockResourceRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockResourceRepositoryInterfaceMockRecorder is the mock recorder for MockResourceRepositoryInterface.
+type MockResourceRepositoryInterfaceMockRecorder struct {
+	mock *MockResourceRepositoryInterface
+}
+
+// NewMockResourceRepositoryInterface creates a new mock instance.
+func NewMockResourceRepositoryInterface(ctrl *gomock.Controller) *MockResourceRepositoryInterface {
+	mock := &MockResourceRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockResourceRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceRepositoryInterface) EXPECT() *MockResourceRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Compartment mocks base method.
+func (m *MockResourceRepositoryInterface) Compartment(ctx context.Context, query domain.CompartmentQuery) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compartment", ctx, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Compartment indicates an expected call of Compartment.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Compartment(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compartment", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Compartment), ctx, query)
+}
+
+// Create mocks base method.
+func (m *MockResourceRepositoryInterface) Create(ctx context.Context, resource *domain.Resource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, resource)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Create(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Create), ctx, resource)
+}
+
+// Delete mocks base method.
+func (m *MockResourceRepositoryInterface) Delete(ctx context.Context, id uint, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Delete(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Delete), ctx, id, expectedVersion)
+}
+
+// GetByLogicalID mocks base method.
+func (m *MockResourceRepositoryInterface) GetByLogicalID(ctx context.Context, resourceType, logicalID string) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalID", ctx, resourceType, logicalID)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalID indicates an expected call of GetByLogicalID.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) GetByLogicalID(ctx, resourceType, logicalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalID", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).GetByLogicalID), ctx, resourceType, logicalID)
+}
+
+// GetByLogicalIDs mocks base method.
+func (m *MockResourceRepositoryInterface) GetByLogicalIDs(ctx context.Context, resourceType string, logicalIDs []string) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByLogicalIDs", ctx, resourceType, logicalIDs)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogicalIDs indicates an expected call of GetByLogicalIDs.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) GetByLogicalIDs(ctx, resourceType, logicalIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogicalIDs", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).GetByLogicalIDs), ctx, resourceType, logicalIDs)
+}
+
+// Referencing mocks base method.
+func (m *MockResourceRepositoryInterface) Referencing(ctx context.Context, resourceType, param string, references []string, limit int) ([]*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Referencing", ctx, resourceType, param, references, limit)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Referencing indicates an expected call of Referencing.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Referencing(ctx, resourceType, param, references, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Referencing", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Referencing), ctx, resourceType, param, references, limit)
+}
+
+// Search mocks base method.
+func (m *MockResourceRepositoryInterface) Search(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Search(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Search), ctx, resourceType, query)
+}
+
+// Update mocks base method.
+func (m *MockResourceRepositoryInterface) Update(ctx context.Context, resource *domain.Resource, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, resource, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockResourceRepositoryInterfaceMockRecorder) Update(ctx, resource, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockResourceRepositoryInterface)(nil).Update), ctx, resource, expectedVersion)
+}
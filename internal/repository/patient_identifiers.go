@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"gorm.io/gorm"
+)
+
+// identifierExists selects the indexed identifiers of the patient being searched
+const identifierExists = "EXISTS (SELECT 1 FROM patient_identifiers pi WHERE pi.patient_id = patients.id"
+
+// indexIdentifiers replaces the identifier rows of a patient with the identifiers of its
+// stored resource. Identifiers in one of the unique systems must not belong to any other
+// patient.
+func indexIdentifiers(tx *gorm.DB, patient *domain.Patient, uniqueSystems []string) error {
+	identifiers, err := patientIdentifiers(patient)
+	if err != nil {
+		return err
+	}
+	if err := checkUniqueIdentifiers(tx, patient, identifiers, uniqueSystems); err != nil {
+		return err
+	}
+
+	if err := tx.Where("patient_id = ?", patient.ID).Delete(&domain.PatientIdentifier{}).Error; err != nil {
+		return err
+	}
+	if len(identifiers) == 0 {
+		return nil
+	}
+	return tx.Create(&identifiers).Error
+}
+
+// checkUniqueIdentifiers verifies that the identifiers of a patient in one of the unique
+// systems do not belong to any other patient. They are locked for the rest of the
+// transaction so concurrent writes of the same identifier are checked one after the other.
+func checkUniqueIdentifiers(tx *gorm.DB, patient *domain.Patient, identifiers []domain.PatientIdentifier, uniqueSystems []string) error {
+	// Lock in a stable order so writers of overlapping identifiers cannot deadlock
+	var unique []domain.PatientIdentifier
+	for _, identifier := range identifiers {
+		if slices.Contains(uniqueSystems, identifier.System) {
+			unique = append(unique, identifier)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return identifierKey(unique[i]) < identifierKey(unique[j])
+	})
+	for _, identifier := range unique {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", identifierKey(identifier)).Error; err != nil {
+			return err
+		}
+		var owners []string
+		err := tx.Model(&domain.PatientIdentifier{}).
+			Joins("JOIN patients ON patients.id = patient_identifiers.patient_id").
+			Where("patient_identifiers.system = ? AND patient_identifiers.value = ? AND patient_identifiers.patient_id <> ?",
+				identifier.System, identifier.Value, patient.ID).
+			Limit(1).
+			Pluck("patients.logical_id", &owners).Error
+		if err != nil {
+			return err
+		}
+		if len(owners) > 0 {
+			return fmt.Errorf("%w: identifier %s is already assigned to Patient/%s", domain.ErrConflict, identifierKey(identifier), owners[0])
+		}
+	}
+	return nil
+}
+
+// patientIdentifiers returns the distinct identifiers of a patient's stored resource
+func patientIdentifiers(patient *domain.Patient) ([]domain.PatientIdentifier, error) {
+	def := domain.PatientSearchParameters[slices.IndexFunc(domain.PatientSearchParameters, func(def fhirsearch.Definition) bool {
+		return def.Name == "identifier"
+	})]
+	values, err := fhirsearch.Extract(patient.FHIRData, []fhirsearch.Definition{def})
+	if err != nil {
+		return nil, err
+	}
+
+	identifiers := make([]domain.PatientIdentifier, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		identifier := domain.PatientIdentifier{PatientID: patient.ID, System: value.System, Value: value.Value}
+		if seen[identifierKey(identifier)] {
+			continue
+		}
+		seen[identifierKey(identifier)] = true
+		identifiers = append(identifiers, identifier)
+	}
+	return identifiers, nil
+}
+
+// identifierKey returns the system|value form of an identifier
+func identifierKey(identifier domain.PatientIdentifier) string {
+	return identifier.System + "|" + identifier.Value
+}
+
+// MigrateIdentifiers indexes the identifiers of existing patients that have none indexed yet
+func MigrateIdentifiers(db *gorm.DB) error {
+	err := db.Exec(`INSERT INTO patient_identifiers (patient_id, system, value)
+		SELECT DISTINCT p.id, COALESCE(i->>'system', ''), i->>'value'
+		FROM patients AS p
+		CROSS JOIN LATERAL jsonb_array_elements(
+			CASE WHEN jsonb_typeof(p.fhir_data->'identifier') = 'array' THEN p.fhir_data->'identifier' ELSE '[]'::jsonb END
+		) AS i
+		WHERE p.deleted_at IS NULL
+			AND COALESCE(i->>'value', '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM patient_identifiers AS pi WHERE pi.patient_id = p.id)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to migrate patient identifiers: %w", err)
+	}
+	return nil
+}
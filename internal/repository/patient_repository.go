@@ -2,53 +2,149 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils/tracer"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PatientRepositoryInterface defines the contract for patient repository
 type PatientRepositoryInterface interface {
 	Create(ctx context.Context, patient *domain.Patient) error
+	CreateBatch(ctx context.Context, patients []*domain.Patient) error
 	GetByID(ctx context.Context, id uint) (*domain.Patient, error)
+	GetByLogicalID(ctx context.Context, logicalID string) (*domain.Patient, error)
 	GetAll(ctx context.Context, limit, offset int) ([]*domain.Patient, error)
-	Update(ctx context.Context, patient *domain.Patient) error
-	Delete(ctx context.Context, id uint) error
+	Search(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error)
+	Update(ctx context.Context, patient *domain.Patient, expectedVersion int) error
+	Delete(ctx context.Context, id uint, expectedVersion int) error
+	Undelete(ctx context.Context, logicalID string) (*domain.Patient, error)
+	Expunge(ctx context.Context, logicalID string, history bool) (int64, error)
 	Count(ctx context.Context) (int64, error)
+	SearchCount(ctx context.Context, query *fhirsearch.Query) (int64, error)
+	History(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error)
+	GetVersion(ctx context.Context, logicalID string, versionID int) (*domain.PatientHistory, error)
+	MatchCandidates(ctx context.Context, criteria domain.MatchCriteria, limit int) ([]*domain.Patient, error)
+	Transaction(ctx context.Context, fn func(repo domain.PatientRepository) error) error
 }
 
 type patientRepository struct {
-	db *gorm.DB
+	db            *gorm.DB
+	uniqueSystems []string
 }
 
-// NewPatientRepository creates a new patient repository
-func NewPatientRepository(db *gorm.DB) PatientRepositoryInterface {
+// NewPatientRepository creates a new patient repository. Patient identifiers are indexed in
+// patient_identifiers, and an identifier in one of uniqueSystems may belong to one patient only.
+func NewPatientRepository(db *gorm.DB, uniqueSystems []string) PatientRepositoryInterface {
 	return &patientRepository{
-		db: db,
+		db:            db,
+		uniqueSystems: uniqueSystems,
 	}
 }
 
-// Create creates a new patient record
+// Create creates a new patient record as version 1 and records it in the history
 func (r *patientRepository) Create(ctx context.Context, patient *domain.Patient) error {
 	ctx, span := tracer.StartSpan(ctx, "Create")
 	defer span.End()
-	if err := r.db.WithContext(ctx).Create(patient).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		patient.VersionID = 1
+		if err := tx.Create(patient).Error; err != nil {
+			return err
+		}
+		if err := indexIdentifiers(tx, patient, r.uniqueSystems); err != nil {
+			return err
+		}
+		return tx.Create(newHistoryEntry(patient, http.MethodPost)).Error
+	})
+	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to create patient: %v", err)
 		return err
 	}
-	logger.WithContext(ctx).Infof("Patient created successfully with ID: %d", patient.ID)
+	logger.WithContext(ctx).Infof("Patient created successfully with ID: %d and logical ID: %s", patient.ID, patient.LogicalID)
+	return nil
+}
+
+// createBatchSize is the number of rows written by each multi-row insert of CreateBatch
+const createBatchSize = 500
+
+// CreateBatch creates many patients in one transaction with multi-row inserts, recording each
+// as version 1 in the history. The whole batch fails when any patient does, e.g. when an
+// identifier of a unique system is already assigned, including to another patient of the batch.
+// The patients of a failed batch are left unsaved, so they can be created again one at a time.
+func (r *patientRepository) CreateBatch(ctx context.Context, patients []*domain.Patient) error {
+	ctx, span := tracer.StartSpan(ctx, "CreateBatch")
+	defer span.End()
+	if len(patients) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, patient := range patients {
+			patient.VersionID = 1
+		}
+		if err := tx.CreateInBatches(patients, createBatchSize).Error; err != nil {
+			return err
+		}
+
+		histories := make([]*domain.PatientHistory, 0, len(patients))
+		var identifiers []domain.PatientIdentifier
+		owners := make(map[string]string)
+		for _, patient := range patients {
+			histories = append(histories, newHistoryEntry(patient, http.MethodPost))
+			patientIdentifiers, err := patientIdentifiers(patient)
+			if err != nil {
+				return err
+			}
+			if err := checkUniqueIdentifiers(tx, patient, patientIdentifiers, r.uniqueSystems); err != nil {
+				return err
+			}
+			// The identifiers of the batch are not indexed yet, so they are checked against each other here
+			for _, identifier := range patientIdentifiers {
+				if !slices.Contains(r.uniqueSystems, identifier.System) {
+					continue
+				}
+				if owner, ok := owners[identifierKey(identifier)]; ok {
+					return fmt.Errorf("%w: identifier %s is already assigned to Patient/%s", domain.ErrConflict, identifierKey(identifier), owner)
+				}
+				owners[identifierKey(identifier)] = patient.LogicalID
+			}
+			identifiers = append(identifiers, patientIdentifiers...)
+		}
+		if err := tx.CreateInBatches(histories, createBatchSize).Error; err != nil {
+			return err
+		}
+		if len(identifiers) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(identifiers, createBatchSize).Error
+	})
+	if err != nil {
+		for _, patient := range patients {
+			patient.ID, patient.VersionID = 0, 0
+			patient.CreatedAt, patient.UpdatedAt = time.Time{}, time.Time{}
+		}
+		logger.WithContext(ctx).Errorf("Failed to create a batch of %d patients: %v", len(patients), err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("Created a batch of %d patients", len(patients))
 	return nil
 }
 
-// GetByID retrieves a patient by ID
+// GetByID retrieves a patient by ID, returning domain.ErrNotFound when it does not exist
 func (r *patientRepository) GetByID(ctx context.Context, id uint) (*domain.Patient, error) {
 	var patient domain.Patient
 	if err := r.db.WithContext(ctx).First(&patient, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.WithContext(ctx).Warnf("Patient not found with ID: %d", id)
-			return nil, err
+			return nil, domain.ErrNotFound
 		}
 		logger.WithContext(ctx).Errorf("Failed to get patient by ID %d: %v", id, err)
 		return nil, err
@@ -56,10 +152,36 @@ func (r *patientRepository) GetByID(ctx context.Context, id uint) (*domain.Patie
 	return &patient, nil
 }
 
+// GetByLogicalID retrieves a patient by its FHIR logical id, returning domain.ErrGone when it
+// has been deleted and domain.ErrNotFound when it never existed
+func (r *patientRepository) GetByLogicalID(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	var patient domain.Patient
+	if err := r.db.WithContext(ctx).Where("logical_id = ?", logicalID).First(&patient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var deleted int64
+			if err := r.db.WithContext(ctx).Unscoped().Model(&domain.Patient{}).
+				Where("logical_id = ? AND deleted_at IS NOT NULL", logicalID).
+				Count(&deleted).Error; err != nil {
+				logger.WithContext(ctx).Errorf("Failed to check whether patient %s was deleted: %v", logicalID, err)
+				return nil, err
+			}
+			if deleted > 0 {
+				logger.WithContext(ctx).Warnf("Patient with logical ID %s has been deleted", logicalID)
+				return nil, fmt.Errorf("%w: patient %s has been deleted", domain.ErrGone, logicalID)
+			}
+			logger.WithContext(ctx).Warnf("Patient not found with logical ID: %s", logicalID)
+			return nil, domain.ErrNotFound
+		}
+		logger.WithContext(ctx).Errorf("Failed to get patient by logical ID %s: %v", logicalID, err)
+		return nil, err
+	}
+	return &patient, nil
+}
+
 // GetAll retrieves all patients with pagination
 func (r *patientRepository) GetAll(ctx context.Context, limit, offset int) ([]*domain.Patient, error) {
 	var patients []*domain.Patient
-	query := r.db.WithContext(ctx).Limit(limit).Offset(offset)
+	query := r.db.WithContext(ctx).Order("id").Limit(limit).Offset(offset)
 
 	if err := query.Find(&patients).Error; err != nil {
 		logger.WithContext(ctx).Errorf("Failed to get patients: %v", err)
@@ -70,19 +192,148 @@ func (r *patientRepository) GetAll(ctx context.Context, limit, offset int) ([]*d
 	return patients, nil
 }
 
-// Update updates an existing patient record
-func (r *patientRepository) Update(ctx context.Context, patient *domain.Patient) error {
-	if err := r.db.WithContext(ctx).Save(patient).Error; err != nil {
+// Search retrieves a page of patients matching a FHIR search query, in _sort order with the id
+// breaking ties. Pages after the first are found from a keyset cursor rather than an offset,
+// so deep pages cost the same as the first and stay stable while patients are added.
+func (r *patientRepository) Search(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	ctx, span := tracer.StartSpan(ctx, "Search")
+	defer span.End()
+
+	conditions, err := buildPatientConditions(query)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid patient search: %v", err)
+		return nil, err
+	}
+	keys, err := patientSortKeys(query)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid patient search: %v", err)
+		return nil, err
+	}
+
+	// Counting reads every match, so later pages skip it unless _total=accurate asks for it
+	page := &domain.PatientPage{Total: -1}
+	firstPage := query.Cursor == nil && query.Offset == 0
+	if query.Total == fhirsearch.TotalAccurate || firstPage && query.Total != fhirsearch.TotalNone {
+		if err := applyConditions(r.db.WithContext(ctx).Model(&domain.Patient{}), conditions).Count(&page.Total).Error; err != nil {
+			logger.WithContext(ctx).Errorf("Failed to count patient search results: %v", err)
+			return nil, err
+		}
+	}
+
+	// One row more than the page tells whether there is a page beyond it
+	backward := query.Cursor != nil && query.Cursor.Backward
+	db := applyConditions(r.db.WithContext(ctx), conditions)
+	if tsquery := patientTextQuery(query); tsquery != "" {
+		db = db.Select("patients.*, "+patientRankExpr+" AS score", tsquery)
+	}
+	switch {
+	case query.Cursor != nil && len(query.Cursor.Values) > 0:
+		keyset, err := keysetCondition(keys, query.Cursor)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Invalid patient search: %v", err)
+			return nil, err
+		}
+		db = db.Where(keyset.sql, keyset.args...)
+	case query.Cursor == nil:
+		db = db.Offset(query.Offset)
+	}
+	var patients []*domain.Patient
+	if err := db.Order(orderClause(keys, backward)).Limit(query.Count + 1).Find(&patients).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to search patients: %v", err)
+		return nil, err
+	}
+	more := len(patients) > query.Count
+	if more {
+		patients = patients[:query.Count]
+	}
+	if backward {
+		slices.Reverse(patients)
+	}
+	page.Patients = patients
+
+	if len(patients) > 0 {
+		sortSpec := fhirsearch.FormatSort(query.Sort)
+		hasNext, hasPrevious := more, query.Cursor != nil || query.Offset > 0
+		if backward {
+			hasNext, hasPrevious = len(query.Cursor.Values) > 0, more
+		}
+		if hasNext {
+			page.Next = patientCursor(keys, sortSpec, patients[len(patients)-1], false)
+		}
+		if hasPrevious {
+			page.Previous = patientCursor(keys, sortSpec, patients[0], true)
+		}
+	}
+
+	logger.WithContext(ctx).Infof("Patient search matched %d patients, returning %d", page.Total, len(patients))
+	return page, nil
+}
+
+// Update updates an existing patient record, incrementing its version and recording it in the history.
+// A non-zero expectedVersion must match the stored version, which is checked under a row lock.
+func (r *patientRepository) Update(ctx context.Context, patient *domain.Patient, expectedVersion int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockPatient(tx, patient.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, expectedVersion); err != nil {
+			return err
+		}
+		patient.VersionID = current.VersionID + 1
+		if err := tx.Save(patient).Error; err != nil {
+			return err
+		}
+		if err := indexIdentifiers(tx, patient, r.uniqueSystems); err != nil {
+			return err
+		}
+		return tx.Create(newHistoryEntry(patient, http.MethodPut)).Error
+	})
+	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to update patient with ID %d: %v", patient.ID, err)
 		return err
 	}
-	logger.WithContext(ctx).Infof("Patient updated successfully with ID: %d", patient.ID)
+	logger.WithContext(ctx).Infof("Patient updated successfully with ID: %d to version %d", patient.ID, patient.VersionID)
 	return nil
 }
 
-// Delete soft deletes a patient record
-func (r *patientRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&domain.Patient{}, id).Error; err != nil {
+// Delete soft deletes a patient record and records the deletion as a new version.
+// A non-zero expectedVersion must match the stored version.
+func (r *patientRepository) Delete(ctx context.Context, id uint, expectedVersion int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockPatient(tx, id)
+		if errors.Is(err, domain.ErrNotFound) && expectedVersion == 0 {
+			// Deleting a missing patient is a no-op
+			return nil
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrVersionConflict
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, expectedVersion); err != nil {
+			return err
+		}
+		current.VersionID++
+		if err := tx.Model(current).Update("version_id", current.VersionID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(current).Error; err != nil {
+			return err
+		}
+		// Identifiers of a deleted patient are free to be assigned again
+		if err := tx.Where("patient_id = ?", id).Delete(&domain.PatientIdentifier{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.PatientHistory{
+			PatientID: id,
+			LogicalID: current.LogicalID,
+			VersionID: current.VersionID,
+			Method:    http.MethodDelete,
+		}).Error
+	})
+	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to delete patient with ID %d: %v", id, err)
 		return err
 	}
@@ -90,6 +341,91 @@ func (r *patientRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// Undelete restores a deleted patient as a new version, indexing its identifiers again.
+// Restoring a patient that is not deleted is a conflict, as is restoring an identifier of a
+// unique system that another patient took since the deletion.
+func (r *patientRepository) Undelete(ctx context.Context, logicalID string) (*domain.Patient, error) {
+	ctx, span := tracer.StartSpan(ctx, "Undelete")
+	defer span.End()
+	var patient domain.Patient
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("logical_id = ?", logicalID).First(&patient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+		if !patient.DeletedAt.Valid {
+			return fmt.Errorf("%w: patient %s is not deleted", domain.ErrConflict, logicalID)
+		}
+		patient.VersionID++
+		patient.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Save(&patient).Error; err != nil {
+			return err
+		}
+		if err := indexIdentifiers(tx, &patient, r.uniqueSystems); err != nil {
+			return err
+		}
+		return tx.Create(newHistoryEntry(&patient, http.MethodPut)).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to undelete patient %s: %v", logicalID, err)
+		return nil, err
+	}
+	logger.WithContext(ctx).Infof("Patient %s undeleted as version %d", logicalID, patient.VersionID)
+	return &patient, nil
+}
+
+// Expunge permanently removes a patient, deleted or not, along with its identifiers and the
+// merges it took part in, returning the number of rows of patient data removed. With history
+// the patient's versions are removed too; otherwise a patient that was not yet deleted gets a
+// deletion version so the history it leaves behind ends with its removal.
+func (r *patientRepository) Expunge(ctx context.Context, logicalID string, history bool) (int64, error) {
+	ctx, span := tracer.StartSpan(ctx, "Expunge")
+	defer span.End()
+	var removed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient domain.Patient
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("logical_id = ?", logicalID).First(&patient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Where("patient_id = ?", patient.ID).Delete(&domain.PatientIdentifier{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("source_id = ? OR target_id = ?", patient.ID, patient.ID).Delete(&domain.PatientMerge{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&patient).Error; err != nil {
+			return err
+		}
+		removed = 1
+
+		if !history {
+			if patient.DeletedAt.Valid {
+				return nil
+			}
+			return tx.Create(&domain.PatientHistory{
+				PatientID: patient.ID,
+				LogicalID: patient.LogicalID,
+				VersionID: patient.VersionID + 1,
+				Method:    http.MethodDelete,
+			}).Error
+		}
+		result := tx.Where("patient_id = ?", patient.ID).Delete(&domain.PatientHistory{})
+		removed += result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to expunge patient %s: %v", logicalID, err)
+		return 0, err
+	}
+	logger.WithContext(ctx).Infof("Patient %s expunged, removing %d rows", logicalID, removed)
+	return removed, nil
+}
+
 // Count returns the total number of patients
 func (r *patientRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
@@ -99,3 +435,159 @@ func (r *patientRepository) Count(ctx context.Context) (int64, error) {
 	}
 	return count, nil
 }
+
+// SearchCount counts the patients matching a FHIR search query without loading them
+func (r *patientRepository) SearchCount(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	ctx, span := tracer.StartSpan(ctx, "SearchCount")
+	defer span.End()
+
+	conditions, err := buildPatientConditions(query)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid patient search: %v", err)
+		return 0, err
+	}
+	var total int64
+	if err := applyConditions(r.db.WithContext(ctx).Model(&domain.Patient{}), conditions).Count(&total).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to count patient search results: %v", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// History retrieves history entries, newest first, for one patient or across all patients
+func (r *patientRepository) History(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		if query.PatientID != "" {
+			db = db.Where("logical_id = ?", query.PatientID)
+		}
+		if query.Since != nil {
+			db = db.Where("created_at >= ?", *query.Since)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&domain.PatientHistory{}).Scopes(filter).Count(&total).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to count patient history: %v", err)
+		return nil, 0, err
+	}
+
+	var entries []*domain.PatientHistory
+	err := r.db.WithContext(ctx).
+		Scopes(filter).
+		Order("created_at DESC, id DESC").
+		Limit(query.Count).
+		Offset(query.Offset).
+		Find(&entries).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get patient history: %v", err)
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetVersion retrieves a specific version of a patient from the history
+func (r *patientRepository) GetVersion(ctx context.Context, logicalID string, versionID int) (*domain.PatientHistory, error) {
+	var entry domain.PatientHistory
+	err := r.db.WithContext(ctx).Where("logical_id = ? AND version_id = ?", logicalID, versionID).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WithContext(ctx).Warnf("Version %d of patient %s not found", versionID, logicalID)
+			return nil, domain.ErrNotFound
+		}
+		logger.WithContext(ctx).Errorf("Failed to get version %d of patient %s: %v", versionID, logicalID, err)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// MatchCandidates retrieves up to limit patients agreeing with any of the match criteria,
+// which keeps $match from scoring every stored patient
+func (r *patientRepository) MatchCandidates(ctx context.Context, criteria domain.MatchCriteria, limit int) ([]*domain.Patient, error) {
+	ctx, span := tracer.StartSpan(ctx, "MatchCandidates")
+	defer span.End()
+
+	var conditions []string
+	var args []interface{}
+	if criteria.BirthDate != nil {
+		conditions = append(conditions, "birth_date = ?")
+		args = append(args, *criteria.BirthDate)
+	}
+	for _, prefix := range criteria.FamilyPrefixes {
+		conditions = append(conditions, "family ILIKE ?")
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	if criteria.BirthDate != nil {
+		for _, prefix := range criteria.GivenPrefixes {
+			conditions = append(conditions, "(given ILIKE ? AND EXTRACT(YEAR FROM birth_date) = ?)")
+			args = append(args, escapeLike(prefix)+"%", criteria.BirthDate.Year())
+		}
+	}
+	for _, identifier := range criteria.Identifiers {
+		conditions = append(conditions, identifierExists+" AND pi.system = ? AND pi.value = ?)")
+		args = append(args, identifier.System, identifier.Value)
+	}
+
+	var patients []*domain.Patient
+	if len(conditions) == 0 {
+		return patients, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where(strings.Join(conditions, " OR "), args...).
+		Order("id").
+		Limit(limit).
+		Find(&patients).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get match candidates: %v", err)
+		return nil, err
+	}
+	return patients, nil
+}
+
+// Transaction runs fn with a repository bound to a single database transaction, which is
+// committed when fn succeeds and rolled back when it returns an error. Writes made through
+// the bound repository run in nested transactions backed by savepoints.
+func (r *patientRepository) Transaction(ctx context.Context, fn func(repo domain.PatientRepository) error) error {
+	ctx, span := tracer.StartSpan(ctx, "Transaction")
+	defer span.End()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&patientRepository{db: tx, uniqueSystems: r.uniqueSystems})
+	})
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Transaction rolled back: %v", err)
+		return err
+	}
+	return nil
+}
+
+// lockPatient loads the current row of a patient and locks it for the rest of the transaction
+func lockPatient(tx *gorm.DB, id uint) (*domain.Patient, error) {
+	var current domain.Patient
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &current, nil
+}
+
+// checkVersion verifies the locked row is at the expected version; zero skips the check
+func checkVersion(current *domain.Patient, expectedVersion int) error {
+	if expectedVersion != 0 && current.VersionID != expectedVersion {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// newHistoryEntry snapshots the current state of a patient as a history entry
+func newHistoryEntry(patient *domain.Patient, method string) *domain.PatientHistory {
+	return &domain.PatientHistory{
+		PatientID: patient.ID,
+		LogicalID: patient.LogicalID,
+		VersionID: patient.VersionID,
+		Method:    method,
+		FHIRData:  patient.FHIRData,
+		CreatedAt: patient.UpdatedAt,
+	}
+}
@@ -2,12 +2,16 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
 	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
 	"go-fhir-demo/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -47,17 +51,20 @@ func (suite *PatientRepositoryTestSuite) SetupSuite() {
 	})
 	suite.Require().NoError(err)
 
-	err = db.AutoMigrate(&domain.Patient{})
+	err = db.AutoMigrate(&domain.Patient{}, &domain.PatientHistory{}, &domain.PatientIdentifier{}, &domain.PatientMerge{}, &domain.Resource{}, &domain.ResourceSearchValue{})
 	suite.Require().NoError(err)
+	suite.Require().NoError(MigrateLogicalIDs(db))
+	suite.Require().NoError(MigrateSortIndexes(db))
+	suite.Require().NoError(MigrateSearchText(db))
 
 	suite.db = db
-	suite.repository = NewPatientRepository(db)
+	suite.repository = NewPatientRepository(db, []string{"urn:mrn"})
 }
 
 // SetupTest runs before each test
 func (suite *PatientRepositoryTestSuite) SetupTest() {
 	// Clean up data before each test
-	suite.db.Exec("TRUNCATE TABLE patients RESTART IDENTITY CASCADE")
+	suite.db.Exec("TRUNCATE TABLE patients, patient_history, patient_identifiers, patient_merges, resources, resource_search_values RESTART IDENTITY CASCADE")
 }
 
 // TearDownSuite cleans up after all tests
@@ -81,6 +88,7 @@ func (suite *PatientRepositoryTestSuite) TestCreate_Success() {
 	active := true
 	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 	patient := &domain.Patient{
+		LogicalID: "p1",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p1"}`),
 		Active:    &active,
 		Family:    "Doe",
@@ -113,6 +121,7 @@ func (suite *PatientRepositoryTestSuite) TestGetByID_Success() {
 	active := true
 	birthDate := time.Date(1985, 5, 15, 0, 0, 0, 0, time.UTC)
 	patient := &domain.Patient{
+		LogicalID: "p2",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p2"}`),
 		Active:    &active,
 		Family:    "Smith",
@@ -137,12 +146,69 @@ func (suite *PatientRepositoryTestSuite) TestGetByID_NotFound() {
 	assert.Nil(suite.T(), got)
 }
 
+// TestGetByLogicalID_Success tests retrieval by FHIR logical id
+func (suite *PatientRepositoryTestSuite) TestGetByLogicalID_Success() {
+	patient := &domain.Patient{
+		LogicalID: "b7e4c1d2-0a9f-4a44-9d8e-1f2a3b4c5d6e",
+		FHIRData:  []byte(`{"resourceType":"Patient","id":"b7e4c1d2-0a9f-4a44-9d8e-1f2a3b4c5d6e"}`),
+		Family:    "Omicron",
+	}
+	suite.Require().NoError(suite.repository.Create(context.Background(), patient))
+
+	got, err := suite.repository.GetByLogicalID(context.Background(), patient.LogicalID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), patient.ID, got.ID)
+
+	_, err = suite.repository.GetByLogicalID(context.Background(), "missing")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestSequentialIDGenerator tests that sequential logical ids are increasing numbers
+func (suite *PatientRepositoryTestSuite) TestSequentialIDGenerator() {
+	generator, err := NewIDGenerator(suite.db, IDStrategySequential)
+	suite.Require().NoError(err)
+
+	first, err := generator.NextID(context.Background())
+	suite.Require().NoError(err)
+	second, err := generator.NextID(context.Background())
+	suite.Require().NoError(err)
+
+	firstValue, _ := strconv.Atoi(first)
+	secondValue, _ := strconv.Atoi(second)
+	assert.Positive(suite.T(), firstValue)
+	assert.Equal(suite.T(), firstValue+1, secondValue)
+}
+
+// TestMigrateLogicalIDs tests that patients stored without a logical id get their primary key
+func (suite *PatientRepositoryTestSuite) TestMigrateLogicalIDs() {
+	// Arrange
+	suite.Require().NoError(suite.db.Exec(
+		`INSERT INTO patients (fhir_data, family, version_id) VALUES ('{"resourceType":"Patient"}', 'Legacy', 1)`).Error)
+	var legacy domain.Patient
+	suite.Require().NoError(suite.db.Where("family = ?", "Legacy").First(&legacy).Error)
+	suite.Require().NoError(suite.db.Create(&domain.PatientHistory{PatientID: legacy.ID, VersionID: 1, Method: "POST", FHIRData: legacy.FHIRData}).Error)
+
+	// Act
+	err := MigrateLogicalIDs(suite.db)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	expected := strconv.FormatUint(uint64(legacy.ID), 10)
+	got, err := suite.repository.GetByLogicalID(context.Background(), expected)
+	suite.Require().NoError(err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Patient","id":"`+expected+`"}`, string(got.FHIRData))
+	version, err := suite.repository.GetVersion(context.Background(), expected, 1)
+	suite.Require().NoError(err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Patient","id":"`+expected+`"}`, string(version.FHIRData))
+}
+
 // TestGetAll_Success tests successful retrieval of all patients
 func (suite *PatientRepositoryTestSuite) TestGetAll_Success() {
 	active := true
 	birthDate1 := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 	birthDate2 := time.Date(1992, 2, 2, 0, 0, 0, 0, time.UTC)
 	p1 := &domain.Patient{
+		LogicalID: "p3",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p3"}`),
 		Active:    &active,
 		Family:    "Alpha",
@@ -151,6 +217,7 @@ func (suite *PatientRepositoryTestSuite) TestGetAll_Success() {
 		BirthDate: &birthDate1,
 	}
 	p2 := &domain.Patient{
+		LogicalID: "p4",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p4"}`),
 		Active:    &active,
 		Family:    "Beta",
@@ -172,7 +239,8 @@ func (suite *PatientRepositoryTestSuite) TestGetAll_WithPagination() {
 	// Arrange
 	for i := 0; i < 5; i++ {
 		patient := &domain.Patient{
-			FHIRData:  []byte(`{"resourceType":"Patient","id":"p5"}`),
+			LogicalID: fmt.Sprintf("page-%d", i),
+			FHIRData:  []byte(`{"resourceType":"Patient"}`),
 			Active:    utils.CreateBoolPtr(true),
 			Family:    "Patient",
 			Given:     fmt.Sprintf("%d", i),
@@ -199,6 +267,7 @@ func (suite *PatientRepositoryTestSuite) TestUpdate_Success() {
 	active := true
 	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 	patient := &domain.Patient{
+		LogicalID: "p5",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p5"}`),
 		Active:    &active,
 		Family:    "Gamma",
@@ -209,7 +278,7 @@ func (suite *PatientRepositoryTestSuite) TestUpdate_Success() {
 	err := suite.repository.Create(context.Background(), patient)
 	assert.NoError(suite.T(), err)
 	patient.Family = "Delta"
-	err2 := suite.repository.Update(context.Background(), patient)
+	err2 := suite.repository.Update(context.Background(), patient, 0)
 	assert.NoError(suite.T(), err2)
 	got, _ := suite.repository.GetByID(context.Background(), patient.ID)
 	assert.Equal(suite.T(), "Delta", got.Family)
@@ -220,6 +289,7 @@ func (suite *PatientRepositoryTestSuite) TestDelete_Success() {
 	active := true
 	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 	patient := &domain.Patient{
+		LogicalID: "p6",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p6"}`),
 		Active:    &active,
 		Family:    "Epsilon",
@@ -229,7 +299,7 @@ func (suite *PatientRepositoryTestSuite) TestDelete_Success() {
 	}
 	err := suite.repository.Create(context.Background(), patient)
 	assert.NoError(suite.T(), err)
-	err = suite.repository.Delete(context.Background(), patient.ID)
+	err = suite.repository.Delete(context.Background(), patient.ID, 0)
 	assert.NoError(suite.T(), err)
 	got, err := suite.repository.GetByID(context.Background(), patient.ID)
 	assert.Error(suite.T(), err)
@@ -241,6 +311,7 @@ func (suite *PatientRepositoryTestSuite) TestCount_Success() {
 	active := true
 	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 	p1 := &domain.Patient{
+		LogicalID: "p7",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p7"}`),
 		Active:    &active,
 		Family:    "Zeta",
@@ -249,6 +320,7 @@ func (suite *PatientRepositoryTestSuite) TestCount_Success() {
 		BirthDate: &birthDate,
 	}
 	p2 := &domain.Patient{
+		LogicalID: "p8",
 		FHIRData:  []byte(`{"resourceType":"Patient","id":"p8"}`),
 		Active:    &active,
 		Family:    "Eta",
@@ -274,3 +346,557 @@ func (suite *PatientRepositoryTestSuite) TestCount_EmptyTable() {
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(0), count)
 }
+
+// TestSearch_ByNameGenderAndBirthDate tests FHIR search over the indexed columns
+func (suite *PatientRepositoryTestSuite) TestSearch_ByNameGenderAndBirthDate() {
+	// Arrange
+	active := true
+	birthDate1 := time.Date(1980, 3, 1, 0, 0, 0, 0, time.UTC)
+	birthDate2 := time.Date(1995, 7, 9, 0, 0, 0, 0, time.UTC)
+	patients := []*domain.Patient{
+		{LogicalID: "s1", FHIRData: []byte(`{"resourceType":"Patient"}`), Active: &active, Family: "Johnson", Given: "Anna", Gender: "female", BirthDate: &birthDate1},
+		{LogicalID: "s2", FHIRData: []byte(`{"resourceType":"Patient"}`), Active: &active, Family: "Jones", Given: "Bob", Gender: "male", BirthDate: &birthDate2},
+		{LogicalID: "s3", FHIRData: []byte(`{"resourceType":"Patient"}`), Active: &active, Family: "Smith", Given: "Johan", Gender: "male", BirthDate: &birthDate1},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+
+	search := func(rawQuery string) ([]*domain.Patient, int64) {
+		values, err := url.ParseQuery(rawQuery)
+		suite.Require().NoError(err)
+		query, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+		suite.Require().NoError(err)
+		page, err := suite.repository.Search(context.Background(), query)
+		suite.Require().NoError(err)
+		return page.Patients, page.Total
+	}
+
+	// Act & Assert
+	result, total := search("name=jo")
+	assert.Equal(suite.T(), int64(3), total)
+	assert.Len(suite.T(), result, 3)
+
+	_, total = search("family=jo&gender=male")
+	assert.Equal(suite.T(), int64(1), total)
+
+	values, err := url.ParseQuery("family=jo&gender=male")
+	suite.Require().NoError(err)
+	query, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+	suite.Require().NoError(err)
+	count, err := suite.repository.SearchCount(context.Background(), query)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), count)
+
+	_, total = search("family:exact=Jones,Smith")
+	assert.Equal(suite.T(), int64(2), total)
+
+	_, total = search("birthdate=1980")
+	assert.Equal(suite.T(), int64(2), total)
+
+	_, total = search("birthdate=gt1990-01-01&birthdate=lt2000")
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, total = search("gender:not=male")
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, total = search("name:contains=%25")
+	assert.Equal(suite.T(), int64(0), total)
+}
+
+// TestSearch_SortAndKeysetPaging tests that sorted pages follow each other through their
+// cursors in both directions, and that the last page is found from the end
+func (suite *PatientRepositoryTestSuite) TestSearch_SortAndKeysetPaging() {
+	// Arrange
+	birthDate := time.Date(1980, 3, 1, 0, 0, 0, 0, time.UTC)
+	patients := []*domain.Patient{
+		{LogicalID: "k1", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Brown", Given: "Zoe", BirthDate: &birthDate},
+		{LogicalID: "k2", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Adams", Given: "Amy"},
+		{LogicalID: "k3", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Brown", Given: "Al", BirthDate: &birthDate},
+		{LogicalID: "k4", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Clark", Given: "Max"},
+		{LogicalID: "k5", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Adams", Given: "Bea"},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+	search := func(values url.Values, cursor *fhirsearch.Cursor) *domain.PatientPage {
+		query, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+		suite.Require().NoError(err)
+		query.Cursor = cursor
+		page, err := suite.repository.Search(context.Background(), query)
+		suite.Require().NoError(err)
+		return page
+	}
+	ids := func(page *domain.PatientPage) []string {
+		result := make([]string, 0, len(page.Patients))
+		for _, p := range page.Patients {
+			result = append(result, p.LogicalID)
+		}
+		return result
+	}
+	values := url.Values{"_sort": {"family,-given"}, "_count": {"2"}}
+
+	// Act & Assert: forward through every page
+	first := search(values, nil)
+	assert.Equal(suite.T(), int64(5), first.Total)
+	assert.Equal(suite.T(), []string{"k5", "k2"}, ids(first))
+	assert.Nil(suite.T(), first.Previous)
+	suite.Require().NotNil(first.Next)
+
+	second := search(values, first.Next)
+	assert.Equal(suite.T(), []string{"k1", "k3"}, ids(second))
+	assert.Equal(suite.T(), int64(-1), second.Total)
+	suite.Require().NotNil(second.Previous)
+	suite.Require().NotNil(second.Next)
+
+	third := search(values, second.Next)
+	assert.Equal(suite.T(), []string{"k4"}, ids(third))
+	assert.Nil(suite.T(), third.Next)
+
+	// Backward from the second page, and the last page from the end
+	assert.Equal(suite.T(), []string{"k5", "k2"}, ids(search(values, second.Previous)))
+	last := search(values, &fhirsearch.Cursor{Sort: "family,-given", Backward: true})
+	assert.Equal(suite.T(), []string{"k3", "k4"}, ids(last))
+	assert.Nil(suite.T(), last.Next)
+	assert.NotNil(suite.T(), last.Previous)
+
+	// Patients without a birth date sort last, and _total=none skips the count
+	page := search(url.Values{"_sort": {"birthdate,_id"}, "_count": {"10"}, "_total": {"none"}}, nil)
+	assert.Equal(suite.T(), []string{"k1", "k3", "k2", "k4", "k5"}, ids(page))
+	assert.Equal(suite.T(), int64(-1), page.Total)
+
+	page = search(url.Values{"_sort": {"-_lastUpdated"}, "_count": {"1"}}, nil)
+	assert.Equal(suite.T(), []string{"k5"}, ids(page))
+
+	// _total=accurate counts on every page
+	accurate := url.Values{"_sort": {"family,-given"}, "_count": {"2"}, "_total": {"accurate"}}
+	assert.Equal(suite.T(), int64(5), search(accurate, first.Next).Total)
+}
+
+// TestSearch_Content tests full-text search over the patient JSON, ranked by relevance
+func (suite *PatientRepositoryTestSuite) TestSearch_Content() {
+	// Arrange
+	patients := []*domain.Patient{
+		{LogicalID: "t1", Family: "Baker", FHIRData: []byte(`{"resourceType":"Patient","name":[{"family":"Baker","given":["Ann"]}],` +
+			`"telecom":[{"system":"email","value":"ann.baker@example.org"}],"address":[{"line":["12 Elm Street"],"city":"Springfield"}]}`)},
+		{LogicalID: "t2", Family: "Elm", FHIRData: []byte(`{"resourceType":"Patient","name":[{"family":"Elm"}],` +
+			`"address":[{"line":["4 Oak Road"],"city":"Shelbyville"}]}`)},
+		{LogicalID: "t3", Family: "Stone", FHIRData: []byte(`{"resourceType":"Patient","name":[{"family":"Stone"}],` +
+			`"text":{"status":"generated","div":"<div xmlns=\"http://www.w3.org/1999/xhtml\">Moved to Elm Street</div>"}}`)},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+	search := func(values url.Values) []string {
+		query, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+		suite.Require().NoError(err)
+		page, err := suite.repository.Search(context.Background(), query)
+		suite.Require().NoError(err)
+		ids := make([]string, 0, len(page.Patients))
+		for _, p := range page.Patients {
+			assert.Greater(suite.T(), p.Score, 0.0)
+			ids = append(ids, p.LogicalID)
+		}
+		return ids
+	}
+
+	// Act & Assert: a name ranks above an address, which ranks above the narrative
+	assert.Equal(suite.T(), []string{"t2", "t1", "t3"}, search(url.Values{"_content": {"elm"}}))
+	assert.Equal(suite.T(), []string{"t1"}, search(url.Values{"_content": {"ann.bak"}}))
+	assert.Equal(suite.T(), []string{"t1"}, search(url.Values{"_content": {"example.org"}}))
+	assert.Equal(suite.T(), []string{"t1", "t3"}, search(url.Values{"_content": {"elm street"}, "_sort": {"_id"}}))
+	assert.Equal(suite.T(), []string{"t3"}, search(url.Values{"_text": {"elm"}}))
+	assert.Equal(suite.T(), []string{"t2"}, search(url.Values{"_content": {"elm"}, "family": {"elm"}}))
+	assert.Empty(suite.T(), search(url.Values{"_content": {"!!"}}))
+}
+
+// TestUpdate_RecordsHistory tests that every write creates a new version in the history
+func (suite *PatientRepositoryTestSuite) TestUpdate_RecordsHistory() {
+	// Arrange
+	patient := &domain.Patient{
+		LogicalID: "theta",
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Theta"}]}`),
+		Family:    "Theta",
+	}
+	suite.Require().NoError(suite.repository.Create(context.Background(), patient))
+	assert.Equal(suite.T(), 1, patient.VersionID)
+
+	// Act
+	patient.FHIRData = []byte(`{"resourceType":"Patient","name":[{"family":"Iota"}]}`)
+	patient.Family = "Iota"
+	suite.Require().NoError(suite.repository.Update(context.Background(), patient, 0))
+	suite.Require().NoError(suite.repository.Delete(context.Background(), patient.ID, 0))
+
+	// Assert
+	assert.Equal(suite.T(), 2, patient.VersionID)
+	entries, total, err := suite.repository.History(context.Background(), domain.HistoryQuery{PatientID: "theta", Count: 10})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), total)
+	assert.Equal(suite.T(), "DELETE", entries[0].Method)
+	assert.Equal(suite.T(), 3, entries[0].VersionID)
+
+	first, err := suite.repository.GetVersion(context.Background(), "theta", 1)
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Patient","name":[{"family":"Theta"}]}`, string(first.FHIRData))
+
+	_, err = suite.repository.GetVersion(context.Background(), "theta", 9)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestUpdate_VersionConflict tests that a stale expected version is rejected without writing
+func (suite *PatientRepositoryTestSuite) TestUpdate_VersionConflict() {
+	// Arrange
+	patient := &domain.Patient{
+		LogicalID: "kappa",
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Kappa"}]}`),
+		Family:    "Kappa",
+	}
+	suite.Require().NoError(suite.repository.Create(context.Background(), patient))
+	suite.Require().NoError(suite.repository.Update(context.Background(), patient, 1))
+
+	// Act
+	stale := &domain.Patient{
+		ID:        patient.ID,
+		LogicalID: "kappa",
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Lambda"}]}`),
+		Family:    "Lambda",
+	}
+	err := suite.repository.Update(context.Background(), stale, 1)
+	deleteErr := suite.repository.Delete(context.Background(), patient.ID, 1)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrVersionConflict)
+	assert.ErrorIs(suite.T(), deleteErr, domain.ErrVersionConflict)
+	stored, err := suite.repository.GetByID(context.Background(), patient.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, stored.VersionID)
+	assert.Equal(suite.T(), "Kappa", stored.Family)
+}
+
+// TestSearch_ByIdentifier tests identifier search over the stored FHIR resource
+func (suite *PatientRepositoryTestSuite) TestSearch_ByIdentifier() {
+	// Arrange
+	patients := []*domain.Patient{
+		{LogicalID: "mu", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"100"}]}`), Family: "Mu"},
+		{LogicalID: "nu", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:ssn","value":"100"}]}`), Family: "Nu"},
+		{LogicalID: "xi", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Xi"},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+
+	search := func(rawQuery string) int64 {
+		values, err := url.ParseQuery(rawQuery)
+		suite.Require().NoError(err)
+		query, err := fhirsearch.Parse(values, domain.PatientSearchParameters)
+		suite.Require().NoError(err)
+		page, err := suite.repository.Search(context.Background(), query)
+		suite.Require().NoError(err)
+		return page.Total
+	}
+
+	// Act & Assert
+	assert.Equal(suite.T(), int64(1), search("identifier=urn:mrn|100"))
+	assert.Equal(suite.T(), int64(2), search("identifier=100"))
+	assert.Equal(suite.T(), int64(0), search("identifier=urn:mrn|200"))
+	assert.Equal(suite.T(), int64(1), search("identifier:missing=true"))
+}
+
+// TestSearch_ByIdentifierSystem tests matching every value of a system and identifiers without a system
+func (suite *PatientRepositoryTestSuite) TestSearch_ByIdentifierSystem() {
+	// Arrange
+	patients := []*domain.Patient{
+		{LogicalID: "omicron", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"1"},{"value":"local-1"}]}`)},
+		{LogicalID: "pi", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"2"}]}`)},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+	search := func(value string) int64 {
+		query, err := fhirsearch.Parse(url.Values{"identifier": {value}}, domain.PatientSearchParameters)
+		suite.Require().NoError(err)
+		page, err := suite.repository.Search(context.Background(), query)
+		suite.Require().NoError(err)
+		return page.Total
+	}
+
+	// Act & Assert
+	assert.Equal(suite.T(), int64(2), search("urn:mrn|"))
+	assert.Equal(suite.T(), int64(1), search("|local-1"))
+	assert.Equal(suite.T(), int64(0), search("|1"))
+}
+
+// TestUniqueIdentifiers tests that an identifier of a unique system belongs to one patient at a time
+func (suite *PatientRepositoryTestSuite) TestUniqueIdentifiers() {
+	// Arrange
+	first := &domain.Patient{LogicalID: "rho", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"7"}]}`)}
+	suite.Require().NoError(suite.repository.Create(context.Background(), first))
+
+	// Act
+	duplicate := &domain.Patient{LogicalID: "sigma", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"7"}]}`)}
+	duplicateErr := suite.repository.Create(context.Background(), duplicate)
+	otherSystem := &domain.Patient{LogicalID: "tau", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:ssn","value":"7"}]}`)}
+	otherSystemErr := suite.repository.Create(context.Background(), otherSystem)
+	first.FHIRData = []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"7"}],"active":true}`)
+	updateErr := suite.repository.Update(context.Background(), first, 0)
+
+	// Assert
+	assert.ErrorIs(suite.T(), duplicateErr, domain.ErrConflict)
+	assert.NoError(suite.T(), otherSystemErr)
+	assert.NoError(suite.T(), updateErr)
+
+	suite.Require().NoError(suite.repository.Delete(context.Background(), first.ID, 0))
+	reused := &domain.Patient{LogicalID: "upsilon", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"7"}]}`)}
+	assert.NoError(suite.T(), suite.repository.Create(context.Background(), reused))
+}
+
+// TestCreateBatch tests that a batch is stored with its history and identifiers, and that a
+// duplicate identifier within the batch rolls the whole batch back
+func (suite *PatientRepositoryTestSuite) TestCreateBatch() {
+	// Arrange
+	patients := []*domain.Patient{
+		{LogicalID: "b1", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"b1"}]}`)},
+		{LogicalID: "b2", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"b2"}]}`)},
+	}
+	conflicting := []*domain.Patient{
+		{LogicalID: "b3", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"b3"}]}`)},
+		{LogicalID: "b4", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"b3"}]}`)},
+	}
+
+	// Act
+	err := suite.repository.CreateBatch(context.Background(), patients)
+	conflictErr := suite.repository.CreateBatch(context.Background(), conflicting)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.NotZero(suite.T(), patients[0].ID)
+	assert.Equal(suite.T(), 1, patients[1].VersionID)
+	version, err := suite.repository.GetVersion(context.Background(), "b2", 1)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "b2", version.LogicalID)
+	duplicate := &domain.Patient{LogicalID: "b5", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"b1"}]}`)}
+	assert.ErrorIs(suite.T(), suite.repository.Create(context.Background(), duplicate), domain.ErrConflict)
+
+	assert.ErrorIs(suite.T(), conflictErr, domain.ErrConflict)
+	assert.Zero(suite.T(), conflicting[0].ID)
+	count, err := suite.repository.Count(context.Background())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), count)
+}
+
+// TestMigrateIdentifiers tests that identifiers of patients stored before indexing are indexed
+func (suite *PatientRepositoryTestSuite) TestMigrateIdentifiers() {
+	// Arrange
+	suite.Require().NoError(suite.db.Exec(`INSERT INTO patients (logical_id, fhir_data, version_id)
+		VALUES ('phi', '{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"9"}]}', 1)`).Error)
+
+	// Act
+	err := MigrateIdentifiers(suite.db)
+
+	// Assert
+	suite.Require().NoError(err)
+	var identifiers []domain.PatientIdentifier
+	suite.db.Find(&identifiers)
+	suite.Require().Len(identifiers, 1)
+	assert.Equal(suite.T(), "urn:mrn", identifiers[0].System)
+	assert.Equal(suite.T(), "9", identifiers[0].Value)
+}
+
+// TestMatchCandidates tests that candidates agree with the birth date, a name prefix or an identifier
+func (suite *PatientRepositoryTestSuite) TestMatchCandidates() {
+	// Arrange
+	birthDate := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	sameYear := time.Date(1980, 6, 30, 0, 0, 0, 0, time.UTC)
+	otherYear := time.Date(1990, 6, 30, 0, 0, 0, 0, time.UTC)
+	patients := []*domain.Patient{
+		{LogicalID: "m1", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Smith", Given: "Anna", BirthDate: &birthDate},
+		{LogicalID: "m2", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Doherty", Given: "Bob", BirthDate: &otherYear},
+		{LogicalID: "m3", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Jones", Given: "Johan", BirthDate: &sameYear},
+		{LogicalID: "m4", FHIRData: []byte(`{"resourceType":"Patient"}`), Family: "Jones", Given: "Johan", BirthDate: &otherYear},
+		{LogicalID: "m5", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"9"}]}`), Family: "Brown"},
+	}
+	for _, p := range patients {
+		suite.Require().NoError(suite.repository.Create(context.Background(), p))
+	}
+
+	// Act
+	candidates, err := suite.repository.MatchCandidates(context.Background(), domain.MatchCriteria{
+		BirthDate:      &birthDate,
+		FamilyPrefixes: []string{"do"},
+		GivenPrefixes:  []string{"jo"},
+		Identifiers:    []domain.PatientIdentifier{{System: "urn:mrn", Value: "9"}},
+	}, 10)
+	suite.Require().NoError(err)
+	limited, err := suite.repository.MatchCandidates(context.Background(), domain.MatchCriteria{FamilyPrefixes: []string{"jo"}}, 1)
+	suite.Require().NoError(err)
+	none, err := suite.repository.MatchCandidates(context.Background(), domain.MatchCriteria{}, 10)
+	suite.Require().NoError(err)
+
+	// Assert
+	var ids []string
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.LogicalID)
+	}
+	assert.Equal(suite.T(), []string{"m1", "m2", "m3", "m5"}, ids)
+	assert.Len(suite.T(), limited, 1)
+	assert.Empty(suite.T(), none)
+}
+
+// TestTransaction_RollsBackOnError tests that writes made in a failed transaction are discarded
+func (suite *PatientRepositoryTestSuite) TestTransaction_RollsBackOnError() {
+	// Arrange
+	failure := errors.New("second write failed")
+
+	// Act
+	err := suite.repository.Transaction(context.Background(), func(repo domain.PatientRepository) error {
+		patient := &domain.Patient{LogicalID: "omicron", FHIRData: []byte(`{"resourceType":"Patient","id":"omicron"}`), Family: "Omicron"}
+		if err := repo.Create(context.Background(), patient); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, failure)
+	count, err := suite.repository.Count(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), count)
+	var history int64
+	suite.Require().NoError(suite.db.Model(&domain.PatientHistory{}).Count(&history).Error)
+	assert.Equal(suite.T(), int64(0), history)
+}
+
+// TestTransaction_Commits tests that writes made in a successful transaction are kept
+func (suite *PatientRepositoryTestSuite) TestTransaction_Commits() {
+	// Act
+	err := suite.repository.Transaction(context.Background(), func(repo domain.PatientRepository) error {
+		for _, id := range []string{"pi", "rho"} {
+			patient := &domain.Patient{LogicalID: id, FHIRData: []byte(`{"resourceType":"Patient","id":"` + id + `"}`)}
+			if err := repo.Create(context.Background(), patient); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	count, err := suite.repository.Count(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), count)
+}
+
+// TestMergeAndUnmerge tests that a merge repoints references to the source and an unmerge
+// restores those still pointing to the target
+func (suite *PatientRepositoryTestSuite) TestMergeAndUnmerge() {
+	// Arrange
+	ctx := context.Background()
+	source := &domain.Patient{LogicalID: "sigma", FHIRData: []byte(`{"resourceType":"Patient","id":"sigma","identifier":[{"system":"urn:mrn","value":"S1"}]}`)}
+	target := &domain.Patient{LogicalID: "tau", FHIRData: []byte(`{"resourceType":"Patient","id":"tau"}`)}
+	suite.Require().NoError(suite.repository.Create(ctx, source))
+	suite.Require().NoError(suite.repository.Create(ctx, target))
+	resources := NewResourceRepository(suite.db)
+	observation := &domain.Resource{ResourceType: "Observation", LogicalID: "o1", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/sigma"},"performer":[{"reference":"Patient/sigma/_history/1"}]}`)}
+	encounter := &domain.Resource{ResourceType: "Encounter", LogicalID: "e1", FHIRData: []byte(`{"resourceType":"Encounter","subject":{"reference":"Patient/sigmax"}}`)}
+	suite.Require().NoError(resources.Create(ctx, observation))
+	suite.Require().NoError(resources.Create(ctx, encounter))
+	merges := NewMergeRepository(suite.db, []string{"urn:mrn"})
+
+	// Act
+	mergedSource := &domain.Patient{ID: source.ID, LogicalID: "sigma", FHIRData: []byte(`{"resourceType":"Patient","id":"sigma","active":false}`)}
+	mergedTarget := &domain.Patient{ID: target.ID, LogicalID: "tau", FHIRData: []byte(`{"resourceType":"Patient","id":"tau","identifier":[{"system":"urn:mrn","value":"S1"}]}`)}
+	merge := &domain.PatientMerge{SourceData: source.FHIRData, TargetData: target.FHIRData}
+	suite.Require().NoError(merges.Merge(ctx, merge, mergedSource, mergedTarget, 1, 1))
+
+	// Assert
+	found, err := resources.GetByLogicalID(ctx, "Observation", "o1")
+	suite.Require().NoError(err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Observation","subject":{"reference":"Patient/tau"},"performer":[{"reference":"Patient/tau"}]}`, string(found.FHIRData))
+	assert.Equal(suite.T(), 2, found.VersionID)
+	unrelated, err := resources.GetByLogicalID(ctx, "Encounter", "e1")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, unrelated.VersionID)
+	active, err := merges.GetActiveMerge(ctx, source.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), target.ID, active.TargetID)
+
+	// Act
+	suite.Require().NoError(resources.Update(ctx, &domain.Resource{
+		ID: found.ID, ResourceType: "Observation", LogicalID: "o1", CreatedAt: found.CreatedAt,
+		FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/tau"},"performer":[{"reference":"Practitioner/p1"}]}`),
+	}, 2))
+	restoredSource := &domain.Patient{ID: source.ID, LogicalID: "sigma", FHIRData: source.FHIRData}
+	restoredTarget := &domain.Patient{ID: target.ID, LogicalID: "tau", FHIRData: target.FHIRData}
+	suite.Require().NoError(merges.Unmerge(ctx, active, restoredSource, restoredTarget, 2, 2))
+
+	// Assert
+	found, err = resources.GetByLogicalID(ctx, "Observation", "o1")
+	suite.Require().NoError(err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Observation","subject":{"reference":"Patient/sigma"},"performer":[{"reference":"Practitioner/p1"}]}`, string(found.FHIRData))
+	_, err = merges.GetActiveMerge(ctx, source.ID)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+	matched, err := suite.repository.MatchCandidates(ctx, domain.MatchCriteria{Identifiers: []domain.PatientIdentifier{{System: "urn:mrn", Value: "S1"}}}, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(matched, 1)
+	assert.Equal(suite.T(), "sigma", matched[0].LogicalID)
+}
+
+// TestDeleteUndeleteAndExpunge tests that a deleted patient is gone rather than missing, can be
+// restored, and is removed for good by an expunge
+func (suite *PatientRepositoryTestSuite) TestDeleteUndeleteAndExpunge() {
+	// Arrange
+	ctx := context.Background()
+	patient := &domain.Patient{LogicalID: "upsilon", FHIRData: []byte(`{"resourceType":"Patient","id":"upsilon","identifier":[{"system":"urn:mrn","value":"U1"}]}`)}
+	suite.Require().NoError(suite.repository.Create(ctx, patient))
+	suite.Require().NoError(suite.repository.Delete(ctx, patient.ID, 1))
+
+	// Act & Assert
+	_, err := suite.repository.GetByLogicalID(ctx, "upsilon")
+	assert.ErrorIs(suite.T(), err, domain.ErrGone)
+	_, err = suite.repository.GetByLogicalID(ctx, "phi")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+
+	restored, err := suite.repository.Undelete(ctx, "upsilon")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 3, restored.VersionID)
+	found, err := suite.repository.GetByLogicalID(ctx, "upsilon")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 3, found.VersionID)
+	_, err = suite.repository.Undelete(ctx, "upsilon")
+	assert.ErrorIs(suite.T(), err, domain.ErrConflict)
+	matched, err := suite.repository.MatchCandidates(ctx, domain.MatchCriteria{Identifiers: []domain.PatientIdentifier{{System: "urn:mrn", Value: "U1"}}}, 10)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), matched, 1)
+
+	removed, err := suite.repository.Expunge(ctx, "upsilon", true)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(4), removed)
+	_, err = suite.repository.GetByLogicalID(ctx, "upsilon")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+	_, total, err := suite.repository.History(ctx, domain.HistoryQuery{PatientID: "upsilon", Count: 10})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(0), total)
+	_, err = suite.repository.Expunge(ctx, "upsilon", false)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestExpunge_KeepsHistory tests that expunging an active patient without its history
+// records the removal as a deletion version
+func (suite *PatientRepositoryTestSuite) TestExpunge_KeepsHistory() {
+	// Arrange
+	ctx := context.Background()
+	patient := &domain.Patient{LogicalID: "chi", FHIRData: []byte(`{"resourceType":"Patient","id":"chi"}`)}
+	suite.Require().NoError(suite.repository.Create(ctx, patient))
+
+	// Act
+	removed, err := suite.repository.Expunge(ctx, "chi", false)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), removed)
+	entries, total, err := suite.repository.History(ctx, domain.HistoryQuery{PatientID: "chi", Count: 10})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), total)
+	assert.Equal(suite.T(), "DELETE", entries[0].Method)
+	assert.Equal(suite.T(), 2, entries[0].VersionID)
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"gorm.io/gorm"
+)
+
+// condition is a parameterised SQL fragment; column names never come from user input
+type condition struct {
+	sql  string
+	args []interface{}
+}
+
+// patientStringColumns maps string search parameters to the indexed columns they match
+var patientStringColumns = map[string][]string{
+	"name":   {"family", "given"},
+	"family": {"family"},
+	"given":  {"given"},
+}
+
+// buildPatientConditions translates a FHIR search query into SQL conditions over the patients table
+func buildPatientConditions(query *fhirsearch.Query) ([]condition, error) {
+	conditions := make([]condition, 0, len(query.Params))
+	for _, param := range query.Params {
+		var (
+			cond condition
+			err  error
+		)
+		switch param.Name {
+		case "name", "family", "given":
+			cond = stringCondition(patientStringColumns[param.Name], param)
+		case "gender":
+			cond = tokenCondition("gender", param, func(code string) (interface{}, bool) {
+				return strings.ToLower(code), true
+			})
+		case "active":
+			cond = tokenCondition("active", param, func(code string) (interface{}, bool) {
+				active, err := strconv.ParseBool(code)
+				return active, err == nil
+			})
+		case "_id":
+			cond = tokenCondition("logical_id", param, func(code string) (interface{}, bool) {
+				return code, true
+			})
+		case "identifier":
+			cond = identifierCondition(param)
+		case "birthdate":
+			cond, err = dateCondition("birth_date", param)
+		case "_lastUpdated":
+			cond, err = dateCondition("updated_at", param)
+		case "_content", "_text":
+			cond, err = textCondition(param)
+		default:
+			err = fmt.Errorf("search parameter %q is not supported for Patient", param.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// applyConditions adds all conditions to the query; they are AND-ed together
+func applyConditions(db *gorm.DB, conditions []condition) *gorm.DB {
+	for _, cond := range conditions {
+		db = db.Where(cond.sql, cond.args...)
+	}
+	return db
+}
+
+// missingCondition handles the :missing modifier for any column
+func missingCondition(column string, param fhirsearch.Param, emptyValue bool) condition {
+	missing := param.Values[0] == "true"
+	switch {
+	case missing && emptyValue:
+		return condition{sql: fmt.Sprintf("(%s IS NULL OR %s = '')", column, column)}
+	case missing:
+		return condition{sql: column + " IS NULL"}
+	case emptyValue:
+		return condition{sql: fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", column, column)}
+	default:
+		return condition{sql: column + " IS NOT NULL"}
+	}
+}
+
+// stringCondition matches string parameters case-insensitively by prefix, or
+// exactly / by substring when the :exact or :contains modifiers are used
+func stringCondition(columns []string, param fhirsearch.Param) condition {
+	if param.Modifier == "missing" {
+		parts := make([]string, 0, len(columns))
+		for _, column := range columns {
+			parts = append(parts, missingCondition(column, param, true).sql)
+		}
+		// A multi-column parameter is missing only when every column is empty
+		joiner := " OR "
+		if param.Values[0] == "true" {
+			joiner = " AND "
+		}
+		return condition{sql: "(" + strings.Join(parts, joiner) + ")"}
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, value := range param.Values {
+		for _, column := range columns {
+			switch param.Modifier {
+			case "exact":
+				parts = append(parts, column+" = ?")
+				args = append(args, value)
+			case "contains":
+				parts = append(parts, column+" ILIKE ?")
+				args = append(args, "%"+escapeLike(value)+"%")
+			default:
+				parts = append(parts, column+" ILIKE ?")
+				args = append(args, escapeLike(value)+"%")
+			}
+		}
+	}
+	return condition{sql: "(" + strings.Join(parts, " OR ") + ")", args: args}
+}
+
+// tokenCondition matches token parameters by code; values that cannot be
+// converted for the column match nothing rather than failing the search
+func tokenCondition(column string, param fhirsearch.Param, convert func(code string) (interface{}, bool)) condition {
+	if param.Modifier == "missing" {
+		return missingCondition(column, param, false)
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, value := range param.Values {
+		_, code, _ := fhirsearch.ParseToken(value)
+		converted, ok := convert(code)
+		if !ok {
+			parts = append(parts, "FALSE")
+			continue
+		}
+		parts = append(parts, column+" = ?")
+		args = append(args, converted)
+	}
+	sql := "(" + strings.Join(parts, " OR ") + ")"
+	if param.Modifier == "not" {
+		sql = fmt.Sprintf("(%s IS NULL OR NOT %s)", column, sql)
+	}
+	return condition{sql: sql, args: args}
+}
+
+// identifierCondition matches Patient.identifier entries against the indexed identifiers.
+// A value without a system matches identifiers of any system, system| matches every value
+// of a system and |value matches identifiers without a system.
+func identifierCondition(param fhirsearch.Param) condition {
+	if param.Modifier == "missing" {
+		if param.Values[0] == "true" {
+			return condition{sql: "NOT " + identifierExists + ")"}
+		}
+		return condition{sql: identifierExists + ")"}
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, value := range param.Values {
+		system, code, hasSystem := fhirsearch.ParseToken(value)
+		switch {
+		case !hasSystem:
+			parts = append(parts, identifierExists+" AND pi.value = ?)")
+			args = append(args, code)
+		case code == "":
+			parts = append(parts, identifierExists+" AND pi.system = ?)")
+			args = append(args, system)
+		default:
+			parts = append(parts, identifierExists+" AND pi.system = ? AND pi.value = ?)")
+			args = append(args, system, code)
+		}
+	}
+	sql := "(" + strings.Join(parts, " OR ") + ")"
+	if param.Modifier == "not" {
+		sql = "NOT " + sql
+	}
+	return condition{sql: sql, args: args}
+}
+
+// dateCondition compares a date column against the range implied by each value and its prefix
+func dateCondition(column string, param fhirsearch.Param) (condition, error) {
+	if param.Modifier == "missing" {
+		return missingCondition(column, param, false), nil
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, value := range param.Values {
+		prefix, dateValue := fhirsearch.SplitPrefix(value)
+		start, end, err := fhirsearch.ParseDateRange(dateValue)
+		if err != nil {
+			return condition{}, err
+		}
+		switch prefix {
+		case fhirsearch.PrefixEq:
+			parts = append(parts, fmt.Sprintf("(%s >= ? AND %s < ?)", column, column))
+			args = append(args, start, end)
+		case fhirsearch.PrefixNe:
+			parts = append(parts, fmt.Sprintf("(%s < ? OR %s >= ?)", column, column))
+			args = append(args, start, end)
+		case fhirsearch.PrefixLt, fhirsearch.PrefixEb:
+			parts = append(parts, column+" < ?")
+			args = append(args, start)
+		case fhirsearch.PrefixGt, fhirsearch.PrefixSa:
+			parts = append(parts, column+" >= ?")
+			args = append(args, end)
+		case fhirsearch.PrefixLe:
+			parts = append(parts, column+" < ?")
+			args = append(args, end)
+		case fhirsearch.PrefixGe:
+			parts = append(parts, column+" >= ?")
+			args = append(args, start)
+		case fhirsearch.PrefixAp:
+			low, high := fhirsearch.ApproximateRange(start, end)
+			parts = append(parts, fmt.Sprintf("(%s >= ? AND %s < ?)", column, column))
+			args = append(args, low, high)
+		}
+	}
+	return condition{sql: "(" + strings.Join(parts, " OR ") + ")", args: args}, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// noBirthDate stands in for a missing birth date so patients without one sort last and
+// keyset comparisons never meet NULL
+var noBirthDate = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// sortColumn is an expression patients can be sorted and paged by
+type sortColumn struct {
+	expr  string                            // SQL expression, also used by the sort index
+	args  []interface{}                     // Arguments of the expression's placeholders
+	index string                            // Name of the index supporting the sort
+	value func(*domain.Patient) string      // Cursor value of a patient
+	parse func(string) (interface{}, error) // Query argument of a cursor value
+}
+
+// patientSortColumns maps the sortable patient search parameters to the expressions they sort by
+var patientSortColumns = map[string]sortColumn{
+	"_id": {
+		expr:  "logical_id",
+		index: "idx_patients_sort_logical_id",
+		value: func(p *domain.Patient) string { return p.LogicalID },
+		parse: parseText,
+	},
+	"_lastUpdated": {
+		expr:  "updated_at",
+		index: "idx_patients_sort_updated_at",
+		value: func(p *domain.Patient) string { return p.UpdatedAt.UTC().Format(time.RFC3339Nano) },
+		parse: parseTime,
+	},
+	"family": {
+		expr:  "COALESCE(family, '')",
+		index: "idx_patients_sort_family",
+		value: func(p *domain.Patient) string { return p.Family },
+		parse: parseText,
+	},
+	"given": {
+		expr:  "COALESCE(given, '')",
+		index: "idx_patients_sort_given",
+		value: func(p *domain.Patient) string { return p.Given },
+		parse: parseText,
+	},
+	"birthdate": {
+		expr:  "COALESCE(birth_date, '9999-12-31 00:00:00+00')",
+		index: "idx_patients_sort_birth_date",
+		value: func(p *domain.Patient) string {
+			if p.BirthDate == nil {
+				return noBirthDate.Format(time.RFC3339Nano)
+			}
+			return p.BirthDate.UTC().Format(time.RFC3339Nano)
+		},
+		parse: parseTime,
+	},
+}
+
+// patientIDColumn breaks ties between patients with equal sort values
+var patientIDColumn = sortColumn{
+	expr:  "id",
+	value: func(p *domain.Patient) string { return strconv.FormatUint(uint64(p.ID), 10) },
+	parse: func(value string) (interface{}, error) { return strconv.ParseUint(value, 10, 64) },
+}
+
+func parseText(value string) (interface{}, error) {
+	return value, nil
+}
+
+func parseTime(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// patientSortKey is a sort column with its direction
+type patientSortKey struct {
+	column     sortColumn
+	descending bool
+}
+
+// patientSortKeys resolves the _sort keys of a query, ending with the id so every row has a
+// unique position. The id follows the direction of the last key, which lets a single-key sort
+// be served by its index in either direction. A full-text search without _sort is sorted by
+// relevance.
+func patientSortKeys(query *fhirsearch.Query) ([]patientSortKey, error) {
+	keys := make([]patientSortKey, 0, len(query.Sort)+1)
+	if tsquery := patientTextQuery(query); tsquery != "" && len(query.Sort) == 0 {
+		keys = append(keys, patientSortKey{column: relevanceColumn(tsquery), descending: true})
+	}
+	for _, key := range query.Sort {
+		column, ok := patientSortColumns[key.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot sort patients by %q", domain.ErrValidation, key.Name)
+		}
+		keys = append(keys, patientSortKey{column: column, descending: key.Descending})
+	}
+	descending := len(keys) > 0 && keys[len(keys)-1].descending
+	return append(keys, patientSortKey{column: patientIDColumn, descending: descending}), nil
+}
+
+// orderClause returns the ORDER BY clause of the sort keys, reversed for a backward page
+func orderClause(keys []patientSortKey, backward bool) clause.OrderBy {
+	parts := make([]string, 0, len(keys))
+	var args []interface{}
+	for _, key := range keys {
+		direction := " ASC"
+		if key.descending != backward {
+			direction = " DESC"
+		}
+		parts = append(parts, key.column.expr+direction)
+		args = append(args, key.column.args...)
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(parts, ", "), Vars: args, WithoutParentheses: true}}
+}
+
+// keysetCondition selects the rows after the cursor in the sort order, or before it for a
+// backward cursor. When all keys share a direction it compares row values, which the sort
+// indexes can answer directly.
+func keysetCondition(keys []patientSortKey, cursor *fhirsearch.Cursor) (condition, error) {
+	if len(cursor.Values) != len(keys) {
+		return condition{}, fmt.Errorf("%w: invalid page token", domain.ErrValidation)
+	}
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		arg, err := key.column.parse(cursor.Values[i])
+		if err != nil {
+			return condition{}, fmt.Errorf("%w: invalid page token", domain.ErrValidation)
+		}
+		args[i] = arg
+	}
+	operator := func(key patientSortKey) string {
+		if key.descending != cursor.Backward {
+			return " < "
+		}
+		return " > "
+	}
+
+	uniform := true
+	for _, key := range keys[1:] {
+		uniform = uniform && key.descending == keys[0].descending
+	}
+	if uniform {
+		exprs := make([]string, len(keys))
+		placeholders := make([]string, len(keys))
+		var exprArgs []interface{}
+		for i, key := range keys {
+			exprs[i] = key.column.expr
+			placeholders[i] = "?"
+			exprArgs = append(exprArgs, key.column.args...)
+		}
+		sql := "(" + strings.Join(exprs, ", ") + ")" + operator(keys[0]) + "(" + strings.Join(placeholders, ", ") + ")"
+		return condition{sql: sql, args: append(exprArgs, args...)}, nil
+	}
+
+	// Mixed directions: (k1 > v1) OR (k1 = v1 AND k2 < v2) OR ...
+	var alternatives []string
+	var alternativeArgs []interface{}
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].column.expr+" = ?")
+			alternativeArgs = append(append(alternativeArgs, keys[j].column.args...), args[j])
+		}
+		parts = append(parts, key.column.expr+operator(key)+"?")
+		alternativeArgs = append(append(alternativeArgs, key.column.args...), args[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return condition{sql: "(" + strings.Join(alternatives, " OR ") + ")", args: alternativeArgs}, nil
+}
+
+// patientCursor returns the cursor at a patient's position in the sort order
+func patientCursor(keys []patientSortKey, sortSpec string, patient *domain.Patient, backward bool) *fhirsearch.Cursor {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = key.column.value(patient)
+	}
+	return &fhirsearch.Cursor{Sort: sortSpec, Values: values, Backward: backward}
+}
+
+// MigrateSortIndexes creates the indexes that let each single-key _sort page through the
+// patients without scanning the rows before the page
+func MigrateSortIndexes(db *gorm.DB) error {
+	names := make([]string, 0, len(patientSortColumns))
+	for name := range patientSortColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		column := patientSortColumns[name]
+		err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON patients ((%s), id) WHERE deleted_at IS NULL",
+			column.index, column.expr)).Error
+		if err != nil {
+			return fmt.Errorf("failed to create patient sort index %s: %w", column.index, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"gorm.io/gorm"
+)
+
+// patientTextFields are the elements of the stored Patient JSON indexed for _content, by
+// weight. Names rank highest; the narrative, weighted D, is also what _text searches.
+var patientTextFields = []struct {
+	weight string
+	paths  []string // JSON paths of the string values
+}{
+	{"A", []string{
+		"$.name[*].text", "$.name[*].family", "$.name[*].given[*]", "$.name[*].prefix[*]", "$.name[*].suffix[*]",
+	}},
+	{"B", []string{
+		"$.telecom[*].value", "$.address[*].text", "$.address[*].line[*]", "$.address[*].city",
+		"$.address[*].district", "$.address[*].state", "$.address[*].postalCode", "$.address[*].country",
+	}},
+	{"C", []string{
+		"$.contact[*].name.text", "$.contact[*].name.family", "$.contact[*].name.given[*]", "$.contact[*].telecom[*].value",
+		"$.extension[*].valueString", "$.extension[*].valueMarkdown", "$.extension[*].valueAnnotation.text",
+	}},
+}
+
+// patientRankExpr ranks a patient against a tsquery, scaled to between 0 and 1
+const patientRankExpr = "ts_rank_cd(search_text, to_tsquery('simple', ?), 32)"
+
+// textSeparators are split into words before indexing, so fragments of an email address,
+// phone number or URL match on their own
+const textSeparators = "@.-_/:+"
+
+// patientSearchTextExpr is the tsvector the search_text column is generated from
+func patientSearchTextExpr() string {
+	blanks := strings.Repeat(" ", len(textSeparators))
+	parts := make([]string, 0, len(patientTextFields)+1)
+	for _, field := range patientTextFields {
+		values := make([]string, len(field.paths))
+		for i, path := range field.paths {
+			values[i] = fmt.Sprintf("jsonb_path_query_array(fhir_data, '%s')::text", path)
+		}
+		parts = append(parts, fmt.Sprintf("setweight(to_tsvector('simple', translate(%s, '%s', '%s')), '%s')",
+			strings.Join(values, " || ' ' || "), textSeparators, blanks, field.weight))
+	}
+	// The narrative is XHTML, whose tags the parser drops on its own
+	parts = append(parts, "setweight(to_tsvector('simple', COALESCE(fhir_data #>> '{text,div}', '')), 'D')")
+	return strings.Join(parts, " || ")
+}
+
+// textCondition matches _content against the indexed text of a patient and _text against its
+// narrative
+func textCondition(param fhirsearch.Param) (condition, error) {
+	if param.Modifier != "" {
+		return condition{}, fmt.Errorf("modifier %q is not supported for %s", param.Modifier, param.Name)
+	}
+	tsquery := textQuery(param)
+	if tsquery == "" {
+		return condition{sql: "FALSE"}, nil
+	}
+	return condition{sql: "search_text @@ to_tsquery('simple', ?)", args: []interface{}{tsquery}}, nil
+}
+
+// textQuery converts the values of a _content or _text parameter to a tsquery. Every word of
+// a value must be the start of an indexed word, and any of the values may match. Only the
+// letters and digits of a value are kept, so the query is always valid.
+func textQuery(param fhirsearch.Param) string {
+	label := ":*"
+	if param.Name == "_text" {
+		label = ":*D"
+	}
+	var alternatives []string
+	for _, value := range param.Values {
+		words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = word + label
+		}
+		alternatives = append(alternatives, "("+strings.Join(words, " & ")+")")
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+// patientTextQuery combines the _content and _text parameters of a search into the tsquery its
+// matches are ranked by, or returns an empty string when the search has none
+func patientTextQuery(query *fhirsearch.Query) string {
+	var parts []string
+	for _, param := range query.Params {
+		if param.Name != "_content" && param.Name != "_text" {
+			continue
+		}
+		if tsquery := textQuery(param); tsquery != "" {
+			parts = append(parts, "("+tsquery+")")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// relevanceColumn sorts full-text matches by rank. The rank is selected as the patient's
+// Score, which its cursor value is read from.
+func relevanceColumn(tsquery string) sortColumn {
+	return sortColumn{
+		expr:  patientRankExpr,
+		args:  []interface{}{tsquery},
+		value: func(p *domain.Patient) string { return strconv.FormatFloat(p.Score, 'g', -1, 64) },
+		parse: func(value string) (interface{}, error) { return strconv.ParseFloat(value, 64) },
+	}
+}
+
+// MigrateSearchText adds the search_text column _content and _text search, generated from the
+// patient JSON so every write keeps it in sync, and its GIN index
+func MigrateSearchText(db *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE patients ADD COLUMN IF NOT EXISTS search_text tsvector GENERATED ALWAYS AS (" +
+			patientSearchTextExpr() + ") STORED",
+		"CREATE INDEX IF NOT EXISTS idx_patients_search_text ON patients USING GIN (search_text)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate patient search text: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPatientSearchTextExpr_MatchesMigration tests that the search_text expression of the SQL
+// migration is the one MigrateSearchText adds, so both schemas index the same text
+func TestPatientSearchTextExpr_MatchesMigration(t *testing.T) {
+	migration, err := os.ReadFile("../../migrations/000008_add_patient_search_text.up.sql")
+	require.NoError(t, err)
+
+	match := regexp.MustCompile(`(?s)GENERATED ALWAYS AS \((.*)\) STORED`).FindSubmatch(migration)
+	require.NotNil(t, match, "migration does not generate search_text")
+
+	assert.Equal(t, normalizeSQL(patientSearchTextExpr()), normalizeSQL(string(match[1])))
+}
+
+// normalizeSQL collapses runs of whitespace and drops those just inside parentheses, so an
+// expression laid out over several lines compares equal to the same expression on one
+var (
+	sqlSpaces       = regexp.MustCompile(`\s+`)
+	sqlParenSpacing = regexp.MustCompile(`\( | \)`)
+)
+
+func normalizeSQL(sql string) string {
+	sql = sqlSpaces.ReplaceAllString(strings.TrimSpace(sql), " ")
+	return sqlParenSpacing.ReplaceAllStringFunc(sql, func(s string) string { return strings.TrimSpace(s) })
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+	"go-fhir-demo/pkg/utils/tracer"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResourceRepositoryInterface defines the contract for generic resource repository
+type ResourceRepositoryInterface interface {
+	Create(ctx context.Context, resource *domain.Resource) error
+	GetByLogicalID(ctx context.Context, resourceType, logicalID string) (*domain.Resource, error)
+	Search(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error)
+	Update(ctx context.Context, resource *domain.Resource, expectedVersion int) error
+	Delete(ctx context.Context, id uint, expectedVersion int) error
+	Compartment(ctx context.Context, query domain.CompartmentQuery) ([]*domain.Resource, int64, error)
+	GetByLogicalIDs(ctx context.Context, resourceType string, logicalIDs []string) ([]*domain.Resource, error)
+	Referencing(ctx context.Context, resourceType, param string, references []string, limit int) ([]*domain.Resource, error)
+}
+
+type resourceRepository struct {
+	db *gorm.DB
+}
+
+// NewResourceRepository creates a new generic resource repository. The search parameter
+// values of every stored resource are extracted into resource_search_values.
+func NewResourceRepository(db *gorm.DB) ResourceRepositoryInterface {
+	return &resourceRepository{
+		db: db,
+	}
+}
+
+// Create creates a new resource record as version 1 together with its search values
+func (r *resourceRepository) Create(ctx context.Context, resource *domain.Resource) error {
+	ctx, span := tracer.StartSpan(ctx, "CreateResource")
+	defer span.End()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		resource.VersionID = 1
+		if err := tx.Create(resource).Error; err != nil {
+			return err
+		}
+		return indexResource(tx, resource)
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to create %s: %v", resource.ResourceType, err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("%s created successfully with logical ID: %s", resource.ResourceType, resource.LogicalID)
+	return nil
+}
+
+// GetByLogicalID retrieves a resource by type and logical id, returning domain.ErrNotFound when it does not exist
+func (r *resourceRepository) GetByLogicalID(ctx context.Context, resourceType, logicalID string) (*domain.Resource, error) {
+	var resource domain.Resource
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND logical_id = ?", resourceType, logicalID).
+		First(&resource).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WithContext(ctx).Warnf("%s not found with logical ID: %s", resourceType, logicalID)
+			return nil, domain.ErrNotFound
+		}
+		logger.WithContext(ctx).Errorf("Failed to get %s %s: %v", resourceType, logicalID, err)
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// Search retrieves resources of a type matching a FHIR search query along with the total match count
+func (r *resourceRepository) Search(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	ctx, span := tracer.StartSpan(ctx, "SearchResources")
+	defer span.End()
+
+	definition, ok := domain.ResourceDefinitions[resourceType]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: resource type %s is not supported", domain.ErrValidation, resourceType)
+	}
+	conditions, err := buildResourceConditions(definition, query)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Invalid %s search: %v", resourceType, err)
+		return nil, 0, err
+	}
+	scope := func() *gorm.DB {
+		return applyConditions(r.db.WithContext(ctx).Model(&domain.Resource{}).Where("resource_type = ?", resourceType), conditions)
+	}
+
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to count %s search results: %v", resourceType, err)
+		return nil, 0, err
+	}
+
+	var resources []*domain.Resource
+	if err := scope().Order("id").Limit(query.Count).Offset(query.Offset).Find(&resources).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to search %s: %v", resourceType, err)
+		return nil, 0, err
+	}
+
+	logger.WithContext(ctx).Infof("%s search matched %d resources, returning %d", resourceType, total, len(resources))
+	return resources, total, nil
+}
+
+// Update stores a new version of a resource and replaces its search values.
+// A non-zero expectedVersion must match the stored version, which is checked under a row lock.
+func (r *resourceRepository) Update(ctx context.Context, resource *domain.Resource, expectedVersion int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Resource
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, resource.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+		if expectedVersion != 0 && current.VersionID != expectedVersion {
+			return domain.ErrVersionConflict
+		}
+
+		resource.VersionID = current.VersionID + 1
+		if err := tx.Save(resource).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("resource_id = ?", resource.ID).Delete(&domain.ResourceSearchValue{}).Error; err != nil {
+			return err
+		}
+		return indexResource(tx, resource)
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to update %s %s: %v", resource.ResourceType, resource.LogicalID, err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("%s updated successfully with logical ID: %s, version %d", resource.ResourceType, resource.LogicalID, resource.VersionID)
+	return nil
+}
+
+// Delete soft deletes a resource. A non-zero expectedVersion must match the stored version.
+func (r *resourceRepository) Delete(ctx context.Context, id uint, expectedVersion int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Resource
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+		if expectedVersion != 0 && current.VersionID != expectedVersion {
+			return domain.ErrVersionConflict
+		}
+		return tx.Delete(&current).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete resource with ID %d: %v", id, err)
+		return err
+	}
+	logger.WithContext(ctx).Infof("Resource deleted successfully with ID: %d", id)
+	return nil
+}
+
+// Compartment retrieves the resources that refer to a patient through one of the compartment
+// parameters of their type, along with the total match count
+func (r *resourceRepository) Compartment(ctx context.Context, query domain.CompartmentQuery) ([]*domain.Resource, int64, error) {
+	ctx, span := tracer.StartSpan(ctx, "PatientCompartment")
+	defer span.End()
+
+	var (
+		members []string
+		args    []interface{}
+	)
+	for _, resourceType := range domain.ResourceTypes() {
+		definition := domain.ResourceDefinitions[resourceType]
+		if len(definition.Compartment) == 0 || (len(query.Types) > 0 && !slices.Contains(query.Types, resourceType)) {
+			continue
+		}
+		members = append(members, compartmentMember)
+		args = append(args, resourceType, definition.Compartment, "Patient/"+query.PatientID)
+	}
+	if len(members) == 0 {
+		return []*domain.Resource{}, 0, nil
+	}
+	where := strings.Join(members, " OR ")
+
+	scope := func() *gorm.DB {
+		db := r.db.WithContext(ctx).Model(&domain.Resource{}).Where(where, args...)
+		if query.Since != nil {
+			db = db.Where("updated_at >= ?", *query.Since)
+		}
+		return db
+	}
+
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to count compartment of patient %s: %v", query.PatientID, err)
+		return nil, 0, err
+	}
+
+	var resources []*domain.Resource
+	if err := scope().Order("resource_type, id").Limit(query.Count).Offset(query.Offset).Find(&resources).Error; err != nil {
+		logger.WithContext(ctx).Errorf("Failed to read compartment of patient %s: %v", query.PatientID, err)
+		return nil, 0, err
+	}
+
+	logger.WithContext(ctx).Infof("Compartment of patient %s holds %d resources, returning %d", query.PatientID, total, len(resources))
+	return resources, total, nil
+}
+
+// GetByLogicalIDs retrieves the resources of a type with any of the given logical ids; missing ids are skipped
+func (r *resourceRepository) GetByLogicalIDs(ctx context.Context, resourceType string, logicalIDs []string) ([]*domain.Resource, error) {
+	resources := []*domain.Resource{}
+	if len(logicalIDs) == 0 {
+		return resources, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND logical_id IN ?", resourceType, logicalIDs).
+		Order("id").
+		Find(&resources).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get %s resources by logical ID: %v", resourceType, err)
+		return nil, err
+	}
+	return resources, nil
+}
+
+// Referencing retrieves up to limit resources of a type that refer to any of the given Type/id
+// references through a reference parameter
+func (r *resourceRepository) Referencing(ctx context.Context, resourceType, param string, references []string, limit int) ([]*domain.Resource, error) {
+	ctx, span := tracer.StartSpan(ctx, "ReferencingResources")
+	defer span.End()
+
+	resources := []*domain.Resource{}
+	if len(references) == 0 || limit <= 0 {
+		return resources, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ?", resourceType).
+		Where(referencesAny, param, references).
+		Order("id").
+		Limit(limit).
+		Find(&resources).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to get %s resources referencing %d resources: %v", resourceType, len(references), err)
+		return nil, err
+	}
+	return resources, nil
+}
+
+// indexResource extracts the search parameter values of a resource and stores them
+func indexResource(tx *gorm.DB, resource *domain.Resource) error {
+	definition, ok := domain.ResourceDefinitions[resource.ResourceType]
+	if !ok {
+		return fmt.Errorf("%w: resource type %s is not supported", domain.ErrValidation, resource.ResourceType)
+	}
+	extracted, err := fhirsearch.Extract(resource.FHIRData, definition.SearchParameters)
+	if err != nil {
+		return err
+	}
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	values := make([]domain.ResourceSearchValue, len(extracted))
+	for i, value := range extracted {
+		values[i] = domain.ResourceSearchValue{
+			ResourceID: resource.ID,
+			Name:       value.Name,
+			System:     value.System,
+			Value:      value.Value,
+			DateStart:  value.Start,
+			DateEnd:    value.End,
+		}
+	}
+	return tx.Create(&values).Error
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// ResourceRepositoryTestSuite defines the test suite
+type ResourceRepositoryTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	repository ResourceRepositoryInterface
+	postgres   *embeddedpostgres.EmbeddedPostgres
+}
+
+// SetupSuite initializes the test suite
+func (suite *ResourceRepositoryTestSuite) SetupSuite() {
+	port := 54330
+	suite.postgres = embeddedpostgres.NewDatabase(
+		embeddedpostgres.DefaultConfig().
+			Port(uint32(port)).
+			Username("postgres").
+			Password("postgres").
+			Database("testdb"),
+	)
+	err := suite.postgres.Start()
+	suite.Require().NoError(err)
+
+	dsn := fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=testdb sslmode=disable", port)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	suite.Require().NoError(err)
+
+	err = db.AutoMigrate(&domain.Resource{}, &domain.ResourceSearchValue{})
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.repository = NewResourceRepository(db)
+}
+
+// SetupTest runs before each test
+func (suite *ResourceRepositoryTestSuite) SetupTest() {
+	suite.db.Exec("TRUNCATE TABLE resources, resource_search_values RESTART IDENTITY CASCADE")
+}
+
+// TearDownSuite cleans up after all tests
+func (suite *ResourceRepositoryTestSuite) TearDownSuite() {
+	if suite.postgres != nil {
+		_ = suite.postgres.Stop()
+	}
+}
+
+// TestResourceRepositoryTestSuite runs the test suite
+func TestResourceRepositoryTestSuite(t *testing.T) {
+	// Skip if running in CI without postgres support
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping embedded postgres test in CI")
+	}
+	suite.Run(t, new(ResourceRepositoryTestSuite))
+}
+
+// TestCreateAndGet tests that resources are stored per type and logical id
+func (suite *ResourceRepositoryTestSuite) TestCreateAndGet() {
+	// Arrange
+	observation := &domain.Resource{ResourceType: "Observation", LogicalID: "1", FHIRData: []byte(`{"resourceType":"Observation","id":"1"}`)}
+	encounter := &domain.Resource{ResourceType: "Encounter", LogicalID: "1", FHIRData: []byte(`{"resourceType":"Encounter","id":"1"}`)}
+
+	// Act
+	suite.Require().NoError(suite.repository.Create(context.Background(), observation))
+	suite.Require().NoError(suite.repository.Create(context.Background(), encounter))
+	found, err := suite.repository.GetByLogicalID(context.Background(), "Encounter", "1")
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), encounter.ID, found.ID)
+	assert.Equal(suite.T(), 1, found.VersionID)
+	_, err = suite.repository.GetByLogicalID(context.Background(), "Practitioner", "1")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestUpdateAndDelete tests version checks and that updates replace the search values
+func (suite *ResourceRepositoryTestSuite) TestUpdateAndDelete() {
+	// Arrange
+	resource := &domain.Resource{ResourceType: "Organization", LogicalID: "o1", FHIRData: []byte(`{"resourceType":"Organization","name":"Acme"}`)}
+	suite.Require().NoError(suite.repository.Create(context.Background(), resource))
+
+	// Act
+	resource.FHIRData = []byte(`{"resourceType":"Organization","name":"Globex"}`)
+	suite.Require().NoError(suite.repository.Update(context.Background(), resource, 1))
+	err := suite.repository.Update(context.Background(), resource, 1)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrVersionConflict)
+	assert.Equal(suite.T(), 2, resource.VersionID)
+	var names []string
+	suite.db.Model(&domain.ResourceSearchValue{}).Where("resource_id = ? AND name = ?", resource.ID, "name").Pluck("value", &names)
+	assert.Equal(suite.T(), []string{"Globex"}, names)
+
+	suite.Require().NoError(suite.repository.Delete(context.Background(), resource.ID, 2))
+	_, err = suite.repository.GetByLogicalID(context.Background(), "Organization", "o1")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestSearch_ExtractedValues tests searching on values extracted from the stored resources
+func (suite *ResourceRepositoryTestSuite) TestSearch_ExtractedValues() {
+	// Arrange
+	observations := []string{
+		`{"resourceType":"Observation","status":"final","code":{"coding":[{"system":"http://loinc.org","code":"8867-4"}]},
+			"subject":{"reference":"Patient/1"},"effectiveDateTime":"2024-03-01T10:00:00Z"}`,
+		`{"resourceType":"Observation","status":"preliminary","code":{"coding":[{"system":"http://loinc.org","code":"8310-5"}]},
+			"subject":{"reference":"http://example.org/fhir/Patient/2/_history/3"},"effectivePeriod":{"start":"2024-05-01","end":"2024-05-03"}}`,
+		`{"resourceType":"Observation","status":"final","code":{"text":"Note"},"subject":{"reference":"Group/1"}}`,
+	}
+	for i, data := range observations {
+		resource := &domain.Resource{ResourceType: "Observation", LogicalID: fmt.Sprintf("obs%d", i+1), FHIRData: []byte(data)}
+		suite.Require().NoError(suite.repository.Create(context.Background(), resource))
+	}
+
+	search := func(rawQuery string) ([]*domain.Resource, int64) {
+		values, err := url.ParseQuery(rawQuery)
+		suite.Require().NoError(err)
+		query, err := fhirsearch.Parse(values, domain.ResourceDefinitions["Observation"].SearchParameters)
+		suite.Require().NoError(err)
+		result, total, err := suite.repository.Search(context.Background(), "Observation", query)
+		suite.Require().NoError(err)
+		return result, total
+	}
+
+	// Act & Assert
+	result, total := search("status=final")
+	assert.Equal(suite.T(), int64(2), total)
+	assert.Len(suite.T(), result, 2)
+
+	_, total = search("code=http://loinc.org|8867-4,8310-5")
+	assert.Equal(suite.T(), int64(2), total)
+
+	result, total = search("patient=2")
+	assert.Equal(suite.T(), int64(1), total)
+	assert.Equal(suite.T(), "obs2", result[0].LogicalID)
+
+	_, total = search("subject=Group/1")
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, total = search("date=2024-05-02")
+	assert.Equal(suite.T(), int64(0), total)
+
+	_, total = search("date=ge2024-04-01")
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, total = search("date:missing=true")
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, total = search("status:not=final&_id=obs1,obs2")
+	assert.Equal(suite.T(), int64(1), total)
+}
+
+// TestCompartment tests that the patient compartment follows the compartment parameters of each type
+func (suite *ResourceRepositoryTestSuite) TestCompartment() {
+	// Arrange
+	resources := []*domain.Resource{
+		{ResourceType: "Observation", LogicalID: "o1", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/1"}}`)},
+		{ResourceType: "Observation", LogicalID: "o2", FHIRData: []byte(`{"resourceType":"Observation","performer":[{"reference":"Patient/1"}]}`)},
+		{ResourceType: "Observation", LogicalID: "o3", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/2"}}`)},
+		{ResourceType: "Encounter", LogicalID: "e1", FHIRData: []byte(`{"resourceType":"Encounter","subject":{"reference":"Patient/1"}}`)},
+		{ResourceType: "Practitioner", LogicalID: "p1", FHIRData: []byte(`{"resourceType":"Practitioner"}`)},
+	}
+	for _, resource := range resources {
+		suite.Require().NoError(suite.repository.Create(context.Background(), resource))
+	}
+
+	// Act
+	all, total, err := suite.repository.Compartment(context.Background(), domain.CompartmentQuery{PatientID: "1", Count: 10})
+	suite.Require().NoError(err)
+	encounters, encounterTotal, err := suite.repository.Compartment(context.Background(), domain.CompartmentQuery{PatientID: "1", Types: []string{"Encounter"}, Count: 10})
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Equal(suite.T(), int64(3), total)
+	suite.Require().Len(all, 3)
+	assert.Equal(suite.T(), "e1", all[0].LogicalID)
+	assert.Equal(suite.T(), int64(1), encounterTotal)
+	assert.Len(suite.T(), encounters, 1)
+}
+
+// TestExportResources tests that exports read batches in id order and that patient exports
+// keep to the patient compartment
+func (suite *ResourceRepositoryTestSuite) TestExportResources() {
+	// Arrange
+	resources := []*domain.Resource{
+		{ResourceType: "Observation", LogicalID: "o1", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/1"}}`)},
+		{ResourceType: "Observation", LogicalID: "o2", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Group/1"}}`)},
+		{ResourceType: "Observation", LogicalID: "o3", FHIRData: []byte(`{"resourceType":"Observation","performer":[{"reference":"Patient/2"}]}`)},
+	}
+	for _, resource := range resources {
+		suite.Require().NoError(suite.repository.Create(context.Background(), resource))
+	}
+	bulk := NewBulkRepository(suite.db)
+	query := domain.ExportQuery{Until: time.Now().Add(time.Minute), Limit: 2}
+
+	// Act
+	first, err := bulk.ExportResources(context.Background(), "Observation", query)
+	suite.Require().NoError(err)
+	query.AfterID = first[len(first)-1].ID
+	second, err := bulk.ExportResources(context.Background(), "Observation", query)
+	suite.Require().NoError(err)
+	patients, err := bulk.ExportResources(context.Background(), "Observation", domain.ExportQuery{Until: query.Until, Patient: true, Limit: 10})
+	suite.Require().NoError(err)
+
+	// Assert
+	suite.Require().Len(first, 2)
+	assert.Equal(suite.T(), "o1", first[0].LogicalID)
+	suite.Require().Len(second, 1)
+	assert.Equal(suite.T(), "o3", second[0].LogicalID)
+	suite.Require().Len(patients, 2)
+	assert.Equal(suite.T(), "o1", patients[0].LogicalID)
+	assert.Equal(suite.T(), "o3", patients[1].LogicalID)
+}
+
+// TestIncludeLookups tests fetching resources by logical ids and by the resources they refer to
+func (suite *ResourceRepositoryTestSuite) TestIncludeLookups() {
+	// Arrange
+	resources := []*domain.Resource{
+		{ResourceType: "Organization", LogicalID: "7", FHIRData: []byte(`{"resourceType":"Organization"}`)},
+		{ResourceType: "Observation", LogicalID: "o1", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/1"}}`)},
+		{ResourceType: "Observation", LogicalID: "o2", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/2"}}`)},
+		{ResourceType: "Observation", LogicalID: "o3", FHIRData: []byte(`{"resourceType":"Observation","subject":{"reference":"Patient/3"}}`)},
+	}
+	for _, resource := range resources {
+		suite.Require().NoError(suite.repository.Create(context.Background(), resource))
+	}
+
+	// Act
+	organizations, err := suite.repository.GetByLogicalIDs(context.Background(), "Organization", []string{"7", "8"})
+	suite.Require().NoError(err)
+	referring, err := suite.repository.Referencing(context.Background(), "Observation", "subject", []string{"Patient/1", "Patient/2"}, 10)
+	suite.Require().NoError(err)
+	limited, err := suite.repository.Referencing(context.Background(), "Observation", "subject", []string{"Patient/1", "Patient/2"}, 1)
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Len(suite.T(), organizations, 1)
+	suite.Require().Len(referring, 2)
+	assert.Equal(suite.T(), "o1", referring[0].LogicalID)
+	assert.Len(suite.T(), limited, 1)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+)
+
+// searchValueExists selects the extracted values of one parameter of the resource being searched
+const searchValueExists = "EXISTS (SELECT 1 FROM resource_search_values v WHERE v.resource_id = resources.id AND v.name = ?"
+
+// compartmentMember selects resources of one type that refer to a patient through any of
+// the type's compartment parameters
+const compartmentMember = "(resources.resource_type = ? AND EXISTS (SELECT 1 FROM resource_search_values v " +
+	"WHERE v.resource_id = resources.id AND v.name IN ? AND v.value = ?))"
+
+// referencesAny selects resources whose values of one reference parameter include any of the references
+const referencesAny = "EXISTS (SELECT 1 FROM resource_search_values v WHERE v.resource_id = resources.id AND v.name = ? AND v.value IN ?)"
+
+// buildResourceConditions translates a FHIR search query into SQL conditions over the resources
+// table. Parameters other than _id are matched against the values extracted when storing.
+func buildResourceConditions(definition domain.ResourceDefinition, query *fhirsearch.Query) ([]condition, error) {
+	defs := make(map[string]fhirsearch.Definition, len(definition.SearchParameters))
+	for _, def := range definition.SearchParameters {
+		defs[def.Name] = def
+	}
+
+	conditions := make([]condition, 0, len(query.Params))
+	for _, param := range query.Params {
+		def, ok := defs[param.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: search parameter %q is not supported for %s", domain.ErrValidation, param.Name, definition.Type)
+		}
+		if param.Name == "_id" {
+			conditions = append(conditions, tokenCondition("resources.logical_id", param, func(code string) (interface{}, bool) {
+				return code, true
+			}))
+			continue
+		}
+		cond, err := searchValueCondition(def, param)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// searchValueCondition matches resources having an extracted value of the parameter that
+// matches any of the parameter values
+func searchValueCondition(def fhirsearch.Definition, param fhirsearch.Param) (condition, error) {
+	if param.Modifier == "missing" {
+		sql := searchValueExists + ")"
+		if param.Values[0] == "true" {
+			sql = "NOT " + sql
+		}
+		return condition{sql: sql, args: []interface{}{def.Name}}, nil
+	}
+
+	var parts []string
+	args := []interface{}{def.Name}
+	for _, value := range param.Values {
+		part, partArgs, err := valueMatch(def, param.Modifier, value)
+		if err != nil {
+			return condition{}, err
+		}
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+	sql := searchValueExists + " AND (" + strings.Join(parts, " OR ") + "))"
+	if param.Modifier == "not" {
+		sql = "NOT " + sql
+	}
+	return condition{sql: sql, args: args}, nil
+}
+
+// valueMatch compares one search value with the extracted value row v
+func valueMatch(def fhirsearch.Definition, modifier, value string) (string, []interface{}, error) {
+	switch def.Type {
+	case fhirsearch.TypeString:
+		switch modifier {
+		case "exact":
+			return "v.value = ?", []interface{}{value}, nil
+		case "contains":
+			return "v.value ILIKE ?", []interface{}{"%" + escapeLike(value) + "%"}, nil
+		default:
+			return "v.value ILIKE ?", []interface{}{escapeLike(value) + "%"}, nil
+		}
+	case fhirsearch.TypeToken:
+		system, code, hasSystem := fhirsearch.ParseToken(value)
+		switch {
+		case !hasSystem:
+			return "v.value = ?", []interface{}{code}, nil
+		case code == "":
+			return "v.system = ?", []interface{}{system}, nil
+		default:
+			return "(v.system = ? AND v.value = ?)", []interface{}{system, code}, nil
+		}
+	case fhirsearch.TypeReference:
+		reference := fhirsearch.NormalizeReference(value)
+		switch {
+		case strings.Contains(reference, "/"):
+			return "v.value = ?", []interface{}{reference}, nil
+		case def.Target != "":
+			return "v.value = ?", []interface{}{def.Target + "/" + reference}, nil
+		default:
+			return "v.value LIKE ?", []interface{}{"%/" + escapeLike(reference)}, nil
+		}
+	case fhirsearch.TypeDate:
+		return dateRangeMatch(value)
+	default:
+		return "", nil, fmt.Errorf("search parameter %q has unsupported type %s", def.Name, def.Type)
+	}
+}
+
+// dateRangeMatch compares the range of a date search value with the extracted range
+// [v.date_start, v.date_end), where a missing bound is unbounded
+func dateRangeMatch(value string) (string, []interface{}, error) {
+	const (
+		start = "COALESCE(v.date_start, '-infinity')"
+		end   = "COALESCE(v.date_end, 'infinity')"
+	)
+	prefix, dateValue := fhirsearch.SplitPrefix(value)
+	low, high, err := fhirsearch.ParseDateRange(dateValue)
+	if err != nil {
+		return "", nil, err
+	}
+	switch prefix {
+	case fhirsearch.PrefixNe:
+		return fmt.Sprintf("NOT (%s >= ? AND %s <= ?)", start, end), []interface{}{low, high}, nil
+	case fhirsearch.PrefixGt:
+		return end + " > ?", []interface{}{high}, nil
+	case fhirsearch.PrefixLt:
+		return start + " < ?", []interface{}{low}, nil
+	case fhirsearch.PrefixGe:
+		return end + " > ?", []interface{}{low}, nil
+	case fhirsearch.PrefixLe:
+		return start + " < ?", []interface{}{high}, nil
+	case fhirsearch.PrefixSa:
+		return start + " >= ?", []interface{}{high}, nil
+	case fhirsearch.PrefixEb:
+		return end + " <= ?", []interface{}{low}, nil
+	case fhirsearch.PrefixAp:
+		approxLow, approxHigh := fhirsearch.ApproximateRange(low, high)
+		return fmt.Sprintf("(%s < ? AND %s > ?)", start, end), []interface{}{approxHigh, approxLow}, nil
+	default:
+		return fmt.Sprintf("(%s >= ? AND %s <= ?)", start, end), []interface{}{low, high}, nil
+	}
+}
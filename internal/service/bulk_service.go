@@ -0,0 +1,644 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// BulkServiceInterface defines the contract for bulk data service
+type BulkServiceInterface interface {
+	StartExport(ctx context.Context, request domain.ExportRequest) (*domain.BulkJob, error)
+	StartImport(ctx context.Context, request domain.ImportRequest) (*domain.BulkJob, error)
+	GetJob(ctx context.Context, id string) (*domain.BulkJob, error)
+	DeleteJob(ctx context.Context, id string) error
+	JobFile(ctx context.Context, id, name string) (string, error)
+	FailInterruptedJobs(ctx context.Context) error
+}
+
+// exportBatchSize is the number of resources an export reads from the database at a time
+const exportBatchSize = 1000
+
+// importBatchSize is the number of patients an import writes to the database at a time
+const importBatchSize = 500
+
+// importErrorsFile is the NDJSON file of OperationOutcomes an import writes for the lines it
+// could not import
+const importErrorsFile = "errors.ndjson"
+
+// importUploadFile is the file an uploaded import is kept in until it is read
+const importUploadFile = "upload.ndjson"
+
+// runningJob is a job whose goroutine has not returned yet
+type runningJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type bulkService struct {
+	repo            domain.BulkRepository
+	patientRepo     domain.PatientRepository
+	patients        domain.PatientService
+	directory       string
+	importDirectory string
+
+	mu      sync.Mutex
+	running map[string]*runningJob
+}
+
+// NewBulkService creates a new service for asynchronous bulk data jobs. Every job writes its
+// files to a directory of its own under directory. Imports read patients through the patient
+// service and may name the files under importDirectory.
+func NewBulkService(repo domain.BulkRepository, patientRepo domain.PatientRepository, patients domain.PatientService, directory, importDirectory string) BulkServiceInterface {
+	return &bulkService{
+		repo:            repo,
+		patientRepo:     patientRepo,
+		patients:        patients,
+		directory:       directory,
+		importDirectory: importDirectory,
+		running:         make(map[string]*runningJob),
+	}
+}
+
+// StartExport records a new export job and starts it in the background. The job exports the
+// resources last updated up to the time it was started.
+func (s *bulkService) StartExport(ctx context.Context, request domain.ExportRequest) (*domain.BulkJob, error) {
+	types, err := exportTypes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.BulkJob{
+		ID:              uuid.NewString(),
+		Kind:            domain.BulkJobExport,
+		Status:          domain.BulkJobInProgress,
+		Request:         request.Request,
+		TransactionTime: time.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	query := domain.ExportQuery{Since: request.Since, Until: job.TransactionTime, Patient: request.Patient, Limit: exportBatchSize}
+	// The job outlives the kick-off request, but keeps its logging and tracing values
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.start(job.ID, cancel, func() {
+		s.runExport(runCtx, *job, types, query)
+	})
+	logger.WithContext(ctx).Infof("Started bulk export %s of %v", job.ID, types)
+	return job, nil
+}
+
+// StartImport records a new import job and starts it in the background. An upload is saved
+// to the job's directory before StartImport returns.
+func (s *bulkService) StartImport(ctx context.Context, request domain.ImportRequest) (*domain.BulkJob, error) {
+	if request.Upload == nil && len(request.Paths) == 0 {
+		return nil, fmt.Errorf("%w: an import needs an NDJSON upload or input files", domain.ErrValidation)
+	}
+	inputs := make([]importInput, 0, len(request.Paths)+1)
+	for _, path := range request.Paths {
+		input, err := s.resolveImportPath(path)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+
+	job := &domain.BulkJob{
+		ID:              uuid.NewString(),
+		Kind:            domain.BulkJobImport,
+		Status:          domain.BulkJobInProgress,
+		Request:         request.Request,
+		TransactionTime: time.Now().UTC(),
+	}
+	if request.Upload != nil {
+		input, err := s.saveUpload(job.ID, request.Upload)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to save the upload of bulk import %s: %v", job.ID, err)
+			os.RemoveAll(s.jobDirectory(job.ID))
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		os.RemoveAll(s.jobDirectory(job.ID))
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.start(job.ID, cancel, func() {
+		s.runImport(runCtx, *job, inputs)
+	})
+	logger.WithContext(ctx).Infof("Started bulk import %s of %d inputs", job.ID, len(inputs))
+	return job, nil
+}
+
+// GetJob retrieves a bulk data job
+func (s *bulkService) GetJob(ctx context.Context, id string) (*domain.BulkJob, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// DeleteJob cancels a job that is still running and removes it with its files
+func (s *bulkService) DeleteJob(ctx context.Context, id string) error {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	running := s.running[id]
+	s.mu.Unlock()
+	if running != nil {
+		running.cancel()
+		<-running.done
+	}
+
+	if err := os.RemoveAll(s.jobDirectory(id)); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to remove the files of bulk data job %s: %v", id, err)
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	logger.WithContext(ctx).Infof("Deleted bulk data job %s", id)
+	return nil
+}
+
+// JobFile returns the path of a file written by a completed job
+func (s *bulkService) JobFile(ctx context.Context, id, name string) (string, error) {
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if job.Status == domain.BulkJobCompleted {
+		var files []domain.BulkFile
+		if err := json.Unmarshal(job.Output, &files); err != nil {
+			return "", fmt.Errorf("failed to unmarshal the output of bulk data job %s: %w", id, err)
+		}
+		for _, file := range files {
+			if file.Name == name {
+				return filepath.Join(s.jobDirectory(id), file.Name), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: file %s of bulk data job %s", domain.ErrNotFound, name, id)
+}
+
+// FailInterruptedJobs marks the jobs a previous run of the server left in progress as failed
+func (s *bulkService) FailInterruptedJobs(ctx context.Context) error {
+	count, err := s.repo.FailInterrupted(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logger.WithContext(ctx).Warnf("Marked %d interrupted bulk data jobs as failed", count)
+	}
+	return nil
+}
+
+// start runs a job in its own goroutine, tracked until it returns so it can be cancelled
+func (s *bulkService) start(id string, cancel context.CancelFunc, run func()) {
+	running := &runningJob{cancel: cancel, done: make(chan struct{})}
+	s.mu.Lock()
+	s.running[id] = running
+	s.mu.Unlock()
+
+	go func() {
+		defer close(running.done)
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			delete(s.running, id)
+			s.mu.Unlock()
+		}()
+		run()
+	}()
+}
+
+// runExport writes one NDJSON file for every type with resources to export and records the
+// outcome on the job
+func (s *bulkService) runExport(ctx context.Context, job domain.BulkJob, types []string, query domain.ExportQuery) {
+	files, err := s.export(ctx, &job, types, query)
+	s.finish(ctx, &job, files, err)
+}
+
+// runImport imports the patients of every input and records the outcome on the job
+func (s *bulkService) runImport(ctx context.Context, job domain.BulkJob, inputs []importInput) {
+	files, err := s.importPatients(ctx, &job, inputs)
+	s.removeUpload(ctx, job.ID)
+	s.finish(ctx, &job, files, err)
+}
+
+// removeUpload deletes the saved upload of an import once it has been read, since it holds
+// patient data and is not among the job's files. A job without an upload has none to delete.
+func (s *bulkService) removeUpload(ctx context.Context, id string) {
+	path := filepath.Join(s.jobDirectory(id), importUploadFile)
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.WithContext(ctx).Errorf("Failed to remove the upload of bulk import %s: %v", id, err)
+	}
+}
+
+// finish records the files a job wrote, or why it failed. A cancelled job is left as it is,
+// since it is being deleted.
+func (s *bulkService) finish(ctx context.Context, job *domain.BulkJob, files []domain.BulkFile, err error) {
+	if ctx.Err() != nil {
+		logger.WithContext(ctx).Infof("Cancelled bulk %s %s", job.Kind, job.ID)
+		return
+	}
+
+	if err == nil {
+		job.Output, err = json.Marshal(files)
+	}
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Bulk %s %s failed: %v", job.Kind, job.ID, err)
+		job.Status = domain.BulkJobFailed
+		job.Error = err.Error()
+	} else {
+		logger.WithContext(ctx).Infof("Completed bulk %s %s", job.Kind, job.ID)
+		job.Status = domain.BulkJobCompleted
+		job.Progress = ""
+	}
+	// Update logs its own errors, and a job left in progress is failed at the next startup
+	_ = s.repo.Update(ctx, job)
+}
+
+// export writes the files of an export job, reporting its progress after every type
+func (s *bulkService) export(ctx context.Context, job *domain.BulkJob, types []string, query domain.ExportQuery) ([]domain.BulkFile, error) {
+	directory := s.jobDirectory(job.ID)
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the export directory: %w", err)
+	}
+
+	files := []domain.BulkFile{}
+	for i, resourceType := range types {
+		file, err := s.exportType(ctx, directory, resourceType, query)
+		if err != nil {
+			return nil, err
+		}
+		if file.Count > 0 {
+			files = append(files, file)
+		}
+		job.Progress = fmt.Sprintf("Exported %d of %d resource types", i+1, len(types))
+		if err := s.repo.Update(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// exportType writes the resources of one type to an NDJSON file, one resource per line.
+// No file is kept when the type has no resources to export.
+func (s *bulkService) exportType(ctx context.Context, directory, resourceType string, query domain.ExportQuery) (domain.BulkFile, error) {
+	file := domain.BulkFile{Type: resourceType, Name: resourceType + ".ndjson"}
+	path := filepath.Join(directory, file.Name)
+	f, err := os.Create(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to create %s: %w", file.Name, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for {
+		lines, lastID, err := exportBatch(ctx, s.repo, resourceType, query)
+		if err != nil {
+			return file, err
+		}
+		for _, line := range lines {
+			if _, err := w.Write(line); err != nil {
+				return file, fmt.Errorf("failed to write %s: %w", file.Name, err)
+			}
+			if err := w.WriteByte('\n'); err != nil {
+				return file, fmt.Errorf("failed to write %s: %w", file.Name, err)
+			}
+		}
+		file.Count += int64(len(lines))
+		if len(lines) < query.Limit {
+			break
+		}
+		query.AfterID = lastID
+	}
+	if err := w.Flush(); err != nil {
+		return file, fmt.Errorf("failed to write %s: %w", file.Name, err)
+	}
+	if err := f.Close(); err != nil {
+		return file, fmt.Errorf("failed to write %s: %w", file.Name, err)
+	}
+
+	if file.Count == 0 {
+		if err := os.Remove(path); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to remove the empty export file %s: %v", path, err)
+		}
+	}
+	return file, nil
+}
+
+// exportBatch reads the next batch of resources of a type as FHIR JSON, returning the id the
+// batch after it starts from
+func exportBatch(ctx context.Context, repo domain.BulkRepository, resourceType string, query domain.ExportQuery) ([][]byte, uint, error) {
+	var lines [][]byte
+	var lastID uint
+	if resourceType == "Patient" {
+		patients, err := repo.ExportPatients(ctx, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, patient := range patients {
+			entry, err := patientEntry(patient)
+			if err != nil {
+				return nil, 0, err
+			}
+			lines = append(lines, entry.Resource)
+			lastID = patient.ID
+		}
+		return lines, lastID, nil
+	}
+
+	resources, err := repo.ExportResources(ctx, resourceType, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, resource := range resources {
+		data, err := resourceToFHIR(resource)
+		if err != nil {
+			return nil, 0, err
+		}
+		lines = append(lines, data)
+		lastID = resource.ID
+	}
+	return lines, lastID, nil
+}
+
+// exportTypes resolves the resource types an export request asks for. A patient export
+// covers the patients and the types with a patient compartment.
+func exportTypes(request domain.ExportRequest) ([]string, error) {
+	supported := []string{"Patient"}
+	for _, resourceType := range domain.ResourceTypes() {
+		if !request.Patient || len(domain.ResourceDefinitions[resourceType].Compartment) > 0 {
+			supported = append(supported, resourceType)
+		}
+	}
+	if len(request.Types) == 0 {
+		return supported, nil
+	}
+
+	var types []string
+	for _, resourceType := range request.Types {
+		if !slices.Contains(supported, resourceType) {
+			return nil, fmt.Errorf("%w: resource type %s cannot be exported", domain.ErrValidation, resourceType)
+		}
+		if !slices.Contains(types, resourceType) {
+			types = append(types, resourceType)
+		}
+	}
+	return types, nil
+}
+
+// importInput is an NDJSON file an import reads
+type importInput struct {
+	name string // How the input is named in the job's output and errors
+	path string
+}
+
+// importLine is a patient read from a line of an input, waiting to be written
+type importLine struct {
+	number  int
+	patient *domain.Patient
+}
+
+// importReport writes an OperationOutcome for every line an import could not import
+type importReport struct {
+	errors *bufio.Writer
+	failed int64
+}
+
+// importPatients reads the patients of every input in turn and writes them in batches,
+// reporting its progress after every batch
+func (s *bulkService) importPatients(ctx context.Context, job *domain.BulkJob, inputs []importInput) ([]domain.BulkFile, error) {
+	directory := s.jobDirectory(job.ID)
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the import directory: %w", err)
+	}
+	errorsPath := filepath.Join(directory, importErrorsFile)
+	f, err := os.Create(errorsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", importErrorsFile, err)
+	}
+	defer f.Close()
+
+	report := &importReport{errors: bufio.NewWriter(f)}
+	files := make([]domain.BulkFile, 0, len(inputs)+1)
+	var imported int64
+	for i, input := range inputs {
+		progress := func(count int64) error {
+			job.Progress = fmt.Sprintf("Imported %d patients with %d failed, reading input %d of %d",
+				imported+count, report.failed, i+1, len(inputs))
+			return s.repo.Update(ctx, job)
+		}
+		file, err := s.importInput(ctx, input, report, progress)
+		if err != nil {
+			return nil, err
+		}
+		imported += file.Count
+		files = append(files, file)
+	}
+	if err := report.errors.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", importErrorsFile, err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", importErrorsFile, err)
+	}
+
+	if report.failed > 0 {
+		files = append(files, domain.BulkFile{Type: "OperationOutcome", Name: importErrorsFile, Count: report.failed})
+	} else if err := os.Remove(errorsPath); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to remove the empty import error file %s: %v", errorsPath, err)
+	}
+	return files, nil
+}
+
+// importInput imports the patients of one input, line by line. Lines that are not valid
+// patients are reported and skipped; a blank line is skipped silently.
+func (s *bulkService) importInput(ctx context.Context, input importInput, report *importReport, progress func(count int64) error) (domain.BulkFile, error) {
+	file := domain.BulkFile{Type: "Patient", Input: input.name}
+	f, err := os.Open(input.path)
+	if err != nil {
+		return file, fmt.Errorf("failed to open %s: %w", input.name, err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	batch := make([]importLine, 0, importBatchSize)
+	write := func() error {
+		count, err := s.writeBatch(ctx, input, batch, report)
+		if err != nil {
+			return err
+		}
+		file.Count += count
+		batch = batch[:0]
+		return progress(file.Count)
+	}
+	for number := 1; ; number++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return file, fmt.Errorf("failed to read %s: %w", input.name, readErr)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			patient, err := s.patients.PreparePatient(ctx, line)
+			switch {
+			case isLineError(err):
+				if err := report.add(input, number, err); err != nil {
+					return file, err
+				}
+			case err != nil:
+				return file, err
+			default:
+				batch = append(batch, importLine{number: number, patient: patient})
+			}
+		}
+		if len(batch) == importBatchSize {
+			if err := write(); err != nil {
+				return file, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if len(batch) > 0 {
+		if err := write(); err != nil {
+			return file, err
+		}
+	}
+	return file, nil
+}
+
+// writeBatch stores a batch of patients with multi-row inserts. When the batch is refused
+// because of one of its patients, its patients are stored one at a time so only the lines at
+// fault are reported. It returns the number of patients stored.
+func (s *bulkService) writeBatch(ctx context.Context, input importInput, batch []importLine, report *importReport) (int64, error) {
+	patients := make([]*domain.Patient, len(batch))
+	for i, line := range batch {
+		patients[i] = line.patient
+	}
+	err := s.patientRepo.CreateBatch(ctx, patients)
+	if err == nil {
+		return int64(len(batch)), nil
+	}
+	if !isLineError(err) {
+		return 0, err
+	}
+
+	var count int64
+	for _, line := range batch {
+		err := s.patientRepo.Create(ctx, line.patient)
+		switch {
+		case isLineError(err):
+			if err := report.add(input, line.number, err); err != nil {
+				return count, err
+			}
+		case err != nil:
+			return count, err
+		default:
+			count++
+		}
+	}
+	return count, nil
+}
+
+// add writes the OperationOutcome for a line that could not be imported
+func (r *importReport) add(input importInput, number int, err error) error {
+	location := fmt.Sprintf("%s line %d: ", input.name, number)
+	var issues []fhir.OperationOutcomeIssue
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		issues = slices.Clone(validationErr.Issues)
+	} else {
+		code := fhir.IssueTypeInvalid
+		if errors.Is(err, domain.ErrConflict) {
+			code = fhir.IssueTypeDuplicate
+		}
+		issues = []fhir.OperationOutcomeIssue{{Severity: fhir.IssueSeverityError, Code: code}}
+		diagnostics := err.Error()
+		issues[0].Diagnostics = &diagnostics
+	}
+	for i := range issues {
+		diagnostics := location
+		if issues[i].Diagnostics != nil {
+			diagnostics += *issues[i].Diagnostics
+		}
+		issues[i].Diagnostics = &diagnostics
+	}
+
+	data, err := json.Marshal(fhir.OperationOutcome{Issue: issues})
+	if err != nil {
+		return fmt.Errorf("failed to marshal OperationOutcome: %w", err)
+	}
+	r.errors.Write(data)
+	r.errors.WriteByte('\n')
+	r.failed++
+	return nil
+}
+
+// isLineError reports whether err is the fault of the line being imported rather than of the
+// server, such as an invalid resource or an identifier already assigned to another patient
+func isLineError(err error) bool {
+	return errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrConflict)
+}
+
+// resolveImportPath checks that an input named by an import request is a file under the
+// import directory. Paths may be given as file: URLs.
+func (s *bulkService) resolveImportPath(path string) (importInput, error) {
+	relative := filepath.FromSlash(strings.TrimPrefix(path, "file://"))
+	if !filepath.IsLocal(relative) {
+		return importInput{}, fmt.Errorf("%w: input %s must be a relative path within the import directory", domain.ErrValidation, path)
+	}
+	resolved := filepath.Join(s.importDirectory, relative)
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return importInput{}, fmt.Errorf("%w: input %s is not a file in the import directory", domain.ErrValidation, path)
+	}
+	return importInput{name: path, path: resolved}, nil
+}
+
+// saveUpload copies an uploaded import to the job's directory, since the upload is gone once
+// the kick-off request completes
+func (s *bulkService) saveUpload(id string, upload io.Reader) (importInput, error) {
+	directory := s.jobDirectory(id)
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return importInput{}, fmt.Errorf("failed to create the import directory: %w", err)
+	}
+	path := filepath.Join(directory, importUploadFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return importInput{}, fmt.Errorf("failed to create %s: %w", importUploadFile, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, upload); err != nil {
+		return importInput{}, fmt.Errorf("failed to save the upload: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return importInput{}, fmt.Errorf("failed to save the upload: %w", err)
+	}
+	return importInput{name: "upload", path: path}, nil
+}
+
+// jobDirectory is the directory the files of a job are written to
+func (s *bulkService) jobDirectory(id string) string {
+	return filepath.Join(s.directory, id)
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// BulkServiceTestSuite defines the test suite
+type BulkServiceTestSuite struct {
+	suite.Suite
+	ctrl            *gomock.Controller
+	mockRepo        *mocks.MockBulkRepository
+	mockPatientRepo *mocks.MockPatientRepository
+	mockPatients    *mocks.MockPatientService
+	directory       string
+	importDirectory string
+	service         *bulkService
+}
+
+// SetupTest initializes the test suite before each test
+func (suite *BulkServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockRepo = mocks.NewMockBulkRepository(suite.ctrl)
+	suite.mockPatientRepo = mocks.NewMockPatientRepository(suite.ctrl)
+	suite.mockPatients = mocks.NewMockPatientService(suite.ctrl)
+	suite.directory = suite.T().TempDir()
+	suite.importDirectory = suite.T().TempDir()
+	suite.service = NewBulkService(suite.mockRepo, suite.mockPatientRepo, suite.mockPatients, suite.directory, suite.importDirectory).(*bulkService)
+}
+
+// TearDownTest cleans up after each test
+func (suite *BulkServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestBulkServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkServiceTestSuite))
+}
+
+// waitForJobs waits until no job goroutine is running
+func (suite *BulkServiceTestSuite) waitForJobs() {
+	assert.Eventually(suite.T(), func() bool {
+		suite.service.mu.Lock()
+		defer suite.service.mu.Unlock()
+		return len(suite.service.running) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (suite *BulkServiceTestSuite) TestStartExport_Completes() {
+	var stored domain.BulkJob
+	suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	suite.mockRepo.EXPECT().
+		ExportPatients(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, query domain.ExportQuery) ([]*domain.Patient, error) {
+			assert.Equal(suite.T(), exportBatchSize, query.Limit)
+			return []*domain.Patient{
+				{ID: 1, LogicalID: "1", FHIRData: []byte(`{"resourceType":"Patient","id":"1"}`), VersionID: 2},
+				{ID: 2, LogicalID: "2", FHIRData: []byte(`{"resourceType":"Patient","id":"2"}`), VersionID: 1},
+			}, nil
+		})
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, job *domain.BulkJob) error {
+			stored = *job
+			return nil
+		}).
+		Times(2)
+
+	job, err := suite.service.StartExport(context.Background(), domain.ExportRequest{Request: "http://example.com/$export", Types: []string{"Patient"}})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.BulkJobInProgress, job.Status)
+	suite.waitForJobs()
+
+	assert.Equal(suite.T(), domain.BulkJobCompleted, stored.Status)
+	assert.JSONEq(suite.T(), `[{"type":"Patient","name":"Patient.ndjson","count":2}]`, string(stored.Output))
+	data, err := os.ReadFile(filepath.Join(suite.directory, job.ID, "Patient.ndjson"))
+	suite.Require().NoError(err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	suite.Require().Len(lines, 2)
+	var patient map[string]interface{}
+	suite.Require().NoError(json.Unmarshal([]byte(lines[0]), &patient))
+	assert.Equal(suite.T(), "1", patient["id"])
+	assert.Equal(suite.T(), "2", patient["meta"].(map[string]interface{})["versionId"])
+}
+
+func (suite *BulkServiceTestSuite) TestStartExport_UnsupportedType() {
+	_, err := suite.service.StartExport(context.Background(), domain.ExportRequest{Types: []string{"Unknown"}})
+
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+func (suite *BulkServiceTestSuite) TestExportTypes_PatientCompartments() {
+	types, err := exportTypes(domain.ExportRequest{Patient: true})
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), "Patient", types[0])
+	for _, resourceType := range types[1:] {
+		assert.NotEmpty(suite.T(), domain.ResourceDefinitions[resourceType].Compartment, resourceType)
+	}
+}
+
+func (suite *BulkServiceTestSuite) TestRunExport_Batches() {
+	query := domain.ExportQuery{Until: time.Now(), Limit: 2}
+	suite.mockRepo.EXPECT().
+		ExportResources(gomock.Any(), "Observation", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, resourceType string, query domain.ExportQuery) ([]*domain.Resource, error) {
+			if query.AfterID == 0 {
+				return []*domain.Resource{
+					{ID: 1, FHIRData: []byte(`{"resourceType":"Observation","id":"a"}`)},
+					{ID: 5, FHIRData: []byte(`{"resourceType":"Observation","id":"b"}`)},
+				}, nil
+			}
+			assert.Equal(suite.T(), uint(5), query.AfterID)
+			return []*domain.Resource{{ID: 7, FHIRData: []byte(`{"resourceType":"Observation","id":"c"}`)}}, nil
+		}).
+		Times(2)
+	suite.mockRepo.EXPECT().
+		ExportResources(gomock.Any(), "Encounter", gomock.Any()).
+		Return([]*domain.Resource{}, nil)
+	var stored domain.BulkJob
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, job *domain.BulkJob) error {
+			stored = *job
+			return nil
+		}).
+		Times(3)
+
+	suite.service.runExport(context.Background(), domain.BulkJob{ID: "job-1"}, []string{"Observation", "Encounter"}, query)
+
+	assert.Equal(suite.T(), domain.BulkJobCompleted, stored.Status)
+	assert.JSONEq(suite.T(), `[{"type":"Observation","name":"Observation.ndjson","count":3}]`, string(stored.Output))
+	_, err := os.Stat(filepath.Join(suite.directory, "job-1", "Encounter.ndjson"))
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *BulkServiceTestSuite) TestRunExport_Fails() {
+	suite.mockRepo.EXPECT().
+		ExportPatients(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("connection lost"))
+	var stored domain.BulkJob
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, job *domain.BulkJob) error {
+			stored = *job
+			return nil
+		})
+
+	suite.service.runExport(context.Background(), domain.BulkJob{ID: "job-1"}, []string{"Patient"}, domain.ExportQuery{Limit: 10})
+
+	assert.Equal(suite.T(), domain.BulkJobFailed, stored.Status)
+	assert.Equal(suite.T(), "connection lost", stored.Error)
+}
+
+func (suite *BulkServiceTestSuite) TestDeleteJob_RemovesFiles() {
+	directory := filepath.Join(suite.directory, "job-1")
+	suite.Require().NoError(os.MkdirAll(directory, 0o755))
+	suite.Require().NoError(os.WriteFile(filepath.Join(directory, "Patient.ndjson"), []byte("{}\n"), 0o644))
+	suite.mockRepo.EXPECT().Get(gomock.Any(), "job-1").Return(&domain.BulkJob{ID: "job-1", Status: domain.BulkJobCompleted}, nil)
+	suite.mockRepo.EXPECT().Delete(gomock.Any(), "job-1").Return(nil)
+
+	err := suite.service.DeleteJob(context.Background(), "job-1")
+
+	suite.Require().NoError(err)
+	_, err = os.Stat(directory)
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *BulkServiceTestSuite) TestJobFile() {
+	job := &domain.BulkJob{ID: "job-1", Status: domain.BulkJobCompleted, Output: []byte(`[{"type":"Patient","name":"Patient.ndjson","count":1}]`)}
+	suite.mockRepo.EXPECT().Get(gomock.Any(), "job-1").Return(job, nil).Times(2)
+
+	path, err := suite.service.JobFile(context.Background(), "job-1", "Patient.ndjson")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), filepath.Join(suite.directory, "job-1", "Patient.ndjson"), path)
+
+	_, err = suite.service.JobFile(context.Background(), "job-1", "../secrets")
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+func (suite *BulkServiceTestSuite) TestStartImport_PathOutsideImportDirectory() {
+	_, err := suite.service.StartImport(context.Background(), domain.ImportRequest{Paths: []string{"../patients.ndjson"}})
+
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+func (suite *BulkServiceTestSuite) TestStartImport_Upload() {
+	var stored domain.BulkJob
+	suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	suite.mockPatients.EXPECT().
+		PreparePatient(gomock.Any(), []byte(`{"resourceType":"Patient"}`)).
+		Return(&domain.Patient{LogicalID: "1"}, nil)
+	suite.mockPatientRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(1)).Return(nil)
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, job *domain.BulkJob) error {
+			stored = *job
+			return nil
+		}).
+		Times(2)
+
+	job, err := suite.service.StartImport(context.Background(), domain.ImportRequest{Upload: strings.NewReader(`{"resourceType":"Patient"}` + "\n")})
+	suite.Require().NoError(err)
+	suite.waitForJobs()
+
+	assert.Equal(suite.T(), domain.BulkJobImport, job.Kind)
+	assert.Equal(suite.T(), domain.BulkJobCompleted, stored.Status)
+	assert.JSONEq(suite.T(), `[{"type":"Patient","input":"upload","count":1}]`, string(stored.Output))
+	assert.NoFileExists(suite.T(), filepath.Join(suite.directory, job.ID, importUploadFile), "the upload is deleted once imported")
+	assert.NoFileExists(suite.T(), filepath.Join(suite.directory, job.ID, importErrorsFile), "an empty error file is not kept")
+}
+
+func (suite *BulkServiceTestSuite) TestRunImport_ReportsFailedLines() {
+	lines := `{"resourceType":"Patient","id":"a"}` + "\n" +
+		`{"resourceType":"Observation"}` + "\n" +
+		"\n" +
+		`{"resourceType":"Patient","id":"b"}`
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.importDirectory, "patients.ndjson"), []byte(lines), 0o644))
+	input, err := suite.service.resolveImportPath("patients.ndjson")
+	suite.Require().NoError(err)
+
+	suite.mockPatients.EXPECT().
+		PreparePatient(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, resource []byte) (*domain.Patient, error) {
+			if strings.Contains(string(resource), "Observation") {
+				diagnostics := "resource type must be Patient"
+				return nil, &domain.ValidationError{Issues: []fhir.OperationOutcomeIssue{{Severity: fhir.IssueSeverityError, Code: fhir.IssueTypeStructure, Diagnostics: &diagnostics}}}
+			}
+			return &domain.Patient{FHIRData: resource}, nil
+		}).
+		Times(3)
+	suite.mockPatientRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(2)).Return(nil)
+	var stored domain.BulkJob
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, job *domain.BulkJob) error {
+			stored = *job
+			return nil
+		}).
+		Times(2)
+
+	suite.service.runImport(context.Background(), domain.BulkJob{ID: "job-1", Kind: domain.BulkJobImport}, []importInput{input})
+
+	assert.Equal(suite.T(), domain.BulkJobCompleted, stored.Status)
+	assert.JSONEq(suite.T(), `[
+		{"type":"Patient","input":"patients.ndjson","count":2},
+		{"type":"OperationOutcome","name":"errors.ndjson","count":1}
+	]`, string(stored.Output))
+	data, err := os.ReadFile(filepath.Join(suite.directory, "job-1", "errors.ndjson"))
+	suite.Require().NoError(err)
+	var outcome fhir.OperationOutcome
+	suite.Require().NoError(json.Unmarshal(data, &outcome))
+	suite.Require().Len(outcome.Issue, 1)
+	assert.Equal(suite.T(), "patients.ndjson line 2: resource type must be Patient", *outcome.Issue[0].Diagnostics)
+}
+
+func (suite *BulkServiceTestSuite) TestWriteBatch_RetriesConflictsOneByOne() {
+	batch := []importLine{
+		{number: 1, patient: &domain.Patient{LogicalID: "1"}},
+		{number: 2, patient: &domain.Patient{LogicalID: "2"}},
+	}
+	suite.mockPatientRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(2)).Return(domain.ErrConflict)
+	suite.mockPatientRepo.EXPECT().Create(gomock.Any(), batch[0].patient).Return(nil)
+	suite.mockPatientRepo.EXPECT().Create(gomock.Any(), batch[1].patient).Return(domain.ErrConflict)
+	var errorsFile bytes.Buffer
+	report := &importReport{errors: bufio.NewWriter(&errorsFile)}
+
+	count, err := suite.service.writeBatch(context.Background(), importInput{name: "upload"}, batch, report)
+
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), count)
+	assert.Equal(suite.T(), int64(1), report.failed)
+	suite.Require().NoError(report.errors.Flush())
+	assert.Contains(suite.T(), errorsFile.String(), `"code":"duplicate"`)
+	assert.Contains(suite.T(), errorsFile.String(), "upload line 2: ")
+}
+
+func (suite *BulkServiceTestSuite) TestWriteBatch_FailsOnDatabaseError() {
+	batch := []importLine{{number: 1, patient: &domain.Patient{LogicalID: "1"}}}
+	suite.mockPatientRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return(errors.New("connection lost"))
+
+	_, err := suite.service.writeBatch(context.Background(), importInput{name: "upload"}, batch, &importReport{})
+
+	assert.EqualError(suite.T(), err, "connection lost")
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirvalidation"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// BundleServiceInterface defines the contract for bundle service
+type BundleServiceInterface interface {
+	ProcessBatch(ctx context.Context, requests []domain.BundleEntryRequest) []domain.BundleEntryResult
+	ProcessTransaction(ctx context.Context, requests []domain.BundleEntryRequest) ([]domain.BundleEntryResult, error)
+}
+
+// urnPrefix marks the temporary fullUrl of a resource created by a transaction
+const urnPrefix = "urn:uuid:"
+
+type bundleService struct {
+	repo      domain.PatientRepository
+	validator *fhirvalidation.Validator
+	ids       domain.IDGenerator
+}
+
+// NewBundleService creates a new bundle service. Entries are validated and assigned logical
+// ids exactly as the patient service does for individual requests.
+func NewBundleService(repo domain.PatientRepository, validator *fhirvalidation.Validator, ids domain.IDGenerator) BundleServiceInterface {
+	return &bundleService{
+		repo:      repo,
+		validator: validator,
+		ids:       ids,
+	}
+}
+
+// ProcessBatch executes every entry independently in bundle order. A failing entry is
+// reported in its result and does not affect the others.
+func (s *bundleService) ProcessBatch(ctx context.Context, requests []domain.BundleEntryRequest) []domain.BundleEntryResult {
+	patients := s.patients(s.repo)
+	results := make([]domain.BundleEntryResult, len(requests))
+	for i, request := range requests {
+		result, err := s.execute(ctx, patients, request, "")
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Batch entry %d failed: %v", i, err)
+			result = domain.BundleEntryResult{Err: err}
+		}
+		results[i] = result
+	}
+	logger.WithContext(ctx).Infof("Processed batch of %d entries", len(requests))
+	return results
+}
+
+// ProcessTransaction executes every entry in a single database transaction, in the FHIR
+// processing order: deletes, creates, updates and patches, then reads. Logical ids are
+// reserved up front for patients created under a urn:uuid fullUrl, and references to that
+// fullUrl anywhere in the bundle are rewritten to Patient/<id>. The first failing entry
+// rolls back the whole transaction and is returned as an error naming the entry.
+func (s *bundleService) ProcessTransaction(ctx context.Context, requests []domain.BundleEntryRequest) ([]domain.BundleEntryResult, error) {
+	references := make(map[string]string)
+	reserved := make(map[int]string)
+	for i, request := range requests {
+		if !strings.HasPrefix(request.FullURL, urnPrefix) {
+			continue
+		}
+		if _, duplicate := references[request.FullURL]; duplicate {
+			return nil, fmt.Errorf("%w: entry %d: fullUrl %s is used by more than one entry", domain.ErrValidation, i, request.FullURL)
+		}
+		references[request.FullURL] = ""
+		if request.Method != http.MethodPost || request.Criteria != nil {
+			continue
+		}
+		logicalID, err := s.ids.NextID(ctx)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to reserve logical ID: %v", err)
+			return nil, err
+		}
+		reserved[i] = logicalID
+		references[request.FullURL] = "Patient/" + logicalID
+	}
+
+	results := make([]domain.BundleEntryResult, len(requests))
+	err := s.repo.Transaction(ctx, func(repo domain.PatientRepository) error {
+		patients := s.patients(repo)
+		resolved := make([][]byte, len(requests))
+		for _, i := range transactionOrder(requests) {
+			request := requests[i]
+			resource, err := resolveReferences(request.Resource, references)
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", i, err)
+			}
+			request.Resource = resource
+			resolved[i] = resource
+
+			result, err := s.execute(ctx, patients, request, reserved[i])
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", i, err)
+			}
+			// Conditional creates resolve to the patient they matched or created
+			if strings.HasPrefix(request.FullURL, urnPrefix) && result.Patient != nil {
+				references[request.FullURL] = "Patient/" + result.Patient.LogicalID
+			}
+			results[i] = result
+		}
+		// A reference to an entry processed later, such as another conditional create, was
+		// stored as the urn:uuid itself; roll back rather than keep the dangling reference
+		for i, resource := range resolved {
+			if reference := unresolvedReference(resource, references); reference != "" {
+				return fmt.Errorf("%w: entry %d: reference %s does not resolve to a resource created earlier in the transaction",
+					domain.ErrValidation, i, reference)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Transaction of %d entries failed: %v", len(requests), err)
+		return nil, err
+	}
+
+	logger.WithContext(ctx).Infof("Committed transaction of %d entries", len(requests))
+	return results, nil
+}
+
+// patients returns a patient service that reads and writes through repo
+func (s *bundleService) patients(repo domain.PatientRepository) *patientService {
+	return newPatientService(repo, s.validator, s.ids)
+}
+
+// execute runs one entry against the patient service. reservedID is the logical id
+// reserved for a create, or empty to allocate one.
+func (s *bundleService) execute(ctx context.Context, patients *patientService, request domain.BundleEntryRequest, reservedID string) (domain.BundleEntryResult, error) {
+	switch request.Method {
+	case http.MethodGet:
+		return read(ctx, patients, request)
+	case http.MethodPost:
+		fhirPatient, err := decodeEntryPatient(request.Resource)
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		if request.Criteria != nil {
+			return writeResult(patients.ConditionalCreatePatient(ctx, fhirPatient, request.Criteria))
+		}
+		patient, err := patients.createPatient(ctx, fhirPatient, reservedID)
+		return writeResult(patient, true, err)
+	case http.MethodPut:
+		fhirPatient, err := decodeEntryPatient(request.Resource)
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		if request.Criteria != nil {
+			return writeResult(patients.ConditionalUpdatePatient(ctx, request.Criteria, fhirPatient))
+		}
+		patient, err := patients.UpdatePatient(ctx, request.ID, fhirPatient, request.ExpectedVersion)
+		return writeResult(patient, false, err)
+	case http.MethodPatch:
+		var (
+			patient *domain.Patient
+			err     error
+		)
+		switch request.PatchFormat {
+		case domain.PatchFormatJSONPatch:
+			patient, err = patients.JSONPatchPatient(ctx, request.ID, request.Resource, request.ExpectedVersion)
+		case domain.PatchFormatFHIRPathPatch:
+			patient, err = patients.FHIRPathPatchPatient(ctx, request.ID, request.Resource, request.ExpectedVersion)
+		default:
+			err = fmt.Errorf("%w: unsupported patch format %q", domain.ErrValidation, request.PatchFormat)
+		}
+		return writeResult(patient, false, err)
+	case http.MethodDelete:
+		var err error
+		if request.Criteria != nil {
+			err = patients.ConditionalDeletePatient(ctx, request.Criteria)
+		} else {
+			err = patients.DeletePatient(ctx, request.ID, request.ExpectedVersion)
+		}
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		return domain.BundleEntryResult{Status: http.StatusNoContent}, nil
+	default:
+		return domain.BundleEntryResult{}, fmt.Errorf("%w: unsupported method %q", domain.ErrValidation, request.Method)
+	}
+}
+
+// read runs a read, vread or search entry
+func read(ctx context.Context, patients *patientService, request domain.BundleEntryRequest) (domain.BundleEntryResult, error) {
+	switch {
+	case request.VersionID > 0:
+		version, err := patients.GetPatientVersion(ctx, request.ID, request.VersionID)
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		return domain.BundleEntryResult{Status: http.StatusOK, Version: version}, nil
+	case request.ID != "":
+		patient, err := patients.GetPatient(ctx, request.ID)
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		return domain.BundleEntryResult{Status: http.StatusOK, Patient: patient}, nil
+	case request.Criteria != nil:
+		page, err := patients.SearchPatients(ctx, request.Criteria)
+		if err != nil {
+			return domain.BundleEntryResult{}, err
+		}
+		return domain.BundleEntryResult{Status: http.StatusOK, Matches: page.Patients, Total: page.Total}, nil
+	default:
+		return domain.BundleEntryResult{}, fmt.Errorf("%w: read entry needs an id or search criteria", domain.ErrValidation)
+	}
+}
+
+// writeResult reports a create or update, with 201 Created when a patient was created
+func writeResult(patient *domain.Patient, created bool, err error) (domain.BundleEntryResult, error) {
+	if err != nil {
+		return domain.BundleEntryResult{}, err
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return domain.BundleEntryResult{Status: status, Patient: patient}, nil
+}
+
+// decodeEntryPatient decodes the Patient resource of a create or update entry
+func decodeEntryPatient(resource []byte) (*fhir.Patient, error) {
+	if len(resource) == 0 {
+		return nil, fmt.Errorf("%w: entry has no resource", domain.ErrValidation)
+	}
+	fhirPatient, _, err := decodePatient(resource)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+	return fhirPatient, nil
+}
+
+// transactionOrder returns the entry indexes in FHIR transaction processing order.
+// Conditional creates run before plain creates so references to them are known.
+func transactionOrder(requests []domain.BundleEntryRequest) []int {
+	rank := func(request domain.BundleEntryRequest) int {
+		switch request.Method {
+		case http.MethodDelete:
+			return 0
+		case http.MethodPost:
+			if request.Criteria != nil {
+				return 1
+			}
+			return 2
+		case http.MethodPut, http.MethodPatch:
+			return 3
+		default:
+			return 4
+		}
+	}
+
+	order := make([]int, len(requests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rank(requests[order[a]]) < rank(requests[order[b]])
+	})
+	return order
+}
+
+// resolveReferences replaces every string in a JSON document that is a resolved urn:uuid
+// fullUrl with the reference to the resource it was resolved to
+func resolveReferences(document []byte, references map[string]string) ([]byte, error) {
+	if len(document) == 0 || !bytes.Contains(document, []byte(urnPrefix)) {
+		return document, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(document, &value); err != nil {
+		return nil, fmt.Errorf("%w: entry resource is not valid JSON", domain.ErrValidation)
+	}
+	return json.Marshal(replaceReferences(value, references))
+}
+
+// unresolvedReference returns the first string in a JSON document that is a urn:uuid fullUrl of
+// the transaction, or an empty string when the document has none
+func unresolvedReference(document []byte, references map[string]string) string {
+	if len(document) == 0 || !bytes.Contains(document, []byte(urnPrefix)) {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(document, &value); err != nil {
+		return ""
+	}
+	return findReference(value, references)
+}
+
+func findReference(value interface{}, references map[string]string) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if reference := findReference(child, references); reference != "" {
+				return reference
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if reference := findReference(child, references); reference != "" {
+				return reference
+			}
+		}
+	case string:
+		if _, ok := references[v]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func replaceReferences(value interface{}, references map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = replaceReferences(child, references)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = replaceReferences(child, references)
+		}
+	case string:
+		if reference := references[v]; reference != "" {
+			return reference
+		}
+	}
+	return value
+}
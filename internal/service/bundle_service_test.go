@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirvalidation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// BundleServiceTestSuite defines the test suite
+type BundleServiceTestSuite struct {
+	suite.Suite
+	ctrl     *gomock.Controller
+	mockRepo *mocks.MockPatientRepository
+	mockIDs  *mocks.MockIDGenerator
+	service  BundleServiceInterface
+}
+
+// SetupTest initializes the test suite before each test
+func (suite *BundleServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockRepo = mocks.NewMockPatientRepository(suite.ctrl)
+	suite.mockIDs = mocks.NewMockIDGenerator(suite.ctrl)
+	suite.service = NewBundleService(suite.mockRepo, fhirvalidation.NewValidator(), suite.mockIDs)
+
+	// Created patients are checked for duplicates, which none of these tests find
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		AnyTimes()
+}
+
+// TearDownTest cleans up after each test
+func (suite *BundleServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestBundleServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(BundleServiceTestSuite))
+}
+
+// expectTransaction runs transaction callbacks against the mock repository and returns their error
+func (suite *BundleServiceTestSuite) expectTransaction() {
+	suite.mockRepo.EXPECT().
+		Transaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repo domain.PatientRepository) error) error {
+			return fn(suite.mockRepo)
+		})
+}
+
+// TestProcessTransaction_ResolvesReferences tests that urn:uuid references resolve to reserved ids
+func (suite *BundleServiceTestSuite) TestProcessTransaction_ResolvesReferences() {
+	// Arrange
+	requests := []domain.BundleEntryRequest{
+		{
+			Method:   http.MethodPost,
+			FullURL:  "urn:uuid:61ebe359-bfdc-4613-8bf2-c5e300945f0a",
+			Resource: []byte(`{"resourceType":"Patient","link":[{"other":{"reference":"urn:uuid:88f151c0-a954-468a-88bd-5ae15c08e059"},"type":"seealso"}]}`),
+		},
+		{
+			Method:   http.MethodPost,
+			FullURL:  "urn:uuid:88f151c0-a954-468a-88bd-5ae15c08e059",
+			Resource: []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}]}`),
+		},
+	}
+	suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("10", nil)
+	suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("11", nil)
+	suite.expectTransaction()
+	var stored []*domain.Patient
+	suite.mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, patient *domain.Patient) error {
+			stored = append(stored, patient)
+			return nil
+		}).
+		Times(2)
+
+	// Act
+	results, err := suite.service.ProcessTransaction(context.Background(), requests)
+
+	// Assert
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	assert.Equal(suite.T(), http.StatusCreated, results[0].Status)
+	assert.Equal(suite.T(), "10", results[0].Patient.LogicalID)
+	assert.Equal(suite.T(), "11", results[1].Patient.LogicalID)
+	suite.Require().Len(stored, 2)
+	assert.Contains(suite.T(), string(stored[0].FHIRData), `"reference":"Patient/11"`)
+}
+
+// TestProcessTransaction_ProcessingOrder tests that deletes run before creates regardless of entry order
+func (suite *BundleServiceTestSuite) TestProcessTransaction_ProcessingOrder() {
+	// Arrange
+	requests := []domain.BundleEntryRequest{
+		{Method: http.MethodPost, Resource: []byte(`{"resourceType":"Patient"}`)},
+		{Method: http.MethodDelete, ID: "7"},
+	}
+	suite.expectTransaction()
+	gomock.InOrder(
+		suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "7").Return(&domain.Patient{ID: 7, LogicalID: "7", VersionID: 1}, nil),
+		suite.mockRepo.EXPECT().Delete(gomock.Any(), uint(7), 0).Return(nil),
+		suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("8", nil),
+		suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	// Act
+	results, err := suite.service.ProcessTransaction(context.Background(), requests)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), http.StatusCreated, results[0].Status)
+	assert.Equal(suite.T(), http.StatusNoContent, results[1].Status)
+}
+
+// TestProcessTransaction_FailingEntry tests that a failing entry fails the whole transaction
+func (suite *BundleServiceTestSuite) TestProcessTransaction_FailingEntry() {
+	// Arrange
+	requests := []domain.BundleEntryRequest{
+		{Method: http.MethodPost, Resource: []byte(`{"resourceType":"Patient"}`)},
+		{Method: http.MethodGet, ID: "missing"},
+	}
+	suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("1", nil)
+	suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "missing").Return(nil, domain.ErrNotFound)
+	suite.mockRepo.EXPECT().
+		Transaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repo domain.PatientRepository) error) error {
+			err := fn(suite.mockRepo)
+			assert.Error(suite.T(), err, "the callback must fail so the repository rolls back")
+			return err
+		})
+
+	// Act
+	results, err := suite.service.ProcessTransaction(context.Background(), requests)
+
+	// Assert
+	assert.Nil(suite.T(), results)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+	assert.Contains(suite.T(), err.Error(), "entry 1")
+}
+
+// TestProcessTransaction_DuplicateFullURL tests that a fullUrl may only identify one entry
+func (suite *BundleServiceTestSuite) TestProcessTransaction_DuplicateFullURL() {
+	// Arrange
+	fullURL := "urn:uuid:0c3151bd-1cbf-4d64-b04d-cd9187a4c6e0"
+	requests := []domain.BundleEntryRequest{
+		{Method: http.MethodPost, FullURL: fullURL, Resource: []byte(`{"resourceType":"Patient"}`)},
+		{Method: http.MethodPost, FullURL: fullURL, Resource: []byte(`{"resourceType":"Patient"}`)},
+	}
+	suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("1", nil)
+
+	// Act
+	_, err := suite.service.ProcessTransaction(context.Background(), requests)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+// TestProcessTransaction_UnresolvedReference tests that a reference to a conditional create
+// processed later is rejected rather than stored as a urn:uuid
+func (suite *BundleServiceTestSuite) TestProcessTransaction_UnresolvedReference() {
+	// Arrange
+	fullURL := "urn:uuid:5d1f0c2e-3a49-4f7b-9d27-8c1e6b0a4f13"
+	requests := []domain.BundleEntryRequest{
+		{
+			Method:   http.MethodPost,
+			Criteria: &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "identifier", Values: []string{"urn:mrn|1"}}}},
+			Resource: []byte(`{"resourceType":"Patient","link":[{"other":{"reference":"` + fullURL + `"},"type":"seealso"}]}`),
+		},
+		{
+			Method:   http.MethodPost,
+			FullURL:  fullURL,
+			Criteria: &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "identifier", Values: []string{"urn:mrn|2"}}}},
+			Resource: []byte(`{"resourceType":"Patient"}`),
+		},
+	}
+	gomock.InOrder(
+		suite.mockRepo.EXPECT().Search(gomock.Any(), gomock.Any()).Return(&domain.PatientPage{}, nil),
+		suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("1", nil),
+		suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+		suite.mockRepo.EXPECT().Search(gomock.Any(), gomock.Any()).
+			Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 2, LogicalID: "2", VersionID: 1}}, Total: 1}, nil),
+	)
+	suite.mockRepo.EXPECT().
+		Transaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repo domain.PatientRepository) error) error {
+			err := fn(suite.mockRepo)
+			assert.Error(suite.T(), err, "the callback must fail so the repository rolls back")
+			return err
+		})
+
+	// Act
+	results, err := suite.service.ProcessTransaction(context.Background(), requests)
+
+	// Assert
+	assert.Nil(suite.T(), results)
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+	assert.Contains(suite.T(), err.Error(), fullURL)
+}
+
+// TestProcessBatch_ReportsEntryFailures tests that batch entries fail independently
+func (suite *BundleServiceTestSuite) TestProcessBatch_ReportsEntryFailures() {
+	// Arrange
+	requests := []domain.BundleEntryRequest{
+		{Method: http.MethodGet, ID: "missing"},
+		{Method: http.MethodPost, Resource: []byte(`{"resourceType":"Observation"}`)},
+		{Method: http.MethodGet, ID: "5"},
+	}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "missing").Return(nil, domain.ErrNotFound)
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "5").Return(&domain.Patient{ID: 5, LogicalID: "5"}, nil)
+
+	// Act
+	results := suite.service.ProcessBatch(context.Background(), requests)
+
+	// Assert
+	suite.Require().Len(results, 3)
+	assert.True(suite.T(), errors.Is(results[0].Err, domain.ErrNotFound))
+	assert.True(suite.T(), errors.Is(results[1].Err, domain.ErrValidation))
+	assert.NoError(suite.T(), results[2].Err)
+	assert.Equal(suite.T(), http.StatusOK, results[2].Status)
+	assert.Equal(suite.T(), "5", results[2].Patient.LogicalID)
+}
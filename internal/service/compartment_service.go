@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// CompartmentServiceInterface defines the contract for patient compartment service
+type CompartmentServiceInterface interface {
+	PatientEverything(ctx context.Context, query domain.CompartmentQuery) ([]domain.CompartmentEntry, int64, error)
+	PatientIncludes(ctx context.Context, patients []*domain.Patient, query *fhirsearch.Query) ([]domain.CompartmentEntry, bool, error)
+}
+
+type compartmentService struct {
+	patients  domain.PatientRepository
+	resources domain.ResourceRepository
+}
+
+// NewCompartmentService creates a new service for the patient compartment, which spans the
+// patients table and the generic resource store
+func NewCompartmentService(patients domain.PatientRepository, resources domain.ResourceRepository) CompartmentServiceInterface {
+	return &compartmentService{
+		patients:  patients,
+		resources: resources,
+	}
+}
+
+// PatientEverything returns a page of a patient's compartment: the patient itself, first,
+// followed by every resource that refers to it, with the total size of the compartment
+func (s *compartmentService) PatientEverything(ctx context.Context, query domain.CompartmentQuery) ([]domain.CompartmentEntry, int64, error) {
+	for _, resourceType := range query.Types {
+		if _, ok := domain.ResourceDefinitions[resourceType]; !ok && resourceType != "Patient" {
+			return nil, 0, fmt.Errorf("%w: resource type %s is not supported", domain.ErrValidation, resourceType)
+		}
+	}
+
+	patient, err := s.patients.GetByLogicalID(ctx, query.PatientID)
+	if err != nil {
+		return nil, 0, err
+	}
+	includePatient := (len(query.Types) == 0 || slices.Contains(query.Types, "Patient")) &&
+		(query.Since == nil || !patient.UpdatedAt.Before(*query.Since))
+
+	// The patient takes the first slot of the first page
+	resourceQuery := query
+	if includePatient {
+		if query.Offset == 0 {
+			resourceQuery.Count = max(query.Count-1, 0)
+		} else {
+			resourceQuery.Offset = query.Offset - 1
+		}
+	}
+	resources, total, err := s.resources.Compartment(ctx, resourceQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]domain.CompartmentEntry, 0, len(resources)+1)
+	if includePatient {
+		total++
+		if query.Offset == 0 && query.Count > 0 {
+			entry, err := patientEntry(patient)
+			if err != nil {
+				return nil, 0, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+	for _, resource := range resources {
+		data, err := resourceToFHIR(resource)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to convert %s %s to FHIR: %v", resource.ResourceType, resource.LogicalID, err)
+			continue
+		}
+		entries = append(entries, domain.CompartmentEntry{ResourceType: resource.ResourceType, LogicalID: resource.LogicalID, Resource: data})
+	}
+
+	logger.WithContext(ctx).Infof("Patient %s compartment holds %d resources, returning %d", query.PatientID, total, len(entries))
+	return entries, total, nil
+}
+
+// PatientIncludes resolves the _include and _revinclude directives of a patient search for a
+// page of matching patients. Included resources are returned once each, up to
+// fhirsearch.MaxIncludeCount, together with whether that cap left resources out.
+func (s *compartmentService) PatientIncludes(ctx context.Context, patients []*domain.Patient, query *fhirsearch.Query) ([]domain.CompartmentEntry, bool, error) {
+	if err := validatePatientIncludes(query); err != nil {
+		return nil, false, err
+	}
+	included := &includeSet{seen: map[string]bool{}, entries: []domain.CompartmentEntry{}}
+	if len(patients) == 0 {
+		return included.entries, false, nil
+	}
+
+	for _, include := range query.Include {
+		def := patientIncludeParameter(include.Param)
+		idsByType := map[string][]string{}
+		for _, patient := range patients {
+			values, err := fhirsearch.Extract(patient.FHIRData, []fhirsearch.Definition{def})
+			if err != nil {
+				return nil, false, err
+			}
+			for _, value := range values {
+				resourceType, id, ok := strings.Cut(value.Value, "/")
+				if ok && (include.Target == "" || include.Target == resourceType) {
+					idsByType[resourceType] = append(idsByType[resourceType], id)
+				}
+			}
+		}
+
+		types := make([]string, 0, len(idsByType))
+		for resourceType := range idsByType {
+			if _, ok := domain.ResourceDefinitions[resourceType]; ok {
+				types = append(types, resourceType)
+			}
+		}
+		sort.Strings(types)
+		for _, resourceType := range types {
+			resources, err := s.resources.GetByLogicalIDs(ctx, resourceType, idsByType[resourceType])
+			if err != nil {
+				return nil, false, err
+			}
+			included.add(ctx, resources)
+		}
+	}
+
+	references := make([]string, len(patients))
+	for i, patient := range patients {
+		references[i] = "Patient/" + patient.LogicalID
+	}
+	for _, include := range query.RevInclude {
+		resources, err := s.resources.Referencing(ctx, include.Source, include.Param, references, fhirsearch.MaxIncludeCount+1)
+		if err != nil {
+			return nil, false, err
+		}
+		included.add(ctx, resources)
+	}
+
+	if included.truncated {
+		logger.WithContext(ctx).Warnf("Included resources were capped at %d", fhirsearch.MaxIncludeCount)
+	}
+	return included.entries, included.truncated, nil
+}
+
+// includeSet collects included resources once each, up to fhirsearch.MaxIncludeCount
+type includeSet struct {
+	seen      map[string]bool
+	entries   []domain.CompartmentEntry
+	truncated bool
+}
+
+// add adds the resources not collected yet, noting when the cap leaves some out
+func (i *includeSet) add(ctx context.Context, resources []*domain.Resource) {
+	for _, resource := range resources {
+		key := resource.ResourceType + "/" + resource.LogicalID
+		if i.seen[key] {
+			continue
+		}
+		if len(i.entries) >= fhirsearch.MaxIncludeCount {
+			i.truncated = true
+			return
+		}
+		data, err := resourceToFHIR(resource)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to convert %s to FHIR: %v", key, err)
+			continue
+		}
+		i.seen[key] = true
+		i.entries = append(i.entries, domain.CompartmentEntry{ResourceType: resource.ResourceType, LogicalID: resource.LogicalID, Resource: data})
+	}
+}
+
+// validatePatientIncludes checks that every _include follows a Patient reference parameter to a
+// served type and every _revinclude follows a reference parameter of a served type to Patient
+func validatePatientIncludes(query *fhirsearch.Query) error {
+	for _, include := range query.Include {
+		def := patientIncludeParameter(include.Param)
+		_, served := domain.ResourceDefinitions[include.Target]
+		if include.Source != "Patient" || def.Name == "" ||
+			(include.Target != "" && (!served || (def.Target != "" && def.Target != include.Target))) {
+			return fmt.Errorf("%w: _include %s is not supported", domain.ErrValidation, include)
+		}
+	}
+	revIncludes := domain.PatientRevIncludes()
+	for _, include := range query.RevInclude {
+		supported := fhirsearch.Include{Source: include.Source, Param: include.Param}
+		if (include.Target != "" && include.Target != "Patient") || !slices.Contains(revIncludes, supported) {
+			return fmt.Errorf("%w: _revinclude %s is not supported", domain.ErrValidation, include)
+		}
+	}
+	return nil
+}
+
+// patientIncludeParameter returns the Patient reference parameter with the given name, or an
+// empty definition when there is none
+func patientIncludeParameter(name string) fhirsearch.Definition {
+	for _, def := range domain.PatientIncludeParameters {
+		if def.Name == name {
+			return def
+		}
+	}
+	return fhirsearch.Definition{}
+}
+
+// patientEntry returns the FHIR JSON of a stored patient with its version metadata
+func patientEntry(patient *domain.Patient) (domain.CompartmentEntry, error) {
+	var fhirPatient fhir.Patient
+	if err := json.Unmarshal(patient.FHIRData, &fhirPatient); err != nil {
+		return domain.CompartmentEntry{}, fmt.Errorf("failed to unmarshal FHIR data: %w", err)
+	}
+	applyVersionMeta(&fhirPatient, patient.VersionID, patient.UpdatedAt)
+	data, err := json.Marshal(fhirPatient)
+	if err != nil {
+		return domain.CompartmentEntry{}, fmt.Errorf("failed to marshal FHIR patient: %w", err)
+	}
+	return domain.CompartmentEntry{ResourceType: "Patient", LogicalID: patient.LogicalID, Resource: data}, nil
+}
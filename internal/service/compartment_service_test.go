@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// CompartmentServiceTestSuite defines the test suite
+type CompartmentServiceTestSuite struct {
+	suite.Suite
+	ctrl          *gomock.Controller
+	mockPatients  *mocks.MockPatientRepository
+	mockResources *mocks.MockResourceRepository
+	service       CompartmentServiceInterface
+}
+
+// SetupTest initializes the test suite before each test
+func (suite *CompartmentServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockPatients = mocks.NewMockPatientRepository(suite.ctrl)
+	suite.mockResources = mocks.NewMockResourceRepository(suite.ctrl)
+	suite.service = NewCompartmentService(suite.mockPatients, suite.mockResources)
+}
+
+// TearDownTest cleans up after each test
+func (suite *CompartmentServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestCompartmentServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CompartmentServiceTestSuite))
+}
+
+var testCompartmentPatient = &domain.Patient{
+	LogicalID: "1",
+	FHIRData:  []byte(`{"resourceType":"Patient","id":"1"}`),
+	VersionID: 2,
+	UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestPatientEverything_FirstPage tests that the patient leads the first page and counts towards the total
+func (suite *CompartmentServiceTestSuite) TestPatientEverything_FirstPage() {
+	// Arrange
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(testCompartmentPatient, nil)
+	suite.mockResources.EXPECT().
+		Compartment(gomock.Any(), domain.CompartmentQuery{PatientID: "1", Count: 2}).
+		Return([]*domain.Resource{
+			{ResourceType: "Encounter", LogicalID: "5", FHIRData: []byte(`{"resourceType":"Encounter","id":"5"}`)},
+			{ResourceType: "Observation", LogicalID: "6", FHIRData: []byte(`{"resourceType":"Observation","id":"6"}`)},
+		}, int64(4), nil)
+
+	// Act
+	entries, total, err := suite.service.PatientEverything(context.Background(), domain.CompartmentQuery{PatientID: "1", Count: 3})
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(5), total)
+	suite.Require().Len(entries, 3)
+	assert.Equal(suite.T(), "Patient", entries[0].ResourceType)
+	assert.JSONEq(suite.T(), `{"resourceType":"Patient","id":"1","meta":{"versionId":"2","lastUpdated":"2024-01-01T00:00:00.000Z"}}`, string(entries[0].Resource))
+	assert.Equal(suite.T(), "Observation", entries[2].ResourceType)
+}
+
+// TestPatientEverything_LaterPage tests that later pages skip the slot taken by the patient
+func (suite *CompartmentServiceTestSuite) TestPatientEverything_LaterPage() {
+	// Arrange
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(testCompartmentPatient, nil)
+	suite.mockResources.EXPECT().
+		Compartment(gomock.Any(), domain.CompartmentQuery{PatientID: "1", Count: 3, Offset: 2}).
+		Return([]*domain.Resource{}, int64(4), nil)
+
+	// Act
+	entries, total, err := suite.service.PatientEverything(context.Background(), domain.CompartmentQuery{PatientID: "1", Count: 3, Offset: 3})
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(5), total)
+	assert.Empty(suite.T(), entries)
+}
+
+// TestPatientEverything_Filters tests that _type and _since can leave out the patient
+func (suite *CompartmentServiceTestSuite) TestPatientEverything_Filters() {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query domain.CompartmentQuery
+	}{
+		{name: "type", query: domain.CompartmentQuery{PatientID: "1", Types: []string{"Observation"}, Count: 10}},
+		{name: "since", query: domain.CompartmentQuery{PatientID: "1", Since: &since, Count: 10}},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(testCompartmentPatient, nil)
+			suite.mockResources.EXPECT().Compartment(gomock.Any(), tt.query).Return([]*domain.Resource{}, int64(0), nil)
+
+			entries, total, err := suite.service.PatientEverything(context.Background(), tt.query)
+
+			suite.Require().NoError(err)
+			assert.Equal(suite.T(), int64(0), total)
+			assert.Empty(suite.T(), entries)
+		})
+	}
+}
+
+// TestPatientEverything_Errors tests unknown types and unknown patients
+func (suite *CompartmentServiceTestSuite) TestPatientEverything_Errors() {
+	_, _, err := suite.service.PatientEverything(context.Background(), domain.CompartmentQuery{PatientID: "1", Types: []string{"Medication"}})
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "2").Return(nil, domain.ErrNotFound)
+	_, _, err = suite.service.PatientEverything(context.Background(), domain.CompartmentQuery{PatientID: "2"})
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestPatientIncludes tests that referenced and referring resources are added once each
+func (suite *CompartmentServiceTestSuite) TestPatientIncludes() {
+	// Arrange
+	patients := []*domain.Patient{
+		{LogicalID: "1", FHIRData: []byte(`{"resourceType":"Patient","managingOrganization":{"reference":"Organization/7"},
+			"generalPractitioner":[{"reference":"Practitioner/3"},{"reference":"Organization/7"}]}`)},
+		{LogicalID: "2", FHIRData: []byte(`{"resourceType":"Patient","managingOrganization":{"reference":"Organization/7"}}`)},
+	}
+	organization := &domain.Resource{ResourceType: "Organization", LogicalID: "7", FHIRData: []byte(`{"resourceType":"Organization","id":"7"}`)}
+	practitioner := &domain.Resource{ResourceType: "Practitioner", LogicalID: "3", FHIRData: []byte(`{"resourceType":"Practitioner","id":"3"}`)}
+	observation := &domain.Resource{ResourceType: "Observation", LogicalID: "9", FHIRData: []byte(`{"resourceType":"Observation","id":"9"}`)}
+	suite.mockResources.EXPECT().GetByLogicalIDs(gomock.Any(), "Organization", []string{"7", "7"}).Return([]*domain.Resource{organization}, nil)
+	suite.mockResources.EXPECT().GetByLogicalIDs(gomock.Any(), "Organization", []string{"7"}).Return([]*domain.Resource{organization}, nil)
+	suite.mockResources.EXPECT().GetByLogicalIDs(gomock.Any(), "Practitioner", []string{"3"}).Return([]*domain.Resource{practitioner}, nil)
+	suite.mockResources.EXPECT().
+		Referencing(gomock.Any(), "Observation", "subject", []string{"Patient/1", "Patient/2"}, gomock.Any()).
+		Return([]*domain.Resource{observation}, nil)
+	query := &fhirsearch.Query{
+		Include: []fhirsearch.Include{
+			{Source: "Patient", Param: "organization"},
+			{Source: "Patient", Param: "general-practitioner"},
+		},
+		RevInclude: []fhirsearch.Include{{Source: "Observation", Param: "subject"}},
+	}
+
+	// Act
+	entries, truncated, err := suite.service.PatientIncludes(context.Background(), patients, query)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.False(suite.T(), truncated)
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.ResourceType+"/"+entry.LogicalID)
+	}
+	assert.Equal(suite.T(), []string{"Organization/7", "Practitioner/3", "Observation/9"}, keys)
+}
+
+// TestPatientIncludes_Cap tests that included resources stop at the cap
+func (suite *CompartmentServiceTestSuite) TestPatientIncludes_Cap() {
+	// Arrange
+	resources := make([]*domain.Resource, fhirsearch.MaxIncludeCount+1)
+	for i := range resources {
+		resources[i] = &domain.Resource{ResourceType: "Encounter", LogicalID: strconv.Itoa(i), FHIRData: []byte(`{"resourceType":"Encounter"}`)}
+	}
+	suite.mockResources.EXPECT().Referencing(gomock.Any(), "Encounter", "patient", []string{"Patient/1"}, fhirsearch.MaxIncludeCount+1).Return(resources, nil)
+	query := &fhirsearch.Query{RevInclude: []fhirsearch.Include{{Source: "Encounter", Param: "patient", Target: "Patient"}}}
+
+	// Act
+	entries, truncated, err := suite.service.PatientIncludes(context.Background(), []*domain.Patient{{LogicalID: "1"}}, query)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.True(suite.T(), truncated)
+	assert.Len(suite.T(), entries, fhirsearch.MaxIncludeCount)
+}
+
+// TestPatientIncludes_Unsupported tests that includes the server cannot follow are rejected
+func (suite *CompartmentServiceTestSuite) TestPatientIncludes_Unsupported() {
+	tests := []struct {
+		name  string
+		query *fhirsearch.Query
+	}{
+		{name: "unknown parameter", query: &fhirsearch.Query{Include: []fhirsearch.Include{{Source: "Patient", Param: "link"}}}},
+		{name: "other source", query: &fhirsearch.Query{Include: []fhirsearch.Include{{Source: "Observation", Param: "subject"}}}},
+		{name: "wrong target", query: &fhirsearch.Query{Include: []fhirsearch.Include{{Source: "Patient", Param: "organization", Target: "Practitioner"}}}},
+		{name: "revinclude not to patients", query: &fhirsearch.Query{RevInclude: []fhirsearch.Include{{Source: "Encounter", Param: "service-provider"}}}},
+		{name: "revinclude unknown type", query: &fhirsearch.Query{RevInclude: []fhirsearch.Include{{Source: "Condition", Param: "subject"}}}},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, _, err := suite.service.PatientIncludes(context.Background(), []*domain.Patient{{LogicalID: "1"}}, tt.query)
+			assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+		})
+	}
+}
@@ -2,8 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"time"
 
+	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/pkg/cache"
 	"go-fhir-demo/pkg/fhirclient"
 	"go-fhir-demo/pkg/logger"
@@ -18,6 +24,7 @@ type ExternalPatientServiceInterface interface {
 	GetExternalPatientByIDDelayed(ctx context.Context, id string, timeout time.Duration) (*fhir.Patient, error)
 	SearchExternalPatients(ctx context.Context, params map[string]string) (*fhir.Bundle, error)
 	CreateExternalPatient(ctx context.Context, patient *fhir.Patient) (*fhir.Patient, error)
+	GetExternalPatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error)
 }
 
 type externalPatientService struct {
@@ -35,17 +42,39 @@ func NewExternalPatientService(client fhirclient.ClientInterface, cache cache.Ca
 
 // GetExternalPatientByID retrieves a patient from the external FHIR server by ID.
 func (s *externalPatientService) GetExternalPatientByID(ctx context.Context, id string) (*fhir.Patient, error) {
-	return s.client.GetPatientByID(ctx, id)
+	patient, err := s.client.GetPatientByID(ctx, id)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+	return patient, nil
 }
 
 // SearchExternalPatients searches for patients on the external FHIR server.
 func (s *externalPatientService) SearchExternalPatients(ctx context.Context, params map[string]string) (*fhir.Bundle, error) {
-	return s.client.SearchPatients(ctx, params)
+	bundle, err := s.client.SearchPatients(ctx, params)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+	return bundle, nil
 }
 
 // CreateExternalPatient creates a patient on the external FHIR server.
 func (s *externalPatientService) CreateExternalPatient(ctx context.Context, patient *fhir.Patient) (*fhir.Patient, error) {
-	return s.client.CreatePatient(ctx, patient)
+	created, err := s.client.CreatePatient(ctx, patient)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+	return created, nil
+}
+
+// GetExternalPatientEverything retrieves a patient's compartment from the external FHIR server
+// through its Patient/$everything operation.
+func (s *externalPatientService) GetExternalPatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error) {
+	bundle, err := s.client.PatientEverything(ctx, id, params)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+	return bundle, nil
 }
 
 // GetExternalPatientByIDCached retrieves a patient with Redis caching
@@ -65,7 +94,7 @@ func (s *externalPatientService) GetExternalPatientByIDCached(ctx context.Contex
 	logger.WithContext(ctx).Infof("Cache miss for patient %s, fetching from external server", id)
 	patient, err := s.client.GetPatientByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, upstreamError(err)
 	}
 
 	// Store in cache for future requests (expire after 1 hour)
@@ -106,12 +135,35 @@ func (s *externalPatientService) GetExternalPatientByIDDelayed(ctx context.Conte
 	case result := <-resultChan:
 		if result.err != nil {
 			logger.WithContext(ctx).Errorf("Failed to get patient %s from external server: %v", id, result.err)
-			return nil, result.err
+			return nil, upstreamError(result.err)
 		}
 		logger.WithContext(ctx).Infof("Patient %s retrieved from external server within timeout", id)
 		return result.patient, nil
 	case <-timeoutCtx.Done():
 		logger.WithContext(ctx).Errorf("Timeout occurred while fetching patient %s from external server", id)
-		return nil, timeoutCtx.Err()
+		return nil, upstreamError(timeoutCtx.Err())
+	}
+}
+
+// upstreamError maps an error from the external FHIR server onto the domain error it represents,
+// keeping the original error in the chain
+func upstreamError(err error) error {
+	var statusErr *fhirclient.StatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+		case http.StatusGone:
+			return fmt.Errorf("%w: %w", domain.ErrGone, err)
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return fmt.Errorf("%w: %w", domain.ErrValidation, err)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %w", domain.ErrConflict, err)
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %w", domain.ErrTimeout, err)
 	}
+	return fmt.Errorf("%w: %w", domain.ErrUpstream, err)
 }
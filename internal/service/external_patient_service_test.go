@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"go-fhir-demo/internal/domain"
 	redisclientmock "go-fhir-demo/pkg/cache/mocks"
+	"go-fhir-demo/pkg/fhirclient"
 	fhirclientmocks "go-fhir-demo/pkg/fhirclient/mocks"
+	"net/url"
 	"testing"
 	"time"
 
@@ -69,6 +72,35 @@ func (suite *ExternalPatientServiceTestSuite) TestGetExternalPatientByID_Error()
 	assert.Contains(suite.T(), err.Error(), "patient not found")
 }
 
+// TestGetExternalPatientByID_UpstreamNotFound tests that a 404 from the FHIR server is reported as not found
+func (suite *ExternalPatientServiceTestSuite) TestGetExternalPatientByID_UpstreamNotFound() {
+	// Arrange
+	testID := "missing"
+	suite.mockClient.EXPECT().GetPatientByID(gomock.Any(), testID).Return(nil, &fhirclient.StatusError{StatusCode: 404})
+
+	// Act
+	patient, err := suite.service.GetExternalPatientByID(context.Background(), testID)
+
+	// Assert
+	assert.Nil(suite.T(), patient)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestGetExternalPatientByID_UpstreamFailure tests that other FHIR server failures are reported as upstream errors
+func (suite *ExternalPatientServiceTestSuite) TestGetExternalPatientByID_UpstreamFailure() {
+	// Arrange
+	testID := "broken"
+	suite.mockClient.EXPECT().GetPatientByID(gomock.Any(), testID).Return(nil, &fhirclient.StatusError{StatusCode: 503})
+
+	// Act
+	patient, err := suite.service.GetExternalPatientByID(context.Background(), testID)
+
+	// Assert
+	assert.Nil(suite.T(), patient)
+	assert.ErrorIs(suite.T(), err, domain.ErrUpstream)
+	assert.NotErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
 // TestSearchExternalPatients_Success tests successful patient search
 func (suite *ExternalPatientServiceTestSuite) TestSearchExternalPatients_Success() {
 	// Arrange
@@ -275,4 +307,19 @@ func (suite *ExternalPatientServiceTestSuite) TestGetExternalPatientByIDDelayed_
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), patient)
 	assert.Contains(suite.T(), err.Error(), "context deadline exceeded")
+	assert.ErrorIs(suite.T(), err, domain.ErrTimeout)
+}
+
+// TestGetExternalPatientEverything_UpstreamNotFound tests that $everything passes parameters through and maps upstream errors
+func (suite *ExternalPatientServiceTestSuite) TestGetExternalPatientEverything_UpstreamNotFound() {
+	// Arrange
+	params := url.Values{"_type": {"Observation"}}
+	suite.mockClient.EXPECT().PatientEverything(gomock.Any(), "missing", params).Return(nil, &fhirclient.StatusError{StatusCode: 404})
+
+	// Act
+	bundle, err := suite.service.GetExternalPatientEverything(context.Background(), "missing", params)
+
+	// Assert
+	assert.Nil(suite.T(), bundle)
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
 }
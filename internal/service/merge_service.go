@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirvalidation"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// MergeServiceInterface defines the contract for merge service
+type MergeServiceInterface interface {
+	MergePatients(ctx context.Context, request domain.MergeRequest) (*domain.MergeResult, error)
+	UnmergePatient(ctx context.Context, sourceID string) (*domain.MergeResult, error)
+}
+
+type mergeService struct {
+	patients *patientService
+	merges   domain.MergeRepository
+	rules    domain.MergeRules
+}
+
+// NewMergeService creates a new merge service. Identifiers, names, telecom and addresses are
+// combined by rules; both merged patients are validated like any other write.
+func NewMergeService(repo domain.PatientRepository, merges domain.MergeRepository, validator *fhirvalidation.Validator, ids domain.IDGenerator, rules domain.MergeRules) MergeServiceInterface {
+	return &mergeService{
+		patients: newPatientService(repo, validator, ids),
+		merges:   merges,
+		rules:    rules,
+	}
+}
+
+// MergePatients merges the source patient into the target. The target takes the source's
+// identifiers, names, telecom and addresses by the merge rules and a replaces link; the
+// source is marked inactive with a replaced-by link and loses the identifiers the target
+// took. References to the source in other resources are repointed to the target. A preview
+// returns the merged patients without storing them.
+func (s *mergeService) MergePatients(ctx context.Context, request domain.MergeRequest) (*domain.MergeResult, error) {
+	if request.SourceID == request.TargetID {
+		return nil, fmt.Errorf("%w: a patient cannot be merged into itself", domain.ErrValidation)
+	}
+	source, sourceFHIR, err := s.load(ctx, request.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, targetFHIR, err := s.load(ctx, request.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if replacement := replacedBy(sourceFHIR); replacement != "" {
+		return nil, fmt.Errorf("%w: Patient/%s has already been merged into %s", domain.ErrConflict, source.LogicalID, replacement)
+	}
+	if replacement := replacedBy(targetFHIR); replacement != "" {
+		return nil, fmt.Errorf("%w: Patient/%s has been merged into %s and cannot be a merge target", domain.ErrConflict, target.LogicalID, replacement)
+	}
+
+	targetFHIR.Identifier = mergeValues(s.rules.Identifier, targetFHIR.Identifier, sourceFHIR.Identifier, identifierKey)
+	targetFHIR.Name = mergeValues(s.rules.Name, targetFHIR.Name, sourceFHIR.Name, elementKey[fhir.HumanName])
+	targetFHIR.Telecom = mergeValues(s.rules.Telecom, targetFHIR.Telecom, sourceFHIR.Telecom, telecomKey)
+	targetFHIR.Address = mergeValues(s.rules.Address, targetFHIR.Address, sourceFHIR.Address, elementKey[fhir.Address])
+	targetFHIR.Link = append(targetFHIR.Link, patientLink(source.LogicalID, fhir.LinkTypeReplaces))
+
+	// An identifier stays with one patient, so those the target took leave the source
+	sourceFHIR.Identifier = withoutValues(sourceFHIR.Identifier, targetFHIR.Identifier, identifierKey)
+	inactive := false
+	sourceFHIR.Active = &inactive
+	sourceFHIR.Link = append(sourceFHIR.Link, patientLink(target.LogicalID, fhir.LinkTypeReplacedBy))
+
+	mergedSource, err := s.revise(ctx, source, sourceFHIR)
+	if err != nil {
+		return nil, err
+	}
+	mergedTarget, err := s.revise(ctx, target, targetFHIR)
+	if err != nil {
+		return nil, err
+	}
+	if request.Preview {
+		return &domain.MergeResult{Source: mergedSource, Target: mergedTarget}, nil
+	}
+
+	merge := &domain.PatientMerge{SourceData: source.FHIRData, TargetData: target.FHIRData}
+	if err := s.merges.Merge(ctx, merge, mergedSource, mergedTarget, source.VersionID, target.VersionID); err != nil {
+		return nil, err
+	}
+	references, err := repointedReferences(merge)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithContext(ctx).Infof("Merged patient %s into %s, repointing %d references", source.LogicalID, target.LogicalID, len(references))
+	return &domain.MergeResult{Source: mergedSource, Target: mergedTarget, References: len(references)}, nil
+}
+
+// UnmergePatient reverses the merge of a source patient. The source is restored as it was
+// before the merge; the target gets back its identifiers, names, telecom and addresses from
+// before the merge and loses its replaces link, keeping its other changes. References the
+// merge repointed and that still point to the target are pointed back to the source.
+func (s *mergeService) UnmergePatient(ctx context.Context, sourceID string) (*domain.MergeResult, error) {
+	source, err := s.patients.repo.GetByLogicalID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := s.merges.GetActiveMerge(ctx, source.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: Patient/%s has not been merged", domain.ErrConflict, sourceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.patients.repo.GetByID(ctx, merge.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	targetFHIR, err := s.patients.ConvertToFHIR(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceFHIR, originalTarget fhir.Patient
+	if err := json.Unmarshal(merge.SourceData, &sourceFHIR); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merged source: %w", err)
+	}
+	if err := json.Unmarshal(merge.TargetData, &originalTarget); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merged target: %w", err)
+	}
+	targetFHIR.Identifier = originalTarget.Identifier
+	targetFHIR.Name = originalTarget.Name
+	targetFHIR.Telecom = originalTarget.Telecom
+	targetFHIR.Address = originalTarget.Address
+	links := targetFHIR.Link[:0]
+	for _, link := range targetFHIR.Link {
+		if link.Type != fhir.LinkTypeReplaces || link.Other.Reference == nil || *link.Other.Reference != "Patient/"+source.LogicalID {
+			links = append(links, link)
+		}
+	}
+	targetFHIR.Link = links
+
+	restoredSource, err := s.revise(ctx, source, &sourceFHIR)
+	if err != nil {
+		return nil, err
+	}
+	restoredTarget, err := s.revise(ctx, target, targetFHIR)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.merges.Unmerge(ctx, merge, restoredSource, restoredTarget, source.VersionID, target.VersionID); err != nil {
+		return nil, err
+	}
+	references, err := repointedReferences(merge)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithContext(ctx).Infof("Unmerged patient %s from %s", source.LogicalID, target.LogicalID)
+	return &domain.MergeResult{Source: restoredSource, Target: restoredTarget, References: len(references)}, nil
+}
+
+// load retrieves a patient along with its FHIR representation
+func (s *mergeService) load(ctx context.Context, id string) (*domain.Patient, *fhir.Patient, error) {
+	patient, err := s.patients.repo.GetByLogicalID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	fhirPatient, err := s.patients.ConvertToFHIR(ctx, patient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return patient, fhirPatient, nil
+}
+
+// revise validates the new content of a stored patient and returns it as the patient's next version
+func (s *mergeService) revise(ctx context.Context, existing *domain.Patient, fhirPatient *fhir.Patient) (*domain.Patient, error) {
+	if err := s.patients.validate(ctx, fhirPatient); err != nil {
+		return nil, err
+	}
+	assignIdentity(fhirPatient, existing.LogicalID)
+	revised, err := s.patients.ConvertFromFHIR(ctx, fhirPatient)
+	if err != nil {
+		return nil, err
+	}
+	revised.ID = existing.ID
+	revised.CreatedAt = existing.CreatedAt
+	return revised, nil
+}
+
+// replacedBy returns the patient a merged patient was replaced by, or "" when it was not merged
+func replacedBy(fhirPatient *fhir.Patient) string {
+	for _, link := range fhirPatient.Link {
+		if link.Type == fhir.LinkTypeReplacedBy && link.Other.Reference != nil {
+			return *link.Other.Reference
+		}
+	}
+	return ""
+}
+
+// patientLink creates a Patient.link to another patient
+func patientLink(logicalID string, linkType fhir.LinkType) fhir.PatientLink {
+	reference := "Patient/" + logicalID
+	return fhir.PatientLink{Other: fhir.Reference{Reference: &reference}, Type: linkType}
+}
+
+// repointedReferences returns the references a merge repointed
+func repointedReferences(merge *domain.PatientMerge) ([]domain.RepointedReference, error) {
+	var references []domain.RepointedReference
+	if len(merge.References) == 0 {
+		return references, nil
+	}
+	if err := json.Unmarshal(merge.References, &references); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal repointed references: %w", err)
+	}
+	return references, nil
+}
+
+// mergeValues combines the values of an element of the target and source patients by a merge rule
+func mergeValues[T any](rule domain.MergeRule, target, source []T, key func(T) string) []T {
+	switch rule {
+	case domain.MergeRuleTarget:
+		return target
+	case domain.MergeRuleSource:
+		if len(source) > 0 {
+			return source
+		}
+		return target
+	default:
+		return append(append([]T{}, target...), withoutValues(source, target, key)...)
+	}
+}
+
+// withoutValues returns the values that have no equal in others
+func withoutValues[T any](values, others []T, key func(T) string) []T {
+	present := make(map[string]bool, len(others))
+	for _, other := range others {
+		present[key(other)] = true
+	}
+	var kept []T
+	for _, value := range values {
+		if !present[key(value)] {
+			kept = append(kept, value)
+			present[key(value)] = true
+		}
+	}
+	return kept
+}
+
+// identifierKey identifies an identifier by its system and value
+func identifierKey(identifier fhir.Identifier) string {
+	return stringValue(identifier.System) + "|" + stringValue(identifier.Value)
+}
+
+// telecomKey identifies a contact point by its system and value
+func telecomKey(telecom fhir.ContactPoint) string {
+	system := ""
+	if telecom.System != nil {
+		system = telecom.System.Code()
+	}
+	return system + "|" + stringValue(telecom.Value)
+}
+
+// elementKey identifies an element by its full JSON form
+func elementKey[T any](element T) string {
+	data, _ := json.Marshal(element)
+	return string(data)
+}
+
+// stringValue dereferences an optional string
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirvalidation"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// MergeServiceTestSuite defines the test suite
+type MergeServiceTestSuite struct {
+	suite.Suite
+	ctrl         *gomock.Controller
+	mockPatients *mocks.MockPatientRepository
+	mockMerges   *mocks.MockMergeRepository
+	mockIDs      *mocks.MockIDGenerator
+	service      MergeServiceInterface
+}
+
+// SetupTest initializes the test suite before each test
+func (suite *MergeServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockPatients = mocks.NewMockPatientRepository(suite.ctrl)
+	suite.mockMerges = mocks.NewMockMergeRepository(suite.ctrl)
+	suite.mockIDs = mocks.NewMockIDGenerator(suite.ctrl)
+	rules := domain.MergeRules{Identifier: domain.MergeRuleUnion, Name: domain.MergeRuleUnion, Telecom: domain.MergeRuleUnion, Address: domain.MergeRuleTarget}
+	suite.service = NewMergeService(suite.mockPatients, suite.mockMerges, fhirvalidation.NewValidator(), suite.mockIDs, rules)
+}
+
+// TearDownTest cleans up after each test
+func (suite *MergeServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestMergeServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(MergeServiceTestSuite))
+}
+
+// mergePatients returns a fresh source and target patient for each test
+func mergePatients() (*domain.Patient, *domain.Patient) {
+	source := &domain.Patient{
+		ID:        1,
+		LogicalID: "src",
+		VersionID: 2,
+		FHIRData: []byte(`{"resourceType":"Patient","id":"src","active":true,
+			"identifier":[{"system":"urn:mrn","value":"A1"},{"system":"urn:ssn","value":"111"}],
+			"name":[{"family":"Doe","given":["Jon"]}],
+			"telecom":[{"system":"phone","value":"555-0100"}],
+			"address":[{"city":"Springfield"}]}`),
+	}
+	target := &domain.Patient{
+		ID:        2,
+		LogicalID: "tgt",
+		VersionID: 5,
+		FHIRData: []byte(`{"resourceType":"Patient","id":"tgt","active":true,
+			"identifier":[{"system":"urn:mrn","value":"B2"},{"system":"urn:ssn","value":"111"}],
+			"name":[{"family":"Doe","given":["John"]}],
+			"telecom":[{"system":"phone","value":"555-0100"}],
+			"address":[{"city":"Shelbyville"}]}`),
+	}
+	return source, target
+}
+
+// decode unmarshals the FHIR data of a patient
+func (suite *MergeServiceTestSuite) decode(patient *domain.Patient) fhir.Patient {
+	var fhirPatient fhir.Patient
+	suite.Require().NoError(json.Unmarshal(patient.FHIRData, &fhirPatient))
+	return fhirPatient
+}
+
+// TestMergePatients_Success tests that the target takes the source's values and both patients are linked
+func (suite *MergeServiceTestSuite) TestMergePatients_Success() {
+	// Arrange
+	source, target := mergePatients()
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "src").Return(source, nil)
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "tgt").Return(target, nil)
+	suite.mockMerges.EXPECT().
+		Merge(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 2, 5).
+		DoAndReturn(func(ctx context.Context, merge *domain.PatientMerge, mergedSource, mergedTarget *domain.Patient, sourceVersion, targetVersion int) error {
+			assert.JSONEq(suite.T(), string(source.FHIRData), string(merge.SourceData))
+			assert.JSONEq(suite.T(), string(target.FHIRData), string(merge.TargetData))
+			merge.References = []byte(`[{"resourceId":7,"path":"/subject/reference","reference":"Patient/src"}]`)
+			return nil
+		})
+
+	// Act
+	result, err := suite.service.MergePatients(context.Background(), domain.MergeRequest{SourceID: "src", TargetID: "tgt"})
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, result.References)
+	assert.Equal(suite.T(), uint(2), result.Target.ID)
+
+	merged := suite.decode(result.Target)
+	suite.Require().Len(merged.Identifier, 3)
+	assert.Equal(suite.T(), "A1", *merged.Identifier[2].Value)
+	assert.Len(suite.T(), merged.Name, 2)
+	assert.Len(suite.T(), merged.Telecom, 1)
+	suite.Require().Len(merged.Address, 1)
+	assert.Equal(suite.T(), "Shelbyville", *merged.Address[0].City)
+	suite.Require().Len(merged.Link, 1)
+	assert.Equal(suite.T(), fhir.LinkTypeReplaces, merged.Link[0].Type)
+	assert.Equal(suite.T(), "Patient/src", *merged.Link[0].Other.Reference)
+
+	replaced := suite.decode(result.Source)
+	assert.False(suite.T(), *replaced.Active)
+	assert.Empty(suite.T(), replaced.Identifier)
+	suite.Require().Len(replaced.Link, 1)
+	assert.Equal(suite.T(), fhir.LinkTypeReplacedBy, replaced.Link[0].Type)
+	assert.Equal(suite.T(), "Patient/tgt", *replaced.Link[0].Other.Reference)
+}
+
+// TestMergePatients_Preview tests that a preview stores nothing
+func (suite *MergeServiceTestSuite) TestMergePatients_Preview() {
+	// Arrange
+	source, target := mergePatients()
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "src").Return(source, nil)
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "tgt").Return(target, nil)
+
+	// Act
+	result, err := suite.service.MergePatients(context.Background(), domain.MergeRequest{SourceID: "src", TargetID: "tgt", Preview: true})
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Zero(suite.T(), result.References)
+	assert.Len(suite.T(), suite.decode(result.Target).Link, 1)
+}
+
+// TestMergePatients_Self tests that a patient cannot be merged into itself
+func (suite *MergeServiceTestSuite) TestMergePatients_Self() {
+	// Act
+	_, err := suite.service.MergePatients(context.Background(), domain.MergeRequest{SourceID: "src", TargetID: "src"})
+
+	// Assert
+	assert.True(suite.T(), errors.Is(err, domain.ErrValidation))
+}
+
+// TestMergePatients_AlreadyMerged tests that a replaced patient cannot be merged again
+func (suite *MergeServiceTestSuite) TestMergePatients_AlreadyMerged() {
+	// Arrange
+	source, target := mergePatients()
+	source.FHIRData = []byte(`{"resourceType":"Patient","id":"src","link":[{"other":{"reference":"Patient/other"},"type":"replaced-by"}]}`)
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "src").Return(source, nil)
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "tgt").Return(target, nil)
+
+	// Act
+	_, err := suite.service.MergePatients(context.Background(), domain.MergeRequest{SourceID: "src", TargetID: "tgt"})
+
+	// Assert
+	assert.True(suite.T(), errors.Is(err, domain.ErrConflict))
+}
+
+// TestUnmergePatient_Success tests that the source is restored and the target gets its values back
+func (suite *MergeServiceTestSuite) TestUnmergePatient_Success() {
+	// Arrange
+	original, originalTarget := mergePatients()
+	source := &domain.Patient{
+		ID:        1,
+		LogicalID: "src",
+		VersionID: 3,
+		FHIRData:  []byte(`{"resourceType":"Patient","id":"src","active":false,"link":[{"other":{"reference":"Patient/tgt"},"type":"replaced-by"}]}`),
+	}
+	target := &domain.Patient{
+		ID:        2,
+		LogicalID: "tgt",
+		VersionID: 6,
+		FHIRData: []byte(`{"resourceType":"Patient","id":"tgt","gender":"male",
+			"identifier":[{"system":"urn:mrn","value":"B2"},{"system":"urn:ssn","value":"111"},{"system":"urn:mrn","value":"A1"}],
+			"link":[{"other":{"reference":"Patient/src"},"type":"replaces"}]}`),
+	}
+	merge := &domain.PatientMerge{
+		ID:         4,
+		SourceID:   1,
+		TargetID:   2,
+		SourceData: original.FHIRData,
+		TargetData: originalTarget.FHIRData,
+		References: []byte(`[{"resourceId":7,"path":"/subject/reference","reference":"Patient/src"}]`),
+	}
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "src").Return(source, nil)
+	suite.mockMerges.EXPECT().GetActiveMerge(gomock.Any(), uint(1)).Return(merge, nil)
+	suite.mockPatients.EXPECT().GetByID(gomock.Any(), uint(2)).Return(target, nil)
+	suite.mockMerges.EXPECT().Unmerge(gomock.Any(), merge, gomock.Any(), gomock.Any(), 3, 6).Return(nil)
+
+	// Act
+	result, err := suite.service.UnmergePatient(context.Background(), "src")
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, result.References)
+
+	restored := suite.decode(result.Source)
+	assert.True(suite.T(), *restored.Active)
+	assert.Len(suite.T(), restored.Identifier, 2)
+	assert.Empty(suite.T(), restored.Link)
+
+	unmerged := suite.decode(result.Target)
+	assert.Len(suite.T(), unmerged.Identifier, 2)
+	assert.Empty(suite.T(), unmerged.Link)
+	assert.Equal(suite.T(), fhir.AdministrativeGenderMale, *unmerged.Gender)
+}
+
+// TestUnmergePatient_NotMerged tests that a patient without an active merge cannot be unmerged
+func (suite *MergeServiceTestSuite) TestUnmergePatient_NotMerged() {
+	// Arrange
+	source, _ := mergePatients()
+	suite.mockPatients.EXPECT().GetByLogicalID(gomock.Any(), "src").Return(source, nil)
+	suite.mockMerges.EXPECT().GetActiveMerge(gomock.Any(), uint(1)).Return(nil, domain.ErrNotFound)
+
+	// Act
+	_, err := suite.service.UnmergePatient(context.Background(), "src")
+
+	// Assert
+	assert.True(suite.T(), errors.Is(err, domain.ErrConflict))
+}
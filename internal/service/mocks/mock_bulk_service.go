@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\bulk_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\bulk_service.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\mocks\mock_bulk_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBulkServiceInterface is a mock of BulkServiceInterface interface.
+type MockBulkServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBulkServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockBulkServiceInterfaceMockRecorder is the mock recorder for MockBulkServiceInterface.
+type MockBulkServiceInterfaceMockRecorder struct {
+	mock *MockBulkServiceInterface
+}
+
+// NewMockBulkServiceInterface creates a new mock instance.
+func NewMockBulkServiceInterface(ctrl *gomock.Controller) *MockBulkServiceInterface {
+	mock := &MockBulkServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockBulkServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBulkServiceInterface) EXPECT() *MockBulkServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteJob mocks base method.
+func (m *MockBulkServiceInterface) DeleteJob(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJob indicates an expected call of DeleteJob.
+func (mr *MockBulkServiceInterfaceMockRecorder) DeleteJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJob", reflect.TypeOf((*MockBulkServiceInterface)(nil).DeleteJob), ctx, id)
+}
+
+// FailInterruptedJobs mocks base method.
+func (m *MockBulkServiceInterface) FailInterruptedJobs(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailInterruptedJobs", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailInterruptedJobs indicates an expected call of FailInterruptedJobs.
+func (mr *MockBulkServiceInterfaceMockRecorder) FailInterruptedJobs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailInterruptedJobs", reflect.TypeOf((*MockBulkServiceInterface)(nil).FailInterruptedJobs), ctx)
+}
+
+// GetJob mocks base method.
+func (m *MockBulkServiceInterface) GetJob(ctx context.Context, id string) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockBulkServiceInterfaceMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockBulkServiceInterface)(nil).GetJob), ctx, id)
+}
+
+// JobFile mocks base method.
+func (m *MockBulkServiceInterface) JobFile(ctx context.Context, id, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobFile", ctx, id, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JobFile indicates an expected call of JobFile.
+func (mr *MockBulkServiceInterfaceMockRecorder) JobFile(ctx, id, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobFile", reflect.TypeOf((*MockBulkServiceInterface)(nil).JobFile), ctx, id, name)
+}
+
+// StartExport mocks base method.
+func (m *MockBulkServiceInterface) StartExport(ctx context.Context, request domain.ExportRequest) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartExport", ctx, request)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartExport indicates an expected call of StartExport.
+func (mr *MockBulkServiceInterfaceMockRecorder) StartExport(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartExport", reflect.TypeOf((*MockBulkServiceInterface)(nil).StartExport), ctx, request)
+}
+
+// StartImport mocks base method.
+func (m *MockBulkServiceInterface) StartImport(ctx context.Context, request domain.ImportRequest) (*domain.BulkJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImport", ctx, request)
+	ret0, _ := ret[0].(*domain.BulkJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartImport indicates an expected call of StartImport.
+func (mr *MockBulkServiceInterfaceMockRecorder) StartImport(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImport", reflect.TypeOf((*MockBulkServiceInterface)(nil).StartImport), ctx, request)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\bundle_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\bundle_service.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\mocks\mock_bundle_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBundleServiceInterface is a mock of BundleServiceInterface interface.
+type MockBundleServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBundleServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockBundleServiceInterfaceMockRecorder is the mock recorder for MockBundleServiceInterface.
+type MockBundleServiceInterfaceMockRecorder struct {
+	mock *MockBundleServiceInterface
+}
+
+// NewMockBundleServiceInterface creates a new mock instance.
+func NewMockBundleServiceInterface(ctrl *gomock.Controller) *MockBundleServiceInterface {
+	mock := &MockBundleServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockBundleServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBundleServiceInterface) EXPECT() *MockBundleServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ProcessBatch mocks base method.
+func (m *MockBundleServiceInterface) ProcessBatch(ctx context.Context, requests []domain.BundleEntryRequest) []domain.BundleEntryResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBatch", ctx, requests)
+	ret0, _ := ret[0].([]domain.BundleEntryResult)
+	return ret0
+}
+
+// ProcessBatch indicates an expected call of ProcessBatch.
+func (mr *MockBundleServiceInterfaceMockRecorder) ProcessBatch(ctx, requests any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBatch", reflect.TypeOf((*MockBundleServiceInterface)(nil).ProcessBatch), ctx, requests)
+}
+
+// ProcessTransaction mocks base method.
+func (m *MockBundleServiceInterface) ProcessTransaction(ctx context.Context, requests []domain.BundleEntryRequest) ([]domain.BundleEntryResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessTransaction", ctx, requests)
+	ret0, _ := ret[0].([]domain.BundleEntryResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessTransaction indicates an expected call of ProcessTransaction.
+func (mr *MockBundleServiceInterfaceMockRecorder) ProcessTransaction(ctx, requests any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessTransaction", reflect.TypeOf((*MockBundleServiceInterface)(nil).ProcessTransaction), ctx, requests)
+}
@@ -0,0 +1,75 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\compartment_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\compartment_service.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\mocks\mock_compartment_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCompartmentServiceInterface is a mock of CompartmentServiceInterface interface.
+type MockCompartmentServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompartmentServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCompartmentServiceInterfaceMockRecorder is the mock recorder for MockCompartmentServiceInterface.
+type MockCompartmentServiceInterfaceMockRecorder struct {
+	mock *MockCompartmentServiceInterface
+}
+
+// NewMockCompartmentServiceInterface creates a new mock instance.
+func NewMockCompartmentServiceInterface(ctrl *gomock.Controller) *MockCompartmentServiceInterface {
+	mock := &MockCompartmentServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockCompartmentServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompartmentServiceInterface) EXPECT() *MockCompartmentServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// PatientEverything mocks base method.
+func (m *MockCompartmentServiceInterface) PatientEverything(ctx context.Context, query domain.CompartmentQuery) ([]domain.CompartmentEntry, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatientEverything", ctx, query)
+	ret0, _ := ret[0].([]domain.CompartmentEntry)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PatientEverything indicates an expected call of PatientEverything.
+func (mr *MockCompartmentServiceInterfaceMockRecorder) PatientEverything(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatientEverything", reflect.TypeOf((*MockCompartmentServiceInterface)(nil).PatientEverything), ctx, query)
+}
+
+// PatientIncludes mocks base method.
+func (m *MockCompartmentServiceInterface) PatientIncludes(ctx context.Context, patients []*domain.Patient, query *fhirsearch.Query) ([]domain.CompartmentEntry, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatientIncludes", ctx, patients, query)
+	ret0, _ := ret[0].([]domain.CompartmentEntry)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PatientIncludes indicates an expected call of PatientIncludes.
+func (mr *MockCompartmentServiceInterfaceMockRecorder) PatientIncludes(ctx, patients, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatientIncludes", reflect.TypeOf((*MockCompartmentServiceInterface)(nil).PatientIncludes), ctx, patients, query)
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\merge_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\merge_service.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\mocks\mock_merge_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMergeServiceInterface is a mock of MergeServiceInterface interface.
+type MockMergeServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMergeServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMergeServiceInterfaceMockRecorder is the mock recorder for MockMergeServiceInterface.
+type MockMergeServiceInterfaceMockRecorder struct {
+	mock *MockMergeServiceInterface
+}
+
+// NewMockMergeServiceInterface creates a new mock instance.
+func NewMockMergeServiceInterface(ctrl *gomock.Controller) *MockMergeServiceInterface {
+	mock := &MockMergeServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockMergeServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMergeServiceInterface) EXPECT() *MockMergeServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// MergePatients mocks base method.
+func (m *MockMergeServiceInterface) MergePatients(ctx context.Context, request domain.MergeRequest) (*domain.MergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergePatients", ctx, request)
+	ret0, _ := ret[0].(*domain.MergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergePatients indicates an expected call of MergePatients.
+func (mr *MockMergeServiceInterfaceMockRecorder) MergePatients(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePatients", reflect.TypeOf((*MockMergeServiceInterface)(nil).MergePatients), ctx, request)
+}
+
+// UnmergePatient mocks base method.
+func (m *MockMergeServiceInterface) UnmergePatient(ctx context.Context, sourceID string) (*domain.MergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnmergePatient", ctx, sourceID)
+	ret0, _ := ret[0].(*domain.MergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnmergePatient indicates an expected call of UnmergePatient.
+func (mr *MockMergeServiceInterfaceMockRecorder) UnmergePatient(ctx, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmergePatient", reflect.TypeOf((*MockMergeServiceInterface)(nil).UnmergePatient), ctx, sourceID)
+}
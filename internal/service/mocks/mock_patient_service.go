@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
 	reflect "reflect"
 
 	fhir "github.com/samply/golang-fhir-models/fhir-models/fhir"
@@ -42,6 +43,52 @@ func (m *MockPatientServiceInterface) EXPECT() *MockPatientServiceInterfaceMockR
 	return m.recorder
 }
 
+// ConditionalCreatePatient mocks base method.
+func (m *MockPatientServiceInterface) ConditionalCreatePatient(ctx context.Context, fhirPatient *fhir.Patient, criteria *fhirsearch.Query) (*domain.Patient, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalCreatePatient", ctx, fhirPatient, criteria)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConditionalCreatePatient indicates an expected call of ConditionalCreatePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) ConditionalCreatePatient(ctx, fhirPatient, criteria any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalCreatePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConditionalCreatePatient), ctx, fhirPatient, criteria)
+}
+
+// ConditionalDeletePatient mocks base method.
+func (m *MockPatientServiceInterface) ConditionalDeletePatient(ctx context.Context, criteria *fhirsearch.Query) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalDeletePatient", ctx, criteria)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConditionalDeletePatient indicates an expected call of ConditionalDeletePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) ConditionalDeletePatient(ctx, criteria any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalDeletePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConditionalDeletePatient), ctx, criteria)
+}
+
+// ConditionalUpdatePatient mocks base method.
+func (m *MockPatientServiceInterface) ConditionalUpdatePatient(ctx context.Context, criteria *fhirsearch.Query, fhirPatient *fhir.Patient) (*domain.Patient, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalUpdatePatient", ctx, criteria, fhirPatient)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConditionalUpdatePatient indicates an expected call of ConditionalUpdatePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) ConditionalUpdatePatient(ctx, criteria, fhirPatient any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalUpdatePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConditionalUpdatePatient), ctx, criteria, fhirPatient)
+}
+
 // ConvertFromFHIR mocks base method.
 func (m *MockPatientServiceInterface) ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
@@ -57,6 +104,21 @@ func (mr *MockPatientServiceInterfaceMockRecorder) ConvertFromFHIR(ctx, fhirPati
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertFromFHIR", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConvertFromFHIR), ctx, fhirPatient)
 }
 
+// ConvertHistoryToFHIR mocks base method.
+func (m *MockPatientServiceInterface) ConvertHistoryToFHIR(ctx context.Context, entry *domain.PatientHistory) (*fhir.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertHistoryToFHIR", ctx, entry)
+	ret0, _ := ret[0].(*fhir.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertHistoryToFHIR indicates an expected call of ConvertHistoryToFHIR.
+func (mr *MockPatientServiceInterfaceMockRecorder) ConvertHistoryToFHIR(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertHistoryToFHIR", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConvertHistoryToFHIR), ctx, entry)
+}
+
 // ConvertToFHIR mocks base method.
 func (m *MockPatientServiceInterface) ConvertToFHIR(ctx context.Context, patient *domain.Patient) (*fhir.Patient, error) {
 	m.ctrl.T.Helper()
@@ -72,6 +134,21 @@ func (mr *MockPatientServiceInterfaceMockRecorder) ConvertToFHIR(ctx, patient an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertToFHIR", reflect.TypeOf((*MockPatientServiceInterface)(nil).ConvertToFHIR), ctx, patient)
 }
 
+// CountPatients mocks base method.
+func (m *MockPatientServiceInterface) CountPatients(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPatients", ctx, query)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPatients indicates an expected call of CountPatients.
+func (mr *MockPatientServiceInterfaceMockRecorder) CountPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPatients", reflect.TypeOf((*MockPatientServiceInterface)(nil).CountPatients), ctx, query)
+}
+
 // CreatePatient mocks base method.
 func (m *MockPatientServiceInterface) CreatePatient(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
@@ -88,21 +165,51 @@ func (mr *MockPatientServiceInterfaceMockRecorder) CreatePatient(ctx, fhirPatien
 }
 
 // DeletePatient mocks base method.
-func (m *MockPatientServiceInterface) DeletePatient(ctx context.Context, id uint) error {
+func (m *MockPatientServiceInterface) DeletePatient(ctx context.Context, id string, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePatient", ctx, id)
+	ret := m.ctrl.Call(m, "DeletePatient", ctx, id, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePatient indicates an expected call of DeletePatient.
-func (mr *MockPatientServiceInterfaceMockRecorder) DeletePatient(ctx, id any) *gomock.Call {
+func (mr *MockPatientServiceInterfaceMockRecorder) DeletePatient(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).DeletePatient), ctx, id, expectedVersion)
+}
+
+// ExpungePatient mocks base method.
+func (m *MockPatientServiceInterface) ExpungePatient(ctx context.Context, id string, history bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpungePatient", ctx, id, history)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpungePatient indicates an expected call of ExpungePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) ExpungePatient(ctx, id, history any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpungePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).ExpungePatient), ctx, id, history)
+}
+
+// FHIRPathPatchPatient mocks base method.
+func (m *MockPatientServiceInterface) FHIRPathPatchPatient(ctx context.Context, id string, parameters []byte, expectedVersion int) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FHIRPathPatchPatient", ctx, id, parameters, expectedVersion)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FHIRPathPatchPatient indicates an expected call of FHIRPathPatchPatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) FHIRPathPatchPatient(ctx, id, parameters, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).DeletePatient), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FHIRPathPatchPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).FHIRPathPatchPatient), ctx, id, parameters, expectedVersion)
 }
 
 // GetPatient mocks base method.
-func (m *MockPatientServiceInterface) GetPatient(ctx context.Context, id uint) (*domain.Patient, error) {
+func (m *MockPatientServiceInterface) GetPatient(ctx context.Context, id string) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPatient", ctx, id)
 	ret0, _ := ret[0].(*domain.Patient)
@@ -116,6 +223,37 @@ func (mr *MockPatientServiceInterfaceMockRecorder) GetPatient(ctx, id any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).GetPatient), ctx, id)
 }
 
+// GetPatientHistory mocks base method.
+func (m *MockPatientServiceInterface) GetPatientHistory(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatientHistory", ctx, query)
+	ret0, _ := ret[0].([]*domain.PatientHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPatientHistory indicates an expected call of GetPatientHistory.
+func (mr *MockPatientServiceInterfaceMockRecorder) GetPatientHistory(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientHistory", reflect.TypeOf((*MockPatientServiceInterface)(nil).GetPatientHistory), ctx, query)
+}
+
+// GetPatientVersion mocks base method.
+func (m *MockPatientServiceInterface) GetPatientVersion(ctx context.Context, id string, versionID int) (*domain.PatientHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatientVersion", ctx, id, versionID)
+	ret0, _ := ret[0].(*domain.PatientHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatientVersion indicates an expected call of GetPatientVersion.
+func (mr *MockPatientServiceInterfaceMockRecorder) GetPatientVersion(ctx, id, versionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientVersion", reflect.TypeOf((*MockPatientServiceInterface)(nil).GetPatientVersion), ctx, id, versionID)
+}
+
 // GetPatients mocks base method.
 func (m *MockPatientServiceInterface) GetPatients(ctx context.Context, limit, offset int) ([]*domain.Patient, int64, error) {
 	m.ctrl.T.Helper()
@@ -132,32 +270,122 @@ func (mr *MockPatientServiceInterfaceMockRecorder) GetPatients(ctx, limit, offse
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatients", reflect.TypeOf((*MockPatientServiceInterface)(nil).GetPatients), ctx, limit, offset)
 }
 
+// JSONPatchPatient mocks base method.
+func (m *MockPatientServiceInterface) JSONPatchPatient(ctx context.Context, id string, patch []byte, expectedVersion int) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JSONPatchPatient", ctx, id, patch, expectedVersion)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JSONPatchPatient indicates an expected call of JSONPatchPatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) JSONPatchPatient(ctx, id, patch, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JSONPatchPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).JSONPatchPatient), ctx, id, patch, expectedVersion)
+}
+
+// MatchPatient mocks base method.
+func (m *MockPatientServiceInterface) MatchPatient(ctx context.Context, fhirPatient *fhir.Patient, onlyCertainMatches bool, count int) ([]domain.PatientMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchPatient", ctx, fhirPatient, onlyCertainMatches, count)
+	ret0, _ := ret[0].([]domain.PatientMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchPatient indicates an expected call of MatchPatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) MatchPatient(ctx, fhirPatient, onlyCertainMatches, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).MatchPatient), ctx, fhirPatient, onlyCertainMatches, count)
+}
+
 // PatchPatient mocks base method.
-func (m *MockPatientServiceInterface) PatchPatient(ctx context.Context, id uint, updates map[string]any) (*domain.Patient, error) {
+func (m *MockPatientServiceInterface) PatchPatient(ctx context.Context, id string, updates map[string]any, expectedVersion int) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchPatient", ctx, id, updates)
+	ret := m.ctrl.Call(m, "PatchPatient", ctx, id, updates, expectedVersion)
 	ret0, _ := ret[0].(*domain.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchPatient indicates an expected call of PatchPatient.
-func (mr *MockPatientServiceInterfaceMockRecorder) PatchPatient(ctx, id, updates any) *gomock.Call {
+func (mr *MockPatientServiceInterfaceMockRecorder) PatchPatient(ctx, id, updates, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).PatchPatient), ctx, id, updates, expectedVersion)
+}
+
+// PreparePatient mocks base method.
+func (m *MockPatientServiceInterface) PreparePatient(ctx context.Context, resource []byte) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreparePatient", ctx, resource)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreparePatient indicates an expected call of PreparePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) PreparePatient(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreparePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).PreparePatient), ctx, resource)
+}
+
+// SearchPatients mocks base method.
+func (m *MockPatientServiceInterface) SearchPatients(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPatients", ctx, query)
+	ret0, _ := ret[0].(*domain.PatientPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPatients indicates an expected call of SearchPatients.
+func (mr *MockPatientServiceInterfaceMockRecorder) SearchPatients(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatients", reflect.TypeOf((*MockPatientServiceInterface)(nil).SearchPatients), ctx, query)
+}
+
+// UndeletePatient mocks base method.
+func (m *MockPatientServiceInterface) UndeletePatient(ctx context.Context, id string) (*domain.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndeletePatient", ctx, id)
+	ret0, _ := ret[0].(*domain.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UndeletePatient indicates an expected call of UndeletePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) UndeletePatient(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).PatchPatient), ctx, id, updates)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndeletePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).UndeletePatient), ctx, id)
 }
 
 // UpdatePatient mocks base method.
-func (m *MockPatientServiceInterface) UpdatePatient(ctx context.Context, id uint, fhirPatient *fhir.Patient) (*domain.Patient, error) {
+func (m *MockPatientServiceInterface) UpdatePatient(ctx context.Context, id string, fhirPatient *fhir.Patient, expectedVersion int) (*domain.Patient, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdatePatient", ctx, id, fhirPatient)
+	ret := m.ctrl.Call(m, "UpdatePatient", ctx, id, fhirPatient, expectedVersion)
 	ret0, _ := ret[0].(*domain.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdatePatient indicates an expected call of UpdatePatient.
-func (mr *MockPatientServiceInterfaceMockRecorder) UpdatePatient(ctx, id, fhirPatient any) *gomock.Call {
+func (mr *MockPatientServiceInterfaceMockRecorder) UpdatePatient(ctx, id, fhirPatient, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).UpdatePatient), ctx, id, fhirPatient, expectedVersion)
+}
+
+// ValidatePatient mocks base method.
+func (m *MockPatientServiceInterface) ValidatePatient(ctx context.Context, resource []byte, profiles []string) ([]fhir.OperationOutcomeIssue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidatePatient", ctx, resource, profiles)
+	ret0, _ := ret[0].([]fhir.OperationOutcomeIssue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidatePatient indicates an expected call of ValidatePatient.
+func (mr *MockPatientServiceInterfaceMockRecorder) ValidatePatient(ctx, resource, profiles any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).UpdatePatient), ctx, id, fhirPatient)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePatient", reflect.TypeOf((*MockPatientServiceInterface)(nil).ValidatePatient), ctx, resource, profiles)
 }
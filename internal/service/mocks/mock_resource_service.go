@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\resource_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\resource_service.go -destination=D:\Chinmay_Personal_Projects\Go_FHIR_Demo\internal\service\mocks\mock_resource_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-fhir-demo/internal/domain"
+	fhirsearch "go-fhir-demo/pkg/fhirsearch"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockResourceServiceInterface is a mock of ResourceServiceInterface interface.
+type MockResourceServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceServiceInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockResourceServiceInterfaceMockRecorder is the mock recorder for MockResourceServiceInterface.
+type MockResourceServiceInterfaceMockRecorder struct {
+	mock *MockResourceServiceInterface
+}
+
+// NewMockResourceServiceInterface creates a new mock instance.
+func NewMockResourceServiceInterface(ctrl *gomock.Controller) *MockResourceServiceInterface {
+	mock := &MockResourceServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockResourceServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceServiceInterface) EXPECT() *MockResourceServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConvertToFHIR mocks base method.
+func (m *MockResourceServiceInterface) ConvertToFHIR(ctx context.Context, resource *domain.Resource) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertToFHIR", ctx, resource)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertToFHIR indicates an expected call of ConvertToFHIR.
+func (mr *MockResourceServiceInterfaceMockRecorder) ConvertToFHIR(ctx, resource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertToFHIR", reflect.TypeOf((*MockResourceServiceInterface)(nil).ConvertToFHIR), ctx, resource)
+}
+
+// CreateResource mocks base method.
+func (m *MockResourceServiceInterface) CreateResource(ctx context.Context, resourceType string, data []byte) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResource", ctx, resourceType, data)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateResource indicates an expected call of CreateResource.
+func (mr *MockResourceServiceInterfaceMockRecorder) CreateResource(ctx, resourceType, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResource", reflect.TypeOf((*MockResourceServiceInterface)(nil).CreateResource), ctx, resourceType, data)
+}
+
+// DeleteResource mocks base method.
+func (m *MockResourceServiceInterface) DeleteResource(ctx context.Context, resourceType, id string, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteResource", ctx, resourceType, id, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteResource indicates an expected call of DeleteResource.
+func (mr *MockResourceServiceInterfaceMockRecorder) DeleteResource(ctx, resourceType, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResource", reflect.TypeOf((*MockResourceServiceInterface)(nil).DeleteResource), ctx, resourceType, id, expectedVersion)
+}
+
+// GetResource mocks base method.
+func (m *MockResourceServiceInterface) GetResource(ctx context.Context, resourceType, id string) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResource", ctx, resourceType, id)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResource indicates an expected call of GetResource.
+func (mr *MockResourceServiceInterfaceMockRecorder) GetResource(ctx, resourceType, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResource", reflect.TypeOf((*MockResourceServiceInterface)(nil).GetResource), ctx, resourceType, id)
+}
+
+// SearchResources mocks base method.
+func (m *MockResourceServiceInterface) SearchResources(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchResources", ctx, resourceType, query)
+	ret0, _ := ret[0].([]*domain.Resource)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchResources indicates an expected call of SearchResources.
+func (mr *MockResourceServiceInterfaceMockRecorder) SearchResources(ctx, resourceType, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchResources", reflect.TypeOf((*MockResourceServiceInterface)(nil).SearchResources), ctx, resourceType, query)
+}
+
+// UpdateResource mocks base method.
+func (m *MockResourceServiceInterface) UpdateResource(ctx context.Context, resourceType, id string, data []byte, expectedVersion int) (*domain.Resource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateResource", ctx, resourceType, id, data, expectedVersion)
+	ret0, _ := ret[0].(*domain.Resource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateResource indicates an expected call of UpdateResource.
+func (mr *MockResourceServiceInterfaceMockRecorder) UpdateResource(ctx, resourceType, id, data, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateResource", reflect.TypeOf((*MockResourceServiceInterface)(nil).UpdateResource), ctx, resourceType, id, data, expectedVersion)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirmatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// matchCandidateLimit caps the stored patients scored for one match
+const matchCandidateLimit = 500
+
+// matchPrefixLength is the length of the name prefixes candidates are selected by
+const matchPrefixLength = 2
+
+// MatchPatient scores the stored patients that may be the patient described by fhirPatient
+// and returns up to count of them, best first. Patients graded certainly-not are left out,
+// as is everything below certain when onlyCertainMatches is set.
+func (s *patientService) MatchPatient(ctx context.Context, fhirPatient *fhir.Patient, onlyCertainMatches bool, count int) ([]domain.PatientMatch, error) {
+	if len(fhirPatient.Name) == 0 && fhirPatient.BirthDate == nil && len(fhirPatient.Identifier) == 0 && len(fhirPatient.Telecom) == 0 {
+		return nil, fmt.Errorf("%w: the patient to match needs a name, birth date, identifier or telecom", domain.ErrValidation)
+	}
+	if count <= 0 {
+		count = fhirsearch.DefaultCount
+	}
+
+	matches, err := s.findMatches(ctx, fhirPatient)
+	if err != nil {
+		return nil, err
+	}
+	if onlyCertainMatches {
+		certain := matches[:0]
+		for _, match := range matches {
+			if match.Grade == fhirmatch.GradeCertain {
+				certain = append(certain, match)
+			}
+		}
+		matches = certain
+	}
+	if len(matches) > count {
+		matches = matches[:count]
+	}
+	logger.WithContext(ctx).Infof("Patient match returned %d candidates", len(matches))
+	return matches, nil
+}
+
+// findMatches scores the candidates for a patient, dropping those graded certainly-not
+func (s *patientService) findMatches(ctx context.Context, fhirPatient *fhir.Patient) ([]domain.PatientMatch, error) {
+	candidates, err := s.repo.MatchCandidates(ctx, matchCriteria(fhirPatient), matchCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]domain.PatientMatch, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateFHIR, err := s.ConvertToFHIR(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		result := fhirmatch.Compare(fhirPatient, candidateFHIR)
+		if result.Grade == fhirmatch.GradeCertainlyNot {
+			continue
+		}
+		matches = append(matches, domain.PatientMatch{Patient: candidate, Score: result.Score, Grade: result.Grade})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches, nil
+}
+
+// findDuplicates returns the stored patients a new patient is probably or certainly a
+// duplicate of. A failed check is logged rather than failing the write.
+func (s *patientService) findDuplicates(ctx context.Context, fhirPatient *fhir.Patient) []domain.PatientMatch {
+	matches, err := s.findMatches(ctx, fhirPatient)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Skipped duplicate check for new patient: %v", err)
+		return nil
+	}
+
+	var duplicates []domain.PatientMatch
+	for _, match := range matches {
+		if match.Grade == fhirmatch.GradeCertain || match.Grade == fhirmatch.GradeProbable {
+			duplicates = append(duplicates, match)
+		}
+	}
+	if len(duplicates) > 0 {
+		logger.WithContext(ctx).Warnf("New patient may duplicate Patient/%s (score %.2f) and %d others",
+			duplicates[0].Patient.LogicalID, duplicates[0].Score, len(duplicates)-1)
+	}
+	return duplicates
+}
+
+// matchCriteria selects candidates sharing the birth date, the start of a family name, the
+// start of a given name within the birth year, or an identifier with the patient
+func matchCriteria(fhirPatient *fhir.Patient) domain.MatchCriteria {
+	var criteria domain.MatchCriteria
+	if fhirPatient.BirthDate != nil {
+		if birthDate, err := time.Parse("2006-01-02", *fhirPatient.BirthDate); err == nil {
+			criteria.BirthDate = &birthDate
+		}
+	}
+	for _, name := range fhirPatient.Name {
+		if name.Family != nil {
+			criteria.FamilyPrefixes = appendPrefix(criteria.FamilyPrefixes, *name.Family)
+		}
+		for _, given := range name.Given {
+			criteria.GivenPrefixes = appendPrefix(criteria.GivenPrefixes, given)
+		}
+	}
+	for _, identifier := range fhirPatient.Identifier {
+		if identifier.Value == nil || *identifier.Value == "" {
+			continue
+		}
+		system := ""
+		if identifier.System != nil {
+			system = *identifier.System
+		}
+		criteria.Identifiers = append(criteria.Identifiers, domain.PatientIdentifier{System: system, Value: *identifier.Value})
+	}
+	return criteria
+}
+
+// appendPrefix adds the lowercase prefix of a name to prefixes unless the name is too short
+// or the prefix is already present
+func appendPrefix(prefixes []string, name string) []string {
+	runes := []rune(strings.ToLower(strings.TrimSpace(name)))
+	if len(runes) < matchPrefixLength {
+		return prefixes
+	}
+	prefix := string(runes[:matchPrefixLength])
+	for _, existing := range prefixes {
+		if existing == prefix {
+			return prefixes
+		}
+	}
+	return append(prefixes, prefix)
+}
@@ -3,10 +3,19 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirvalidation"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils"
 
@@ -16,28 +25,78 @@ import (
 // PatientServiceInterface defines the contract for patient service
 type PatientServiceInterface interface {
 	CreatePatient(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error)
-	GetPatient(ctx context.Context, id uint) (*domain.Patient, error)
+	GetPatient(ctx context.Context, id string) (*domain.Patient, error)
 	GetPatients(ctx context.Context, limit, offset int) ([]*domain.Patient, int64, error)
-	UpdatePatient(ctx context.Context, id uint, fhirPatient *fhir.Patient) (*domain.Patient, error)
-	PatchPatient(ctx context.Context, id uint, updates map[string]interface{}) (*domain.Patient, error)
-	DeletePatient(ctx context.Context, id uint) error
+	SearchPatients(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error)
+	CountPatients(ctx context.Context, query *fhirsearch.Query) (int64, error)
+	UpdatePatient(ctx context.Context, id string, fhirPatient *fhir.Patient, expectedVersion int) (*domain.Patient, error)
+	PatchPatient(ctx context.Context, id string, updates map[string]interface{}, expectedVersion int) (*domain.Patient, error)
+	JSONPatchPatient(ctx context.Context, id string, patch []byte, expectedVersion int) (*domain.Patient, error)
+	FHIRPathPatchPatient(ctx context.Context, id string, parameters []byte, expectedVersion int) (*domain.Patient, error)
+	DeletePatient(ctx context.Context, id string, expectedVersion int) error
+	UndeletePatient(ctx context.Context, id string) (*domain.Patient, error)
+	ExpungePatient(ctx context.Context, id string, history bool) (int64, error)
+	ConditionalCreatePatient(ctx context.Context, fhirPatient *fhir.Patient, criteria *fhirsearch.Query) (*domain.Patient, bool, error)
+	ConditionalUpdatePatient(ctx context.Context, criteria *fhirsearch.Query, fhirPatient *fhir.Patient) (*domain.Patient, bool, error)
+	ConditionalDeletePatient(ctx context.Context, criteria *fhirsearch.Query) error
+	GetPatientHistory(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error)
+	GetPatientVersion(ctx context.Context, id string, versionID int) (*domain.PatientHistory, error)
+	ConvertHistoryToFHIR(ctx context.Context, entry *domain.PatientHistory) (*fhir.Patient, error)
 	ConvertToFHIR(ctx context.Context, patient *domain.Patient) (*fhir.Patient, error)
 	ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error)
+	ValidatePatient(ctx context.Context, resource []byte, profiles []string) ([]fhir.OperationOutcomeIssue, error)
+	PreparePatient(ctx context.Context, resource []byte) (*domain.Patient, error)
+	MatchPatient(ctx context.Context, fhirPatient *fhir.Patient, onlyCertainMatches bool, count int) ([]domain.PatientMatch, error)
 }
 
+// InstantFormat is the layout used for FHIR instant values such as meta.lastUpdated
+const InstantFormat = "2006-01-02T15:04:05.000Z07:00"
+
 type patientService struct {
-	repo domain.PatientRepository
+	repo      domain.PatientRepository
+	validator *fhirvalidation.Validator
+	ids       domain.IDGenerator
+}
+
+// NewPatientService creates a new patient service. Every write is checked by the validator
+// and new patients get their logical id from the id generator.
+func NewPatientService(repo domain.PatientRepository, validator *fhirvalidation.Validator, ids domain.IDGenerator) PatientServiceInterface {
+	return newPatientService(repo, validator, ids)
 }
 
-// NewPatientService creates a new patient service
-func NewPatientService(repo domain.PatientRepository) PatientServiceInterface {
+// newPatientService creates the patient service the bundle and merge services write through
+func newPatientService(repo domain.PatientRepository, validator *fhirvalidation.Validator, ids domain.IDGenerator) *patientService {
 	return &patientService{
-		repo: repo,
+		repo:      repo,
+		validator: validator,
+		ids:       ids,
 	}
 }
 
-// CreatePatient creates a new patient from FHIR data
+// CreatePatient creates a new patient from FHIR data. Any id in the resource is replaced
+// by a server-assigned logical id.
 func (s *patientService) CreatePatient(ctx context.Context, fhirPatient *fhir.Patient) (*domain.Patient, error) {
+	return s.createPatient(ctx, fhirPatient, "")
+}
+
+// createPatient validates and stores a new patient under logicalID, allocating a logical id
+// when none was reserved for it. Probable duplicates of the patient are reported on the
+// returned patient but do not prevent the write.
+func (s *patientService) createPatient(ctx context.Context, fhirPatient *fhir.Patient, logicalID string) (*domain.Patient, error) {
+	if err := s.validate(ctx, fhirPatient); err != nil {
+		return nil, err
+	}
+	duplicates := s.findDuplicates(ctx, fhirPatient)
+
+	if logicalID == "" {
+		var err error
+		if logicalID, err = s.ids.NextID(ctx); err != nil {
+			logger.WithContext(ctx).Errorf("Failed to assign logical ID: %v", err)
+			return nil, err
+		}
+	}
+	assignIdentity(fhirPatient, logicalID)
+
 	patient, err := s.ConvertFromFHIR(ctx, fhirPatient)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to convert FHIR patient: %v", err)
@@ -47,13 +106,14 @@ func (s *patientService) CreatePatient(ctx context.Context, fhirPatient *fhir.Pa
 	if err := s.repo.Create(ctx, patient); err != nil {
 		return nil, err
 	}
+	patient.Duplicates = duplicates
 
 	return patient, nil
 }
 
-// GetPatient retrieves a patient by ID
-func (s *patientService) GetPatient(ctx context.Context, id uint) (*domain.Patient, error) {
-	return s.repo.GetByID(ctx, id)
+// GetPatient retrieves a patient by logical ID
+func (s *patientService) GetPatient(ctx context.Context, id string) (*domain.Patient, error) {
+	return s.repo.GetByLogicalID(ctx, id)
 }
 
 // GetPatients retrieves all patients with pagination
@@ -71,13 +131,37 @@ func (s *patientService) GetPatients(ctx context.Context, limit, offset int) ([]
 	return patients, count, nil
 }
 
-// UpdatePatient updates an existing patient
-func (s *patientService) UpdatePatient(ctx context.Context, id uint, fhirPatient *fhir.Patient) (*domain.Patient, error) {
-	existingPatient, err := s.repo.GetByID(ctx, id)
+// SearchPatients retrieves patients matching FHIR search parameters
+func (s *patientService) SearchPatients(ctx context.Context, query *fhirsearch.Query) (*domain.PatientPage, error) {
+	return s.repo.Search(ctx, query)
+}
+
+// CountPatients counts the patients matching FHIR search parameters without loading them.
+// Without parameters every patient counts.
+func (s *patientService) CountPatients(ctx context.Context, query *fhirsearch.Query) (int64, error) {
+	if len(query.Params) == 0 {
+		return s.repo.Count(ctx)
+	}
+	return s.repo.SearchCount(ctx, query)
+}
+
+// UpdatePatient updates an existing patient. A non-zero expectedVersion enforces optimistic concurrency.
+// An id in the resource must match the logical id being updated.
+func (s *patientService) UpdatePatient(ctx context.Context, id string, fhirPatient *fhir.Patient, expectedVersion int) (*domain.Patient, error) {
+	if fhirPatient.Id != nil && *fhirPatient.Id != id {
+		return nil, fmt.Errorf("%w: resource id %q does not match the requested id %q", domain.ErrValidation, *fhirPatient.Id, id)
+	}
+
+	existingPatient, err := s.repo.GetByLogicalID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.validate(ctx, fhirPatient); err != nil {
+		return nil, err
+	}
+	assignIdentity(fhirPatient, existingPatient.LogicalID)
+
 	// Convert FHIR data to domain model
 	updatedPatient, err := s.ConvertFromFHIR(ctx, fhirPatient)
 	if err != nil {
@@ -88,16 +172,16 @@ func (s *patientService) UpdatePatient(ctx context.Context, id uint, fhirPatient
 	updatedPatient.ID = existingPatient.ID
 	updatedPatient.CreatedAt = existingPatient.CreatedAt
 
-	if err := s.repo.Update(ctx, updatedPatient); err != nil {
+	if err := s.repo.Update(ctx, updatedPatient, expectedVersion); err != nil {
 		return nil, err
 	}
 
 	return updatedPatient, nil
 }
 
-// PatchPatient partially updates a patient
-func (s *patientService) PatchPatient(ctx context.Context, id uint, updates map[string]interface{}) (*domain.Patient, error) {
-	patient, err := s.repo.GetByID(ctx, id)
+// PatchPatient partially updates a patient. A non-zero expectedVersion enforces optimistic concurrency.
+func (s *patientService) PatchPatient(ctx context.Context, id string, updates map[string]interface{}, expectedVersion int) (*domain.Patient, error) {
+	patient, err := s.repo.GetByLogicalID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +196,10 @@ func (s *patientService) PatchPatient(ctx context.Context, id uint, updates map[
 	if err := s.applyUpdatesToFHIR(fhirPatient, updates); err != nil {
 		return nil, err
 	}
+	if err := s.validate(ctx, fhirPatient); err != nil {
+		return nil, err
+	}
+	assignIdentity(fhirPatient, patient.LogicalID)
 
 	// Convert back to domain model
 	updatedPatient, err := s.ConvertFromFHIR(ctx, fhirPatient)
@@ -123,16 +211,197 @@ func (s *patientService) PatchPatient(ctx context.Context, id uint, updates map[
 	updatedPatient.ID = patient.ID
 	updatedPatient.CreatedAt = patient.CreatedAt
 
-	if err := s.repo.Update(ctx, updatedPatient); err != nil {
+	// The patch was applied to the version read above, so the write must not
+	// overwrite a version committed in the meantime
+	if expectedVersion == 0 {
+		expectedVersion = patient.VersionID
+	}
+	if err := s.repo.Update(ctx, updatedPatient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	return updatedPatient, nil
+}
+
+// JSONPatchPatient applies an RFC 6902 JSON Patch to the full FHIR representation of a patient
+func (s *patientService) JSONPatchPatient(ctx context.Context, id string, patch []byte, expectedVersion int) (*domain.Patient, error) {
+	return s.patchDocument(ctx, id, expectedVersion, func(doc []byte) ([]byte, error) {
+		return fhirpatch.ApplyJSONPatch(doc, patch)
+	})
+}
+
+// FHIRPathPatchPatient applies a FHIRPath Patch Parameters resource to a patient
+func (s *patientService) FHIRPathPatchPatient(ctx context.Context, id string, parameters []byte, expectedVersion int) (*domain.Patient, error) {
+	return s.patchDocument(ctx, id, expectedVersion, func(doc []byte) ([]byte, error) {
+		return fhirpatch.ApplyFHIRPathPatch(doc, parameters, fhir.Patient{})
+	})
+}
+
+// patchDocument applies a document-level patch to the current FHIR representation of a patient,
+// checks the result is still a valid Patient with the same id and stores it as a new version
+func (s *patientService) patchDocument(ctx context.Context, id string, expectedVersion int, apply func(doc []byte) ([]byte, error)) (*domain.Patient, error) {
+	patient, err := s.repo.GetByLogicalID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fhirPatient, err := s.ConvertToFHIR(ctx, patient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse existing FHIR data: %w", err)
+	}
+	doc, err := json.Marshal(fhirPatient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal FHIR patient: %w", err)
+	}
+
+	patched, err := apply(doc)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to apply patch to patient %s: %v", id, err)
+		return nil, err
+	}
+	patchedPatient, err := decodePatchedPatient(patched)
+	if err != nil {
+		return nil, err
+	}
+	if patchedPatient.Id != nil && *patchedPatient.Id != patient.LogicalID {
+		return nil, fmt.Errorf("%w: the resource id cannot be changed", fhirpatch.ErrInvalidPath)
+	}
+	if err := s.validate(ctx, patchedPatient); err != nil {
+		return nil, err
+	}
+	assignIdentity(patchedPatient, patient.LogicalID)
+
+	updatedPatient, err := s.ConvertFromFHIR(ctx, patchedPatient)
+	if err != nil {
 		return nil, err
 	}
+	updatedPatient.ID = patient.ID
+	updatedPatient.CreatedAt = patient.CreatedAt
 
+	if expectedVersion == 0 {
+		expectedVersion = patient.VersionID
+	}
+	if err := s.repo.Update(ctx, updatedPatient, expectedVersion); err != nil {
+		return nil, err
+	}
 	return updatedPatient, nil
 }
 
-// DeletePatient deletes a patient
-func (s *patientService) DeletePatient(ctx context.Context, id uint) error {
-	return s.repo.Delete(ctx, id)
+// DeletePatient deletes a patient. A non-zero expectedVersion enforces optimistic concurrency.
+func (s *patientService) DeletePatient(ctx context.Context, id string, expectedVersion int) error {
+	patient, err := s.repo.GetByLogicalID(ctx, id)
+	missing := errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrGone)
+	if missing && expectedVersion == 0 {
+		// Deleting a missing or already deleted patient is a no-op
+		return nil
+	}
+	if missing {
+		return domain.ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, patient.ID, expectedVersion)
+}
+
+// UndeletePatient restores a deleted patient as a new version
+func (s *patientService) UndeletePatient(ctx context.Context, id string) (*domain.Patient, error) {
+	return s.repo.Undelete(ctx, id)
+}
+
+// ExpungePatient permanently removes a patient, and its history when history is set,
+// returning the number of rows removed
+func (s *patientService) ExpungePatient(ctx context.Context, id string, history bool) (int64, error) {
+	return s.repo.Expunge(ctx, id, history)
+}
+
+// ConditionalCreatePatient creates a patient only if no existing patient matches the criteria.
+// It returns the existing patient and false when exactly one match is found.
+func (s *patientService) ConditionalCreatePatient(ctx context.Context, fhirPatient *fhir.Patient, criteria *fhirsearch.Query) (*domain.Patient, bool, error) {
+	existing, err := s.findConditionalMatch(ctx, criteria)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		logger.WithContext(ctx).Infof("Conditional create matched existing patient %s", existing.LogicalID)
+		return existing, false, nil
+	}
+
+	patient, err := s.CreatePatient(ctx, fhirPatient)
+	if err != nil {
+		return nil, false, err
+	}
+	return patient, true, nil
+}
+
+// ConditionalUpdatePatient updates the patient matching the criteria, or creates it when nothing matches.
+// The boolean result reports whether a new patient was created.
+func (s *patientService) ConditionalUpdatePatient(ctx context.Context, criteria *fhirsearch.Query, fhirPatient *fhir.Patient) (*domain.Patient, bool, error) {
+	existing, err := s.findConditionalMatch(ctx, criteria)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		patient, err := s.CreatePatient(ctx, fhirPatient)
+		if err != nil {
+			return nil, false, err
+		}
+		return patient, true, nil
+	}
+
+	patient, err := s.UpdatePatient(ctx, existing.LogicalID, fhirPatient, existing.VersionID)
+	if err != nil {
+		return nil, false, err
+	}
+	return patient, false, nil
+}
+
+// ConditionalDeletePatient deletes the patient matching the criteria; no match is not an error
+func (s *patientService) ConditionalDeletePatient(ctx context.Context, criteria *fhirsearch.Query) error {
+	existing, err := s.findConditionalMatch(ctx, criteria)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		logger.WithContext(ctx).Infof("Conditional delete matched no patients")
+		return nil
+	}
+	return s.repo.Delete(ctx, existing.ID, existing.VersionID)
+}
+
+// GetPatientHistory retrieves the version history of one patient, or of all patients when no ID is given
+func (s *patientService) GetPatientHistory(ctx context.Context, query domain.HistoryQuery) ([]*domain.PatientHistory, int64, error) {
+	entries, total, err := s.repo.History(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Distinguish an unknown patient from one with no history in the requested window
+	if query.PatientID != "" && total == 0 && query.Since == nil {
+		if _, err := s.repo.GetByLogicalID(ctx, query.PatientID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return entries, total, nil
+}
+
+// GetPatientVersion retrieves a specific version of a patient
+func (s *patientService) GetPatientVersion(ctx context.Context, id string, versionID int) (*domain.PatientHistory, error) {
+	return s.repo.GetVersion(ctx, id, versionID)
+}
+
+// ConvertHistoryToFHIR converts a history entry to the FHIR Patient as it was at that version
+func (s *patientService) ConvertHistoryToFHIR(ctx context.Context, entry *domain.PatientHistory) (*fhir.Patient, error) {
+	if entry.Method == http.MethodDelete {
+		return nil, domain.ErrGone
+	}
+	var fhirPatient fhir.Patient
+	if err := json.Unmarshal(entry.FHIRData, &fhirPatient); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal FHIR data: %w", err)
+	}
+	applyVersionMeta(&fhirPatient, entry.VersionID, entry.CreatedAt)
+	return &fhirPatient, nil
 }
 
 // ConvertToFHIR converts a domain patient to FHIR format
@@ -141,6 +410,7 @@ func (s *patientService) ConvertToFHIR(ctx context.Context, patient *domain.Pati
 	if err := json.Unmarshal([]byte(patient.FHIRData), &fhirPatient); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal FHIR data: %w", err)
 	}
+	applyVersionMeta(&fhirPatient, patient.VersionID, patient.UpdatedAt)
 	return &fhirPatient, nil
 }
 
@@ -164,6 +434,11 @@ func (s *patientService) ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.
 		FHIRData: cleaned,
 	}
 
+	// Extract the logical id
+	if fhirPatient.Id != nil {
+		patient.LogicalID = *fhirPatient.Id
+	}
+
 	// Extract searchable fields
 	if fhirPatient.Active != nil {
 		patient.Active = fhirPatient.Active
@@ -195,49 +470,272 @@ func (s *patientService) ConvertFromFHIR(ctx context.Context, fhirPatient *fhir.
 	return patient, nil
 }
 
-// applyUpdatesToFHIR applies partial updates to a FHIR patient
+// applyUpdatesToFHIR applies partial updates to a FHIR patient. Only the active, family,
+// given, gender and birthDate keys are understood; any other key, or a value of the wrong
+// type or outside its value set, is rejected with domain.ErrValidation and nothing is applied.
 func (s *patientService) applyUpdatesToFHIR(fhirPatient *fhir.Patient, updates map[string]interface{}) error {
-	for key, value := range updates {
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	patched := *fhirPatient
+	patched.Name = slices.Clone(fhirPatient.Name)
+	name := func() *fhir.HumanName {
+		if len(patched.Name) == 0 {
+			patched.Name = []fhir.HumanName{{}}
+		}
+		return &patched.Name[0]
+	}
+	for _, key := range keys {
+		value := updates[key]
+		text, isText := value.(string)
 		switch key {
 		case "active":
-			if active, ok := value.(bool); ok {
-				fhirPatient.Active = &active
+			active, ok := value.(bool)
+			if !ok {
+				return fmt.Errorf("%w: active must be true or false", domain.ErrValidation)
 			}
+			patched.Active = &active
 		case "family":
-			if family, ok := value.(string); ok {
-				if len(fhirPatient.Name) == 0 {
-					fhirPatient.Name = []fhir.HumanName{{}}
-				}
-				fhirPatient.Name[0].Family = &family
+			if !isText {
+				return fmt.Errorf("%w: family must be a string", domain.ErrValidation)
 			}
+			name().Family = &text
 		case "given":
-			if given, ok := value.(string); ok {
-				if len(fhirPatient.Name) == 0 {
-					fhirPatient.Name = []fhir.HumanName{{}}
-				}
-				if fhirPatient.Name[0].Given == nil {
-					fhirPatient.Name[0].Given = []string{given}
-				} else {
-					fhirPatient.Name[0].Given = []string{given}
-				}
+			if !isText {
+				return fmt.Errorf("%w: given must be a string", domain.ErrValidation)
 			}
+			name().Given = []string{text}
 		case "gender":
-			if gender, ok := value.(string); ok {
-				// Validate gender values according to FHIR spec
-				if gender == "male" || gender == "female" || gender == "other" || gender == "unknown" {
-					gender := fhir.AdministrativeGender(*utils.GenderPtr(gender))
-					fhirPatient.Gender = &gender
-				}
+			if !isText || !slices.Contains([]string{"male", "female", "other", "unknown"}, text) {
+				return fmt.Errorf("%w: gender must be one of male, female, other or unknown", domain.ErrValidation)
 			}
+			gender := fhir.AdministrativeGender(*utils.GenderPtr(text))
+			patched.Gender = &gender
 		case "birthDate":
-			if birthDate, ok := value.(string); ok {
-				// Validate date format
-				if _, err := time.Parse("2006-01-02", birthDate); err == nil {
-					fhirPatient.BirthDate = &birthDate
-				}
+			if !isText {
+				return fmt.Errorf("%w: birthDate must be a date such as 1980-01-31", domain.ErrValidation)
+			}
+			if _, err := time.Parse("2006-01-02", text); err != nil {
+				return fmt.Errorf("%w: birthDate must be a date such as 1980-01-31, got %q", domain.ErrValidation, text)
 			}
+			patched.BirthDate = &text
+		default:
+			return fmt.Errorf("%w: %s cannot be changed with partial updates; use JSON Patch or FHIRPath Patch", domain.ErrValidation, key)
 		}
 	}
 
+	*fhirPatient = patched
 	return nil
 }
+
+// ValidatePatient checks a Patient resource against the base specification, the enforced
+// profiles and the requested profiles. Problems with the resource are reported as issues;
+// an error is only returned when the input is not a JSON object.
+func (s *patientService) ValidatePatient(ctx context.Context, resource []byte, profiles []string) ([]fhir.OperationOutcomeIssue, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(resource, &document); err != nil {
+		return nil, fmt.Errorf("%w: resource is not a JSON object", domain.ErrValidation)
+	}
+
+	var issues []fhir.OperationOutcomeIssue
+	if _, unknown, err := decodePatient(resource); err != nil {
+		issues = append(issues, structureIssue("Patient", err.Error()))
+	} else {
+		for _, element := range unknown {
+			issues = append(issues, structureIssue(element, "Unknown element "+element))
+		}
+	}
+
+	profileIssues, err := s.validator.Validate(resource, profiles...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+	issues = append(issues, profileIssues...)
+	logger.WithContext(ctx).Infof("Validated patient resource with %d issues", len(issues))
+	return issues, nil
+}
+
+// PreparePatient validates a Patient resource and converts it to a new patient, with a
+// server-assigned logical id, for a bulk import to store. Unlike CreatePatient it does not
+// look for duplicates, which would take a search per patient.
+func (s *patientService) PreparePatient(ctx context.Context, resource []byte) (*domain.Patient, error) {
+	fhirPatient, unknown, err := decodePatient(resource)
+	if err != nil {
+		return nil, &domain.ValidationError{Issues: []fhir.OperationOutcomeIssue{structureIssue("Patient", err.Error())}}
+	}
+	if len(unknown) > 0 {
+		issues := make([]fhir.OperationOutcomeIssue, 0, len(unknown))
+		for _, element := range unknown {
+			issues = append(issues, structureIssue(element, "Unknown element "+element))
+		}
+		return nil, &domain.ValidationError{Issues: issues}
+	}
+	if err := s.validate(ctx, fhirPatient); err != nil {
+		return nil, err
+	}
+
+	logicalID, err := s.ids.NextID(ctx)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to assign logical ID: %v", err)
+		return nil, err
+	}
+	assignIdentity(fhirPatient, logicalID)
+	return s.ConvertFromFHIR(ctx, fhirPatient)
+}
+
+// validate checks a patient about to be written and rejects it when any error is found
+func (s *patientService) validate(ctx context.Context, fhirPatient *fhir.Patient) error {
+	data, err := json.Marshal(fhirPatient)
+	if err != nil {
+		return fmt.Errorf("failed to marshal FHIR patient: %w", err)
+	}
+	issues, err := s.validator.Validate(data)
+	if err != nil {
+		return err
+	}
+	if fhirvalidation.HasErrors(issues) {
+		logger.WithContext(ctx).Warnf("Rejected invalid patient with %d issues", len(issues))
+		return &domain.ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// decodePatchedPatient decodes a patched document, rejecting results that are not a Patient
+// or that contain elements the Patient model does not define
+func decodePatchedPatient(patched []byte) (*fhir.Patient, error) {
+	fhirPatient, unknown, err := decodePatient(patched)
+	if err != nil {
+		return nil, fmt.Errorf("%w: patched %v", fhirpatch.ErrInvalidPath, err)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown elements %s", fhirpatch.ErrInvalidPath, strings.Join(unknown, ", "))
+	}
+	return fhirPatient, nil
+}
+
+// decodePatient decodes a Patient resource and lists the elements present in the document
+// that the Patient model does not define
+func decodePatient(data []byte) (*fhir.Patient, []string, error) {
+	var fhirPatient fhir.Patient
+	_, unknown, err := decodeResource(data, "Patient", &fhirPatient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &fhirPatient, unknown, nil
+}
+
+// decodeResource decodes a resource of the given type into model and returns the document
+// along with the elements present in it that the model does not define
+func decodeResource(data []byte, resourceType string, model interface{}) (map[string]interface{}, []string, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, errors.New("document is not a JSON object")
+	}
+	if document["resourceType"] != resourceType {
+		return nil, nil, fmt.Errorf("resource type must be %s", resourceType)
+	}
+
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, nil, fmt.Errorf("resource is not a valid %s: %v", resourceType, err)
+	}
+
+	roundTrip, err := json.Marshal(model)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal FHIR %s: %w", resourceType, err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(roundTrip, &decoded); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal FHIR %s: %w", resourceType, err)
+	}
+	return document, unknownElements(document, decoded, resourceType), nil
+}
+
+// structureIssue reports a resource that does not match the structure of its type
+func structureIssue(location, diagnostics string) fhir.OperationOutcomeIssue {
+	return fhir.OperationOutcomeIssue{
+		Severity:    fhir.IssueSeverityError,
+		Code:        fhir.IssueTypeStructure,
+		Diagnostics: &diagnostics,
+		Expression:  []string{location},
+	}
+}
+
+// unknownElements lists the paths present in a patched document that were dropped when decoding it
+func unknownElements(patched, decoded interface{}, path string) []string {
+	var unknown []string
+	switch p := patched.(type) {
+	case map[string]interface{}:
+		d, _ := decoded.(map[string]interface{})
+		for key, value := range p {
+			child, ok := d[key]
+			if !ok && value != nil {
+				unknown = append(unknown, path+"."+key)
+				continue
+			}
+			unknown = append(unknown, unknownElements(value, child, path+"."+key)...)
+		}
+	case []interface{}:
+		d, _ := decoded.([]interface{})
+		for i, value := range p {
+			if i < len(d) {
+				unknown = append(unknown, unknownElements(value, d[i], fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// findConditionalMatch returns the single patient matching the criteria, nil when
+// nothing matches, or ErrMultipleMatches when the criteria are not selective enough
+func (s *patientService) findConditionalMatch(ctx context.Context, criteria *fhirsearch.Query) (*domain.Patient, error) {
+	query := *criteria
+	query.Count = 2
+	query.Offset = 0
+	query.Cursor = nil
+	query.Total = ""
+	page, err := s.repo.Search(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case page.Total == 0 || len(page.Patients) == 0:
+		return nil, nil
+	case page.Total > 1:
+		logger.WithContext(ctx).Warnf("Conditional criteria matched %d patients", page.Total)
+		return nil, domain.ErrMultipleMatches
+	default:
+		return page.Patients[0], nil
+	}
+}
+
+// assignIdentity sets the logical id of a patient about to be written and stamps meta.lastUpdated
+func assignIdentity(fhirPatient *fhir.Patient, logicalID string) {
+	fhirPatient.Id = &logicalID
+	if fhirPatient.Meta == nil {
+		fhirPatient.Meta = &fhir.Meta{}
+	}
+	lastUpdated := time.Now().UTC().Format(InstantFormat)
+	fhirPatient.Meta.LastUpdated = &lastUpdated
+}
+
+// applyVersionMeta populates meta.versionId and meta.lastUpdated from the stored version
+func applyVersionMeta(fhirPatient *fhir.Patient, versionID int, lastUpdated time.Time) {
+	if versionID == 0 && lastUpdated.IsZero() {
+		return
+	}
+	if fhirPatient.Meta == nil {
+		fhirPatient.Meta = &fhir.Meta{}
+	}
+	if versionID > 0 {
+		version := strconv.Itoa(versionID)
+		fhirPatient.Meta.VersionId = &version
+	}
+	if !lastUpdated.IsZero() {
+		updated := lastUpdated.UTC().Format(InstantFormat)
+		fhirPatient.Meta.LastUpdated = &updated
+	}
+}
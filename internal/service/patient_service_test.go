@@ -3,11 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"go-fhir-demo/internal/domain"
 	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirmatch"
+	"go-fhir-demo/pkg/fhirpatch"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirvalidation"
 	"go-fhir-demo/pkg/utils"
 
 	"github.com/samply/golang-fhir-models/fhir-models/fhir"
@@ -21,6 +26,7 @@ type PatientServiceTestSuite struct {
 	suite.Suite
 	ctrl     *gomock.Controller
 	mockRepo *mocks.MockPatientRepository
+	mockIDs  *mocks.MockIDGenerator
 	service  PatientServiceInterface
 }
 
@@ -28,7 +34,8 @@ type PatientServiceTestSuite struct {
 func (suite *PatientServiceTestSuite) SetupTest() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.mockRepo = mocks.NewMockPatientRepository(suite.ctrl)
-	suite.service = NewPatientService(suite.mockRepo)
+	suite.mockIDs = mocks.NewMockIDGenerator(suite.ctrl)
+	suite.service = NewPatientService(suite.mockRepo, fhirvalidation.NewValidator(), suite.mockIDs)
 }
 
 // TearDownTest cleans up after each test
@@ -51,6 +58,16 @@ func (suite *PatientServiceTestSuite) TestCreatePatient_Success() {
 		BirthDate: utils.CreateStringPtr("1980-01-01"),
 	}
 
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		Times(1)
+
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("42", nil).
+		Times(1)
+
 	suite.mockRepo.EXPECT().
 		Create(gomock.Any(), gomock.Any()).
 		Return(nil).
@@ -62,11 +79,36 @@ func (suite *PatientServiceTestSuite) TestCreatePatient_Success() {
 	// Assert
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), patient)
+	assert.Equal(suite.T(), "42", patient.LogicalID)
+	assert.Contains(suite.T(), string(patient.FHIRData), `"id":"42"`)
+	assert.Contains(suite.T(), string(patient.FHIRData), `"lastUpdated":`)
 	assert.Equal(suite.T(), "Doe", patient.Family)
 	assert.Equal(suite.T(), "John", patient.Given)
 	assert.Equal(suite.T(), "male", patient.Gender)
 }
 
+// TestPreparePatient tests that an imported resource gets a new logical id without a duplicate search
+func (suite *PatientServiceTestSuite) TestPreparePatient() {
+	// Arrange
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("7", nil).
+		Times(1)
+
+	// Act
+	patient, err := suite.service.PreparePatient(context.Background(), []byte(`{"resourceType":"Patient","id":"old","name":[{"family":"Doe"}]}`))
+	_, unknownErr := suite.service.PreparePatient(context.Background(), []byte(`{"resourceType":"Patient","nickname":"JD"}`))
+	_, invalidErr := suite.service.PreparePatient(context.Background(), []byte(`{"resourceType":`))
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "7", patient.LogicalID)
+	assert.Equal(suite.T(), "Doe", patient.Family)
+	assert.Contains(suite.T(), string(patient.FHIRData), `"id":"7"`)
+	assert.ErrorIs(suite.T(), unknownErr, domain.ErrValidation)
+	assert.ErrorIs(suite.T(), invalidErr, domain.ErrValidation)
+}
+
 // TestCreatePatient_Error tests patient creation error
 func (suite *PatientServiceTestSuite) TestCreatePatient_Error() {
 	// Arrange
@@ -80,6 +122,16 @@ func (suite *PatientServiceTestSuite) TestCreatePatient_Error() {
 		},
 	}
 
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		Times(1)
+
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("1", nil).
+		Times(1)
+
 	suite.mockRepo.EXPECT().
 		Create(gomock.Any(), gomock.Any()).
 		Return(errors.New("database error")).
@@ -94,19 +146,70 @@ func (suite *PatientServiceTestSuite) TestCreatePatient_Error() {
 	assert.Contains(suite.T(), err.Error(), "database error")
 }
 
+// TestCreatePatient_DuplicateIdentifier tests that the repository's conflict for an identifier
+// of a unique system is returned as a conflict
+func (suite *PatientServiceTestSuite) TestCreatePatient_DuplicateIdentifier() {
+	// Arrange
+	fhirPatient := &fhir.Patient{
+		Identifier: []fhir.Identifier{{System: utils.CreateStringPtr("urn:mrn"), Value: utils.CreateStringPtr("123")}},
+	}
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil)
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("2", nil)
+	suite.mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf("%w: identifier urn:mrn|123 is already assigned to Patient/1", domain.ErrConflict))
+
+	// Act
+	patient, err := suite.service.CreatePatient(context.Background(), fhirPatient)
+
+	// Assert
+	assert.Nil(suite.T(), patient)
+	assert.ErrorIs(suite.T(), err, domain.ErrConflict)
+	assert.Contains(suite.T(), err.Error(), "urn:mrn|123 is already assigned to Patient/1")
+}
+
+// TestCreatePatient_InvalidPatient tests that invalid patients are rejected before they are stored
+func (suite *PatientServiceTestSuite) TestCreatePatient_InvalidPatient() {
+	// Arrange
+	fhirPatient := &fhir.Patient{
+		BirthDate: utils.CreateStringPtr("1990-13-45"),
+		Telecom:   []fhir.ContactPoint{{Value: utils.CreateStringPtr("555-0100")}},
+	}
+
+	// Act
+	patient, err := suite.service.CreatePatient(context.Background(), fhirPatient)
+
+	// Assert
+	assert.Nil(suite.T(), patient)
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+	var validationErr *domain.ValidationError
+	if assert.ErrorAs(suite.T(), err, &validationErr) {
+		assert.Len(suite.T(), validationErr.Issues, 2)
+		assert.Equal(suite.T(), []string{"Patient.telecom[0]"}, validationErr.Issues[0].Expression)
+		assert.Equal(suite.T(), fhir.IssueTypeInvariant, validationErr.Issues[0].Code)
+		assert.Equal(suite.T(), []string{"Patient.birthDate"}, validationErr.Issues[1].Expression)
+		assert.Equal(suite.T(), fhir.IssueTypeValue, validationErr.Issues[1].Code)
+	}
+}
+
 // TestGetPatient_Success tests successful patient retrieval
 func (suite *PatientServiceTestSuite) TestGetPatient_Success() {
 	// Arrange
-	patientID := uint(1)
+	patientID := "1"
 	expectedPatient := &domain.Patient{
-		ID:     patientID,
-		Family: "Doe",
-		Given:  "John",
-		Gender: "male",
+		ID:        1,
+		LogicalID: patientID,
+		Family:    "Doe",
+		Given:     "John",
+		Gender:    "male",
 	}
 
 	suite.mockRepo.EXPECT().
-		GetByID(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
 		Return(expectedPatient, nil).
 		Times(1)
 
@@ -116,16 +219,16 @@ func (suite *PatientServiceTestSuite) TestGetPatient_Success() {
 	// Assert
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), patient)
-	assert.Equal(suite.T(), patientID, patient.ID)
+	assert.Equal(suite.T(), patientID, patient.LogicalID)
 	assert.Equal(suite.T(), "Doe", patient.Family)
 }
 
 // TestGetPatient_NotFound tests patient not found scenario
 func (suite *PatientServiceTestSuite) TestGetPatient_NotFound() {
 	// Arrange
-	patientID := uint(999)
+	patientID := "999"
 	suite.mockRepo.EXPECT().
-		GetByID(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
 		Return(nil, errors.New("patient not found")).
 		Times(1)
 
@@ -185,12 +288,57 @@ func (suite *PatientServiceTestSuite) TestGetPatients_Error() {
 	assert.Equal(suite.T(), int64(0), count)
 }
 
+// TestSearchPatients_Success tests that search queries are passed to the repository
+func (suite *PatientServiceTestSuite) TestSearchPatients_Success() {
+	// Arrange
+	query := &fhirsearch.Query{
+		Params: []fhirsearch.Param{{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Doe"}}},
+		Count:  10,
+	}
+	expectedPatients := []*domain.Patient{{ID: 1, Family: "Doe", Given: "John"}}
+
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), query).
+		Return(&domain.PatientPage{Patients: expectedPatients, Total: 1}, nil).
+		Times(1)
+
+	// Act
+	page, err := suite.service.SearchPatients(context.Background(), query)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expectedPatients, page.Patients)
+	assert.Equal(suite.T(), int64(1), page.Total)
+}
+
+// TestCountPatients tests that counting without parameters uses the plain count and with parameters the search count
+func (suite *PatientServiceTestSuite) TestCountPatients() {
+	// Arrange
+	query := &fhirsearch.Query{
+		Params: []fhirsearch.Param{{Name: "gender", Type: fhirsearch.TypeToken, Values: []string{"female"}}},
+		Count:  10,
+	}
+	suite.mockRepo.EXPECT().Count(gomock.Any()).Return(int64(12), nil).Times(1)
+	suite.mockRepo.EXPECT().SearchCount(gomock.Any(), query).Return(int64(5), nil).Times(1)
+
+	// Act
+	all, err := suite.service.CountPatients(context.Background(), &fhirsearch.Query{Count: 10})
+	suite.Require().NoError(err)
+	matching, err := suite.service.CountPatients(context.Background(), query)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(12), all)
+	assert.Equal(suite.T(), int64(5), matching)
+}
+
 // TestUpdatePatient_Success tests successful patient update
 func (suite *PatientServiceTestSuite) TestUpdatePatient_Success() {
 	// Arrange
-	patientID := uint(1)
+	patientID := "1"
 	existingPatient := &domain.Patient{
-		ID:        patientID,
+		ID:        1,
+		LogicalID: patientID,
 		Family:    "Doe",
 		Given:     "John",
 		CreatedAt: time.Now(),
@@ -207,22 +355,24 @@ func (suite *PatientServiceTestSuite) TestUpdatePatient_Success() {
 	}
 
 	suite.mockRepo.EXPECT().
-		GetByID(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
 		Return(existingPatient, nil).
 		Times(1)
 
 	suite.mockRepo.EXPECT().
-		Update(gomock.Any(), gomock.Any()).
+		Update(gomock.Any(), gomock.Any(), 0).
 		Return(nil).
 		Times(1)
 
 	// Act
-	patient, err := suite.service.UpdatePatient(context.Background(), patientID, updatedFhirPatient)
+	patient, err := suite.service.UpdatePatient(context.Background(), patientID, updatedFhirPatient, 0)
 
 	// Assert
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), patient)
-	assert.Equal(suite.T(), patientID, patient.ID)
+	assert.Equal(suite.T(), uint(1), patient.ID)
+	assert.Equal(suite.T(), patientID, patient.LogicalID)
+	assert.Contains(suite.T(), string(patient.FHIRData), `"id":"1"`)
 	assert.Equal(suite.T(), "Updated", patient.Family)
 	assert.Equal(suite.T(), "Jane", patient.Given)
 }
@@ -230,7 +380,7 @@ func (suite *PatientServiceTestSuite) TestUpdatePatient_Success() {
 // TestUpdatePatient_NotFound tests update when patient not found
 func (suite *PatientServiceTestSuite) TestUpdatePatient_NotFound() {
 	// Arrange
-	patientID := uint(999)
+	patientID := "999"
 	updatedFhirPatient := &fhir.Patient{
 		Name: []fhir.HumanName{
 			{
@@ -240,29 +390,46 @@ func (suite *PatientServiceTestSuite) TestUpdatePatient_NotFound() {
 	}
 
 	suite.mockRepo.EXPECT().
-		GetByID(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
 		Return(nil, errors.New("patient not found")).
 		Times(1)
 
 	// Act
-	patient, err := suite.service.UpdatePatient(context.Background(), patientID, updatedFhirPatient)
+	patient, err := suite.service.UpdatePatient(context.Background(), patientID, updatedFhirPatient, 0)
 
 	// Assert
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), patient)
 }
 
+// TestUpdatePatient_IDMismatch tests that the resource id must match the id being updated
+func (suite *PatientServiceTestSuite) TestUpdatePatient_IDMismatch() {
+	// Arrange
+	fhirPatient := &fhir.Patient{Id: utils.CreateStringPtr("2")}
+
+	// Act
+	patient, err := suite.service.UpdatePatient(context.Background(), "1", fhirPatient, 0)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+	assert.Nil(suite.T(), patient)
+}
+
 // TestDeletePatient_Success tests successful patient deletion
 func (suite *PatientServiceTestSuite) TestDeletePatient_Success() {
 	// Arrange
-	patientID := uint(1)
+	patientID := "1"
 	suite.mockRepo.EXPECT().
-		Delete(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
+		Return(&domain.Patient{ID: 7, LogicalID: patientID}, nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		Delete(gomock.Any(), uint(7), 0).
 		Return(nil).
 		Times(1)
 
 	// Act
-	err := suite.service.DeletePatient(context.Background(), patientID)
+	err := suite.service.DeletePatient(context.Background(), patientID, 0)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -271,20 +438,83 @@ func (suite *PatientServiceTestSuite) TestDeletePatient_Success() {
 // TestDeletePatient_Error tests patient deletion error
 func (suite *PatientServiceTestSuite) TestDeletePatient_Error() {
 	// Arrange
-	patientID := uint(1)
+	patientID := "1"
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), patientID).
+		Return(&domain.Patient{ID: 1, LogicalID: patientID}, nil).
+		Times(1)
 	suite.mockRepo.EXPECT().
-		Delete(gomock.Any(), patientID).
+		Delete(gomock.Any(), uint(1), 0).
 		Return(errors.New("delete failed")).
 		Times(1)
 
 	// Act
-	err := suite.service.DeletePatient(context.Background(), patientID)
+	err := suite.service.DeletePatient(context.Background(), patientID, 0)
 
 	// Assert
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "delete failed")
 }
 
+// TestDeletePatient_NotFound tests that deleting an unknown patient is a no-op unless a version is expected
+func (suite *PatientServiceTestSuite) TestDeletePatient_NotFound() {
+	// Arrange
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), "missing").
+		Return(nil, domain.ErrNotFound).
+		Times(2)
+
+	// Act & Assert
+	assert.NoError(suite.T(), suite.service.DeletePatient(context.Background(), "missing", 0))
+	assert.ErrorIs(suite.T(), suite.service.DeletePatient(context.Background(), "missing", 2), domain.ErrVersionConflict)
+}
+
+// TestDeletePatient_AlreadyDeleted tests that deleting a deleted patient is a no-op unless a version is expected
+func (suite *PatientServiceTestSuite) TestDeletePatient_AlreadyDeleted() {
+	// Arrange
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), "1").
+		Return(nil, fmt.Errorf("%w: patient 1 has been deleted", domain.ErrGone)).
+		Times(2)
+
+	// Act & Assert
+	assert.NoError(suite.T(), suite.service.DeletePatient(context.Background(), "1", 0))
+	assert.ErrorIs(suite.T(), suite.service.DeletePatient(context.Background(), "1", 3), domain.ErrVersionConflict)
+}
+
+// TestUndeletePatient_Success tests that undelete restores the patient through the repository
+func (suite *PatientServiceTestSuite) TestUndeletePatient_Success() {
+	// Arrange
+	restored := &domain.Patient{ID: 1, LogicalID: "1", VersionID: 4}
+	suite.mockRepo.EXPECT().
+		Undelete(gomock.Any(), "1").
+		Return(restored, nil).
+		Times(1)
+
+	// Act
+	patient, err := suite.service.UndeletePatient(context.Background(), "1")
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), restored, patient)
+}
+
+// TestExpungePatient_Success tests that expunge passes the history flag to the repository
+func (suite *PatientServiceTestSuite) TestExpungePatient_Success() {
+	// Arrange
+	suite.mockRepo.EXPECT().
+		Expunge(gomock.Any(), "1", true).
+		Return(int64(3), nil).
+		Times(1)
+
+	// Act
+	removed, err := suite.service.ExpungePatient(context.Background(), "1", true)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), removed)
+}
+
 // TestConvertToFHIR_Success tests successful conversion to FHIR
 func (suite *PatientServiceTestSuite) TestConvertToFHIR_Success() {
 	// Arrange
@@ -303,6 +533,25 @@ func (suite *PatientServiceTestSuite) TestConvertToFHIR_Success() {
 	assert.Equal(suite.T(), "Doe", *fhirPatient.Name[0].Family)
 }
 
+// TestConvertToFHIR_PopulatesMeta tests that the stored version is exposed in meta
+func (suite *PatientServiceTestSuite) TestConvertToFHIR_PopulatesMeta() {
+	// Arrange
+	patient := &domain.Patient{
+		ID:        1,
+		VersionID: 2,
+		UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		FHIRData:  []byte(`{"resourceType":"Patient"}`),
+	}
+
+	// Act
+	fhirPatient, err := suite.service.ConvertToFHIR(context.Background(), patient)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2", *fhirPatient.Meta.VersionId)
+	assert.Equal(suite.T(), "2024-01-02T03:04:05.000Z", *fhirPatient.Meta.LastUpdated)
+}
+
 // TestConvertToFHIR_InvalidJSON tests conversion with invalid JSON
 func (suite *PatientServiceTestSuite) TestConvertToFHIR_InvalidJSON() {
 	// Arrange
@@ -350,10 +599,12 @@ func (suite *PatientServiceTestSuite) TestConvertFromFHIR_Success() {
 // TestPatchPatient_Success tests successful patient patching
 func (suite *PatientServiceTestSuite) TestPatchPatient_Success() {
 	// Arrange
-	patientID := uint(1)
+	patientID := "1"
 	existingPatient := &domain.Patient{
-		ID:       patientID,
-		FHIRData: []byte(`{"resourceType":"Patient","active":true,"name":[{"family":"Doe","given":["John"]}]}`),
+		ID:        1,
+		LogicalID: patientID,
+		VersionID: 4,
+		FHIRData:  []byte(`{"resourceType":"Patient","active":true,"name":[{"family":"Doe","given":["John"]}]}`),
 	}
 
 	updates := map[string]interface{}{
@@ -362,26 +613,545 @@ func (suite *PatientServiceTestSuite) TestPatchPatient_Success() {
 	}
 
 	suite.mockRepo.EXPECT().
-		GetByID(gomock.Any(), patientID).
+		GetByLogicalID(gomock.Any(), patientID).
 		Return(existingPatient, nil).
 		Times(1)
 
+	// The patch is guarded by the version it was applied to
 	suite.mockRepo.EXPECT().
-		Update(gomock.Any(), gomock.Any()).
+		Update(gomock.Any(), gomock.Any(), 4).
 		Return(nil).
 		Times(1)
 
 	// Act
-	patient, err := suite.service.PatchPatient(context.Background(), patientID, updates)
+	patient, err := suite.service.PatchPatient(context.Background(), patientID, updates, 0)
 
 	// Assert
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), patient)
-	assert.Equal(suite.T(), patientID, patient.ID)
+	assert.Equal(suite.T(), patientID, patient.LogicalID)
 	assert.Equal(suite.T(), "Updated", patient.Family)
 }
 
+// TestPatchPatient_RejectsInvalidUpdates tests that unknown keys and invalid values are
+// errors rather than being ignored
+func (suite *PatientServiceTestSuite) TestPatchPatient_RejectsInvalidUpdates() {
+	testCases := []struct {
+		name    string
+		updates map[string]interface{}
+	}{
+		{"unknown key", map[string]interface{}{"family": "Updated", "telecom": []interface{}{}}},
+		{"invalid gender", map[string]interface{}{"gender": "robot"}},
+		{"malformed birthDate", map[string]interface{}{"birthDate": "31/01/1980"}},
+		{"wrong type", map[string]interface{}{"active": "yes"}},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.mockRepo.EXPECT().
+				GetByLogicalID(gomock.Any(), "1").
+				Return(&domain.Patient{ID: 1, LogicalID: "1", VersionID: 1, FHIRData: []byte(`{"resourceType":"Patient"}`)}, nil)
+
+			patient, err := suite.service.PatchPatient(context.Background(), "1", tc.updates, 0)
+
+			assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+			assert.Nil(suite.T(), patient)
+		})
+	}
+}
+
+// TestJSONPatchPatient_Success tests that JSON Patch can change any element of the resource
+func (suite *PatientServiceTestSuite) TestJSONPatchPatient_Success() {
+	// Arrange
+	existingPatient := &domain.Patient{
+		ID:        1,
+		LogicalID: "1",
+		VersionID: 2,
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"phone","value":"555"}]}`),
+	}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(existingPatient, nil).Times(1)
+
+	var stored *domain.Patient
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any(), 2).
+		DoAndReturn(func(_ context.Context, patient *domain.Patient, _ int) error {
+			stored = patient
+			return nil
+		}).
+		Times(1)
+
+	patch := []byte(`[
+		{"op":"test","path":"/name/0/family","value":"Doe"},
+		{"op":"replace","path":"/telecom/0/value","value":"556"},
+		{"op":"add","path":"/address","value":[{"city":"Metropolis"}]},
+		{"op":"replace","path":"/name/0/family","value":"Smith"}
+	]`)
+
+	// Act
+	patient, err := suite.service.JSONPatchPatient(context.Background(), "1", patch, 0)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Smith", patient.Family)
+	assert.Contains(suite.T(), string(stored.FHIRData), `"value":"556"`)
+	assert.Contains(suite.T(), string(stored.FHIRData), `"city":"Metropolis"`)
+}
+
+// TestJSONPatchPatient_RejectsInvalidPatches tests that failed tests, missing paths and unknown elements are errors
+func (suite *PatientServiceTestSuite) TestJSONPatchPatient_RejectsInvalidPatches() {
+	existingPatient := &domain.Patient{
+		ID:        1,
+		LogicalID: "1",
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}]}`),
+	}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(existingPatient, nil).Times(4)
+
+	cases := map[string]struct {
+		patch string
+		err   error
+	}{
+		"failed test":     {`[{"op":"test","path":"/name/0/family","value":"Roe"}]`, fhirpatch.ErrTestFailed},
+		"missing path":    {`[{"op":"replace","path":"/address/0/city","value":"X"}]`, fhirpatch.ErrInvalidPath},
+		"unknown element": {`[{"op":"add","path":"/shoeSize","value":9}]`, fhirpatch.ErrInvalidPath},
+		"bad operation":   {`[{"op":"frobnicate","path":"/name"}]`, fhirpatch.ErrInvalidPatch},
+	}
+	for name, tc := range cases {
+		// Act
+		patient, err := suite.service.JSONPatchPatient(context.Background(), "1", []byte(tc.patch), 0)
+
+		// Assert
+		assert.ErrorIs(suite.T(), err, tc.err, name)
+		assert.Nil(suite.T(), patient, name)
+	}
+}
+
+// TestJSONPatchPatient_CannotChangeID tests that a patch may not change the logical id
+func (suite *PatientServiceTestSuite) TestJSONPatchPatient_CannotChangeID() {
+	// Arrange
+	existingPatient := &domain.Patient{
+		ID:        1,
+		LogicalID: "1",
+		FHIRData:  []byte(`{"resourceType":"Patient","id":"1","name":[{"family":"Doe"}]}`),
+	}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(existingPatient, nil).Times(1)
+
+	// Act
+	patient, err := suite.service.JSONPatchPatient(context.Background(), "1", []byte(`[{"op":"replace","path":"/id","value":"2"}]`), 0)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, fhirpatch.ErrInvalidPath)
+	assert.Nil(suite.T(), patient)
+}
+
+// TestFHIRPathPatchPatient_Success tests applying a FHIRPath Patch Parameters resource
+func (suite *PatientServiceTestSuite) TestFHIRPathPatchPatient_Success() {
+	// Arrange
+	existingPatient := &domain.Patient{
+		ID:        1,
+		LogicalID: "1",
+		VersionID: 1,
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}],"telecom":[{"system":"email","value":"old@example.com"}]}`),
+	}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "1").Return(existingPatient, nil).Times(1)
+	suite.mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), 1).Return(nil).Times(1)
+
+	parameters := []byte(`{"resourceType":"Parameters","parameter":[
+		{"name":"operation","part":[
+			{"name":"type","valueCode":"add"},
+			{"name":"path","valueString":"Patient"},
+			{"name":"name","valueString":"birthDate"},
+			{"name":"value","valueDate":"1970-02-03"}]},
+		{"name":"operation","part":[
+			{"name":"type","valueCode":"replace"},
+			{"name":"path","valueString":"Patient.telecom.where(system='email').value"},
+			{"name":"value","valueString":"new@example.com"}]}
+	]}`)
+
+	// Act
+	patient, err := suite.service.FHIRPathPatchPatient(context.Background(), "1", parameters, 0)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1970-02-03", patient.BirthDate.Format("2006-01-02"))
+	assert.Contains(suite.T(), string(patient.FHIRData), "new@example.com")
+}
+
+// TestPatchPatient_VersionConflict tests that a stale If-Match version is rejected
+func (suite *PatientServiceTestSuite) TestPatchPatient_VersionConflict() {
+	// Arrange
+	patientID := "1"
+	existingPatient := &domain.Patient{
+		ID:        1,
+		LogicalID: patientID,
+		VersionID: 5,
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}]}`),
+	}
+
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), patientID).
+		Return(existingPatient, nil).
+		Times(1)
+
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any(), 3).
+		Return(domain.ErrVersionConflict).
+		Times(1)
+
+	// Act
+	patient, err := suite.service.PatchPatient(context.Background(), patientID, map[string]interface{}{"family": "Late"}, 3)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrVersionConflict)
+	assert.Nil(suite.T(), patient)
+}
+
+// TestConditionalCreatePatient_Existing tests that a matching patient is returned instead of creating a duplicate
+func (suite *PatientServiceTestSuite) TestConditionalCreatePatient_Existing() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "identifier", Type: fhirsearch.TypeToken, Values: []string{"urn:mrn|1"}}}, Count: 10}
+	existing := &domain.Patient{ID: 7, VersionID: 2}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), &fhirsearch.Query{Params: criteria.Params, Count: 2}).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{existing}, Total: 1}, nil).
+		Times(1)
+
+	// Act
+	patient, created, err := suite.service.ConditionalCreatePatient(context.Background(), &fhir.Patient{}, criteria)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), created)
+	assert.Equal(suite.T(), existing, patient)
+}
+
+// TestConditionalCreatePatient_NoMatch tests that the patient is created when nothing matches
+func (suite *PatientServiceTestSuite) TestConditionalCreatePatient_NoMatch() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Doe"}}}}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{}, Total: 0}, nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		Times(1)
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("1", nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	// Act
+	fhirPatient := &fhir.Patient{Name: []fhir.HumanName{{Family: utils.CreateStringPtr("Doe")}}}
+	patient, created, err := suite.service.ConditionalCreatePatient(context.Background(), fhirPatient, criteria)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), created)
+	assert.Equal(suite.T(), "Doe", patient.Family)
+}
+
+// TestConditionalCreatePatient_MultipleMatches tests that ambiguous criteria are rejected
+func (suite *PatientServiceTestSuite) TestConditionalCreatePatient_MultipleMatches() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Doe"}}}}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 1}, {ID: 2}}, Total: 5}, nil).
+		Times(1)
+
+	// Act
+	patient, _, err := suite.service.ConditionalCreatePatient(context.Background(), &fhir.Patient{}, criteria)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrMultipleMatches)
+	assert.Nil(suite.T(), patient)
+}
+
+// TestConditionalUpdatePatient_SingleMatch tests that the matching patient is updated at its current version
+func (suite *PatientServiceTestSuite) TestConditionalUpdatePatient_SingleMatch() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "identifier", Type: fhirsearch.TypeToken, Values: []string{"urn:mrn|1"}}}}
+	existing := &domain.Patient{ID: 3, LogicalID: "3", VersionID: 4}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{existing}, Total: 1}, nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), "3").
+		Return(existing, nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any(), 4).
+		Return(nil).
+		Times(1)
+
+	// Act
+	patient, created, err := suite.service.ConditionalUpdatePatient(context.Background(), criteria, &fhir.Patient{})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), created)
+	assert.Equal(suite.T(), uint(3), patient.ID)
+}
+
+// TestConditionalDeletePatient_NoMatch tests that deleting with unmatched criteria is a no-op
+func (suite *PatientServiceTestSuite) TestConditionalDeletePatient_NoMatch() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Nobody"}}}}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{}, Total: 0}, nil).
+		Times(1)
+
+	// Act
+	err := suite.service.ConditionalDeletePatient(context.Background(), criteria)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+}
+
+// TestConditionalDeletePatient_SingleMatch tests that the matching patient is deleted
+func (suite *PatientServiceTestSuite) TestConditionalDeletePatient_SingleMatch() {
+	// Arrange
+	criteria := &fhirsearch.Query{Params: []fhirsearch.Param{{Name: "family", Type: fhirsearch.TypeString, Values: []string{"Doe"}}}}
+	suite.mockRepo.EXPECT().
+		Search(gomock.Any(), gomock.Any()).
+		Return(&domain.PatientPage{Patients: []*domain.Patient{{ID: 9, VersionID: 1}}, Total: 1}, nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		Delete(gomock.Any(), uint(9), 1).
+		Return(nil).
+		Times(1)
+
+	// Act
+	err := suite.service.ConditionalDeletePatient(context.Background(), criteria)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+}
+
+// TestGetPatientHistory_Success tests retrieval of a patient's history
+func (suite *PatientServiceTestSuite) TestGetPatientHistory_Success() {
+	// Arrange
+	query := domain.HistoryQuery{PatientID: "1", Count: 10}
+	entries := []*domain.PatientHistory{
+		{PatientID: 1, LogicalID: "1", VersionID: 2, Method: "PUT"},
+		{PatientID: 1, LogicalID: "1", VersionID: 1, Method: "POST"},
+	}
+	suite.mockRepo.EXPECT().
+		History(gomock.Any(), query).
+		Return(entries, int64(2), nil).
+		Times(1)
+
+	// Act
+	result, total, err := suite.service.GetPatientHistory(context.Background(), query)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), entries, result)
+	assert.Equal(suite.T(), int64(2), total)
+}
+
+// TestGetPatientHistory_UnknownPatient tests history of a patient that never existed
+func (suite *PatientServiceTestSuite) TestGetPatientHistory_UnknownPatient() {
+	// Arrange
+	query := domain.HistoryQuery{PatientID: "42", Count: 10}
+	suite.mockRepo.EXPECT().
+		History(gomock.Any(), query).
+		Return([]*domain.PatientHistory{}, int64(0), nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		GetByLogicalID(gomock.Any(), "42").
+		Return(nil, domain.ErrNotFound).
+		Times(1)
+
+	// Act
+	_, _, err := suite.service.GetPatientHistory(context.Background(), query)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrNotFound)
+}
+
+// TestConvertHistoryToFHIR_Success tests that history entries carry their version metadata
+func (suite *PatientServiceTestSuite) TestConvertHistoryToFHIR_Success() {
+	// Arrange
+	entry := &domain.PatientHistory{
+		PatientID: 1,
+		VersionID: 3,
+		Method:    "PUT",
+		FHIRData:  []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}]}`),
+		CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+	}
+
+	// Act
+	fhirPatient, err := suite.service.ConvertHistoryToFHIR(context.Background(), entry)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "3", *fhirPatient.Meta.VersionId)
+	assert.Equal(suite.T(), "2024-05-01T10:30:00.000Z", *fhirPatient.Meta.LastUpdated)
+	assert.Equal(suite.T(), "Doe", *fhirPatient.Name[0].Family)
+}
+
+// TestConvertHistoryToFHIR_Deleted tests that deletion entries have no resource
+func (suite *PatientServiceTestSuite) TestConvertHistoryToFHIR_Deleted() {
+	// Arrange
+	entry := &domain.PatientHistory{PatientID: 1, VersionID: 4, Method: "DELETE"}
+
+	// Act
+	fhirPatient, err := suite.service.ConvertHistoryToFHIR(context.Background(), entry)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrGone)
+	assert.Nil(suite.T(), fhirPatient)
+}
+
 // TestPatientServiceTestSuite runs the test suite
 func TestPatientServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(PatientServiceTestSuite))
 }
+
+// TestValidatePatient_Valid tests that a valid patient has no issues
+func (suite *PatientServiceTestSuite) TestValidatePatient_Valid() {
+	resource := []byte(`{"resourceType":"Patient","name":[{"family":"Doe"}],"gender":"female","birthDate":"1990-05"}`)
+
+	issues, err := suite.service.ValidatePatient(context.Background(), resource, nil)
+
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), issues)
+}
+
+// TestValidatePatient_ReportsIssues tests that structure, base specification and profile problems are all reported
+func (suite *PatientServiceTestSuite) TestValidatePatient_ReportsIssues() {
+	resource := []byte(`{"resourceType":"Patient","nickname":"JD","name":[{"use":"official","family":""}],"telecom":[{"value":"555-0100"}]}`)
+
+	issues, err := suite.service.ValidatePatient(context.Background(), resource, []string{"http://example.org/StructureDefinition/unknown"})
+
+	assert.NoError(suite.T(), err)
+	var codes []fhir.IssueType
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	assert.Equal(suite.T(), []fhir.IssueType{
+		fhir.IssueTypeStructure,
+		fhir.IssueTypeInvalid,
+		fhir.IssueTypeInvariant,
+		fhir.IssueTypeNotSupported,
+	}, codes)
+	assert.Equal(suite.T(), "Unknown element Patient.nickname", *issues[0].Diagnostics)
+}
+
+// TestValidatePatient_NotJSONObject tests that a body that is not a JSON object is a validation error
+func (suite *PatientServiceTestSuite) TestValidatePatient_NotJSONObject() {
+	issues, err := suite.service.ValidatePatient(context.Background(), []byte(`[]`), nil)
+
+	assert.Nil(suite.T(), issues)
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+// matchCandidates returns stored patients for the match tests: the same person with the
+// same identifier, the same person with a misspelled given name, and someone else
+func matchCandidates() []*domain.Patient {
+	return []*domain.Patient{
+		{LogicalID: "3", FHIRData: []byte(`{"resourceType":"Patient","name":[{"family":"Smith","given":["Jane"]}],"gender":"female","birthDate":"1975-04-02"}`)},
+		{LogicalID: "2", FHIRData: []byte(`{"resourceType":"Patient","name":[{"family":"Doe","given":["Jon"]}],"gender":"male","birthDate":"1980-01-01"}`)},
+		{LogicalID: "1", FHIRData: []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"123"}],"name":[{"family":"Doe","given":["John"]}],"gender":"male","birthDate":"1980-01-01"}`)},
+	}
+}
+
+// matchInput returns the patient the match tests look for
+func matchInput() *fhir.Patient {
+	return &fhir.Patient{
+		Identifier: []fhir.Identifier{{System: utils.CreateStringPtr("urn:mrn"), Value: utils.CreateStringPtr("123")}},
+		Name:       []fhir.HumanName{{Family: utils.CreateStringPtr("Doe"), Given: []string{"John"}}},
+		Gender:     utils.GenderPtr("male"),
+		BirthDate:  utils.CreateStringPtr("1980-01-01"),
+	}
+}
+
+// TestMatchPatient_ScoresCandidates tests that candidates are graded, ordered by score and unlikely ones dropped
+func (suite *PatientServiceTestSuite) TestMatchPatient_ScoresCandidates() {
+	// Arrange
+	birthDate := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), domain.MatchCriteria{
+			BirthDate:      &birthDate,
+			FamilyPrefixes: []string{"do"},
+			GivenPrefixes:  []string{"jo"},
+			Identifiers:    []domain.PatientIdentifier{{System: "urn:mrn", Value: "123"}},
+		}, gomock.Any()).
+		Return(matchCandidates(), nil).
+		Times(1)
+
+	// Act
+	matches, err := suite.service.MatchPatient(context.Background(), matchInput(), false, 0)
+
+	// Assert
+	suite.Require().NoError(err)
+	suite.Require().Len(matches, 2)
+	assert.Equal(suite.T(), "1", matches[0].Patient.LogicalID)
+	assert.Equal(suite.T(), fhirmatch.GradeCertain, matches[0].Grade)
+	assert.Equal(suite.T(), 1.0, matches[0].Score)
+	assert.Equal(suite.T(), "2", matches[1].Patient.LogicalID)
+	assert.Equal(suite.T(), fhirmatch.GradeProbable, matches[1].Grade)
+	assert.InDelta(suite.T(), 0.968, matches[1].Score, 0.001)
+}
+
+// TestMatchPatient_OnlyCertainMatches tests that onlyCertainMatches and count limit the results
+func (suite *PatientServiceTestSuite) TestMatchPatient_OnlyCertainMatches() {
+	// Arrange
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(matchCandidates(), nil).
+		Times(2)
+
+	// Act
+	certain, err := suite.service.MatchPatient(context.Background(), matchInput(), true, 0)
+	suite.Require().NoError(err)
+	counted, err := suite.service.MatchPatient(context.Background(), matchInput(), false, 1)
+	suite.Require().NoError(err)
+
+	// Assert
+	suite.Require().Len(certain, 1)
+	assert.Equal(suite.T(), "1", certain[0].Patient.LogicalID)
+	suite.Require().Len(counted, 1)
+	assert.Equal(suite.T(), "1", counted[0].Patient.LogicalID)
+}
+
+// TestMatchPatient_RequiresCriteria tests that a patient without anything to match on is rejected
+func (suite *PatientServiceTestSuite) TestMatchPatient_RequiresCriteria() {
+	matches, err := suite.service.MatchPatient(context.Background(), &fhir.Patient{Gender: utils.GenderPtr("male")}, false, 0)
+
+	assert.Nil(suite.T(), matches)
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+// TestCreatePatient_ReportsDuplicates tests that probable duplicates are reported without preventing the create
+func (suite *PatientServiceTestSuite) TestCreatePatient_ReportsDuplicates() {
+	// Arrange
+	suite.mockRepo.EXPECT().
+		MatchCandidates(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(matchCandidates(), nil).
+		Times(1)
+	suite.mockIDs.EXPECT().
+		NextID(gomock.Any()).
+		Return("4", nil).
+		Times(1)
+	suite.mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	// Act
+	patient, err := suite.service.CreatePatient(context.Background(), matchInput())
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "4", patient.LogicalID)
+	suite.Require().Len(patient.Duplicates, 2)
+	assert.Equal(suite.T(), "1", patient.Duplicates[0].Patient.LogicalID)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirvalidation"
+	"go-fhir-demo/pkg/logger"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// ResourceServiceInterface defines the contract for generic resource service
+type ResourceServiceInterface interface {
+	CreateResource(ctx context.Context, resourceType string, data []byte) (*domain.Resource, error)
+	GetResource(ctx context.Context, resourceType, id string) (*domain.Resource, error)
+	SearchResources(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error)
+	UpdateResource(ctx context.Context, resourceType, id string, data []byte, expectedVersion int) (*domain.Resource, error)
+	DeleteResource(ctx context.Context, resourceType, id string, expectedVersion int) error
+	ConvertToFHIR(ctx context.Context, resource *domain.Resource) ([]byte, error)
+}
+
+type resourceService struct {
+	repo      domain.ResourceRepository
+	validator *fhirvalidation.Validator
+	ids       domain.IDGenerator
+}
+
+// NewResourceService creates a new service for the resource types of domain.ResourceDefinitions.
+// Resources are checked against their model and the validator before every write.
+func NewResourceService(repo domain.ResourceRepository, validator *fhirvalidation.Validator, ids domain.IDGenerator) ResourceServiceInterface {
+	return &resourceService{
+		repo:      repo,
+		validator: validator,
+		ids:       ids,
+	}
+}
+
+// CreateResource creates a new resource. Any id in the resource is replaced by a
+// server-assigned logical id.
+func (s *resourceService) CreateResource(ctx context.Context, resourceType string, data []byte) (*domain.Resource, error) {
+	document, err := s.validate(ctx, resourceType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	logicalID, err := s.ids.NextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := newResource(resourceType, logicalID, document)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// GetResource retrieves a resource by type and logical id
+func (s *resourceService) GetResource(ctx context.Context, resourceType, id string) (*domain.Resource, error) {
+	if _, err := definitionOf(resourceType); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByLogicalID(ctx, resourceType, id)
+}
+
+// SearchResources retrieves the resources of a type matching a FHIR search query
+func (s *resourceService) SearchResources(ctx context.Context, resourceType string, query *fhirsearch.Query) ([]*domain.Resource, int64, error) {
+	return s.repo.Search(ctx, resourceType, query)
+}
+
+// UpdateResource replaces a resource with a new version. A non-zero expectedVersion enforces optimistic concurrency.
+func (s *resourceService) UpdateResource(ctx context.Context, resourceType, id string, data []byte, expectedVersion int) (*domain.Resource, error) {
+	document, err := s.validate(ctx, resourceType, data)
+	if err != nil {
+		return nil, err
+	}
+	if bodyID, ok := document["id"].(string); ok && bodyID != id {
+		return nil, fmt.Errorf("%w: resource id %q does not match the requested id %q", domain.ErrValidation, bodyID, id)
+	}
+
+	existing, err := s.repo.GetByLogicalID(ctx, resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := newResource(resourceType, existing.LogicalID, document)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preserve ID and timestamps
+	updated.ID = existing.ID
+	updated.CreatedAt = existing.CreatedAt
+
+	if err := s.repo.Update(ctx, updated, expectedVersion); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteResource deletes a resource. A non-zero expectedVersion enforces optimistic concurrency.
+func (s *resourceService) DeleteResource(ctx context.Context, resourceType, id string, expectedVersion int) error {
+	resource, err := s.GetResource(ctx, resourceType, id)
+	if errors.Is(err, domain.ErrNotFound) && expectedVersion == 0 {
+		// Deleting a missing resource is a no-op
+		return nil
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, resource.ID, expectedVersion)
+}
+
+// ConvertToFHIR returns the stored FHIR JSON of a resource with meta.versionId and
+// meta.lastUpdated populated from the stored version
+func (s *resourceService) ConvertToFHIR(ctx context.Context, resource *domain.Resource) ([]byte, error) {
+	return resourceToFHIR(resource)
+}
+
+// resourceToFHIR returns the FHIR JSON of a stored resource with its version metadata
+func resourceToFHIR(resource *domain.Resource) ([]byte, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(resource.FHIRData, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal FHIR data: %w", err)
+	}
+	meta := resourceMeta(document)
+	if resource.VersionID > 0 {
+		meta["versionId"] = strconv.Itoa(resource.VersionID)
+	}
+	if !resource.UpdatedAt.IsZero() {
+		meta["lastUpdated"] = resource.UpdatedAt.UTC().Format(InstantFormat)
+	}
+	return json.Marshal(document)
+}
+
+// validate checks a resource about to be written against its model and the validator and
+// returns it as a JSON document
+func (s *resourceService) validate(ctx context.Context, resourceType string, data []byte) (map[string]interface{}, error) {
+	definition, err := definitionOf(resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	document, unknown, err := decodeResource(data, resourceType, definition.New())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+	var issues []fhir.OperationOutcomeIssue
+	for _, element := range unknown {
+		issues = append(issues, structureIssue(element, "Unknown element "+element))
+	}
+
+	profileIssues, err := s.validator.Validate(data)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, profileIssues...)
+	if fhirvalidation.HasErrors(issues) {
+		logger.WithContext(ctx).Warnf("Rejected invalid %s with %d issues", resourceType, len(issues))
+		return nil, &domain.ValidationError{Issues: issues}
+	}
+	return document, nil
+}
+
+// definitionOf returns the definition of a resource type served by the generic store
+func definitionOf(resourceType string) (domain.ResourceDefinition, error) {
+	definition, ok := domain.ResourceDefinitions[resourceType]
+	if !ok {
+		return domain.ResourceDefinition{}, fmt.Errorf("%w: resource type %s is not supported", domain.ErrValidation, resourceType)
+	}
+	return definition, nil
+}
+
+// newResource builds the stored form of a validated resource, setting its logical id and
+// stamping meta.lastUpdated
+func newResource(resourceType, logicalID string, document map[string]interface{}) (*domain.Resource, error) {
+	document["id"] = logicalID
+	resourceMeta(document)["lastUpdated"] = time.Now().UTC().Format(InstantFormat)
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal FHIR %s: %w", resourceType, err)
+	}
+	return &domain.Resource{
+		ResourceType: resourceType,
+		LogicalID:    logicalID,
+		FHIRData:     data,
+	}, nil
+}
+
+// resourceMeta returns the meta element of a resource document, adding it when missing
+func resourceMeta(document map[string]interface{}) map[string]interface{} {
+	meta, ok := document["meta"].(map[string]interface{})
+	if !ok {
+		meta = map[string]interface{}{}
+		document["meta"] = meta
+	}
+	return meta
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go-fhir-demo/internal/domain"
+	"go-fhir-demo/internal/domain/mocks"
+	"go-fhir-demo/pkg/fhirvalidation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// ResourceServiceTestSuite defines the test suite
+type ResourceServiceTestSuite struct {
+	suite.Suite
+	ctrl     *gomock.Controller
+	mockRepo *mocks.MockResourceRepository
+	mockIDs  *mocks.MockIDGenerator
+	service  ResourceServiceInterface
+}
+
+// SetupTest initializes the test suite before each test
+func (suite *ResourceServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockRepo = mocks.NewMockResourceRepository(suite.ctrl)
+	suite.mockIDs = mocks.NewMockIDGenerator(suite.ctrl)
+	suite.service = NewResourceService(suite.mockRepo, fhirvalidation.NewValidator(), suite.mockIDs)
+}
+
+// TearDownTest cleans up after each test
+func (suite *ResourceServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func TestResourceServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ResourceServiceTestSuite))
+}
+
+const testObservation = `{"resourceType":"Observation","id":"client-id","status":"final",
+	"code":{"coding":[{"system":"http://loinc.org","code":"8867-4"}]},"subject":{"reference":"Patient/1"}}`
+
+// TestCreateResource_AssignsID tests that a created resource gets a server-assigned id
+func (suite *ResourceServiceTestSuite) TestCreateResource_AssignsID() {
+	// Arrange
+	suite.mockIDs.EXPECT().NextID(gomock.Any()).Return("42", nil)
+	suite.mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	// Act
+	resource, err := suite.service.CreateResource(context.Background(), "Observation", []byte(testObservation))
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Observation", resource.ResourceType)
+	assert.Equal(suite.T(), "42", resource.LogicalID)
+	var document map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(resource.FHIRData, &document))
+	assert.Equal(suite.T(), "42", document["id"])
+	assert.NotEmpty(suite.T(), document["meta"].(map[string]interface{})["lastUpdated"])
+}
+
+// TestCreateResource_Invalid tests that resources breaking the base rules or model are rejected
+func (suite *ResourceServiceTestSuite) TestCreateResource_Invalid() {
+	tests := []struct {
+		name     string
+		body     string
+		expected func(err error) bool
+	}{
+		{
+			name:     "wrong resource type",
+			body:     `{"resourceType":"Encounter","status":"finished","class":{"code":"AMB"}}`,
+			expected: func(err error) bool { return errors.Is(err, domain.ErrValidation) },
+		},
+		{
+			name: "missing status",
+			body: `{"resourceType":"Observation","code":{"text":"Heart rate"}}`,
+			expected: func(err error) bool {
+				var validationErr *domain.ValidationError
+				return errors.As(err, &validationErr)
+			},
+		},
+		{
+			name: "unknown element",
+			body: `{"resourceType":"Observation","status":"final","code":{"text":"Heart rate"},"colour":"red"}`,
+			expected: func(err error) bool {
+				var validationErr *domain.ValidationError
+				return errors.As(err, &validationErr) && *validationErr.Issues[0].Diagnostics == "Unknown element Observation.colour"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := suite.service.CreateResource(context.Background(), "Observation", []byte(tt.body))
+			assert.True(suite.T(), tt.expected(err), "unexpected error: %v", err)
+		})
+	}
+}
+
+// TestUpdateResource_PreservesIdentity tests that an update keeps the row id and creation time
+func (suite *ResourceServiceTestSuite) TestUpdateResource_PreservesIdentity() {
+	// Arrange
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	existing := &domain.Resource{ID: 7, ResourceType: "Observation", LogicalID: "client-id", VersionID: 2, CreatedAt: created}
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "Observation", "client-id").Return(existing, nil)
+	suite.mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any(), 2).
+		DoAndReturn(func(ctx context.Context, resource *domain.Resource, expectedVersion int) error {
+			assert.Equal(suite.T(), uint(7), resource.ID)
+			assert.Equal(suite.T(), created, resource.CreatedAt)
+			return nil
+		})
+
+	// Act
+	_, err := suite.service.UpdateResource(context.Background(), "Observation", "client-id", []byte(testObservation), 2)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+}
+
+// TestUpdateResource_IDMismatch tests that the resource id must match the requested id
+func (suite *ResourceServiceTestSuite) TestUpdateResource_IDMismatch() {
+	_, err := suite.service.UpdateResource(context.Background(), "Observation", "other", []byte(testObservation), 0)
+	assert.ErrorIs(suite.T(), err, domain.ErrValidation)
+}
+
+// TestDeleteResource_Missing tests that deleting a missing resource is a no-op without a version precondition
+func (suite *ResourceServiceTestSuite) TestDeleteResource_Missing() {
+	suite.mockRepo.EXPECT().GetByLogicalID(gomock.Any(), "Practitioner", "9").Return(nil, domain.ErrNotFound).Times(2)
+
+	assert.NoError(suite.T(), suite.service.DeleteResource(context.Background(), "Practitioner", "9", 0))
+	assert.ErrorIs(suite.T(), suite.service.DeleteResource(context.Background(), "Practitioner", "9", 1), domain.ErrVersionConflict)
+}
+
+// TestConvertToFHIR_SetsVersionMeta tests that the stored version is reported in meta
+func (suite *ResourceServiceTestSuite) TestConvertToFHIR_SetsVersionMeta() {
+	resource := &domain.Resource{
+		FHIRData:  []byte(`{"resourceType":"Organization","id":"3","name":"Acme"}`),
+		VersionID: 4,
+		UpdatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+	}
+
+	data, err := suite.service.ConvertToFHIR(context.Background(), resource)
+
+	suite.Require().NoError(err)
+	assert.JSONEq(suite.T(), `{"resourceType":"Organization","id":"3","name":"Acme",
+		"meta":{"versionId":"4","lastUpdated":"2024-05-06T07:08:09.000Z"}}`, string(data))
+}
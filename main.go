@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -20,6 +21,8 @@ import (
 	"go-fhir-demo/pkg/cache"
 	"go-fhir-demo/pkg/database"
 	"go-fhir-demo/pkg/fhirclient" // Import the new fhirclient package
+	"go-fhir-demo/pkg/fhirsearch"
+	"go-fhir-demo/pkg/fhirvalidation"
 	"go-fhir-demo/pkg/logger"
 	"go-fhir-demo/pkg/utils"
 	"go-fhir-demo/pkg/utils/consul"
@@ -107,16 +110,74 @@ func main() {
 
 	// Auto-migrate the database schema
 	db := database.GetDB()
-	if err := db.AutoMigrate(&domain.Patient{}); err != nil {
+	if err := db.AutoMigrate(&domain.Patient{}, &domain.PatientHistory{}, &domain.PatientIdentifier{}, &domain.PatientMerge{}, &domain.Resource{}, &domain.ResourceSearchValue{}, &domain.BulkJob{}); err != nil {
+		logger.Errorf("Failed to migrate database: %v", err)
+		os.Exit(1)
+	}
+	if err := repository.MigrateLogicalIDs(db); err != nil {
+		logger.Errorf("Failed to migrate database: %v", err)
+		os.Exit(1)
+	}
+	if err := repository.MigrateIdentifiers(db); err != nil {
+		logger.Errorf("Failed to migrate database: %v", err)
+		os.Exit(1)
+	}
+	if err := repository.MigrateSortIndexes(db); err != nil {
+		logger.Errorf("Failed to migrate database: %v", err)
+		os.Exit(1)
+	}
+	if err := repository.MigrateSearchText(db); err != nil {
 		logger.Errorf("Failed to migrate database: %v", err)
 		os.Exit(1)
 	}
 
 	// Initialize repositories
-	patientRepo := repository.NewPatientRepository(db)
+	patientRepo := repository.NewPatientRepository(db, cfg.Identifier.UniqueSystems)
+	resourceRepo := repository.NewResourceRepository(db)
+	mergeRepo := repository.NewMergeRepository(db, cfg.Identifier.UniqueSystems)
+	bulkRepo := repository.NewBulkRepository(db)
+
+	// Load validation profiles
+	profiles, err := fhirvalidation.LoadProfiles(cfg.Validation.Directory)
+	if err != nil {
+		logger.Errorf("Failed to load validation profiles: %v", err)
+		os.Exit(1)
+	}
+	validator := fhirvalidation.NewValidator(profiles...)
+	if err := validator.Enforce(cfg.Validation.Profiles...); err != nil {
+		logger.Errorf("Failed to enforce validation profiles: %v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Loaded %d validation profiles, enforcing %d", len(profiles), len(cfg.Validation.Profiles))
+
+	// Initialize the logical id generator
+	idGenerator, err := repository.NewIDGenerator(db, cfg.FHIR.IDs)
+	if err != nil {
+		logger.Errorf("Failed to initialize logical id generator: %v", err)
+		os.Exit(1)
+	}
 
 	// Initialize services
-	patientService := service.NewPatientService(patientRepo)
+	patientService := service.NewPatientService(patientRepo, validator, idGenerator)
+	bundleService := service.NewBundleService(patientRepo, validator, idGenerator)
+	resourceService := service.NewResourceService(resourceRepo, validator, idGenerator)
+	compartmentService := service.NewCompartmentService(patientRepo, resourceRepo)
+	mergeRules := domain.MergeRules{
+		Identifier: domain.MergeRule(cfg.Merge.Identifier),
+		Name:       domain.MergeRule(cfg.Merge.Name),
+		Telecom:    domain.MergeRule(cfg.Merge.Telecom),
+		Address:    domain.MergeRule(cfg.Merge.Address),
+	}
+	if err := mergeRules.Validate(); err != nil {
+		logger.Errorf("Invalid merge configuration: %v", err)
+		os.Exit(1)
+	}
+	mergeService := service.NewMergeService(patientRepo, mergeRepo, validator, idGenerator, mergeRules)
+	bulkService := service.NewBulkService(bulkRepo, patientRepo, patientService, cfg.Bulk.Directory, cfg.Bulk.ImportDirectory)
+	if err := bulkService.FailInterruptedJobs(context.Background()); err != nil {
+		logger.Errorf("Failed to fail interrupted bulk data jobs: %v", err)
+		os.Exit(1)
+	}
 
 	// Initialize FHIR client
 	fhirClient := fhirclient.NewClient(cfg.Server.ExternalFHIRServerBaseURL)
@@ -227,36 +288,38 @@ func main() {
 	}
 
 	for _, dummy := range dummyPatients {
-		// Check if patient exists by unique fields (family, given, birthdate, gender)
-		var count int64
-		db.Model(&domain.Patient{}).
-			Where("family = ? AND given = ? AND gender = ? AND birth_date = ?",
-				*dummy.Name[0].Family,
-				func() string {
-					if len(dummy.Name[0].Given) > 0 {
-						return dummy.Name[0].Given[0]
-					} else {
-						return ""
-					}
-				}(),
-				dummy.Gender,
-				*dummy.BirthDate,
-			).Count(&count)
-		if count == 0 {
-			ctx := context.Background()
-			_, err := patientService.CreatePatient(ctx, &dummy)
-			if err != nil {
-				logger.Warnf("Failed to seed dummy patient: %v", err)
-			} else {
-				logger.Infof("Seeded dummy patient: %s %s", dummy.Name[0].Given[0], *dummy.Name[0].Family)
-			}
+		// Conditionally create on unique fields (family, given, birthdate, gender)
+		criteria, err := fhirsearch.Parse(url.Values{
+			"family:exact": {*dummy.Name[0].Family},
+			"given:exact":  {dummy.Name[0].Given[0]},
+			"gender":       {dummy.Gender.String()},
+			"birthdate":    {*dummy.BirthDate},
+		}, domain.PatientSearchParameters)
+		if err != nil {
+			logger.Warnf("Invalid seed criteria: %v", err)
+			continue
+		}
+		ctx := context.Background()
+		_, created, err := patientService.ConditionalCreatePatient(ctx, &dummy, criteria)
+		if err != nil {
+			logger.Warnf("Failed to seed dummy patient: %v", err)
+		} else if created {
+			logger.Infof("Seeded dummy patient: %s %s", dummy.Name[0].Given[0], *dummy.Name[0].Family)
 		}
 	}
 
 	// --- End seed logic ---
 
 	// Initialize handlers
-	patientHandler := handlers.NewPatientHandler(patientService)
+	patientHandler := handlers.NewPatientHandler(patientService, compartmentService)
+	bundleHandler := handlers.NewBundleHandler(bundleService, patientService)
+	resourceHandlers := make([]handlers.ResourceHandlerInterface, 0, len(domain.ResourceDefinitions))
+	for _, resourceType := range domain.ResourceTypes() {
+		resourceHandlers = append(resourceHandlers, handlers.NewResourceHandler(resourceType, resourceService))
+	}
+	compartmentHandler := handlers.NewCompartmentHandler(compartmentService)
+	mergeHandler := handlers.NewMergeHandler(mergeService, patientService)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
 	externalPatientHandler := handlers.NewExternalPatientHandler(externalPatientService)
 	cronJobHandler := cron.NewCronJobHandler() // or nil if not used
 	consulHandler := handlers.NewConsulHandler(&cfg.Consul)
@@ -264,7 +327,7 @@ func main() {
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 	// Setup routes (pass consulHandler)
-	router := routes.SetupRoutes(patientHandler, externalPatientHandler, cronJobHandler, consulHandler)
+	router := routes.SetupRoutes(patientHandler, bundleHandler, resourceHandlers, compartmentHandler, mergeHandler, bulkHandler, externalPatientHandler, cronJobHandler, consulHandler)
 
 	// Add OpenTelemetry middleware
 	if cfg.Jaeger.Enabled {
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_patient_history_created_at;
+DROP INDEX IF EXISTS idx_patient_history_version;
+DROP TABLE IF EXISTS patient_history;
+ALTER TABLE patients DROP COLUMN IF EXISTS version_id;
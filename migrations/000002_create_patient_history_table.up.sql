@@ -0,0 +1,19 @@
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS version_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS patient_history (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL,
+    version_id INTEGER NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    fhir_data JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Each patient version is recorded exactly once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patient_history_version ON patient_history(patient_id, version_id);
+CREATE INDEX IF NOT EXISTS idx_patient_history_created_at ON patient_history(created_at);
+
+-- Seed the history with the current state of existing patients
+INSERT INTO patient_history (patient_id, version_id, method, fhir_data, created_at)
+SELECT id, version_id, 'POST', fhir_data, updated_at FROM patients
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP SEQUENCE IF EXISTS patient_logical_id_seq;
+DROP INDEX IF EXISTS idx_patient_history_logical_id;
+DROP INDEX IF EXISTS idx_patients_logical_id;
+ALTER TABLE patient_history DROP COLUMN IF EXISTS logical_id;
+ALTER TABLE patients DROP COLUMN IF EXISTS logical_id;
@@ -0,0 +1,20 @@
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS logical_id VARCHAR(64);
+ALTER TABLE patient_history ADD COLUMN IF NOT EXISTS logical_id VARCHAR(64);
+
+-- Existing patients keep their numeric id as logical id, embedded in the stored resource
+UPDATE patients
+SET logical_id = id::text, fhir_data = jsonb_set(fhir_data, '{id}', to_jsonb(id::text))
+WHERE logical_id IS NULL;
+
+UPDATE patient_history AS h
+SET logical_id = p.logical_id,
+    fhir_data = CASE WHEN h.fhir_data IS NULL THEN NULL ELSE jsonb_set(h.fhir_data, '{id}', to_jsonb(p.logical_id)) END
+FROM patients AS p
+WHERE h.patient_id = p.id AND h.logical_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_logical_id ON patients(logical_id);
+CREATE INDEX IF NOT EXISTS idx_patient_history_logical_id ON patient_history(logical_id);
+
+-- Sequential logical ids start after every existing patient
+CREATE SEQUENCE IF NOT EXISTS patient_logical_id_seq;
+SELECT setval('patient_logical_id_seq', COALESCE((SELECT MAX(id) FROM patients), 0) + 1, false);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resource_search_values;
+DROP TABLE IF EXISTS resources;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS resources (
+    id SERIAL PRIMARY KEY,
+    resource_type VARCHAR(64) NOT NULL,
+    logical_id VARCHAR(64) NOT NULL,
+    fhir_data JSONB NOT NULL,
+    version_id INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_resources_type_logical_id ON resources(resource_type, logical_id);
+CREATE INDEX IF NOT EXISTS idx_resources_deleted_at ON resources(deleted_at);
+
+-- Search parameter values extracted from each stored resource
+CREATE TABLE IF NOT EXISTS resource_search_values (
+    id SERIAL PRIMARY KEY,
+    resource_id INTEGER NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    system TEXT,
+    value TEXT,
+    date_start TIMESTAMP WITH TIME ZONE,
+    date_end TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_search_values_resource_id ON resource_search_values(resource_id);
+CREATE INDEX IF NOT EXISTS idx_resource_search_values_lookup ON resource_search_values(name, value);
@@ -0,0 +1,19 @@
+-- Identifiers of each stored patient, kept in sync with Patient.identifier on every write
+CREATE TABLE IF NOT EXISTS patient_identifiers (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL,
+    system TEXT NOT NULL DEFAULT '',
+    value TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_identifiers_patient_id ON patient_identifiers(patient_id);
+CREATE INDEX IF NOT EXISTS idx_patient_identifiers_lookup ON patient_identifiers(system, value);
+
+-- Index the identifiers of existing patients
+INSERT INTO patient_identifiers (patient_id, system, value)
+SELECT DISTINCT p.id, COALESCE(i->>'system', ''), i->>'value'
+FROM patients AS p
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(p.fhir_data->'identifier') = 'array' THEN p.fhir_data->'identifier' ELSE '[]'::jsonb END
+) AS i
+WHERE p.deleted_at IS NULL AND COALESCE(i->>'value', '') <> '';
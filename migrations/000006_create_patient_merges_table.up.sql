@@ -0,0 +1,15 @@
+-- Merges of duplicate patients, with the state needed to reverse them
+CREATE TABLE IF NOT EXISTS patient_merges (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    source_data JSONB NOT NULL,
+    target_data JSONB NOT NULL,
+    "references" JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    unmerged_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_merges_source_id ON patient_merges(source_id);
+CREATE INDEX IF NOT EXISTS idx_patient_merges_target_id ON patient_merges(target_id);
+CREATE INDEX IF NOT EXISTS idx_patient_merges_unmerged_at ON patient_merges(unmerged_at);
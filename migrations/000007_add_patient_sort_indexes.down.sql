@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_patients_sort_birth_date;
+DROP INDEX IF EXISTS idx_patients_sort_given;
+DROP INDEX IF EXISTS idx_patients_sort_family;
+DROP INDEX IF EXISTS idx_patients_sort_updated_at;
+DROP INDEX IF EXISTS idx_patients_sort_logical_id;
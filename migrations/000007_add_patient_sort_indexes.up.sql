@@ -0,0 +1,7 @@
+-- Indexes serving each single-key _sort, so keyset pages start at the cursor instead of
+-- scanning the rows before it. The expressions must match those the repository sorts by.
+CREATE INDEX IF NOT EXISTS idx_patients_sort_logical_id ON patients ((logical_id), id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_patients_sort_updated_at ON patients ((updated_at), id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_patients_sort_family ON patients ((COALESCE(family, '')), id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_patients_sort_given ON patients ((COALESCE(given, '')), id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_patients_sort_birth_date ON patients ((COALESCE(birth_date, '9999-12-31 00:00:00+00')), id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_patients_search_text;
+ALTER TABLE patients DROP COLUMN IF EXISTS search_text;
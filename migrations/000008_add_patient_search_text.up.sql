@@ -0,0 +1,35 @@
+-- Full-text index behind _content and _text. search_text is generated from the patient JSON, so
+-- every write keeps it in sync: names weigh A, telecom and addresses B, contacts and notes C and
+-- the narrative D. Separators are blanked so fragments of emails and phone numbers match.
+-- The expression must match patientSearchTextExpr, which a repository test checks.
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS search_text tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', translate(
+            jsonb_path_query_array(fhir_data, '$.name[*].text')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.name[*].family')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.name[*].given[*]')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.name[*].prefix[*]')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.name[*].suffix[*]')::text,
+            '@.-_/:+', '       ')), 'A')
+        || setweight(to_tsvector('simple', translate(
+            jsonb_path_query_array(fhir_data, '$.telecom[*].value')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].text')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].line[*]')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].city')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].district')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].state')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].postalCode')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.address[*].country')::text,
+            '@.-_/:+', '       ')), 'B')
+        || setweight(to_tsvector('simple', translate(
+            jsonb_path_query_array(fhir_data, '$.contact[*].name.text')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.contact[*].name.family')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.contact[*].name.given[*]')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.contact[*].telecom[*].value')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.extension[*].valueString')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.extension[*].valueMarkdown')::text
+            || ' ' || jsonb_path_query_array(fhir_data, '$.extension[*].valueAnnotation.text')::text,
+            '@.-_/:+', '       ')), 'C')
+        || setweight(to_tsvector('simple', COALESCE(fhir_data #>> '{text,div}', '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_patients_search_text ON patients USING GIN (search_text);
@@ -0,0 +1,15 @@
+-- Asynchronous bulk data jobs; the NDJSON files they write are kept on local disk
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    request TEXT NOT NULL,
+    progress TEXT,
+    output JSONB,
+    error TEXT,
+    transaction_time TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_status ON bulk_jobs(status);
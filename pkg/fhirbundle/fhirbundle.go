@@ -0,0 +1,149 @@
+package fhirbundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-fhir-demo/pkg/fhirsearch"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// pagingParams are query parameters replaced by the page token in generated links
+var pagingParams = []string{fhirsearch.PageTokenParam, "limit", "offset"}
+
+// NewSearchSet creates a searchset Bundle with the given total, entries and links. A negative
+// total, for matches that were not counted, leaves Bundle.total out.
+func NewSearchSet(total int64, entries []fhir.BundleEntry, links []fhir.BundleLink) *fhir.Bundle {
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	bundle := &fhir.Bundle{
+		Type:      fhir.BundleTypeSearchset,
+		Timestamp: &timestamp,
+		Link:      links,
+		Entry:     entries,
+	}
+	if total >= 0 {
+		count := int(total)
+		bundle.Total = &count
+	}
+	return bundle
+}
+
+// NewHistory creates a history Bundle with the given total, entries and links
+func NewHistory(total int64, entries []fhir.BundleEntry, links []fhir.BundleLink) *fhir.Bundle {
+	bundle := NewSearchSet(total, entries, links)
+	bundle.Type = fhir.BundleTypeHistory
+	return bundle
+}
+
+// NewHistoryEntry creates a history Bundle entry. resource is nil for deletions.
+func NewHistoryEntry(fullURL string, resource interface{}, method, requestURL, status, etag string, lastModified time.Time) (fhir.BundleEntry, error) {
+	var verb fhir.HTTPVerb
+	if err := verb.UnmarshalJSON([]byte(`"` + method + `"`)); err != nil {
+		return fhir.BundleEntry{}, fmt.Errorf("invalid history method %q: %w", method, err)
+	}
+	modified := lastModified.UTC().Format(time.RFC3339)
+	entry := fhir.BundleEntry{
+		FullUrl: &fullURL,
+		Request: &fhir.BundleEntryRequest{Method: verb, Url: requestURL},
+		Response: &fhir.BundleEntryResponse{
+			Status:       status,
+			Etag:         &etag,
+			LastModified: &modified,
+		},
+	}
+	if resource != nil {
+		raw, err := json.Marshal(resource)
+		if err != nil {
+			return fhir.BundleEntry{}, fmt.Errorf("failed to marshal bundle entry resource: %w", err)
+		}
+		entry.Resource = raw
+	}
+	return entry, nil
+}
+
+// NewEntry creates a Bundle entry for a resource with the given search mode
+func NewEntry(fullURL string, resource interface{}, mode fhir.SearchEntryMode) (fhir.BundleEntry, error) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return fhir.BundleEntry{}, fmt.Errorf("failed to marshal bundle entry resource: %w", err)
+	}
+	return fhir.BundleEntry{
+		FullUrl:  &fullURL,
+		Resource: raw,
+		Search:   &fhir.BundleEntrySearch{Mode: &mode},
+	}, nil
+}
+
+// PageLinks builds the self, first, previous, next and last links for a page of results.
+// pageURL is the absolute URL of the search endpoint without a query string.
+func PageLinks(pageURL string, params url.Values, total int64, count, offset int) []fhir.BundleLink {
+	link := func(relation string, pageOffset int) fhir.BundleLink {
+		token := ""
+		if pageOffset > 0 {
+			token = fhirsearch.EncodePageToken(pageOffset)
+		}
+		return pageLink(pageURL, params, relation, count, token)
+	}
+
+	links := []fhir.BundleLink{link("self", offset)}
+	if count <= 0 {
+		return links
+	}
+
+	links = append(links, link("first", 0))
+	if offset > 0 {
+		previous := offset - count
+		if previous < 0 {
+			previous = 0
+		}
+		links = append(links, link("previous", previous))
+	}
+	if int64(offset+count) < total {
+		links = append(links, link("next", offset+count))
+	}
+	if total > 0 {
+		links = append(links, link("last", int((total-1)/int64(count))*count))
+	}
+	return links
+}
+
+// CursorLinks builds the self, first, previous, next and last links for a page of results
+// paged with keyset cursors. self is the page token of the current page, previous and next
+// are nil when there is no such page, and last is nil when there are no results.
+func CursorLinks(pageURL string, params url.Values, count int, self string, previous, next, last *fhirsearch.Cursor) []fhir.BundleLink {
+	links := []fhir.BundleLink{pageLink(pageURL, params, "self", count, self)}
+	if count <= 0 {
+		return links
+	}
+
+	links = append(links, pageLink(pageURL, params, "first", count, ""))
+	for _, page := range []struct {
+		relation string
+		cursor   *fhirsearch.Cursor
+	}{{"previous", previous}, {"next", next}, {"last", last}} {
+		if page.cursor != nil {
+			links = append(links, pageLink(pageURL, params, page.relation, count, fhirsearch.EncodeCursor(*page.cursor)))
+		}
+	}
+	return links
+}
+
+// pageLink builds a paging link carrying the search parameters, the page size and a page token
+func pageLink(pageURL string, params url.Values, relation string, count int, token string) fhir.BundleLink {
+	values := url.Values{}
+	for key, v := range params {
+		values[key] = v
+	}
+	for _, key := range pagingParams {
+		values.Del(key)
+	}
+	values.Set("_count", strconv.Itoa(count))
+	if token != "" {
+		values.Set(fhirsearch.PageTokenParam, token)
+	}
+	return fhir.BundleLink{Relation: relation, Url: pageURL + "?" + values.Encode()}
+}
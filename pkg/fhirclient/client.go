@@ -18,6 +18,17 @@ type ClientInterface interface {
 	GetPatientByID(ctx context.Context, id string) (*fhir.Patient, error)
 	SearchPatients(ctx context.Context, queryParams map[string]string) (*fhir.Bundle, error)
 	CreatePatient(ctx context.Context, patient *fhir.Patient) (*fhir.Patient, error)
+	PatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error)
+}
+
+// StatusError is returned when the FHIR server answers with an unexpected HTTP status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("fhir server returned status %d: %s", e.StatusCode, e.Body)
 }
 
 // Client is a client for interacting with a FHIR server.
@@ -53,7 +64,7 @@ func (c *Client) GetPatientByID(ctx context.Context, id string) (*fhir.Patient,
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("fhir server returned non-OK status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var patient fhir.Patient
@@ -92,7 +103,7 @@ func (c *Client) SearchPatients(ctx context.Context, queryParams map[string]stri
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("fhir server returned non-OK status for search %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("search failed: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)})
 	}
 
 	var bundle fhir.Bundle
@@ -125,7 +136,7 @@ func (c *Client) CreatePatient(ctx context.Context, patient *fhir.Patient) (*fhi
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("fhir server returned non-success status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("create failed: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)})
 	}
 
 	var createdPatient fhir.Patient
@@ -135,3 +146,35 @@ func (c *Client) CreatePatient(ctx context.Context, patient *fhir.Patient) (*fhi
 
 	return &createdPatient, nil
 }
+
+// PatientEverything invokes the Patient/$everything operation for a patient.
+// It returns the searchset Bundle of the patient's compartment as sent by the server.
+func (c *Client) PatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error) {
+	reqURL := fmt.Sprintf("%s/Patient/%s/$everything", c.BaseURL, url.PathEscape(id))
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create $everything request: %w", err)
+	}
+	req.Header.Set("Accept", "application/fhir+json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute $everything request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("$everything failed: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)})
+	}
+
+	var bundle fhir.Bundle
+	if err := json.NewDecoder(resp.Body).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle response: %w", err)
+	}
+
+	return &bundle, nil
+}
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	context "context"
+	url "net/url"
 	reflect "reflect"
 
 	fhir "github.com/samply/golang-fhir-models/fhir-models/fhir"
@@ -71,6 +72,21 @@ func (mr *MockClientInterfaceMockRecorder) GetPatientByID(ctx, id any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientByID", reflect.TypeOf((*MockClientInterface)(nil).GetPatientByID), ctx, id)
 }
 
+// PatientEverything mocks base method.
+func (m *MockClientInterface) PatientEverything(ctx context.Context, id string, params url.Values) (*fhir.Bundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatientEverything", ctx, id, params)
+	ret0, _ := ret[0].(*fhir.Bundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatientEverything indicates an expected call of PatientEverything.
+func (mr *MockClientInterfaceMockRecorder) PatientEverything(ctx, id, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatientEverything", reflect.TypeOf((*MockClientInterface)(nil).PatientEverything), ctx, id, params)
+}
+
 // SearchPatients mocks base method.
 func (m *MockClientInterface) SearchPatients(ctx context.Context, queryParams map[string]string) (*fhir.Bundle, error) {
 	m.ctrl.T.Helper()
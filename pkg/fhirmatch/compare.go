@@ -0,0 +1,147 @@
+package fhirmatch
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// phoneticSimilarity is the similarity of different spellings that sound alike
+const phoneticSimilarity = 0.85
+
+// minimumSimilarity is the edit-distance similarity below which names count as different
+const minimumSimilarity = 0.6
+
+// nameSimilarity compares two names ignoring case, accents and punctuation. Names that
+// sound alike score at least phoneticSimilarity; otherwise the edit distance decides.
+func nameSimilarity(a, b string) float64 {
+	a, b = normalizeName(a), normalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	similarity := editSimilarity(a, b)
+	if Soundex(a) == Soundex(b) {
+		similarity = math.Max(similarity, phoneticSimilarity)
+	}
+	if similarity < minimumSimilarity {
+		return 0
+	}
+	return similarity
+}
+
+// textSimilarity compares free text such as address lines by edit distance
+func textSimilarity(a, b string) float64 {
+	a, b = strings.Join(strings.Fields(strings.ToLower(a)), " "), strings.Join(strings.Fields(strings.ToLower(b)), " ")
+	if a == "" || b == "" {
+		return 0
+	}
+	similarity := editSimilarity(a, b)
+	if similarity < minimumSimilarity {
+		return 0
+	}
+	return similarity
+}
+
+// editSimilarity is one minus the edit distance relative to the longer string
+func editSimilarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein returns the number of single character insertions, deletions and
+// substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Soundex returns the American Soundex code of a name, e.g. R163 for both Robert and Rupert
+func Soundex(name string) string {
+	codes := map[rune]byte{
+		'b': '1', 'f': '1', 'p': '1', 'v': '1',
+		'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+		'd': '3', 't': '3',
+		'l': '4',
+		'm': '5', 'n': '5',
+		'r': '6',
+	}
+
+	name = normalizeName(name)
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	code := []byte{byte(unicode.ToUpper(runes[0]))}
+	last := codes[runes[0]]
+	for _, r := range runes[1:] {
+		digit, ok := codes[r]
+		switch {
+		case ok && digit != last:
+			code = append(code, digit)
+			last = digit
+		case r == 'h' || r == 'w':
+			// h and w do not separate letters with the same code
+		default:
+			last = digit
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// normalizeName lowercases a name and drops accents and everything but letters
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		if r <= unicode.MaxASCII && unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// normalizeCode uppercases a code such as a postal code and drops spaces and dashes
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// digitsOf returns the last ten digits of a phone number, ignoring formatting and country prefixes
+func digitsOf(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
@@ -0,0 +1,243 @@
+package fhirmatch
+
+import (
+	"math"
+	"strings"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+// Grade is a code of the FHIR match-grade value set
+type Grade string
+
+const (
+	GradeCertain      Grade = "certain"
+	GradeProbable     Grade = "probable"
+	GradePossible     Grade = "possible"
+	GradeCertainlyNot Grade = "certainly-not"
+)
+
+// GradeExtensionURL is the extension that carries the grade of a $match result entry
+const GradeExtensionURL = "http://hl7.org/fhir/StructureDefinition/match-grade"
+
+const (
+	// CertainScore is the lowest score graded certain, given corroborating evidence
+	CertainScore = 0.95
+	// ProbableScore is the lowest score graded probable
+	ProbableScore = 0.8
+	// PossibleScore is the lowest score graded possible
+	PossibleScore = 0.6
+)
+
+// Field weights. Family, given, birth date and gender are always weighed, so a patient
+// described only by a name cannot score highly; the other fields count when the patient
+// being matched has them, and identifiers only when both patients have one in the same system.
+const (
+	weightFamily     = 0.25
+	weightGiven      = 0.15
+	weightBirthDate  = 0.25
+	weightGender     = 0.05
+	weightTelecom    = 0.1
+	weightAddress    = 0.1
+	weightIdentifier = 0.3
+)
+
+// Result is the outcome of comparing a candidate patient with the patient being matched
+type Result struct {
+	Score float64 // Weighted agreement between 0 and 1
+	Grade Grade
+}
+
+// Compare scores how likely candidate is the same person as patient. The score is the
+// weighted agreement of name, birth date, gender, telecom, address and identifiers. A
+// shared identifier, telecom or address is needed to grade a match certain, and an
+// identifier conflict in a shared system keeps a match from grading above possible.
+func Compare(patient, candidate *fhir.Patient) Result {
+	var total, agreement float64
+	weigh := func(weight, similarity float64) {
+		total += weight
+		agreement += weight * similarity
+	}
+
+	weigh(weightFamily, bestSimilarity(families(patient), families(candidate), nameSimilarity))
+	weigh(weightGiven, bestSimilarity(givens(patient), givens(candidate), nameSimilarity))
+	weigh(weightBirthDate, birthDateSimilarity(patient.BirthDate, candidate.BirthDate))
+	weigh(weightGender, genderSimilarity(patient.Gender, candidate.Gender))
+
+	corroborated := false
+	if telecoms := telecomValues(patient); len(telecoms) > 0 {
+		similarity := bestSimilarity(telecoms, telecomValues(candidate), exactSimilarity)
+		weigh(weightTelecom, similarity)
+		corroborated = corroborated || similarity == 1
+	}
+	if len(patient.Address) > 0 {
+		similarity := 0.0
+		for _, address := range patient.Address {
+			for _, other := range candidate.Address {
+				similarity = math.Max(similarity, addressSimilarity(address, other))
+			}
+		}
+		weigh(weightAddress, similarity)
+		corroborated = corroborated || similarity >= ProbableScore
+	}
+	identifierMatch, identifierConflict := compareIdentifiers(patient.Identifier, candidate.Identifier)
+	if identifierMatch || identifierConflict {
+		weigh(weightIdentifier, boolSimilarity(identifierMatch))
+	}
+
+	score := math.Round(agreement/total*1000) / 1000
+	return Result{Score: score, Grade: grade(score, identifierMatch, identifierConflict, corroborated)}
+}
+
+// grade maps a score to a match grade, taking the corroborating evidence into account
+func grade(score float64, identifierMatch, identifierConflict, corroborated bool) Grade {
+	switch {
+	case identifierConflict && !identifierMatch:
+		if score >= PossibleScore {
+			return GradePossible
+		}
+		return GradeCertainlyNot
+	case identifierMatch && score >= ProbableScore, score >= CertainScore && corroborated:
+		return GradeCertain
+	case score >= ProbableScore:
+		return GradeProbable
+	case score >= PossibleScore:
+		return GradePossible
+	default:
+		return GradeCertainlyNot
+	}
+}
+
+// compareIdentifiers reports whether two patients share an identifier, and whether they
+// hold different values in a system they both have identifiers in
+func compareIdentifiers(identifiers, others []fhir.Identifier) (match, conflict bool) {
+	for _, identifier := range identifiers {
+		if identifier.System == nil || identifier.Value == nil {
+			continue
+		}
+		for _, other := range others {
+			if other.System == nil || other.Value == nil || *other.System != *identifier.System {
+				continue
+			}
+			if *other.Value == *identifier.Value {
+				match = true
+			} else {
+				conflict = true
+			}
+		}
+	}
+	return match, conflict
+}
+
+// families returns the family names of a patient
+func families(patient *fhir.Patient) []string {
+	var names []string
+	for _, name := range patient.Name {
+		if name.Family != nil {
+			names = append(names, *name.Family)
+		}
+	}
+	return names
+}
+
+// givens returns the given names of a patient
+func givens(patient *fhir.Patient) []string {
+	var names []string
+	for _, name := range patient.Name {
+		names = append(names, name.Given...)
+	}
+	return names
+}
+
+// telecomValues returns the normalized phone numbers and email addresses of a patient
+func telecomValues(patient *fhir.Patient) []string {
+	var values []string
+	for _, telecom := range patient.Telecom {
+		if telecom.Value == nil {
+			continue
+		}
+		if telecom.System != nil && *telecom.System == fhir.ContactPointSystemEmail {
+			values = append(values, strings.ToLower(strings.TrimSpace(*telecom.Value)))
+			continue
+		}
+		if digits := digitsOf(*telecom.Value); digits != "" {
+			values = append(values, digits)
+		}
+	}
+	return values
+}
+
+// birthDateSimilarity compares two dates of birth. Dates one typing mistake apart, or with
+// the day and month swapped, partially agree.
+func birthDateSimilarity(birthDate, other *string) float64 {
+	if birthDate == nil || other == nil {
+		return 0
+	}
+	if *birthDate == *other {
+		return 1
+	}
+	if len(*birthDate) == 10 && len(*other) == 10 {
+		swapped := (*other)[:5] + (*other)[8:10] + "-" + (*other)[5:7]
+		if *birthDate == swapped || levenshtein(*birthDate, *other) == 1 {
+			return 0.6
+		}
+	}
+	return 0
+}
+
+// genderSimilarity compares two administrative genders
+func genderSimilarity(gender, other *fhir.AdministrativeGender) float64 {
+	return boolSimilarity(gender != nil && other != nil && *gender == *other)
+}
+
+// addressSimilarity compares the postal code, city and first line of two addresses,
+// averaging the parts present in the first
+func addressSimilarity(address, other fhir.Address) float64 {
+	var parts, agreement float64
+	if address.PostalCode != nil {
+		parts++
+		if other.PostalCode != nil && normalizeCode(*address.PostalCode) == normalizeCode(*other.PostalCode) {
+			agreement++
+		}
+	}
+	if address.City != nil {
+		parts++
+		if other.City != nil {
+			agreement += nameSimilarity(*address.City, *other.City)
+		}
+	}
+	if len(address.Line) > 0 {
+		parts++
+		if len(other.Line) > 0 {
+			agreement += textSimilarity(address.Line[0], other.Line[0])
+		}
+	}
+	if parts == 0 {
+		return 0
+	}
+	return agreement / parts
+}
+
+// bestSimilarity returns the highest similarity of any pair of values
+func bestSimilarity(values, others []string, similarity func(a, b string) float64) float64 {
+	best := 0.0
+	for _, value := range values {
+		for _, other := range others {
+			best = math.Max(best, similarity(value, other))
+		}
+	}
+	return best
+}
+
+// exactSimilarity is 1 for equal values and 0 otherwise
+func exactSimilarity(a, b string) float64 {
+	return boolSimilarity(a == b)
+}
+
+// boolSimilarity converts agreement to a similarity
+func boolSimilarity(agree bool) float64 {
+	if agree {
+		return 1
+	}
+	return 0
+}
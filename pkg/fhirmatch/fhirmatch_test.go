@@ -0,0 +1,192 @@
+package fhirmatch
+
+import (
+	"testing"
+
+	"go-fhir-demo/pkg/utils"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+)
+
+// basePatient returns a patient whose name, birth date and gender the candidates in these tests share
+func basePatient() *fhir.Patient {
+	return &fhir.Patient{
+		Name:      []fhir.HumanName{{Family: utils.CreateStringPtr("Doe"), Given: []string{"John"}}},
+		Gender:    utils.GenderPtr("male"),
+		BirthDate: utils.CreateStringPtr("1980-01-01"),
+	}
+}
+
+// telecom returns a contact point of the given system
+func telecom(system fhir.ContactPointSystem, value string) fhir.ContactPoint {
+	return fhir.ContactPoint{System: &system, Value: &value}
+}
+
+// address returns an address with a first line, city and postal code
+func address(line, city, postalCode string) fhir.Address {
+	return fhir.Address{Line: []string{line}, City: &city, PostalCode: &postalCode}
+}
+
+// identifier returns an identifier in the given system
+func identifier(system, value string) fhir.Identifier {
+	return fhir.Identifier{System: &system, Value: &value}
+}
+
+// TestCompare tests that telecom, address and identifier each change the score and grade
+// of a candidate whose name, birth date and gender already agree
+func TestCompare(t *testing.T) {
+	cases := map[string]struct {
+		patient   func(*fhir.Patient)
+		candidate func(*fhir.Patient)
+		score     float64
+		grade     Grade
+	}{
+		"demographics alone are not corroborated": {
+			score: 1, grade: GradeProbable,
+		},
+		"matching phone corroborates": {
+			patient: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemPhone, "+1 (555) 123-4567")}
+			},
+			candidate: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemPhone, "555.123.4567")}
+			},
+			score: 1, grade: GradeCertain,
+		},
+		"matching email ignores case": {
+			patient: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemEmail, "John@Example.com")}
+			},
+			candidate: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemEmail, "john@example.com ")}
+			},
+			score: 1, grade: GradeCertain,
+		},
+		"different phone lowers the score": {
+			patient: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemPhone, "555-123-4567")}
+			},
+			candidate: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemPhone, "555-765-4321")}
+			},
+			score: 0.875, grade: GradeProbable,
+		},
+		"candidate without telecom lowers the score": {
+			patient: func(p *fhir.Patient) {
+				p.Telecom = []fhir.ContactPoint{telecom(fhir.ContactPointSystemPhone, "555-123-4567")}
+			},
+			score: 0.875, grade: GradeProbable,
+		},
+		"matching address corroborates": {
+			patient:   func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 Main Street", "Springfield", "62701")} },
+			candidate: func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 main street", "Springfield", "62701")} },
+			score:     1, grade: GradeCertain,
+		},
+		"similar address corroborates": {
+			patient:   func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 Main Street", "Springfield", "62701")} },
+			candidate: func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 Main St", "Springfield", "62701")} },
+			score:     0.988, grade: GradeCertain,
+		},
+		"address with a different line does not corroborate": {
+			patient: func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 Main Street", "Springfield", "62701")} },
+			candidate: func(p *fhir.Patient) {
+				p.Address = []fhir.Address{address("Apartment 4B, Elm Road", "Springfield", "62701")}
+			},
+			score: 0.958, grade: GradeProbable,
+		},
+		"different address lowers the score": {
+			patient:   func(p *fhir.Patient) { p.Address = []fhir.Address{address("12 Main Street", "Springfield", "62701")} },
+			candidate: func(p *fhir.Patient) { p.Address = []fhir.Address{address("9 Harbour View", "Shelbyville", "62565")} },
+			score:     0.875, grade: GradeProbable,
+		},
+		"shared identifier is certain": {
+			patient:   func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")} },
+			candidate: func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")} },
+			score:     1, grade: GradeCertain,
+		},
+		"shared identifier lifts a weaker name match": {
+			patient: func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")} },
+			candidate: func(p *fhir.Patient) {
+				p.Name[0].Given = []string{"Peter"}
+				p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")}
+			},
+			score: 0.85, grade: GradeCertain,
+		},
+		"conflicting identifier caps the grade": {
+			patient:   func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")} },
+			candidate: func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "456")} },
+			score:     0.7, grade: GradePossible,
+		},
+		"identifier in another system is ignored": {
+			patient:   func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:mrn", "123")} },
+			candidate: func(p *fhir.Patient) { p.Identifier = []fhir.Identifier{identifier("urn:ssn", "456")} },
+			score:     1, grade: GradeProbable,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patient, candidate := basePatient(), basePatient()
+			if tc.patient != nil {
+				tc.patient(patient)
+			}
+			if tc.candidate != nil {
+				tc.candidate(candidate)
+			}
+
+			result := Compare(patient, candidate)
+
+			assert.InDelta(t, tc.score, result.Score, 0.001)
+			assert.Equal(t, tc.grade, result.Grade)
+		})
+	}
+}
+
+// TestAddressSimilarity tests that postal code, city and first line are averaged over the parts present
+func TestAddressSimilarity(t *testing.T) {
+	cases := map[string]struct {
+		address, other fhir.Address
+		expected       float64
+	}{
+		"identical":                  {address("12 Main Street", "Springfield", "62701"), address("12 Main Street", "Springfield", "62701"), 1},
+		"postal code formatting":     {address("1 High St", "London", "SW1A 1AA"), address("1 High St", "london", "sw1a-1aa"), 1},
+		"different postal code":      {address("12 Main Street", "Springfield", "62701"), address("12 Main Street", "Springfield", "62702"), 2.0 / 3},
+		"abbreviated line":           {address("12 Main Street", "Springfield", "62701"), address("12 Main St", "Springfield", "62701"), (2 + 10.0/14) / 3},
+		"misspelled city":            {fhir.Address{City: utils.CreateStringPtr("Springfield")}, fhir.Address{City: utils.CreateStringPtr("Springfeld")}, 10.0 / 11},
+		"only the first line counts": {fhir.Address{Line: []string{"Flat 2", "12 Main Street"}}, fhir.Address{Line: []string{"12 Main Street"}}, 0},
+		"other has no parts":         {address("12 Main Street", "Springfield", "62701"), fhir.Address{}, 0},
+		"first has no parts":         {fhir.Address{}, address("12 Main Street", "Springfield", "62701"), 0},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, addressSimilarity(tc.address, tc.other), 0.001)
+		})
+	}
+}
+
+// TestTextSimilarity tests that free text is compared by edit distance ignoring case and spacing
+func TestTextSimilarity(t *testing.T) {
+	cases := map[string]struct {
+		a, b     string
+		expected float64
+	}{
+		"case and spacing": {"12  Main Street", "12 main street ", 1},
+		"abbreviation":     {"12 Main Street", "12 Main St", 1 - 4.0/14},
+		"one typo":         {"12 Main Street", "12 Mian Street", 1 - 2.0/14},
+		"unrelated":        {"12 Main Street", "Apartment 4B", 0},
+		"empty":            {"", "12 Main Street", 0},
+		"only spaces":      {"   ", "   ", 0},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, textSimilarity(tc.a, tc.b), 0.001)
+		})
+	}
+}
+
+// TestExactSimilarity tests that telecom and identifier values must be equal to agree
+func TestExactSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, exactSimilarity("5551234567", "5551234567"))
+	assert.Equal(t, 0.0, exactSimilarity("5551234567", "5551234568"))
+	assert.Equal(t, 0.0, exactSimilarity("john@example.com", "John@example.com"))
+}
@@ -0,0 +1,589 @@
+package fhirpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parameter is one entry of a FHIR Parameters resource. Value holds whichever value[x] was sent.
+type parameter struct {
+	Name  string
+	Value json.RawMessage
+	Part  []parameter
+}
+
+// UnmarshalJSON decodes a parameter, collecting its value[x] regardless of the datatype suffix
+func (p *parameter) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key, value := range raw {
+		switch {
+		case key == "name":
+			if err := json.Unmarshal(value, &p.Name); err != nil {
+				return err
+			}
+		case key == "part":
+			if err := json.Unmarshal(value, &p.Part); err != nil {
+				return err
+			}
+		case strings.HasPrefix(key, "value"):
+			p.Value = value
+		}
+	}
+	return nil
+}
+
+// fhirPathOperation is a decoded FHIRPath Patch operation
+type fhirPathOperation struct {
+	Type        string
+	Path        string
+	Name        string
+	Value       interface{}
+	HasValue    bool
+	Index       *int
+	Source      *int
+	Destination *int
+}
+
+// segment is one step of the supported FHIRPath subset
+type segment struct {
+	name  string // element name, or function name when function is true
+	index int    // -1 when no [n] indexer is present
+	// function segments: where(field='literal'), first(), last()
+	function   bool
+	whereField string
+	whereValue string
+}
+
+// IsParameters reports whether a JSON body is a FHIR Parameters resource, as used by FHIRPath Patch
+func IsParameters(body []byte) bool {
+	var probe struct {
+		ResourceType string `json:"resourceType"`
+	}
+	return json.Unmarshal(body, &probe) == nil && probe.ResourceType == "Parameters"
+}
+
+// ApplyFHIRPathPatch applies a FHIRPath Patch Parameters resource to a FHIR resource.
+// model is the Go type of the resource (e.g. fhir.Patient{}) and is used to check element
+// names and decide whether an element repeats. Paths support a practical FHIRPath subset:
+// element names, [n] indexers, where(element='value'), first() and last().
+func ApplyFHIRPathPatch(doc, params []byte, model interface{}) ([]byte, error) {
+	operations, err := parseFHIRPathOperations(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	object, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document is not a FHIR resource")
+	}
+	resourceType, _ := object["resourceType"].(string)
+	modelType := reflect.TypeOf(model)
+
+	for i, op := range operations {
+		root, err = applyFHIRPathOperation(root, op, resourceType, modelType)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Type, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// parseFHIRPathOperations decodes the operation parameters of a FHIRPath Patch
+func parseFHIRPathOperations(params []byte) ([]fhirPathOperation, error) {
+	var body struct {
+		ResourceType string      `json:"resourceType"`
+		Parameter    []parameter `json:"parameter"`
+	}
+	if err := json.Unmarshal(params, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if body.ResourceType != "Parameters" {
+		return nil, fmt.Errorf("%w: a FHIRPath Patch must be a Parameters resource", ErrInvalidPatch)
+	}
+
+	operations := make([]fhirPathOperation, 0, len(body.Parameter))
+	for _, param := range body.Parameter {
+		if param.Name != "operation" {
+			return nil, fmt.Errorf("%w: unexpected parameter %q", ErrInvalidPatch, param.Name)
+		}
+		var op fhirPathOperation
+		for _, part := range param.Part {
+			var err error
+			switch part.Name {
+			case "type":
+				err = json.Unmarshal(part.Value, &op.Type)
+			case "path":
+				err = json.Unmarshal(part.Value, &op.Path)
+			case "name":
+				err = json.Unmarshal(part.Value, &op.Name)
+			case "value":
+				op.Value, err = partValue(part)
+				op.HasValue = true
+			case "index":
+				op.Index, err = intPart(part)
+			case "source":
+				op.Source, err = intPart(part)
+			case "destination":
+				op.Destination, err = intPart(part)
+			default:
+				err = fmt.Errorf("unexpected part %q", part.Name)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+			}
+		}
+		if op.Path == "" {
+			return nil, fmt.Errorf("%w: operation %q has no path", ErrInvalidPatch, op.Type)
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// partValue returns the value of a part: its value[x], or an object assembled from nested parts
+func partValue(part parameter) (interface{}, error) {
+	if part.Value != nil {
+		var value interface{}
+		err := json.Unmarshal(part.Value, &value)
+		return value, err
+	}
+	if len(part.Part) == 0 {
+		return nil, fmt.Errorf("part %q has no value", part.Name)
+	}
+	object := map[string]interface{}{}
+	for _, child := range part.Part {
+		value, err := partValue(child)
+		if err != nil {
+			return nil, err
+		}
+		// Repeated part names become arrays
+		switch existing := object[child.Name].(type) {
+		case nil:
+			object[child.Name] = value
+		case []interface{}:
+			object[child.Name] = append(existing, value)
+		default:
+			object[child.Name] = []interface{}{existing, value}
+		}
+	}
+	return object, nil
+}
+
+// intPart decodes a valueInteger part
+func intPart(part parameter) (*int, error) {
+	var value int
+	if err := json.Unmarshal(part.Value, &value); err != nil {
+		return nil, fmt.Errorf("part %q must be an integer", part.Name)
+	}
+	return &value, nil
+}
+
+// applyFHIRPathOperation applies one FHIRPath Patch operation and returns the new document root
+func applyFHIRPathOperation(root interface{}, op fhirPathOperation, resourceType string, modelType reflect.Type) (interface{}, error) {
+	segments, err := parsePath(op.Path, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Type {
+	case "add":
+		if op.Name == "" || !op.HasValue {
+			return nil, fmt.Errorf("%w: add requires a name and a value", ErrInvalidPatch)
+		}
+		target, err := single(root, segments)
+		if err != nil {
+			return nil, err
+		}
+		location := append(append([]string{}, target...), op.Name)
+		elementType, ok := fieldType(modelType, location)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has no element %q", ErrInvalidPath, op.Path, op.Name)
+		}
+		parent, err := getValue(root, target)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := parent.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: %s is not an element that can have children", ErrInvalidPath, op.Path)
+		}
+		existing, exists := parent.(map[string]interface{})[op.Name]
+		if isRepeating(elementType) {
+			value := normalize(elementType.Elem(), op.Value)
+			if exists {
+				if _, ok := existing.([]interface{}); !ok {
+					return nil, fmt.Errorf("%w: %s.%s is not a list", ErrInvalidPath, op.Path, op.Name)
+				}
+				return addValue(root, append(location, "-"), value)
+			}
+			return addValue(root, location, []interface{}{value})
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %s.%s already has a value, use replace", ErrInvalidPath, op.Path, op.Name)
+		}
+		return addValue(root, location, normalize(elementType, op.Value))
+
+	case "insert":
+		if op.Index == nil || !op.HasValue {
+			return nil, fmt.Errorf("%w: insert requires an index and a value", ErrInvalidPatch)
+		}
+		list, elementType, err := container(root, segments, modelType, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		current, _ := getValue(root, list)
+		items, _ := current.([]interface{})
+		if *op.Index < 0 || *op.Index > len(items) {
+			return nil, fmt.Errorf("%w: index %d is out of bounds", ErrInvalidPath, *op.Index)
+		}
+		value := normalize(elementType.Elem(), op.Value)
+		if current == nil {
+			return addValue(root, list, []interface{}{value})
+		}
+		return addValue(root, append(list, strconv.Itoa(*op.Index)), value)
+
+	case "delete":
+		locations, err := evaluate(root, segments)
+		if err != nil {
+			return nil, err
+		}
+		switch len(locations) {
+		case 0:
+			// Deleting an element that does not exist is not an error
+			return root, nil
+		case 1:
+			root, _, err = removeValue(root, locations[0])
+			if err != nil {
+				return nil, err
+			}
+			return pruneEmpty(root, locations[0][:len(locations[0])-1])
+		default:
+			return nil, fmt.Errorf("%w: %s matches %d elements, delete requires at most one", ErrInvalidPath, op.Path, len(locations))
+		}
+
+	case "replace":
+		if !op.HasValue {
+			return nil, fmt.Errorf("%w: replace requires a value", ErrInvalidPatch)
+		}
+		target, err := single(root, segments)
+		if err != nil {
+			return nil, err
+		}
+		elementType, ok := fieldType(modelType, target)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a known element", ErrInvalidPath, op.Path)
+		}
+		return replaceValue(root, target, normalize(elementType, op.Value))
+
+	case "move":
+		if op.Source == nil || op.Destination == nil {
+			return nil, fmt.Errorf("%w: move requires a source and a destination", ErrInvalidPatch)
+		}
+		list, _, err := container(root, segments, modelType, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		current, _ := getValue(root, list)
+		items, _ := current.([]interface{})
+		if *op.Source < 0 || *op.Source >= len(items) || *op.Destination < 0 || *op.Destination >= len(items) {
+			return nil, fmt.Errorf("%w: move indexes are out of bounds", ErrInvalidPath)
+		}
+		root, value, err := removeValue(root, append(list, strconv.Itoa(*op.Source)))
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, append(list, strconv.Itoa(*op.Destination)), value)
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation type %q", ErrInvalidPatch, op.Type)
+	}
+}
+
+// parsePath parses the supported FHIRPath subset, dropping a leading resource type
+func parsePath(path, resourceType string) ([]segment, error) {
+	parts, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && parts[0] == resourceType {
+		parts = parts[1:]
+	}
+
+	segments := make([]segment, 0, len(parts))
+	for _, part := range parts {
+		seg := segment{index: -1}
+		if open := strings.Index(part, "["); open >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("%w: malformed indexer in %q", ErrInvalidPath, part)
+			}
+			index, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("%w: invalid index in %q", ErrInvalidPath, part)
+			}
+			seg.index = index
+			part = part[:open]
+		}
+		switch {
+		case part == "first()" || part == "last()":
+			seg.function = true
+			seg.name = strings.TrimSuffix(part, "()")
+		case strings.HasPrefix(part, "where(") && strings.HasSuffix(part, ")"):
+			field, literal, ok := strings.Cut(part[len("where("):len(part)-1], "=")
+			literal = strings.TrimSpace(literal)
+			if !ok || len(literal) < 2 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+				return nil, fmt.Errorf("%w: only where(element='value') is supported, got %q", ErrInvalidPath, part)
+			}
+			seg.function = true
+			seg.name = "where"
+			seg.whereField = strings.TrimSpace(field)
+			seg.whereValue = literal[1 : len(literal)-1]
+		case isIdentifier(part):
+			seg.name = part
+		default:
+			return nil, fmt.Errorf("%w: unsupported FHIRPath expression %q", ErrInvalidPath, part)
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// splitPath splits a FHIRPath expression on dots outside of quotes and parentheses
+func splitPath(path string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	depth, quoted := 0, false
+	for _, r := range path {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == '.' && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if quoted || depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced expression %q", ErrInvalidPath, path)
+	}
+	parts = append(parts, current.String())
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%w: empty step in %q", ErrInvalidPath, path)
+		}
+	}
+	return parts, nil
+}
+
+// evaluate resolves path segments to the JSON Pointer locations they select
+func evaluate(root interface{}, segments []segment) ([][]string, error) {
+	locations := [][]string{{}}
+	for _, seg := range segments {
+		var next [][]string
+		switch {
+		case !seg.function:
+			for _, location := range locations {
+				value, _ := getValue(root, location)
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				child, ok := object[seg.name]
+				if !ok {
+					continue
+				}
+				base := append(append([]string{}, location...), seg.name)
+				if items, ok := child.([]interface{}); ok {
+					for i := range items {
+						next = append(next, append(append([]string{}, base...), strconv.Itoa(i)))
+					}
+					continue
+				}
+				next = append(next, base)
+			}
+		case seg.name == "where":
+			for _, location := range locations {
+				value, _ := getValue(root, location)
+				object, ok := value.(map[string]interface{})
+				if ok && fmt.Sprint(object[seg.whereField]) == seg.whereValue {
+					next = append(next, location)
+				}
+			}
+		case seg.name == "first" && len(locations) > 0:
+			next = locations[:1]
+		case seg.name == "last" && len(locations) > 0:
+			next = locations[len(locations)-1:]
+		}
+		if seg.index >= 0 {
+			if seg.index < len(next) {
+				next = next[seg.index : seg.index+1]
+			} else {
+				next = nil
+			}
+		}
+		locations = next
+	}
+	return locations, nil
+}
+
+// single resolves a path that must select exactly one element
+func single(root interface{}, segments []segment) ([]string, error) {
+	locations, err := evaluate(root, segments)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) != 1 {
+		return nil, fmt.Errorf("%w: path must select exactly one element, it selects %d", ErrInvalidPath, len(locations))
+	}
+	return locations[0], nil
+}
+
+// container resolves the list a path refers to for insert and move.
+// The last step must be a plain element name that repeats.
+func container(root interface{}, segments []segment, modelType reflect.Type, path string) ([]string, reflect.Type, error) {
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s does not refer to a list", ErrInvalidPath, path)
+	}
+	last := segments[len(segments)-1]
+	if last.function || last.index >= 0 {
+		return nil, nil, fmt.Errorf("%w: %s must end with a list element name", ErrInvalidPath, path)
+	}
+	parent, err := single(root, segments[:len(segments)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	list := append(append([]string{}, parent...), last.name)
+	elementType, ok := fieldType(modelType, list)
+	if !ok || !isRepeating(elementType) {
+		return nil, nil, fmt.Errorf("%w: %s is not a list element", ErrInvalidPath, path)
+	}
+	return list, elementType, nil
+}
+
+// pruneEmpty removes a list or object left empty by a delete, since FHIR does not allow empty elements
+func pruneEmpty(root interface{}, location []string) (interface{}, error) {
+	for len(location) > 0 {
+		value, err := getValue(root, location)
+		if err != nil {
+			return nil, err
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			if len(v) > 0 {
+				return root, nil
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return root, nil
+			}
+		default:
+			return root, nil
+		}
+		if root, _, err = removeValue(root, location); err != nil {
+			return nil, err
+		}
+		location = location[:len(location)-1]
+	}
+	return root, nil
+}
+
+// fieldType walks a location through the model's JSON field names and returns the Go type found there
+func fieldType(t reflect.Type, location []string) (reflect.Type, bool) {
+	for _, token := range location {
+		t = indirect(t)
+		switch t.Kind() {
+		case reflect.Slice:
+			if _, err := strconv.Atoi(token); err != nil {
+				return nil, false
+			}
+			t = t.Elem()
+		case reflect.Struct:
+			field, ok := jsonField(t, token)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		default:
+			return nil, false
+		}
+	}
+	return indirect(t), true
+}
+
+// jsonField finds the struct field serialised under the given JSON name
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tagName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tagName == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// normalize wraps single values in lists where the model expects a repeating element,
+// so values assembled from Parameters parts fit the resource structure
+func normalize(t reflect.Type, value interface{}) interface{} {
+	t = indirect(t)
+	switch {
+	case isRepeating(t):
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for i, item := range items {
+			items[i] = normalize(t.Elem(), item)
+		}
+		return items
+	case t.Kind() == reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for key, child := range object {
+			if field, ok := jsonField(t, key); ok {
+				object[key] = normalize(field.Type, child)
+			}
+		}
+		return object
+	default:
+		return value
+	}
+}
+
+// isRepeating reports whether a model type is a FHIR list (raw JSON byte slices are not lists)
+func isRepeating(t reflect.Type) bool {
+	t = indirect(t)
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
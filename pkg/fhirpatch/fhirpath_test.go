@@ -0,0 +1,195 @@
+package fhirpatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fhirPathDocument = `{
+	"resourceType":"Patient",
+	"name":[{"family":"Doe","given":["John","Q"]}],
+	"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],
+	"contact":[{"name":{"family":"Roe"}}]
+}`
+
+// parameters builds a FHIRPath Patch Parameters resource, one operation per argument
+func parameters(operations ...string) []byte {
+	parts := make([]string, len(operations))
+	for i, operation := range operations {
+		parts[i] = `{"name":"operation","part":[` + operation + `]}`
+	}
+	return []byte(`{"resourceType":"Parameters","parameter":[` + strings.Join(parts, ",") + `]}`)
+}
+
+// TestApplyFHIRPathPatch tests each FHIRPath Patch operation against a Patient document
+func TestApplyFHIRPathPatch(t *testing.T) {
+	cases := map[string]struct {
+		params   []byte
+		expected string
+	}{
+		"add primitive": {
+			parameters(`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"birthDate"},{"name":"value","valueDate":"1970-02-03"}`),
+			`{"resourceType":"Patient","birthDate":"1970-02-03","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"add appends to an existing list": {
+			parameters(`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"telecom"},{"name":"value","part":[{"name":"system","valueCode":"fax"},{"name":"value","valueString":"556"}]}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"},{"system":"fax","value":"556"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"add creates a missing list and wraps repeating parts": {
+			parameters(`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"address"},{"name":"value","part":[{"name":"line","valueString":"1 Main St"},{"name":"city","valueString":"Metropolis"}]}`),
+			`{"resourceType":"Patient","address":[{"line":["1 Main St"],"city":"Metropolis"}],"name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"insert": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.name[0].given"},{"name":"index","valueInteger":1},{"name":"value","valueString":"Jim"}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Jim","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"insert creates a missing list": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.address"},{"name":"index","valueInteger":0},{"name":"value","part":[{"name":"city","valueString":"Metropolis"}]}`),
+			`{"resourceType":"Patient","address":[{"city":"Metropolis"}],"name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"delete with where": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.telecom.where(system='email')"}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"delete prunes emptied elements": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.contact.name.family"}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"delete of a missing element": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.birthDate"}`),
+			fhirPathDocument,
+		},
+		"replace with first()": {
+			parameters(`{"name":"type","valueCode":"replace"},{"name":"path","valueString":"Patient.telecom.first().value"},{"name":"value","valueString":"556"}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"556"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"replace with last() and no resource type": {
+			parameters(`{"name":"type","valueCode":"replace"},{"name":"path","valueString":"telecom.last().value"},{"name":"value","valueString":"jd@example.com"}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"jd@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+		"move": {
+			parameters(`{"name":"type","valueCode":"move"},{"name":"path","valueString":"Patient.name[0].given"},{"name":"source","valueInteger":1},{"name":"destination","valueInteger":0}`),
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["Q","John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}],"contact":[{"name":{"family":"Roe"}}]}`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patched, err := ApplyFHIRPathPatch([]byte(fhirPathDocument), tc.params, fhir.Patient{})
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(patched))
+		})
+	}
+}
+
+// TestApplyFHIRPathPatch_Errors tests that invalid operations and paths are rejected
+func TestApplyFHIRPathPatch_Errors(t *testing.T) {
+	cases := map[string]struct {
+		params []byte
+		err    error
+	}{
+		"not Parameters": {
+			[]byte(`{"resourceType":"Patient"}`),
+			ErrInvalidPatch,
+		},
+		"unexpected parameter": {
+			[]byte(`{"resourceType":"Parameters","parameter":[{"name":"op"}]}`),
+			ErrInvalidPatch,
+		},
+		"missing path": {
+			parameters(`{"name":"type","valueCode":"delete"}`),
+			ErrInvalidPatch,
+		},
+		"non-integer index": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.telecom"},{"name":"index","valueString":"first"}`),
+			ErrInvalidPatch,
+		},
+		"unsupported type": {
+			parameters(`{"name":"type","valueCode":"frobnicate"},{"name":"path","valueString":"Patient"}`),
+			ErrInvalidPatch,
+		},
+		"add without a value": {
+			parameters(`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"birthDate"}`),
+			ErrInvalidPatch,
+		},
+		"add unknown element": {
+			parameters(`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"shoeSize"},{"name":"value","valueInteger":9}`),
+			ErrInvalidPath,
+		},
+		"add to an element that already has a value": {
+			parameters(
+				`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"gender"},{"name":"value","valueCode":"male"}`,
+				`{"name":"type","valueCode":"add"},{"name":"path","valueString":"Patient"},{"name":"name","valueString":"gender"},{"name":"value","valueCode":"female"}`,
+			),
+			ErrInvalidPath,
+		},
+		"insert out of range": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.telecom"},{"name":"index","valueInteger":3},{"name":"value","part":[{"name":"system","valueCode":"fax"}]}`),
+			ErrInvalidPath,
+		},
+		"insert into an element that does not repeat": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.birthDate"},{"name":"index","valueInteger":0},{"name":"value","valueDate":"1970-02-03"}`),
+			ErrInvalidPath,
+		},
+		"insert into an indexed path": {
+			parameters(`{"name":"type","valueCode":"insert"},{"name":"path","valueString":"Patient.telecom[0]"},{"name":"index","valueInteger":0},{"name":"value","part":[{"name":"system","valueCode":"fax"}]}`),
+			ErrInvalidPath,
+		},
+		"move out of range": {
+			parameters(`{"name":"type","valueCode":"move"},{"name":"path","valueString":"Patient.telecom"},{"name":"source","valueInteger":2},{"name":"destination","valueInteger":0}`),
+			ErrInvalidPath,
+		},
+		"delete matching several elements": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.telecom"}`),
+			ErrInvalidPath,
+		},
+		"replace a missing element": {
+			parameters(`{"name":"type","valueCode":"replace"},{"name":"path","valueString":"Patient.birthDate"},{"name":"value","valueDate":"1970-02-03"}`),
+			ErrInvalidPath,
+		},
+		"unsupported expression": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.telecom.exists()"}`),
+			ErrInvalidPath,
+		},
+		"unbalanced expression": {
+			parameters(`{"name":"type","valueCode":"delete"},{"name":"path","valueString":"Patient.telecom.where(system='email'"}`),
+			ErrInvalidPath,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patched, err := ApplyFHIRPathPatch([]byte(fhirPathDocument), tc.params, fhir.Patient{})
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, patched)
+		})
+	}
+}
+
+// TestPruneEmpty tests that pruning stops at the first ancestor that still has content
+func TestPruneEmpty(t *testing.T) {
+	root := map[string]interface{}{
+		"name": []interface{}{
+			map[string]interface{}{"given": []interface{}{}},
+			map[string]interface{}{"family": "Doe"},
+		},
+	}
+
+	pruned, err := pruneEmpty(root, []string{"name", "0", "given"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": []interface{}{map[string]interface{}{"family": "Doe"}},
+	}, pruned)
+}
+
+// TestIsParameters tests detection of FHIRPath Patch bodies
+func TestIsParameters(t *testing.T) {
+	assert.True(t, IsParameters([]byte(`{"resourceType":"Parameters","parameter":[]}`)))
+	assert.False(t, IsParameters([]byte(`{"resourceType":"Patient"}`)))
+	assert.False(t, IsParameters([]byte(`[{"op":"remove","path":"/name"}]`)))
+}
@@ -0,0 +1,322 @@
+package fhirpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned when a patch document is malformed or uses an unsupported operation
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrInvalidPath is returned when a patch operation targets a location that cannot be resolved
+	ErrInvalidPath = errors.New("invalid patch path")
+	// ErrTestFailed is returned when a JSON Patch test operation does not match
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Operation is a single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch document to a JSON document.
+// Operations are applied in order and the whole patch fails if any operation fails.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations: %v", ErrInvalidPatch, err)
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	for i, op := range operations {
+		var err error
+		root, err = applyOperation(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// applyOperation applies one JSON Patch operation and returns the new document root
+func applyOperation(root interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %q requires a value", ErrInvalidPatch, op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: invalid value: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return addValue(root, path, value)
+		case "replace":
+			return replaceValue(root, path, value)
+		default:
+			current, err := getValue(root, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return root, nil
+		}
+	case "remove":
+		root, _, err = removeValue(root, path)
+		return root, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isProperPrefix(from, path) {
+				return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPath)
+			}
+			var value interface{}
+			root, value, err = removeValue(root, from)
+			if err != nil {
+				return nil, err
+			}
+			return addValue(root, path, value)
+		}
+		value, err := getValue(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, path, deepCopy(value))
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: JSON Pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// getValue returns the value at the given location
+func getValue(root interface{}, path []string) (interface{}, error) {
+	node := root
+	for i, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPath, formatPointer(path[:i+1]))
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatPointer(path[:i+1]), err)
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%w: %s is not an object or array", ErrInvalidPath, formatPointer(path[:i]))
+		}
+	}
+	return node, nil
+}
+
+// addValue adds a value at the location, inserting into arrays and creating or replacing object members
+func addValue(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), nil
+			}
+			index, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%w: parent is not an object or array", ErrInvalidPath)
+		}
+	})
+}
+
+// replaceValue replaces the existing value at the location
+func replaceValue(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, key)
+			}
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(p)-1)
+			if err != nil {
+				return nil, err
+			}
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%w: parent is not an object or array", ErrInvalidPath)
+		}
+	})
+}
+
+// removeValue removes the value at the location and returns it
+func removeValue(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the document root", ErrInvalidPath)
+	}
+	var removed interface{}
+	root, err := updateParent(root, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			value, ok := p[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, key)
+			}
+			removed = value
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(p)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = p[index]
+			return append(p[:index], p[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: parent is not an object or array", ErrInvalidPath)
+		}
+	})
+	return root, removed, err
+}
+
+// updateParent walks to the parent of the target location and replaces it with the result of fn.
+// Arrays are values in Go, so every level on the way back up is reassigned.
+func updateParent(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, path[0])
+		}
+		updated, err := updateParent(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		index, err := arrayIndex(path[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(n[index], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not an object or array", ErrInvalidPath, path[0])
+	}
+}
+
+// arrayIndex parses an array index token and checks it is within [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPath, token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("%w: array index %d is out of bounds", ErrInvalidPath, index)
+	}
+	return index, nil
+}
+
+// isProperPrefix reports whether prefix is a proper prefix of path
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatPointer renders reference tokens back into a JSON Pointer for error messages
+func formatPointer(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// deepCopy copies a decoded JSON value so copies do not share maps or slices
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,162 @@
+package fhirpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonPatchDocument = `{
+	"resourceType":"Patient",
+	"name":[{"family":"Doe","given":["John"]}],
+	"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]
+}`
+
+// TestApplyJSONPatch tests each RFC 6902 operation against a Patient document
+func TestApplyJSONPatch(t *testing.T) {
+	cases := map[string]struct {
+		patch    string
+		expected string
+	}{
+		"add member": {
+			`[{"op":"add","path":"/active","value":true}]`,
+			`{"resourceType":"Patient","active":true,"name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"add inserts into an array": {
+			`[{"op":"add","path":"/name/0/given/0","value":"Jim"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["Jim","John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"add appends with -": {
+			`[{"op":"add","path":"/name/0/given/-","value":"Q"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John","Q"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"add unescapes pointer tokens": {
+			`[{"op":"add","path":"/a~1b~0c","value":1}]`,
+			`{"resourceType":"Patient","a/b~c":1,"name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"remove array element": {
+			`[{"op":"remove","path":"/telecom/0"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"email","value":"john@example.com"}]}`,
+		},
+		"remove member": {
+			`[{"op":"remove","path":"/name/0/family"}]`,
+			`{"resourceType":"Patient","name":[{"given":["John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"replace": {
+			`[{"op":"replace","path":"/telecom/1/value","value":"jd@example.com"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"jd@example.com"}]}`,
+		},
+		"move within an array": {
+			`[{"op":"move","from":"/telecom/1","path":"/telecom/0"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John"]}],"telecom":[{"system":"email","value":"john@example.com"},{"system":"phone","value":"555"}]}`,
+		},
+		"move member": {
+			`[{"op":"move","from":"/name/0/family","path":"/name/0/text"}]`,
+			`{"resourceType":"Patient","name":[{"text":"Doe","given":["John"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"copy does not share the copied value": {
+			`[{"op":"copy","from":"/name/0","path":"/name/-"},{"op":"replace","path":"/name/1/given/0","value":"Jane"}]`,
+			`{"resourceType":"Patient","name":[{"family":"Doe","given":["John"]},{"family":"Doe","given":["Jane"]}],"telecom":[{"system":"phone","value":"555"},{"system":"email","value":"john@example.com"}]}`,
+		},
+		"test": {
+			`[{"op":"test","path":"/telecom/0","value":{"system":"phone","value":"555"}}]`,
+			jsonPatchDocument,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patched, err := ApplyJSONPatch([]byte(jsonPatchDocument), []byte(tc.patch))
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(patched))
+		})
+	}
+}
+
+// TestApplyJSONPatch_Errors tests that invalid patches fail without a partial result
+func TestApplyJSONPatch_Errors(t *testing.T) {
+	cases := map[string]struct {
+		patch string
+		err   error
+	}{
+		"not an array":              {`{"op":"remove","path":"/name"}`, ErrInvalidPatch},
+		"unsupported operation":     {`[{"op":"frobnicate","path":"/name"}]`, ErrInvalidPatch},
+		"missing value":             {`[{"op":"add","path":"/active"}]`, ErrInvalidPatch},
+		"pointer without slash":     {`[{"op":"remove","path":"name"}]`, ErrInvalidPatch},
+		"remove out of range index": {`[{"op":"remove","path":"/telecom/2"}]`, ErrInvalidPath},
+		"add past the end":          {`[{"op":"add","path":"/telecom/3","value":{}}]`, ErrInvalidPath},
+		"leading zero index":        {`[{"op":"replace","path":"/telecom/01","value":{}}]`, ErrInvalidPath},
+		"negative index":            {`[{"op":"remove","path":"/telecom/-1"}]`, ErrInvalidPath},
+		"remove missing member":     {`[{"op":"remove","path":"/birthDate"}]`, ErrInvalidPath},
+		"replace missing member":    {`[{"op":"replace","path":"/birthDate","value":"1970-01-01"}]`, ErrInvalidPath},
+		"missing parent":            {`[{"op":"add","path":"/address/0/city","value":"Metropolis"}]`, ErrInvalidPath},
+		"parent is a primitive":     {`[{"op":"add","path":"/name/0/family/x","value":"y"}]`, ErrInvalidPath},
+		"remove the root":           {`[{"op":"remove","path":""}]`, ErrInvalidPath},
+		"move from missing member":  {`[{"op":"move","from":"/birthDate","path":"/deceasedDateTime"}]`, ErrInvalidPath},
+		"move into a child":         {`[{"op":"move","from":"/name/0","path":"/name/0/given/-"}]`, ErrInvalidPath},
+		"copy from out of range":    {`[{"op":"copy","from":"/telecom/5","path":"/telecom/-"}]`, ErrInvalidPath},
+		"test value differs":        {`[{"op":"test","path":"/name/0/family","value":"Roe"}]`, ErrTestFailed},
+		"test missing member":       {`[{"op":"test","path":"/gender","value":"male"}]`, ErrInvalidPath},
+		"later operation fails":     {`[{"op":"replace","path":"/name/0/family","value":"Roe"},{"op":"remove","path":"/gender"}]`, ErrInvalidPath},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			patched, err := ApplyJSONPatch([]byte(jsonPatchDocument), []byte(tc.patch))
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, patched)
+		})
+	}
+}
+
+// TestRemoveValue tests that the removed value is returned and arrays close up
+func TestRemoveValue(t *testing.T) {
+	root := map[string]interface{}{
+		"given": []interface{}{"John", "Q", "Public"},
+	}
+
+	updated, removed, err := removeValue(root, []string{"given", "1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Q", removed)
+	assert.Equal(t, []interface{}{"John", "Public"}, updated.(map[string]interface{})["given"])
+
+	_, _, err = removeValue(root, []string{"given", "2"})
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+// TestDeepCopy tests that nested maps and slices are copied rather than shared
+func TestDeepCopy(t *testing.T) {
+	original := map[string]interface{}{
+		"name": []interface{}{map[string]interface{}{"given": []interface{}{"John"}}},
+		"age":  float64(42),
+	}
+
+	copied := deepCopy(original).(map[string]interface{})
+	copied["name"].([]interface{})[0].(map[string]interface{})["given"].([]interface{})[0] = "Jane"
+	copied["age"] = float64(43)
+
+	assert.Equal(t, "John", original["name"].([]interface{})[0].(map[string]interface{})["given"].([]interface{})[0])
+	assert.Equal(t, float64(42), original["age"])
+}
+
+// TestIsProperPrefix tests the check that stops a value being moved into itself
+func TestIsProperPrefix(t *testing.T) {
+	cases := map[string]struct {
+		prefix, path []string
+		expected     bool
+	}{
+		"child":         {[]string{"name", "0"}, []string{"name", "0", "given"}, true},
+		"root":          {[]string{}, []string{"name"}, true},
+		"same location": {[]string{"name", "0"}, []string{"name", "0"}, false},
+		"sibling":       {[]string{"name", "0"}, []string{"name", "1", "given"}, false},
+		"parent":        {[]string{"name", "0", "given"}, []string{"name", "0"}, false},
+		"shared prefix": {[]string{"name"}, []string{"names", "0"}, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isProperPrefix(tc.prefix, tc.path))
+		})
+	}
+}
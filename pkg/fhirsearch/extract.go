@@ -0,0 +1,191 @@
+package fhirsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Value is a search parameter value extracted from a resource
+type Value struct {
+	Name   string
+	Type   ParamType
+	System string     // Token system
+	Value  string     // String value, token code or normalized reference
+	Start  *time.Time // Inclusive start of a date value; nil when unbounded
+	End    *time.Time // Exclusive end of a date value; nil when unbounded
+}
+
+// stringParts are the parts of complex data types such as HumanName and Address
+// that string parameters match
+var stringParts = []string{"text", "family", "given", "prefix", "suffix", "line", "city", "district", "state", "postalCode", "country"}
+
+// Extract returns the values of every search parameter found in a JSON resource by
+// following the element paths of its definition
+func Extract(resource []byte, defs []Definition) ([]Value, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(resource, &document); err != nil {
+		return nil, fmt.Errorf("resource is not a JSON object: %w", err)
+	}
+
+	var values []Value
+	for _, def := range defs {
+		for _, path := range def.Paths {
+			for _, element := range elements(document, strings.Split(path, ".")) {
+				values = append(values, extractValues(def, element)...)
+			}
+		}
+	}
+	return values, nil
+}
+
+// NormalizeReference reduces a reference to its Type/id form, dropping the server base
+// of absolute references and any version suffix
+func NormalizeReference(reference string) string {
+	if idx := strings.Index(reference, "/_history/"); idx >= 0 {
+		reference = reference[:idx]
+	}
+	if strings.Contains(reference, "://") {
+		segments := strings.Split(strings.TrimRight(reference, "/"), "/")
+		if len(segments) >= 2 {
+			return segments[len(segments)-2] + "/" + segments[len(segments)-1]
+		}
+	}
+	return reference
+}
+
+// elements returns the values reached by following the path from the document, flattening arrays
+func elements(document interface{}, path []string) []interface{} {
+	current := []interface{}{document}
+	for _, name := range path {
+		var next []interface{}
+		for _, item := range current {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch child := object[name].(type) {
+			case nil:
+			case []interface{}:
+				next = append(next, child...)
+			default:
+				next = append(next, child)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func extractValues(def Definition, element interface{}) []Value {
+	switch def.Type {
+	case TypeString:
+		return stringValues(def, element)
+	case TypeToken:
+		return tokenValues(def, element)
+	case TypeReference:
+		return referenceValues(def, element)
+	case TypeDate:
+		return dateValues(def, element)
+	default:
+		return nil
+	}
+}
+
+// stringValues extracts plain strings and the parts of names and addresses
+func stringValues(def Definition, element interface{}) []Value {
+	switch v := element.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []Value{{Name: def.Name, Type: def.Type, Value: v}}
+	case map[string]interface{}:
+		var values []Value
+		for _, part := range stringParts {
+			for _, item := range elements(v, []string{part}) {
+				values = append(values, stringValues(def, item)...)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// tokenValues extracts codes, booleans, Codings, CodeableConcepts, Identifiers and ContactPoints
+func tokenValues(def Definition, element interface{}) []Value {
+	token := func(system, code interface{}) []Value {
+		codeValue, _ := code.(string)
+		if codeValue == "" {
+			return nil
+		}
+		systemValue, _ := system.(string)
+		return []Value{{Name: def.Name, Type: def.Type, System: systemValue, Value: codeValue}}
+	}
+
+	switch v := element.(type) {
+	case string:
+		return token(nil, v)
+	case bool:
+		return token(nil, fmt.Sprintf("%t", v))
+	case map[string]interface{}:
+		if codings, ok := v["coding"].([]interface{}); ok {
+			var values []Value
+			for _, coding := range codings {
+				values = append(values, tokenValues(def, coding)...)
+			}
+			return values
+		}
+		if code, ok := v["code"]; ok {
+			return token(v["system"], code)
+		}
+		return token(v["system"], v["value"])
+	default:
+		return nil
+	}
+}
+
+// referenceValues extracts the literal reference of a Reference; contained references are skipped
+func referenceValues(def Definition, element interface{}) []Value {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	reference, _ := object["reference"].(string)
+	if reference == "" || strings.HasPrefix(reference, "#") {
+		return nil
+	}
+	return []Value{{Name: def.Name, Type: def.Type, Value: NormalizeReference(reference)}}
+}
+
+// dateValues extracts the range covered by a date, dateTime, instant or Period
+func dateValues(def Definition, element interface{}) []Value {
+	switch v := element.(type) {
+	case string:
+		start, end, err := ParseDateRange(v)
+		if err != nil {
+			return nil
+		}
+		return []Value{{Name: def.Name, Type: def.Type, Start: &start, End: &end}}
+	case map[string]interface{}:
+		value := Value{Name: def.Name, Type: def.Type}
+		if start, ok := v["start"].(string); ok {
+			if low, _, err := ParseDateRange(start); err == nil {
+				value.Start = &low
+			}
+		}
+		if end, ok := v["end"].(string); ok {
+			if _, high, err := ParseDateRange(end); err == nil {
+				value.End = &high
+			}
+		}
+		if value.Start == nil && value.End == nil {
+			return nil
+		}
+		return []Value{value}
+	default:
+		return nil
+	}
+}
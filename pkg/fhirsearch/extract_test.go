@@ -0,0 +1,47 @@
+package fhirsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtract_Tokens tests that identifiers keep their system, or none, next to their value
+func TestExtract_Tokens(t *testing.T) {
+	resource := `{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"123"},{"value":"456"},{"system":"urn:ssn"}],` +
+		`"gender":"female","active":true,"maritalStatus":{"coding":[{"system":"http://terminology.hl7.org/CodeSystem/v3-MaritalStatus","code":"M"}]}}`
+	defs := []Definition{
+		{Name: "identifier", Type: TypeToken, Paths: []string{"identifier"}},
+		{Name: "gender", Type: TypeToken, Paths: []string{"gender"}},
+		{Name: "active", Type: TypeToken, Paths: []string{"active"}},
+		{Name: "marital-status", Type: TypeToken, Paths: []string{"maritalStatus"}},
+	}
+
+	values, err := Extract([]byte(resource), defs)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Value{
+		{Name: "identifier", Type: TypeToken, System: "urn:mrn", Value: "123"},
+		{Name: "identifier", Type: TypeToken, Value: "456"},
+		{Name: "gender", Type: TypeToken, Value: "female"},
+		{Name: "active", Type: TypeToken, Value: "true"},
+		{Name: "marital-status", Type: TypeToken, System: "http://terminology.hl7.org/CodeSystem/v3-MaritalStatus", Value: "M"},
+	}, values)
+}
+
+// TestNormalizeReference tests that references are reduced to Type/id
+func TestNormalizeReference(t *testing.T) {
+	cases := map[string]string{
+		"Patient/1":                               "Patient/1",
+		"Patient/1/_history/2":                    "Patient/1",
+		"http://example.com/fhir/Patient/1":       "Patient/1",
+		"http://example.com/fhir/Patient/1/":      "Patient/1",
+		"http://example.com/Patient/1/_history/2": "Patient/1",
+	}
+	for reference, expected := range cases {
+		t.Run(reference, func(t *testing.T) {
+			assert.Equal(t, expected, NormalizeReference(reference))
+		})
+	}
+}
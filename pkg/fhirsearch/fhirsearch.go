@@ -0,0 +1,480 @@
+package fhirsearch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamType is the FHIR search parameter type
+type ParamType string
+
+const (
+	TypeString    ParamType = "string"
+	TypeToken     ParamType = "token"
+	TypeDate      ParamType = "date"
+	TypeReference ParamType = "reference"
+)
+
+// Prefix is a FHIR comparison prefix used by ordered parameter types such as date
+type Prefix string
+
+const (
+	PrefixEq Prefix = "eq"
+	PrefixNe Prefix = "ne"
+	PrefixGt Prefix = "gt"
+	PrefixLt Prefix = "lt"
+	PrefixGe Prefix = "ge"
+	PrefixLe Prefix = "le"
+	PrefixSa Prefix = "sa"
+	PrefixEb Prefix = "eb"
+	PrefixAp Prefix = "ap"
+)
+
+const (
+	// DefaultCount is the page size used when the client does not send _count
+	DefaultCount = 10
+	// MaxCount caps the page size a client can request
+	MaxCount = 1000
+	// PageTokenParam carries the opaque token used in paging links
+	PageTokenParam = "_page_token"
+	// MaxIncludeCount caps the resources _include and _revinclude add to a page
+	MaxIncludeCount = 100
+)
+
+// Definition describes a search parameter supported for a resource type
+type Definition struct {
+	Name        string
+	Type        ParamType
+	Description string
+	// Codes optionally restricts token values to a fixed set (e.g. gender codes)
+	Codes []string
+	// Paths are the dotted element paths values are extracted from by Extract. Choice
+	// elements are listed once per type, e.g. effectiveDateTime and effectivePeriod.
+	Paths []string
+	// Target is the resource type bare ids of a reference parameter refer to
+	Target string
+	// Sortable marks parameters that can be used with _sort
+	Sortable bool
+}
+
+// Param is a single occurrence of a search parameter in the query string.
+// Values inside one Param are OR-ed; separate Params are AND-ed.
+type Param struct {
+	Name     string
+	Modifier string
+	Type     ParamType
+	Values   []string
+}
+
+// Include is an _include or _revinclude directive: resources of Source refer through the
+// reference parameter Param to resources of Target, which is empty when any type is allowed
+type Include struct {
+	Source string
+	Param  string
+	Target string
+}
+
+// SortKey is one key of the _sort parameter
+type SortKey struct {
+	Name       string
+	Descending bool
+}
+
+// Total is a value of the _total parameter
+type Total string
+
+const (
+	TotalNone     Total = "none"     // Do not count the matches
+	TotalEstimate Total = "estimate" // An estimate of the matches is enough
+	TotalAccurate Total = "accurate" // Count the matches exactly
+)
+
+// Cursor is the position carried by a keyset page token: the sort values of the row a page
+// starts after, or ends before when Backward is set. Sort is the _sort the values belong to.
+// A backward cursor without values selects the last page.
+type Cursor struct {
+	Sort     string   `json:"s,omitempty"`
+	Values   []string `json:"v,omitempty"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Query is a parsed FHIR search request. A page token sets either Offset or Cursor.
+type Query struct {
+	Params     []Param
+	Include    []Include
+	RevInclude []Include
+	Sort       []SortKey
+	Total      Total
+	Count      int
+	Offset     int
+	Cursor     *Cursor
+}
+
+var supportedModifiers = map[ParamType][]string{
+	TypeString:    {"exact", "contains", "missing"},
+	TypeToken:     {"not", "missing"},
+	TypeDate:      {"missing"},
+	TypeReference: {"missing"},
+}
+
+// Parse converts URL query values into a Query using the given parameter definitions.
+// Unknown parameters are ignored, as the FHIR specification allows for lenient handling.
+func Parse(values url.Values, defs []Definition) (*Query, error) {
+	byName := make(map[string]Definition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	query := &Query{Count: DefaultCount}
+
+	// Iterate in a stable order so generated SQL is deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "_count" {
+			count, err := strconv.Atoi(values.Get(key))
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("invalid _count value %q", values.Get(key))
+			}
+			if count > MaxCount {
+				count = MaxCount
+			}
+			query.Count = count
+			continue
+		}
+		if key == PageTokenParam {
+			token := values.Get(key)
+			if IsCursorToken(token) {
+				cursor, err := DecodeCursor(token)
+				if err != nil {
+					return nil, err
+				}
+				query.Cursor = &cursor
+				continue
+			}
+			offset, err := DecodePageToken(token)
+			if err != nil {
+				return nil, err
+			}
+			query.Offset = offset
+			continue
+		}
+		if key == "_sort" {
+			sortKeys, err := parseSort(values.Get(key), byName)
+			if err != nil {
+				return nil, err
+			}
+			query.Sort = sortKeys
+			continue
+		}
+		if key == "_total" {
+			switch total := Total(values.Get(key)); total {
+			case TotalNone, TotalEstimate, TotalAccurate:
+				query.Total = total
+			default:
+				return nil, fmt.Errorf("invalid _total value %q, expected none, estimate or accurate", total)
+			}
+			continue
+		}
+
+		name, modifier, _ := strings.Cut(key, ":")
+		if name == "_include" || name == "_revinclude" {
+			if modifier != "" {
+				return nil, fmt.Errorf("unsupported modifier %q for %s", modifier, name)
+			}
+			for _, raw := range values[key] {
+				include, err := ParseInclude(raw)
+				if err != nil {
+					return nil, err
+				}
+				if name == "_include" {
+					query.Include = append(query.Include, include)
+				} else {
+					query.RevInclude = append(query.RevInclude, include)
+				}
+			}
+			continue
+		}
+		def, ok := byName[name]
+		if !ok {
+			continue
+		}
+		if modifier != "" && !contains(supportedModifiers[def.Type], modifier) {
+			return nil, fmt.Errorf("unsupported modifier %q for search parameter %q", modifier, name)
+		}
+
+		for _, raw := range values[key] {
+			param := Param{
+				Name:     name,
+				Modifier: modifier,
+				Type:     def.Type,
+				Values:   SplitValues(raw),
+			}
+			if err := validate(def, param); err != nil {
+				return nil, err
+			}
+			query.Params = append(query.Params, param)
+		}
+	}
+
+	if query.Cursor != nil && query.Cursor.Sort != FormatSort(query.Sort) {
+		return nil, fmt.Errorf("page token does not match _sort")
+	}
+	return query, nil
+}
+
+// parseSort parses a comma-separated _sort value, where a leading - sorts descending
+func parseSort(raw string, byName map[string]Definition) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(raw, ",") {
+		key := SortKey{Name: strings.TrimSpace(part)}
+		if name, ok := strings.CutPrefix(key.Name, "-"); ok {
+			key = SortKey{Name: name, Descending: true}
+		}
+		if key.Name == "" {
+			return nil, fmt.Errorf("invalid _sort value %q", raw)
+		}
+		if def, ok := byName[key.Name]; !ok || !def.Sortable {
+			return nil, fmt.Errorf("cannot sort by %q", key.Name)
+		}
+		if slices.ContainsFunc(keys, func(k SortKey) bool { return k.Name == key.Name }) {
+			return nil, fmt.Errorf("_sort lists %q more than once", key.Name)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FormatSort returns sort keys in their _sort form
+func FormatSort(keys []SortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Descending {
+			parts = append(parts, "-"+key.Name)
+		} else {
+			parts = append(parts, key.Name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseInclude parses an _include or _revinclude value of the form Source:param[:Target]
+func ParseInclude(value string) (Include, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") || parts[1] == "*" {
+		return Include{}, fmt.Errorf("invalid include %q, expected Type:parameter or Type:parameter:TargetType", value)
+	}
+	include := Include{Source: parts[0], Param: parts[1]}
+	if len(parts) == 3 {
+		include.Target = parts[2]
+	}
+	return include, nil
+}
+
+// String returns the include in its Source:param[:Target] form
+func (i Include) String() string {
+	if i.Target == "" {
+		return i.Source + ":" + i.Param
+	}
+	return i.Source + ":" + i.Param + ":" + i.Target
+}
+
+// HasPageToken reports whether the query values carry a page token
+func HasPageToken(values url.Values) bool {
+	_, ok := values[PageTokenParam]
+	return ok
+}
+
+// Has reports whether the query contains the named parameter
+func (q *Query) Has(name string) bool {
+	for _, p := range q.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodePageToken creates an opaque page token for the given result offset
+func EncodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// DecodePageToken returns the result offset encoded in a page token
+func DecodePageToken(token string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	value, ok := strings.CutPrefix(string(raw), "offset:")
+	if !ok {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	return offset, nil
+}
+
+// EncodeCursor creates an opaque page token for a keyset cursor
+func EncodeCursor(cursor Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(append([]byte("cursor:"), raw...))
+}
+
+// IsCursorToken reports whether a page token carries a keyset cursor rather than an offset
+func IsCursorToken(token string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	return err == nil && strings.HasPrefix(string(raw), "cursor:")
+}
+
+// DecodeCursor returns the keyset cursor encoded in a page token
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid page token")
+	}
+	value, ok := strings.CutPrefix(string(raw), "cursor:")
+	if !ok {
+		return Cursor{}, fmt.Errorf("invalid page token")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal([]byte(value), &cursor); err != nil {
+		return Cursor{}, fmt.Errorf("invalid page token")
+	}
+	return cursor, nil
+}
+
+// validate checks parameter values against the definition
+func validate(def Definition, param Param) error {
+	for _, value := range param.Values {
+		if value == "" {
+			return fmt.Errorf("empty value for search parameter %q", def.Name)
+		}
+		if param.Modifier == "missing" {
+			if value != "true" && value != "false" {
+				return fmt.Errorf("search parameter %q:missing must be true or false", def.Name)
+			}
+			continue
+		}
+		switch def.Type {
+		case TypeDate:
+			_, dateValue := SplitPrefix(value)
+			if _, _, err := ParseDateRange(dateValue); err != nil {
+				return fmt.Errorf("invalid date for search parameter %q: %w", def.Name, err)
+			}
+		case TypeToken:
+			if len(def.Codes) > 0 {
+				_, code, _ := ParseToken(value)
+				if !contains(def.Codes, code) {
+					return fmt.Errorf("invalid value %q for search parameter %q, expected one of %s",
+						value, def.Name, strings.Join(def.Codes, ", "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SplitValues splits a parameter value on unescaped commas
+func SplitValues(raw string) []string {
+	var values []string
+	var current strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == ',' {
+			current.WriteByte(',')
+			i++
+			continue
+		}
+		if raw[i] == ',' {
+			values = append(values, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(raw[i])
+	}
+	return append(values, current.String())
+}
+
+// SplitPrefix separates a comparison prefix from the value; values without a prefix use eq
+func SplitPrefix(value string) (Prefix, string) {
+	if len(value) > 2 {
+		switch p := Prefix(value[:2]); p {
+		case PrefixEq, PrefixNe, PrefixGt, PrefixLt, PrefixGe, PrefixLe, PrefixSa, PrefixEb, PrefixAp:
+			return p, value[2:]
+		}
+	}
+	return PrefixEq, value
+}
+
+// ParseToken splits a token value of the form [system|]code
+func ParseToken(value string) (system, code string, hasSystem bool) {
+	if idx := strings.Index(value, "|"); idx >= 0 {
+		return value[:idx], value[idx+1:], true
+	}
+	return "", value, false
+}
+
+// ParseDateRange parses a FHIR date or dateTime and returns the half-open
+// interval [start, end) it covers at the precision it was given
+func ParseDateRange(value string) (time.Time, time.Time, error) {
+	layouts := []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01-02T15:04", func(t time.Time) time.Time { return t.Add(time.Minute) }},
+		{"2006-01-02T15:04Z07:00", func(t time.Time) time.Time { return t.Add(time.Minute) }},
+		{"2006-01-02T15:04:05", func(t time.Time) time.Time { return t.Add(time.Second) }},
+		{time.RFC3339, func(t time.Time) time.Time { return t.Add(time.Second) }},
+	}
+	// time.Parse accepts fractional seconds after any seconds field, so check for them first
+	if strings.Contains(value, ".") {
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t, t.Add(time.Millisecond), nil
+		}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l.layout, value); err == nil {
+			return t, l.next(t), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// ApproximateRange widens a date range by 10% of its distance from now, as
+// suggested by the specification for the ap prefix
+func ApproximateRange(start, end time.Time) (time.Time, time.Time) {
+	gap := time.Since(start)
+	if gap < 0 {
+		gap = -gap
+	}
+	margin := gap / 10
+	if margin < 24*time.Hour {
+		margin = 24 * time.Hour
+	}
+	return start.Add(-margin), end.Add(margin)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package fhirsearch
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDefinitions covers one parameter of each type
+var testDefinitions = []Definition{
+	{Name: "family", Type: TypeString, Sortable: true},
+	{Name: "gender", Type: TypeToken, Codes: []string{"male", "female", "other", "unknown"}},
+	{Name: "identifier", Type: TypeToken},
+	{Name: "birthdate", Type: TypeDate, Sortable: true},
+	{Name: "organization", Type: TypeReference, Target: "Organization"},
+}
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestParse tests how query values become OR-ed values within a Param and AND-ed Params
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		query    string
+		expected []Param
+	}{
+		"single value": {
+			"family=Doe",
+			[]Param{{Name: "family", Type: TypeString, Values: []string{"Doe"}}},
+		},
+		"comma is OR": {
+			"gender=male,female",
+			[]Param{{Name: "gender", Type: TypeToken, Values: []string{"male", "female"}}},
+		},
+		"escaped comma is part of the value": {
+			`family=Doe\,Jr`,
+			[]Param{{Name: "family", Type: TypeString, Values: []string{"Doe,Jr"}}},
+		},
+		"repeated parameter is AND": {
+			"birthdate=ge1970&birthdate=lt1980",
+			[]Param{
+				{Name: "birthdate", Type: TypeDate, Values: []string{"ge1970"}},
+				{Name: "birthdate", Type: TypeDate, Values: []string{"lt1980"}},
+			},
+		},
+		"string modifiers": {
+			"family:exact=Doe&family:contains=oe",
+			[]Param{
+				{Name: "family", Modifier: "contains", Type: TypeString, Values: []string{"oe"}},
+				{Name: "family", Modifier: "exact", Type: TypeString, Values: []string{"Doe"}},
+			},
+		},
+		"token not modifier": {
+			"gender:not=male",
+			[]Param{{Name: "gender", Modifier: "not", Type: TypeToken, Values: []string{"male"}}},
+		},
+		"missing modifier on every type": {
+			"birthdate:missing=true&organization:missing=false",
+			[]Param{
+				{Name: "birthdate", Modifier: "missing", Type: TypeDate, Values: []string{"true"}},
+				{Name: "organization", Modifier: "missing", Type: TypeReference, Values: []string{"false"}},
+			},
+		},
+		"token with a system": {
+			"gender=http://hl7.org/fhir/administrative-gender|female",
+			[]Param{{Name: "gender", Type: TypeToken, Values: []string{"http://hl7.org/fhir/administrative-gender|female"}}},
+		},
+		"unknown parameters are ignored": {
+			"shoeSize=9&_elements=name",
+			nil,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			query, err := Parse(values, testDefinitions)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, query.Params)
+			assert.Equal(t, DefaultCount, query.Count)
+		})
+	}
+}
+
+// TestParse_Errors tests that invalid modifiers, prefixes and values are rejected
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"modifier of another type":     "family:not=Doe",
+		"unknown modifier":             "birthdate:exact=1970",
+		"invalid prefix":               "birthdate=xx1970",
+		"upper case prefix":            "birthdate=GE1970",
+		"prefix without a date":        "birthdate=ge",
+		"invalid date":                 "birthdate=1970-13",
+		"one invalid date in a list":   "birthdate=1970,soon",
+		"empty value":                  "family=",
+		"empty value in a list":        "gender=male,",
+		"code outside the set":         "gender=M",
+		"missing is not boolean":       "family:missing=yes",
+		"negative count":               "_count=-1",
+		"invalid total":                "_total=some",
+		"sort by unsortable parameter": "_sort=gender",
+		"sort twice by a parameter":    "_sort=family,-family",
+		"include modifier":             "_include:iterate=Patient:organization",
+		"invalid include":              "_include=Patient",
+		"invalid page token":           "_page_token=not-a-token",
+	}
+	for name, query := range cases {
+		t.Run(name, func(t *testing.T) {
+			values, err := url.ParseQuery(query)
+			require.NoError(t, err)
+
+			parsed, err := Parse(values, testDefinitions)
+
+			assert.Error(t, err)
+			assert.Nil(t, parsed)
+		})
+	}
+}
+
+// TestParse_Controls tests the result parameters that are not search criteria
+func TestParse_Controls(t *testing.T) {
+	values := url.Values{
+		"_count":      {"5000"},
+		"_sort":       {"-birthdate,family"},
+		"_total":      {"accurate"},
+		"_include":    {"Patient:organization:Organization"},
+		"_revinclude": {"Observation:subject"},
+	}
+
+	query, err := Parse(values, testDefinitions)
+
+	require.NoError(t, err)
+	assert.Equal(t, MaxCount, query.Count)
+	assert.Equal(t, []SortKey{{Name: "birthdate", Descending: true}, {Name: "family"}}, query.Sort)
+	assert.Equal(t, TotalAccurate, query.Total)
+	assert.Equal(t, []Include{{Source: "Patient", Param: "organization", Target: "Organization"}}, query.Include)
+	assert.Equal(t, []Include{{Source: "Observation", Param: "subject"}}, query.RevInclude)
+}
+
+// TestParse_PageTokens tests offset and cursor page tokens, which must match the _sort
+func TestParse_PageTokens(t *testing.T) {
+	query, err := Parse(url.Values{PageTokenParam: {EncodePageToken(20)}}, testDefinitions)
+	require.NoError(t, err)
+	assert.Equal(t, 20, query.Offset)
+	assert.Nil(t, query.Cursor)
+
+	cursor := Cursor{Sort: "-birthdate", Values: []string{"1970-01-01", "p1"}}
+	query, err = Parse(url.Values{PageTokenParam: {EncodeCursor(cursor)}, "_sort": {"-birthdate"}}, testDefinitions)
+	require.NoError(t, err)
+	assert.Equal(t, &cursor, query.Cursor)
+
+	_, err = Parse(url.Values{PageTokenParam: {EncodeCursor(cursor)}, "_sort": {"family"}}, testDefinitions)
+	assert.EqualError(t, err, "page token does not match _sort")
+}
+
+// TestSplitPrefix tests that only the known two-letter prefixes are split off
+func TestSplitPrefix(t *testing.T) {
+	cases := map[string]struct {
+		prefix Prefix
+		value  string
+	}{
+		"1970-01-01":   {PrefixEq, "1970-01-01"},
+		"eq1970-01-01": {PrefixEq, "1970-01-01"},
+		"ne1970":       {PrefixNe, "1970"},
+		"lt1970":       {PrefixLt, "1970"},
+		"gt1970":       {PrefixGt, "1970"},
+		"le1970":       {PrefixLe, "1970"},
+		"ge1970":       {PrefixGe, "1970"},
+		"sa1970":       {PrefixSa, "1970"},
+		"eb1970":       {PrefixEb, "1970"},
+		"ap1970":       {PrefixAp, "1970"},
+		"xx1970":       {PrefixEq, "xx1970"},
+		"Ge1970":       {PrefixEq, "Ge1970"},
+		"ge":           {PrefixEq, "ge"},
+	}
+	for value, tc := range cases {
+		t.Run(value, func(t *testing.T) {
+			prefix, rest := SplitPrefix(value)
+
+			assert.Equal(t, tc.prefix, prefix)
+			assert.Equal(t, tc.value, rest)
+		})
+	}
+}
+
+// TestParseToken tests the [system|]code forms of token values
+func TestParseToken(t *testing.T) {
+	cases := map[string]struct {
+		system, code string
+		hasSystem    bool
+	}{
+		"123":                 {"", "123", false},
+		"urn:mrn|123":         {"urn:mrn", "123", true},
+		"|123":                {"", "123", true},
+		"urn:mrn|":            {"urn:mrn", "", true},
+		"http://a.org/x|1|2":  {"http://a.org/x", "1|2", true},
+		"http://a.org/x|a,b":  {"http://a.org/x", "a,b", true},
+		"http://a.org/x?q=1|": {"http://a.org/x?q=1", "", true},
+	}
+	for value, tc := range cases {
+		t.Run(value, func(t *testing.T) {
+			system, code, hasSystem := ParseToken(value)
+
+			assert.Equal(t, tc.system, system)
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.hasSystem, hasSystem)
+		})
+	}
+}
+
+// TestParse_TokenSystems tests that system|value, |value and system| reach the query unchanged
+func TestParse_TokenSystems(t *testing.T) {
+	values := url.Values{"identifier": {"urn:mrn|123,|456", "urn:ssn|"}}
+
+	query, err := Parse(values, testDefinitions)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Param{
+		{Name: "identifier", Type: TypeToken, Values: []string{"urn:mrn|123", "|456"}},
+		{Name: "identifier", Type: TypeToken, Values: []string{"urn:ssn|"}},
+	}, query.Params)
+
+	_, err = Parse(url.Values{"gender": {"http://hl7.org/fhir/administrative-gender|"}}, testDefinitions)
+	assert.Error(t, err, "a code-restricted token needs a code")
+}
+
+// TestParseDateRange tests that a date covers the whole period of the precision it was given
+func TestParseDateRange(t *testing.T) {
+	cases := map[string]struct {
+		start, end string
+	}{
+		"2024":                          {"2024-01-01T00:00:00Z", "2025-01-01T00:00:00Z"},
+		"2024-02":                       {"2024-02-01T00:00:00Z", "2024-03-01T00:00:00Z"},
+		"2024-12":                       {"2024-12-01T00:00:00Z", "2025-01-01T00:00:00Z"},
+		"2024-02-28":                    {"2024-02-28T00:00:00Z", "2024-02-29T00:00:00Z"},
+		"2024-02-29":                    {"2024-02-29T00:00:00Z", "2024-03-01T00:00:00Z"},
+		"2024-02-29T10:30":              {"2024-02-29T10:30:00Z", "2024-02-29T10:31:00Z"},
+		"2024-02-29T10:30+02:00":        {"2024-02-29T10:30:00+02:00", "2024-02-29T10:31:00+02:00"},
+		"2024-02-29T10:30:15":           {"2024-02-29T10:30:15Z", "2024-02-29T10:30:16Z"},
+		"2024-02-29T10:30:15Z":          {"2024-02-29T10:30:15Z", "2024-02-29T10:30:16Z"},
+		"2024-02-29T10:30:15.250-05:00": {"2024-02-29T10:30:15.25-05:00", "2024-02-29T10:30:15.251-05:00"},
+	}
+	for value, tc := range cases {
+		t.Run(value, func(t *testing.T) {
+			start, end, err := ParseDateRange(value)
+
+			require.NoError(t, err)
+			assert.True(t, date(tc.start).Equal(start), "start %s", start)
+			assert.True(t, date(tc.end).Equal(end), "end %s", end)
+		})
+	}
+}
+
+// TestParseDateRange_Errors tests that values that are not FHIR dates are rejected
+func TestParseDateRange_Errors(t *testing.T) {
+	for _, value := range []string{"", "24", "2024-2", "2024-13", "2023-02-29", "2024-02-29T25:00", "2024/02/29", "today"} {
+		t.Run(value, func(t *testing.T) {
+			_, _, err := ParseDateRange(value)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestApproximateRange tests that ap widens a range by a tenth of its distance from now, and at least a day
+func TestApproximateRange(t *testing.T) {
+	cases := map[string]struct {
+		offset time.Duration
+		margin time.Duration
+	}{
+		"a thousand days ago":   {-1000 * 24 * time.Hour, 100 * 24 * time.Hour},
+		"a thousand days ahead": {1000 * 24 * time.Hour, 100 * 24 * time.Hour},
+		"recent":                {-48 * time.Hour, 24 * time.Hour},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now().Add(tc.offset)
+			end := start.Add(24 * time.Hour)
+
+			low, high := ApproximateRange(start, end)
+
+			assert.InDelta(t, tc.margin.Seconds(), start.Sub(low).Seconds(), 1)
+			assert.InDelta(t, tc.margin.Seconds(), high.Sub(end).Seconds(), 1)
+		})
+	}
+}
+
+// TestSplitValues tests splitting on commas that are not escaped
+func TestSplitValues(t *testing.T) {
+	cases := map[string][]string{
+		"a":        {"a"},
+		"a,b,c":    {"a", "b", "c"},
+		`a\,b,c`:   {"a,b", "c"},
+		`a\b`:      {`a\b`},
+		"a,":       {"a", ""},
+		"":         {""},
+		`trail\\,`: {`trail\,`},
+	}
+	for raw, expected := range cases {
+		t.Run(raw, func(t *testing.T) {
+			assert.Equal(t, expected, SplitValues(raw))
+		})
+	}
+}
+
+// TestParseInclude tests the Source:param[:Target] form of _include and _revinclude
+func TestParseInclude(t *testing.T) {
+	include, err := ParseInclude("Observation:subject:Patient")
+	require.NoError(t, err)
+	assert.Equal(t, Include{Source: "Observation", Param: "subject", Target: "Patient"}, include)
+	assert.Equal(t, "Observation:subject:Patient", include.String())
+
+	include, err = ParseInclude("Patient:organization")
+	require.NoError(t, err)
+	assert.Equal(t, "Patient:organization", include.String())
+
+	for _, value := range []string{"Patient", "Patient:", ":organization", "Patient:*", "Patient:a:b:c"} {
+		_, err := ParseInclude(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestPageTokens tests that page tokens round-trip and reject tampered values
+func TestPageTokens(t *testing.T) {
+	offset, err := DecodePageToken(EncodePageToken(40))
+	require.NoError(t, err)
+	assert.Equal(t, 40, offset)
+
+	cursor := Cursor{Sort: "family", Values: []string{"Doe", "p1"}, Backward: true}
+	token := EncodeCursor(cursor)
+	assert.True(t, IsCursorToken(token))
+	assert.False(t, IsCursorToken(EncodePageToken(40)))
+	decoded, err := DecodeCursor(token)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	for _, token := range []string{"!", EncodeCursor(cursor), "b2Zmc2V0Oi0x", "b2Zmc2V0OmFiYw"} {
+		_, err := DecodePageToken(token)
+		assert.Error(t, err, token)
+	}
+	_, err = DecodeCursor(EncodePageToken(40))
+	assert.Error(t, err)
+}
@@ -0,0 +1,200 @@
+package fhirsubset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Summary is a value of the _summary parameter
+type Summary string
+
+const (
+	SummaryTrue  Summary = "true"  // Only the elements marked as summary elements
+	SummaryText  Summary = "text"  // Only the narrative, id and meta
+	SummaryData  Summary = "data"  // Everything but the narrative
+	SummaryCount Summary = "count" // Only the number of matches of a search
+	SummaryFalse Summary = "false" // The full resource
+)
+
+const (
+	// TagSystem is the code system of the tag marking a subsetted resource
+	TagSystem = "http://terminology.hl7.org/CodeSystem/v3-ObservationValue"
+	// TagCode is the code of the tag marking a subsetted resource
+	TagCode = "SUBSETTED"
+)
+
+// alwaysKept are the elements every subset keeps
+var alwaysKept = []string{"resourceType", "id", "meta", "implicitRules"}
+
+// Projection selects the elements of resources returned by a read or search
+type Projection struct {
+	Summary  Summary
+	Elements []string // Top-level elements requested with _elements
+}
+
+// Parse reads the _summary and _elements parameters. Only one of them may be given.
+func Parse(values url.Values) (Projection, error) {
+	var projection Projection
+	if raw := values.Get("_summary"); raw != "" {
+		summary := Summary(raw)
+		switch summary {
+		case SummaryTrue, SummaryText, SummaryData, SummaryCount, SummaryFalse:
+			projection.Summary = summary
+		default:
+			return Projection{}, fmt.Errorf("invalid _summary value %q, expected true, text, data, count or false", raw)
+		}
+	}
+	for _, raw := range values["_elements"] {
+		for _, element := range strings.Split(raw, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				projection.Elements = append(projection.Elements, element)
+			}
+		}
+	}
+	if len(projection.Elements) > 0 && projection.Summary != "" && projection.Summary != SummaryFalse {
+		return Projection{}, errors.New("_summary and _elements cannot be combined")
+	}
+	return projection, nil
+}
+
+// Subsets reports whether the projection leaves out elements of a resource
+func (p Projection) Subsets() bool {
+	switch p.Summary {
+	case SummaryTrue, SummaryText, SummaryData:
+		return true
+	}
+	return len(p.Elements) > 0
+}
+
+// Apply returns the resource with only the elements the projection selects, tagged as
+// SUBSETTED, keeping the order of the elements. summaryElements are the summary elements of
+// the resource type, with choice elements named without their type suffix. A projection that
+// does not subset returns the resource unchanged.
+func (p Projection) Apply(resource []byte, summaryElements []string) ([]byte, error) {
+	if !p.Subsets() {
+		return resource, nil
+	}
+	keys, values, err := decodeObject(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	write := func(key string, value json.RawMessage) {
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	tagged := false
+	for i, key := range keys {
+		if key == "meta" {
+			meta, err := tagMeta(values[i])
+			if err != nil {
+				return nil, err
+			}
+			write(key, meta)
+			tagged = true
+			continue
+		}
+		if !p.keeps(key, summaryElements) {
+			continue
+		}
+		write(key, values[i])
+		if key == "id" && !slices.Contains(keys, "meta") {
+			meta, _ := tagMeta(nil)
+			write("meta", meta)
+			tagged = true
+		}
+	}
+	if !tagged {
+		meta, _ := tagMeta(nil)
+		write("meta", meta)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// keeps reports whether the projection keeps a top-level element
+func (p Projection) keeps(key string, summaryElements []string) bool {
+	if slices.Contains(alwaysKept, key) {
+		return true
+	}
+	if len(p.Elements) > 0 {
+		return matchesAny(key, p.Elements)
+	}
+	switch p.Summary {
+	case SummaryText:
+		return key == "text"
+	case SummaryData:
+		return key != "text"
+	default:
+		return matchesAny(key, summaryElements)
+	}
+}
+
+// matchesAny reports whether a JSON property is one of the elements, where a choice element
+// such as deceased matches each of its typed properties such as deceasedBoolean
+func matchesAny(key string, elements []string) bool {
+	for _, element := range elements {
+		if key == element {
+			return true
+		}
+		if rest, ok := strings.CutPrefix(key, element); ok && rest != "" && unicode.IsUpper(rune(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagMeta adds the SUBSETTED tag to a meta element, creating the element when it is nil
+func tagMeta(raw json.RawMessage) (json.RawMessage, error) {
+	meta := map[string]interface{}{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("invalid meta element: %w", err)
+		}
+	}
+	tags, _ := meta["tag"].([]interface{})
+	for _, tag := range tags {
+		if coding, ok := tag.(map[string]interface{}); ok && coding["system"] == TagSystem && coding["code"] == TagCode {
+			return json.Marshal(meta)
+		}
+	}
+	meta["tag"] = append(tags, map[string]interface{}{"system": TagSystem, "code": TagCode})
+	return json.Marshal(meta)
+}
+
+// decodeObject splits a JSON object into its property names and values, in document order
+func decodeObject(data []byte) ([]string, []json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, errors.New("resource must be a JSON object")
+	}
+	var keys []string
+	var values []json.RawMessage
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid resource: %w", err)
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("invalid resource: %w", err)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
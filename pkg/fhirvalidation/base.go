@@ -0,0 +1,128 @@
+package fhirvalidation
+
+// valueSets holds the codes of the value sets used by required bindings in the base
+// specification. Bindings to other value sets are not checked.
+var valueSets = map[string]map[string]bool{
+	"http://hl7.org/fhir/ValueSet/administrative-gender": codes("male", "female", "other", "unknown"),
+	"http://hl7.org/fhir/ValueSet/identifier-use":        codes("usual", "official", "temp", "secondary", "old"),
+	"http://hl7.org/fhir/ValueSet/name-use":              codes("usual", "official", "temp", "nickname", "anonymous", "old", "maiden"),
+	"http://hl7.org/fhir/ValueSet/contact-point-system":  codes("phone", "fax", "email", "pager", "url", "sms", "other"),
+	"http://hl7.org/fhir/ValueSet/contact-point-use":     codes("home", "work", "temp", "old", "mobile"),
+	"http://hl7.org/fhir/ValueSet/address-use":           codes("home", "work", "temp", "old", "billing"),
+	"http://hl7.org/fhir/ValueSet/address-type":          codes("postal", "physical", "both"),
+	"http://hl7.org/fhir/ValueSet/link-type":             codes("replaced-by", "replaces", "refer", "seealso"),
+	"http://hl7.org/fhir/ValueSet/observation-status": codes("registered", "preliminary", "final", "amended", "corrected",
+		"cancelled", "entered-in-error", "unknown"),
+	"http://hl7.org/fhir/ValueSet/encounter-status": codes("planned", "arrived", "triaged", "in-progress", "onleave",
+		"finished", "cancelled", "entered-in-error", "unknown"),
+}
+
+// baseProfiles are the parts of the base specification checked for every resource of a type
+var baseProfiles = map[string]*Profile{
+	"Patient": {
+		URL:  "http://hl7.org/fhir/StructureDefinition/Patient",
+		Name: "Patient",
+		Type: "Patient",
+		Elements: []Element{
+			{Path: "Patient.identifier.use", Max: "1", Type: types("code"), Binding: required("identifier-use")},
+			{Path: "Patient.active", Max: "1", Type: types("boolean")},
+			{Path: "Patient.name.use", Max: "1", Type: types("code"), Binding: required("name-use")},
+			{Path: "Patient.name.family", Max: "1", Type: types("string")},
+			{Path: "Patient.telecom", Constraint: []Constraint{{
+				Key:        "cpt-2",
+				Severity:   "error",
+				Human:      "A system is required if a value is provided.",
+				Expression: "value.empty() or system.exists()",
+			}}},
+			{Path: "Patient.telecom.system", Max: "1", Type: types("code"), Binding: required("contact-point-system")},
+			{Path: "Patient.telecom.use", Max: "1", Type: types("code"), Binding: required("contact-point-use")},
+			{Path: "Patient.gender", Max: "1", Type: types("code"), Binding: required("administrative-gender")},
+			{Path: "Patient.birthDate", Max: "1", Type: types("date")},
+			{Path: "Patient.deceased[x]", Max: "1", Type: types("boolean", "dateTime")},
+			{Path: "Patient.address.use", Max: "1", Type: types("code"), Binding: required("address-use")},
+			{Path: "Patient.address.type", Max: "1", Type: types("code"), Binding: required("address-type")},
+			{Path: "Patient.multipleBirth[x]", Max: "1", Type: types("boolean", "integer")},
+			{Path: "Patient.contact", Constraint: []Constraint{{
+				Key:        "pat-1",
+				Severity:   "error",
+				Human:      "SHALL at least contain a contact's details or a reference to an organization",
+				Expression: "name.exists() or telecom.exists() or address.exists() or organization.exists()",
+			}}},
+			{Path: "Patient.contact.gender", Max: "1", Type: types("code"), Binding: required("administrative-gender")},
+			{Path: "Patient.communication.language", Min: 1, Max: "1"},
+			{Path: "Patient.link.other", Min: 1, Max: "1"},
+			{Path: "Patient.link.type", Min: 1, Max: "1", Type: types("code"), Binding: required("link-type")},
+		},
+	},
+	"Observation": {
+		URL:  "http://hl7.org/fhir/StructureDefinition/Observation",
+		Name: "Observation",
+		Type: "Observation",
+		Elements: []Element{
+			{Path: "Observation.status", Min: 1, Max: "1", Type: types("code"), Binding: required("observation-status")},
+			{Path: "Observation.code", Min: 1, Max: "1"},
+			{Path: "Observation.subject", Max: "1"},
+			{Path: "Observation.effective[x]", Max: "1", Type: types("dateTime", "Period", "Timing", "instant")},
+			{Path: "Observation.issued", Max: "1", Type: types("instant")},
+		},
+	},
+	"Encounter": {
+		URL:  "http://hl7.org/fhir/StructureDefinition/Encounter",
+		Name: "Encounter",
+		Type: "Encounter",
+		Elements: []Element{
+			{Path: "Encounter.status", Min: 1, Max: "1", Type: types("code"), Binding: required("encounter-status")},
+			{Path: "Encounter.class", Min: 1, Max: "1"},
+			{Path: "Encounter.subject", Max: "1"},
+		},
+	},
+	"Practitioner": {
+		URL:  "http://hl7.org/fhir/StructureDefinition/Practitioner",
+		Name: "Practitioner",
+		Type: "Practitioner",
+		Elements: []Element{
+			{Path: "Practitioner.identifier.use", Max: "1", Type: types("code"), Binding: required("identifier-use")},
+			{Path: "Practitioner.active", Max: "1", Type: types("boolean")},
+			{Path: "Practitioner.name.use", Max: "1", Type: types("code"), Binding: required("name-use")},
+			{Path: "Practitioner.telecom.system", Max: "1", Type: types("code"), Binding: required("contact-point-system")},
+			{Path: "Practitioner.gender", Max: "1", Type: types("code"), Binding: required("administrative-gender")},
+			{Path: "Practitioner.birthDate", Max: "1", Type: types("date")},
+		},
+	},
+	"Organization": {
+		URL:  "http://hl7.org/fhir/StructureDefinition/Organization",
+		Name: "Organization",
+		Type: "Organization",
+		Elements: []Element{
+			{Path: "Organization", Constraint: []Constraint{{
+				Key:        "org-1",
+				Severity:   "error",
+				Human:      "The organization SHALL at least have a name or an identifier, and possibly more than one",
+				Expression: "identifier.exists() or name.exists()",
+			}}},
+			{Path: "Organization.active", Max: "1", Type: types("boolean")},
+			{Path: "Organization.name", Max: "1", Type: types("string")},
+			{Path: "Organization.partOf", Max: "1"},
+		},
+	},
+}
+
+func codes(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func types(codes ...string) []TypeRef {
+	refs := make([]TypeRef, len(codes))
+	for i, code := range codes {
+		refs[i] = TypeRef{Code: code}
+	}
+	return refs
+}
+
+func required(valueSet string) *Binding {
+	return &Binding{Strength: "required", ValueSet: "http://hl7.org/fhir/ValueSet/" + valueSet}
+}
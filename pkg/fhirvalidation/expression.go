@@ -0,0 +1,233 @@
+package fhirvalidation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// errUnsupportedExpression is returned for invariants outside the supported FHIRPath subset
+var errUnsupportedExpression = errors.New("unsupported FHIRPath expression")
+
+// evaluate evaluates an invariant against a JSON value. The supported subset of FHIRPath is
+// paths ending in exists(), empty() or hasValue(), optionally followed by not(), combined
+// with and, or, xor and implies and grouped with parentheses.
+func evaluate(expression string, context interface{}) (bool, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return false, err
+	}
+	p := &parser{tokens: tokens, context: context}
+	result, err := p.parseImplies()
+	if err != nil {
+		return false, err
+	}
+	if p.pos != len(p.tokens) {
+		return false, fmt.Errorf("%w: unexpected %q", errUnsupportedExpression, p.tokens[p.pos])
+	}
+	return result, nil
+}
+
+// tokenize splits an expression into identifiers and the punctuation . ( )
+func tokenize(expression string) ([]string, error) {
+	var tokens []string
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '.' || r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q", errUnsupportedExpression, r)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens  []string
+	pos     int
+	context interface{}
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("%w: expected %q", errUnsupportedExpression, token)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseImplies() (bool, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	for p.peek() == "implies" {
+		p.pos++
+		right, err := p.parseOr()
+		if err != nil {
+			return false, err
+		}
+		left = !left || right
+	}
+	return left, nil
+}
+
+func (p *parser) parseOr() (bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return false, err
+	}
+	for p.peek() == "or" || p.peek() == "xor" {
+		op := p.peek()
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		if op == "or" {
+			left = left || right
+		} else {
+			left = left != right
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (bool, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return false, err
+	}
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return false, err
+		}
+		left = left && right
+	}
+	return left, nil
+}
+
+// parseTerm parses a parenthesised expression or a path ending in a function call
+func (p *parser) parseTerm() (bool, error) {
+	var result bool
+	if p.peek() == "(" {
+		p.pos++
+		var err error
+		if result, err = p.parseImplies(); err != nil {
+			return false, err
+		}
+		if err := p.expect(")"); err != nil {
+			return false, err
+		}
+	} else {
+		var path []string
+	path:
+		for {
+			name := p.peek()
+			if name == "" || name == "(" || name == ")" || name == "." {
+				return false, fmt.Errorf("%w: expected a name", errUnsupportedExpression)
+			}
+			p.pos++
+			if p.peek() == "(" {
+				values := collect(p.context, path)
+				if err := p.call(); err != nil {
+					return false, err
+				}
+				switch name {
+				case "exists":
+					result = len(values) > 0
+				case "empty":
+					result = len(values) == 0
+				case "hasValue":
+					result = len(values) == 1 && isPrimitive(values[0])
+				default:
+					return false, fmt.Errorf("%w: function %s()", errUnsupportedExpression, name)
+				}
+				break path
+			}
+			path = append(path, name)
+			if err := p.expect("."); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// Boolean results may be negated with a trailing .not()
+	for p.peek() == "." && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1] == "not" {
+		p.pos += 2
+		if err := p.call(); err != nil {
+			return false, err
+		}
+		result = !result
+	}
+	return result, nil
+}
+
+// call consumes the parentheses of an argument-less function call
+func (p *parser) call() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	return p.expect(")")
+}
+
+// collect returns the values reached by following the path from the context, flattening arrays
+func collect(context interface{}, path []string) []interface{} {
+	values := flatten(context)
+	for _, name := range path {
+		var next []interface{}
+		for _, value := range values {
+			if object, ok := value.(map[string]interface{}); ok {
+				next = append(next, flatten(object[name])...)
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+func flatten(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []interface{}{v}
+	default:
+		return []interface{}{v}
+	}
+}
+
+func isPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
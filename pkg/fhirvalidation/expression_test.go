@@ -0,0 +1,61 @@
+package fhirvalidation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvaluate tests the supported FHIRPath subset against a Patient name
+func TestEvaluate(t *testing.T) {
+	var context interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"family":"Doe","given":["Ann"],"text":" ","period":{"start":"2020"}}`), &context))
+
+	cases := map[string]bool{
+		"family.exists()":                            true,
+		"suffix.exists()":                            false,
+		"suffix.empty()":                             true,
+		"text.empty()":                               true,
+		"family.hasValue()":                          true,
+		"given.hasValue()":                           true,
+		"period.hasValue()":                          false,
+		"period.start.exists()":                      true,
+		"period.end.exists().not()":                  true,
+		"family.exists() or given.exists()":          true,
+		"suffix.exists() or prefix.exists()":         false,
+		"family.exists() and suffix.exists()":        false,
+		"family.exists() xor given.exists()":         false,
+		"suffix.exists() implies family.empty()":     true,
+		"family.exists() implies suffix.exists()":    false,
+		"(suffix.exists() or family.exists()).not()": false,
+	}
+	for expression, expected := range cases {
+		t.Run(expression, func(t *testing.T) {
+			result, err := evaluate(expression, context)
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, result)
+		})
+	}
+}
+
+// TestEvaluate_Unsupported tests that expressions outside the subset are reported as unsupported
+func TestEvaluate_Unsupported(t *testing.T) {
+	for _, expression := range []string{
+		"name.count() > 1",
+		"telecom.where(system = 'phone').exists()",
+		"family.startsWith('D')",
+		"family.exists() family.exists()",
+		"(family.exists()",
+		"family.",
+		"",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := evaluate(expression, map[string]interface{}{})
+
+			assert.ErrorIs(t, err, errUnsupportedExpression)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package fhirvalidation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profile is the subset of a StructureDefinition the validator enforces
+type Profile struct {
+	URL      string
+	Name     string
+	Type     string
+	Elements []Element
+}
+
+// Element is the subset of an ElementDefinition the validator enforces: cardinality,
+// primitive types, required bindings and invariants
+type Element struct {
+	Path       string       `json:"path"`
+	SliceName  string       `json:"sliceName,omitempty"`
+	Min        int          `json:"min"`
+	Max        string       `json:"max,omitempty"`
+	Type       []TypeRef    `json:"type,omitempty"`
+	Binding    *Binding     `json:"binding,omitempty"`
+	Constraint []Constraint `json:"constraint,omitempty"`
+}
+
+// TypeRef names an allowed data type of an element
+type TypeRef struct {
+	Code string `json:"code"`
+}
+
+// Binding ties a coded element to a value set
+type Binding struct {
+	Strength string `json:"strength"`
+	ValueSet string `json:"valueSet"`
+}
+
+// Constraint is an invariant expressed in FHIRPath
+type Constraint struct {
+	Key        string `json:"key"`
+	Severity   string `json:"severity"`
+	Human      string `json:"human"`
+	Expression string `json:"expression"`
+}
+
+type elementList struct {
+	Element []Element `json:"element"`
+}
+
+type structureDefinition struct {
+	ResourceType string       `json:"resourceType"`
+	URL          string       `json:"url"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Snapshot     *elementList `json:"snapshot"`
+	Differential *elementList `json:"differential"`
+}
+
+// ParseProfile reads a StructureDefinition resource. The snapshot is used when present,
+// otherwise the differential. Sliced elements are not supported and are skipped.
+func ParseProfile(data []byte) (*Profile, error) {
+	var sd structureDefinition
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return nil, fmt.Errorf("failed to parse StructureDefinition: %w", err)
+	}
+	if sd.ResourceType != "StructureDefinition" {
+		return nil, fmt.Errorf("expected a StructureDefinition, got %q", sd.ResourceType)
+	}
+	if sd.URL == "" || sd.Type == "" {
+		return nil, errors.New("StructureDefinition must have a url and a type")
+	}
+
+	elements := sd.Differential
+	if sd.Snapshot != nil && len(sd.Snapshot.Element) > 0 {
+		elements = sd.Snapshot
+	}
+	profile := &Profile{URL: sd.URL, Name: sd.Name, Type: sd.Type}
+	if elements != nil {
+		for _, element := range elements.Element {
+			if element.SliceName != "" {
+				continue
+			}
+			if element.Path != sd.Type && !strings.HasPrefix(element.Path, sd.Type+".") {
+				return nil, fmt.Errorf("element %q does not belong to type %s", element.Path, sd.Type)
+			}
+			profile.Elements = append(profile.Elements, element)
+		}
+	}
+	return profile, nil
+}
+
+// LoadProfiles reads every *.json StructureDefinition in dir, in file name order.
+// A missing directory yields no profiles.
+func LoadProfiles(dir string) ([]*Profile, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	profiles := make([]*Profile, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read profile %s: %w", file, err)
+		}
+		profile, err := ParseProfile(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %w", file, err)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// canonical strips the version suffix from a canonical URL
+func canonical(url string) string {
+	if idx := strings.Index(url, "|"); idx >= 0 {
+		return url[:idx]
+	}
+	return url
+}
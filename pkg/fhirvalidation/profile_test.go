@@ -0,0 +1,128 @@
+package fhirvalidation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usCorePatient = "http://hl7.org/fhir/us/core/StructureDefinition/us-core-patient"
+
+// TestLoadProfiles tests that the profiles shipped in the repository load
+func TestLoadProfiles(t *testing.T) {
+	profiles, err := LoadProfiles(filepath.Join("..", "..", "profiles"))
+
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	profile := profiles[0]
+	assert.Equal(t, usCorePatient, profile.URL)
+	assert.Equal(t, "Patient", profile.Type)
+
+	paths := make([]string, len(profile.Elements))
+	for i, element := range profile.Elements {
+		paths[i] = element.Path
+	}
+	assert.Contains(t, paths, "Patient.identifier.system")
+	assert.Contains(t, paths, "Patient.gender")
+	for _, element := range profile.Elements {
+		if element.Path == "Patient.name" {
+			assert.Equal(t, 1, element.Min)
+			require.Len(t, element.Constraint, 1)
+			assert.Equal(t, "us-core-8", element.Constraint[0].Key)
+		}
+	}
+}
+
+// TestLoadProfiles_Directory tests missing directories, file ordering and invalid files
+func TestLoadProfiles_Directory(t *testing.T) {
+	profiles, err := LoadProfiles(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"),
+		[]byte(`{"resourceType":"StructureDefinition","url":"http://example.org/b","type":"Patient"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"),
+		[]byte(`{"resourceType":"StructureDefinition","url":"http://example.org/a","type":"Patient"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`not a profile`), 0o600))
+
+	profiles, err = LoadProfiles(dir)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "http://example.org/a", profiles[0].URL)
+	assert.Equal(t, "http://example.org/b", profiles[1].URL)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"resourceType":"Patient"}`), 0o600))
+	_, err = LoadProfiles(dir)
+	assert.ErrorContains(t, err, "c.json")
+}
+
+// TestParseProfile tests which elements a StructureDefinition contributes
+func TestParseProfile(t *testing.T) {
+	cases := map[string]struct {
+		data     string
+		expected []string
+	}{
+		"snapshot wins over differential": {
+			`{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient",` +
+				`"snapshot":{"element":[{"path":"Patient"},{"path":"Patient.name","min":1}]},` +
+				`"differential":{"element":[{"path":"Patient.gender","min":1}]}}`,
+			[]string{"Patient", "Patient.name"},
+		},
+		"differential without a snapshot": {
+			`{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient",` +
+				`"differential":{"element":[{"path":"Patient.gender","min":1}]}}`,
+			[]string{"Patient.gender"},
+		},
+		"slices are skipped": {
+			`{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient",` +
+				`"differential":{"element":[{"path":"Patient.identifier"},{"path":"Patient.identifier","sliceName":"mrn","min":1}]}}`,
+			[]string{"Patient.identifier"},
+		},
+		"no elements": {
+			`{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient"}`,
+			nil,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile, err := ParseProfile([]byte(tc.data))
+
+			require.NoError(t, err)
+			var paths []string
+			for _, element := range profile.Elements {
+				paths = append(paths, element.Path)
+			}
+			assert.Equal(t, tc.expected, paths)
+		})
+	}
+}
+
+// TestParseProfile_Errors tests that resources that are not usable profiles are rejected
+func TestParseProfile_Errors(t *testing.T) {
+	cases := map[string]string{
+		"not JSON":        `<StructureDefinition/>`,
+		"other resource":  `{"resourceType":"Patient","url":"http://example.org/p","type":"Patient"}`,
+		"missing url":     `{"resourceType":"StructureDefinition","type":"Patient"}`,
+		"missing type":    `{"resourceType":"StructureDefinition","url":"http://example.org/p"}`,
+		"foreign element": `{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient","differential":{"element":[{"path":"Observation.code"}]}}`,
+		"path prefix":     `{"resourceType":"StructureDefinition","url":"http://example.org/p","type":"Patient","differential":{"element":[{"path":"PatientX.name"}]}}`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile, err := ParseProfile([]byte(data))
+
+			assert.Error(t, err)
+			assert.Nil(t, profile)
+		})
+	}
+}
+
+// TestCanonical tests that versions are ignored when comparing canonical URLs
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, usCorePatient, canonical(usCorePatient+"|6.1.0"))
+	assert.Equal(t, usCorePatient, canonical(usCorePatient))
+}
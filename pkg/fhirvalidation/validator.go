@@ -0,0 +1,415 @@
+package fhirvalidation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+var (
+	dateRegex     = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+	dateTimeRegex = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2}(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}))?)?)?$`)
+)
+
+// Validator checks resources against the base specification and StructureDefinition profiles
+type Validator struct {
+	profiles map[string]*Profile
+	enforced []*Profile
+}
+
+// NewValidator creates a validator that knows the given profiles. Profiles are only applied
+// when enforced, requested for a validation or declared in a resource's meta.profile.
+func NewValidator(profiles ...*Profile) *Validator {
+	v := &Validator{profiles: make(map[string]*Profile, len(profiles))}
+	for _, profile := range profiles {
+		v.profiles[canonical(profile.URL)] = profile
+	}
+	return v
+}
+
+// Enforce makes the profiles with the given canonical URLs apply to every resource of their type
+func (v *Validator) Enforce(urls ...string) error {
+	for _, url := range urls {
+		profile, ok := v.profiles[canonical(url)]
+		if !ok {
+			return fmt.Errorf("profile %s is not loaded", url)
+		}
+		v.enforced = append(v.enforced, profile)
+	}
+	return nil
+}
+
+// Validate checks a JSON resource and returns the issues found, which may be empty.
+// An error is only returned when the input is not a JSON object.
+func (v *Validator) Validate(resource []byte, profiles ...string) ([]fhir.OperationOutcomeIssue, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(resource, &document); err != nil {
+		return nil, fmt.Errorf("resource is not a JSON object: %w", err)
+	}
+
+	resourceType, _ := document["resourceType"].(string)
+	if resourceType == "" {
+		return []fhir.OperationOutcomeIssue{
+			newIssue(fhir.IssueSeverityError, fhir.IssueTypeRequired, "resourceType", "resourceType is required"),
+		}, nil
+	}
+
+	issues := checkEmpty(document, resourceType)
+	if base, ok := baseProfiles[resourceType]; ok {
+		issues = append(issues, validateProfile(document, base, "")...)
+	}
+
+	applied := make(map[string]bool)
+	for _, profile := range v.enforced {
+		if profile.Type == resourceType && !applied[profile.URL] {
+			applied[profile.URL] = true
+			issues = append(issues, validateProfile(document, profile, profile.URL)...)
+		}
+	}
+
+	// Explicitly requested profiles must be known; declared ones only warn when they are not
+	requested := make([]string, 0, len(profiles))
+	for _, url := range profiles {
+		requested = append(requested, canonical(url))
+	}
+	declared := declaredProfiles(document)
+	for i, url := range append(requested, declared...) {
+		if applied[url] {
+			continue
+		}
+		applied[url] = true
+		profile, ok := v.profiles[url]
+		switch {
+		case !ok && i < len(requested):
+			issues = append(issues, newIssue(fhir.IssueSeverityError, fhir.IssueTypeNotSupported, resourceType,
+				"Profile %s is not known to this server", url))
+		case !ok:
+			issues = append(issues, newIssue(fhir.IssueSeverityWarning, fhir.IssueTypeNotSupported, resourceType+".meta.profile",
+				"Profile %s is not known to this server and was not checked", url))
+		case profile.Type != resourceType:
+			issues = append(issues, newIssue(fhir.IssueSeverityError, fhir.IssueTypeInvalid, resourceType,
+				"Profile %s applies to %s, not %s", url, profile.Type, resourceType))
+		default:
+			issues = append(issues, validateProfile(document, profile, profile.URL)...)
+		}
+	}
+	return issues, nil
+}
+
+// HasErrors reports whether any issue is an error or fatal
+func HasErrors(issues []fhir.OperationOutcomeIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == fhir.IssueSeverityError || issue.Severity == fhir.IssueSeverityFatal {
+			return true
+		}
+	}
+	return false
+}
+
+// node is a JSON value together with its FHIRPath location
+type node struct {
+	value    interface{}
+	location string
+	key      string
+}
+
+// validateProfile checks every element definition of a profile against the document
+func validateProfile(document map[string]interface{}, profile *Profile, url string) []fhir.OperationOutcomeIssue {
+	var issues []fhir.OperationOutcomeIssue
+	root := node{value: document, location: profile.Type}
+	for _, element := range profile.Elements {
+		segments := strings.Split(element.Path, ".")[1:]
+		if len(segments) == 0 {
+			issues = append(issues, checkConstraints([]node{root}, element, url)...)
+			continue
+		}
+
+		parents := []node{root}
+		for _, name := range segments[:len(segments)-1] {
+			var next []node
+			for _, parent := range parents {
+				next = append(next, children(parent, name)...)
+			}
+			parents = next
+		}
+
+		name := segments[len(segments)-1]
+		for _, parent := range parents {
+			if _, ok := parent.value.(map[string]interface{}); !ok {
+				continue
+			}
+			values := children(parent, name)
+			issues = append(issues, checkCardinality(parent, name, values, element, url)...)
+			for _, value := range values {
+				issues = append(issues, checkType(value, element, url)...)
+				issues = append(issues, checkBinding(value, element, url)...)
+			}
+			issues = append(issues, checkConstraints(values, element, url)...)
+		}
+	}
+	return issues
+}
+
+// children returns the values of a named child of an object, expanding arrays and choice types
+func children(parent node, name string) []node {
+	object, ok := parent.value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keys := []string{name}
+	if prefix, isChoice := strings.CutSuffix(name, "[x]"); isChoice {
+		keys = nil
+		for key := range object {
+			if rest, ok := strings.CutPrefix(key, prefix); ok && rest != "" && unicode.IsUpper(rune(rest[0])) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+	}
+
+	var nodes []node
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case nil:
+		case []interface{}:
+			for i, item := range value {
+				nodes = append(nodes, node{value: item, location: fmt.Sprintf("%s.%s[%d]", parent.location, key, i), key: key})
+			}
+		default:
+			nodes = append(nodes, node{value: value, location: parent.location + "." + key, key: key})
+		}
+	}
+	return nodes
+}
+
+func checkCardinality(parent node, name string, values []node, element Element, url string) []fhir.OperationOutcomeIssue {
+	location := parent.location + "." + strings.TrimSuffix(name, "[x]")
+	if len(values) < element.Min {
+		return []fhir.OperationOutcomeIssue{newIssue(fhir.IssueSeverityError, fhir.IssueTypeRequired, location,
+			"%s: minimum required = %d, but only found %d%s", element.Path, element.Min, len(values), fromProfile(url))}
+	}
+	if element.Max != "" && element.Max != "*" {
+		max, err := strconv.Atoi(element.Max)
+		if err == nil && len(values) > max {
+			return []fhir.OperationOutcomeIssue{newIssue(fhir.IssueSeverityError, fhir.IssueTypeStructure, location,
+				"%s: maximum allowed = %d, but found %d%s", element.Path, max, len(values), fromProfile(url))}
+		}
+	}
+	return nil
+}
+
+// checkType checks the format of primitive values. For choice elements the type is taken
+// from the property name, e.g. deceasedDateTime is a dateTime.
+func checkType(value node, element Element, url string) []fhir.OperationOutcomeIssue {
+	typeCode := ""
+	if prefix, isChoice := strings.CutSuffix(element.Path[strings.LastIndex(element.Path, ".")+1:], "[x]"); isChoice {
+		suffix := strings.TrimPrefix(value.key, prefix)
+		typeCode = strings.ToLower(suffix[:1]) + suffix[1:]
+		allowed := len(element.Type) == 0
+		for _, ref := range element.Type {
+			if ref.Code == typeCode {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return []fhir.OperationOutcomeIssue{newIssue(fhir.IssueSeverityError, fhir.IssueTypeStructure, value.location,
+				"%s: type %s is not allowed%s", element.Path, typeCode, fromProfile(url))}
+		}
+	} else if len(element.Type) == 1 {
+		typeCode = element.Type[0].Code
+	}
+
+	var problem string
+	switch typeCode {
+	case "boolean":
+		if _, ok := value.value.(bool); !ok {
+			problem = "must be a boolean"
+		}
+	case "integer", "positiveInt", "unsignedInt":
+		if number, ok := value.value.(float64); !ok || number != math.Trunc(number) {
+			problem = "must be an integer"
+		}
+	case "string":
+		if _, ok := value.value.(string); !ok {
+			problem = "must be a string"
+		}
+	case "code":
+		if code, ok := value.value.(string); !ok || code != strings.TrimSpace(code) {
+			problem = "must be a code without leading or trailing whitespace"
+		}
+	case "date":
+		if date, ok := value.value.(string); !ok || !validDate(date) {
+			problem = "must be a valid date in the format YYYY, YYYY-MM or YYYY-MM-DD"
+		}
+	case "dateTime":
+		if dateTime, ok := value.value.(string); !ok || !validDateTime(dateTime) {
+			problem = "must be a valid dateTime"
+		}
+	}
+	if problem == "" {
+		return nil
+	}
+	return []fhir.OperationOutcomeIssue{newIssue(fhir.IssueSeverityError, fhir.IssueTypeValue, value.location,
+		"%s: value %v %s%s", element.Path, value.value, problem, fromProfile(url))}
+}
+
+// checkBinding checks coded values against required bindings to known value sets
+func checkBinding(value node, element Element, url string) []fhir.OperationOutcomeIssue {
+	if element.Binding == nil || element.Binding.Strength != "required" {
+		return nil
+	}
+	valueSet, ok := valueSets[canonical(element.Binding.ValueSet)]
+	if !ok {
+		return nil
+	}
+
+	var found []string
+	switch v := value.value.(type) {
+	case string:
+		if valueSet[v] {
+			return nil
+		}
+		found = append(found, v)
+	case map[string]interface{}:
+		// CodeableConcept and Coding values need at least one code from the value set
+		for _, coding := range collect(v, []string{"coding"}) {
+			if code, ok := coding.(map[string]interface{})["code"].(string); ok {
+				if valueSet[code] {
+					return nil
+				}
+				found = append(found, code)
+			}
+		}
+		if code, ok := v["code"].(string); ok {
+			if valueSet[code] {
+				return nil
+			}
+			found = append(found, code)
+		}
+	default:
+		return nil
+	}
+	return []fhir.OperationOutcomeIssue{newIssue(fhir.IssueSeverityError, fhir.IssueTypeCodeInvalid, value.location,
+		"%s: %q is not in the required value set %s%s", element.Path, strings.Join(found, ", "), element.Binding.ValueSet, fromProfile(url))}
+}
+
+// checkConstraints evaluates the invariants of an element on each of its values.
+// Invariants outside the supported FHIRPath subset are skipped.
+func checkConstraints(values []node, element Element, url string) []fhir.OperationOutcomeIssue {
+	var issues []fhir.OperationOutcomeIssue
+	for _, constraint := range element.Constraint {
+		if constraint.Expression == "" {
+			continue
+		}
+		for _, value := range values {
+			ok, err := evaluate(constraint.Expression, value.value)
+			if errors.Is(err, errUnsupportedExpression) {
+				break
+			}
+			if err != nil || ok {
+				continue
+			}
+			severity := fhir.IssueSeverityError
+			if constraint.Severity == "warning" {
+				severity = fhir.IssueSeverityWarning
+			}
+			issues = append(issues, newIssue(severity, fhir.IssueTypeInvariant, value.location,
+				"Constraint failed: %s: %s%s", constraint.Key, constraint.Human, fromProfile(url)))
+		}
+	}
+	return issues
+}
+
+// checkEmpty enforces ele-1: elements must have a value or children, so empty strings,
+// objects and arrays are not allowed
+func checkEmpty(value interface{}, location string) []fhir.OperationOutcomeIssue {
+	var issues []fhir.OperationOutcomeIssue
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			issues = append(issues, newIssue(fhir.IssueSeverityError, fhir.IssueTypeInvalid, location,
+				"%s: value must not be empty", location))
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			issues = append(issues, newIssue(fhir.IssueSeverityError, fhir.IssueTypeInvalid, location,
+				"%s: element must have a value or children", location))
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			issues = append(issues, checkEmpty(v[key], location+"."+key)...)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			issues = append(issues, newIssue(fhir.IssueSeverityError, fhir.IssueTypeInvalid, location,
+				"%s: arrays must not be empty", location))
+		}
+		for i, item := range v {
+			issues = append(issues, checkEmpty(item, fmt.Sprintf("%s[%d]", location, i))...)
+		}
+	}
+	return issues
+}
+
+// declaredProfiles returns the canonical URLs listed in meta.profile
+func declaredProfiles(document map[string]interface{}) []string {
+	meta, _ := document["meta"].(map[string]interface{})
+	var urls []string
+	for _, value := range collect(meta, []string{"profile"}) {
+		if url, ok := value.(string); ok {
+			urls = append(urls, canonical(url))
+		}
+	}
+	return urls
+}
+
+func validDate(value string) bool {
+	if !dateRegex.MatchString(value) {
+		return false
+	}
+	layout := "2006-01-02"[:len(value)]
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
+
+func validDateTime(value string) bool {
+	if !dateTimeRegex.MatchString(value) {
+		return false
+	}
+	if len(value) <= len("2006-01-02") {
+		return validDate(value)
+	}
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
+
+func fromProfile(url string) string {
+	if url == "" {
+		return ""
+	}
+	return " (profile " + url + ")"
+}
+
+func newIssue(severity fhir.IssueSeverity, code fhir.IssueType, location, format string, args ...interface{}) fhir.OperationOutcomeIssue {
+	diagnostics := fmt.Sprintf(format, args...)
+	return fhir.OperationOutcomeIssue{
+		Severity:    severity,
+		Code:        code,
+		Diagnostics: &diagnostics,
+		Expression:  []string{location},
+	}
+}
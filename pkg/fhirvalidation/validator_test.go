@@ -0,0 +1,331 @@
+package fhirvalidation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usCoreValidator returns a validator that knows the profiles shipped in the repository
+func usCoreValidator(t *testing.T) *Validator {
+	profiles, err := LoadProfiles(filepath.Join("..", "..", "profiles"))
+	require.NoError(t, err)
+	return NewValidator(profiles...)
+}
+
+// issue is the part of an OperationOutcome issue the tests compare
+type issue struct {
+	severity    fhir.IssueSeverity
+	code        fhir.IssueType
+	expression  string
+	diagnostics string
+}
+
+func summarize(issues []fhir.OperationOutcomeIssue) []issue {
+	var summary []issue
+	for _, i := range issues {
+		summary = append(summary, issue{i.Severity, i.Code, i.Expression[0], *i.Diagnostics})
+	}
+	return summary
+}
+
+// TestValidate_Base tests the base specification checks applied to every Patient
+func TestValidate_Base(t *testing.T) {
+	cases := map[string]struct {
+		resource string
+		expected []issue
+	}{
+		"valid patient": {
+			`{"resourceType":"Patient","active":true,"name":[{"use":"official","family":"Doe"}],"gender":"male",` +
+				`"birthDate":"1970-02","deceasedDateTime":"2024-01-02T10:00:00.5+01:00","multipleBirthInteger":2}`,
+			nil,
+		},
+		"missing resourceType": {
+			`{"active":true}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeRequired, "resourceType", "resourceType is required"}},
+		},
+		"required binding": {
+			`{"resourceType":"Patient","gender":"M"}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeCodeInvalid, "Patient.gender",
+				`Patient.gender: "M" is not in the required value set http://hl7.org/fhir/ValueSet/administrative-gender`}},
+		},
+		"maximum cardinality": {
+			`{"resourceType":"Patient","name":[{"family":["Doe","Roe"]}]}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeStructure, "Patient.name[0].family",
+				"Patient.name.family: maximum allowed = 1, but found 2"}},
+		},
+		"minimum cardinality": {
+			`{"resourceType":"Patient","link":[{"type":"seealso"}]}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.link[0].other",
+				"Patient.link.other: minimum required = 1, but only found 0"}},
+		},
+		"base invariant": {
+			`{"resourceType":"Patient","telecom":[{"value":"555"}]}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeInvariant, "Patient.telecom[0]",
+				"Constraint failed: cpt-2: A system is required if a value is provided."}},
+		},
+		"primitive type": {
+			`{"resourceType":"Patient","active":"yes"}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeValue, "Patient.active",
+				"Patient.active: value yes must be a boolean"}},
+		},
+		"unchecked resource type": {
+			`{"resourceType":"Basic","code":{"text":"Note"}}`,
+			nil,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			issues, err := NewValidator().Validate([]byte(tc.resource))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, summarize(issues))
+		})
+	}
+}
+
+// TestValidate_Dates tests that date and dateTime values must be well-formed calendar dates
+func TestValidate_Dates(t *testing.T) {
+	cases := map[string]struct {
+		property, value string
+		valid           bool
+	}{
+		"year":                       {"birthDate", "1970", true},
+		"year and month":             {"birthDate", "1970-02", true},
+		"full date":                  {"birthDate", "1970-02-28", true},
+		"leap day":                   {"birthDate", "2000-02-29", true},
+		"day out of range":           {"birthDate", "1970-02-30", false},
+		"month out of range":         {"birthDate", "1970-13", false},
+		"two digit year":             {"birthDate", "70-01-01", false},
+		"date with a time":           {"birthDate", "1970-01-01T00:00:00Z", false},
+		"dateTime as a date":         {"deceasedDateTime", "2024-01-02", true},
+		"dateTime in UTC":            {"deceasedDateTime", "2024-01-02T10:00:00Z", true},
+		"dateTime with an offset":    {"deceasedDateTime", "2024-01-02T10:00:00.123-05:00", true},
+		"dateTime without seconds":   {"deceasedDateTime", "2024-01-02T10:00Z", false},
+		"dateTime without a zone":    {"deceasedDateTime", "2024-01-02T10:00:00", false},
+		"dateTime hour out of range": {"deceasedDateTime", "2024-01-02T25:00:00Z", false},
+		"dateTime day out of range":  {"deceasedDateTime", "2023-02-29", false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resource := `{"resourceType":"Patient","` + tc.property + `":"` + tc.value + `"}`
+
+			issues, err := NewValidator().Validate([]byte(resource))
+
+			require.NoError(t, err)
+			if tc.valid {
+				assert.Empty(t, issues)
+				return
+			}
+			require.Len(t, issues, 1)
+			assert.Equal(t, fhir.IssueTypeValue, issues[0].Code)
+			assert.Equal(t, []string{"Patient." + tc.property}, issues[0].Expression)
+		})
+	}
+}
+
+// TestValidate_ChoiceTypes tests that the type of a choice element is taken from its property name
+func TestValidate_ChoiceTypes(t *testing.T) {
+	cases := map[string]struct {
+		resource string
+		expected []issue
+	}{
+		"allowed types": {
+			`{"resourceType":"Patient","deceasedBoolean":false,"multipleBirthBoolean":true}`,
+			nil,
+		},
+		"type not allowed": {
+			`{"resourceType":"Patient","deceasedString":"yes"}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeStructure, "Patient.deceasedString",
+				"Patient.deceased[x]: type string is not allowed"}},
+		},
+		"value does not match the type": {
+			`{"resourceType":"Patient","multipleBirthInteger":1.5}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeValue, "Patient.multipleBirthInteger",
+				"Patient.multipleBirth[x]: value 1.5 must be an integer"}},
+		},
+		"more than one type": {
+			`{"resourceType":"Patient","deceasedBoolean":true,"deceasedDateTime":"2024-01-02"}`,
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeStructure, "Patient.deceased",
+				"Patient.deceased[x]: maximum allowed = 1, but found 2"}},
+		},
+		"lower case suffix is not a choice": {
+			`{"resourceType":"Patient","deceasedtext":"yes"}`,
+			nil,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			issues, err := NewValidator().Validate([]byte(tc.resource))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, summarize(issues))
+		})
+	}
+}
+
+// TestValidate_USCoreInvariant tests us-core-8: every name needs a family or a given name
+func TestValidate_USCoreInvariant(t *testing.T) {
+	validator := usCoreValidator(t)
+	require.NoError(t, validator.Enforce(usCorePatient))
+	patient := func(name string) []byte {
+		return []byte(`{"resourceType":"Patient","identifier":[{"system":"urn:mrn","value":"1"}],"gender":"female","name":[` + name + `]}`)
+	}
+
+	for _, name := range []string{`{"family":"Doe"}`, `{"given":["Ann"]}`, `{"family":"Doe","given":["Ann"]}`} {
+		issues, err := validator.Validate(patient(name))
+
+		require.NoError(t, err)
+		assert.Empty(t, issues, name)
+	}
+
+	issues, err := validator.Validate(patient(`{"family":"Doe"},{"text":"Ann Doe"}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, []issue{{fhir.IssueSeverityError, fhir.IssueTypeInvariant, "Patient.name[1]",
+		"Constraint failed: us-core-8: Patient.name.given or Patient.name.family or both SHALL be present (profile " + usCorePatient + ")"}},
+		summarize(issues))
+}
+
+// TestValidate_Profiles tests when a profile applies: enforced, requested or declared in meta.profile
+func TestValidate_Profiles(t *testing.T) {
+	minimal := `{"resourceType":"Patient","name":[{"family":"Doe"}]`
+	cases := map[string]struct {
+		resource  string
+		enforce   bool
+		requested []string
+		expected  []issue
+	}{
+		"known but not applied": {
+			resource: minimal + `}`,
+		},
+		"enforced": {
+			resource: minimal + `}`,
+			enforce:  true,
+			expected: []issue{
+				{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.identifier",
+					"Patient.identifier: minimum required = 1, but only found 0 (profile " + usCorePatient + ")"},
+				{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.gender",
+					"Patient.gender: minimum required = 1, but only found 0 (profile " + usCorePatient + ")"},
+			},
+		},
+		"requested with a version": {
+			resource:  minimal + `,"identifier":[{"system":"urn:mrn","value":"1"}]}`,
+			requested: []string{usCorePatient + "|6.1.0"},
+			expected: []issue{{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.gender",
+				"Patient.gender: minimum required = 1, but only found 0 (profile " + usCorePatient + ")"}},
+		},
+		"declared": {
+			resource: minimal + `,"gender":"male","meta":{"profile":["` + usCorePatient + `"]}}`,
+			expected: []issue{{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.identifier",
+				"Patient.identifier: minimum required = 1, but only found 0 (profile " + usCorePatient + ")"}},
+		},
+		"enforced and declared is checked once": {
+			resource: minimal + `,"gender":"male","identifier":[{"value":"1"}],"meta":{"profile":["` + usCorePatient + `"]}}`,
+			enforce:  true,
+			expected: []issue{{fhir.IssueSeverityError, fhir.IssueTypeRequired, "Patient.identifier[0].system",
+				"Patient.identifier.system: minimum required = 1, but only found 0 (profile " + usCorePatient + ")"}},
+		},
+		"unknown requested profile": {
+			resource:  minimal + `}`,
+			requested: []string{"http://example.org/unknown"},
+			expected: []issue{{fhir.IssueSeverityError, fhir.IssueTypeNotSupported, "Patient",
+				"Profile http://example.org/unknown is not known to this server"}},
+		},
+		"unknown declared profile": {
+			resource: minimal + `,"meta":{"profile":["http://example.org/unknown"]}}`,
+			expected: []issue{{fhir.IssueSeverityWarning, fhir.IssueTypeNotSupported, "Patient.meta.profile",
+				"Profile http://example.org/unknown is not known to this server and was not checked"}},
+		},
+		"profile for another type": {
+			resource:  `{"resourceType":"Organization","name":"Clinic"}`,
+			requested: []string{usCorePatient},
+			expected: []issue{{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Organization",
+				"Profile " + usCorePatient + " applies to Patient, not Organization"}},
+		},
+		"enforced profile ignores other types": {
+			resource: `{"resourceType":"Organization","name":"Clinic"}`,
+			enforce:  true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			validator := usCoreValidator(t)
+			if tc.enforce {
+				require.NoError(t, validator.Enforce(usCorePatient))
+			}
+
+			issues, err := validator.Validate([]byte(tc.resource), tc.requested...)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, summarize(issues))
+		})
+	}
+}
+
+// TestValidate_NotJSON tests that only input that is not a JSON object is an error
+func TestValidate_NotJSON(t *testing.T) {
+	for _, resource := range []string{``, `<Patient/>`, `["Patient"]`} {
+		issues, err := NewValidator().Validate([]byte(resource))
+
+		assert.Error(t, err, resource)
+		assert.Nil(t, issues, resource)
+	}
+}
+
+// TestEnforce tests that only loaded profiles can be enforced
+func TestEnforce(t *testing.T) {
+	validator := usCoreValidator(t)
+
+	assert.NoError(t, validator.Enforce(usCorePatient+"|6.1.0"))
+	assert.EqualError(t, validator.Enforce("http://example.org/unknown"), "profile http://example.org/unknown is not loaded")
+	assert.Error(t, NewValidator().Enforce(usCorePatient))
+}
+
+// TestCheckEmpty tests ele-1: empty strings, objects and arrays are reported wherever they are nested
+func TestCheckEmpty(t *testing.T) {
+	cases := map[string]struct {
+		value    interface{}
+		expected []issue
+	}{
+		"values and children": {
+			map[string]interface{}{"active": false, "count": float64(0), "name": []interface{}{map[string]interface{}{"family": "Doe"}}},
+			nil,
+		},
+		"blank string": {
+			map[string]interface{}{"name": []interface{}{map[string]interface{}{"family": " "}}},
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Patient.name[0].family", "Patient.name[0].family: value must not be empty"}},
+		},
+		"empty object": {
+			map[string]interface{}{"meta": map[string]interface{}{}},
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Patient.meta", "Patient.meta: element must have a value or children"}},
+		},
+		"empty array": {
+			map[string]interface{}{"telecom": []interface{}{}},
+			[]issue{{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Patient.telecom", "Patient.telecom: arrays must not be empty"}},
+		},
+		"issues in key order": {
+			map[string]interface{}{"text": "", "address": []interface{}{map[string]interface{}{}}},
+			[]issue{
+				{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Patient.address[0]", "Patient.address[0]: element must have a value or children"},
+				{fhir.IssueSeverityError, fhir.IssueTypeInvalid, "Patient.text", "Patient.text: value must not be empty"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, summarize(checkEmpty(tc.value, "Patient")))
+		})
+	}
+}
+
+// TestHasErrors tests that warnings and information do not count as errors
+func TestHasErrors(t *testing.T) {
+	assert.False(t, HasErrors(nil))
+	assert.False(t, HasErrors([]fhir.OperationOutcomeIssue{{Severity: fhir.IssueSeverityWarning}, {Severity: fhir.IssueSeverityInformation}}))
+	assert.True(t, HasErrors([]fhir.OperationOutcomeIssue{{Severity: fhir.IssueSeverityWarning}, {Severity: fhir.IssueSeverityError}}))
+	assert.True(t, HasErrors([]fhir.OperationOutcomeIssue{{Severity: fhir.IssueSeverityFatal}}))
+}
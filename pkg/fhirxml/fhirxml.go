@@ -0,0 +1,641 @@
+// Package fhirxml converts FHIR resources between their JSON and XML representations.
+//
+// JSON already tells arrays, primitives and nested resources apart, so it is converted to
+// XML using the FHIR models only to write the elements in the order XML requires. XML is
+// parsed using the models as the schema, which tell which elements repeat and which
+// primitives are booleans or numbers.
+package fhirxml
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samply/golang-fhir-models/fhir-models/fhir"
+)
+
+const (
+	// Namespace is the XML namespace of FHIR resources
+	Namespace = "http://hl7.org/fhir"
+	// XHTMLNamespace is the namespace of the narrative div
+	XHTMLNamespace = "http://www.w3.org/1999/xhtml"
+)
+
+// resourceTypes are the resource types XML can be parsed into
+var resourceTypes = map[string]reflect.Type{
+	"Bundle":              reflect.TypeOf(fhir.Bundle{}),
+	"CapabilityStatement": withContained(reflect.TypeOf(fhir.CapabilityStatement{})),
+	"Encounter":           withContained(reflect.TypeOf(fhir.Encounter{})),
+	"Observation":         withContained(reflect.TypeOf(fhir.Observation{})),
+	"OperationDefinition": withContained(reflect.TypeOf(fhir.OperationDefinition{})),
+	"OperationOutcome":    withContained(reflect.TypeOf(fhir.OperationOutcome{})),
+	"Organization":        withContained(reflect.TypeOf(fhir.Organization{})),
+	"Parameters":          reflect.TypeOf(fhir.Parameters{}),
+	"Patient":             withContained(reflect.TypeOf(fhir.Patient{})),
+	"Practitioner":        withContained(reflect.TypeOf(fhir.Practitioner{})),
+}
+
+// baseResourceType and baseElementType stand in for resources and elements without a model.
+// They hold the elements every resource or element starts with, so those still come first in
+// definition order, and the other elements keep their document order after them.
+var (
+	baseResourceType = reflect.TypeOf(struct {
+		ID                *string           `json:"id,omitempty"`
+		Meta              *fhir.Meta        `json:"meta,omitempty"`
+		ImplicitRules     *string           `json:"implicitRules,omitempty"`
+		Language          *string           `json:"language,omitempty"`
+		Text              *fhir.Narrative   `json:"text,omitempty"`
+		Contained         []json.RawMessage `json:"contained,omitempty"`
+		Extension         []fhir.Extension  `json:"extension,omitempty"`
+		ModifierExtension []fhir.Extension  `json:"modifierExtension,omitempty"`
+	}{})
+	baseElementType = reflect.TypeOf(struct {
+		ID                *string          `json:"id,omitempty"`
+		Extension         []fhir.Extension `json:"extension,omitempty"`
+		ModifierExtension []fhir.Extension `json:"modifierExtension,omitempty"`
+	}{})
+)
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	extensionType  = reflect.TypeOf(fhir.Extension{})
+	numberType     = reflect.TypeOf(json.Number(""))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Marshal converts a JSON resource to XML
+func Marshal(resource []byte) ([]byte, error) {
+	return MarshalIndent(resource, "")
+}
+
+// MarshalIndent converts a JSON resource to XML with each element on its own line, nested
+// elements indented by one more copy of indent. An empty indent writes no line breaks.
+func MarshalIndent(resource []byte, indent string) ([]byte, error) {
+	value, err := decodeJSON(resource)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(*object)
+	if !ok || obj.resourceType() == "" {
+		return nil, errors.New("resource must be a JSON object with a resourceType")
+	}
+
+	out := &writer{indent: indent}
+	out.WriteString(xml.Header)
+	writeResource(out, obj, true)
+	return out.Bytes(), nil
+}
+
+// Unmarshal converts an XML resource to JSON
+func Unmarshal(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("XML document has no root element")
+			}
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := parseNode(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			resource, err := resourceJSON(root)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(resource)
+		}
+	}
+}
+
+// object is a JSON object that keeps its keys in document order
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: map[string]interface{}{}}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) resourceType() string {
+	resourceType, _ := o.values["resourceType"].(string)
+	return resourceType
+}
+
+// MarshalJSON writes the object with its keys in order
+func (o *object) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// decodeJSON decodes JSON into objects, slices, strings, booleans, json.Number and nil
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return value, nil
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		obj := newObject()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), value)
+		}
+		_, err := decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		items := []interface{}{}
+		for decoder.More() {
+			item, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := decoder.Token()
+		return items, err
+	default:
+		return token, nil
+	}
+}
+
+// writer is the XML being written, tracking the nesting depth for indentation
+type writer struct {
+	bytes.Buffer
+	indent string
+	depth  int
+}
+
+// newline starts a line at the current depth when the output is indented
+func (w *writer) newline() {
+	if w.indent == "" {
+		return
+	}
+	w.WriteByte('\n')
+	w.WriteString(strings.Repeat(w.indent, w.depth))
+}
+
+// writeResource writes a resource as an element named after its type
+func writeResource(out *writer, obj *object, root bool) {
+	if root {
+		out.WriteString("<" + obj.resourceType() + ` xmlns="` + Namespace + `">`)
+	} else {
+		out.newline()
+		out.WriteString("<" + obj.resourceType() + ">")
+	}
+	t, ok := resourceTypes[obj.resourceType()]
+	if !ok {
+		t = baseResourceType
+	}
+	out.depth++
+	writeChildren(out, obj, t, "resourceType")
+	out.depth--
+	out.newline()
+	out.WriteString("</" + obj.resourceType() + ">")
+}
+
+// writeChildren writes the properties of an object as child elements in the order the
+// struct type t defines them, except for the properties written as attributes
+func writeChildren(out *writer, obj *object, t reflect.Type, attributes ...string) {
+	fields := jsonFields(t)
+	for _, name := range elementNames(obj, t) {
+		if slices.Contains(attributes, name) {
+			continue
+		}
+		value, extension := obj.values[name], obj.values["_"+name]
+		items, repeats := value.([]interface{})
+		extensions, _ := extension.([]interface{})
+		if value == nil && extensions != nil {
+			// A list of primitives that only have extensions has no value property
+			items, repeats = make([]interface{}, len(extensions)), true
+		}
+		if !repeats {
+			writeElement(out, name, value, extension, fields[name])
+			continue
+		}
+		for i, item := range items {
+			var itemExtension interface{}
+			if i < len(extensions) {
+				itemExtension = extensions[i]
+			}
+			writeElement(out, name, item, itemExtension, fields[name])
+		}
+	}
+}
+
+// elementNames returns the element names of an object, merging each primitive with its
+// underscore property, in the field order of t. Names t does not define keep their
+// document order after the ones it does.
+func elementNames(obj *object, t reflect.Type) []string {
+	var names []string
+	for _, key := range obj.keys {
+		name := strings.TrimPrefix(key, "_")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	order := fieldOrder(t)
+	rank := func(name string) int {
+		if index, ok := order[name]; ok {
+			return index
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(names, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+	return names
+}
+
+// writeElement writes one occurrence of an element of the field type t. extension holds
+// the id and extensions of a primitive value.
+func writeElement(out *writer, name string, value, extension interface{}, t reflect.Type) {
+	switch v := value.(type) {
+	case *object:
+		if v.resourceType() != "" {
+			out.newline()
+			out.WriteString("<" + name + ">")
+			out.depth++
+			writeResource(out, v, false)
+			out.depth--
+			out.newline()
+			out.WriteString("</" + name + ">")
+			return
+		}
+		// The id of an element and the url of an extension are attributes
+		attributes := []string{"id"}
+		if name == "extension" || name == "modifierExtension" {
+			attributes = append(attributes, "url")
+		}
+		out.newline()
+		out.WriteString("<" + name)
+		for _, attribute := range attributes {
+			writeAttribute(out, attribute, v.values[attribute])
+		}
+		out.WriteByte('>')
+		out.depth++
+		writeChildren(out, v, elementType(t), attributes...)
+		out.depth--
+		out.newline()
+		out.WriteString("</" + name + ">")
+	case string:
+		if name == "div" {
+			out.newline()
+			out.WriteString(v)
+			return
+		}
+		writePrimitive(out, name, v, extension)
+	case bool:
+		writePrimitive(out, name, strconv.FormatBool(v), extension)
+	case json.Number:
+		writePrimitive(out, name, v.String(), extension)
+	case nil:
+		if extension != nil {
+			writePrimitive(out, name, nil, extension)
+		}
+	}
+}
+
+// writePrimitive writes a primitive element with its value in the value attribute
+func writePrimitive(out *writer, name string, value interface{}, extension interface{}) {
+	out.newline()
+	out.WriteString("<" + name)
+	ext, _ := extension.(*object)
+	if ext != nil {
+		writeAttribute(out, "id", ext.values["id"])
+	}
+	writeAttribute(out, "value", value)
+	if ext == nil || ext.values["extension"] == nil {
+		out.WriteString("/>")
+		return
+	}
+	out.WriteByte('>')
+	out.depth++
+	if items, ok := ext.values["extension"].([]interface{}); ok {
+		for _, item := range items {
+			writeElement(out, "extension", item, nil, extensionType)
+		}
+	}
+	out.depth--
+	out.newline()
+	out.WriteString("</" + name + ">")
+}
+
+// writeAttribute writes a string attribute, skipping values that are not set
+func writeAttribute(out *writer, name string, value interface{}) {
+	text, ok := value.(string)
+	if !ok {
+		return
+	}
+	out.WriteString(" " + name + `="`)
+	_ = xml.EscapeText(out, []byte(text))
+	out.WriteByte('"')
+}
+
+// node is a parsed XML element
+type node struct {
+	name     string
+	space    string
+	attrs    map[string]string
+	children []*node
+	inner    string // Raw content of an XHTML div
+}
+
+// parseNode reads the element opened by start, up to its end tag
+func parseNode(decoder *xml.Decoder, start xml.StartElement) (*node, error) {
+	n := &node{name: start.Name.Local, space: start.Name.Space, attrs: map[string]string{}}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local != "xmlns" {
+			n.attrs[attr.Name.Local] = attr.Value
+		}
+	}
+	if n.name == "div" {
+		var div struct {
+			Inner string `xml:",innerxml"`
+		}
+		if err := decoder.DecodeElement(&div, &start); err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		n.inner = div.Inner
+		return n, nil
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := parseNode(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) != "" {
+				return nil, fmt.Errorf("unexpected text in element %s", n.name)
+			}
+		case xml.EndElement:
+			return n, nil
+		}
+	}
+}
+
+// resourceJSON converts a resource element to JSON
+func resourceJSON(n *node) (*object, error) {
+	if n.space != Namespace {
+		return nil, fmt.Errorf("element %s is not in the FHIR namespace %s", n.name, Namespace)
+	}
+	t, ok := resourceTypes[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported resource type %s", n.name)
+	}
+	obj := newObject()
+	obj.set("resourceType", n.name)
+	if err := fillObject(obj, n, t, true); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// fillObject converts the attributes and children of an element to the properties of obj
+// using the struct type t as the schema
+func fillObject(obj *object, n *node, t reflect.Type, resource bool) error {
+	if !resource {
+		for _, attr := range []string{"id", "url"} {
+			if value, ok := n.attrs[attr]; ok {
+				obj.set(attr, value)
+			}
+		}
+	}
+	fields := jsonFields(t)
+	for _, child := range n.children {
+		fieldType, ok := fields[child.name]
+		if !ok {
+			return fmt.Errorf("unknown element %s in %s", child.name, n.name)
+		}
+		repeats := fieldType.Kind() == reflect.Slice && fieldType != rawMessageType
+		if repeats {
+			fieldType = fieldType.Elem()
+		}
+		value, extension, err := elementJSON(child, fieldType)
+		if err != nil {
+			return err
+		}
+		if !repeats {
+			if value != nil {
+				obj.set(child.name, value)
+			}
+			if extension != nil {
+				obj.set("_"+child.name, extension)
+			}
+			continue
+		}
+		items, _ := obj.values[child.name].([]interface{})
+		extensions, _ := obj.values["_"+child.name].([]interface{})
+		if extension != nil || extensions != nil {
+			for len(extensions) < len(items) {
+				extensions = append(extensions, nil)
+			}
+			obj.set("_"+child.name, append(extensions, extension))
+		}
+		obj.set(child.name, append(items, value))
+	}
+	return nil
+}
+
+// elementJSON converts one element of type t, returning the JSON value and, for a
+// primitive with an id or extensions, the value of its underscore property
+func elementJSON(n *node, t reflect.Type) (interface{}, *object, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == rawMessageType:
+		if len(n.children) != 1 {
+			return nil, nil, fmt.Errorf("element %s must contain one resource", n.name)
+		}
+		resource, err := resourceJSON(n.children[0])
+		return resource, nil, err
+	case n.name == "div" && t.Kind() == reflect.String:
+		return `<div xmlns="` + XHTMLNamespace + `">` + n.inner + "</div>", nil, nil
+	case t.Kind() == reflect.Struct:
+		obj := newObject()
+		if err := fillObject(obj, n, t, false); err != nil {
+			return nil, nil, err
+		}
+		return obj, nil, nil
+	}
+
+	var value interface{}
+	if raw, ok := n.attrs["value"]; ok {
+		converted, err := primitiveJSON(raw, t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value of element %s: %w", n.name, err)
+		}
+		value = converted
+	}
+	var extension *object
+	if id, ok := n.attrs["id"]; ok {
+		extension = newObject()
+		extension.set("id", id)
+	}
+	var extensions []interface{}
+	for _, child := range n.children {
+		if child.name != "extension" {
+			return nil, nil, fmt.Errorf("unknown element %s in %s", child.name, n.name)
+		}
+		item, _, err := elementJSON(child, extensionType)
+		if err != nil {
+			return nil, nil, err
+		}
+		extensions = append(extensions, item)
+	}
+	if extensions != nil {
+		if extension == nil {
+			extension = newObject()
+		}
+		extension.set("extension", extensions)
+	}
+	return value, extension, nil
+}
+
+// primitiveJSON converts the value attribute of a primitive element to the JSON type of t
+func primitiveJSON(raw string, t reflect.Type) (interface{}, error) {
+	switch {
+	case t == numberType:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a decimal", raw)
+		}
+		return json.Number(raw), nil
+	case t.Implements(marshalerType):
+		// Coded values are enums that are written as strings
+		return raw, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return value, nil
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return json.Number(raw), nil
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a decimal", raw)
+		}
+		return json.Number(raw), nil
+	default:
+		return raw, nil
+	}
+}
+
+// jsonFields maps the JSON property names of a struct to their field types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// fieldOrder maps the JSON property names of a struct to their position in it, which
+// follows the order of the FHIR definition
+func fieldOrder(t reflect.Type) map[string]int {
+	order := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			order[name] = i
+		}
+	}
+	return order
+}
+
+// withContained returns the struct type of a DomainResource model with the contained
+// resources the models leave out added back after text, where the definition has them
+func withContained(t reflect.Type) reflect.Type {
+	fields := make([]reflect.StructField, 0, t.NumField()+1)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fields = append(fields, field)
+		if field.Name == "Text" {
+			fields = append(fields, reflect.StructField{
+				Name: "Contained",
+				Type: reflect.TypeOf([]json.RawMessage{}),
+				Tag:  `json:"contained,omitempty"`,
+			})
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// elementType returns the struct a field of type t holds, or baseElementType when there is
+// no model for it
+func elementType(t reflect.Type) reflect.Type {
+	for t != nil && t != rawMessageType && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return baseElementType
+	}
+	return t
+}
@@ -0,0 +1,150 @@
+package fhirxml
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMarshal_DefinitionOrder tests that elements are written in FHIR definition order
+// whatever the order of the JSON properties
+func TestMarshal_DefinitionOrder(t *testing.T) {
+	cases := map[string]struct {
+		json     string
+		expected string
+	}{
+		"resource and datatype fields": {
+			`{"code":{"text":"Weight"},"id":"o1","meta":{"versionId":"1"},"resourceType":"Observation","status":"final",` +
+				`"valueQuantity":{"unit":"kg","value":72.5}}`,
+			`<Observation xmlns="http://hl7.org/fhir"><id value="o1"/><meta><versionId value="1"/></meta><status value="final"/>` +
+				`<code><text value="Weight"/></code><valueQuantity><value value="72.5"/><unit value="kg"/></valueQuantity></Observation>`,
+		},
+		"primitive with only extensions": {
+			`{"_gender":{"extension":[{"url":"http://example.org/reason","valueString":"asked"}]},"active":true,"birthDate":"1970-01-01",` +
+				`"id":"p1","resourceType":"Patient"}`,
+			`<Patient xmlns="http://hl7.org/fhir"><id value="p1"/><active value="true"/>` +
+				`<gender><extension url="http://example.org/reason"><valueString value="asked"/></extension></gender>` +
+				`<birthDate value="1970-01-01"/></Patient>`,
+		},
+		"nested resources": {
+			`{"entry":[{"resource":{"name":[{"given":["Ann"],"family":"Baker"}],"id":"p1","resourceType":"Patient"},"fullUrl":"urn:uuid:1"}],` +
+				`"type":"collection","resourceType":"Bundle"}`,
+			`<Bundle xmlns="http://hl7.org/fhir"><type value="collection"/><entry><fullUrl value="urn:uuid:1"/><resource>` +
+				`<Patient><id value="p1"/><name><family value="Baker"/><given value="Ann"/></name></Patient></resource></entry></Bundle>`,
+		},
+		"contained resources": {
+			`{"managingOrganization":{"reference":"#org1"},"contained":[{"resourceType":"Organization","name":"Clinic","id":"org1"}],` +
+				`"active":true,"resourceType":"Patient"}`,
+			`<Patient xmlns="http://hl7.org/fhir"><contained><Organization><id value="org1"/><name value="Clinic"/></Organization></contained>` +
+				`<active value="true"/><managingOrganization><reference value="#org1"/></managingOrganization></Patient>`,
+		},
+		"resource without a model": {
+			`{"code":{"text":"Note"},"text":{"div":"<div xmlns=\"http://www.w3.org/1999/xhtml\">Note</div>","status":"generated"},` +
+				`"id":"b1","resourceType":"Basic"}`,
+			`<Basic xmlns="http://hl7.org/fhir"><id value="b1"/><text><status value="generated"/>` +
+				`<div xmlns="http://www.w3.org/1999/xhtml">Note</div></text><code><text value="Note"/></code></Basic>`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			data, err := Marshal([]byte(tc.json))
+
+			require.NoError(t, err)
+			assert.Equal(t, xml.Header+tc.expected, string(data))
+		})
+	}
+}
+
+// TestRoundTrip tests that resources converted to XML and back are unchanged
+func TestRoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"narrative div": `{"resourceType":"Patient","id":"p1","text":{"status":"generated",` +
+			`"div":"<div xmlns=\"http://www.w3.org/1999/xhtml\"><p>Ann <b>Baker</b> &amp; family</p></div>"}}`,
+		"primitive extensions": `{"resourceType":"Patient","id":"p1",` +
+			`"name":[{"family":"Baker","given":["Ann",null],"_given":[null,{"extension":[{"url":"http://example.org/absent","valueCode":"unknown"}]}]}],` +
+			`"birthDate":"1970-01-01","_birthDate":{"id":"bd","extension":[{"url":"http://hl7.org/fhir/StructureDefinition/patient-birthTime",` +
+			`"valueDateTime":"1970-01-01T10:00:00Z"}]},"_gender":{"extension":[{"url":"http://example.org/reason","valueString":"asked"}]}}`,
+		"value[x] choices": `{"resourceType":"Observation","id":"o1","status":"final","code":{"text":"Weight"},` +
+			`"effectiveDateTime":"2024-01-02T03:04:05Z","valueQuantity":{"value":72.5,"unit":"kg"},` +
+			`"extension":[{"url":"http://example.org/fasting","valueBoolean":true},{"url":"http://example.org/attempts","valueInteger":2}]}`,
+		"nested resource in a Bundle": `{"resourceType":"Bundle","type":"searchset","total":1,"entry":[{"fullUrl":"http://example.com/Patient/p1",` +
+			`"resource":{"resourceType":"Patient","id":"p1","active":true,"contained":[{"resourceType":"Organization","id":"org1","name":"Clinic"}],` +
+			`"managingOrganization":{"reference":"#org1"}},"search":{"mode":"match","score":1}}]}`,
+		"nested resource in Parameters": `{"resourceType":"Parameters","parameter":[{"name":"resource","resource":{"resourceType":"Patient","id":"p1"}},` +
+			`{"name":"operation","part":[{"name":"type","valueCode":"insert"},{"name":"index","valueInteger":0},{"name":"value","valueString":"Ann"}]}]}`,
+	}
+	for name, resource := range cases {
+		t.Run(name, func(t *testing.T) {
+			data, err := Marshal([]byte(resource))
+			require.NoError(t, err)
+
+			converted, err := Unmarshal(data)
+
+			require.NoError(t, err)
+			assert.JSONEq(t, resource, string(converted))
+		})
+	}
+}
+
+// TestRoundTrip_DecimalPrecision tests that decimals keep their digits, including trailing zeros
+func TestRoundTrip_DecimalPrecision(t *testing.T) {
+	resource := `{"resourceType":"Observation","status":"final","code":{"text":"Glucose"},` +
+		`"valueQuantity":{"value":5.10,"unit":"mmol/L"},"referenceRange":[{"low":{"value":0.000001},"high":{"value":12345678901234567890.5}}]}`
+
+	data, err := Marshal([]byte(resource))
+	require.NoError(t, err)
+	converted, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `<value value="5.10"/>`)
+	assert.Contains(t, string(data), `<value value="12345678901234567890.5"/>`)
+	assert.Contains(t, string(converted), `"value":5.10`)
+	assert.Contains(t, string(converted), `"value":0.000001`)
+	assert.Contains(t, string(converted), `"value":12345678901234567890.5`)
+}
+
+// TestUnmarshal tests that XML is read using the models as the schema
+func TestUnmarshal(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<Patient xmlns="http://hl7.org/fhir">
+  <id value="p1"/>
+  <active value="true"/>
+  <name>
+    <given value="Ann"/>
+  </name>
+  <multipleBirthInteger value="2"/>
+</Patient>`
+
+	converted, err := Unmarshal([]byte(data))
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"resourceType":"Patient","id":"p1","active":true,"name":[{"given":["Ann"]}],"multipleBirthInteger":2}`, string(converted))
+
+	written, err := MarshalIndent(converted, "  ")
+	require.NoError(t, err)
+	assert.Equal(t, data, string(written))
+}
+
+// TestUnmarshal_Errors tests that XML the models do not describe is rejected
+func TestUnmarshal_Errors(t *testing.T) {
+	cases := map[string]string{
+		"no root element":        ``,
+		"malformed":              `<Patient xmlns="http://hl7.org/fhir"><id value="p1"></Patient>`,
+		"not the FHIR namespace": `<Patient><id value="p1"/></Patient>`,
+		"unsupported resource":   `<Basic xmlns="http://hl7.org/fhir"/>`,
+		"unknown element":        `<Patient xmlns="http://hl7.org/fhir"><shoeSize value="9"/></Patient>`,
+		"invalid boolean":        `<Patient xmlns="http://hl7.org/fhir"><active value="yes"/></Patient>`,
+		"invalid integer":        `<Patient xmlns="http://hl7.org/fhir"><multipleBirthInteger value="two"/></Patient>`,
+		"text content":           `<Patient xmlns="http://hl7.org/fhir"><id>p1</id></Patient>`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			converted, err := Unmarshal([]byte(data))
+
+			assert.Error(t, err)
+			assert.Nil(t, converted)
+		})
+	}
+}